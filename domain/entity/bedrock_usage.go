@@ -27,6 +27,26 @@ type BedrockModelMetric struct {
 	LatencyMs       float64
 }
 
+// FilterBedrockModelMetrics keeps the metrics of models that match an include pattern
+// (all models when include is empty) and no exclude pattern
+func FilterBedrockModelMetrics(metrics []BedrockModelMetric, include, exclude []string) []BedrockModelMetric {
+	if len(include) == 0 && len(exclude) == 0 {
+		return metrics
+	}
+
+	var filtered []BedrockModelMetric
+	for _, metric := range metrics {
+		if len(include) > 0 && !matchesAnyModelPattern(metric.ModelID, include) {
+			continue
+		}
+		if matchesAnyModelPattern(metric.ModelID, exclude) {
+			continue
+		}
+		filtered = append(filtered, metric)
+	}
+	return filtered
+}
+
 // NewBedrockUsage creates a new BedrockUsage instance
 func NewBedrockUsage(
 	inputTokens int64,
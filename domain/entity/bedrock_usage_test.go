@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"testing"
+)
+
+func TestFilterBedrockModelMetrics(t *testing.T) {
+	metrics := []BedrockModelMetric{
+		{ModelID: "anthropic.claude-3-5-sonnet-20240620-v1:0", InputTokens: 100},
+		{ModelID: "anthropic.claude-3-haiku-20240307-v1:0", InputTokens: 200},
+		{ModelID: "amazon.titan-embed-text-v2:0", InputTokens: 300},
+	}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{name: "no filters", want: []string{"anthropic.claude-3-5-sonnet-20240620-v1:0", "anthropic.claude-3-haiku-20240307-v1:0", "amazon.titan-embed-text-v2:0"}},
+		{name: "include prefix", include: []string{"anthropic."}, want: []string{"anthropic.claude-3-5-sonnet-20240620-v1:0", "anthropic.claude-3-haiku-20240307-v1:0"}},
+		{name: "exclude glob", exclude: []string{"*embed*"}, want: []string{"anthropic.claude-3-5-sonnet-20240620-v1:0", "anthropic.claude-3-haiku-20240307-v1:0"}},
+		{name: "include and exclude", include: []string{"anthropic."}, exclude: []string{"*haiku*"}, want: []string{"anthropic.claude-3-5-sonnet-20240620-v1:0"}},
+		{name: "no match", include: []string{"meta."}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FilterBedrockModelMetrics(metrics, tt.include, tt.exclude)
+			if len(got) != len(tt.want) {
+				t.Fatalf("FilterBedrockModelMetrics() = %+v, want models %v", got, tt.want)
+			}
+			for i, metric := range got {
+				if metric.ModelID != tt.want[i] {
+					t.Errorf("model[%d] = %s, want %s", i, metric.ModelID, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package entity
 
 import (
 	"fmt"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/ca-srg/tosage/domain/valueobject"
@@ -19,6 +21,7 @@ type CcEntry struct {
 	messageID    string
 	requestID    string
 	userTimezone *time.Location // Optional: user's timezone for date calculations
+	sourcePath   string         // Optional: the Claude data directory the entry was read from
 }
 
 // NewCcEntry creates a new CcEntry entity with validation
@@ -136,6 +139,16 @@ func (u *CcEntry) SetUserTimezone(loc *time.Location) {
 	u.userTimezone = loc
 }
 
+// SourcePath returns the Claude data directory the entry was read from (may be empty)
+func (u *CcEntry) SourcePath() string {
+	return u.sourcePath
+}
+
+// SetSourcePath sets the Claude data directory the entry was read from
+func (u *CcEntry) SetSourcePath(sourcePath string) {
+	u.sourcePath = sourcePath
+}
+
 // TimestampInUserTimezone returns the timestamp in user's timezone
 func (u *CcEntry) TimestampInUserTimezone() time.Time {
 	if u.userTimezone != nil {
@@ -233,6 +246,35 @@ func (u *CcEntry) IsForModel(model string) bool {
 	return u.model == model
 }
 
+// MatchesModelPattern checks if the model matches a glob pattern such as "claude-*-embed".
+// Patterns without glob characters match as a prefix.
+func (u *CcEntry) MatchesModelPattern(pattern string) bool {
+	return MatchesModelPattern(u.model, pattern)
+}
+
+// MatchesModelPattern checks if model matches a glob pattern such as "claude-*-embed".
+// Patterns without glob characters match as a prefix.
+func MatchesModelPattern(model, pattern string) bool {
+	if pattern == "" {
+		return false
+	}
+	if !strings.ContainsAny(pattern, "*?[") {
+		return strings.HasPrefix(model, pattern)
+	}
+	matched, err := path.Match(pattern, model)
+	return err == nil && matched
+}
+
+// matchesAnyModelPattern checks if model matches any of the patterns
+func matchesAnyModelPattern(model string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if MatchesModelPattern(model, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
 // IsForProject checks if the cc entry is for a specific project
 func (u *CcEntry) IsForProject(projectPath string) bool {
 	return u.projectPath == projectPath
@@ -350,6 +392,28 @@ func (c *CcEntryCollection) FilterByModel(model string) *CcEntryCollection {
 	return NewCcEntryCollection(filtered)
 }
 
+// ExcludeModels removes entries whose model matches any of the given patterns
+func (c *CcEntryCollection) ExcludeModels(patterns []string) *CcEntryCollection {
+	if len(patterns) == 0 {
+		return c
+	}
+
+	var filtered []*CcEntry
+	for _, entry := range c.entries {
+		excluded := false
+		for _, pattern := range patterns {
+			if entry.MatchesModelPattern(pattern) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			filtered = append(filtered, entry)
+		}
+	}
+	return NewCcEntryCollection(filtered)
+}
+
 // FilterByProject filters entries by project
 func (c *CcEntryCollection) FilterByProject(projectPath string) *CcEntryCollection {
 	var filtered []*CcEntry
@@ -0,0 +1,322 @@
+package entity
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ccEntryFields maps the field names accepted in filter expressions to the CcEntry accessors
+var ccEntryFields = map[string]func(*CcEntry) string{
+	"id":           (*CcEntry).ID,
+	"session_id":   (*CcEntry).SessionID,
+	"session":      (*CcEntry).SessionID,
+	"project_path": (*CcEntry).ProjectPath,
+	"project":      (*CcEntry).ProjectPath,
+	"model":        (*CcEntry).Model,
+	"version":      (*CcEntry).Version,
+	"message_id":   (*CcEntry).MessageID,
+	"request_id":   (*CcEntry).RequestID,
+}
+
+// CcEntryFilterFields returns the field names accepted in filter expressions, sorted
+func CcEntryFilterFields() []string {
+	names := make([]string, 0, len(ccEntryFields))
+	for name := range ccEntryFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CcEntryFilter is a parsed filter expression selecting Claude Code entries, e.g.
+//
+//	model ~ "claude-3" AND (project = "/work/app" OR project = "/work/api")
+//
+// A condition compares a field with a value: "=" matches the exact value and "~" a regular
+// expression anywhere in the field, so a plain word matches as a substring. AND binds
+// tighter than OR, and parentheses group conditions. Keywords are case-insensitive.
+type CcEntryFilter struct {
+	expression string
+	root       ccFilterNode
+}
+
+// ParseCcEntryFilter parses a filter expression
+func ParseCcEntryFilter(expression string) (*CcEntryFilter, error) {
+	tokens, err := tokenizeCcFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("filter expression is empty")
+	}
+
+	p := &ccFilterParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != ccTokenEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d", tok, tok.pos)
+	}
+	return &CcEntryFilter{expression: expression, root: root}, nil
+}
+
+// String returns the expression the filter was parsed from
+func (f *CcEntryFilter) String() string {
+	return f.expression
+}
+
+// Matches reports whether entry satisfies the filter
+func (f *CcEntryFilter) Matches(entry *CcEntry) bool {
+	return f.root.matches(entry)
+}
+
+// Filter keeps the entries matching filter; a nil filter keeps all entries
+func (c *CcEntryCollection) Filter(filter *CcEntryFilter) *CcEntryCollection {
+	if filter == nil {
+		return c
+	}
+
+	var filtered []*CcEntry
+	for _, entry := range c.entries {
+		if filter.Matches(entry) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return NewCcEntryCollection(filtered)
+}
+
+// ccFilterNode is a node of a parsed filter expression
+type ccFilterNode interface {
+	matches(entry *CcEntry) bool
+}
+
+// ccFilterAnd matches when both sides match
+type ccFilterAnd struct {
+	left, right ccFilterNode
+}
+
+func (n ccFilterAnd) matches(entry *CcEntry) bool {
+	return n.left.matches(entry) && n.right.matches(entry)
+}
+
+// ccFilterOr matches when either side matches
+type ccFilterOr struct {
+	left, right ccFilterNode
+}
+
+func (n ccFilterOr) matches(entry *CcEntry) bool {
+	return n.left.matches(entry) || n.right.matches(entry)
+}
+
+// ccFilterEquals matches when the field equals the value
+type ccFilterEquals struct {
+	field func(*CcEntry) string
+	value string
+}
+
+func (n ccFilterEquals) matches(entry *CcEntry) bool {
+	return n.field(entry) == n.value
+}
+
+// ccFilterRegexp matches when the pattern matches anywhere in the field
+type ccFilterRegexp struct {
+	field   func(*CcEntry) string
+	pattern *regexp.Regexp
+}
+
+func (n ccFilterRegexp) matches(entry *CcEntry) bool {
+	return n.pattern.MatchString(n.field(entry))
+}
+
+// ccTokenKind is the kind of a filter expression token
+type ccTokenKind int
+
+const (
+	ccTokenEOF ccTokenKind = iota
+	ccTokenWord
+	ccTokenString
+	ccTokenEquals
+	ccTokenMatch
+	ccTokenLParen
+	ccTokenRParen
+)
+
+// ccToken is a token of a filter expression with its byte offset
+type ccToken struct {
+	kind ccTokenKind
+	text string
+	pos  int
+}
+
+func (t ccToken) String() string {
+	switch t.kind {
+	case ccTokenEOF:
+		return "end of expression"
+	case ccTokenString:
+		return fmt.Sprintf("%q", t.text)
+	default:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+}
+
+// isKeyword reports whether the token is the given keyword, ignoring case
+func (t ccToken) isKeyword(keyword string) bool {
+	return t.kind == ccTokenWord && strings.EqualFold(t.text, keyword)
+}
+
+// tokenizeCcFilter splits a filter expression into tokens. Strings are double-quoted,
+// with backslash escaping the next character.
+func tokenizeCcFilter(expression string) ([]ccToken, error) {
+	var tokens []ccToken
+	runes := []rune(expression)
+	offset := func(i int) int { return len(string(runes[:i])) }
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '=':
+			tokens = append(tokens, ccToken{kind: ccTokenEquals, text: "=", pos: offset(i)})
+			i++
+		case r == '~':
+			tokens = append(tokens, ccToken{kind: ccTokenMatch, text: "~", pos: offset(i)})
+			i++
+		case r == '(':
+			tokens = append(tokens, ccToken{kind: ccTokenLParen, text: "(", pos: offset(i)})
+			i++
+		case r == ')':
+			tokens = append(tokens, ccToken{kind: ccTokenRParen, text: ")", pos: offset(i)})
+			i++
+		case r == '"':
+			start := i
+			var value strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				value.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", offset(start))
+			}
+			i++
+			tokens = append(tokens, ccToken{kind: ccTokenString, text: value.String(), pos: offset(start)})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(`=~()"`, runes[i]) {
+				i++
+			}
+			tokens = append(tokens, ccToken{kind: ccTokenWord, text: string(runes[start:i]), pos: offset(start)})
+		}
+	}
+	return tokens, nil
+}
+
+// ccFilterParser is a recursive descent parser over the tokens of a filter expression
+type ccFilterParser struct {
+	tokens []ccToken
+	next   int
+}
+
+func (p *ccFilterParser) peek() ccToken {
+	if p.next >= len(p.tokens) {
+		end := 0
+		if len(p.tokens) > 0 {
+			last := p.tokens[len(p.tokens)-1]
+			end = last.pos + len(last.text)
+		}
+		return ccToken{kind: ccTokenEOF, pos: end}
+	}
+	return p.tokens[p.next]
+}
+
+func (p *ccFilterParser) advance() ccToken {
+	tok := p.peek()
+	if tok.kind != ccTokenEOF {
+		p.next++
+	}
+	return tok
+}
+
+// parseOr parses conditions joined by OR
+func (p *ccFilterParser) parseOr() (ccFilterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("OR") {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = ccFilterOr{left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseAnd parses conditions joined by AND
+func (p *ccFilterParser) parseAnd() (ccFilterNode, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("AND") {
+		p.advance()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = ccFilterAnd{left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseTerm parses a parenthesized expression or a single condition
+func (p *ccFilterParser) parseTerm() (ccFilterNode, error) {
+	tok := p.advance()
+	if tok.kind == ccTokenLParen {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.advance(); closing.kind != ccTokenRParen {
+			return nil, fmt.Errorf("expected ')' at position %d, got %s", closing.pos, closing)
+		}
+		return node, nil
+	}
+
+	if tok.kind != ccTokenWord || tok.isKeyword("AND") || tok.isKeyword("OR") {
+		return nil, fmt.Errorf("expected a field name at position %d, got %s", tok.pos, tok)
+	}
+	field, ok := ccEntryFields[strings.ToLower(tok.text)]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q at position %d (available: %s)",
+			tok.text, tok.pos, strings.Join(CcEntryFilterFields(), ", "))
+	}
+
+	op := p.advance()
+	if op.kind != ccTokenEquals && op.kind != ccTokenMatch {
+		return nil, fmt.Errorf("expected '=' or '~' after %s at position %d, got %s", tok.text, op.pos, op)
+	}
+
+	value := p.advance()
+	if value.kind != ccTokenString && value.kind != ccTokenWord {
+		return nil, fmt.Errorf("expected a value at position %d, got %s", value.pos, value)
+	}
+
+	if op.kind == ccTokenEquals {
+		return ccFilterEquals{field: field, value: value.text}, nil
+	}
+	pattern, err := regexp.Compile(value.text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q at position %d: %w", value.text, value.pos, err)
+	}
+	return ccFilterRegexp{field: field, pattern: pattern}, nil
+}
@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ca-srg/tosage/domain/valueobject"
+)
+
+func TestParseCcEntryFilter(t *testing.T) {
+	newEntry := func(id, project, model string) *CcEntry {
+		entry, err := NewCcEntry(id, time.Now(), "session-"+id, project, model, valueobject.NewTokenStats(1, 1, 0, 0), "1.0.0", "msg-"+id, "req-"+id)
+		if err != nil {
+			t.Fatalf("NewCcEntry() error = %v", err)
+		}
+		return entry
+	}
+	entries := []*CcEntry{
+		newEntry("1", "/work/app", "claude-3-opus-20240229"),
+		newEntry("2", "/work/app", "claude-sonnet-4-20250514"),
+		newEntry("3", "/work/api", "claude-3-haiku-20240307"),
+		newEntry("4", "/home/me/scratch", "claude-3-5-sonnet-20241022"),
+	}
+
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{`model ~ "claude-3" AND project = "/work/app"`, "1"},
+		{`project = "/work/app" OR project = "/work/api"`, "1,2,3"},
+		// AND binds tighter than OR
+		{`project = "/work/api" OR project = "/work/app" and model ~ sonnet`, "2,3"},
+		{`(project = "/work/api" OR project = "/work/app") AND model ~ "haiku|opus"`, "1,3"},
+		{`model ~ "^claude-3-5-"`, "4"},
+		{`session_id = session-2 or request_id = "req-4"`, "2,4"},
+		{`project ~ "\"none\""`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			filter, err := ParseCcEntryFilter(tt.expression)
+			if err != nil {
+				t.Fatalf("ParseCcEntryFilter() error = %v", err)
+			}
+			var ids []string
+			for _, entry := range NewCcEntryCollection(entries).Filter(filter).Entries() {
+				ids = append(ids, entry.ID())
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("matching entries = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCcEntryFilter_Errors(t *testing.T) {
+	tests := []struct {
+		expression string
+		wantErr    string
+	}{
+		{``, "empty"},
+		{`cost = 1`, "unknown field"},
+		{`model`, "expected '=' or '~'"},
+		{`model =`, "expected a value"},
+		{`model = x AND`, "expected a field name"},
+		{`model = x project = y`, "unexpected"},
+		{`(model = x`, "expected ')'"},
+		{`model = "x`, "unterminated string"},
+		{`model ~ "("`, "invalid pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := ParseCcEntryFilter(tt.expression)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseCcEntryFilter() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
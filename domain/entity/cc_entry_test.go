@@ -217,6 +217,45 @@ func TestCcEntryCollection_Filters(t *testing.T) {
 			t.Errorf("FilterBySession() count = %v, want 2", filtered.Count())
 		}
 	})
+
+	t.Run("ExcludeModels", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			patterns []string
+			want     int
+		}{
+			{name: "no patterns", patterns: nil, want: 4},
+			{name: "exact", patterns: []string{"model-1"}, want: 2},
+			{name: "prefix", patterns: []string{"model-"}, want: 0},
+			{name: "glob", patterns: []string{"model-?"}, want: 0},
+			{name: "no match", patterns: []string{"other-*"}, want: 4},
+		}
+		for _, tt := range tests {
+			if got := collection.ExcludeModels(tt.patterns).Count(); got != tt.want {
+				t.Errorf("%s: ExcludeModels() count = %v, want %v", tt.name, got, tt.want)
+			}
+		}
+	})
+}
+
+func TestCcEntry_MatchesModelPattern(t *testing.T) {
+	entry, _ := NewCcEntry("1", time.Now(), "s1", "/p1", "claude-3-embed", valueobject.NewTokenStats(1, 1, 0, 0), "1.0", "", "")
+
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"claude-*-embed", true},
+		{"claude-3", true},
+		{"claude-*-opus", false},
+		{"gpt", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := entry.MatchesModelPattern(tt.pattern); got != tt.want {
+			t.Errorf("MatchesModelPattern(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
 }
 
 func TestCcEntryCollection_GroupBy(t *testing.T) {
@@ -2,6 +2,7 @@ package entity
 
 import (
 	"fmt"
+	"math"
 	"time"
 )
 
@@ -35,6 +36,48 @@ type MonthlyUsage struct {
 	HasUnpaidInvoice bool
 }
 
+// Period returns the month as YYYY-MM, e.g. "2025-01"
+func (m MonthlyUsage) Period() string {
+	return fmt.Sprintf("%04d-%02d", m.Year, m.Month)
+}
+
+// TotalCostCents returns the cost of all usage items in cents, including usage already
+// covered by a mid-month payment
+func (m MonthlyUsage) TotalCostCents() int64 {
+	total := 0.0
+	for _, item := range m.Items {
+		total += item.TotalCost
+	}
+	return int64(math.Round(total * 100))
+}
+
+// MidMonthPaymentCents returns the usage paid mid-month in cents
+func (m MonthlyUsage) MidMonthPaymentCents() int64 {
+	return int64(math.Round(m.MidMonthPayment * 100))
+}
+
+// ToolCallCount returns the number of tool calls billed in the month
+func (m MonthlyUsage) ToolCallCount() int {
+	count := 0
+	for _, item := range m.Items {
+		if item.IsToolCall {
+			count += item.RequestCount
+		}
+	}
+	return count
+}
+
+// TokenBasedCallCount returns the number of token-based calls billed in the month
+func (m MonthlyUsage) TokenBasedCallCount() int {
+	count := 0
+	for _, item := range m.Items {
+		if item.IsTokenBased {
+			count += item.RequestCount
+		}
+	}
+	return count
+}
+
 // UsageItem represents a single usage item
 type UsageItem struct {
 	RequestCount   int
@@ -44,6 +87,7 @@ type UsageItem struct {
 	Description    string
 	IsDiscounted   bool
 	IsToolCall     bool
+	IsTokenBased   bool
 }
 
 // TeamInfo contains team membership information
@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"time"
+)
+
+// CursorUsagePosition records how far the Cursor usage events of a daily window have been
+// read, so that later fetches only need to request newer events
+type CursorUsagePosition struct {
+	// WindowStart is the start of the daily window the position belongs to
+	WindowStart time.Time `json:"window_start"`
+
+	// LastEventAt is the timestamp of the newest event read
+	LastEventAt time.Time `json:"last_event_at"`
+
+	// Tokens is the running token total of the window
+	Tokens int64 `json:"tokens"`
+
+	// RecentEvents identifies the counted events that are fetched again to catch late arrivals
+	RecentEvents []string `json:"recent_events,omitempty"`
+}
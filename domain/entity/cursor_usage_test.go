@@ -157,6 +157,30 @@ func TestCursorUsage_CurrentMonthTotalCost(t *testing.T) {
 	}
 }
 
+func TestMonthlyUsage_Cents(t *testing.T) {
+	month := MonthlyUsage{
+		Month: 3,
+		Year:  2025,
+		Items: []UsageItem{
+			{TotalCost: 0.1},
+			{TotalCost: 0.2},
+			{TotalCost: 12.34},
+		},
+		MidMonthPayment: 7.005,
+	}
+
+	if got := month.Period(); got != "2025-03" {
+		t.Errorf("Period() = %q, want 2025-03", got)
+	}
+	// Float sums are rounded to whole cents
+	if got := month.TotalCostCents(); got != 1264 {
+		t.Errorf("TotalCostCents() = %d, want 1264", got)
+	}
+	if got := month.MidMonthPaymentCents(); got != 701 {
+		t.Errorf("MidMonthPaymentCents() = %d, want 701", got)
+	}
+}
+
 func TestCursorUsage_Validate(t *testing.T) {
 	tests := []struct {
 		name      string
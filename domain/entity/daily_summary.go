@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"sort"
+	"time"
+)
+
+// DailySummary is a digest of one day's token usage across sources
+type DailySummary struct {
+	Date        string          `json:"date"`
+	Host        string          `json:"host,omitempty"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	TotalTokens int64           `json:"total_tokens"`
+	Sources     []SourceSummary `json:"sources"`
+	TopModels   []ModelSummary  `json:"top_models"`
+}
+
+// SourceSummary is the token total of a single source such as claude_code or cursor
+type SourceSummary struct {
+	Source      string `json:"source"`
+	TotalTokens int64  `json:"total_tokens"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ModelSummary is the token total of a single model
+type ModelSummary struct {
+	Source      string `json:"source"`
+	Model       string `json:"model"`
+	TotalTokens int64  `json:"total_tokens"`
+}
+
+// NewDailySummary creates an empty summary for the given date
+func NewDailySummary(date time.Time, host string, generatedAt time.Time) *DailySummary {
+	return &DailySummary{
+		Date:        date.Format("2006-01-02"),
+		Host:        host,
+		GeneratedAt: generatedAt,
+		Sources:     []SourceSummary{},
+		TopModels:   []ModelSummary{},
+	}
+}
+
+// AddSource records the total of a source and adds it to the overall total
+func (s *DailySummary) AddSource(source string, totalTokens int64) {
+	s.Sources = append(s.Sources, SourceSummary{Source: source, TotalTokens: totalTokens})
+	s.TotalTokens += totalTokens
+}
+
+// AddSourceError records a source whose usage could not be retrieved
+func (s *DailySummary) AddSourceError(source string, err error) {
+	s.Sources = append(s.Sources, SourceSummary{Source: source, Error: err.Error()})
+}
+
+// AddModel records the total of a model; models without usage are ignored
+func (s *DailySummary) AddModel(source, model string, totalTokens int64) {
+	if model == "" || totalTokens <= 0 {
+		return
+	}
+	s.TopModels = append(s.TopModels, ModelSummary{Source: source, Model: model, TotalTokens: totalTokens})
+}
+
+// LimitTopModels sorts models by usage, largest first, and keeps the first n
+func (s *DailySummary) LimitTopModels(n int) {
+	sort.SliceStable(s.TopModels, func(i, j int) bool {
+		if s.TopModels[i].TotalTokens != s.TopModels[j].TotalTokens {
+			return s.TopModels[i].TotalTokens > s.TopModels[j].TotalTokens
+		}
+		return s.TopModels[i].Model < s.TopModels[j].Model
+	})
+	if n >= 0 && len(s.TopModels) > n {
+		s.TopModels = s.TopModels[:n]
+	}
+}
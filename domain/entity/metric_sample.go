@@ -0,0 +1,12 @@
+package entity
+
+import (
+	"time"
+)
+
+// MetricSample is the value of a series at a point in time
+type MetricSample struct {
+	Value     float64
+	Timestamp time.Time
+	Labels    map[string]string
+}
@@ -0,0 +1,95 @@
+package entity
+
+import (
+	"time"
+)
+
+// MetricsState holds metric values that are persisted across restarts
+type MetricsState struct {
+	Metrics   map[string]MetricState `json:"metrics"`
+	Counters  map[string]float64     `json:"counters"`
+	UpdatedAt time.Time              `json:"updated_at"`
+
+	// CursorPosition is where incremental Cursor usage fetching continues from
+	CursorPosition *CursorUsagePosition `json:"cursor_position,omitempty"`
+
+	// DeltaBaselines are the values delta metrics are computed from, keyed by metric name
+	DeltaBaselines map[string]MetricState `json:"delta_baselines,omitempty"`
+}
+
+// MetricState holds the last sent value of a single metric
+type MetricState struct {
+	LastValue  float64   `json:"last_value"`
+	LastSentAt time.Time `json:"last_sent_at"`
+}
+
+// NewMetricsState creates an empty MetricsState
+func NewMetricsState() *MetricsState {
+	return &MetricsState{
+		Metrics:  make(map[string]MetricState),
+		Counters: make(map[string]float64),
+	}
+}
+
+// RecordSent records the value of a metric that was sent successfully
+func (s *MetricsState) RecordSent(metricName string, value float64, at time.Time) {
+	s.ensureMaps()
+	s.Metrics[metricName] = MetricState{
+		LastValue:  value,
+		LastSentAt: at,
+	}
+	s.UpdatedAt = at
+}
+
+// Last returns the last sent state of a metric
+func (s *MetricsState) Last(metricName string) (MetricState, bool) {
+	state, exists := s.Metrics[metricName]
+	return state, exists
+}
+
+// AddToCounter adds delta to a cumulative counter and returns the new value
+func (s *MetricsState) AddToCounter(name string, delta float64) float64 {
+	s.ensureMaps()
+	s.Counters[name] += delta
+	return s.Counters[name]
+}
+
+// Counter returns the current value of a cumulative counter
+func (s *MetricsState) Counter(name string) float64 {
+	return s.Counters[name]
+}
+
+// Delta returns how much value grew since the baseline of name. A value below the baseline,
+// e.g. a daily total after midnight, yields zero. ok is false when there is no baseline yet.
+func (s *MetricsState) Delta(name string, value float64) (delta float64, ok bool) {
+	baseline, exists := s.DeltaBaselines[name]
+	if !exists {
+		return 0, false
+	}
+	if value < baseline.LastValue {
+		return 0, true
+	}
+	return value - baseline.LastValue, true
+}
+
+// SetDeltaBaseline records value as the baseline the next delta of name is computed from
+func (s *MetricsState) SetDeltaBaseline(name string, value float64, at time.Time) {
+	if s.DeltaBaselines == nil {
+		s.DeltaBaselines = make(map[string]MetricState)
+	}
+	s.DeltaBaselines[name] = MetricState{
+		LastValue:  value,
+		LastSentAt: at,
+	}
+	s.UpdatedAt = at
+}
+
+// ensureMaps initializes maps that may be nil after decoding
+func (s *MetricsState) ensureMaps() {
+	if s.Metrics == nil {
+		s.Metrics = make(map[string]MetricState)
+	}
+	if s.Counters == nil {
+		s.Counters = make(map[string]float64)
+	}
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"time"
 
 	"github.com/ca-srg/tosage/domain/entity"
@@ -19,8 +20,8 @@ type BedrockRepository interface {
 	// GetCurrentMonthUsage retrieves usage for the current month
 	GetCurrentMonthUsage(region string) (*entity.BedrockUsage, error)
 
-	// CheckConnection verifies AWS credentials and CloudWatch access
-	CheckConnection() error
+	// CheckConnection verifies AWS credentials and CloudWatch access within the context deadline
+	CheckConnection(ctx context.Context) error
 
 	// ListAvailableRegions returns regions with Bedrock activity
 	ListAvailableRegions() ([]string, error)
@@ -42,6 +43,16 @@ type BedrockConfig struct {
 
 	// CollectionInterval is how often to collect metrics
 	CollectionInterval time.Duration
+
+	// IngestionDelay ends usage queries this long before now, so samples CloudWatch has
+	// not published yet are picked up by a later query instead of being missed
+	IngestionDelay time.Duration
+
+	// IncludeModels limits per-model metrics to matching model IDs (all models when empty)
+	IncludeModels []string
+
+	// ExcludeModels drops matching model IDs from per-model metrics
+	ExcludeModels []string
 }
 
 // DefaultBedrockConfig returns the default configuration
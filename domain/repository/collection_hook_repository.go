@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// CollectionHookRepository runs a user-configured action after each successful collection
+type CollectionHookRepository interface {
+	// Run passes the values sent in the collection at collectedAt, keyed by metric series,
+	// to the hook and returns its combined output
+	Run(ctx context.Context, collectedAt time.Time, metrics map[string]float64) (string, error)
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/ca-srg/tosage/domain/entity"
 	"github.com/ca-srg/tosage/domain/valueobject"
 )
@@ -18,6 +20,29 @@ type CursorAPIRepository interface {
 
 	// GetAggregatedTokenUsage retrieves aggregated token usage from JST 00:00 to current time
 	GetAggregatedTokenUsage(token *valueobject.CursorToken) (int64, error)
+
+	// GetIncrementalTokenUsage retrieves today's token usage, requesting only events newer than position.
+	// A nil position, or one from an earlier daily window, starts from the beginning of the window.
+	GetIncrementalTokenUsage(token *valueobject.CursorToken, position *entity.CursorUsagePosition) (*entity.CursorUsagePosition, error)
+
+	// GetBillingPeriodTokenUsage retrieves aggregated token usage from the start of the current billing period to current time
+	GetBillingPeriodTokenUsage(token *valueobject.CursorToken) (int64, error)
+
+	// GetBillingCycleTokenUsage retrieves aggregated token usage from the start of the billing cycle
+	// reported by Cursor to current time
+	GetBillingCycleTokenUsage(token *valueobject.CursorToken) (int64, error)
+
+	// GetTeamMemberTokenUsage retrieves today's token usage of the members of the user's team
+	// chosen by selectMembers, or of every member if it is nil. A member whose usage can't be
+	// read is returned with Err set. It requires the session token of a team admin.
+	GetTeamMemberTokenUsage(token *valueobject.CursorToken, selectMembers TeamMemberSelector) ([]TeamMemberTokenUsage, error)
+
+	// CheckConnection verifies the token is accepted by the Cursor API with a lightweight call
+	CheckConnection(ctx context.Context, token *valueobject.CursorToken) error
+
+	// ResponseFormatOK reports whether every response since the previous call decoded with all
+	// of its fields understood, and starts the next check
+	ResponseFormatOK() bool
 }
 
 // UsageLimitInfo contains information about usage limits
@@ -32,3 +57,17 @@ type UsageBasedStatus struct {
 	IsEnabled bool
 	Limit     *float64
 }
+
+// TeamMemberTokenUsage is the token usage of a single Cursor team member
+type TeamMemberTokenUsage struct {
+	UserID int
+	Name   string
+	Email  string
+	Tokens int64
+	Err    error // set if the member's usage could not be read
+}
+
+// TeamMemberSelector picks the members whose usage is read from the team roster, which lists
+// the members without their tokens. Reading a member's usage takes its own requests, so
+// selecting first keeps large teams from costing a request per member every cycle.
+type TeamMemberSelector func(roster []TeamMemberTokenUsage) []TeamMemberTokenUsage
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"github.com/ca-srg/tosage/domain/entity"
+)
+
+// MetricsQueryRepository defines the interface for reading previously pushed series back
+type MetricsQueryRepository interface {
+	// QueryLatest returns the most recent sample of metric among the series matching labels.
+	// A label with an empty value matches series without that label.
+	// It returns nil without an error when no sample exists.
+	QueryLatest(metric string, labels map[string]string) (*entity.MetricSample, error)
+}
@@ -1,17 +1,55 @@
 package repository
 
+import (
+	"context"
+	"errors"
+)
+
 // MetricsRepository defines the interface for sending metrics to external systems
 type MetricsRepository interface {
 	// SendTokenMetric sends the total token count metric with specified metric name
-	SendTokenMetric(totalTokens int, hostLabel string, metricName string) error
+	SendTokenMetric(totalTokens int64, hostLabel string, metricName string) error
 
 	// SendTokenMetricWithTimezone sends the total token count metric with timezone information
-	SendTokenMetricWithTimezone(totalTokens int, hostLabel string, metricName string, timezoneInfo TimezoneInfo) error
+	SendTokenMetricWithTimezone(totalTokens int64, hostLabel string, metricName string, timezoneInfo TimezoneInfo) error
+
+	// SendTokenMetricWithLabels sends the total token count metric with additional series labels
+	// such as the model; timezoneInfo is optional
+	SendTokenMetricWithLabels(totalTokens int64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *TimezoneInfo) error
 
 	// Close cleans up any resources used by the metrics repository
 	Close() error
 }
 
+// MetricValueSender is implemented by metrics repositories that can send fractional values.
+// Wrappers that transform token counts use it so that scaled values are not rounded.
+type MetricValueSender interface {
+	// SendMetricValue sends a metric value; labels and timezoneInfo are optional
+	SendMetricValue(value float64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *TimezoneInfo) error
+}
+
+// ConnectionChecker is implemented by metrics repositories that can verify their backend is reachable
+type ConnectionChecker interface {
+	// CheckConnection verifies the backend accepts requests within the context deadline
+	CheckConnection(ctx context.Context) error
+}
+
+// CircuitStateReporter is implemented by metrics repositories that stop pushing to a failing backend
+type CircuitStateReporter interface {
+	// CircuitOpen reports whether pushes are currently being skipped
+	CircuitOpen() bool
+}
+
+// MetricsExpirer is implemented by metrics repositories that keep serving the last sent values,
+// such as a scrape endpoint
+type MetricsExpirer interface {
+	// ExpireMetrics stops serving the last sent values of the given metrics
+	ExpireMetrics(metricNames ...string)
+}
+
+// ErrCircuitOpen is returned instead of pushing while a circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit open: skipping push to failing backend")
+
 // MetricsRepositoryError represents errors from the metrics repository
 type MetricsRepositoryError struct {
 	Operation string
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"github.com/ca-srg/tosage/domain/entity"
+)
+
+// MetricsStateRepository defines the interface for persisting metrics state across restarts
+type MetricsStateRepository interface {
+	// Load reads the persisted state.
+	// A missing file yields an empty state. A corrupt file yields an empty
+	// state together with an error describing the problem.
+	Load() (*entity.MetricsState, error)
+
+	// Save persists the state
+	Save(state *entity.MetricsState) error
+
+	// GetStatePath returns the path of the state file
+	GetStatePath() string
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ca-srg/tosage/domain/entity"
+)
+
+// SummaryRepository defines the interface for delivering daily usage summaries
+type SummaryRepository interface {
+	// Send delivers the summary, retrying transient failures
+	Send(ctx context.Context, summary *entity.DailySummary) error
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"time"
 
 	"github.com/ca-srg/tosage/domain/entity"
@@ -19,8 +20,8 @@ type VertexAIRepository interface {
 	// GetCurrentMonthUsage retrieves usage for the current month
 	GetCurrentMonthUsage(projectID string) (*entity.VertexAIUsage, error)
 
-	// CheckConnection verifies Google Cloud credentials and Cloud Monitoring access
-	CheckConnection() error
+	// CheckConnection verifies Google Cloud credentials and Cloud Monitoring access within the context deadline
+	CheckConnection(ctx context.Context) error
 }
 
 // VertexAIConfig contains configuration for Vertex AI data collection
@@ -39,6 +40,10 @@ type VertexAIConfig struct {
 
 	// CollectionInterval is how often to collect metrics
 	CollectionInterval time.Duration
+
+	// IngestionDelay ends usage queries this long before now, so samples Cloud Monitoring has
+	// not made visible yet are picked up by a later query instead of being missed
+	IngestionDelay time.Duration
 }
 
 // DefaultVertexAIConfig returns the default configuration
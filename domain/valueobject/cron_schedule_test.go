@@ -0,0 +1,95 @@
+package valueobject
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronSchedule_Next(t *testing.T) {
+	loc := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{
+			name:  "top of the hour during work hours",
+			expr:  "0 9-18 * * MON-FRI",
+			after: time.Date(2025, 3, 14, 10, 30, 0, 0, loc), // Friday
+			want:  time.Date(2025, 3, 14, 11, 0, 0, 0, loc),
+		},
+		{
+			name:  "work hours roll over the weekend",
+			expr:  "0 9-18 * * MON-FRI",
+			after: time.Date(2025, 3, 14, 18, 0, 0, 0, loc),
+			want:  time.Date(2025, 3, 17, 9, 0, 0, 0, loc),
+		},
+		{
+			name:  "every 15 minutes",
+			expr:  "*/15 * * * *",
+			after: time.Date(2025, 3, 14, 10, 44, 59, 0, loc),
+			want:  time.Date(2025, 3, 14, 10, 45, 0, 0, loc),
+		},
+		{
+			name:  "a matching time is not returned again",
+			expr:  "*/15 * * * *",
+			after: time.Date(2025, 3, 14, 10, 45, 0, 0, loc),
+			want:  time.Date(2025, 3, 14, 11, 0, 0, 0, loc),
+		},
+		{
+			name:  "descriptor",
+			expr:  "@daily",
+			after: time.Date(2025, 12, 31, 23, 59, 0, 0, loc),
+			want:  time.Date(2026, 1, 1, 0, 0, 0, 0, loc),
+		},
+		{
+			name:  "day of month or day of week",
+			expr:  "0 0 1 * SUN",
+			after: time.Date(2025, 3, 10, 0, 0, 0, 0, loc), // Monday
+			want:  time.Date(2025, 3, 16, 0, 0, 0, 0, loc),
+		},
+		{
+			name:  "7 is Sunday",
+			expr:  "30 8 * * 7",
+			after: time.Date(2025, 3, 10, 0, 0, 0, 0, loc),
+			want:  time.Date(2025, 3, 16, 8, 30, 0, 0, loc),
+		},
+		{
+			name:  "leap day",
+			expr:  "0 0 29 2 *",
+			after: time.Date(2025, 3, 1, 0, 0, 0, 0, loc),
+			want:  time.Date(2028, 2, 29, 0, 0, 0, 0, loc),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCronSchedule(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCronSchedule(%q) error = %v", tt.expr, err)
+			}
+			if got := schedule.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronSchedule_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * FOO *",
+		"0 0 30 2 *",
+	} {
+		if _, err := ParseCronSchedule(expr); err == nil {
+			t.Errorf("ParseCronSchedule(%q) error = nil, want an error", expr)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package valueobject
+
+import "time"
+
+// DailyWindowMode defines the period "today" covers in daily token totals
+type DailyWindowMode string
+
+const (
+	// DailyWindowCalendar covers the current calendar day
+	DailyWindowCalendar DailyWindowMode = "calendar"
+	// DailyWindowRolling24h covers the trailing 24 hours
+	DailyWindowRolling24h DailyWindowMode = "rolling24h"
+)
+
+// rollingWindowLength is the length of the trailing window
+const rollingWindowLength = 24 * time.Hour
+
+// IsRolling reports whether "today" is the trailing 24 hours rather than the calendar day
+func (m DailyWindowMode) IsRolling() bool {
+	return m == DailyWindowRolling24h
+}
+
+// RollingWindowStart returns the start of the trailing 24-hour window that ends at now
+func RollingWindowStart(now time.Time) time.Time {
+	return now.Add(-rollingWindowLength)
+}
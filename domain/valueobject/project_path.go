@@ -0,0 +1,32 @@
+package valueobject
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// hashedProjectPathPrefix marks a project path that has been anonymized
+const hashedProjectPathPrefix = "project-"
+
+// hashedProjectPathLength is the number of hex characters of the SHA-256 digest that are kept
+const hashedProjectPathLength = 12
+
+// HashProjectPath returns a stable, anonymized identifier for a project path.
+// The same path always yields the same identifier, so it can still be used for grouping.
+func HashProjectPath(projectPath string) string {
+	if projectPath == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(projectPath))
+	return hashedProjectPathPrefix + hex.EncodeToString(sum[:])[:hashedProjectPathLength]
+}
+
+// DisplayProjectPath returns a project path as tosage prints or exports it: its HashProjectPath
+// identifier when hash is set, the path itself otherwise. Paths stay raw inside tosage so
+// filters and lookups keep matching; only output goes through here.
+func DisplayProjectPath(projectPath string, hash bool) string {
+	if hash {
+		return HashProjectPath(projectPath)
+	}
+	return projectPath
+}
@@ -0,0 +1,47 @@
+package valueobject
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashProjectPath(t *testing.T) {
+	t.Run("stable", func(t *testing.T) {
+		first := HashProjectPath("/Users/alice/work/secret-client")
+		second := HashProjectPath("/Users/alice/work/secret-client")
+		if first != second {
+			t.Errorf("HashProjectPath() not stable: %q != %q", first, second)
+		}
+	})
+
+	t.Run("hides the path", func(t *testing.T) {
+		got := HashProjectPath("/Users/alice/work/secret-client")
+		if strings.Contains(got, "secret-client") {
+			t.Errorf("HashProjectPath() = %q, leaks the path", got)
+		}
+		if !strings.HasPrefix(got, "project-") || len(got) != len("project-")+12 {
+			t.Errorf("HashProjectPath() = %q, want project-<12 hex chars>", got)
+		}
+	})
+
+	t.Run("distinct paths", func(t *testing.T) {
+		if HashProjectPath("/a") == HashProjectPath("/b") {
+			t.Error("HashProjectPath() returned the same value for different paths")
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		if got := HashProjectPath(""); got != "" {
+			t.Errorf("HashProjectPath(\"\") = %q, want empty", got)
+		}
+	})
+}
+
+func TestDisplayProjectPath(t *testing.T) {
+	if got := DisplayProjectPath("/work/app", false); got != "/work/app" {
+		t.Errorf("DisplayProjectPath(hash=false) = %q, want the raw path", got)
+	}
+	if got := DisplayProjectPath("/work/app", true); got != HashProjectPath("/work/app") {
+		t.Errorf("DisplayProjectPath(hash=true) = %q, want %q", got, HashProjectPath("/work/app"))
+	}
+}
@@ -0,0 +1,19 @@
+package valueobject
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// hashedSessionIDPrefix marks a session ID that has been anonymized
+const hashedSessionIDPrefix = "session-"
+
+// HashSessionID returns a stable, anonymized identifier for a Claude Code session ID,
+// shortened like HashProjectPath
+func HashSessionID(sessionID string) string {
+	if sessionID == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(sessionID))
+	return hashedSessionIDPrefix + hex.EncodeToString(sum[:])[:hashedProjectPathLength]
+}
@@ -0,0 +1,19 @@
+package valueobject
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// hashedSourcePathPrefix marks a Claude data directory that has been anonymized
+const hashedSourcePathPrefix = "source-"
+
+// HashSourcePath returns a stable, anonymized identifier for a Claude data directory,
+// shortened like HashProjectPath
+func HashSourcePath(sourcePath string) string {
+	if sourcePath == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(sourcePath))
+	return hashedSourcePathPrefix + hex.EncodeToString(sum[:])[:hashedProjectPathLength]
+}
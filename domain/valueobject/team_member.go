@@ -0,0 +1,20 @@
+package valueobject
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// hashedTeamMemberPrefix marks a team member email or name that has been anonymized
+const hashedTeamMemberPrefix = "user-"
+
+// HashTeamMember returns a stable, anonymized identifier for a team member's email or name,
+// shortened like HashProjectPath. Case is ignored, as it is for the team member allowlist.
+func HashTeamMember(member string) string {
+	if member == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(member)))
+	return hashedTeamMemberPrefix + hex.EncodeToString(sum[:])[:hashedProjectPathLength]
+}
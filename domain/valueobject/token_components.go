@@ -0,0 +1,76 @@
+package valueobject
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Token component names accepted by ParseTokenComponents
+const (
+	TokenComponentInput         = "input"
+	TokenComponentOutput        = "output"
+	TokenComponentCacheCreation = "cache_creation"
+	TokenComponentCacheRead     = "cache_read"
+)
+
+// TokenComponents selects the token components counted in a total.
+// The zero value counts every component.
+type TokenComponents struct {
+	restricted    bool
+	input         bool
+	output        bool
+	cacheCreation bool
+	cacheRead     bool
+}
+
+// ParseTokenComponents builds a selection from component names. An empty list selects every component.
+func ParseTokenComponents(names []string) (TokenComponents, error) {
+	if len(names) == 0 {
+		return TokenComponents{}, nil
+	}
+
+	components := TokenComponents{restricted: true}
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case TokenComponentInput:
+			components.input = true
+		case TokenComponentOutput:
+			components.output = true
+		case TokenComponentCacheCreation:
+			components.cacheCreation = true
+		case TokenComponentCacheRead:
+			components.cacheRead = true
+		default:
+			return TokenComponents{}, fmt.Errorf("unknown token component %q (available: %s, %s, %s, %s)", name,
+				TokenComponentInput, TokenComponentOutput, TokenComponentCacheCreation, TokenComponentCacheRead)
+		}
+	}
+	return components, nil
+}
+
+// IsAll reports whether every component is selected
+func (c TokenComponents) IsAll() bool {
+	return !c.restricted || (c.input && c.output && c.cacheCreation && c.cacheRead)
+}
+
+// Total returns the sum of the selected components of ts
+func (c TokenComponents) Total(ts TokenStats) int {
+	if !c.restricted {
+		return ts.TotalTokens()
+	}
+
+	total := 0
+	if c.input {
+		total += ts.InputTokens()
+	}
+	if c.output {
+		total += ts.OutputTokens()
+	}
+	if c.cacheCreation {
+		total += ts.CacheCreationTokens()
+	}
+	if c.cacheRead {
+		total += ts.CacheReadTokens()
+	}
+	return total
+}
@@ -0,0 +1,38 @@
+package valueobject
+
+import "testing"
+
+func TestTokenComponents_Total(t *testing.T) {
+	stats := NewTokenStats(100, 20, 30, 1000)
+
+	tests := []struct {
+		name    string
+		names   []string
+		want    int
+		wantAll bool
+	}{
+		{name: "empty selects all", names: nil, want: 1150, wantAll: true},
+		{name: "without cache read", names: []string{"input", "output", "cache_creation"}, want: 150},
+		{name: "output only", names: []string{" Output "}, want: 20},
+		{name: "every component", names: []string{"input", "output", "cache_creation", "cache_read"}, want: 1150, wantAll: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			components, err := ParseTokenComponents(tt.names)
+			if err != nil {
+				t.Fatalf("ParseTokenComponents() error = %v", err)
+			}
+			if got := components.Total(stats); got != tt.want {
+				t.Errorf("Total() = %d, want %d", got, tt.want)
+			}
+			if got := components.IsAll(); got != tt.wantAll {
+				t.Errorf("IsAll() = %v, want %v", got, tt.wantAll)
+			}
+		})
+	}
+
+	if _, err := ParseTokenComponents([]string{"input", "reasoning"}); err == nil {
+		t.Error("ParseTokenComponents() with an unknown component returned no error")
+	}
+}
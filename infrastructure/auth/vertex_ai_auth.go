@@ -3,8 +3,11 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
@@ -43,6 +46,234 @@ type ServiceAccountKey struct {
 	ClientX509CertURL       string `json:"client_x509_cert_url"`
 }
 
+// ServiceAccountKeyInfo describes a validated service account key without its secret material
+type ServiceAccountKeyInfo struct {
+	Source       string
+	Type         string
+	ProjectID    string
+	ClientEmail  string
+	PrivateKeyID string
+}
+
+// InspectServiceAccountKey loads a service account key from inline JSON or a file path,
+// validates it and creates a token source from it. No token is requested, so the
+// check works offline and isolates key problems from API or network problems.
+func InspectServiceAccountKey(ctx context.Context, keyOrPath string) (*ServiceAccountKeyInfo, error) {
+	keyJSON, source, err := readServiceAccountKey(keyOrPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var key ServiceAccountKey
+	if err := json.Unmarshal([]byte(keyJSON), &key); err != nil {
+		return nil, fmt.Errorf("invalid service account key JSON: %w", err)
+	}
+
+	info := &ServiceAccountKeyInfo{
+		Source:       source,
+		Type:         key.Type,
+		ProjectID:    key.ProjectID,
+		ClientEmail:  key.ClientEmail,
+		PrivateKeyID: key.PrivateKeyID,
+	}
+
+	a := &vertexAIAuthenticatorImpl{serviceAccountKey: keyJSON}
+	if _, err := a.createTokenSourceFromJSON(ctx, keyJSON); err != nil {
+		return info, err
+	}
+
+	return info, nil
+}
+
+// readServiceAccountKey returns the key JSON of keyOrPath, which is inline JSON or a file path,
+// and a description of where it came from
+func readServiceAccountKey(keyOrPath string) (keyJSON, source string, err error) {
+	keyJSON = strings.TrimSpace(keyOrPath)
+	if strings.HasPrefix(keyJSON, "{") {
+		return keyJSON, "inline JSON", nil
+	}
+	data, err := os.ReadFile(keyJSON)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read service account key file: %w", err)
+	}
+	return string(data), keyOrPath, nil
+}
+
+// NewVertexAIAuthenticatorFromKeys creates an authenticator from keys, each inline JSON or a
+// file path. Every token request tries the key that last obtained a token first and falls
+// through to the others in order, so listing the old and new key during a rotation keeps
+// collection working whichever of them is valid, also when one is revoked later on.
+//
+// A token is requested within ctx to pick the key in use, which the returned info describes.
+// It fails only when no key could be loaded. When the keys load but none obtains a token, the
+// authenticator is returned together with an error listing why each key failed, and later
+// requests try every key again.
+func NewVertexAIAuthenticatorFromKeys(ctx context.Context, keys []string) (VertexAIAuthenticator, *ServiceAccountKeyInfo, error) {
+	if len(keys) == 0 {
+		return nil, nil, fmt.Errorf("no service account keys provided")
+	}
+
+	a := &multiKeyAuthenticator{}
+	failures := make([]string, 0, len(keys))
+	for i, keyOrPath := range keys {
+		key, err := loadServiceAccountKey(ctx, i+1, keyOrPath)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		a.keys = append(a.keys, key)
+	}
+	if len(a.keys) == 0 {
+		return nil, nil, fmt.Errorf("no service account key could be loaded: %s", strings.Join(failures, "; "))
+	}
+
+	if _, err := a.token(ctx); err != nil {
+		return a, a.currentKey().info, fmt.Errorf("no service account key obtained an access token: %s", strings.Join(append(failures, err.Error()), "; "))
+	}
+	return a, a.currentKey().info, nil
+}
+
+// serviceAccountKey is one of the keys of a multiKeyAuthenticator
+type serviceAccountKey struct {
+	// number is the position of the key in the configured list, starting at 1
+	number int
+	info   *ServiceAccountKeyInfo
+	auth   *vertexAIAuthenticatorImpl
+}
+
+// loadServiceAccountKey validates a single key and creates its token source without
+// requesting a token. Errors name the key by its number and source.
+func loadServiceAccountKey(ctx context.Context, number int, keyOrPath string) (*serviceAccountKey, error) {
+	keyJSON, source, err := readServiceAccountKey(keyOrPath)
+	if err != nil {
+		return nil, fmt.Errorf("key %d (%s): %w", number, keyOrPath, err)
+	}
+
+	var key ServiceAccountKey
+	if err := json.Unmarshal([]byte(keyJSON), &key); err != nil {
+		return nil, fmt.Errorf("key %d (%s): invalid service account key JSON: %w", number, source, err)
+	}
+	info := &ServiceAccountKeyInfo{
+		Source:       source,
+		Type:         key.Type,
+		ProjectID:    key.ProjectID,
+		ClientEmail:  key.ClientEmail,
+		PrivateKeyID: key.PrivateKeyID,
+	}
+
+	auth := &vertexAIAuthenticatorImpl{serviceAccountKey: keyJSON}
+	// The token source outlives ctx, which only bounds the token requests made at startup
+	tokenSource, err := auth.createTokenSourceFromJSON(context.WithoutCancel(ctx), keyJSON)
+	if err != nil {
+		return nil, fmt.Errorf("key %d (%s): %w", number, source, err)
+	}
+	auth.tokenSource = tokenSource
+
+	return &serviceAccountKey{number: number, info: info, auth: auth}, nil
+}
+
+// multiKeyAuthenticator requests tokens with the first of several service account keys that
+// obtains one, starting with the key that succeeded last
+type multiKeyAuthenticator struct {
+	keys []*serviceAccountKey
+
+	mu      sync.Mutex
+	current int
+}
+
+// token requests a token with the current key, falling through to the other keys in order
+func (a *multiKeyAuthenticator) token(ctx context.Context) (*oauth2.Token, error) {
+	a.mu.Lock()
+	start := a.current
+	a.mu.Unlock()
+
+	failures := make([]string, 0, len(a.keys))
+	for i := range a.keys {
+		index := (start + i) % len(a.keys)
+		key := a.keys[index]
+		token, err := requestToken(ctx, key.auth.tokenSource)
+		if err == nil {
+			a.mu.Lock()
+			a.current = index
+			a.mu.Unlock()
+			return token, nil
+		}
+		failures = append(failures, fmt.Sprintf("key %d (%s): %v", key.number, key.info.Source, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.New(strings.Join(failures, "; "))
+}
+
+// currentKey returns the key that obtained the last token
+func (a *multiKeyAuthenticator) currentKey() *serviceAccountKey {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.keys[a.current]
+}
+
+// GetAccessToken returns a valid access token of the first key that obtains one
+func (a *multiKeyAuthenticator) GetAccessToken(ctx context.Context) (string, error) {
+	token, err := a.token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("no service account key obtained an access token: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// ValidateCredentials validates the key currently in use
+func (a *multiKeyAuthenticator) ValidateCredentials() error {
+	return a.currentKey().auth.ValidateCredentials()
+}
+
+// IsUsingADC returns false, as the authenticator always uses service account keys
+func (a *multiKeyAuthenticator) IsUsingADC() bool {
+	return false
+}
+
+// GetTokenSource returns a token source that falls through the keys like GetAccessToken
+func (a *multiKeyAuthenticator) GetTokenSource() oauth2.TokenSource {
+	return multiKeyTokenSource{a}
+}
+
+// multiKeyTokenSource adapts a multiKeyAuthenticator to oauth2.TokenSource
+type multiKeyTokenSource struct {
+	a *multiKeyAuthenticator
+}
+
+// Token returns a token of the first key that obtains one
+func (s multiKeyTokenSource) Token() (*oauth2.Token, error) {
+	return s.a.token(context.Background())
+}
+
+// requestToken requests a valid token from tokenSource, giving up once ctx is done. The token
+// sources don't take a context per request, so a request still running is left to finish.
+func requestToken(ctx context.Context, tokenSource oauth2.TokenSource) (*oauth2.Token, error) {
+	type result struct {
+		token *oauth2.Token
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		token, err := tokenSource.Token()
+		done <- result{token, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, fmt.Errorf("failed to get access token: %w", r.err)
+		}
+		if !r.token.Valid() {
+			return nil, fmt.Errorf("token is invalid or expired")
+		}
+		return r.token, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to get access token: %w", ctx.Err())
+	}
+}
+
 // NewVertexAIAuthenticator creates a new Vertex AI authenticator
 func NewVertexAIAuthenticator(serviceAccountKey, serviceAccountKeyPath string) (VertexAIAuthenticator, error) {
 	auth := &vertexAIAuthenticatorImpl{
@@ -1,14 +1,110 @@
 package config
 
 import (
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"math"
+	"net"
+	"net/url"
 	"os"
+	"path"
+	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Netflix/go-env"
+
+	"github.com/ca-srg/tosage/domain/valueobject"
+)
+
+// DefaultCursorBillingDay is the day of the month Cursor billing periods start on by default
+const DefaultCursorBillingDay = 3
+
+// DefaultCursorBaseURL is the Cursor API endpoint used unless configured otherwise
+const DefaultCursorBaseURL = "https://cursor.com"
+
+// DefaultCursorTeamMemberLimit is the number of Cursor team members sent as separate series by default
+const DefaultCursorTeamMemberLimit = 50
+
+// MaxCursorTeamMemberLimit caps the number of Cursor team members sent as separate series
+const MaxCursorTeamMemberLimit = 1000
+
+// DefaultDaemonLogMaxSizeMB is the size in megabytes at which the daemon log file is rotated by default
+const DefaultDaemonLogMaxSizeMB = 10
+
+// DefaultDaemonLogMaxBackups is the number of rotated daemon log files kept by default
+const DefaultDaemonLogMaxBackups = 5
+
+// DefaultDaemonLogMaxAgeDays is the age in days after which rotated daemon log files are removed by default
+const DefaultDaemonLogMaxAgeDays = 30
+
+// MaxSessionMetricsTopN caps the number of Claude Code sessions sent as tosage_cc_session_token
+const MaxSessionMetricsTopN = 50
+
+// MinPrometheusIntervalSec is the minimum allowed interval in seconds between metric pushes
+const MinPrometheusIntervalSec = 60
+
+// intervalSources are the sources accepted as keys of source_interval_seconds
+var intervalSources = map[string]bool{
+	"claude_code": true,
+	"cursor":      true,
+	"bedrock":     true,
+	"vertex_ai":   true,
+}
+
+// MinCircuitBreakerBackoffSec is the minimum time in seconds the Remote Write circuit stays open
+const MinCircuitBreakerBackoffSec = 10
+
+// MinScrapeRefreshAfterSec is the minimum age in seconds of the last collection before a scrape
+// collects on demand, so frequent scrapes do not turn into frequent collections
+const MinScrapeRefreshAfterSec = 30
+
+// DefaultMaxConcurrentRequests is the default limit on outbound requests in flight across providers
+const DefaultMaxConcurrentRequests = 8
+
+// MaxCostPrecision is the largest number of decimal places costs can be printed with
+const MaxCostPrecision = 10
+
+// Actions taken on Claude Code entries above the per-entry token cap
+const (
+	// TokenCapSkip drops the entry from all totals
+	TokenCapSkip = "skip"
+	// TokenCapClamp scales the entry's token counts down to the cap
+	TokenCapClamp = "clamp"
+)
+
+// Remote Write payload compression methods
+const (
+	CompressionSnappy = "snappy"
+	CompressionGzip   = "gzip"
+	CompressionNone   = "none"
+)
+
+// Default CloudWatch names of the Bedrock invocation metrics
+const (
+	DefaultBedrockNamespace         = "AWS/Bedrock"
+	DefaultBedrockInputTokenMetric  = "InputTokenCount"
+	DefaultBedrockOutputTokenMetric = "OutputTokenCount"
+	DefaultBedrockInvocationsMetric = "Invocations"
+	DefaultBedrockLatencyMetric     = "InvocationLatency"
+	DefaultBedrockModelDimension    = "ModelId"
+)
+
+// Default ingestion delays of the cloud providers, how long their monitoring services take to
+// make a sample visible, and the largest delay accepted
+const (
+	DefaultBedrockIngestionDelaySec  = 180
+	DefaultVertexAIIngestionDelaySec = 240
+	MaxIngestionDelaySec             = 3600
+)
+
+// Batch sizes of the metric queries sent per CloudWatch GetMetricData request
+const (
+	DefaultBedrockMetricBatchSize = 500
+	MaxBedrockMetricBatchSize     = 500
 )
 
 // PrometheusConfig holds Prometheus integration configuration
@@ -21,7 +117,15 @@ type PrometheusConfig struct {
 	RemoteWriteUsername string `json:"remote_write_username" env:"TOSAGE_PROMETHEUS_REMOTE_WRITE_USERNAME"`
 
 	// RemoteWritePassword is the password for Remote Write authentication
-	RemoteWritePassword string `json:"remote_write_password" env:"TOSAGE_PROMETHEUS_REMOTE_WRITE_PASSWORD"`
+	RemoteWritePassword string `json:"remote_write_password" env:"TOSAGE_PROMETHEUS_REMOTE_WRITE_PASSWORD" secret:"true"`
+
+	// RemoteWritePasswordFile is a file holding the Remote Write password. It takes
+	// precedence over RemoteWritePassword and is re-read on SIGHUP.
+	RemoteWritePasswordFile string `json:"remote_write_password_file,omitempty" env:"TOSAGE_PROMETHEUS_REMOTE_WRITE_PASSWORD_FILE"`
+
+	// RemoteWriteTokenFile is a file holding a bearer token sent instead of basic
+	// authentication. It is re-read on SIGHUP.
+	RemoteWriteTokenFile string `json:"remote_write_token_file,omitempty" env:"TOSAGE_PROMETHEUS_REMOTE_WRITE_TOKEN_FILE"`
 
 	// Query configuration (new fields)
 	// URL is the Prometheus query endpoint URL
@@ -31,7 +135,7 @@ type PrometheusConfig struct {
 	Username string `json:"username" env:"TOSAGE_PROMETHEUS_USERNAME"`
 
 	// Password is the password for query authentication
-	Password string `json:"password" env:"TOSAGE_PROMETHEUS_PASSWORD"`
+	Password string `json:"password" env:"TOSAGE_PROMETHEUS_PASSWORD" secret:"true"`
 
 	// Common configuration
 	// HostLabel is the host label value for metrics
@@ -40,8 +144,334 @@ type PrometheusConfig struct {
 	// IntervalSec is the interval in seconds between metric pushes
 	IntervalSec int `json:"interval_seconds,omitempty" env:"TOSAGE_PROMETHEUS_INTERVAL_SECONDS,default=600"`
 
+	// SourceIntervalSec overrides the collection interval of individual sources, keyed by claude_code,
+	// cursor, bedrock or vertex_ai, e.g. to query the cloud APIs less often (default: IntervalSec)
+	SourceIntervalSec map[string]int `json:"source_interval_seconds,omitempty"`
+
+	// CcTokensDelta sends tosage_cc_tokens_delta, the Claude Code tokens used since the previous push
+	CcTokensDelta bool `json:"cc_tokens_delta,omitempty" env:"TOSAGE_PROMETHEUS_CC_TOKENS_DELTA"`
+
+	// AlignToInterval schedules pushes on interval boundaries (e.g. every full 10 minutes)
+	// instead of relative to the start time
+	AlignToInterval bool `json:"align_to_interval" env:"TOSAGE_PROMETHEUS_ALIGN_TO_INTERVAL"`
+
+	// Schedule is a cron expression, e.g. "0 9-18 * * MON-FRI", that replaces the interval
+	// ticker in daemon mode (default: empty, push every IntervalSec)
+	Schedule string `json:"schedule,omitempty" env:"TOSAGE_PROMETHEUS_SCHEDULE"`
+
+	// InitialDelaySec delays the first push after startup, e.g. while the network comes up after login (default: 0)
+	InitialDelaySec int `json:"initial_delay_seconds,omitempty" env:"TOSAGE_PROMETHEUS_INITIAL_DELAY_SECONDS"`
+
+	// NetworkWaitSec waits up to this many seconds, after InitialDelaySec, for the metrics backend
+	// to become reachable before the first push (default: 0, no wait)
+	NetworkWaitSec int `json:"network_wait_seconds,omitempty" env:"TOSAGE_PROMETHEUS_NETWORK_WAIT_SECONDS"`
+
 	// TimeoutSec is the timeout in seconds for metric pushes
 	TimeoutSec int `json:"timeout_seconds,omitempty" env:"TOSAGE_PROMETHEUS_TIMEOUT_SECONDS,default=30"`
+
+	// Compression is the Remote Write payload compression: "snappy" (default), "gzip" or "none"
+	Compression string `json:"compression,omitempty" env:"TOSAGE_PROMETHEUS_COMPRESSION,default=snappy"`
+
+	// ClientCertPath is the PEM client certificate presented to the Remote Write endpoint (mTLS)
+	ClientCertPath string `json:"client_cert_path,omitempty" env:"TOSAGE_PROMETHEUS_CLIENT_CERT_PATH"`
+
+	// ClientKeyPath is the PEM private key for ClientCertPath
+	ClientKeyPath string `json:"client_key_path,omitempty" env:"TOSAGE_PROMETHEUS_CLIENT_KEY_PATH"`
+
+	// ProbeOnStartup checks that the Remote Write endpoint is reachable at startup (default: true)
+	ProbeOnStartup *bool `json:"probe_on_startup,omitempty" env:"TOSAGE_PROMETHEUS_PROBE_ON_STARTUP"`
+
+	// CircuitBreakerThreshold is the number of consecutive collection cycles without a successful
+	// push that opens the Remote Write circuit breaker, after which pushes are skipped for
+	// CircuitBreakerBackoffSec.
+	// Zero disables the breaker.
+	CircuitBreakerThreshold int `json:"circuit_breaker_threshold,omitempty" env:"TOSAGE_PROMETHEUS_CIRCUIT_BREAKER_THRESHOLD,default=5"`
+
+	// CircuitBreakerBackoffSec is how long pushes are skipped before a single push tests recovery
+	CircuitBreakerBackoffSec int `json:"circuit_breaker_backoff_seconds,omitempty" env:"TOSAGE_PROMETHEUS_CIRCUIT_BREAKER_BACKOFF_SECONDS,default=300"`
+
+	// Scrape endpoint configuration
+	// ScrapeListenAddress is the address (e.g. ":9464") to serve /metrics on; empty disables the endpoint.
+	// Profiles don't inherit it and must each use a different address.
+	ScrapeListenAddress string `json:"scrape_listen_address,omitempty" env:"TOSAGE_PROMETHEUS_SCRAPE_LISTEN_ADDRESS"`
+
+	// ScrapeRefreshAfterSec makes a scrape collect on demand when the last collection is older
+	// than this many seconds, instead of serving the stale values (default: 0, disabled)
+	ScrapeRefreshAfterSec int `json:"scrape_refresh_after_seconds,omitempty" env:"TOSAGE_PROMETHEUS_SCRAPE_REFRESH_AFTER_SECONDS"`
+
+	// StateFilePath is the path of the JSON file that persists last-sent metrics across restarts
+	// (default: ~/.config/tosage/metrics_state.json)
+	StateFilePath string `json:"state_file_path,omitempty" env:"TOSAGE_PROMETHEUS_STATE_FILE_PATH"`
+
+	// Transforms scales metric values just before they are sent, keyed by metric name
+	Transforms map[string]*MetricTransformConfig `json:"transforms,omitempty"`
+
+	// ExtraLabels are static labels (e.g. team, environment) added to every series
+	ExtraLabels map[string]string `json:"extra_labels,omitempty"`
+
+	// CloudMetadataLabels adds instance_id, zone and instance_type labels read at startup from
+	// the EC2 (IMDSv2) or GCE metadata service; skipped on machines outside those clouds
+	CloudMetadataLabels bool `json:"cloud_metadata_labels,omitempty" env:"TOSAGE_PROMETHEUS_CLOUD_METADATA_LABELS"`
+
+	// DerivedLabels lists labels computed from today's Claude Code usage and sent on tosage_cc_info.
+	// Supported: "most_used_model", "unique_projects" and "unique_sessions" (counts are bucketed).
+	// Environment variable: TOSAGE_PROMETHEUS_DERIVED_LABELS (comma-separated)
+	DerivedLabels []string `json:"derived_labels,omitempty" env:"TOSAGE_PROMETHEUS_DERIVED_LABELS"`
+
+	// MetricAllowlist sends only the metrics whose names match one of these glob patterns (e.g. "tosage_cc_*").
+	// Sources keep collecting for the CLI and CSV export; filtered metrics just never reach a backend.
+	// Environment variable: TOSAGE_PROMETHEUS_METRIC_ALLOWLIST (comma-separated)
+	MetricAllowlist []string `json:"metric_allowlist,omitempty" env:"TOSAGE_PROMETHEUS_METRIC_ALLOWLIST"`
+
+	// MetricDenylist drops the metrics whose names match one of these glob patterns.
+	// It cannot be combined with MetricAllowlist.
+	// Environment variable: TOSAGE_PROMETHEUS_METRIC_DENYLIST (comma-separated)
+	MetricDenylist []string `json:"metric_denylist,omitempty" env:"TOSAGE_PROMETHEUS_METRIC_DENYLIST"`
+
+	// SessionMetricsTopN sends tosage_cc_session_token for today's N largest Claude Code sessions
+	// (0 disables, at most MaxSessionMetricsTopN). Session IDs are high-cardinality, so keep N small.
+	SessionMetricsTopN int `json:"session_metrics_top_n,omitempty" env:"TOSAGE_PROMETHEUS_SESSION_METRICS_TOP_N"`
+
+	// SessionPercentiles sends the p50, p90, p99 and max of today's per-session Claude Code tokens as
+	// tosage_cc_session_tokens_p50 and so on, without per-session labels
+	SessionPercentiles bool `json:"session_percentiles,omitempty" env:"TOSAGE_PROMETHEUS_SESSION_PERCENTILES"`
+
+	// UniqueCountMetrics sends the number of projects, models and sessions used today as
+	// tosage_cc_unique_projects, tosage_cc_unique_models and tosage_cc_unique_sessions
+	UniqueCountMetrics bool `json:"unique_count_metrics,omitempty" env:"TOSAGE_PROMETHEUS_UNIQUE_COUNT_METRICS"`
+
+	// HashSessionIDs replaces session IDs in tosage_cc_session_token with a stable SHA-256 prefix
+	HashSessionIDs bool `json:"hash_session_ids,omitempty" env:"TOSAGE_PROMETHEUS_HASH_SESSION_IDS"`
+
+	// SourcePathLabel also sends tosage_cc_source_path_token per Claude data directory, labeled with source_path
+	SourcePathLabel bool `json:"source_path_label,omitempty" env:"TOSAGE_PROMETHEUS_SOURCE_PATH_LABEL"`
+
+	// HashSourcePaths replaces the source_path label with a stable SHA-256 prefix (default: true).
+	// Paths are always hashed when HashProjectPaths is set.
+	HashSourcePaths *bool `json:"hash_source_paths,omitempty" env:"TOSAGE_PROMETHEUS_HASH_SOURCE_PATHS"`
+
+	// Backends are additional metrics backends that receive every metric alongside the
+	// Remote Write endpoint, e.g. to dual-write while migrating to a new backend
+	Backends []MetricsBackendConfig `json:"backends,omitempty"`
+}
+
+// Metrics backend types
+const (
+	// BackendRemoteWrite pushes with the Prometheus Remote Write protocol
+	BackendRemoteWrite = "remote_write"
+	// BackendOTLP pushes to an OpenTelemetry collector with OTLP/HTTP (JSON encoding)
+	BackendOTLP = "otlp"
+)
+
+// MetricsBackendConfig is an additional metrics backend
+type MetricsBackendConfig struct {
+	// Name identifies the backend in logs (default: its type and URL)
+	Name string `json:"name,omitempty"`
+
+	// Type is "remote_write" (default) or "otlp"
+	Type string `json:"type,omitempty"`
+
+	// URL is the Remote Write endpoint or the OTLP metrics endpoint (e.g. http://collector:4318/v1/metrics)
+	URL string `json:"url"`
+
+	// Username is the username for basic authentication
+	Username string `json:"username,omitempty"`
+
+	// Password is the password for basic authentication
+	Password string `json:"password,omitempty" secret:"true"`
+
+	// TimeoutSec is the timeout in seconds for each push (default: the Remote Write timeout)
+	TimeoutSec int `json:"timeout_seconds,omitempty"`
+}
+
+// BackendType returns the backend's type, defaulting to Remote Write
+func (b *MetricsBackendConfig) BackendType() string {
+	if b.Type == "" {
+		return BackendRemoteWrite
+	}
+	return b.Type
+}
+
+// DisplayName returns the name the backend is identified by in logs
+func (b *MetricsBackendConfig) DisplayName() string {
+	if b.Name != "" {
+		return b.Name
+	}
+	return b.BackendType() + " " + b.URL
+}
+
+// Defaults of a remote source
+const (
+	// DefaultRemoteClaudePath is the Claude Code projects directory, relative to the remote home directory
+	DefaultRemoteClaudePath = ".claude/projects"
+	// DefaultRemoteSyncIntervalSec is how often a remote source is checked for new data
+	DefaultRemoteSyncIntervalSec = 300
+	// DefaultRemoteTimeoutSec bounds connecting to a remote source and waiting for it to respond
+	DefaultRemoteTimeoutSec = 30
+)
+
+// RemoteSourceConfig is a machine whose Claude Code data is read over SFTP
+type RemoteSourceConfig struct {
+	// Name identifies the source in logs and the source_path label (default: user@host)
+	Name string `json:"name,omitempty"`
+
+	// Host is the SSH server, as host or host:port (default port 22)
+	Host string `json:"host"`
+
+	// User is the SSH user
+	User string `json:"user"`
+
+	// KeyPath is the private key to authenticate with. The SSH agent in SSH_AUTH_SOCK is used as well.
+	KeyPath string `json:"key_path,omitempty"`
+
+	// KnownHostsPath is the known_hosts file the host key is checked against (default: ~/.ssh/known_hosts)
+	KnownHostsPath string `json:"known_hosts_path,omitempty"`
+
+	// RemotePath is the Claude Code projects directory on the remote machine (default: .claude/projects,
+	// relative to the remote home directory)
+	RemotePath string `json:"remote_path,omitempty"`
+
+	// SyncIntervalSec is how often the remote files are checked for new data (default: 300)
+	SyncIntervalSec int `json:"sync_interval_seconds,omitempty"`
+
+	// TimeoutSec bounds connecting to the remote machine and waiting for each response (default: 30)
+	TimeoutSec int `json:"timeout_seconds,omitempty"`
+}
+
+// DisplayName returns the name the source is identified by
+func (r *RemoteSourceConfig) DisplayName() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.User + "@" + r.Host
+}
+
+// Address returns the host:port to connect to
+func (r *RemoteSourceConfig) Address() string {
+	if _, _, err := net.SplitHostPort(r.Host); err == nil {
+		return r.Host
+	}
+	return net.JoinHostPort(r.Host, "22")
+}
+
+// ClaudePath returns the remote Claude Code projects directory
+func (r *RemoteSourceConfig) ClaudePath() string {
+	if r.RemotePath == "" {
+		return DefaultRemoteClaudePath
+	}
+	return r.RemotePath
+}
+
+// SyncInterval returns how often the remote files are checked for new data
+func (r *RemoteSourceConfig) SyncInterval() time.Duration {
+	if r.SyncIntervalSec <= 0 {
+		return DefaultRemoteSyncIntervalSec * time.Second
+	}
+	return time.Duration(r.SyncIntervalSec) * time.Second
+}
+
+// Timeout returns the bound on connecting to the remote machine and on each response
+func (r *RemoteSourceConfig) Timeout() time.Duration {
+	if r.TimeoutSec <= 0 {
+		return DefaultRemoteTimeoutSec * time.Second
+	}
+	return time.Duration(r.TimeoutSec) * time.Second
+}
+
+// ShouldProbeOnStartup reports whether the Remote Write endpoint should be probed at startup
+func (p *PrometheusConfig) ShouldProbeOnStartup() bool {
+	return p.ProbeOnStartup == nil || *p.ProbeOnStartup
+}
+
+// ShouldHashSourcePaths reports whether the source_path label is hashed
+func (p *PrometheusConfig) ShouldHashSourcePaths() bool {
+	return p.HashSourcePaths == nil || *p.HashSourcePaths
+}
+
+// ShouldHashTeamMembers reports whether the user label of team member metrics is hashed
+func (c *CursorConfig) ShouldHashTeamMembers() bool {
+	return c.HashTeamMembers == nil || *c.HashTeamMembers
+}
+
+// ShouldCompressLogs reports whether rotated daemon log files should be gzipped
+func (d *DaemonConfig) ShouldCompressLogs() bool {
+	return d.LogCompress == nil || *d.LogCompress
+}
+
+// MaxLogBackups returns the number of rotated daemon log files kept, 0 keeping all
+func (d *DaemonConfig) MaxLogBackups() int {
+	if d.LogMaxBackups == nil {
+		return DefaultDaemonLogMaxBackups
+	}
+	return *d.LogMaxBackups
+}
+
+// MaxLogAgeDays returns the age in days after which rotated daemon log files are removed,
+// 0 keeping them regardless of age
+func (d *DaemonConfig) MaxLogAgeDays() int {
+	if d.LogMaxAgeDays == nil {
+		return DefaultDaemonLogMaxAgeDays
+	}
+	return *d.LogMaxAgeDays
+}
+
+// ShouldCompress reports whether pushed log batches should be gzipped
+func (p *PromtailConfig) ShouldCompress() bool {
+	return p.Compress == nil || *p.Compress
+}
+
+// MetricTransformConfig scales a metric value just before it is sent, as value*Multiplier + Offset.
+// A transformed value no longer means "tokens", so the series should usually be renamed via RenameTo.
+type MetricTransformConfig struct {
+	// Multiplier is applied to the value first (e.g. 0.001 to report thousands of tokens)
+	Multiplier float64 `json:"multiplier"`
+
+	// Offset is added after the multiplier
+	Offset float64 `json:"offset,omitempty"`
+
+	// RenameTo is the metric name to send the transformed value as (e.g. "tosage_cc_ktoken")
+	RenameTo string `json:"rename_to,omitempty"`
+}
+
+// metricNamePattern matches valid Prometheus metric names
+var metricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Derived labels computed from today's Claude Code usage
+const (
+	DerivedLabelMostUsedModel  = "most_used_model"
+	DerivedLabelUniqueProjects = "unique_projects"
+	DerivedLabelUniqueSessions = "unique_sessions"
+)
+
+// labelNamePattern matches valid Prometheus label names
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// profileNamePattern matches profile names, which become part of state file names
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// internalMetricNames are the heartbeat and the marker that ends each collection. The scrape
+// endpoint and the Remote Write circuit breaker rely on their names, so they can't be transformed.
+var internalMetricNames = map[string]bool{
+	"tosage_up": true,
+	"tosage_last_collection_timestamp_seconds": true,
+}
+
+// reservedLabelNames are set by tosage itself and cannot be used as extra labels
+var reservedLabelNames = map[string]bool{
+	"host":             true,
+	"timezone":         true,
+	"timezone_offset":  true,
+	"detection_method": true,
+}
+
+// boolPtr returns a pointer to the given bool
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// intPtr returns a pointer to the given int
+func intPtr(n int) *int {
+	return &n
 }
 
 // CursorConfig holds Cursor integration configuration
@@ -52,8 +482,61 @@ type CursorConfig struct {
 	// APITimeout is the timeout in seconds for Cursor API requests
 	APITimeout int `json:"api_timeout,omitempty" env:"TOSAGE_CURSOR_API_TIMEOUT,default=30"`
 
+	// BaseURL is the Cursor API endpoint, e.g. a mock server or an enterprise endpoint (default: https://cursor.com)
+	BaseURL string `json:"base_url,omitempty" env:"TOSAGE_CURSOR_BASE_URL"`
+
 	// CacheTimeout is the cache timeout in seconds for API responses
 	CacheTimeout int `json:"cache_timeout,omitempty" env:"TOSAGE_CURSOR_CACHE_TIMEOUT,default=300"`
+
+	// BillingDay is the day of the month (1-28) the Cursor billing period starts
+	BillingDay int `json:"billing_day,omitempty" env:"TOSAGE_CURSOR_BILLING_DAY,default=3"`
+
+	// DayStartHour is the local hour (0-23) the daily Cursor token window starts (default: midnight)
+	DayStartHour int `json:"day_start_hour,omitempty" env:"TOSAGE_CURSOR_DAY_START_HOUR"`
+
+	// HostLabel overrides the host label of tosage_cursor_* metrics (defaults to prometheus.host_label)
+	HostLabel string `json:"host_label,omitempty" env:"TOSAGE_CURSOR_HOST_LABEL"`
+
+	// PremiumRequestMetrics sends tosage_cursor_premium_requests and _limit gauges
+	PremiumRequestMetrics bool `json:"premium_request_metrics,omitempty" env:"TOSAGE_CURSOR_PREMIUM_REQUEST_METRICS"`
+
+	// UsageCostMetrics sends the usage-based cost, mid-month payments and unpaid invoices
+	// of the current and last billing month from the Cursor invoices
+	UsageCostMetrics bool `json:"usage_cost_metrics,omitempty" env:"TOSAGE_CURSOR_USAGE_COST_METRICS"`
+
+	// CallCountMetrics sends tosage_cursor_tool_calls and tosage_cursor_token_based_calls,
+	// the calls of each kind billed in the current billing month
+	CallCountMetrics bool `json:"call_count_metrics,omitempty" env:"TOSAGE_CURSOR_CALL_COUNT_METRICS"`
+
+	// BillingCycleMetric sends tosage_cursor_billing_cycle_token, the tokens used since the
+	// billing cycle start reported by Cursor (startOfMonth), falling back to BillingDay
+	BillingCycleMetric bool `json:"billing_cycle_metric,omitempty" env:"TOSAGE_CURSOR_BILLING_CYCLE_METRIC"`
+
+	// UsageBasedStatusMetrics sends tosage_cursor_usage_based_enabled (0 or 1) and
+	// tosage_cursor_spend_limit_dollars, the usage-based pricing switch and its hard limit
+	UsageBasedStatusMetrics bool `json:"usage_based_status_metrics,omitempty" env:"TOSAGE_CURSOR_USAGE_BASED_STATUS_METRICS"`
+
+	// TeamMemberMetrics sends tosage_cursor_member_token for every team member with a user label.
+	// It requires the session token of a team admin.
+	TeamMemberMetrics bool `json:"team_member_metrics,omitempty" env:"TOSAGE_CURSOR_TEAM_MEMBER_METRICS"`
+
+	// TeamMembers limits team member metrics to members with a matching email or name (all members when empty)
+	// Environment variable: TOSAGE_CURSOR_TEAM_MEMBERS (comma-separated)
+	TeamMembers []string `json:"team_members,omitempty" env:"TOSAGE_CURSOR_TEAM_MEMBERS"`
+
+	// TeamMemberLimit caps the number of members whose usage is read and sent, to bound the
+	// requests and the cardinality
+	TeamMemberLimit int `json:"team_member_limit,omitempty" env:"TOSAGE_CURSOR_TEAM_MEMBER_LIMIT,default=50"`
+
+	// HashTeamMembers replaces the user label of team member metrics, an email or name, with a
+	// stable SHA-256 prefix (default: true). Environment variable: TOSAGE_CURSOR_HASH_TEAM_MEMBERS
+	HashTeamMembers *bool `json:"hash_team_members,omitempty" env:"TOSAGE_CURSOR_HASH_TEAM_MEMBERS"`
+
+	// RateLimit caps Cursor API requests per second; 0 sends them without a limit
+	RateLimit float64 `json:"rate_limit,omitempty" env:"TOSAGE_CURSOR_RATE_LIMIT"`
+
+	// RateLimitBurst is the number of requests sent at once before RateLimit applies (default: 1)
+	RateLimitBurst int `json:"rate_limit_burst,omitempty" env:"TOSAGE_CURSOR_RATE_LIMIT_BURST"`
 }
 
 // BedrockConfig holds AWS Bedrock integration configuration
@@ -65,6 +548,10 @@ type BedrockConfig struct {
 	// Environment variable: TOSAGE_BEDROCK_REGIONS (comma-separated, e.g., "us-east-1,us-west-2,eu-west-1")
 	Regions []string `json:"regions,omitempty" env:"TOSAGE_BEDROCK_REGIONS"`
 
+	// AllowUnknownRegions accepts regions missing from the built-in list of Bedrock regions,
+	// e.g. a region launched after this release
+	AllowUnknownRegions bool `json:"allow_unknown_regions,omitempty" env:"TOSAGE_BEDROCK_ALLOW_UNKNOWN_REGIONS"`
+
 	// AWSProfile is the AWS profile to use (optional)
 	AWSProfile string `json:"aws_profile,omitempty" env:"TOSAGE_BEDROCK_AWS_PROFILE,default="`
 
@@ -73,6 +560,43 @@ type BedrockConfig struct {
 
 	// CollectionIntervalSec is how often to collect metrics in seconds
 	CollectionIntervalSec int `json:"collection_interval_seconds,omitempty" env:"TOSAGE_BEDROCK_COLLECTION_INTERVAL_SECONDS,default=600"`
+
+	// IngestionDelaySec ends usage queries this many seconds before now, because CloudWatch
+	// publishes Bedrock metrics a few minutes late; a negative value queries up to now
+	IngestionDelaySec int `json:"ingestion_delay_seconds,omitempty" env:"TOSAGE_BEDROCK_INGESTION_DELAY_SECONDS,default=180"`
+
+	// Namespace is the CloudWatch namespace holding the Bedrock invocation metrics
+	Namespace string `json:"namespace,omitempty" env:"TOSAGE_BEDROCK_NAMESPACE,default=AWS/Bedrock"`
+
+	// InputTokenMetric is the CloudWatch metric name of input token counts
+	InputTokenMetric string `json:"input_token_metric,omitempty" env:"TOSAGE_BEDROCK_INPUT_TOKEN_METRIC,default=InputTokenCount"`
+
+	// OutputTokenMetric is the CloudWatch metric name of output token counts
+	OutputTokenMetric string `json:"output_token_metric,omitempty" env:"TOSAGE_BEDROCK_OUTPUT_TOKEN_METRIC,default=OutputTokenCount"`
+
+	// InvocationsMetric is the CloudWatch metric name of invocation counts
+	InvocationsMetric string `json:"invocations_metric,omitempty" env:"TOSAGE_BEDROCK_INVOCATIONS_METRIC,default=Invocations"`
+
+	// LatencyMetric is the CloudWatch metric name of invocation latency
+	LatencyMetric string `json:"latency_metric,omitempty" env:"TOSAGE_BEDROCK_LATENCY_METRIC,default=InvocationLatency"`
+
+	// ModelDimension is the CloudWatch dimension key identifying the model
+	ModelDimension string `json:"model_dimension,omitempty" env:"TOSAGE_BEDROCK_MODEL_DIMENSION,default=ModelId"`
+
+	// MetricBatchSize is the number of metric queries sent per CloudWatch GetMetricData request
+	// (1-500, CloudWatch's limit); lower it for accounts sensitive to rate limits
+	MetricBatchSize int `json:"metric_batch_size,omitempty" env:"TOSAGE_BEDROCK_METRIC_BATCH_SIZE,default=500"`
+
+	// IncludeModels limits per-model metrics to matching model IDs (globs or prefixes; all models when empty)
+	// Environment variable: TOSAGE_BEDROCK_INCLUDE_MODELS (comma-separated)
+	IncludeModels []string `json:"include_models,omitempty" env:"TOSAGE_BEDROCK_INCLUDE_MODELS"`
+
+	// ExcludeModels drops matching model IDs from per-model metrics (globs or prefixes)
+	// Environment variable: TOSAGE_BEDROCK_EXCLUDE_MODELS (comma-separated)
+	ExcludeModels []string `json:"exclude_models,omitempty" env:"TOSAGE_BEDROCK_EXCLUDE_MODELS"`
+
+	// HostLabel overrides the host label of tosage_bedrock_* metrics (unset means no host label)
+	HostLabel string `json:"host_label,omitempty" env:"TOSAGE_BEDROCK_HOST_LABEL"`
 }
 
 // VertexAIConfig holds Google Cloud Vertex AI integration configuration
@@ -87,10 +611,29 @@ type VertexAIConfig struct {
 	ServiceAccountKeyPath string `json:"service_account_key_path,omitempty" env:"TOSAGE_VERTEX_AI_SERVICE_ACCOUNT_KEY_PATH,default="`
 
 	// ServiceAccountKey is the service account key JSON content (optional)
-	ServiceAccountKey string `json:"service_account_key,omitempty" env:"TOSAGE_VERTEX_AI_SERVICE_ACCOUNT_KEY,default="`
+	ServiceAccountKey string `json:"service_account_key,omitempty" env:"TOSAGE_VERTEX_AI_SERVICE_ACCOUNT_KEY,default=" secret:"true"`
+
+	// ServiceAccountKeys are further service account keys, as inline JSON or file paths, tried in order
+	// after ServiceAccountKey and ServiceAccountKeyPath. The first key that obtains a token is used, so old
+	// and new keys can both be listed during a key rotation
+	ServiceAccountKeys []string `json:"service_account_keys,omitempty" env:"TOSAGE_VERTEX_AI_SERVICE_ACCOUNT_KEYS" secret:"true"`
 
 	// CollectionIntervalSec is how often to collect metrics in seconds
 	CollectionIntervalSec int `json:"collection_interval_seconds,omitempty" env:"TOSAGE_VERTEX_AI_COLLECTION_INTERVAL_SECONDS,default=600"`
+
+	// IngestionDelaySec ends usage queries this many seconds before now, because Cloud Monitoring
+	// makes Vertex AI samples visible up to four minutes late; a negative value queries up to now
+	IngestionDelaySec int `json:"ingestion_delay_seconds,omitempty" env:"TOSAGE_VERTEX_AI_INGESTION_DELAY_SECONDS,default=240"`
+
+	// HostLabel overrides the host label of tosage_vertex_ai_* metrics (unset means no host label)
+	HostLabel string `json:"host_label,omitempty" env:"TOSAGE_VERTEX_AI_HOST_LABEL"`
+
+	// RequestMetrics sends tosage_vertex_ai_request_count and tosage_vertex_ai_latency_ms
+	RequestMetrics bool `json:"request_metrics,omitempty" env:"TOSAGE_VERTEX_AI_REQUEST_METRICS"`
+
+	// RequestMetricsPerModel also sends tosage_vertex_ai_model_request_count and tosage_vertex_ai_model_latency_ms
+	// for each model with a model label
+	RequestMetricsPerModel bool `json:"request_metrics_per_model,omitempty" env:"TOSAGE_VERTEX_AI_REQUEST_METRICS_PER_MODEL"`
 }
 
 // DaemonConfig holds daemon mode configuration
@@ -107,6 +650,18 @@ type DaemonConfig struct {
 	// LogPath is the path for daemon log files
 	LogPath string `json:"log_path,omitempty" env:"TOSAGE_DAEMON_LOG_PATH"`
 
+	// LogMaxSizeMB is the size in megabytes at which the daemon log file is rotated (default: 10)
+	LogMaxSizeMB int `json:"log_max_size_mb,omitempty" env:"TOSAGE_DAEMON_LOG_MAX_SIZE_MB"`
+
+	// LogMaxBackups is the number of rotated log files kept (default: 5, 0 keeps all)
+	LogMaxBackups *int `json:"log_max_backups,omitempty" env:"TOSAGE_DAEMON_LOG_MAX_BACKUPS"`
+
+	// LogMaxAgeDays removes rotated log files older than this many days (default: 30, 0 keeps them regardless of age)
+	LogMaxAgeDays *int `json:"log_max_age_days,omitempty" env:"TOSAGE_DAEMON_LOG_MAX_AGE_DAYS"`
+
+	// LogCompress gzips rotated log files (default: true)
+	LogCompress *bool `json:"log_compress,omitempty" env:"TOSAGE_DAEMON_LOG_COMPRESS"`
+
 	// PidFile is the path for the daemon PID file
 	PidFile string `json:"pid_file,omitempty" env:"TOSAGE_DAEMON_PID_FILE"`
 }
@@ -120,7 +675,15 @@ type PromtailConfig struct {
 	Username string `json:"username" env:"TOSAGE_LOKI_USERNAME,required"`
 
 	// Password is the password for basic authentication
-	Password string `json:"password" env:"TOSAGE_LOKI_PASSWORD,required"`
+	Password string `json:"password" env:"TOSAGE_LOKI_PASSWORD,required" secret:"true"`
+
+	// PasswordFile is a file holding the Loki password. It takes precedence over
+	// Password and is re-read on SIGHUP.
+	PasswordFile string `json:"password_file,omitempty" env:"TOSAGE_LOKI_PASSWORD_FILE"`
+
+	// TokenFile is a file holding a bearer token sent instead of basic
+	// authentication. It is re-read on SIGHUP.
+	TokenFile string `json:"token_file,omitempty" env:"TOSAGE_LOKI_TOKEN_FILE"`
 
 	// BatchWaitSeconds is the time to wait before sending a batch
 	BatchWaitSeconds int `json:"batch_wait_seconds,omitempty" env:"TOSAGE_LOKI_BATCH_WAIT_SECONDS,default=1"`
@@ -130,6 +693,15 @@ type PromtailConfig struct {
 
 	// TimeoutSeconds is the timeout for sending logs
 	TimeoutSeconds int `json:"timeout_seconds,omitempty" env:"TOSAGE_LOKI_TIMEOUT_SECONDS,default=5"`
+
+	// Compress gzips each pushed batch (default: true)
+	Compress *bool `json:"compress,omitempty" env:"TOSAGE_LOKI_COMPRESS"`
+
+	// ClientCertPath is the PEM client certificate presented to Loki (mTLS)
+	ClientCertPath string `json:"client_cert_path,omitempty" env:"TOSAGE_LOKI_CLIENT_CERT_PATH"`
+
+	// ClientKeyPath is the PEM private key for ClientCertPath
+	ClientKeyPath string `json:"client_key_path,omitempty" env:"TOSAGE_LOKI_CLIENT_KEY_PATH"`
 }
 
 // LoggingConfig holds logging configuration
@@ -155,11 +727,69 @@ type CSVExportConfig struct {
 	// DefaultMetricTypes is the default comma-separated list of metric types to export
 	DefaultMetricTypes string `json:"default_metric_types,omitempty" env:"TOSAGE_CSV_EXPORT_DEFAULT_METRIC_TYPES,default=claude_code,cursor,bedrock,vertex_ai"`
 
-	// MaxExportDays is the maximum number of days allowed for export range
-	MaxExportDays int `json:"max_export_days,omitempty" env:"TOSAGE_CSV_EXPORT_MAX_EXPORT_DAYS,default=365"`
+	// MaxExportDays is the maximum number of days allowed for export range. The default of 366
+	// lets ytd cover a full leap year.
+	MaxExportDays int `json:"max_export_days,omitempty" env:"TOSAGE_CSV_EXPORT_MAX_EXPORT_DAYS,default=366"`
 
 	// TimeZone is the timezone to use for CSV export (IANA timezone)
 	TimeZone string `json:"timezone,omitempty" env:"TOSAGE_CSV_EXPORT_TIMEZONE,default=Asia/Tokyo"`
+
+	// HashProjectPaths writes project paths to the CSV as their stable hash. CSV export is local,
+	// so paths stay raw unless this is set, even with the top-level hash_project_paths.
+	HashProjectPaths bool `json:"hash_project_paths,omitempty" env:"TOSAGE_CSV_EXPORT_HASH_PROJECT_PATHS,default=false"`
+}
+
+// SummaryConfig holds the daily summary webhook configuration
+type SummaryConfig struct {
+	// Enabled indicates whether the daemon posts a daily summary
+	Enabled bool `json:"enabled,omitempty" env:"TOSAGE_SUMMARY_ENABLED,default=false"`
+
+	// WebhookURL is the URL the summary is POSTed to as JSON
+	WebhookURL string `json:"webhook_url,omitempty" env:"TOSAGE_SUMMARY_WEBHOOK_URL" secret:"true"`
+
+	// SendTime is the local time of day (HH:MM) at which the summary is sent
+	SendTime string `json:"send_time,omitempty" env:"TOSAGE_SUMMARY_SEND_TIME,default=18:00"`
+
+	// TopModels is the number of models listed in the summary
+	TopModels int `json:"top_models,omitempty" env:"TOSAGE_SUMMARY_TOP_MODELS,default=5"`
+
+	// MaxRetries is how many times a failed post is retried
+	MaxRetries int `json:"max_retries,omitempty" env:"TOSAGE_SUMMARY_MAX_RETRIES,default=3"`
+}
+
+// PostCollectionHookConfig holds the command run after each successful collection
+type PostCollectionHookConfig struct {
+	// Command is run with the shell after each successful collection in daemon mode (empty disables the hook).
+	// The totals are passed as TOSAGE_* environment variables and as JSON on stdin.
+	Command string `json:"command,omitempty" env:"TOSAGE_POST_COLLECTION_HOOK_COMMAND"`
+
+	// TimeoutSec is how long the command may run before it is killed
+	TimeoutSec int `json:"timeout_seconds,omitempty" env:"TOSAGE_POST_COLLECTION_HOOK_TIMEOUT_SECONDS,default=30"`
+}
+
+// ProfileConfig is a named set of providers and a metrics backend that the daemon runs
+// alongside the top-level configuration. Sections set here are merged over the top-level ones.
+type ProfileConfig struct {
+	// Name identifies the profile in logs and state files; letters, digits, '_' and '-' only
+	Name string `json:"name"`
+
+	// Prometheus holds the profile's metrics backend configuration
+	Prometheus *PrometheusConfig `json:"prometheus,omitempty"`
+
+	// Cursor holds the profile's Cursor configuration
+	Cursor *CursorConfig `json:"cursor,omitempty"`
+
+	// Bedrock holds the profile's AWS Bedrock configuration
+	Bedrock *BedrockConfig `json:"bedrock,omitempty"`
+
+	// VertexAI holds the profile's Google Cloud Vertex AI configuration
+	VertexAI *VertexAIConfig `json:"vertex_ai,omitempty"`
+
+	// DisableClaudeCode stops the profile from reporting Claude Code metrics
+	DisableClaudeCode bool `json:"disable_claude_code,omitempty"`
+
+	// DisableCursor stops the profile from reporting Cursor metrics
+	DisableCursor bool `json:"disable_cursor,omitempty"`
 }
 
 // ConfigSource represents the source of a configuration value
@@ -182,6 +812,79 @@ type AppConfig struct {
 	// ClaudePath is the custom path to Claude data directory
 	ClaudePath string `json:"claude_path,omitempty" env:"TOSAGE_CLAUDE_PATH"`
 
+	// RemoteSources are machines whose Claude Code data is read over SFTP and counted alongside
+	// the local data. Their files are mirrored to a local cache and only new data is downloaded.
+	RemoteSources []RemoteSourceConfig `json:"remote_sources,omitempty"`
+
+	// HashProjectPaths replaces project paths with a stable SHA-256 prefix in console and JSON output,
+	// session and source path labels. Paths stay raw internally so project filters keep matching.
+	HashProjectPaths bool `json:"hash_project_paths,omitempty" env:"TOSAGE_HASH_PROJECT_PATHS"`
+
+	// ExcludeModels lists Claude Code models to drop from totals, breakdowns and pushed metrics.
+	// Entries are glob patterns (e.g. "claude-*-embed") or model name prefixes.
+	ExcludeModels []string `json:"exclude_models,omitempty" env:"TOSAGE_EXCLUDE_MODELS"`
+
+	// TokenComponents lists the Claude Code token components counted in today's total, the tosage_cc_token
+	// metric and the CLI output: input, output, cache_creation and cache_read (default: all of them)
+	TokenComponents []string `json:"token_components,omitempty" env:"TOSAGE_TOKEN_COMPONENTS"`
+
+	// HeuristicDedup also treats Claude Code entries without a message ID as duplicates when their
+	// timestamp, session and token count match, e.g. copies written by different Claude versions.
+	// Entries with IDs are then only duplicates when both the message and request IDs match.
+	HeuristicDedup bool `json:"heuristic_dedup,omitempty" env:"TOSAGE_HEURISTIC_DEDUP"`
+
+	// IgnoreBeforeDate (YYYY-MM-DD, local time) drops Claude Code entries before this date when loading.
+	// Totals, metrics and exports, including all-time figures, only count entries from this date on.
+	IgnoreBeforeDate string `json:"ignore_before_date,omitempty" env:"TOSAGE_IGNORE_BEFORE_DATE"`
+
+	// ParseWorkers is the number of Claude Code JSONL files parsed concurrently (default: number of CPUs)
+	ParseWorkers int `json:"parse_workers,omitempty" env:"TOSAGE_PARSE_WORKERS"`
+
+	// MaxLineBytes is the longest Claude Code JSONL line read, in bytes (default: 10 MiB).
+	// Longer lines are skipped and reported once.
+	MaxLineBytes int `json:"max_line_bytes,omitempty" env:"TOSAGE_MAX_LINE_BYTES"`
+
+	// MaxEntryTokens caps the tokens a single Claude Code entry may report, guarding totals against
+	// corrupt data. Entries above it are handled per MaxEntryTokensAction (0 disables the cap)
+	MaxEntryTokens int `json:"max_entry_tokens,omitempty" env:"TOSAGE_MAX_ENTRY_TOKENS"`
+
+	// MaxEntryTokensAction is "skip" (default) to drop entries above MaxEntryTokens or "clamp"
+	// to scale their token counts down to the cap
+	MaxEntryTokensAction string `json:"max_entry_tokens_action,omitempty" env:"TOSAGE_MAX_ENTRY_TOKENS_ACTION"`
+
+	// WalkTimeoutSec bounds how long loading waits for a walk of the Claude directory. A slower walk
+	// falls back to the last good file listing and finishes in the background (0 waits for every walk)
+	WalkTimeoutSec int `json:"walk_timeout_seconds,omitempty" env:"TOSAGE_WALK_TIMEOUT_SECONDS"`
+
+	// ListingCacheSec is how long the Claude directory file listing is reused before walking it again.
+	// New sessions are picked up once it expires (0 walks the directory on every load)
+	ListingCacheSec int `json:"listing_cache_seconds,omitempty" env:"TOSAGE_LISTING_CACHE_SECONDS"`
+
+	// MaxDataStalenessSec skips the Claude Code push when the newest entry is older than this
+	// many seconds and sends tosage_cc_stale instead, so a broken data path doesn't publish
+	// old totals as current (0 disables the check)
+	MaxDataStalenessSec int `json:"max_data_staleness_seconds,omitempty" env:"TOSAGE_MAX_DATA_STALENESS_SECONDS"`
+
+	// DailyWindowMode defines the period "today" covers for every source: "calendar" (default)
+	// for the current calendar day, or "rolling24h" for the trailing 24 hours
+	DailyWindowMode string `json:"daily_window_mode,omitempty" env:"TOSAGE_DAILY_WINDOW_MODE"`
+
+	// UserAgent overrides the User-Agent header of outbound HTTP requests (default: tosage/<version>)
+	UserAgent string `json:"user_agent,omitempty" env:"TOSAGE_USER_AGENT"`
+
+	// MaxConcurrentRequests limits the outbound HTTP and gRPC requests in flight across all providers,
+	// so collection doesn't saturate a constrained connection (default: DefaultMaxConcurrentRequests)
+	MaxConcurrentRequests int `json:"max_concurrent_requests,omitempty" env:"TOSAGE_MAX_CONCURRENT_REQUESTS"`
+
+	// CostPrecision is the number of decimal places costs are printed with in the CLI output
+	// (default: 2 when unset). Raise it to see sub-cent amounts such as $0.0034, or set 0 for
+	// currencies without minor units such as JPY
+	CostPrecision *int `json:"cost_precision,omitempty" env:"TOSAGE_COST_PRECISION"`
+
+	// FailFastOnProviderInitError makes startup fail when an enabled provider (Bedrock, Vertex AI,
+	// a remote source or a profile) can't be initialized, instead of running without it
+	FailFastOnProviderInitError bool `json:"fail_fast_on_provider_init_error,omitempty" env:"TOSAGE_FAIL_FAST_ON_PROVIDER_INIT_ERROR"`
+
 	// Prometheus holds Prometheus integration configuration
 	Prometheus *PrometheusConfig `json:"prometheus,omitempty"`
 
@@ -203,6 +906,15 @@ type AppConfig struct {
 	// CSVExport holds CSV export configuration
 	CSVExport *CSVExportConfig `json:"csv_export,omitempty"`
 
+	// Summary holds the daily summary webhook configuration
+	Summary *SummaryConfig `json:"summary,omitempty"`
+
+	// PostCollectionHook holds the command run after each successful collection
+	PostCollectionHook *PostCollectionHookConfig `json:"post_collection_hook,omitempty"`
+
+	// Profiles are additional named provider/backend sets run by the daemon
+	Profiles []*ProfileConfig `json:"profiles,omitempty"`
+
 	// ConfigSources tracks the source of each configuration field
 	ConfigSources ConfigSourceMap `json:"-"`
 }
@@ -213,20 +925,39 @@ func DefaultConfig() *AppConfig {
 		Version:    1, // Current configuration version
 		ClaudePath: "",
 		Prometheus: &PrometheusConfig{
-			RemoteWriteURL:      "", // Empty by default, must be set via environment variable or config.json
-			RemoteWriteUsername: "",
-			RemoteWritePassword: "",
-			URL:                 "",
-			Username:            "",
-			Password:            "",
-			HostLabel:           "",
-			IntervalSec:         600, // 10 minutes
-			TimeoutSec:          30,
+			RemoteWriteURL:           "", // Empty by default, must be set via environment variable or config.json
+			RemoteWriteUsername:      "",
+			RemoteWritePassword:      "",
+			URL:                      "",
+			Username:                 "",
+			Password:                 "",
+			HostLabel:                "",
+			IntervalSec:              600, // 10 minutes
+			TimeoutSec:               30,
+			ProbeOnStartup:           boolPtr(true),
+			Compression:              CompressionSnappy,
+			AlignToInterval:          false,
+			CircuitBreakerThreshold:  5,
+			CircuitBreakerBackoffSec: 300,
+			HashSourcePaths:          boolPtr(true),
+			Schedule:                 "",
+			UniqueCountMetrics:       false,
 		},
 		Cursor: &CursorConfig{
-			DatabasePath: "",
-			APITimeout:   30,  // 30 seconds
-			CacheTimeout: 300, // 5 minutes
+			DatabasePath:            "",
+			APITimeout:              30,  // 30 seconds
+			CacheTimeout:            300, // 5 minutes
+			BillingDay:              DefaultCursorBillingDay,
+			HostLabel:               "",
+			PremiumRequestMetrics:   false,
+			BaseURL:                 DefaultCursorBaseURL,
+			TeamMemberMetrics:       false,
+			TeamMemberLimit:         DefaultCursorTeamMemberLimit,
+			UsageCostMetrics:        false,
+			CallCountMetrics:        false,
+			UsageBasedStatusMetrics: false,
+			BillingCycleMetric:      false,
+			HashTeamMembers:         boolPtr(true),
 		},
 		Bedrock: &BedrockConfig{
 			Enabled:               false, // Disabled by default for security
@@ -234,6 +965,15 @@ func DefaultConfig() *AppConfig {
 			AWSProfile:            "",
 			AssumeRoleARN:         "",
 			CollectionIntervalSec: 600, // 10 minutes
+			Namespace:             DefaultBedrockNamespace,
+			InputTokenMetric:      DefaultBedrockInputTokenMetric,
+			OutputTokenMetric:     DefaultBedrockOutputTokenMetric,
+			InvocationsMetric:     DefaultBedrockInvocationsMetric,
+			LatencyMetric:         DefaultBedrockLatencyMetric,
+			ModelDimension:        DefaultBedrockModelDimension,
+			HostLabel:             "",
+			MetricBatchSize:       DefaultBedrockMetricBatchSize,
+			IngestionDelaySec:     DefaultBedrockIngestionDelaySec,
 		},
 		VertexAI: &VertexAIConfig{
 			Enabled:               false, // Disabled by default for security
@@ -241,13 +981,19 @@ func DefaultConfig() *AppConfig {
 			ServiceAccountKeyPath: "",
 			ServiceAccountKey:     "",
 			CollectionIntervalSec: 600, // 10 minutes
+			HostLabel:             "",
+			IngestionDelaySec:     DefaultVertexAIIngestionDelaySec,
 		},
 		Daemon: &DaemonConfig{
-			Enabled:      false,
-			StartAtLogin: false,
-			HideFromDock: false,
-			LogPath:      "/tmp/tosage.log",
-			PidFile:      "/tmp/tosage.pid",
+			Enabled:       false,
+			StartAtLogin:  false,
+			HideFromDock:  false,
+			LogPath:       "/tmp/tosage.log",
+			PidFile:       "/tmp/tosage.pid",
+			LogMaxSizeMB:  DefaultDaemonLogMaxSizeMB,
+			LogMaxBackups: intPtr(DefaultDaemonLogMaxBackups),
+			LogMaxAgeDays: intPtr(DefaultDaemonLogMaxAgeDays),
+			LogCompress:   boolPtr(true),
 		},
 		Logging: &LoggingConfig{
 			Level: "info",
@@ -257,15 +1003,27 @@ func DefaultConfig() *AppConfig {
 				BatchWaitSeconds: 1,
 				BatchCapacity:    100,
 				TimeoutSeconds:   5,
+				Compress:         boolPtr(true),
 			},
 		},
 		CSVExport: &CSVExportConfig{
 			DefaultOutputPath:  ".",
 			DefaultStartDays:   30,
 			DefaultMetricTypes: "claude_code,cursor,bedrock,vertex_ai",
-			MaxExportDays:      365,
+			MaxExportDays:      366,
 			TimeZone:           "Asia/Tokyo",
 		},
+		Summary: &SummaryConfig{
+			Enabled:    false,
+			WebhookURL: "",
+			SendTime:   "18:00",
+			TopModels:  5,
+			MaxRetries: 3,
+		},
+		PostCollectionHook: &PostCollectionHookConfig{
+			Command:    "",
+			TimeoutSec: 30,
+		},
 		ConfigSources: make(ConfigSourceMap),
 	}
 }
@@ -301,7 +1059,7 @@ func MinimalDefaultConfig() *AppConfig {
 			DefaultOutputPath:  ".",
 			DefaultStartDays:   30,
 			DefaultMetricTypes: "claude_code,cursor",
-			MaxExportDays:      365,
+			MaxExportDays:      366,
 			TimeZone:           "Asia/Tokyo",
 		},
 		ConfigSources: make(ConfigSourceMap),
@@ -312,6 +1070,11 @@ func MinimalDefaultConfig() *AppConfig {
 func LoadConfig() (*AppConfig, error) {
 	config := DefaultConfig()
 
+	// Populate the environment from .env without overriding real variables
+	if _, err := LoadDotEnv(EnvFilePath()); err != nil {
+		return nil, fmt.Errorf("failed to load env file: %w", err)
+	}
+
 	// Load environment variables using Netflix/go-env
 	if err := config.LoadFromEnv(); err != nil {
 		return nil, fmt.Errorf("failed to load environment variables: %w", err)
@@ -329,26 +1092,90 @@ func LoadConfig() (*AppConfig, error) {
 func (c *AppConfig) LoadFromEnv() error {
 	// Store original values to detect changes
 	original := &AppConfig{
-		ClaudePath: c.ClaudePath,
+		ClaudePath:            c.ClaudePath,
+		RemoteSources:         c.RemoteSources,
+		HashProjectPaths:      c.HashProjectPaths,
+		ExcludeModels:         c.ExcludeModels,
+		TokenComponents:       c.TokenComponents,
+		HeuristicDedup:        c.HeuristicDedup,
+		UserAgent:             c.UserAgent,
+		IgnoreBeforeDate:      c.IgnoreBeforeDate,
+		ParseWorkers:          c.ParseWorkers,
+		WalkTimeoutSec:        c.WalkTimeoutSec,
+		ListingCacheSec:       c.ListingCacheSec,
+		MaxDataStalenessSec:   c.MaxDataStalenessSec,
+		DailyWindowMode:       c.DailyWindowMode,
+		MaxEntryTokens:        c.MaxEntryTokens,
+		MaxEntryTokensAction:  c.MaxEntryTokensAction,
+		MaxConcurrentRequests: c.MaxConcurrentRequests,
+		MaxLineBytes:          c.MaxLineBytes,
+		CostPrecision:         c.CostPrecision,
+
+		FailFastOnProviderInitError: c.FailFastOnProviderInitError,
 	}
 	if c.Prometheus != nil {
 		original.Prometheus = &PrometheusConfig{
-			RemoteWriteURL:      c.Prometheus.RemoteWriteURL,
-			RemoteWriteUsername: c.Prometheus.RemoteWriteUsername,
-			RemoteWritePassword: c.Prometheus.RemoteWritePassword,
-			URL:                 c.Prometheus.URL,
-			Username:            c.Prometheus.Username,
-			Password:            c.Prometheus.Password,
-			HostLabel:           c.Prometheus.HostLabel,
-			IntervalSec:         c.Prometheus.IntervalSec,
-			TimeoutSec:          c.Prometheus.TimeoutSec,
+			RemoteWriteURL:           c.Prometheus.RemoteWriteURL,
+			RemoteWriteUsername:      c.Prometheus.RemoteWriteUsername,
+			RemoteWritePassword:      c.Prometheus.RemoteWritePassword,
+			URL:                      c.Prometheus.URL,
+			Username:                 c.Prometheus.Username,
+			Password:                 c.Prometheus.Password,
+			HostLabel:                c.Prometheus.HostLabel,
+			IntervalSec:              c.Prometheus.IntervalSec,
+			TimeoutSec:               c.Prometheus.TimeoutSec,
+			ScrapeListenAddress:      c.Prometheus.ScrapeListenAddress,
+			StateFilePath:            c.Prometheus.StateFilePath,
+			ProbeOnStartup:           c.Prometheus.ProbeOnStartup,
+			Compression:              c.Prometheus.Compression,
+			Transforms:               c.Prometheus.Transforms,
+			ExtraLabels:              c.Prometheus.ExtraLabels,
+			DerivedLabels:            c.Prometheus.DerivedLabels,
+			Backends:                 c.Prometheus.Backends,
+			ClientCertPath:           c.Prometheus.ClientCertPath,
+			ClientKeyPath:            c.Prometheus.ClientKeyPath,
+			AlignToInterval:          c.Prometheus.AlignToInterval,
+			CircuitBreakerThreshold:  c.Prometheus.CircuitBreakerThreshold,
+			CircuitBreakerBackoffSec: c.Prometheus.CircuitBreakerBackoffSec,
+			SessionMetricsTopN:       c.Prometheus.SessionMetricsTopN,
+			HashSessionIDs:           c.Prometheus.HashSessionIDs,
+			InitialDelaySec:          c.Prometheus.InitialDelaySec,
+			NetworkWaitSec:           c.Prometheus.NetworkWaitSec,
+			SourceIntervalSec:        c.Prometheus.SourceIntervalSec,
+			CcTokensDelta:            c.Prometheus.CcTokensDelta,
+			MetricAllowlist:          c.Prometheus.MetricAllowlist,
+			MetricDenylist:           c.Prometheus.MetricDenylist,
+			SourcePathLabel:          c.Prometheus.SourcePathLabel,
+			HashSourcePaths:          c.Prometheus.HashSourcePaths,
+			SessionPercentiles:       c.Prometheus.SessionPercentiles,
+			RemoteWritePasswordFile:  c.Prometheus.RemoteWritePasswordFile,
+			RemoteWriteTokenFile:     c.Prometheus.RemoteWriteTokenFile,
+			Schedule:                 c.Prometheus.Schedule,
+			UniqueCountMetrics:       c.Prometheus.UniqueCountMetrics,
+			CloudMetadataLabels:      c.Prometheus.CloudMetadataLabels,
+			ScrapeRefreshAfterSec:    c.Prometheus.ScrapeRefreshAfterSec,
 		}
 	}
 	if c.Cursor != nil {
 		original.Cursor = &CursorConfig{
-			DatabasePath: c.Cursor.DatabasePath,
-			APITimeout:   c.Cursor.APITimeout,
-			CacheTimeout: c.Cursor.CacheTimeout,
+			DatabasePath:            c.Cursor.DatabasePath,
+			APITimeout:              c.Cursor.APITimeout,
+			CacheTimeout:            c.Cursor.CacheTimeout,
+			BillingDay:              c.Cursor.BillingDay,
+			DayStartHour:            c.Cursor.DayStartHour,
+			HostLabel:               c.Cursor.HostLabel,
+			PremiumRequestMetrics:   c.Cursor.PremiumRequestMetrics,
+			BaseURL:                 c.Cursor.BaseURL,
+			TeamMemberMetrics:       c.Cursor.TeamMemberMetrics,
+			TeamMembers:             c.Cursor.TeamMembers,
+			TeamMemberLimit:         c.Cursor.TeamMemberLimit,
+			UsageCostMetrics:        c.Cursor.UsageCostMetrics,
+			CallCountMetrics:        c.Cursor.CallCountMetrics,
+			RateLimit:               c.Cursor.RateLimit,
+			RateLimitBurst:          c.Cursor.RateLimitBurst,
+			UsageBasedStatusMetrics: c.Cursor.UsageBasedStatusMetrics,
+			BillingCycleMetric:      c.Cursor.BillingCycleMetric,
+			HashTeamMembers:         c.Cursor.HashTeamMembers,
 		}
 	}
 	if c.Bedrock != nil {
@@ -358,24 +1185,45 @@ func (c *AppConfig) LoadFromEnv() error {
 			AWSProfile:            c.Bedrock.AWSProfile,
 			AssumeRoleARN:         c.Bedrock.AssumeRoleARN,
 			CollectionIntervalSec: c.Bedrock.CollectionIntervalSec,
+			Namespace:             c.Bedrock.Namespace,
+			InputTokenMetric:      c.Bedrock.InputTokenMetric,
+			OutputTokenMetric:     c.Bedrock.OutputTokenMetric,
+			InvocationsMetric:     c.Bedrock.InvocationsMetric,
+			LatencyMetric:         c.Bedrock.LatencyMetric,
+			ModelDimension:        c.Bedrock.ModelDimension,
+			IncludeModels:         c.Bedrock.IncludeModels,
+			ExcludeModels:         c.Bedrock.ExcludeModels,
+			HostLabel:             c.Bedrock.HostLabel,
+			AllowUnknownRegions:   c.Bedrock.AllowUnknownRegions,
+			MetricBatchSize:       c.Bedrock.MetricBatchSize,
+			IngestionDelaySec:     c.Bedrock.IngestionDelaySec,
 		}
 	}
 	if c.VertexAI != nil {
 		original.VertexAI = &VertexAIConfig{
-			Enabled:               c.VertexAI.Enabled,
-			ProjectID:             c.VertexAI.ProjectID,
-			ServiceAccountKeyPath: c.VertexAI.ServiceAccountKeyPath,
-			ServiceAccountKey:     c.VertexAI.ServiceAccountKey,
-			CollectionIntervalSec: c.VertexAI.CollectionIntervalSec,
+			Enabled:                c.VertexAI.Enabled,
+			ProjectID:              c.VertexAI.ProjectID,
+			ServiceAccountKeyPath:  c.VertexAI.ServiceAccountKeyPath,
+			ServiceAccountKey:      c.VertexAI.ServiceAccountKey,
+			CollectionIntervalSec:  c.VertexAI.CollectionIntervalSec,
+			HostLabel:              c.VertexAI.HostLabel,
+			ServiceAccountKeys:     c.VertexAI.ServiceAccountKeys,
+			RequestMetrics:         c.VertexAI.RequestMetrics,
+			RequestMetricsPerModel: c.VertexAI.RequestMetricsPerModel,
+			IngestionDelaySec:      c.VertexAI.IngestionDelaySec,
 		}
 	}
 	if c.Daemon != nil {
 		original.Daemon = &DaemonConfig{
-			Enabled:      c.Daemon.Enabled,
-			StartAtLogin: c.Daemon.StartAtLogin,
-			HideFromDock: c.Daemon.HideFromDock,
-			LogPath:      c.Daemon.LogPath,
-			PidFile:      c.Daemon.PidFile,
+			Enabled:       c.Daemon.Enabled,
+			StartAtLogin:  c.Daemon.StartAtLogin,
+			HideFromDock:  c.Daemon.HideFromDock,
+			LogPath:       c.Daemon.LogPath,
+			PidFile:       c.Daemon.PidFile,
+			LogMaxSizeMB:  c.Daemon.LogMaxSizeMB,
+			LogMaxBackups: c.Daemon.LogMaxBackups,
+			LogMaxAgeDays: c.Daemon.LogMaxAgeDays,
+			LogCompress:   c.Daemon.LogCompress,
 		}
 	}
 	if c.Logging != nil {
@@ -391,6 +1239,11 @@ func (c *AppConfig) LoadFromEnv() error {
 				BatchWaitSeconds: c.Logging.Promtail.BatchWaitSeconds,
 				BatchCapacity:    c.Logging.Promtail.BatchCapacity,
 				TimeoutSeconds:   c.Logging.Promtail.TimeoutSeconds,
+				ClientCertPath:   c.Logging.Promtail.ClientCertPath,
+				ClientKeyPath:    c.Logging.Promtail.ClientKeyPath,
+				Compress:         c.Logging.Promtail.Compress,
+				PasswordFile:     c.Logging.Promtail.PasswordFile,
+				TokenFile:        c.Logging.Promtail.TokenFile,
 			}
 		}
 	}
@@ -401,6 +1254,22 @@ func (c *AppConfig) LoadFromEnv() error {
 			DefaultMetricTypes: c.CSVExport.DefaultMetricTypes,
 			MaxExportDays:      c.CSVExport.MaxExportDays,
 			TimeZone:           c.CSVExport.TimeZone,
+			HashProjectPaths:   c.CSVExport.HashProjectPaths,
+		}
+	}
+	if c.Summary != nil {
+		original.Summary = &SummaryConfig{
+			Enabled:    c.Summary.Enabled,
+			WebhookURL: c.Summary.WebhookURL,
+			SendTime:   c.Summary.SendTime,
+			TopModels:  c.Summary.TopModels,
+			MaxRetries: c.Summary.MaxRetries,
+		}
+	}
+	if c.PostCollectionHook != nil {
+		original.PostCollectionHook = &PostCollectionHookConfig{
+			Command:    c.PostCollectionHook.Command,
+			TimeoutSec: c.PostCollectionHook.TimeoutSec,
 		}
 	}
 
@@ -414,6 +1283,61 @@ func (c *AppConfig) LoadFromEnv() error {
 	if c.ClaudePath != original.ClaudePath && os.Getenv("TOSAGE_CLAUDE_PATH") != "" {
 		c.ConfigSources["ClaudePath"] = SourceEnvironment
 	}
+	if c.HashProjectPaths != original.HashProjectPaths && os.Getenv("TOSAGE_HASH_PROJECT_PATHS") != "" {
+		c.ConfigSources["HashProjectPaths"] = SourceEnvironment
+	}
+	// Custom handling for ExcludeModels slice
+	if excludeEnv := os.Getenv("TOSAGE_EXCLUDE_MODELS"); excludeEnv != "" {
+		c.ExcludeModels = splitCommaSeparated(excludeEnv)
+		c.ConfigSources["ExcludeModels"] = SourceEnvironment
+	}
+	// Custom handling for TokenComponents slice
+	if componentsEnv := os.Getenv("TOSAGE_TOKEN_COMPONENTS"); componentsEnv != "" {
+		c.TokenComponents = splitCommaSeparated(componentsEnv)
+		c.ConfigSources["TokenComponents"] = SourceEnvironment
+	}
+	if c.HeuristicDedup != original.HeuristicDedup && os.Getenv("TOSAGE_HEURISTIC_DEDUP") != "" {
+		c.ConfigSources["HeuristicDedup"] = SourceEnvironment
+	}
+	if c.UserAgent != original.UserAgent && os.Getenv("TOSAGE_USER_AGENT") != "" {
+		c.ConfigSources["UserAgent"] = SourceEnvironment
+	}
+	if c.IgnoreBeforeDate != original.IgnoreBeforeDate && os.Getenv("TOSAGE_IGNORE_BEFORE_DATE") != "" {
+		c.ConfigSources["IgnoreBeforeDate"] = SourceEnvironment
+	}
+	if c.ParseWorkers != original.ParseWorkers && os.Getenv("TOSAGE_PARSE_WORKERS") != "" {
+		c.ConfigSources["ParseWorkers"] = SourceEnvironment
+	}
+	if c.WalkTimeoutSec != original.WalkTimeoutSec && os.Getenv("TOSAGE_WALK_TIMEOUT_SECONDS") != "" {
+		c.ConfigSources["WalkTimeoutSec"] = SourceEnvironment
+	}
+	if c.ListingCacheSec != original.ListingCacheSec && os.Getenv("TOSAGE_LISTING_CACHE_SECONDS") != "" {
+		c.ConfigSources["ListingCacheSec"] = SourceEnvironment
+	}
+	if c.MaxDataStalenessSec != original.MaxDataStalenessSec && os.Getenv("TOSAGE_MAX_DATA_STALENESS_SECONDS") != "" {
+		c.ConfigSources["MaxDataStalenessSec"] = SourceEnvironment
+	}
+	if c.DailyWindowMode != original.DailyWindowMode && os.Getenv("TOSAGE_DAILY_WINDOW_MODE") != "" {
+		c.ConfigSources["DailyWindowMode"] = SourceEnvironment
+	}
+	if c.MaxEntryTokens != original.MaxEntryTokens && os.Getenv("TOSAGE_MAX_ENTRY_TOKENS") != "" {
+		c.ConfigSources["MaxEntryTokens"] = SourceEnvironment
+	}
+	if c.MaxEntryTokensAction != original.MaxEntryTokensAction && os.Getenv("TOSAGE_MAX_ENTRY_TOKENS_ACTION") != "" {
+		c.ConfigSources["MaxEntryTokensAction"] = SourceEnvironment
+	}
+	if c.MaxConcurrentRequests != original.MaxConcurrentRequests && os.Getenv("TOSAGE_MAX_CONCURRENT_REQUESTS") != "" {
+		c.ConfigSources["MaxConcurrentRequests"] = SourceEnvironment
+	}
+	if c.MaxLineBytes != original.MaxLineBytes && os.Getenv("TOSAGE_MAX_LINE_BYTES") != "" {
+		c.ConfigSources["MaxLineBytes"] = SourceEnvironment
+	}
+	if c.CostPrecision != nil && os.Getenv("TOSAGE_COST_PRECISION") != "" {
+		c.ConfigSources["CostPrecision"] = SourceEnvironment
+	}
+	if c.FailFastOnProviderInitError != original.FailFastOnProviderInitError && os.Getenv("TOSAGE_FAIL_FAST_ON_PROVIDER_INIT_ERROR") != "" {
+		c.ConfigSources["FailFastOnProviderInitError"] = SourceEnvironment
+	}
 
 	// Special handling for Prometheus nested struct
 	if c.Prometheus != nil {
@@ -421,6 +1345,16 @@ func (c *AppConfig) LoadFromEnv() error {
 		if err != nil {
 			return fmt.Errorf("failed to unmarshal Prometheus environment variables: %w", err)
 		}
+		// Custom handling for DerivedLabels slice
+		if derivedEnv := os.Getenv("TOSAGE_PROMETHEUS_DERIVED_LABELS"); derivedEnv != "" {
+			c.Prometheus.DerivedLabels = splitCommaSeparated(derivedEnv)
+		}
+		if allowEnv := os.Getenv("TOSAGE_PROMETHEUS_METRIC_ALLOWLIST"); allowEnv != "" {
+			c.Prometheus.MetricAllowlist = splitCommaSeparated(allowEnv)
+		}
+		if denyEnv := os.Getenv("TOSAGE_PROMETHEUS_METRIC_DENYLIST"); denyEnv != "" {
+			c.Prometheus.MetricDenylist = splitCommaSeparated(denyEnv)
+		}
 		c.trackPrometheusEnvOverrides(original.Prometheus)
 	}
 
@@ -430,6 +1364,9 @@ func (c *AppConfig) LoadFromEnv() error {
 		if err != nil {
 			return fmt.Errorf("failed to unmarshal Cursor environment variables: %w", err)
 		}
+		if membersEnv := os.Getenv("TOSAGE_CURSOR_TEAM_MEMBERS"); membersEnv != "" {
+			c.Cursor.TeamMembers = splitCommaSeparated(membersEnv)
+		}
 		c.trackCursorEnvOverrides(original.Cursor)
 	}
 
@@ -443,6 +1380,12 @@ func (c *AppConfig) LoadFromEnv() error {
 		if regionsEnv := os.Getenv("TOSAGE_BEDROCK_REGIONS"); regionsEnv != "" {
 			c.Bedrock.Regions = splitCommaSeparated(regionsEnv)
 		}
+		if includeEnv := os.Getenv("TOSAGE_BEDROCK_INCLUDE_MODELS"); includeEnv != "" {
+			c.Bedrock.IncludeModels = splitCommaSeparated(includeEnv)
+		}
+		if excludeEnv := os.Getenv("TOSAGE_BEDROCK_EXCLUDE_MODELS"); excludeEnv != "" {
+			c.Bedrock.ExcludeModels = splitCommaSeparated(excludeEnv)
+		}
 		c.trackBedrockEnvOverrides(original.Bedrock)
 	}
 
@@ -460,6 +1403,9 @@ func (c *AppConfig) LoadFromEnv() error {
 			}
 			c.VertexAI.ServiceAccountKey = string(decodedKey)
 		}
+		if keysEnv := os.Getenv("TOSAGE_VERTEX_AI_SERVICE_ACCOUNT_KEYS"); keysEnv != "" {
+			c.VertexAI.ServiceAccountKeys = splitCommaSeparated(keysEnv)
+		}
 		c.trackVertexAIEnvOverrides(original.VertexAI)
 	}
 
@@ -501,6 +1447,24 @@ func (c *AppConfig) LoadFromEnv() error {
 		c.trackCSVExportEnvOverrides(original.CSVExport)
 	}
 
+	// Special handling for Summary nested struct
+	if c.Summary != nil {
+		_, err = env.UnmarshalFromEnviron(c.Summary)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal Summary environment variables: %w", err)
+		}
+		c.trackSummaryEnvOverrides(original.Summary)
+	}
+
+	// Special handling for PostCollectionHook nested struct
+	if c.PostCollectionHook != nil {
+		_, err = env.UnmarshalFromEnviron(c.PostCollectionHook)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal PostCollectionHook environment variables: %w", err)
+		}
+		c.trackPostCollectionHookEnvOverrides(original.PostCollectionHook)
+	}
+
 	return nil
 }
 
@@ -508,10 +1472,10 @@ func (c *AppConfig) LoadFromEnv() error {
 func (c *AppConfig) trackPrometheusEnvOverrides(original *PrometheusConfig) {
 	// Debug: Log what we're tracking
 	if os.Getenv("TOSAGE_DEBUG") == "true" {
-		fmt.Fprintf(os.Stderr, "Debug: trackPrometheusEnvOverrides called\n")
-		fmt.Fprintf(os.Stderr, "Debug: ENV TOSAGE_PROMETHEUS_REMOTE_WRITE_URL='%s'\n", os.Getenv("TOSAGE_PROMETHEUS_REMOTE_WRITE_URL"))
-		fmt.Fprintf(os.Stderr, "Debug: Config RemoteWriteURL='%s'\n", c.Prometheus.RemoteWriteURL)
-		fmt.Fprintf(os.Stderr, "Debug: Original RemoteWriteURL='%s'\n", original.RemoteWriteURL)
+		Debugf("Debug: trackPrometheusEnvOverrides called\n")
+		Debugf("Debug: ENV TOSAGE_PROMETHEUS_REMOTE_WRITE_URL='%s'\n", os.Getenv("TOSAGE_PROMETHEUS_REMOTE_WRITE_URL"))
+		Debugf("Debug: Config RemoteWriteURL='%s'\n", c.Prometheus.RemoteWriteURL)
+		Debugf("Debug: Original RemoteWriteURL='%s'\n", original.RemoteWriteURL)
 	}
 	if original == nil {
 		return
@@ -540,9 +1504,87 @@ func (c *AppConfig) trackPrometheusEnvOverrides(original *PrometheusConfig) {
 	if c.Prometheus.IntervalSec != original.IntervalSec && os.Getenv("TOSAGE_PROMETHEUS_INTERVAL_SECONDS") != "" {
 		c.ConfigSources["Prometheus.IntervalSec"] = SourceEnvironment
 	}
+	if c.Prometheus.AlignToInterval != original.AlignToInterval && os.Getenv("TOSAGE_PROMETHEUS_ALIGN_TO_INTERVAL") != "" {
+		c.ConfigSources["Prometheus.AlignToInterval"] = SourceEnvironment
+	}
+	if !slicesEqual(c.Prometheus.DerivedLabels, original.DerivedLabels) && os.Getenv("TOSAGE_PROMETHEUS_DERIVED_LABELS") != "" {
+		c.ConfigSources["Prometheus.DerivedLabels"] = SourceEnvironment
+	}
 	if c.Prometheus.TimeoutSec != original.TimeoutSec && os.Getenv("TOSAGE_PROMETHEUS_TIMEOUT_SECONDS") != "" {
 		c.ConfigSources["Prometheus.TimeoutSec"] = SourceEnvironment
 	}
+	if c.Prometheus.ScrapeListenAddress != original.ScrapeListenAddress && os.Getenv("TOSAGE_PROMETHEUS_SCRAPE_LISTEN_ADDRESS") != "" {
+		c.ConfigSources["Prometheus.ScrapeListenAddress"] = SourceEnvironment
+	}
+	if c.Prometheus.StateFilePath != original.StateFilePath && os.Getenv("TOSAGE_PROMETHEUS_STATE_FILE_PATH") != "" {
+		c.ConfigSources["Prometheus.StateFilePath"] = SourceEnvironment
+	}
+	if os.Getenv("TOSAGE_PROMETHEUS_PROBE_ON_STARTUP") != "" {
+		c.ConfigSources["Prometheus.ProbeOnStartup"] = SourceEnvironment
+	}
+	if c.Prometheus.Compression != original.Compression && os.Getenv("TOSAGE_PROMETHEUS_COMPRESSION") != "" {
+		c.ConfigSources["Prometheus.Compression"] = SourceEnvironment
+	}
+	if c.Prometheus.ClientCertPath != original.ClientCertPath && os.Getenv("TOSAGE_PROMETHEUS_CLIENT_CERT_PATH") != "" {
+		c.ConfigSources["Prometheus.ClientCertPath"] = SourceEnvironment
+	}
+	if c.Prometheus.ClientKeyPath != original.ClientKeyPath && os.Getenv("TOSAGE_PROMETHEUS_CLIENT_KEY_PATH") != "" {
+		c.ConfigSources["Prometheus.ClientKeyPath"] = SourceEnvironment
+	}
+	if c.Prometheus.CircuitBreakerThreshold != original.CircuitBreakerThreshold && os.Getenv("TOSAGE_PROMETHEUS_CIRCUIT_BREAKER_THRESHOLD") != "" {
+		c.ConfigSources["Prometheus.CircuitBreakerThreshold"] = SourceEnvironment
+	}
+	if c.Prometheus.CircuitBreakerBackoffSec != original.CircuitBreakerBackoffSec && os.Getenv("TOSAGE_PROMETHEUS_CIRCUIT_BREAKER_BACKOFF_SECONDS") != "" {
+		c.ConfigSources["Prometheus.CircuitBreakerBackoffSec"] = SourceEnvironment
+	}
+	if c.Prometheus.SessionMetricsTopN != original.SessionMetricsTopN && os.Getenv("TOSAGE_PROMETHEUS_SESSION_METRICS_TOP_N") != "" {
+		c.ConfigSources["Prometheus.SessionMetricsTopN"] = SourceEnvironment
+	}
+	if c.Prometheus.HashSessionIDs != original.HashSessionIDs && os.Getenv("TOSAGE_PROMETHEUS_HASH_SESSION_IDS") != "" {
+		c.ConfigSources["Prometheus.HashSessionIDs"] = SourceEnvironment
+	}
+	if c.Prometheus.InitialDelaySec != original.InitialDelaySec && os.Getenv("TOSAGE_PROMETHEUS_INITIAL_DELAY_SECONDS") != "" {
+		c.ConfigSources["Prometheus.InitialDelaySec"] = SourceEnvironment
+	}
+	if c.Prometheus.NetworkWaitSec != original.NetworkWaitSec && os.Getenv("TOSAGE_PROMETHEUS_NETWORK_WAIT_SECONDS") != "" {
+		c.ConfigSources["Prometheus.NetworkWaitSec"] = SourceEnvironment
+	}
+	if c.Prometheus.CcTokensDelta != original.CcTokensDelta && os.Getenv("TOSAGE_PROMETHEUS_CC_TOKENS_DELTA") != "" {
+		c.ConfigSources["Prometheus.CcTokensDelta"] = SourceEnvironment
+	}
+	if !slicesEqual(c.Prometheus.MetricAllowlist, original.MetricAllowlist) && os.Getenv("TOSAGE_PROMETHEUS_METRIC_ALLOWLIST") != "" {
+		c.ConfigSources["Prometheus.MetricAllowlist"] = SourceEnvironment
+	}
+	if !slicesEqual(c.Prometheus.MetricDenylist, original.MetricDenylist) && os.Getenv("TOSAGE_PROMETHEUS_METRIC_DENYLIST") != "" {
+		c.ConfigSources["Prometheus.MetricDenylist"] = SourceEnvironment
+	}
+	if c.Prometheus.SourcePathLabel != original.SourcePathLabel && os.Getenv("TOSAGE_PROMETHEUS_SOURCE_PATH_LABEL") != "" {
+		c.ConfigSources["Prometheus.SourcePathLabel"] = SourceEnvironment
+	}
+	if os.Getenv("TOSAGE_PROMETHEUS_HASH_SOURCE_PATHS") != "" {
+		c.ConfigSources["Prometheus.HashSourcePaths"] = SourceEnvironment
+	}
+	if c.Prometheus.SessionPercentiles != original.SessionPercentiles && os.Getenv("TOSAGE_PROMETHEUS_SESSION_PERCENTILES") != "" {
+		c.ConfigSources["Prometheus.SessionPercentiles"] = SourceEnvironment
+	}
+	if c.Prometheus.RemoteWritePasswordFile != original.RemoteWritePasswordFile && os.Getenv("TOSAGE_PROMETHEUS_REMOTE_WRITE_PASSWORD_FILE") != "" {
+		c.ConfigSources["Prometheus.RemoteWritePasswordFile"] = SourceEnvironment
+	}
+	if c.Prometheus.RemoteWriteTokenFile != original.RemoteWriteTokenFile && os.Getenv("TOSAGE_PROMETHEUS_REMOTE_WRITE_TOKEN_FILE") != "" {
+		c.ConfigSources["Prometheus.RemoteWriteTokenFile"] = SourceEnvironment
+	}
+	if c.Prometheus.Schedule != original.Schedule && os.Getenv("TOSAGE_PROMETHEUS_SCHEDULE") != "" {
+		c.ConfigSources["Prometheus.Schedule"] = SourceEnvironment
+	}
+	if c.Prometheus.UniqueCountMetrics != original.UniqueCountMetrics && os.Getenv("TOSAGE_PROMETHEUS_UNIQUE_COUNT_METRICS") != "" {
+		c.ConfigSources["Prometheus.UniqueCountMetrics"] = SourceEnvironment
+	}
+	if c.Prometheus.CloudMetadataLabels != original.CloudMetadataLabels && os.Getenv("TOSAGE_PROMETHEUS_CLOUD_METADATA_LABELS") != "" {
+		c.ConfigSources["Prometheus.CloudMetadataLabels"] = SourceEnvironment
+	}
+	if c.Prometheus.ScrapeRefreshAfterSec != original.ScrapeRefreshAfterSec && os.Getenv("TOSAGE_PROMETHEUS_SCRAPE_REFRESH_AFTER_SECONDS") != "" {
+		c.ConfigSources["Prometheus.ScrapeRefreshAfterSec"] = SourceEnvironment
+	}
 }
 
 // trackCursorEnvOverrides tracks environment variable overrides for Cursor config
@@ -559,6 +1601,51 @@ func (c *AppConfig) trackCursorEnvOverrides(original *CursorConfig) {
 	if c.Cursor.CacheTimeout != original.CacheTimeout && os.Getenv("TOSAGE_CURSOR_CACHE_TIMEOUT") != "" {
 		c.ConfigSources["Cursor.CacheTimeout"] = SourceEnvironment
 	}
+	if c.Cursor.BillingDay != original.BillingDay && os.Getenv("TOSAGE_CURSOR_BILLING_DAY") != "" {
+		c.ConfigSources["Cursor.BillingDay"] = SourceEnvironment
+	}
+	if c.Cursor.DayStartHour != original.DayStartHour && os.Getenv("TOSAGE_CURSOR_DAY_START_HOUR") != "" {
+		c.ConfigSources["Cursor.DayStartHour"] = SourceEnvironment
+	}
+	if c.Cursor.HostLabel != original.HostLabel && os.Getenv("TOSAGE_CURSOR_HOST_LABEL") != "" {
+		c.ConfigSources["Cursor.HostLabel"] = SourceEnvironment
+	}
+	if c.Cursor.PremiumRequestMetrics != original.PremiumRequestMetrics && os.Getenv("TOSAGE_CURSOR_PREMIUM_REQUEST_METRICS") != "" {
+		c.ConfigSources["Cursor.PremiumRequestMetrics"] = SourceEnvironment
+	}
+	if c.Cursor.BaseURL != original.BaseURL && os.Getenv("TOSAGE_CURSOR_BASE_URL") != "" {
+		c.ConfigSources["Cursor.BaseURL"] = SourceEnvironment
+	}
+	if c.Cursor.TeamMemberMetrics != original.TeamMemberMetrics && os.Getenv("TOSAGE_CURSOR_TEAM_MEMBER_METRICS") != "" {
+		c.ConfigSources["Cursor.TeamMemberMetrics"] = SourceEnvironment
+	}
+	if !slicesEqual(c.Cursor.TeamMembers, original.TeamMembers) && os.Getenv("TOSAGE_CURSOR_TEAM_MEMBERS") != "" {
+		c.ConfigSources["Cursor.TeamMembers"] = SourceEnvironment
+	}
+	if c.Cursor.TeamMemberLimit != original.TeamMemberLimit && os.Getenv("TOSAGE_CURSOR_TEAM_MEMBER_LIMIT") != "" {
+		c.ConfigSources["Cursor.TeamMemberLimit"] = SourceEnvironment
+	}
+	if c.Cursor.UsageCostMetrics != original.UsageCostMetrics && os.Getenv("TOSAGE_CURSOR_USAGE_COST_METRICS") != "" {
+		c.ConfigSources["Cursor.UsageCostMetrics"] = SourceEnvironment
+	}
+	if c.Cursor.CallCountMetrics != original.CallCountMetrics && os.Getenv("TOSAGE_CURSOR_CALL_COUNT_METRICS") != "" {
+		c.ConfigSources["Cursor.CallCountMetrics"] = SourceEnvironment
+	}
+	if c.Cursor.RateLimit != original.RateLimit && os.Getenv("TOSAGE_CURSOR_RATE_LIMIT") != "" {
+		c.ConfigSources["Cursor.RateLimit"] = SourceEnvironment
+	}
+	if c.Cursor.RateLimitBurst != original.RateLimitBurst && os.Getenv("TOSAGE_CURSOR_RATE_LIMIT_BURST") != "" {
+		c.ConfigSources["Cursor.RateLimitBurst"] = SourceEnvironment
+	}
+	if c.Cursor.UsageBasedStatusMetrics != original.UsageBasedStatusMetrics && os.Getenv("TOSAGE_CURSOR_USAGE_BASED_STATUS_METRICS") != "" {
+		c.ConfigSources["Cursor.UsageBasedStatusMetrics"] = SourceEnvironment
+	}
+	if c.Cursor.BillingCycleMetric != original.BillingCycleMetric && os.Getenv("TOSAGE_CURSOR_BILLING_CYCLE_METRIC") != "" {
+		c.ConfigSources["Cursor.BillingCycleMetric"] = SourceEnvironment
+	}
+	if os.Getenv("TOSAGE_CURSOR_HASH_TEAM_MEMBERS") != "" {
+		c.ConfigSources["Cursor.HashTeamMembers"] = SourceEnvironment
+	}
 }
 
 // trackBedrockEnvOverrides tracks environment variable overrides for Bedrock config
@@ -582,6 +1669,42 @@ func (c *AppConfig) trackBedrockEnvOverrides(original *BedrockConfig) {
 	if !slicesEqual(c.Bedrock.Regions, original.Regions) && os.Getenv("TOSAGE_BEDROCK_REGIONS") != "" {
 		c.ConfigSources["Bedrock.Regions"] = SourceEnvironment
 	}
+	if c.Bedrock.Namespace != original.Namespace && os.Getenv("TOSAGE_BEDROCK_NAMESPACE") != "" {
+		c.ConfigSources["Bedrock.Namespace"] = SourceEnvironment
+	}
+	if c.Bedrock.InputTokenMetric != original.InputTokenMetric && os.Getenv("TOSAGE_BEDROCK_INPUT_TOKEN_METRIC") != "" {
+		c.ConfigSources["Bedrock.InputTokenMetric"] = SourceEnvironment
+	}
+	if c.Bedrock.OutputTokenMetric != original.OutputTokenMetric && os.Getenv("TOSAGE_BEDROCK_OUTPUT_TOKEN_METRIC") != "" {
+		c.ConfigSources["Bedrock.OutputTokenMetric"] = SourceEnvironment
+	}
+	if c.Bedrock.InvocationsMetric != original.InvocationsMetric && os.Getenv("TOSAGE_BEDROCK_INVOCATIONS_METRIC") != "" {
+		c.ConfigSources["Bedrock.InvocationsMetric"] = SourceEnvironment
+	}
+	if c.Bedrock.LatencyMetric != original.LatencyMetric && os.Getenv("TOSAGE_BEDROCK_LATENCY_METRIC") != "" {
+		c.ConfigSources["Bedrock.LatencyMetric"] = SourceEnvironment
+	}
+	if c.Bedrock.ModelDimension != original.ModelDimension && os.Getenv("TOSAGE_BEDROCK_MODEL_DIMENSION") != "" {
+		c.ConfigSources["Bedrock.ModelDimension"] = SourceEnvironment
+	}
+	if !slicesEqual(c.Bedrock.IncludeModels, original.IncludeModels) && os.Getenv("TOSAGE_BEDROCK_INCLUDE_MODELS") != "" {
+		c.ConfigSources["Bedrock.IncludeModels"] = SourceEnvironment
+	}
+	if !slicesEqual(c.Bedrock.ExcludeModels, original.ExcludeModels) && os.Getenv("TOSAGE_BEDROCK_EXCLUDE_MODELS") != "" {
+		c.ConfigSources["Bedrock.ExcludeModels"] = SourceEnvironment
+	}
+	if c.Bedrock.HostLabel != original.HostLabel && os.Getenv("TOSAGE_BEDROCK_HOST_LABEL") != "" {
+		c.ConfigSources["Bedrock.HostLabel"] = SourceEnvironment
+	}
+	if c.Bedrock.AllowUnknownRegions != original.AllowUnknownRegions && os.Getenv("TOSAGE_BEDROCK_ALLOW_UNKNOWN_REGIONS") != "" {
+		c.ConfigSources["Bedrock.AllowUnknownRegions"] = SourceEnvironment
+	}
+	if c.Bedrock.MetricBatchSize != original.MetricBatchSize && os.Getenv("TOSAGE_BEDROCK_METRIC_BATCH_SIZE") != "" {
+		c.ConfigSources["Bedrock.MetricBatchSize"] = SourceEnvironment
+	}
+	if c.Bedrock.IngestionDelaySec != original.IngestionDelaySec && os.Getenv("TOSAGE_BEDROCK_INGESTION_DELAY_SECONDS") != "" {
+		c.ConfigSources["Bedrock.IngestionDelaySec"] = SourceEnvironment
+	}
 }
 
 // trackVertexAIEnvOverrides tracks environment variable overrides for VertexAI config
@@ -605,6 +1728,21 @@ func (c *AppConfig) trackVertexAIEnvOverrides(original *VertexAIConfig) {
 		c.ConfigSources["VertexAI.CollectionIntervalSec"] = SourceEnvironment
 	}
 	// Track Locations if changed from environment
+	if c.VertexAI.HostLabel != original.HostLabel && os.Getenv("TOSAGE_VERTEX_AI_HOST_LABEL") != "" {
+		c.ConfigSources["VertexAI.HostLabel"] = SourceEnvironment
+	}
+	if !slicesEqual(c.VertexAI.ServiceAccountKeys, original.ServiceAccountKeys) && os.Getenv("TOSAGE_VERTEX_AI_SERVICE_ACCOUNT_KEYS") != "" {
+		c.ConfigSources["VertexAI.ServiceAccountKeys"] = SourceEnvironment
+	}
+	if c.VertexAI.RequestMetrics != original.RequestMetrics && os.Getenv("TOSAGE_VERTEX_AI_REQUEST_METRICS") != "" {
+		c.ConfigSources["VertexAI.RequestMetrics"] = SourceEnvironment
+	}
+	if c.VertexAI.RequestMetricsPerModel != original.RequestMetricsPerModel && os.Getenv("TOSAGE_VERTEX_AI_REQUEST_METRICS_PER_MODEL") != "" {
+		c.ConfigSources["VertexAI.RequestMetricsPerModel"] = SourceEnvironment
+	}
+	if c.VertexAI.IngestionDelaySec != original.IngestionDelaySec && os.Getenv("TOSAGE_VERTEX_AI_INGESTION_DELAY_SECONDS") != "" {
+		c.ConfigSources["VertexAI.IngestionDelaySec"] = SourceEnvironment
+	}
 }
 
 // trackDaemonEnvOverrides tracks environment variable overrides for Daemon config
@@ -627,6 +1765,18 @@ func (c *AppConfig) trackDaemonEnvOverrides(original *DaemonConfig) {
 	if c.Daemon.PidFile != original.PidFile && os.Getenv("TOSAGE_DAEMON_PID_FILE") != "" {
 		c.ConfigSources["Daemon.PidFile"] = SourceEnvironment
 	}
+	if c.Daemon.LogMaxSizeMB != original.LogMaxSizeMB && os.Getenv("TOSAGE_DAEMON_LOG_MAX_SIZE_MB") != "" {
+		c.ConfigSources["Daemon.LogMaxSizeMB"] = SourceEnvironment
+	}
+	if c.Daemon.MaxLogBackups() != original.MaxLogBackups() && os.Getenv("TOSAGE_DAEMON_LOG_MAX_BACKUPS") != "" {
+		c.ConfigSources["Daemon.LogMaxBackups"] = SourceEnvironment
+	}
+	if c.Daemon.MaxLogAgeDays() != original.MaxLogAgeDays() && os.Getenv("TOSAGE_DAEMON_LOG_MAX_AGE_DAYS") != "" {
+		c.ConfigSources["Daemon.LogMaxAgeDays"] = SourceEnvironment
+	}
+	if os.Getenv("TOSAGE_DAEMON_LOG_COMPRESS") != "" {
+		c.ConfigSources["Daemon.LogCompress"] = SourceEnvironment
+	}
 }
 
 // trackLoggingEnvOverrides tracks environment variable overrides for Logging config
@@ -665,6 +1815,21 @@ func (c *AppConfig) trackPromtailEnvOverrides(original *PromtailConfig) {
 	if c.Logging.Promtail.TimeoutSeconds != original.TimeoutSeconds && os.Getenv("TOSAGE_LOKI_TIMEOUT_SECONDS") != "" {
 		c.ConfigSources["Promtail.TimeoutSeconds"] = SourceEnvironment
 	}
+	if c.Logging.Promtail.ClientCertPath != original.ClientCertPath && os.Getenv("TOSAGE_LOKI_CLIENT_CERT_PATH") != "" {
+		c.ConfigSources["Promtail.ClientCertPath"] = SourceEnvironment
+	}
+	if c.Logging.Promtail.ClientKeyPath != original.ClientKeyPath && os.Getenv("TOSAGE_LOKI_CLIENT_KEY_PATH") != "" {
+		c.ConfigSources["Promtail.ClientKeyPath"] = SourceEnvironment
+	}
+	if os.Getenv("TOSAGE_LOKI_COMPRESS") != "" {
+		c.ConfigSources["Promtail.Compress"] = SourceEnvironment
+	}
+	if c.Logging.Promtail.PasswordFile != original.PasswordFile && os.Getenv("TOSAGE_LOKI_PASSWORD_FILE") != "" {
+		c.ConfigSources["Promtail.PasswordFile"] = SourceEnvironment
+	}
+	if c.Logging.Promtail.TokenFile != original.TokenFile && os.Getenv("TOSAGE_LOKI_TOKEN_FILE") != "" {
+		c.ConfigSources["Promtail.TokenFile"] = SourceEnvironment
+	}
 }
 
 // trackCSVExportEnvOverrides tracks environment variable overrides for CSVExport config
@@ -687,6 +1852,44 @@ func (c *AppConfig) trackCSVExportEnvOverrides(original *CSVExportConfig) {
 	if c.CSVExport.TimeZone != original.TimeZone && os.Getenv("TOSAGE_CSV_EXPORT_TIMEZONE") != "" {
 		c.ConfigSources["CSVExport.TimeZone"] = SourceEnvironment
 	}
+	if c.CSVExport.HashProjectPaths != original.HashProjectPaths && os.Getenv("TOSAGE_CSV_EXPORT_HASH_PROJECT_PATHS") != "" {
+		c.ConfigSources["CSVExport.HashProjectPaths"] = SourceEnvironment
+	}
+}
+
+// trackSummaryEnvOverrides tracks environment variable overrides for Summary config
+func (c *AppConfig) trackSummaryEnvOverrides(original *SummaryConfig) {
+	if original == nil {
+		return
+	}
+	if c.Summary.Enabled != original.Enabled && os.Getenv("TOSAGE_SUMMARY_ENABLED") != "" {
+		c.ConfigSources["Summary.Enabled"] = SourceEnvironment
+	}
+	if c.Summary.WebhookURL != original.WebhookURL && os.Getenv("TOSAGE_SUMMARY_WEBHOOK_URL") != "" {
+		c.ConfigSources["Summary.WebhookURL"] = SourceEnvironment
+	}
+	if c.Summary.SendTime != original.SendTime && os.Getenv("TOSAGE_SUMMARY_SEND_TIME") != "" {
+		c.ConfigSources["Summary.SendTime"] = SourceEnvironment
+	}
+	if c.Summary.TopModels != original.TopModels && os.Getenv("TOSAGE_SUMMARY_TOP_MODELS") != "" {
+		c.ConfigSources["Summary.TopModels"] = SourceEnvironment
+	}
+	if c.Summary.MaxRetries != original.MaxRetries && os.Getenv("TOSAGE_SUMMARY_MAX_RETRIES") != "" {
+		c.ConfigSources["Summary.MaxRetries"] = SourceEnvironment
+	}
+}
+
+// trackPostCollectionHookEnvOverrides tracks environment variable overrides for PostCollectionHook config
+func (c *AppConfig) trackPostCollectionHookEnvOverrides(original *PostCollectionHookConfig) {
+	if original == nil {
+		return
+	}
+	if c.PostCollectionHook.Command != original.Command && os.Getenv("TOSAGE_POST_COLLECTION_HOOK_COMMAND") != "" {
+		c.ConfigSources["PostCollectionHook.Command"] = SourceEnvironment
+	}
+	if c.PostCollectionHook.TimeoutSec != original.TimeoutSec && os.Getenv("TOSAGE_POST_COLLECTION_HOOK_TIMEOUT_SECONDS") != "" {
+		c.ConfigSources["PostCollectionHook.TimeoutSec"] = SourceEnvironment
+	}
 }
 
 // Validate validates the configuration
@@ -733,20 +1936,285 @@ func (c *AppConfig) Validate() error {
 		}
 	}
 
-	// Validate CSVExport configuration
-	if c.CSVExport != nil {
-		if err := c.validateCSVExport(); err != nil {
-			return err
+	// Validate CSVExport configuration
+	if c.CSVExport != nil {
+		if err := c.validateCSVExport(); err != nil {
+			return err
+		}
+	}
+
+	// Validate Summary configuration
+	if c.Summary != nil {
+		if err := c.validateSummary(); err != nil {
+			return err
+		}
+	}
+	if c.PostCollectionHook != nil && c.PostCollectionHook.Command != "" && c.PostCollectionHook.TimeoutSec <= 0 {
+		return fmt.Errorf("post_collection_hook.timeout_seconds must be positive")
+	}
+
+	if err := c.validateRemoteSources(); err != nil {
+		return err
+	}
+
+	if c.ParseWorkers < 0 {
+		return fmt.Errorf("parse_workers must not be negative")
+	}
+	if c.MaxLineBytes < 0 {
+		return fmt.Errorf("max_line_bytes must not be negative")
+	}
+	if c.CostPrecision != nil && (*c.CostPrecision < 0 || *c.CostPrecision > MaxCostPrecision) {
+		return fmt.Errorf("cost_precision must be between 0 and %d", MaxCostPrecision)
+	}
+	if c.WalkTimeoutSec < 0 {
+		return fmt.Errorf("walk_timeout_seconds must not be negative")
+	}
+	if c.ListingCacheSec < 0 {
+		return fmt.Errorf("listing_cache_seconds must not be negative")
+	}
+	if c.MaxDataStalenessSec < 0 {
+		return fmt.Errorf("max_data_staleness_seconds must not be negative")
+	}
+	if c.MaxEntryTokens < 0 {
+		return fmt.Errorf("max_entry_tokens must not be negative")
+	}
+	if c.MaxConcurrentRequests < 0 {
+		return fmt.Errorf("max_concurrent_requests must not be negative")
+	}
+	switch c.MaxEntryTokensAction {
+	case "", TokenCapSkip, TokenCapClamp:
+	default:
+		return fmt.Errorf("max_entry_tokens_action must be %q or %q, got %q", TokenCapSkip, TokenCapClamp, c.MaxEntryTokensAction)
+	}
+	switch valueobject.DailyWindowMode(c.DailyWindowMode) {
+	case "", valueobject.DailyWindowCalendar, valueobject.DailyWindowRolling24h:
+	default:
+		return fmt.Errorf("daily_window_mode must be %q or %q, got %q",
+			valueobject.DailyWindowCalendar, valueobject.DailyWindowRolling24h, c.DailyWindowMode)
+	}
+	if _, err := valueobject.ParseTokenComponents(c.TokenComponents); err != nil {
+		return fmt.Errorf("token_components: %w", err)
+	}
+
+	// Validate the Claude Code cutoff date
+	if _, err := ParseIgnoreBeforeDate(c.IgnoreBeforeDate); err != nil {
+		return err
+	}
+
+	// Validate profiles
+	if err := c.validateProfiles(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateProfiles validates daemon profiles
+func (c *AppConfig) validateProfiles() error {
+	names := make(map[string]bool, len(c.Profiles))
+	scrapeAddresses := make(map[string]string)
+	if c.Prometheus != nil && c.Prometheus.ScrapeListenAddress != "" {
+		scrapeAddresses[c.Prometheus.ScrapeListenAddress] = "the top-level configuration"
+	}
+	for i, profile := range c.Profiles {
+		if profile == nil || profile.Name == "" {
+			return fmt.Errorf("profile %d must have a name", i)
+		}
+		if !profileNamePattern.MatchString(profile.Name) {
+			return fmt.Errorf("profile name %q may only contain letters, digits, '_' and '-'", profile.Name)
+		}
+		if names[profile.Name] {
+			return fmt.Errorf("duplicate profile name %q", profile.Name)
+		}
+		names[profile.Name] = true
+
+		effective := c.ForProfile(profile)
+		if err := effective.validatePrometheus(); err != nil {
+			return fmt.Errorf("profile %q: %w", profile.Name, err)
+		}
+		if effective.Prometheus != nil && effective.Prometheus.ScrapeListenAddress != "" {
+			address := effective.Prometheus.ScrapeListenAddress
+			if owner, ok := scrapeAddresses[address]; ok {
+				return fmt.Errorf("profile %q: scrape_listen_address %s is already used by %s", profile.Name, address, owner)
+			}
+			scrapeAddresses[address] = fmt.Sprintf("profile %q", profile.Name)
+		}
+		if err := effective.validateBedrock(); err != nil {
+			return fmt.Errorf("profile %q: %w", profile.Name, err)
+		}
+	}
+	return nil
+}
+
+// DailyWindow returns the configured daily window mode, defaulting to the calendar day
+func (c *AppConfig) DailyWindow() valueobject.DailyWindowMode {
+	if c.DailyWindowMode == "" {
+		return valueobject.DailyWindowCalendar
+	}
+	return valueobject.DailyWindowMode(c.DailyWindowMode)
+}
+
+// TotalTokenComponents returns the token components counted in Claude Code totals,
+// defaulting to all of them
+func (c *AppConfig) TotalTokenComponents() valueobject.TokenComponents {
+	components, err := valueobject.ParseTokenComponents(c.TokenComponents)
+	if err != nil {
+		return valueobject.TokenComponents{}
+	}
+	return components
+}
+
+// ConcurrentRequestLimit returns the configured limit on outbound requests in flight,
+// defaulting to DefaultMaxConcurrentRequests
+func (c *AppConfig) ConcurrentRequestLimit() int {
+	if c.MaxConcurrentRequests == 0 {
+		return DefaultMaxConcurrentRequests
+	}
+	return c.MaxConcurrentRequests
+}
+
+// ForProfile returns the effective configuration of a profile: a copy of this
+// configuration with the profile's sections merged over the top-level ones.
+// The scrape listen address is the only setting a profile doesn't inherit.
+func (c *AppConfig) ForProfile(profile *ProfileConfig) *AppConfig {
+	cfg := *c
+	cfg.Profiles = nil
+	cfg.ConfigSources = make(ConfigSourceMap)
+
+	// Copy the sections a profile can override so the top-level configuration is untouched
+	if c.Prometheus != nil {
+		prometheus := *c.Prometheus
+		// Each profile serves its own scrape endpoint, so the top-level address is not inherited
+		prometheus.ScrapeListenAddress = ""
+		cfg.Prometheus = &prometheus
+	}
+	if c.Cursor != nil {
+		cursor := *c.Cursor
+		cfg.Cursor = &cursor
+	}
+	if c.Bedrock != nil {
+		bedrock := *c.Bedrock
+		cfg.Bedrock = &bedrock
+	}
+	if c.VertexAI != nil {
+		vertexAI := *c.VertexAI
+		cfg.VertexAI = &vertexAI
+	}
+
+	cfg.MergeJSONConfig(&AppConfig{
+		Version:    c.Version,
+		Prometheus: profile.Prometheus,
+		Cursor:     profile.Cursor,
+		Bedrock:    profile.Bedrock,
+		VertexAI:   profile.VertexAI,
+	})
+
+	return &cfg
+}
+
+// validatePrometheus validates Prometheus configuration
+func (c *AppConfig) validatePrometheus() error {
+	if c.Prometheus == nil {
+		return nil
+	}
+
+	// Validate metric transforms, which also apply to the scrape endpoint
+	for name, transform := range c.Prometheus.Transforms {
+		if !metricNamePattern.MatchString(name) {
+			return fmt.Errorf("metric transform key %q is not a valid metric name", name)
+		}
+		if transform == nil {
+			return fmt.Errorf("metric transform for %s is empty", name)
+		}
+		if transform.Multiplier == 0 || math.IsNaN(transform.Multiplier) || math.IsInf(transform.Multiplier, 0) {
+			return fmt.Errorf("metric transform for %s must have a finite, non-zero multiplier", name)
+		}
+		if math.IsNaN(transform.Offset) || math.IsInf(transform.Offset, 0) {
+			return fmt.Errorf("metric transform for %s must have a finite offset", name)
+		}
+		if internalMetricNames[name] {
+			return fmt.Errorf("metric transform for %s is not allowed, tosage relies on that metric", name)
+		}
+		if transform.RenameTo == "" || transform.RenameTo == name {
+			continue
+		}
+		if !metricNamePattern.MatchString(transform.RenameTo) {
+			return fmt.Errorf("metric transform for %s has invalid rename_to %q", name, transform.RenameTo)
+		}
+		if internalMetricNames[transform.RenameTo] {
+			return fmt.Errorf("metric transform for %s cannot rename it to %s, tosage relies on that metric", name, transform.RenameTo)
+		}
+		for other, otherTransform := range c.Prometheus.Transforms {
+			if other == name {
+				continue
+			}
+			if other == transform.RenameTo || (otherTransform != nil && otherTransform.RenameTo == transform.RenameTo) {
+				return fmt.Errorf("metric transform for %s renames it to %s, which another metric is sent as", name, transform.RenameTo)
+			}
+		}
+	}
+
+	// Validate extra labels, which also apply to the scrape endpoint
+	for name, value := range c.Prometheus.ExtraLabels {
+		if !labelNamePattern.MatchString(name) {
+			return fmt.Errorf("extra label %q is not a valid label name", name)
+		}
+		if strings.HasPrefix(name, "__") || reservedLabelNames[name] {
+			return fmt.Errorf("extra label %q is reserved", name)
+		}
+		if value == "" || !utf8.ValidString(value) {
+			return fmt.Errorf("extra label %s must have a non-empty UTF-8 value", name)
+		}
+	}
+
+	// Validate derived labels
+	for _, name := range c.Prometheus.DerivedLabels {
+		switch name {
+		case DerivedLabelMostUsedModel, DerivedLabelUniqueProjects, DerivedLabelUniqueSessions:
+		default:
+			return fmt.Errorf("unknown derived label %q (supported: %s, %s, %s)", name,
+				DerivedLabelMostUsedModel, DerivedLabelUniqueProjects, DerivedLabelUniqueSessions)
+		}
+	}
+
+	// Validate the metric name filter
+	if len(c.Prometheus.MetricAllowlist) > 0 && len(c.Prometheus.MetricDenylist) > 0 {
+		return fmt.Errorf("prometheus metric allowlist and denylist cannot both be set")
+	}
+	for _, pattern := range append(append([]string{}, c.Prometheus.MetricAllowlist...), c.Prometheus.MetricDenylist...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("prometheus metric filter pattern %q is invalid: %w", pattern, err)
+		}
+	}
+
+	if c.Prometheus.SessionMetricsTopN < 0 || c.Prometheus.SessionMetricsTopN > MaxSessionMetricsTopN {
+		return fmt.Errorf("prometheus session metrics top N must be between 0 and %d, got %d",
+			MaxSessionMetricsTopN, c.Prometheus.SessionMetricsTopN)
+	}
+
+	if c.Prometheus.Schedule != "" {
+		if _, err := valueobject.ParseCronSchedule(c.Prometheus.Schedule); err != nil {
+			return fmt.Errorf("prometheus schedule is invalid: %w", err)
 		}
 	}
 
-	return nil
-}
-
-// validatePrometheus validates Prometheus configuration
-func (c *AppConfig) validatePrometheus() error {
-	if c.Prometheus == nil {
-		return nil
+	// Validate additional backends
+	for i, backend := range c.Prometheus.Backends {
+		if backend.URL == "" {
+			return fmt.Errorf("prometheus backend %d has no url", i)
+		}
+		switch backend.BackendType() {
+		case BackendRemoteWrite, BackendOTLP:
+		default:
+			return fmt.Errorf("prometheus backend %s has unknown type %q (supported: %s, %s)",
+				backend.DisplayName(), backend.Type, BackendRemoteWrite, BackendOTLP)
+		}
+		if (backend.Username == "") != (backend.Password == "") {
+			return fmt.Errorf("prometheus backend %s needs both username and password, or neither", backend.DisplayName())
+		}
+		if backend.TimeoutSec < 0 {
+			return fmt.Errorf("prometheus backend %s timeout must not be negative", backend.DisplayName())
+		}
 	}
 
 	// Skip validation if RemoteWriteURL is empty (initial configuration)
@@ -755,8 +2223,8 @@ func (c *AppConfig) validatePrometheus() error {
 	}
 
 	// Validate interval is reasonable
-	if c.Prometheus.IntervalSec < 60 {
-		return fmt.Errorf("prometheus interval must be at least 60 seconds")
+	if c.Prometheus.IntervalSec < MinPrometheusIntervalSec {
+		return fmt.Errorf("prometheus interval must be at least %d seconds", MinPrometheusIntervalSec)
 	}
 
 	// Validate timeout is reasonable
@@ -768,8 +2236,73 @@ func (c *AppConfig) validatePrometheus() error {
 		return fmt.Errorf("prometheus timeout must be less than interval")
 	}
 
-	// Validate basic authentication is provided for remote write
-	if c.Prometheus.RemoteWriteUsername == "" || c.Prometheus.RemoteWritePassword == "" {
+	// Validate per-source intervals
+	for source, sec := range c.Prometheus.SourceIntervalSec {
+		if !intervalSources[source] {
+			return fmt.Errorf("unknown source %q in source_interval_seconds (available: claude_code, cursor, bedrock, vertex_ai)", source)
+		}
+		if sec < MinPrometheusIntervalSec {
+			return fmt.Errorf("source_interval_seconds.%s must be at least %d seconds", source, MinPrometheusIntervalSec)
+		}
+	}
+
+	// Validate the circuit breaker settings (a zero threshold disables the breaker)
+	if c.Prometheus.CircuitBreakerThreshold < 0 {
+		return fmt.Errorf("prometheus circuit breaker threshold must not be negative")
+	}
+	if c.Prometheus.CircuitBreakerThreshold > 0 && c.Prometheus.CircuitBreakerBackoffSec < MinCircuitBreakerBackoffSec {
+		return fmt.Errorf("prometheus circuit breaker backoff must be at least %d seconds", MinCircuitBreakerBackoffSec)
+	}
+
+	// Validate the startup grace period
+	if c.Prometheus.InitialDelaySec < 0 {
+		return fmt.Errorf("prometheus initial delay must not be negative")
+	}
+	if c.Prometheus.NetworkWaitSec < 0 {
+		return fmt.Errorf("prometheus network wait must not be negative")
+	}
+
+	// Validate the on-demand scrape collection (zero disables it)
+	if c.Prometheus.ScrapeRefreshAfterSec < 0 {
+		return fmt.Errorf("prometheus scrape refresh threshold must not be negative")
+	}
+	if c.Prometheus.ScrapeRefreshAfterSec > 0 && c.Prometheus.ScrapeRefreshAfterSec < MinScrapeRefreshAfterSec {
+		return fmt.Errorf("prometheus scrape refresh threshold must be at least %d seconds", MinScrapeRefreshAfterSec)
+	}
+
+	// Validate compression method
+	switch c.Prometheus.Compression {
+	case "", CompressionSnappy, CompressionGzip, CompressionNone:
+	default:
+		return fmt.Errorf("prometheus compression must be one of %s, %s or %s, got %q",
+			CompressionSnappy, CompressionGzip, CompressionNone, c.Prometheus.Compression)
+	}
+
+	// Validate the client certificate (mTLS) can be loaded
+	hasClientCert := c.Prometheus.ClientCertPath != "" || c.Prometheus.ClientKeyPath != ""
+	if hasClientCert {
+		if err := validateClientCertificate(c.Prometheus.ClientCertPath, c.Prometheus.ClientKeyPath); err != nil {
+			return fmt.Errorf("prometheus %w", err)
+		}
+	}
+
+	// Validate the credential files can be read
+	for _, path := range []string{c.Prometheus.RemoteWritePasswordFile, c.Prometheus.RemoteWriteTokenFile} {
+		if path == "" {
+			continue
+		}
+		if _, err := ReadSecretFile(path); err != nil {
+			return fmt.Errorf("prometheus remote write %w", err)
+		}
+	}
+
+	// Validate basic authentication is provided for remote write. It is optional
+	// when a client certificate or a bearer token authenticates the connection,
+	// but if either credential is set both are required.
+	hasPassword := c.Prometheus.RemoteWritePassword != "" || c.Prometheus.RemoteWritePasswordFile != ""
+	hasBasicAuth := c.Prometheus.RemoteWriteUsername != "" || hasPassword
+	hasOtherAuth := hasClientCert || c.Prometheus.RemoteWriteTokenFile != ""
+	if (hasBasicAuth || !hasOtherAuth) && (c.Prometheus.RemoteWriteUsername == "" || !hasPassword) {
 		return fmt.Errorf("remote write username and password are required when remote write URL is set")
 	}
 
@@ -784,6 +2317,18 @@ func (c *AppConfig) validatePrometheus() error {
 	return nil
 }
 
+// validateClientCertificate checks that a client certificate and key are both
+// set and can be loaded as a pair
+func validateClientCertificate(certPath, keyPath string) error {
+	if certPath == "" || keyPath == "" {
+		return fmt.Errorf("client certificate and key paths must both be set")
+	}
+	if _, err := tls.LoadX509KeyPair(certPath, keyPath); err != nil {
+		return fmt.Errorf("client certificate %s with key %s cannot be loaded: %w", certPath, keyPath, err)
+	}
+	return nil
+}
+
 // validateCursor validates Cursor configuration
 func (c *AppConfig) validateCursor() error {
 	if c.Cursor == nil {
@@ -800,6 +2345,34 @@ func (c *AppConfig) validateCursor() error {
 		return fmt.Errorf("cursor cache timeout cannot be negative")
 	}
 
+	// Zero selects the default billing day
+	if c.Cursor.BillingDay < 0 || c.Cursor.BillingDay > 28 {
+		return fmt.Errorf("cursor billing day must be between 1 and 28, got %d", c.Cursor.BillingDay)
+	}
+
+	if c.Cursor.DayStartHour < 0 || c.Cursor.DayStartHour > 23 {
+		return fmt.Errorf("cursor day start hour must be between 0 and 23, got %d", c.Cursor.DayStartHour)
+	}
+
+	// Empty selects the default endpoint
+	if c.Cursor.BaseURL != "" {
+		u, err := url.Parse(c.Cursor.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("cursor base URL must be an http or https URL, got %q", c.Cursor.BaseURL)
+		}
+	}
+
+	// Zero selects the default team member limit
+	if c.Cursor.TeamMemberLimit < 0 || c.Cursor.TeamMemberLimit > MaxCursorTeamMemberLimit {
+		return fmt.Errorf("cursor team member limit must be between 1 and %d, got %d", MaxCursorTeamMemberLimit, c.Cursor.TeamMemberLimit)
+	}
+	if c.Cursor.RateLimit < 0 {
+		return fmt.Errorf("cursor rate limit must not be negative, got %g", c.Cursor.RateLimit)
+	}
+	if c.Cursor.RateLimitBurst < 0 {
+		return fmt.Errorf("cursor rate limit burst must not be negative, got %d", c.Cursor.RateLimitBurst)
+	}
+
 	return nil
 }
 
@@ -819,6 +2392,43 @@ func (c *AppConfig) validateBedrock() error {
 		return fmt.Errorf("bedrock regions cannot be empty when bedrock is enabled")
 	}
 
+	// Validate regions against the regions Bedrock is available in, so a typo fails here
+	// instead of silently collecting nothing
+	if c.Bedrock.Enabled && !c.Bedrock.AllowUnknownRegions {
+		for _, region := range c.Bedrock.Regions {
+			if !bedrockRegions[strings.TrimSpace(region)] {
+				return fmt.Errorf("bedrock region %q is not a known Bedrock region (valid: %s); set allow_unknown_regions for newly launched regions",
+					region, strings.Join(BedrockRegionNames(), ", "))
+			}
+		}
+	}
+
+	// Validate the CloudWatch names needed to read token usage
+	if c.Bedrock.Enabled {
+		required := []struct {
+			name  string
+			value string
+		}{
+			{"namespace", c.Bedrock.Namespace},
+			{"input token metric", c.Bedrock.InputTokenMetric},
+			{"output token metric", c.Bedrock.OutputTokenMetric},
+			{"model dimension", c.Bedrock.ModelDimension},
+		}
+		for _, r := range required {
+			if strings.TrimSpace(r.value) == "" {
+				return fmt.Errorf("bedrock %s cannot be empty when bedrock is enabled", r.name)
+			}
+		}
+	}
+
+	if c.Bedrock.Enabled && c.Bedrock.IngestionDelaySec > MaxIngestionDelaySec {
+		return fmt.Errorf("bedrock ingestion delay must be at most %d seconds, got %d", MaxIngestionDelaySec, c.Bedrock.IngestionDelaySec)
+	}
+
+	if c.Bedrock.Enabled && (c.Bedrock.MetricBatchSize < 1 || c.Bedrock.MetricBatchSize > MaxBedrockMetricBatchSize) {
+		return fmt.Errorf("bedrock metric batch size must be between 1 and %d, got %d", MaxBedrockMetricBatchSize, c.Bedrock.MetricBatchSize)
+	}
+
 	return nil
 }
 
@@ -838,27 +2448,59 @@ func (c *AppConfig) validateVertexAI() error {
 		return fmt.Errorf("vertex ai project ID cannot be empty when vertex ai is enabled")
 	}
 
+	if c.VertexAI.Enabled && c.VertexAI.IngestionDelaySec > MaxIngestionDelaySec {
+		return fmt.Errorf("vertex ai ingestion delay must be at most %d seconds, got %d", MaxIngestionDelaySec, c.VertexAI.IngestionDelaySec)
+	}
+
 	// Validate service account key JSON if provided
 	if c.VertexAI.ServiceAccountKey != "" {
-		var keyData map[string]interface{}
-		if err := json.Unmarshal([]byte(c.VertexAI.ServiceAccountKey), &keyData); err != nil {
-			return fmt.Errorf("invalid service account key JSON: %w", err)
+		if err := validateServiceAccountKeyJSON(c.VertexAI.ServiceAccountKey); err != nil {
+			return err
 		}
+	}
 
-		// Check required fields in service account key
-		requiredFields := []string{"type", "project_id", "private_key_id", "private_key", "client_email"}
-		for _, field := range requiredFields {
-			if _, ok := keyData[field]; !ok {
-				return fmt.Errorf("service account key missing required field: %s", field)
-			}
+	// Additional keys may include a stale one during a rotation, so only fail when every
+	// inline key is invalid. File paths are checked when the keys are loaded.
+	var inlineKeys int
+	var keyErr error
+	for _, key := range c.VertexAI.ServiceAccountKeys {
+		if !strings.HasPrefix(strings.TrimSpace(key), "{") {
+			continue
 		}
+		inlineKeys++
+		if err := validateServiceAccountKeyJSON(key); err != nil {
+			keyErr = err
+		} else {
+			keyErr = nil
+			break
+		}
+	}
+	if inlineKeys == len(c.VertexAI.ServiceAccountKeys) && keyErr != nil {
+		return fmt.Errorf("no valid key in service_account_keys: %w", keyErr)
+	}
+
+	return nil
+}
+
+// validateServiceAccountKeyJSON checks that keyJSON is a service account key with the required fields
+func validateServiceAccountKeyJSON(keyJSON string) error {
+	var keyData map[string]interface{}
+	if err := json.Unmarshal([]byte(keyJSON), &keyData); err != nil {
+		return fmt.Errorf("invalid service account key JSON: %w", err)
+	}
 
-		// Validate type field
-		if keyType, ok := keyData["type"].(string); !ok || keyType != "service_account" {
-			return fmt.Errorf("service account key must have type 'service_account'")
+	// Check required fields in service account key
+	requiredFields := []string{"type", "project_id", "private_key_id", "private_key", "client_email"}
+	for _, field := range requiredFields {
+		if _, ok := keyData[field]; !ok {
+			return fmt.Errorf("service account key missing required field: %s", field)
 		}
 	}
 
+	// Validate type field
+	if keyType, ok := keyData["type"].(string); !ok || keyType != "service_account" {
+		return fmt.Errorf("service account key must have type 'service_account'")
+	}
 	return nil
 }
 
@@ -878,6 +2520,19 @@ func (c *AppConfig) validateDaemon() error {
 		return fmt.Errorf("daemon PID file path cannot be empty when daemon is enabled")
 	}
 
+	// Zero selects the default rotation size
+	if c.Daemon.LogMaxSizeMB < 0 {
+		return fmt.Errorf("daemon log max size cannot be negative, got %d MB", c.Daemon.LogMaxSizeMB)
+	}
+
+	if c.Daemon.MaxLogBackups() < 0 {
+		return fmt.Errorf("daemon log max backups cannot be negative, got %d", c.Daemon.MaxLogBackups())
+	}
+
+	if c.Daemon.MaxLogAgeDays() < 0 {
+		return fmt.Errorf("daemon log max age cannot be negative, got %d days", c.Daemon.MaxLogAgeDays())
+	}
+
 	return nil
 }
 
@@ -918,6 +2573,21 @@ func (c *AppConfig) validateLogging() error {
 		if c.Logging.Promtail.TimeoutSeconds < 1 {
 			return fmt.Errorf("promtail timeout must be at least 1 second")
 		}
+
+		if c.Logging.Promtail.ClientCertPath != "" || c.Logging.Promtail.ClientKeyPath != "" {
+			if err := validateClientCertificate(c.Logging.Promtail.ClientCertPath, c.Logging.Promtail.ClientKeyPath); err != nil {
+				return fmt.Errorf("promtail %w", err)
+			}
+		}
+
+		for _, path := range []string{c.Logging.Promtail.PasswordFile, c.Logging.Promtail.TokenFile} {
+			if path == "" {
+				continue
+			}
+			if _, err := ReadSecretFile(path); err != nil {
+				return fmt.Errorf("promtail %w", err)
+			}
+		}
 	}
 
 	return nil
@@ -946,7 +2616,7 @@ func (c *AppConfig) validateCSVExport() error {
 
 	// Validate timezone format
 	if c.CSVExport.TimeZone != "" {
-		if _, err := time.LoadLocation(c.CSVExport.TimeZone); err != nil {
+		if _, err := LoadTimezone(c.CSVExport.TimeZone); err != nil {
 			return fmt.Errorf("csv export timezone is invalid: %w", err)
 		}
 	}
@@ -954,10 +2624,103 @@ func (c *AppConfig) validateCSVExport() error {
 	return nil
 }
 
+// validateRemoteSources validates the remote Claude Code sources
+func (c *AppConfig) validateRemoteSources() error {
+	names := make(map[string]bool)
+	for i := range c.RemoteSources {
+		source := &c.RemoteSources[i]
+		if source.Host == "" {
+			return fmt.Errorf("remote source %d has no host", i)
+		}
+		if source.User == "" {
+			return fmt.Errorf("remote source %s has no user", source.DisplayName())
+		}
+		if source.SyncIntervalSec < 0 || source.TimeoutSec < 0 {
+			return fmt.Errorf("remote source %s: sync_interval_seconds and timeout_seconds must not be negative", source.DisplayName())
+		}
+		// The name keys the local mirror, so two sources must not share it
+		if names[source.DisplayName()] {
+			return fmt.Errorf("remote source name %q is used more than once", source.DisplayName())
+		}
+		names[source.DisplayName()] = true
+	}
+	return nil
+}
+
+// validateSummary validates Summary configuration
+func (c *AppConfig) validateSummary() error {
+	if c.Summary == nil || !c.Summary.Enabled {
+		return nil
+	}
+
+	// Validate webhook URL is an absolute HTTP(S) URL
+	if c.Summary.WebhookURL == "" {
+		return fmt.Errorf("summary webhook URL cannot be empty when summary is enabled")
+	}
+	u, err := url.Parse(c.Summary.WebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("summary webhook URL must be an http or https URL")
+	}
+
+	// Validate send time format
+	if _, err := ParseSummarySendTime(c.Summary.SendTime); err != nil {
+		return err
+	}
+
+	if c.Summary.TopModels < 0 {
+		return fmt.Errorf("summary top models cannot be negative")
+	}
+	if c.Summary.MaxRetries < 0 {
+		return fmt.Errorf("summary max retries cannot be negative")
+	}
+
+	return nil
+}
+
+// ParseSummarySendTime parses a HH:MM send time into the offset from midnight
+func ParseSummarySendTime(sendTime string) (time.Duration, error) {
+	t, err := time.Parse("15:04", sendTime)
+	if err != nil {
+		return 0, fmt.Errorf("summary send time must be in HH:MM format: %q", sendTime)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// ParseIgnoreBeforeDate parses a YYYY-MM-DD cutoff date as local midnight.
+// An empty date returns the zero time, which disables the cutoff.
+func ParseIgnoreBeforeDate(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ignore_before_date must be in YYYY-MM-DD format: %q", date)
+	}
+	return t, nil
+}
+
 // MarkDefaults marks all configuration fields as coming from defaults
 func (c *AppConfig) MarkDefaults() {
 	c.ConfigSources["Version"] = SourceDefault
 	c.ConfigSources["ClaudePath"] = SourceDefault
+	c.ConfigSources["RemoteSources"] = SourceDefault
+	c.ConfigSources["HashProjectPaths"] = SourceDefault
+	c.ConfigSources["ExcludeModels"] = SourceDefault
+	c.ConfigSources["TokenComponents"] = SourceDefault
+	c.ConfigSources["HeuristicDedup"] = SourceDefault
+	c.ConfigSources["IgnoreBeforeDate"] = SourceDefault
+	c.ConfigSources["ParseWorkers"] = SourceDefault
+	c.ConfigSources["MaxLineBytes"] = SourceDefault
+	c.ConfigSources["CostPrecision"] = SourceDefault
+	c.ConfigSources["FailFastOnProviderInitError"] = SourceDefault
+	c.ConfigSources["MaxEntryTokens"] = SourceDefault
+	c.ConfigSources["MaxEntryTokensAction"] = SourceDefault
+	c.ConfigSources["WalkTimeoutSec"] = SourceDefault
+	c.ConfigSources["ListingCacheSec"] = SourceDefault
+	c.ConfigSources["MaxDataStalenessSec"] = SourceDefault
+	c.ConfigSources["DailyWindowMode"] = SourceDefault
+	c.ConfigSources["UserAgent"] = SourceDefault
+	c.ConfigSources["MaxConcurrentRequests"] = SourceDefault
 	c.ConfigSources["Prometheus.RemoteWriteURL"] = SourceDefault
 	c.ConfigSources["Prometheus.RemoteWriteUsername"] = SourceDefault
 	c.ConfigSources["Prometheus.RemoteWritePassword"] = SourceDefault
@@ -967,23 +2730,89 @@ func (c *AppConfig) MarkDefaults() {
 	c.ConfigSources["Prometheus.HostLabel"] = SourceDefault
 	c.ConfigSources["Prometheus.IntervalSec"] = SourceDefault
 	c.ConfigSources["Prometheus.TimeoutSec"] = SourceDefault
+	c.ConfigSources["Prometheus.ScrapeListenAddress"] = SourceDefault
+	c.ConfigSources["Prometheus.StateFilePath"] = SourceDefault
+	c.ConfigSources["Prometheus.ProbeOnStartup"] = SourceDefault
+	c.ConfigSources["Prometheus.Compression"] = SourceDefault
+	c.ConfigSources["Prometheus.Transforms"] = SourceDefault
+	c.ConfigSources["Prometheus.ExtraLabels"] = SourceDefault
+	c.ConfigSources["Prometheus.Backends"] = SourceDefault
+	c.ConfigSources["Prometheus.DerivedLabels"] = SourceDefault
+	c.ConfigSources["Prometheus.ClientCertPath"] = SourceDefault
+	c.ConfigSources["Prometheus.ClientKeyPath"] = SourceDefault
+	c.ConfigSources["Prometheus.AlignToInterval"] = SourceDefault
+	c.ConfigSources["Prometheus.CircuitBreakerThreshold"] = SourceDefault
+	c.ConfigSources["Prometheus.CircuitBreakerBackoffSec"] = SourceDefault
+	c.ConfigSources["Prometheus.SessionMetricsTopN"] = SourceDefault
+	c.ConfigSources["Prometheus.HashSessionIDs"] = SourceDefault
+	c.ConfigSources["Prometheus.InitialDelaySec"] = SourceDefault
+	c.ConfigSources["Prometheus.NetworkWaitSec"] = SourceDefault
+	c.ConfigSources["Prometheus.SourceIntervalSec"] = SourceDefault
+	c.ConfigSources["Prometheus.CcTokensDelta"] = SourceDefault
+	c.ConfigSources["Prometheus.MetricAllowlist"] = SourceDefault
+	c.ConfigSources["Prometheus.MetricDenylist"] = SourceDefault
+	c.ConfigSources["Prometheus.SourcePathLabel"] = SourceDefault
+	c.ConfigSources["Prometheus.HashSourcePaths"] = SourceDefault
+	c.ConfigSources["Prometheus.SessionPercentiles"] = SourceDefault
+	c.ConfigSources["Prometheus.RemoteWritePasswordFile"] = SourceDefault
+	c.ConfigSources["Prometheus.RemoteWriteTokenFile"] = SourceDefault
+	c.ConfigSources["Prometheus.Schedule"] = SourceDefault
+	c.ConfigSources["Prometheus.UniqueCountMetrics"] = SourceDefault
+	c.ConfigSources["Prometheus.CloudMetadataLabels"] = SourceDefault
+	c.ConfigSources["Prometheus.ScrapeRefreshAfterSec"] = SourceDefault
 	c.ConfigSources["Cursor.DatabasePath"] = SourceDefault
 	c.ConfigSources["Cursor.APITimeout"] = SourceDefault
 	c.ConfigSources["Cursor.CacheTimeout"] = SourceDefault
+	c.ConfigSources["Cursor.BillingDay"] = SourceDefault
+	c.ConfigSources["Cursor.DayStartHour"] = SourceDefault
+	c.ConfigSources["Cursor.HostLabel"] = SourceDefault
+	c.ConfigSources["Cursor.PremiumRequestMetrics"] = SourceDefault
+	c.ConfigSources["Cursor.BaseURL"] = SourceDefault
+	c.ConfigSources["Cursor.TeamMemberMetrics"] = SourceDefault
+	c.ConfigSources["Cursor.TeamMembers"] = SourceDefault
+	c.ConfigSources["Cursor.TeamMemberLimit"] = SourceDefault
+	c.ConfigSources["Cursor.UsageCostMetrics"] = SourceDefault
+	c.ConfigSources["Cursor.CallCountMetrics"] = SourceDefault
+	c.ConfigSources["Cursor.RateLimit"] = SourceDefault
+	c.ConfigSources["Cursor.RateLimitBurst"] = SourceDefault
+	c.ConfigSources["Cursor.UsageBasedStatusMetrics"] = SourceDefault
+	c.ConfigSources["Cursor.BillingCycleMetric"] = SourceDefault
+	c.ConfigSources["Cursor.HashTeamMembers"] = SourceDefault
 	c.ConfigSources["Bedrock.Enabled"] = SourceDefault
 	c.ConfigSources["Bedrock.AWSProfile"] = SourceDefault
 	c.ConfigSources["Bedrock.AssumeRoleARN"] = SourceDefault
 	c.ConfigSources["Bedrock.CollectionIntervalSec"] = SourceDefault
+	c.ConfigSources["Bedrock.Namespace"] = SourceDefault
+	c.ConfigSources["Bedrock.InputTokenMetric"] = SourceDefault
+	c.ConfigSources["Bedrock.OutputTokenMetric"] = SourceDefault
+	c.ConfigSources["Bedrock.InvocationsMetric"] = SourceDefault
+	c.ConfigSources["Bedrock.LatencyMetric"] = SourceDefault
+	c.ConfigSources["Bedrock.ModelDimension"] = SourceDefault
+	c.ConfigSources["Bedrock.IncludeModels"] = SourceDefault
+	c.ConfigSources["Bedrock.ExcludeModels"] = SourceDefault
+	c.ConfigSources["Bedrock.HostLabel"] = SourceDefault
+	c.ConfigSources["Bedrock.AllowUnknownRegions"] = SourceDefault
+	c.ConfigSources["Bedrock.MetricBatchSize"] = SourceDefault
+	c.ConfigSources["Bedrock.IngestionDelaySec"] = SourceDefault
 	c.ConfigSources["VertexAI.Enabled"] = SourceDefault
 	c.ConfigSources["VertexAI.ProjectID"] = SourceDefault
 	c.ConfigSources["VertexAI.ServiceAccountKeyPath"] = SourceDefault
 	c.ConfigSources["VertexAI.ServiceAccountKey"] = SourceDefault
 	c.ConfigSources["VertexAI.CollectionIntervalSec"] = SourceDefault
+	c.ConfigSources["VertexAI.HostLabel"] = SourceDefault
+	c.ConfigSources["VertexAI.ServiceAccountKeys"] = SourceDefault
+	c.ConfigSources["VertexAI.RequestMetrics"] = SourceDefault
+	c.ConfigSources["VertexAI.RequestMetricsPerModel"] = SourceDefault
+	c.ConfigSources["VertexAI.IngestionDelaySec"] = SourceDefault
 	c.ConfigSources["Daemon.Enabled"] = SourceDefault
 	c.ConfigSources["Daemon.StartAtLogin"] = SourceDefault
 	c.ConfigSources["Daemon.HideFromDock"] = SourceDefault
 	c.ConfigSources["Daemon.LogPath"] = SourceDefault
 	c.ConfigSources["Daemon.PidFile"] = SourceDefault
+	c.ConfigSources["Daemon.LogMaxSizeMB"] = SourceDefault
+	c.ConfigSources["Daemon.LogMaxBackups"] = SourceDefault
+	c.ConfigSources["Daemon.LogMaxAgeDays"] = SourceDefault
+	c.ConfigSources["Daemon.LogCompress"] = SourceDefault
 	c.ConfigSources["Logging.Level"] = SourceDefault
 	c.ConfigSources["Logging.Debug"] = SourceDefault
 	c.ConfigSources["Promtail.URL"] = SourceDefault
@@ -992,11 +2821,24 @@ func (c *AppConfig) MarkDefaults() {
 	c.ConfigSources["Promtail.BatchWaitSeconds"] = SourceDefault
 	c.ConfigSources["Promtail.BatchCapacity"] = SourceDefault
 	c.ConfigSources["Promtail.TimeoutSeconds"] = SourceDefault
+	c.ConfigSources["Promtail.ClientCertPath"] = SourceDefault
+	c.ConfigSources["Promtail.ClientKeyPath"] = SourceDefault
+	c.ConfigSources["Promtail.Compress"] = SourceDefault
+	c.ConfigSources["Promtail.PasswordFile"] = SourceDefault
+	c.ConfigSources["Promtail.TokenFile"] = SourceDefault
 	c.ConfigSources["CSVExport.DefaultOutputPath"] = SourceDefault
 	c.ConfigSources["CSVExport.DefaultStartDays"] = SourceDefault
 	c.ConfigSources["CSVExport.DefaultMetricTypes"] = SourceDefault
 	c.ConfigSources["CSVExport.MaxExportDays"] = SourceDefault
 	c.ConfigSources["CSVExport.TimeZone"] = SourceDefault
+	c.ConfigSources["CSVExport.HashProjectPaths"] = SourceDefault
+	c.ConfigSources["Summary.Enabled"] = SourceDefault
+	c.ConfigSources["Summary.WebhookURL"] = SourceDefault
+	c.ConfigSources["Summary.SendTime"] = SourceDefault
+	c.ConfigSources["Summary.TopModels"] = SourceDefault
+	c.ConfigSources["Summary.MaxRetries"] = SourceDefault
+	c.ConfigSources["PostCollectionHook.Command"] = SourceDefault
+	c.ConfigSources["PostCollectionHook.TimeoutSec"] = SourceDefault
 }
 
 // MergeJSONConfig merges JSON configuration into the current configuration
@@ -1009,6 +2851,82 @@ func (c *AppConfig) MergeJSONConfig(jsonConfig *AppConfig) {
 		c.ClaudePath = jsonConfig.ClaudePath
 		c.ConfigSources["ClaudePath"] = SourceJSONFile
 	}
+	if len(jsonConfig.RemoteSources) > 0 {
+		c.RemoteSources = jsonConfig.RemoteSources
+		c.ConfigSources["RemoteSources"] = SourceJSONFile
+	}
+	if jsonConfig.HashProjectPaths {
+		c.HashProjectPaths = jsonConfig.HashProjectPaths
+		c.ConfigSources["HashProjectPaths"] = SourceJSONFile
+	}
+	if len(jsonConfig.ExcludeModels) > 0 {
+		c.ExcludeModels = jsonConfig.ExcludeModels
+		c.ConfigSources["ExcludeModels"] = SourceJSONFile
+	}
+	if len(jsonConfig.TokenComponents) > 0 {
+		c.TokenComponents = jsonConfig.TokenComponents
+		c.ConfigSources["TokenComponents"] = SourceJSONFile
+	}
+	if len(jsonConfig.Profiles) > 0 {
+		c.Profiles = jsonConfig.Profiles
+		c.ConfigSources["Profiles"] = SourceJSONFile
+	}
+	if jsonConfig.HeuristicDedup {
+		c.HeuristicDedup = jsonConfig.HeuristicDedup
+		c.ConfigSources["HeuristicDedup"] = SourceJSONFile
+	}
+	if jsonConfig.UserAgent != "" {
+		c.UserAgent = jsonConfig.UserAgent
+		c.ConfigSources["UserAgent"] = SourceJSONFile
+	}
+	if jsonConfig.IgnoreBeforeDate != "" {
+		c.IgnoreBeforeDate = jsonConfig.IgnoreBeforeDate
+		c.ConfigSources["IgnoreBeforeDate"] = SourceJSONFile
+	}
+	if jsonConfig.ParseWorkers != 0 {
+		c.ParseWorkers = jsonConfig.ParseWorkers
+		c.ConfigSources["ParseWorkers"] = SourceJSONFile
+	}
+	if jsonConfig.WalkTimeoutSec != 0 {
+		c.WalkTimeoutSec = jsonConfig.WalkTimeoutSec
+		c.ConfigSources["WalkTimeoutSec"] = SourceJSONFile
+	}
+	if jsonConfig.ListingCacheSec != 0 {
+		c.ListingCacheSec = jsonConfig.ListingCacheSec
+		c.ConfigSources["ListingCacheSec"] = SourceJSONFile
+	}
+	if jsonConfig.MaxDataStalenessSec != 0 {
+		c.MaxDataStalenessSec = jsonConfig.MaxDataStalenessSec
+		c.ConfigSources["MaxDataStalenessSec"] = SourceJSONFile
+	}
+	if jsonConfig.DailyWindowMode != "" {
+		c.DailyWindowMode = jsonConfig.DailyWindowMode
+		c.ConfigSources["DailyWindowMode"] = SourceJSONFile
+	}
+	if jsonConfig.MaxEntryTokens != 0 {
+		c.MaxEntryTokens = jsonConfig.MaxEntryTokens
+		c.ConfigSources["MaxEntryTokens"] = SourceJSONFile
+	}
+	if jsonConfig.MaxEntryTokensAction != "" {
+		c.MaxEntryTokensAction = jsonConfig.MaxEntryTokensAction
+		c.ConfigSources["MaxEntryTokensAction"] = SourceJSONFile
+	}
+	if jsonConfig.MaxConcurrentRequests != 0 {
+		c.MaxConcurrentRequests = jsonConfig.MaxConcurrentRequests
+		c.ConfigSources["MaxConcurrentRequests"] = SourceJSONFile
+	}
+	if jsonConfig.MaxLineBytes != 0 {
+		c.MaxLineBytes = jsonConfig.MaxLineBytes
+		c.ConfigSources["MaxLineBytes"] = SourceJSONFile
+	}
+	if jsonConfig.CostPrecision != nil {
+		c.CostPrecision = jsonConfig.CostPrecision
+		c.ConfigSources["CostPrecision"] = SourceJSONFile
+	}
+	if jsonConfig.FailFastOnProviderInitError {
+		c.FailFastOnProviderInitError = jsonConfig.FailFastOnProviderInitError
+		c.ConfigSources["FailFastOnProviderInitError"] = SourceJSONFile
+	}
 
 	// Merge Prometheus configuration
 	if jsonConfig.Prometheus != nil {
@@ -1065,6 +2983,22 @@ func (c *AppConfig) MergeJSONConfig(jsonConfig *AppConfig) {
 		}
 		c.mergeCSVExportConfig(jsonConfig.CSVExport)
 	}
+
+	// Merge Summary configuration
+	if jsonConfig.Summary != nil {
+		if c.Summary == nil {
+			c.Summary = &SummaryConfig{}
+		}
+		c.mergeSummaryConfig(jsonConfig.Summary)
+	}
+
+	// Merge PostCollectionHook configuration
+	if jsonConfig.PostCollectionHook != nil {
+		if c.PostCollectionHook == nil {
+			c.PostCollectionHook = &PostCollectionHookConfig{}
+		}
+		c.mergePostCollectionHookConfig(jsonConfig.PostCollectionHook)
+	}
 }
 
 // mergePrometheusConfig merges Prometheus configuration from JSON
@@ -1105,6 +3039,127 @@ func (c *AppConfig) mergePrometheusConfig(jsonConfig *PrometheusConfig) {
 		c.Prometheus.TimeoutSec = jsonConfig.TimeoutSec
 		c.ConfigSources["Prometheus.TimeoutSec"] = SourceJSONFile
 	}
+	if jsonConfig.ScrapeListenAddress != "" {
+		c.Prometheus.ScrapeListenAddress = jsonConfig.ScrapeListenAddress
+		c.ConfigSources["Prometheus.ScrapeListenAddress"] = SourceJSONFile
+	}
+	if jsonConfig.StateFilePath != "" {
+		c.Prometheus.StateFilePath = jsonConfig.StateFilePath
+		c.ConfigSources["Prometheus.StateFilePath"] = SourceJSONFile
+	}
+	if jsonConfig.ProbeOnStartup != nil {
+		c.Prometheus.ProbeOnStartup = jsonConfig.ProbeOnStartup
+		c.ConfigSources["Prometheus.ProbeOnStartup"] = SourceJSONFile
+	}
+	if jsonConfig.Compression != "" {
+		c.Prometheus.Compression = jsonConfig.Compression
+		c.ConfigSources["Prometheus.Compression"] = SourceJSONFile
+	}
+	if len(jsonConfig.Transforms) > 0 {
+		c.Prometheus.Transforms = jsonConfig.Transforms
+		c.ConfigSources["Prometheus.Transforms"] = SourceJSONFile
+	}
+	if len(jsonConfig.ExtraLabels) > 0 {
+		c.Prometheus.ExtraLabels = jsonConfig.ExtraLabels
+		c.ConfigSources["Prometheus.ExtraLabels"] = SourceJSONFile
+	}
+	if len(jsonConfig.Backends) > 0 {
+		c.Prometheus.Backends = jsonConfig.Backends
+		c.ConfigSources["Prometheus.Backends"] = SourceJSONFile
+	}
+	if len(jsonConfig.DerivedLabels) > 0 {
+		c.Prometheus.DerivedLabels = jsonConfig.DerivedLabels
+		c.ConfigSources["Prometheus.DerivedLabels"] = SourceJSONFile
+	}
+	if jsonConfig.ClientCertPath != "" {
+		c.Prometheus.ClientCertPath = jsonConfig.ClientCertPath
+		c.ConfigSources["Prometheus.ClientCertPath"] = SourceJSONFile
+	}
+	if jsonConfig.ClientKeyPath != "" {
+		c.Prometheus.ClientKeyPath = jsonConfig.ClientKeyPath
+		c.ConfigSources["Prometheus.ClientKeyPath"] = SourceJSONFile
+	}
+
+	// Note: bool field
+	c.Prometheus.AlignToInterval = jsonConfig.AlignToInterval
+	c.ConfigSources["Prometheus.AlignToInterval"] = SourceJSONFile
+	if jsonConfig.CircuitBreakerThreshold != 0 {
+		c.Prometheus.CircuitBreakerThreshold = jsonConfig.CircuitBreakerThreshold
+		c.ConfigSources["Prometheus.CircuitBreakerThreshold"] = SourceJSONFile
+	}
+	if jsonConfig.CircuitBreakerBackoffSec != 0 {
+		c.Prometheus.CircuitBreakerBackoffSec = jsonConfig.CircuitBreakerBackoffSec
+		c.ConfigSources["Prometheus.CircuitBreakerBackoffSec"] = SourceJSONFile
+	}
+	if jsonConfig.SessionMetricsTopN != 0 {
+		c.Prometheus.SessionMetricsTopN = jsonConfig.SessionMetricsTopN
+		c.ConfigSources["Prometheus.SessionMetricsTopN"] = SourceJSONFile
+	}
+
+	// Note: bool field
+	c.Prometheus.HashSessionIDs = jsonConfig.HashSessionIDs
+	c.ConfigSources["Prometheus.HashSessionIDs"] = SourceJSONFile
+	if jsonConfig.InitialDelaySec != 0 {
+		c.Prometheus.InitialDelaySec = jsonConfig.InitialDelaySec
+		c.ConfigSources["Prometheus.InitialDelaySec"] = SourceJSONFile
+	}
+	if jsonConfig.NetworkWaitSec != 0 {
+		c.Prometheus.NetworkWaitSec = jsonConfig.NetworkWaitSec
+		c.ConfigSources["Prometheus.NetworkWaitSec"] = SourceJSONFile
+	}
+	if len(jsonConfig.SourceIntervalSec) > 0 {
+		c.Prometheus.SourceIntervalSec = jsonConfig.SourceIntervalSec
+		c.ConfigSources["Prometheus.SourceIntervalSec"] = SourceJSONFile
+	}
+
+	// Note: bool field
+	c.Prometheus.CcTokensDelta = jsonConfig.CcTokensDelta
+	c.ConfigSources["Prometheus.CcTokensDelta"] = SourceJSONFile
+	if len(jsonConfig.MetricAllowlist) > 0 {
+		c.Prometheus.MetricAllowlist = jsonConfig.MetricAllowlist
+		c.ConfigSources["Prometheus.MetricAllowlist"] = SourceJSONFile
+	}
+	if len(jsonConfig.MetricDenylist) > 0 {
+		c.Prometheus.MetricDenylist = jsonConfig.MetricDenylist
+		c.ConfigSources["Prometheus.MetricDenylist"] = SourceJSONFile
+	}
+
+	// Note: bool field
+	c.Prometheus.SourcePathLabel = jsonConfig.SourcePathLabel
+	c.ConfigSources["Prometheus.SourcePathLabel"] = SourceJSONFile
+	if jsonConfig.HashSourcePaths != nil {
+		c.Prometheus.HashSourcePaths = jsonConfig.HashSourcePaths
+		c.ConfigSources["Prometheus.HashSourcePaths"] = SourceJSONFile
+	}
+
+	// Note: bool field
+	c.Prometheus.SessionPercentiles = jsonConfig.SessionPercentiles
+	c.ConfigSources["Prometheus.SessionPercentiles"] = SourceJSONFile
+	if jsonConfig.RemoteWritePasswordFile != "" {
+		c.Prometheus.RemoteWritePasswordFile = jsonConfig.RemoteWritePasswordFile
+		c.ConfigSources["Prometheus.RemoteWritePasswordFile"] = SourceJSONFile
+	}
+	if jsonConfig.RemoteWriteTokenFile != "" {
+		c.Prometheus.RemoteWriteTokenFile = jsonConfig.RemoteWriteTokenFile
+		c.ConfigSources["Prometheus.RemoteWriteTokenFile"] = SourceJSONFile
+	}
+	if jsonConfig.Schedule != "" {
+		c.Prometheus.Schedule = jsonConfig.Schedule
+		c.ConfigSources["Prometheus.Schedule"] = SourceJSONFile
+	}
+
+	// Note: bool field
+	c.Prometheus.UniqueCountMetrics = jsonConfig.UniqueCountMetrics
+	c.ConfigSources["Prometheus.UniqueCountMetrics"] = SourceJSONFile
+
+	// Note: bool field
+	c.Prometheus.CloudMetadataLabels = jsonConfig.CloudMetadataLabels
+	c.ConfigSources["Prometheus.CloudMetadataLabels"] = SourceJSONFile
+
+	if jsonConfig.ScrapeRefreshAfterSec != 0 {
+		c.Prometheus.ScrapeRefreshAfterSec = jsonConfig.ScrapeRefreshAfterSec
+		c.ConfigSources["Prometheus.ScrapeRefreshAfterSec"] = SourceJSONFile
+	}
 }
 
 // mergeCursorConfig merges Cursor configuration from JSON
@@ -1121,6 +3176,66 @@ func (c *AppConfig) mergeCursorConfig(jsonConfig *CursorConfig) {
 		c.Cursor.CacheTimeout = jsonConfig.CacheTimeout
 		c.ConfigSources["Cursor.CacheTimeout"] = SourceJSONFile
 	}
+	if jsonConfig.BillingDay != 0 {
+		c.Cursor.BillingDay = jsonConfig.BillingDay
+		c.ConfigSources["Cursor.BillingDay"] = SourceJSONFile
+	}
+	if jsonConfig.DayStartHour != 0 {
+		c.Cursor.DayStartHour = jsonConfig.DayStartHour
+		c.ConfigSources["Cursor.DayStartHour"] = SourceJSONFile
+	}
+	if jsonConfig.HostLabel != "" {
+		c.Cursor.HostLabel = jsonConfig.HostLabel
+		c.ConfigSources["Cursor.HostLabel"] = SourceJSONFile
+	}
+
+	// Note: bool field
+	c.Cursor.PremiumRequestMetrics = jsonConfig.PremiumRequestMetrics
+	c.ConfigSources["Cursor.PremiumRequestMetrics"] = SourceJSONFile
+	if jsonConfig.BaseURL != "" {
+		c.Cursor.BaseURL = jsonConfig.BaseURL
+		c.ConfigSources["Cursor.BaseURL"] = SourceJSONFile
+	}
+
+	// Note: bool field
+	c.Cursor.TeamMemberMetrics = jsonConfig.TeamMemberMetrics
+	c.ConfigSources["Cursor.TeamMemberMetrics"] = SourceJSONFile
+	if len(jsonConfig.TeamMembers) > 0 {
+		c.Cursor.TeamMembers = jsonConfig.TeamMembers
+		c.ConfigSources["Cursor.TeamMembers"] = SourceJSONFile
+	}
+	if jsonConfig.TeamMemberLimit != 0 {
+		c.Cursor.TeamMemberLimit = jsonConfig.TeamMemberLimit
+		c.ConfigSources["Cursor.TeamMemberLimit"] = SourceJSONFile
+	}
+
+	// Note: bool field
+	c.Cursor.UsageCostMetrics = jsonConfig.UsageCostMetrics
+	c.ConfigSources["Cursor.UsageCostMetrics"] = SourceJSONFile
+
+	// Note: bool field
+	c.Cursor.CallCountMetrics = jsonConfig.CallCountMetrics
+	c.ConfigSources["Cursor.CallCountMetrics"] = SourceJSONFile
+	if jsonConfig.RateLimit != 0 {
+		c.Cursor.RateLimit = jsonConfig.RateLimit
+		c.ConfigSources["Cursor.RateLimit"] = SourceJSONFile
+	}
+	if jsonConfig.RateLimitBurst != 0 {
+		c.Cursor.RateLimitBurst = jsonConfig.RateLimitBurst
+		c.ConfigSources["Cursor.RateLimitBurst"] = SourceJSONFile
+	}
+
+	// Note: bool field
+	c.Cursor.UsageBasedStatusMetrics = jsonConfig.UsageBasedStatusMetrics
+	c.ConfigSources["Cursor.UsageBasedStatusMetrics"] = SourceJSONFile
+
+	// Note: bool field
+	c.Cursor.BillingCycleMetric = jsonConfig.BillingCycleMetric
+	c.ConfigSources["Cursor.BillingCycleMetric"] = SourceJSONFile
+	if jsonConfig.HashTeamMembers != nil {
+		c.Cursor.HashTeamMembers = jsonConfig.HashTeamMembers
+		c.ConfigSources["Cursor.HashTeamMembers"] = SourceJSONFile
+	}
 }
 
 // mergeDaemonConfig merges Daemon configuration from JSON
@@ -1143,6 +3258,22 @@ func (c *AppConfig) mergeDaemonConfig(jsonConfig *DaemonConfig) {
 		c.Daemon.PidFile = jsonConfig.PidFile
 		c.ConfigSources["Daemon.PidFile"] = SourceJSONFile
 	}
+	if jsonConfig.LogMaxSizeMB != 0 {
+		c.Daemon.LogMaxSizeMB = jsonConfig.LogMaxSizeMB
+		c.ConfigSources["Daemon.LogMaxSizeMB"] = SourceJSONFile
+	}
+	if jsonConfig.LogMaxBackups != nil {
+		c.Daemon.LogMaxBackups = jsonConfig.LogMaxBackups
+		c.ConfigSources["Daemon.LogMaxBackups"] = SourceJSONFile
+	}
+	if jsonConfig.LogMaxAgeDays != nil {
+		c.Daemon.LogMaxAgeDays = jsonConfig.LogMaxAgeDays
+		c.ConfigSources["Daemon.LogMaxAgeDays"] = SourceJSONFile
+	}
+	if jsonConfig.LogCompress != nil {
+		c.Daemon.LogCompress = jsonConfig.LogCompress
+		c.ConfigSources["Daemon.LogCompress"] = SourceJSONFile
+	}
 }
 
 // mergeLoggingConfig merges Logging configuration from JSON
@@ -1191,6 +3322,26 @@ func (c *AppConfig) mergePromtailConfig(jsonConfig *PromtailConfig) {
 		c.Logging.Promtail.TimeoutSeconds = jsonConfig.TimeoutSeconds
 		c.ConfigSources["Promtail.TimeoutSeconds"] = SourceJSONFile
 	}
+	if jsonConfig.ClientCertPath != "" {
+		c.Logging.Promtail.ClientCertPath = jsonConfig.ClientCertPath
+		c.ConfigSources["Promtail.ClientCertPath"] = SourceJSONFile
+	}
+	if jsonConfig.ClientKeyPath != "" {
+		c.Logging.Promtail.ClientKeyPath = jsonConfig.ClientKeyPath
+		c.ConfigSources["Promtail.ClientKeyPath"] = SourceJSONFile
+	}
+	if jsonConfig.Compress != nil {
+		c.Logging.Promtail.Compress = jsonConfig.Compress
+		c.ConfigSources["Promtail.Compress"] = SourceJSONFile
+	}
+	if jsonConfig.PasswordFile != "" {
+		c.Logging.Promtail.PasswordFile = jsonConfig.PasswordFile
+		c.ConfigSources["Promtail.PasswordFile"] = SourceJSONFile
+	}
+	if jsonConfig.TokenFile != "" {
+		c.Logging.Promtail.TokenFile = jsonConfig.TokenFile
+		c.ConfigSources["Promtail.TokenFile"] = SourceJSONFile
+	}
 }
 
 // mergeBedrockConfig merges Bedrock configuration from JSON
@@ -1215,6 +3366,54 @@ func (c *AppConfig) mergeBedrockConfig(jsonConfig *BedrockConfig) {
 		c.Bedrock.Regions = jsonConfig.Regions
 		c.ConfigSources["Bedrock.Regions"] = SourceJSONFile
 	}
+	if jsonConfig.Namespace != "" {
+		c.Bedrock.Namespace = jsonConfig.Namespace
+		c.ConfigSources["Bedrock.Namespace"] = SourceJSONFile
+	}
+	if jsonConfig.InputTokenMetric != "" {
+		c.Bedrock.InputTokenMetric = jsonConfig.InputTokenMetric
+		c.ConfigSources["Bedrock.InputTokenMetric"] = SourceJSONFile
+	}
+	if jsonConfig.OutputTokenMetric != "" {
+		c.Bedrock.OutputTokenMetric = jsonConfig.OutputTokenMetric
+		c.ConfigSources["Bedrock.OutputTokenMetric"] = SourceJSONFile
+	}
+	if jsonConfig.InvocationsMetric != "" {
+		c.Bedrock.InvocationsMetric = jsonConfig.InvocationsMetric
+		c.ConfigSources["Bedrock.InvocationsMetric"] = SourceJSONFile
+	}
+	if jsonConfig.LatencyMetric != "" {
+		c.Bedrock.LatencyMetric = jsonConfig.LatencyMetric
+		c.ConfigSources["Bedrock.LatencyMetric"] = SourceJSONFile
+	}
+	if jsonConfig.ModelDimension != "" {
+		c.Bedrock.ModelDimension = jsonConfig.ModelDimension
+		c.ConfigSources["Bedrock.ModelDimension"] = SourceJSONFile
+	}
+	if len(jsonConfig.IncludeModels) > 0 {
+		c.Bedrock.IncludeModels = jsonConfig.IncludeModels
+		c.ConfigSources["Bedrock.IncludeModels"] = SourceJSONFile
+	}
+	if len(jsonConfig.ExcludeModels) > 0 {
+		c.Bedrock.ExcludeModels = jsonConfig.ExcludeModels
+		c.ConfigSources["Bedrock.ExcludeModels"] = SourceJSONFile
+	}
+	if jsonConfig.HostLabel != "" {
+		c.Bedrock.HostLabel = jsonConfig.HostLabel
+		c.ConfigSources["Bedrock.HostLabel"] = SourceJSONFile
+	}
+
+	// Note: bool field
+	c.Bedrock.AllowUnknownRegions = jsonConfig.AllowUnknownRegions
+	c.ConfigSources["Bedrock.AllowUnknownRegions"] = SourceJSONFile
+	if jsonConfig.MetricBatchSize != 0 {
+		c.Bedrock.MetricBatchSize = jsonConfig.MetricBatchSize
+		c.ConfigSources["Bedrock.MetricBatchSize"] = SourceJSONFile
+	}
+	if jsonConfig.IngestionDelaySec != 0 {
+		c.Bedrock.IngestionDelaySec = jsonConfig.IngestionDelaySec
+		c.ConfigSources["Bedrock.IngestionDelaySec"] = SourceJSONFile
+	}
 }
 
 // mergeVertexAIConfig merges VertexAI configuration from JSON
@@ -1239,6 +3438,26 @@ func (c *AppConfig) mergeVertexAIConfig(jsonConfig *VertexAIConfig) {
 		c.VertexAI.CollectionIntervalSec = jsonConfig.CollectionIntervalSec
 		c.ConfigSources["VertexAI.CollectionIntervalSec"] = SourceJSONFile
 	}
+	if jsonConfig.HostLabel != "" {
+		c.VertexAI.HostLabel = jsonConfig.HostLabel
+		c.ConfigSources["VertexAI.HostLabel"] = SourceJSONFile
+	}
+	if len(jsonConfig.ServiceAccountKeys) > 0 {
+		c.VertexAI.ServiceAccountKeys = jsonConfig.ServiceAccountKeys
+		c.ConfigSources["VertexAI.ServiceAccountKeys"] = SourceJSONFile
+	}
+
+	// Note: bool field
+	c.VertexAI.RequestMetrics = jsonConfig.RequestMetrics
+	c.ConfigSources["VertexAI.RequestMetrics"] = SourceJSONFile
+
+	// Note: bool field
+	c.VertexAI.RequestMetricsPerModel = jsonConfig.RequestMetricsPerModel
+	c.ConfigSources["VertexAI.RequestMetricsPerModel"] = SourceJSONFile
+	if jsonConfig.IngestionDelaySec != 0 {
+		c.VertexAI.IngestionDelaySec = jsonConfig.IngestionDelaySec
+		c.ConfigSources["VertexAI.IngestionDelaySec"] = SourceJSONFile
+	}
 }
 
 // mergeCSVExportConfig merges CSVExport configuration from JSON
@@ -1263,6 +3482,10 @@ func (c *AppConfig) mergeCSVExportConfig(jsonConfig *CSVExportConfig) {
 		c.CSVExport.TimeZone = jsonConfig.TimeZone
 		c.ConfigSources["CSVExport.TimeZone"] = SourceJSONFile
 	}
+
+	// Note: bool field
+	c.CSVExport.HashProjectPaths = jsonConfig.HashProjectPaths
+	c.ConfigSources["CSVExport.HashProjectPaths"] = SourceJSONFile
 }
 
 // splitCommaSeparated splits a comma-separated string into a slice of strings
@@ -1294,3 +3517,39 @@ func slicesEqual(a, b []string) bool {
 	}
 	return true
 }
+
+// mergeSummaryConfig merges Summary configuration from JSON
+func (c *AppConfig) mergeSummaryConfig(jsonConfig *SummaryConfig) {
+	// Note: bool fields need special handling because zero value is false
+	c.Summary.Enabled = jsonConfig.Enabled
+	c.ConfigSources["Summary.Enabled"] = SourceJSONFile
+
+	if jsonConfig.WebhookURL != "" {
+		c.Summary.WebhookURL = jsonConfig.WebhookURL
+		c.ConfigSources["Summary.WebhookURL"] = SourceJSONFile
+	}
+	if jsonConfig.SendTime != "" {
+		c.Summary.SendTime = jsonConfig.SendTime
+		c.ConfigSources["Summary.SendTime"] = SourceJSONFile
+	}
+	if jsonConfig.TopModels != 0 {
+		c.Summary.TopModels = jsonConfig.TopModels
+		c.ConfigSources["Summary.TopModels"] = SourceJSONFile
+	}
+	if jsonConfig.MaxRetries != 0 {
+		c.Summary.MaxRetries = jsonConfig.MaxRetries
+		c.ConfigSources["Summary.MaxRetries"] = SourceJSONFile
+	}
+}
+
+// mergePostCollectionHookConfig merges PostCollectionHook configuration from JSON
+func (c *AppConfig) mergePostCollectionHookConfig(jsonConfig *PostCollectionHookConfig) {
+	if jsonConfig.Command != "" {
+		c.PostCollectionHook.Command = jsonConfig.Command
+		c.ConfigSources["PostCollectionHook.Command"] = SourceJSONFile
+	}
+	if jsonConfig.TimeoutSec != 0 {
+		c.PostCollectionHook.TimeoutSec = jsonConfig.TimeoutSec
+		c.ConfigSources["PostCollectionHook.TimeoutSec"] = SourceJSONFile
+	}
+}
@@ -144,6 +144,24 @@ func TestVertexAIConfig_Validation(t *testing.T) {
 	}
 }
 
+func TestVertexAIConfig_ValidateServiceAccountKeys(t *testing.T) {
+	validKey := `{"type": "service_account", "project_id": "p", "private_key_id": "k", "private_key": "x", "client_email": "e"}`
+	newConfig := func(keys ...string) *AppConfig {
+		return &AppConfig{VertexAI: &VertexAIConfig{ServiceAccountKeys: keys, CollectionIntervalSec: 600}}
+	}
+
+	// A stale key next to a valid one is accepted during a rotation
+	assert.NoError(t, newConfig(`{"type": "user"}`, validKey).validateVertexAI())
+	// File paths are only checked when the keys are loaded
+	assert.NoError(t, newConfig(`{"type": "user"}`, "/path/to/new.json").validateVertexAI())
+
+	err := newConfig(`{"type": "user"}`, "not json {").validateVertexAI()
+	assert.NoError(t, err, "entries not starting with { are paths")
+
+	err = newConfig(`{"type": "user"}`, `{"broken"`).validateVertexAI()
+	assert.ErrorContains(t, err, "no valid key in service_account_keys")
+}
+
 func TestVertexAIConfig_EnvironmentTracking(t *testing.T) {
 	// Save original env vars
 	originalVars := map[string]string{
@@ -218,6 +236,42 @@ func TestVertexAIConfig_JSONMerge(t *testing.T) {
 	assert.Equal(t, SourceJSONFile, baseConfig.ConfigSources["VertexAI.CollectionIntervalSec"])
 }
 
+func TestDaemonConfig_JSONMergeKeepAll(t *testing.T) {
+	config := DefaultConfig()
+	config.MarkDefaults()
+	assert.Equal(t, DefaultDaemonLogMaxBackups, config.Daemon.MaxLogBackups())
+	assert.Equal(t, DefaultDaemonLogMaxAgeDays, config.Daemon.MaxLogAgeDays())
+
+	var jsonConfig AppConfig
+	require.NoError(t, json.Unmarshal([]byte(`{"daemon":{"log_max_backups":0,"log_max_age_days":0}}`), &jsonConfig))
+	config.MergeJSONConfig(&jsonConfig)
+
+	// 0 keeps every rotated file and is not replaced by the defaults
+	assert.Equal(t, 0, config.Daemon.MaxLogBackups())
+	assert.Equal(t, 0, config.Daemon.MaxLogAgeDays())
+	assert.Equal(t, SourceJSONFile, config.ConfigSources["Daemon.LogMaxBackups"])
+	assert.Equal(t, SourceJSONFile, config.ConfigSources["Daemon.LogMaxAgeDays"])
+}
+
+func TestCostPrecision_JSONMergeKeepsZero(t *testing.T) {
+	config := DefaultConfig()
+	config.MarkDefaults()
+	assert.Nil(t, config.CostPrecision)
+
+	var jsonConfig AppConfig
+	require.NoError(t, json.Unmarshal([]byte(`{"cost_precision":0}`), &jsonConfig))
+	config.MergeJSONConfig(&jsonConfig)
+
+	// 0 prints whole amounts, e.g. for JPY, and is not replaced by the default
+	require.NotNil(t, config.CostPrecision)
+	assert.Equal(t, 0, *config.CostPrecision)
+	assert.Equal(t, SourceJSONFile, config.ConfigSources["CostPrecision"])
+	assert.NoError(t, config.Validate())
+
+	config.CostPrecision = intPtr(-1)
+	assert.Error(t, config.Validate())
+}
+
 func TestVertexAIConfig_BackwardCompatibility(t *testing.T) {
 	// Test that old configs without ServiceAccountKey still work
 	oldConfigJSON := `{
@@ -274,3 +328,454 @@ func TestVertexAIConfig_CompleteValidation(t *testing.T) {
 	err := config.Validate()
 	assert.NoError(t, err)
 }
+
+func TestAppConfig_ForProfile(t *testing.T) {
+	base := DefaultConfig()
+	base.MarkDefaults()
+	base.Prometheus.RemoteWriteURL = "https://base.example.com/api/v1/write"
+	base.Prometheus.HostLabel = "base-host"
+	base.Prometheus.ScrapeListenAddress = ":9464"
+
+	profile := &ProfileConfig{
+		Name: "team",
+		Prometheus: &PrometheusConfig{
+			RemoteWriteURL: "https://team.example.com/api/v1/write",
+		},
+		Bedrock: &BedrockConfig{
+			Enabled: true,
+			Regions: []string{"us-west-2"},
+		},
+	}
+
+	effective := base.ForProfile(profile)
+
+	// Profile values override the top-level ones
+	assert.Equal(t, "https://team.example.com/api/v1/write", effective.Prometheus.RemoteWriteURL)
+	assert.True(t, effective.Bedrock.Enabled)
+	assert.Equal(t, []string{"us-west-2"}, effective.Bedrock.Regions)
+
+	// Unset profile values are inherited
+	assert.Equal(t, "base-host", effective.Prometheus.HostLabel)
+	assert.Equal(t, base.Prometheus.IntervalSec, effective.Prometheus.IntervalSec)
+	assert.Nil(t, effective.Profiles)
+
+	// The scrape endpoint is not inherited, so profiles don't bind the same address
+	assert.Empty(t, effective.Prometheus.ScrapeListenAddress)
+
+	// The top-level configuration is left untouched
+	assert.Equal(t, "https://base.example.com/api/v1/write", base.Prometheus.RemoteWriteURL)
+	assert.False(t, base.Bedrock.Enabled)
+}
+
+func TestAppConfig_ValidateProfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles []*ProfileConfig
+		wantErr  bool
+	}{
+		{
+			name:     "valid profiles",
+			profiles: []*ProfileConfig{{Name: "a"}, {Name: "b"}},
+		},
+		{
+			name:     "missing name",
+			profiles: []*ProfileConfig{{}},
+			wantErr:  true,
+		},
+		{
+			name:     "duplicate name",
+			profiles: []*ProfileConfig{{Name: "a"}, {Name: "a"}},
+			wantErr:  true,
+		},
+		{
+			name: "invalid backend",
+			profiles: []*ProfileConfig{{
+				Name:       "a",
+				Prometheus: &PrometheusConfig{RemoteWriteURL: "not-a-url"},
+			}},
+			wantErr: true,
+		},
+		{
+			name:     "name leaving the state directory",
+			profiles: []*ProfileConfig{{Name: "../a"}},
+			wantErr:  true,
+		},
+		{
+			name: "distinct scrape addresses",
+			profiles: []*ProfileConfig{
+				{Name: "a", Prometheus: &PrometheusConfig{ScrapeListenAddress: ":9465"}},
+				{Name: "b", Prometheus: &PrometheusConfig{ScrapeListenAddress: ":9466"}},
+			},
+		},
+		{
+			name:     "scrape address of the top-level configuration",
+			profiles: []*ProfileConfig{{Name: "a", Prometheus: &PrometheusConfig{ScrapeListenAddress: ":9464"}}},
+			wantErr:  true,
+		},
+		{
+			name: "scrape address of another profile",
+			profiles: []*ProfileConfig{
+				{Name: "a", Prometheus: &PrometheusConfig{ScrapeListenAddress: ":9465"}},
+				{Name: "b", Prometheus: &PrometheusConfig{ScrapeListenAddress: ":9465"}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Prometheus.ScrapeListenAddress = ":9464"
+			cfg.Profiles = tt.profiles
+			err := cfg.validateProfiles()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPrometheusConfig_ValidateTransforms(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform *MetricTransformConfig
+		key       string
+		others    map[string]*MetricTransformConfig
+		wantErr   bool
+	}{
+		{name: "valid", key: "tosage_cc_token", transform: &MetricTransformConfig{Multiplier: 0.001, RenameTo: "tosage_cc_ktoken"}},
+		{name: "zero multiplier", key: "tosage_cc_token", transform: &MetricTransformConfig{Offset: 1}, wantErr: true},
+		{name: "invalid rename", key: "tosage_cc_token", transform: &MetricTransformConfig{Multiplier: 2, RenameTo: "bad-name"}, wantErr: true},
+		{name: "invalid key", key: "bad name", transform: &MetricTransformConfig{Multiplier: 2}, wantErr: true},
+		{name: "nil transform", key: "tosage_cc_token", transform: nil, wantErr: true},
+		{name: "cycle marker", key: "tosage_last_collection_timestamp_seconds", transform: &MetricTransformConfig{Multiplier: 1, RenameTo: "collected_at"}, wantErr: true},
+		{name: "heartbeat", key: "tosage_up", transform: &MetricTransformConfig{Multiplier: 2}, wantErr: true},
+		{name: "rename to cycle marker", key: "tosage_cc_token", transform: &MetricTransformConfig{Multiplier: 1, RenameTo: "tosage_last_collection_timestamp_seconds"}, wantErr: true},
+		{name: "rename to own name", key: "tosage_cc_token", transform: &MetricTransformConfig{Multiplier: 2, RenameTo: "tosage_cc_token"}},
+		{
+			name:      "rename to another transformed metric",
+			key:       "tosage_cc_token",
+			transform: &MetricTransformConfig{Multiplier: 1, RenameTo: "tosage_cursor_token"},
+			others:    map[string]*MetricTransformConfig{"tosage_cursor_token": {Multiplier: 2}},
+			wantErr:   true,
+		},
+		{
+			name:      "two renames to one name",
+			key:       "tosage_cc_token",
+			transform: &MetricTransformConfig{Multiplier: 1, RenameTo: "ktoken"},
+			others:    map[string]*MetricTransformConfig{"tosage_cursor_token": {Multiplier: 1, RenameTo: "ktoken"}},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Prometheus.Transforms = map[string]*MetricTransformConfig{tt.key: tt.transform}
+			for name, transform := range tt.others {
+				cfg.Prometheus.Transforms[name] = transform
+			}
+			err := cfg.validatePrometheus()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBedrockConfig_ValidateMetricNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(b *BedrockConfig)
+		wantErr bool
+	}{
+		{name: "defaults", modify: func(b *BedrockConfig) {}},
+		{name: "custom names", modify: func(b *BedrockConfig) {
+			b.Namespace = "Custom/Bedrock"
+			b.InputTokenMetric = "PromptTokens"
+			b.ModelDimension = "Model"
+		}},
+		{name: "empty namespace", modify: func(b *BedrockConfig) { b.Namespace = "" }, wantErr: true},
+		{name: "empty input metric", modify: func(b *BedrockConfig) { b.InputTokenMetric = " " }, wantErr: true},
+		{name: "empty output metric", modify: func(b *BedrockConfig) { b.OutputTokenMetric = "" }, wantErr: true},
+		{name: "empty model dimension", modify: func(b *BedrockConfig) { b.ModelDimension = "" }, wantErr: true},
+		{name: "smaller metric batch", modify: func(b *BedrockConfig) { b.MetricBatchSize = 100 }},
+		{name: "no ingestion delay", modify: func(b *BedrockConfig) { b.IngestionDelaySec = -1 }},
+		{name: "ingestion delay over an hour", modify: func(b *BedrockConfig) { b.IngestionDelaySec = 3601 }, wantErr: true},
+		{name: "zero metric batch", modify: func(b *BedrockConfig) { b.MetricBatchSize = 0 }, wantErr: true},
+		{name: "metric batch over the limit", modify: func(b *BedrockConfig) { b.MetricBatchSize = 501 }, wantErr: true},
+		{name: "disabled", modify: func(b *BedrockConfig) { b.Enabled = false; b.Namespace = "" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Bedrock.Enabled = true
+			tt.modify(cfg.Bedrock)
+			err := cfg.validateBedrock()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBedrockConfig_ValidateRegions(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Bedrock.Enabled = true
+
+	cfg.Bedrock.Regions = []string{"us-east-1", "ap-northeast-1"}
+	assert.NoError(t, cfg.validateBedrock())
+
+	cfg.Bedrock.Regions = []string{"us-east-1", "us-east-11"}
+	err := cfg.validateBedrock()
+	assert.ErrorContains(t, err, `"us-east-11" is not a known Bedrock region`)
+	assert.ErrorContains(t, err, "us-west-2")
+
+	cfg.Bedrock.AllowUnknownRegions = true
+	assert.NoError(t, cfg.validateBedrock())
+}
+
+func TestSummaryConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary *SummaryConfig
+		wantErr bool
+	}{
+		{name: "disabled without URL", summary: &SummaryConfig{SendTime: "18:00"}},
+		{name: "valid", summary: &SummaryConfig{Enabled: true, WebhookURL: "https://hooks.example.com/x", SendTime: "09:30", TopModels: 5}},
+		{name: "missing URL", summary: &SummaryConfig{Enabled: true, SendTime: "18:00"}, wantErr: true},
+		{name: "non-http URL", summary: &SummaryConfig{Enabled: true, WebhookURL: "ftp://example.com", SendTime: "18:00"}, wantErr: true},
+		{name: "invalid send time", summary: &SummaryConfig{Enabled: true, WebhookURL: "https://hooks.example.com/x", SendTime: "25:00"}, wantErr: true},
+		{name: "negative top models", summary: &SummaryConfig{Enabled: true, WebhookURL: "https://hooks.example.com/x", SendTime: "18:00", TopModels: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Summary = tt.summary
+			err := cfg.validateSummary()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPrometheusConfig_ValidateClientCertificate(t *testing.T) {
+	missing := "/nonexistent/tosage/client.pem"
+	tests := []struct {
+		name    string
+		modify  func(p *PrometheusConfig)
+		wantErr bool
+	}{
+		{name: "basic auth only", modify: func(p *PrometheusConfig) {}},
+		{name: "no authentication", modify: func(p *PrometheusConfig) {
+			p.RemoteWriteUsername = ""
+			p.RemoteWritePassword = ""
+		}, wantErr: true},
+		{name: "cert without key", modify: func(p *PrometheusConfig) { p.ClientCertPath = missing }, wantErr: true},
+		{name: "unloadable pair", modify: func(p *PrometheusConfig) {
+			p.ClientCertPath = missing
+			p.ClientKeyPath = missing
+		}, wantErr: true},
+		{name: "partial basic auth", modify: func(p *PrometheusConfig) { p.RemoteWritePassword = "" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Prometheus.RemoteWriteURL = "https://prometheus.example.com/api/v1/write"
+			cfg.Prometheus.RemoteWriteUsername = "user"
+			cfg.Prometheus.RemoteWritePassword = "pass"
+			tt.modify(cfg.Prometheus)
+			err := cfg.validatePrometheus()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCursorConfig_ValidateBillingWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *CursorConfig)
+		wantErr bool
+	}{
+		{name: "defaults", modify: func(c *CursorConfig) {}},
+		{name: "unset billing day", modify: func(c *CursorConfig) { c.BillingDay = 0 }},
+		{name: "last allowed billing day", modify: func(c *CursorConfig) { c.BillingDay = 28 }},
+		{name: "billing day 29", modify: func(c *CursorConfig) { c.BillingDay = 29 }, wantErr: true},
+		{name: "negative billing day", modify: func(c *CursorConfig) { c.BillingDay = -1 }, wantErr: true},
+		{name: "day start hour 23", modify: func(c *CursorConfig) { c.DayStartHour = 23 }},
+		{name: "day start hour 24", modify: func(c *CursorConfig) { c.DayStartHour = 24 }, wantErr: true},
+		{name: "base URL", modify: func(c *CursorConfig) { c.BaseURL = "http://127.0.0.1:8080" }},
+		{name: "base URL without scheme", modify: func(c *CursorConfig) { c.BaseURL = "cursor.example.com" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(cfg.Cursor)
+			err := cfg.validateCursor()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPrometheusConfig_ValidateExtraLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr bool
+	}{
+		{name: "valid", labels: map[string]string{"team": "platform", "cost_center": "1234"}},
+		{name: "invalid name", labels: map[string]string{"cost-center": "1234"}, wantErr: true},
+		{name: "leading digit", labels: map[string]string{"1team": "platform"}, wantErr: true},
+		{name: "reserved prefix", labels: map[string]string{"__name__": "tosage"}, wantErr: true},
+		{name: "tosage label", labels: map[string]string{"host": "override"}, wantErr: true},
+		{name: "empty value", labels: map[string]string{"team": ""}, wantErr: true},
+		{name: "invalid UTF-8", labels: map[string]string{"team": "\xff"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Prometheus.ExtraLabels = tt.labels
+			err := cfg.validatePrometheus()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPrometheusConfig_ValidateMetricFilter(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Prometheus.MetricAllowlist = []string{"tosage_cc_token", "tosage_cursor_*"}
+	assert.NoError(t, cfg.validatePrometheus())
+
+	cfg.Prometheus.MetricDenylist = []string{"tosage_bedrock_*"}
+	assert.Error(t, cfg.validatePrometheus(), "allowlist and denylist together")
+
+	cfg.Prometheus.MetricAllowlist = nil
+	cfg.Prometheus.MetricDenylist = []string{"tosage_[cc"}
+	assert.Error(t, cfg.validatePrometheus(), "malformed pattern")
+}
+
+func TestPrometheusConfig_ValidateSchedule(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Prometheus.Schedule = "0 9-18 * * MON-FRI"
+	assert.NoError(t, cfg.validatePrometheus())
+
+	cfg.Prometheus.Schedule = "0 25 * * *"
+	assert.Error(t, cfg.validatePrometheus())
+}
+
+func TestPrometheusConfig_ValidateDerivedLabels(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Prometheus.DerivedLabels = []string{DerivedLabelMostUsedModel, DerivedLabelUniqueProjects, DerivedLabelUniqueSessions}
+	assert.NoError(t, cfg.validatePrometheus())
+
+	cfg.Prometheus.DerivedLabels = []string{"project_path"}
+	assert.Error(t, cfg.validatePrometheus())
+}
+
+func TestPrometheusConfig_ValidateBackends(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend MetricsBackendConfig
+		wantErr bool
+	}{
+		{name: "remote write by default", backend: MetricsBackendConfig{URL: "https://new.example.com/api/v1/write"}},
+		{name: "otlp", backend: MetricsBackendConfig{Type: BackendOTLP, URL: "http://collector:4318/v1/metrics"}},
+		{name: "missing url", backend: MetricsBackendConfig{Type: BackendOTLP}, wantErr: true},
+		{name: "unknown type", backend: MetricsBackendConfig{Type: "statsd", URL: "udp://localhost:8125"}, wantErr: true},
+		{name: "username without password", backend: MetricsBackendConfig{URL: "https://new.example.com", Username: "user"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Prometheus.Backends = []MetricsBackendConfig{tt.backend}
+			err := cfg.validatePrometheus()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAppConfig_ValidateDailyWindowMode(t *testing.T) {
+	for _, mode := range []string{"", "calendar", "rolling24h"} {
+		cfg := DefaultConfig()
+		cfg.DailyWindowMode = mode
+		assert.NoError(t, cfg.Validate(), "mode %q", mode)
+	}
+
+	cfg := DefaultConfig()
+	cfg.DailyWindowMode = "rolling"
+	assert.Error(t, cfg.Validate())
+	assert.Equal(t, "calendar", string(DefaultConfig().DailyWindow()))
+}
+
+func TestAppConfig_ValidateTokenComponents(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.True(t, cfg.TotalTokenComponents().IsAll())
+
+	cfg.TokenComponents = []string{"input", "output", "cache_creation"}
+	assert.NoError(t, cfg.Validate())
+	assert.False(t, cfg.TotalTokenComponents().IsAll())
+
+	cfg.TokenComponents = []string{"input", "cache"}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestAppConfig_ValidateSourceIntervals(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Prometheus.RemoteWriteURL = "https://prometheus.example.com/api/v1/write"
+	cfg.Prometheus.RemoteWriteUsername = "user"
+	cfg.Prometheus.RemoteWritePassword = "pass"
+	cfg.Prometheus.SourceIntervalSec = map[string]int{"bedrock": 1800, "claude_code": 300}
+	assert.NoError(t, cfg.validatePrometheus())
+
+	cfg.Prometheus.SourceIntervalSec = map[string]int{"bedrock": 30}
+	assert.ErrorContains(t, cfg.validatePrometheus(), "source_interval_seconds.bedrock must be at least")
+
+	cfg.Prometheus.SourceIntervalSec = map[string]int{"openai": 600}
+	assert.ErrorContains(t, cfg.validatePrometheus(), "unknown source")
+}
+
+func TestPrometheusConfig_ValidateScrapeRefresh(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Prometheus.RemoteWriteURL = "https://prometheus.example.com/api/v1/write"
+	cfg.Prometheus.RemoteWriteUsername = "user"
+	cfg.Prometheus.RemoteWritePassword = "pass"
+	cfg.Prometheus.ScrapeRefreshAfterSec = 120
+	assert.NoError(t, cfg.validatePrometheus())
+
+	cfg.Prometheus.ScrapeRefreshAfterSec = 5
+	assert.ErrorContains(t, cfg.validatePrometheus(), "must be at least")
+
+	cfg.Prometheus.ScrapeRefreshAfterSec = -1
+	assert.Error(t, cfg.validatePrometheus())
+}
@@ -0,0 +1,48 @@
+package config
+
+import "sort"
+
+// bedrockRegions are the AWS regions Amazon Bedrock is available in. Regions launched later
+// are accepted with bedrock.allow_unknown_regions until they are added here.
+var bedrockRegions = map[string]bool{
+	"us-east-1":      true,
+	"us-east-2":      true,
+	"us-west-1":      true,
+	"us-west-2":      true,
+	"us-gov-east-1":  true,
+	"us-gov-west-1":  true,
+	"ca-central-1":   true,
+	"sa-east-1":      true,
+	"eu-central-1":   true,
+	"eu-central-2":   true,
+	"eu-north-1":     true,
+	"eu-south-1":     true,
+	"eu-south-2":     true,
+	"eu-west-1":      true,
+	"eu-west-2":      true,
+	"eu-west-3":      true,
+	"ap-northeast-1": true,
+	"ap-northeast-2": true,
+	"ap-northeast-3": true,
+	"ap-south-1":     true,
+	"ap-south-2":     true,
+	"ap-southeast-1": true,
+	"ap-southeast-2": true,
+	"ap-southeast-3": true,
+	"ap-southeast-4": true,
+	"ap-southeast-5": true,
+	"ap-southeast-7": true,
+	"me-central-1":   true,
+	"me-south-1":     true,
+	"il-central-1":   true,
+}
+
+// BedrockRegionNames returns the known Bedrock regions, sorted
+func BedrockRegionNames() []string {
+	names := make([]string, 0, len(bedrockRegions))
+	for name := range bedrockRegions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
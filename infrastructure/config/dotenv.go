@@ -0,0 +1,122 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultEnvFile is the .env file read when TOSAGE_ENV_FILE is not set
+const DefaultEnvFile = ".env"
+
+// EnvFileVariable names the environment variable that overrides the .env path
+const EnvFileVariable = "TOSAGE_ENV_FILE"
+
+// envKeyPrefix limits .env loading to tosage settings
+const envKeyPrefix = "TOSAGE_"
+
+// EnvFilePath returns the .env file to load: TOSAGE_ENV_FILE, or ./.env
+func EnvFilePath() string {
+	if path := os.Getenv(EnvFileVariable); path != "" {
+		return path
+	}
+	return DefaultEnvFile
+}
+
+// LoadDotEnv sets the TOSAGE_* variables defined in a .env file. Variables already
+// present in the environment are left alone, so real environment variables take
+// precedence over the file. A missing file is not an error. It returns the keys it set.
+func LoadDotEnv(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open env file %s: %w", path, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var loaded []string
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		key, value, ok, err := parseDotEnvLine(scanner.Text())
+		if err != nil {
+			return loaded, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		if !ok || !strings.HasPrefix(key, envKeyPrefix) {
+			continue
+		}
+		if _, exists := os.LookupEnv(key); exists {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return loaded, fmt.Errorf("failed to set %s: %w", key, err)
+		}
+		loaded = append(loaded, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return loaded, fmt.Errorf("failed to read env file %s: %w", path, err)
+	}
+
+	return loaded, nil
+}
+
+// parseDotEnvLine parses KEY=VALUE with an optional "export " prefix. Values may be
+// single quoted (literal), double quoted (\n, \" and \\ escapes) or bare, where a
+// " #" starts a comment. Blank lines and comment lines return ok == false.
+func parseDotEnvLine(line string) (key, value string, ok bool, err error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false, nil
+	}
+	line = strings.TrimPrefix(line, "export ")
+
+	key, value, found := strings.Cut(line, "=")
+	if !found {
+		return "", "", false, fmt.Errorf("expected KEY=VALUE")
+	}
+	key = strings.TrimSpace(key)
+	if key == "" || strings.ContainsAny(key, " \t") {
+		return "", "", false, fmt.Errorf("invalid key %q", key)
+	}
+
+	value = strings.TrimSpace(value)
+	switch {
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", "", false, fmt.Errorf("unterminated quote in %s", key)
+		}
+		return key, value[1 : end+1], true, nil
+	case strings.HasPrefix(value, `"`):
+		var b strings.Builder
+		for i := 1; i < len(value); i++ {
+			switch c := value[i]; {
+			case c == '"':
+				return key, b.String(), true, nil
+			case c == '\\' && i+1 < len(value):
+				i++
+				switch value[i] {
+				case 'n':
+					b.WriteByte('\n')
+				default:
+					b.WriteByte(value[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", "", false, fmt.Errorf("unterminated quote in %s", key)
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return key, value, true, nil
+	}
+}
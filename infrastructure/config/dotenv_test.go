@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// unsetForTest unsets key for the duration of the test
+func unsetForTest(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "")
+	if err := os.Unsetenv(key); err != nil {
+		t.Fatalf("Unsetenv(%s) error = %v", key, err)
+	}
+}
+
+func TestParseDotEnvLine(t *testing.T) {
+	tests := []struct {
+		line      string
+		wantKey   string
+		wantValue string
+		wantOK    bool
+		wantErr   bool
+	}{
+		{line: "", wantOK: false},
+		{line: "# comment", wantOK: false},
+		{line: "TOSAGE_A=plain", wantKey: "TOSAGE_A", wantValue: "plain", wantOK: true},
+		{line: "export TOSAGE_A = spaced ", wantKey: "TOSAGE_A", wantValue: "spaced", wantOK: true},
+		{line: "TOSAGE_A=value # comment", wantKey: "TOSAGE_A", wantValue: "value", wantOK: true},
+		{line: "TOSAGE_A=pass#word", wantKey: "TOSAGE_A", wantValue: "pass#word", wantOK: true},
+		{line: `TOSAGE_A='lit\n # x'`, wantKey: "TOSAGE_A", wantValue: `lit\n # x`, wantOK: true},
+		{line: `TOSAGE_A="a\"b\nc" # comment`, wantKey: "TOSAGE_A", wantValue: "a\"b\nc", wantOK: true},
+		{line: "TOSAGE_A=", wantKey: "TOSAGE_A", wantValue: "", wantOK: true},
+		{line: "NO_EQUALS", wantErr: true},
+		{line: `TOSAGE_A="open`, wantErr: true},
+		{line: "BAD KEY=x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			key, value, ok, err := parseDotEnvLine(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDotEnvLine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if key != tt.wantKey || value != tt.wantValue || ok != tt.wantOK {
+				t.Errorf("parseDotEnvLine() = (%q, %q, %v), want (%q, %q, %v)", key, value, ok, tt.wantKey, tt.wantValue, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	// Other tests restore unset variables as empty strings, which LoadFromEnv rejects for bools
+	for _, entry := range os.Environ() {
+		if key, value, _ := strings.Cut(entry, "="); strings.HasPrefix(key, envKeyPrefix) && value == "" {
+			unsetForTest(t, key)
+		}
+	}
+	unsetForTest(t, "TOSAGE_PROMETHEUS_HOST_LABEL")
+	unsetForTest(t, "TOSAGE_CLAUDE_PATH")
+	t.Setenv("TOSAGE_PROMETHEUS_INTERVAL_SECONDS", "120")
+	unsetForTest(t, "OTHER_TOOL_SETTING")
+
+	path := filepath.Join(t.TempDir(), ".env")
+	content := "# local settings\n" +
+		"TOSAGE_PROMETHEUS_HOST_LABEL=dev-laptop\n" +
+		"TOSAGE_PROMETHEUS_INTERVAL_SECONDS=300\n" +
+		"OTHER_TOOL_SETTING=ignored\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	loaded, err := LoadDotEnv(path)
+	if err != nil {
+		t.Fatalf("LoadDotEnv() error = %v", err)
+	}
+	if len(loaded) != 1 || loaded[0] != "TOSAGE_PROMETHEUS_HOST_LABEL" {
+		t.Errorf("LoadDotEnv() loaded = %v, want [TOSAGE_PROMETHEUS_HOST_LABEL]", loaded)
+	}
+	if got := os.Getenv("TOSAGE_PROMETHEUS_INTERVAL_SECONDS"); got != "120" {
+		t.Errorf("real environment overridden: TOSAGE_PROMETHEUS_INTERVAL_SECONDS = %q, want 120", got)
+	}
+	if _, exists := os.LookupEnv("OTHER_TOOL_SETTING"); exists {
+		t.Error("non-TOSAGE key was loaded")
+	}
+
+	// Values from the file are tracked like any other environment variable
+	cfg := DefaultConfig()
+	cfg.MarkDefaults()
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if cfg.Prometheus.HostLabel != "dev-laptop" {
+		t.Errorf("HostLabel = %q, want dev-laptop", cfg.Prometheus.HostLabel)
+	}
+	if cfg.ConfigSources["Prometheus.HostLabel"] != SourceEnvironment {
+		t.Errorf("HostLabel source = %v, want %v", cfg.ConfigSources["Prometheus.HostLabel"], SourceEnvironment)
+	}
+}
+
+func TestLoadDotEnv_MissingFile(t *testing.T) {
+	loaded, err := LoadDotEnv(filepath.Join(t.TempDir(), ".env"))
+	if err != nil || len(loaded) != 0 {
+		t.Errorf("LoadDotEnv() = %v, %v; want no keys and no error", loaded, err)
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// SecretFile is a credential read from a file, e.g. one mounted by a secret manager.
+// The value is read when the file is loaded and again by ReloadSecretFiles, so a
+// rotated secret can be picked up without restarting.
+type SecretFile struct {
+	path  string
+	mu    sync.RWMutex
+	value string
+}
+
+var (
+	secretFilesMu sync.Mutex
+	secretFiles   []*SecretFile
+)
+
+// ReadSecretFile reads the credential held by the file at path. Surrounding
+// whitespace, such as the trailing newline most tools write, is removed.
+func ReadSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("secret file %s cannot be read: %w", path, err)
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return "", fmt.Errorf("secret file %s is empty", path)
+	}
+	return value, nil
+}
+
+// LoadSecretFile reads the file at path and registers it for ReloadSecretFiles
+func LoadSecretFile(path string) (*SecretFile, error) {
+	value, err := ReadSecretFile(path)
+	if err != nil {
+		return nil, err
+	}
+	secret := &SecretFile{path: path, value: value}
+
+	secretFilesMu.Lock()
+	secretFiles = append(secretFiles, secret)
+	secretFilesMu.Unlock()
+	return secret, nil
+}
+
+// Value returns the credential last read from the file
+func (s *SecretFile) Value() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.value
+}
+
+// Reload re-reads the file. The previous value is kept if the file cannot be read.
+func (s *SecretFile) Reload() error {
+	value, err := ReadSecretFile(s.path)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.value = value
+	s.mu.Unlock()
+	return nil
+}
+
+// ReloadSecretFiles re-reads every loaded secret file and returns the errors of the
+// files that could not be read
+func ReloadSecretFiles() error {
+	secretFilesMu.Lock()
+	files := append([]*SecretFile(nil), secretFiles...)
+	secretFilesMu.Unlock()
+
+	var errs []error
+	for _, secret := range files {
+		if err := secret.Reload(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
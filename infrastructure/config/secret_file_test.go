@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretFile_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte("first\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := LoadSecretFile(path)
+	if err != nil {
+		t.Fatalf("LoadSecretFile() error = %v", err)
+	}
+	if got := secret.Value(); got != "first" {
+		t.Errorf("Value() = %q, want first", got)
+	}
+
+	if err := os.WriteFile(path, []byte("second\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReloadSecretFiles(); err != nil {
+		t.Fatalf("ReloadSecretFiles() error = %v", err)
+	}
+	if got := secret.Value(); got != "second" {
+		t.Errorf("Value() after reload = %q, want second", got)
+	}
+
+	// A file that can no longer be read keeps the previous value
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReloadSecretFiles(); err == nil {
+		t.Error("ReloadSecretFiles() error = nil, want an error for the missing file")
+	}
+	if got := secret.Value(); got != "second" {
+		t.Errorf("Value() after failed reload = %q, want second", got)
+	}
+}
+
+func TestReadSecretFile_Invalid(t *testing.T) {
+	empty := filepath.Join(t.TempDir(), "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{empty, filepath.Join(t.TempDir(), "missing")} {
+		if _, err := ReadSecretFile(path); err == nil {
+			t.Errorf("ReadSecretFile(%s) error = nil, want an error", path)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SecretMask replaces the value of secret fields when configuration is exported
+const SecretMask = "****"
+
+// secretTag marks a configuration field holding a credential, e.g. `secret:"true"`
+const secretTag = "secret"
+
+// MaskSecrets masks the secret fields of a configuration section in its exported map,
+// which is keyed by JSON field name. A set secret is replaced by SecretMask and an empty
+// one is removed, so a field tagged secret:"true" is never exported in plaintext.
+func MaskSecrets(section interface{}, exported map[string]interface{}) {
+	v := reflect.ValueOf(section)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get(secretTag) != "true" {
+			continue
+		}
+		name := jsonFieldName(field)
+		if v.Field(i).IsZero() {
+			delete(exported, name)
+		} else {
+			exported[name] = SecretMask
+		}
+	}
+}
+
+// jsonFieldName returns the name a field is encoded as in the JSON configuration
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
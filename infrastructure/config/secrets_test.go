@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskSecrets(t *testing.T) {
+	// A newly added secret field is masked by its tag alone
+	type section struct {
+		URL      string `json:"url"`
+		APIToken string `json:"api_token,omitempty" secret:"true"`
+		Unset    string `json:"unset_secret" secret:"true"`
+	}
+	value := section{URL: "https://example.com", APIToken: "tok-123"}
+	exported := map[string]interface{}{
+		"url":          value.URL,
+		"api_token":    value.APIToken, // copied by mistake
+		"unset_secret": "",
+	}
+
+	MaskSecrets(&value, exported)
+
+	assert.Equal(t, "https://example.com", exported["url"])
+	assert.Equal(t, SecretMask, exported["api_token"])
+	assert.NotContains(t, exported, "unset_secret")
+
+	// Nil sections are ignored
+	MaskSecrets((*section)(nil), exported)
+}
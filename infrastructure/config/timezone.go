@@ -0,0 +1,53 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// referenceTimezone is the zone tosage uses for daily boundaries. It is in every timezone
+// database, so failing to load it means the database itself is missing.
+const referenceTimezone = "Asia/Tokyo"
+
+// ErrTimezoneDatabaseMissing is returned when no timezone database can be found
+var ErrTimezoneDatabaseMissing = errors.New("timezone database not found: install the tzdata package, or build tosage without the notzdata tag to embed it")
+
+// LoadTimezone loads an IANA timezone. When the name fails to load because there is no
+// timezone database at all, the error says so instead of reporting an unknown zone.
+func LoadTimezone(name string) (*time.Location, error) {
+	loc, err := time.LoadLocation(name)
+	if err == nil {
+		return loc, nil
+	}
+	if _, refErr := time.LoadLocation(referenceTimezone); refErr != nil {
+		return nil, fmt.Errorf("failed to load timezone %q: %w", name, ErrTimezoneDatabaseMissing)
+	}
+	return nil, err
+}
+
+// CheckTimezones loads every timezone tosage depends on: the zone used for daily boundaries,
+// the CSV export timezone and the TZ environment variable. It returns the zones it loaded.
+func CheckTimezones(cfg *AppConfig) ([]string, error) {
+	names := []string{referenceTimezone}
+	if cfg != nil && cfg.CSVExport != nil && cfg.CSVExport.TimeZone != "" && cfg.CSVExport.TimeZone != referenceTimezone {
+		names = append(names, cfg.CSVExport.TimeZone)
+	}
+	// TZ may also name a zone file, e.g. ":/etc/localtime", which is not a database lookup
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" && !filepath.IsAbs(tz) {
+		names = append(names, tz)
+	}
+
+	for _, name := range names {
+		if _, err := LoadTimezone(name); err != nil {
+			if errors.Is(err, ErrTimezoneDatabaseMissing) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("failed to load timezone %q: %w", name, err)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckTimezones(t *testing.T) {
+	cfg := &AppConfig{CSVExport: &CSVExportConfig{TimeZone: "America/New_York"}}
+
+	t.Setenv("TZ", ":/etc/localtime")
+	names, err := CheckTimezones(cfg)
+	if err != nil {
+		t.Fatalf("CheckTimezones() error = %v", err)
+	}
+	if got := strings.Join(names, ","); got != "Asia/Tokyo,America/New_York" {
+		t.Errorf("CheckTimezones() = %q, want the reference and CSV export zones", got)
+	}
+
+	t.Setenv("TZ", "Nowhere/Atlantis")
+	_, err = CheckTimezones(cfg)
+	if err == nil || !strings.Contains(err.Error(), "Nowhere/Atlantis") {
+		t.Errorf("CheckTimezones() error = %v, want one naming the TZ zone", err)
+	}
+	if errors.Is(err, ErrTimezoneDatabaseMissing) {
+		t.Errorf("an unknown zone was reported as a missing timezone database")
+	}
+}
@@ -4,12 +4,17 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/ca-srg/tosage/domain"
+	"github.com/ca-srg/tosage/domain/entity"
 	"github.com/ca-srg/tosage/domain/repository"
 	"github.com/ca-srg/tosage/infrastructure/auth"
 	"github.com/ca-srg/tosage/infrastructure/config"
+	"github.com/ca-srg/tosage/infrastructure/httpclient"
 	"github.com/ca-srg/tosage/infrastructure/logging"
 	infraRepo "github.com/ca-srg/tosage/infrastructure/repository"
 	"github.com/ca-srg/tosage/infrastructure/service"
@@ -28,12 +33,16 @@ type Container struct {
 	// Repositories
 	ccRepo          repository.CcRepository
 	metricsRepo     repository.MetricsRepository
+	metricsQuery    repository.MetricsQueryRepository
 	cursorTokenRepo repository.CursorTokenRepository
 	cursorAPIRepo   repository.CursorAPIRepository
 	bedrockRepo     repository.BedrockRepository
 	vertexAIRepo    repository.VertexAIRepository
 	csvWriterRepo   repository.CSVWriterRepository
 
+	// scrapeRepo records the metrics the scrape endpoint serves, if one is configured
+	scrapeRepo *infraRepo.ScrapeMetricsRepository
+
 	// Services
 	timezoneService repository.TimezoneService
 
@@ -47,6 +56,7 @@ type Container struct {
 	restartManager       usecase.RestartManager
 	metricsDataCollector usecase.MetricsDataCollector
 	csvExportService     usecase.CSVExportService
+	summaryService       usecase.SummaryService
 
 	// Presenters
 	consolePresenter presenter.ConsolePresenter
@@ -61,11 +71,36 @@ type Container struct {
 	// Logging
 	loggerFactory domain.LoggerFactory
 	logger        domain.Logger
+	logFile       *logging.FileSink
 
 	// Options
 	debugMode       bool
 	bedrockEnabled  bool
 	vertexAIEnabled bool
+	providers       map[string]bool
+	metricsInterval time.Duration
+	excludeModels   []string
+	entryFilter     *entity.CcEntryFilter
+	version         string
+	rawNumbers      bool
+	numberSeparator string
+	logPreview      bool
+
+	// Startup checks, appended to by startup probes running in the background
+	startupMu     sync.Mutex
+	startupChecks []StartupCheck
+	startupProbes sync.WaitGroup
+
+	// Profiles
+	profileName string
+	profiles    []*Container
+}
+
+// StartupCheck is the result of a connectivity check performed during initialization
+type StartupCheck struct {
+	Name   string
+	OK     bool
+	Detail string
 }
 
 // ContainerOption is a function that configures the container
@@ -92,6 +127,99 @@ func WithVertexAIEnabled(enabled bool) ContainerOption {
 	}
 }
 
+// WithProviders enables exactly the given providers (usecase.MetricsSource* values), overriding
+// the configuration. Unlike WithBedrockEnabled and WithVertexAIEnabled, it keeps Claude Code and
+// Cursor running next to a cloud provider when they are in the set.
+func WithProviders(providers []string) ContainerOption {
+	return func(c *Container) {
+		c.providers = make(map[string]bool, len(providers))
+		for _, provider := range providers {
+			c.providers[provider] = true
+		}
+		c.bedrockEnabled = c.providers[usecase.MetricsSourceBedrock]
+		c.vertexAIEnabled = c.providers[usecase.MetricsSourceVertexAI]
+	}
+}
+
+// providerAliases maps the names accepted by ParseProviders to usecase.MetricsSource* values
+var providerAliases = map[string]string{
+	"claude":                        usecase.MetricsSourceClaudeCode,
+	usecase.MetricsSourceClaudeCode: usecase.MetricsSourceClaudeCode,
+	usecase.MetricsSourceCursor:     usecase.MetricsSourceCursor,
+	usecase.MetricsSourceBedrock:    usecase.MetricsSourceBedrock,
+	"vertex-ai":                     usecase.MetricsSourceVertexAI,
+	usecase.MetricsSourceVertexAI:   usecase.MetricsSourceVertexAI,
+}
+
+// ParseProviders parses a comma-separated provider list such as "claude,cursor,bedrock"
+// into usecase.MetricsSource* values for WithProviders
+func ParseProviders(value string) ([]string, error) {
+	var providers []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		provider, ok := providerAliases[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown provider %q (valid: claude, cursor, bedrock, vertex_ai)", name)
+		}
+		if !seen[provider] {
+			seen[provider] = true
+			providers = append(providers, provider)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no providers given")
+	}
+	return providers, nil
+}
+
+// WithMetricsInterval overrides the configured metrics push interval
+func WithMetricsInterval(interval time.Duration) ContainerOption {
+	return func(c *Container) {
+		c.metricsInterval = interval
+	}
+}
+
+// WithExcludedModels excludes additional Claude Code models on top of the configured ones
+func WithExcludedModels(patterns []string) ContainerOption {
+	return func(c *Container) {
+		c.excludeModels = append(c.excludeModels, patterns...)
+	}
+}
+
+// WithEntryFilter restricts Claude Code totals and metrics to the entries matching filter
+func WithEntryFilter(filter *entity.CcEntryFilter) ContainerOption {
+	return func(c *Container) {
+		c.entryFilter = filter
+	}
+}
+
+// WithNumberFormat controls how the console presenter formats numbers:
+// raw prints plain integers, otherwise digits are grouped with separator (default ",")
+func WithNumberFormat(raw bool, separator string) ContainerOption {
+	return func(c *Container) {
+		c.rawNumbers = raw
+		c.numberSeparator = separator
+	}
+}
+
+// WithLogPreview prints the log stream, with its labels, to stdout instead of pushing it to Loki
+func WithLogPreview(enabled bool) ContainerOption {
+	return func(c *Container) {
+		c.logPreview = enabled
+	}
+}
+
+// WithVersion sets the build version used in the default User-Agent
+func WithVersion(version string) ContainerOption {
+	return func(c *Container) {
+		c.version = version
+	}
+}
+
 // NewContainer creates a new DI container
 func NewContainer(opts ...ContainerOption) (*Container, error) {
 	container := &Container{}
@@ -104,10 +232,10 @@ func NewContainer(opts ...ContainerOption) (*Container, error) {
 	// Debug: Log container state
 	if container.debugMode {
 		if container.bedrockEnabled {
-			fmt.Fprintf(os.Stderr, "Debug: Bedrock is enabled via command line flag\n")
+			config.Debugf("Debug: Bedrock is enabled via command line flag\n")
 		}
 		if container.vertexAIEnabled {
-			fmt.Fprintf(os.Stderr, "Debug: Vertex AI is enabled via command line flag\n")
+			config.Debugf("Debug: Vertex AI is enabled via command line flag\n")
 		}
 	}
 
@@ -116,10 +244,15 @@ func NewContainer(opts ...ContainerOption) (*Container, error) {
 		return nil, fmt.Errorf("failed to initialize config: %w", err)
 	}
 
+	// Identify outbound HTTP requests and bound how many run at once
+	container.initUserAgent()
+	container.initRequestLimit()
+
 	// Initialize logging
 	if err := container.initLogging(); err != nil {
 		return nil, fmt.Errorf("failed to initialize logging: %w", err)
 	}
+	container.checkTimezones()
 
 	// Initialize repositories
 	if err := container.initRepositories(); err != nil {
@@ -223,7 +356,7 @@ func (c *Container) initConfig() error {
 	// Override Bedrock enabled state if set via command line
 	if c.bedrockEnabled {
 		if c.debugMode {
-			fmt.Fprintf(os.Stderr, "Debug: Setting up Bedrock configuration\n")
+			config.Debugf("Debug: Setting up Bedrock configuration\n")
 		}
 		if cfg.Bedrock == nil {
 			cfg.Bedrock = &config.BedrockConfig{
@@ -232,18 +365,34 @@ func (c *Container) initConfig() error {
 				AWSProfile:            "",
 				AssumeRoleARN:         "",
 				CollectionIntervalSec: 900,
+				Namespace:             config.DefaultBedrockNamespace,
+				InputTokenMetric:      config.DefaultBedrockInputTokenMetric,
+				OutputTokenMetric:     config.DefaultBedrockOutputTokenMetric,
+				InvocationsMetric:     config.DefaultBedrockInvocationsMetric,
+				LatencyMetric:         config.DefaultBedrockLatencyMetric,
+				ModelDimension:        config.DefaultBedrockModelDimension,
 			}
 			if c.debugMode {
-				fmt.Fprintf(os.Stderr, "Debug: Created new Bedrock config\n")
+				config.Debugf("Debug: Created new Bedrock config\n")
 			}
 		} else {
 			cfg.Bedrock.Enabled = true
 			if c.debugMode {
-				fmt.Fprintf(os.Stderr, "Debug: Updated existing Bedrock config\n")
+				config.Debugf("Debug: Updated existing Bedrock config\n")
 			}
 		}
 	}
 
+	// A provider set switches off the cloud providers it leaves out
+	if c.providers != nil {
+		if !c.bedrockEnabled && cfg.Bedrock != nil {
+			cfg.Bedrock.Enabled = false
+		}
+		if !c.vertexAIEnabled && cfg.VertexAI != nil {
+			cfg.VertexAI.Enabled = false
+		}
+	}
+
 	// Override Vertex AI enabled state if set via command line
 	if c.vertexAIEnabled {
 		if cfg.VertexAI == nil {
@@ -269,6 +418,20 @@ func (c *Container) initConfig() error {
 	return nil
 }
 
+// initUserAgent sets the User-Agent of outbound HTTP requests from the config or the build version
+func (c *Container) initUserAgent() {
+	if c.config.UserAgent != "" {
+		httpclient.SetUserAgent(c.config.UserAgent)
+		return
+	}
+	httpclient.SetUserAgent(httpclient.DefaultUserAgent(c.version))
+}
+
+// initRequestLimit limits the outbound HTTP and gRPC requests in flight across all providers
+func (c *Container) initRequestLimit() {
+	httpclient.SetMaxConcurrentRequests(c.config.ConcurrentRequestLimit())
+}
+
 // initLogging initializes logging components
 func (c *Container) initLogging() error {
 	// Ensure logging configuration exists
@@ -286,7 +449,14 @@ func (c *Container) initLogging() error {
 	}
 
 	// Create logger factory
-	c.loggerFactory = logging.NewLoggerFactory(c.config.Logging)
+	var factoryOpts []logging.LoggerFactoryOption
+	if c.logPreview {
+		factoryOpts = append(factoryOpts, logging.WithPreviewWriter(os.Stdout))
+	}
+	c.logFile = logging.NewFileSink()
+	factoryOpts = append(factoryOpts, logging.WithFileSink(c.logFile))
+	c.loggerFactory = logging.NewLoggerFactory(c.config.Logging, factoryOpts...)
+	c.openDaemonLogFile()
 
 	// Create main logger for the container
 	c.logger = c.loggerFactory.CreateLogger("tosage")
@@ -294,46 +464,107 @@ func (c *Container) initLogging() error {
 	return nil
 }
 
+// openDaemonLogFile starts writing logs to the daemon log file, rotated as configured,
+// once daemon mode is enabled. Failing to open the file is reported but not fatal.
+func (c *Container) openDaemonLogFile() {
+	daemon := c.config.Daemon
+	if c.logFile == nil || c.logFile.IsOpen() || daemon == nil || !daemon.Enabled || daemon.LogPath == "" {
+		return
+	}
+
+	maxSizeMB := daemon.LogMaxSizeMB
+	if maxSizeMB == 0 {
+		maxSizeMB = config.DefaultDaemonLogMaxSizeMB
+	}
+	writer, err := logging.NewRotatingFileWriter(daemon.LogPath, logging.RotationOptions{
+		MaxSizeMB:  maxSizeMB,
+		MaxBackups: daemon.MaxLogBackups(),
+		MaxAgeDays: daemon.MaxLogAgeDays(),
+		Compress:   daemon.ShouldCompressLogs(),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to open daemon log file %s: %v\n", daemon.LogPath, err)
+		return
+	}
+	c.logFile.SetWriter(writer)
+}
+
 // initRepositories initializes repository implementations
 func (c *Container) initRepositories() error {
 	// Debug: Log repository initialization
 	if c.debugMode {
 		if c.debugMode {
-			fmt.Fprintf(os.Stderr, "Debug: Starting repository initialization\n")
-			fmt.Fprintf(os.Stderr, "Debug: bedrockEnabled=%v, vertexAIEnabled=%v\n", c.bedrockEnabled, c.vertexAIEnabled)
+			config.Debugf("Debug: Starting repository initialization\n")
+			config.Debugf("Debug: bedrockEnabled=%v, vertexAIEnabled=%v\n", c.bedrockEnabled, c.vertexAIEnabled)
 		}
 		if c.config.Bedrock != nil {
 			if c.debugMode {
-				fmt.Fprintf(os.Stderr, "Debug: Bedrock config exists, enabled=%v\n", c.config.Bedrock.Enabled)
+				config.Debugf("Debug: Bedrock config exists, enabled=%v\n", c.config.Bedrock.Enabled)
 			}
 		}
 	}
-	// Initialize usage repository only if Bedrock and Vertex AI are not enabled
-	if !c.bedrockEnabled && !c.vertexAIEnabled {
-		c.ccRepo = infraRepo.NewJSONLCcRepository(c.config.ClaudePath)
+	// Initialize usage repository only if Claude Code is collected
+	if c.localProviderEnabled(usecase.MetricsSourceClaudeCode) {
+		ccRepo := infraRepo.NewJSONLCcRepository(c.config.ClaudePath)
+		ccRepo.SetHeuristicDedup(c.config.HeuristicDedup)
+		ccRepo.SetParseWorkers(c.config.ParseWorkers)
+		ccRepo.SetWalkTimeout(time.Duration(c.config.WalkTimeoutSec) * time.Second)
+		ccRepo.SetListingCacheTTL(time.Duration(c.config.ListingCacheSec) * time.Second)
+		ccRepo.SetTokenCap(c.config.MaxEntryTokens, c.config.MaxEntryTokensAction)
+		ccRepo.SetMaxLineBytes(c.config.MaxLineBytes)
+		if ignoreBefore, err := config.ParseIgnoreBeforeDate(c.config.IgnoreBeforeDate); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Ignoring invalid cutoff date: %v\n", err)
+		} else {
+			ccRepo.SetIgnoreBefore(ignoreBefore)
+		}
+		for _, source := range c.config.RemoteSources {
+			mirror, err := infraRepo.NewSFTPCcMirror(source)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: Skipping remote source %s: %v\n", source.DisplayName(), err)
+				if err := c.providerInitFailed("remote source "+source.DisplayName(), err); err != nil {
+					return err
+				}
+				continue
+			}
+			ccRepo.AddMirror(mirror)
+		}
+		c.ccRepo = ccRepo
 	}
 
-	// Initialize Cursor repositories only if Bedrock and Vertex AI are not enabled and if Cursor config exists
-	if !c.bedrockEnabled && !c.vertexAIEnabled {
+	// Initialize Cursor repositories only if Cursor is collected and if Cursor config exists
+	if c.localProviderEnabled(usecase.MetricsSourceCursor) {
 		if c.config.Cursor != nil {
 			c.cursorTokenRepo = infraRepo.NewCursorDBRepository(c.config.Cursor.DatabasePath)
-			c.cursorAPIRepo = infraRepo.NewCursorAPIRepository(time.Duration(c.config.Cursor.APITimeout) * time.Second)
+			c.cursorAPIRepo = infraRepo.NewCursorAPIRepository(
+				time.Duration(c.config.Cursor.APITimeout)*time.Second,
+				infraRepo.WithBaseURL(c.config.Cursor.BaseURL),
+				infraRepo.WithBillingDay(c.config.Cursor.BillingDay),
+				infraRepo.WithDayStartHour(c.config.Cursor.DayStartHour),
+				infraRepo.WithDailyWindowMode(c.config.DailyWindow()),
+				infraRepo.WithCursorLogger(c.CreateLogger("cursor")),
+				infraRepo.WithRateLimit(c.config.Cursor.RateLimit, c.config.Cursor.RateLimitBurst),
+			)
 		} else {
 			// Create default Cursor config if not exists
 			c.config.Cursor = &config.CursorConfig{
 				DatabasePath: "",
 				APITimeout:   30,
 				CacheTimeout: 300,
+				BillingDay:   config.DefaultCursorBillingDay,
 			}
 			c.cursorTokenRepo = infraRepo.NewCursorDBRepository(c.config.Cursor.DatabasePath)
-			c.cursorAPIRepo = infraRepo.NewCursorAPIRepository(time.Duration(c.config.Cursor.APITimeout) * time.Second)
+			c.cursorAPIRepo = infraRepo.NewCursorAPIRepository(
+				time.Duration(c.config.Cursor.APITimeout)*time.Second,
+				infraRepo.WithDailyWindowMode(c.config.DailyWindow()),
+				infraRepo.WithCursorLogger(c.CreateLogger("cursor")),
+			)
 		}
 	}
 
 	// Initialize Bedrock repository if enabled
 	if c.config.Bedrock != nil && c.config.Bedrock.Enabled {
 		if c.debugMode {
-			fmt.Fprintf(os.Stderr, "Debug: Attempting to initialize Bedrock repository\n")
+			config.Debugf("Debug: Attempting to initialize Bedrock repository\n")
 		}
 		bedrockRepo, err := infraRepo.NewBedrockCloudWatchRepository(c.config.Bedrock.AWSProfile)
 		if err != nil {
@@ -350,18 +581,30 @@ func (c *Container) initRepositories() error {
 					domain.NewField("regions", c.config.Bedrock.Regions),
 					domain.NewField("error_type", fmt.Sprintf("%T", err)),
 					domain.NewField("error_details", err.Error()))
-				fmt.Fprintf(os.Stderr, "Debug: AWS Profile: %s\n", c.config.Bedrock.AWSProfile)
-				fmt.Fprintf(os.Stderr, "Debug: Regions: %v\n", c.config.Bedrock.Regions)
+				config.Debugf("Debug: AWS Profile: %s\n", c.config.Bedrock.AWSProfile)
+				config.Debugf("Debug: Regions: %v\n", c.config.Bedrock.Regions)
+			}
+			if err := c.providerInitFailed("Bedrock repository", err); err != nil {
+				return err
 			}
 		} else {
+			bedrockRepo.SetMetricNames(infraRepo.CloudWatchMetricNames{
+				Namespace:         c.config.Bedrock.Namespace,
+				InputTokenMetric:  c.config.Bedrock.InputTokenMetric,
+				OutputTokenMetric: c.config.Bedrock.OutputTokenMetric,
+				InvocationsMetric: c.config.Bedrock.InvocationsMetric,
+				LatencyMetric:     c.config.Bedrock.LatencyMetric,
+				ModelDimension:    c.config.Bedrock.ModelDimension,
+			})
+			bedrockRepo.SetMetricBatchSize(c.config.Bedrock.MetricBatchSize)
 			c.bedrockRepo = bedrockRepo
 			if c.debugMode {
-				fmt.Fprintf(os.Stderr, "Debug: Bedrock repository initialized successfully\n")
+				config.Debugf("Debug: Bedrock repository initialized successfully\n")
 			}
 		}
 	} else {
 		if c.debugMode {
-			fmt.Fprintf(os.Stderr, "Debug: Bedrock repository not initialized (config=%v, enabled=%v)\n",
+			config.Debugf("Debug: Bedrock repository not initialized (config=%v, enabled=%v)\n",
 				c.config.Bedrock != nil, c.config.Bedrock != nil && c.config.Bedrock.Enabled)
 		}
 	}
@@ -374,12 +617,12 @@ func (c *Container) initRepositories() error {
 			// Also output to stderr for immediate visibility
 			fmt.Fprintf(os.Stderr, "Warning: Vertex AI is enabled but project ID is not set\n")
 			fmt.Fprintf(os.Stderr, "Please set GOOGLE_CLOUD_PROJECT environment variable.\n")
+			if err := c.providerInitFailed("Vertex AI", fmt.Errorf("project ID is not set")); err != nil {
+				return err
+			}
 		} else {
 			// Create authenticator first
-			authenticator, err := auth.NewVertexAIAuthenticator(
-				c.config.VertexAI.ServiceAccountKey,
-				c.config.VertexAI.ServiceAccountKeyPath,
-			)
+			authenticator, err := c.newVertexAIAuthenticator()
 			if err != nil {
 				// Log warning but don't fail initialization
 				c.logger.Warn(context.TODO(), "Failed to create Vertex AI authenticator", domain.NewField("error", err.Error()))
@@ -395,9 +638,12 @@ func (c *Container) initRepositories() error {
 						domain.NewField("has_service_account_key", c.config.VertexAI.ServiceAccountKey != ""),
 						domain.NewField("error_type", fmt.Sprintf("%T", err)),
 						domain.NewField("error_details", err.Error()))
-					fmt.Fprintf(os.Stderr, "Debug: Project ID: %s\n", c.config.VertexAI.ProjectID)
-					fmt.Fprintf(os.Stderr, "Debug: Service Account Key Path: %s\n", c.config.VertexAI.ServiceAccountKeyPath)
-					fmt.Fprintf(os.Stderr, "Debug: Has Service Account Key: %v\n", c.config.VertexAI.ServiceAccountKey != "")
+					config.Debugf("Debug: Project ID: %s\n", c.config.VertexAI.ProjectID)
+					config.Debugf("Debug: Service Account Key Path: %s\n", c.config.VertexAI.ServiceAccountKeyPath)
+					config.Debugf("Debug: Has Service Account Key: %v\n", c.config.VertexAI.ServiceAccountKey != "")
+				}
+				if err := c.providerInitFailed("Vertex AI authenticator", err); err != nil {
+					return err
 				}
 			} else {
 				// Create REST repository with authenticator
@@ -414,21 +660,27 @@ func (c *Container) initRepositories() error {
 							domain.NewField("project_id", c.config.VertexAI.ProjectID),
 							domain.NewField("error_type", fmt.Sprintf("%T", err)),
 							domain.NewField("error_details", err.Error()))
-                    }
-                } else {
-                    vertexAIMonitoringRepo, err := infraRepo.NewVertexAIMonitoringRepository(c.config.VertexAI.ProjectID, authenticator)
-                    if err != nil {
-                        c.logger.Warn(context.TODO(), "Failed to initialize Vertex AI Monitoring repository", domain.NewField("error", err.Error()))
-                        fmt.Fprintf(os.Stderr, "Warning: Failed to initialize Vertex AI Monitoring repository: %v\n", err)
-                    } else {
-                        c.vertexAIRepo = vertexAIMonitoringRepo
-                        c.logger.Info(context.TODO(), "Vertex AI Monitoring repository initialized",
-                            domain.NewField("project_id", c.config.VertexAI.ProjectID))
-                    }
-                }
-            }
-        }
-    }
+					}
+					if err := c.providerInitFailed("Vertex AI repository", err); err != nil {
+						return err
+					}
+				} else {
+					vertexAIMonitoringRepo, err := infraRepo.NewVertexAIMonitoringRepository(c.config.VertexAI.ProjectID, authenticator)
+					if err != nil {
+						c.logger.Warn(context.TODO(), "Failed to initialize Vertex AI Monitoring repository", domain.NewField("error", err.Error()))
+						fmt.Fprintf(os.Stderr, "Warning: Failed to initialize Vertex AI Monitoring repository: %v\n", err)
+						if err := c.providerInitFailed("Vertex AI Monitoring repository", err); err != nil {
+							return err
+						}
+					} else {
+						c.vertexAIRepo = vertexAIMonitoringRepo
+						c.logger.Info(context.TODO(), "Vertex AI Monitoring repository initialized",
+							domain.NewField("project_id", c.config.VertexAI.ProjectID))
+					}
+				}
+			}
+		}
+	}
 
 	// Initialize CSV writer repository
 	c.csvWriterRepo = infraRepo.NewCSVWriterRepository(c.CreateLogger("csv-writer"))
@@ -436,6 +688,15 @@ func (c *Container) initRepositories() error {
 	return nil
 }
 
+// providerInitFailed returns the error that stops startup when a provider failed to
+// initialize and FailFastOnProviderInitError is set; otherwise the provider is skipped
+func (c *Container) providerInitFailed(provider string, err error) error {
+	if !c.config.FailFastOnProviderInitError {
+		return nil
+	}
+	return fmt.Errorf("failed to initialize %s: %w", provider, err)
+}
+
 // initDomainServices initializes domain services
 func (c *Container) initDomainServices() error {
 	// Initialize timezone service
@@ -445,16 +706,23 @@ func (c *Container) initDomainServices() error {
 
 // initUseCases initializes use case implementations
 func (c *Container) initUseCases() error {
-	// Initialize CC service only if Bedrock and Vertex AI are not enabled
-	if !c.bedrockEnabled && !c.vertexAIEnabled {
-		c.ccService = impl.NewCcServiceImpl(c.ccRepo, c.timezoneService)
+	// Initialize CC service only if Claude Code is collected
+	if c.localProviderEnabled(usecase.MetricsSourceClaudeCode) {
+		c.ccService = impl.NewCcServiceImpl(
+			c.ccRepo,
+			c.timezoneService,
+			impl.WithExcludedModels(append(append([]string{}, c.config.ExcludeModels...), c.excludeModels...)),
+			impl.WithEntryFilter(c.entryFilter),
+			impl.WithCcDailyWindowMode(c.config.DailyWindow()),
+			impl.WithTotalTokenComponents(c.config.TotalTokenComponents()),
+		)
 	}
 
 	// Initialize Status service
 	c.statusService = impl.NewStatusService()
 
-	// Initialize Cursor service only if Bedrock and Vertex AI are not enabled and if configured
-	if !c.bedrockEnabled && !c.vertexAIEnabled && c.config.Cursor != nil && c.cursorTokenRepo != nil && c.cursorAPIRepo != nil {
+	// Initialize Cursor service only if Cursor is collected and if configured
+	if c.localProviderEnabled(usecase.MetricsSourceCursor) && c.config.Cursor != nil && c.cursorTokenRepo != nil && c.cursorAPIRepo != nil {
 		c.cursorService = impl.NewCursorService(c.cursorTokenRepo, c.cursorAPIRepo, c.config.Cursor)
 	}
 
@@ -466,6 +734,9 @@ func (c *Container) initUseCases() error {
 			AWSProfile:         c.config.Bedrock.AWSProfile,
 			AssumeRoleARN:      c.config.Bedrock.AssumeRoleARN,
 			CollectionInterval: time.Duration(c.config.Bedrock.CollectionIntervalSec) * time.Second,
+			IncludeModels:      c.config.Bedrock.IncludeModels,
+			ExcludeModels:      c.config.Bedrock.ExcludeModels,
+			IngestionDelay:     time.Duration(c.config.Bedrock.IngestionDelaySec) * time.Second,
 		}
 		c.bedrockService = impl.NewBedrockService(c.bedrockRepo, bedrockConfig, c.CreateLogger("bedrock"))
 	}
@@ -478,10 +749,13 @@ func (c *Container) initUseCases() error {
 			ServiceAccountKeyPath: c.config.VertexAI.ServiceAccountKeyPath,
 			ServiceAccountKey:     c.config.VertexAI.ServiceAccountKey,
 			CollectionInterval:    time.Duration(c.config.VertexAI.CollectionIntervalSec) * time.Second,
+			IngestionDelay:        time.Duration(c.config.VertexAI.IngestionDelaySec) * time.Second,
 		}
 		c.vertexAIService = impl.NewVertexAIService(c.vertexAIRepo, c.vertexAIRepo, vertexAIConfig)
 	}
 
+	c.registerProviderChecks()
+
 	// Initialize Restart manager
 	restartManager, err := impl.NewRestartManager()
 	if err != nil {
@@ -496,6 +770,7 @@ func (c *Container) initUseCases() error {
 		c.bedrockService,
 		c.vertexAIService,
 		c.CreateLogger("metrics-collector"),
+		impl.WithExportedProjectPathsHashed(c.config.CSVExport != nil && c.config.CSVExport.HashProjectPaths),
 	)
 
 	// Initialize CSV Export Service
@@ -505,13 +780,96 @@ func (c *Container) initUseCases() error {
 		c.CreateLogger("csv-export"),
 	)
 
+	// Initialize daily summary service if enabled
+	if c.config.Summary != nil && c.config.Summary.Enabled {
+		if err := c.initSummaryService(); err != nil {
+			c.logger.Warn(context.TODO(), "Failed to initialize daily summary", domain.NewField("error", err.Error()))
+			fmt.Fprintf(os.Stderr, "Warning: Failed to initialize daily summary: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// vertexAIKeyProbeTimeout bounds the token requests that pick the Vertex AI key in use at startup
+const vertexAIKeyProbeTimeout = 10 * time.Second
+
+// newVertexAIAuthenticator creates the Vertex AI authenticator. With service_account_keys set,
+// the single key, the key path and the listed keys are tried in order on every token request,
+// starting with the key that obtained the last token. A key that fails to obtain a token at
+// startup doesn't disable Vertex AI, as the next collection tries every key again.
+func (c *Container) newVertexAIAuthenticator() (auth.VertexAIAuthenticator, error) {
+	cfg := c.config.VertexAI
+	if len(cfg.ServiceAccountKeys) == 0 {
+		return auth.NewVertexAIAuthenticator(cfg.ServiceAccountKey, cfg.ServiceAccountKeyPath)
+	}
+
+	keys := make([]string, 0, len(cfg.ServiceAccountKeys)+2)
+	for _, key := range append([]string{cfg.ServiceAccountKey, cfg.ServiceAccountKeyPath}, cfg.ServiceAccountKeys...) {
+		if strings.TrimSpace(key) != "" {
+			keys = append(keys, key)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), vertexAIKeyProbeTimeout)
+	defer cancel()
+	authenticator, info, err := auth.NewVertexAIAuthenticatorFromKeys(ctx, keys)
+	if authenticator == nil {
+		return nil, err
+	}
+	if err != nil {
+		c.logger.Warn(context.TODO(), "No Vertex AI service account key obtained an access token yet", domain.NewField("error", err.Error()))
+		fmt.Fprintf(os.Stderr, "Warning: No Vertex AI service account key obtained an access token yet: %v\n", err)
+		return authenticator, nil
+	}
+	c.logger.Info(context.TODO(), "Using Vertex AI service account key",
+		domain.NewField("source", info.Source),
+		domain.NewField("client_email", info.ClientEmail),
+		domain.NewField("private_key_id", info.PrivateKeyID))
+	return authenticator, nil
+}
+
+// initSummaryService initializes the daily summary webhook
+func (c *Container) initSummaryService() error {
+	summaryRepo, err := infraRepo.NewWebhookSummaryRepository(c.config.Summary.WebhookURL, 30*time.Second, c.config.Summary.MaxRetries)
+	if err != nil {
+		return err
+	}
+
+	hostLabel := ""
+	if c.config.Prometheus != nil {
+		hostLabel = c.config.Prometheus.HostLabel
+	}
+	if hostLabel == "" {
+		hostLabel, _ = os.Hostname()
+	}
+
+	c.summaryService = impl.NewSummaryServiceImpl(
+		c.ccService,
+		c.cursorService,
+		c.bedrockService,
+		c.vertexAIService,
+		summaryRepo,
+		c.config.Summary,
+		hostLabel,
+		c.CreateLogger("summary"),
+		c.timezoneService,
+	)
 	return nil
 }
 
 // initPresenters initializes presenter implementations
 func (c *Container) initPresenters() error {
-	c.consolePresenter = presenter.NewConsolePresenter()
-	c.jsonPresenter = presenter.NewJSONPresenter()
+	consolePresenter := presenter.NewConsolePresenter()
+	consolePresenter.SetRawNumbers(c.rawNumbers)
+	consolePresenter.SetThousandsSeparator(c.numberSeparator)
+	if c.config.CostPrecision != nil {
+		consolePresenter.SetCostPrecision(*c.config.CostPrecision)
+	}
+	consolePresenter.SetHashProjectPaths(c.config.HashProjectPaths)
+	c.consolePresenter = consolePresenter
+	jsonPresenter := presenter.NewJSONPresenter()
+	jsonPresenter.SetHashProjectPaths(c.config.HashProjectPaths)
+	c.jsonPresenter = jsonPresenter
 	return nil
 }
 
@@ -532,12 +890,12 @@ func (c *Container) initControllers() error {
 func (c *Container) initPrometheus() error {
 	if c.debugMode {
 		if c.debugMode {
-			fmt.Fprintf(os.Stderr, "Debug: initPrometheus called, Prometheus config nil: %v\n", c.config.Prometheus == nil)
+			config.Debugf("Debug: initPrometheus called, Prometheus config nil: %v\n", c.config.Prometheus == nil)
 		}
 		if c.config.Prometheus != nil {
 			if c.debugMode {
-				fmt.Fprintf(os.Stderr, "Debug: Current RemoteWriteURL: '%s'\n", c.config.Prometheus.RemoteWriteURL)
-				fmt.Fprintf(os.Stderr, "Debug: Current IntervalSec: %d\n", c.config.Prometheus.IntervalSec)
+				config.Debugf("Debug: Current RemoteWriteURL: '%s'\n", c.config.Prometheus.RemoteWriteURL)
+				config.Debugf("Debug: Current IntervalSec: %d\n", c.config.Prometheus.IntervalSec)
 			}
 		}
 	}
@@ -547,8 +905,8 @@ func (c *Container) initPrometheus() error {
 	if c.config.Prometheus == nil {
 		if c.debugMode {
 			if c.debugMode {
-				fmt.Fprintf(os.Stderr, "Debug: ERROR - Prometheus config is nil after config loading!\n")
-				fmt.Fprintf(os.Stderr, "Debug: This indicates a bug in config initialization\n")
+				config.Debugf("Debug: ERROR - Prometheus config is nil after config loading!\n")
+				config.Debugf("Debug: This indicates a bug in config initialization\n")
 			}
 		}
 		// Create emergency config to prevent panic
@@ -556,20 +914,33 @@ func (c *Container) initPrometheus() error {
 		return fmt.Errorf("prometheus config is nil after initialization")
 	}
 
+	// Override interval if set via command line
+	if c.metricsInterval > 0 {
+		intervalSec := int(c.metricsInterval / time.Second)
+		if intervalSec < config.MinPrometheusIntervalSec {
+			return fmt.Errorf("metrics interval must be at least %d seconds, got %s", config.MinPrometheusIntervalSec, c.metricsInterval)
+		}
+		c.config.Prometheus.IntervalSec = intervalSec
+		if c.debugMode {
+			config.Debugf("Debug: IntervalSec overridden via command line: %d\n", intervalSec)
+		}
+	}
+
 	// Initialize metrics repository
 	// If RemoteWriteURL is empty, use NoOpMetricsRepository
+	var circuitBreaker *infraRepo.CircuitBreakerMetricsRepository
 	if c.config.Prometheus.RemoteWriteURL == "" {
 		if c.debugMode {
 			if c.debugMode {
-				fmt.Fprintf(os.Stderr, "Debug: Prometheus RemoteWriteURL is empty, using NoOpMetricsRepository\n")
-				fmt.Fprintf(os.Stderr, "Debug: ENV check - TOSAGE_PROMETHEUS_REMOTE_WRITE_URL='%s'\n", os.Getenv("TOSAGE_PROMETHEUS_REMOTE_WRITE_URL"))
+				config.Debugf("Debug: Prometheus RemoteWriteURL is empty, using NoOpMetricsRepository\n")
+				config.Debugf("Debug: ENV check - TOSAGE_PROMETHEUS_REMOTE_WRITE_URL='%s'\n", os.Getenv("TOSAGE_PROMETHEUS_REMOTE_WRITE_URL"))
 			}
 		}
 		c.metricsRepo = infraRepo.NewNoOpMetricsRepository()
 	} else {
 		if c.debugMode {
 			if c.debugMode {
-				fmt.Fprintf(os.Stderr, "Debug: Creating PrometheusMetricsRepository with URL: %s\n", c.config.Prometheus.RemoteWriteURL)
+				config.Debugf("Debug: Creating PrometheusMetricsRepository with URL: %s\n", c.config.Prometheus.RemoteWriteURL)
 			}
 		}
 		metricsRepo, err := infraRepo.NewPrometheusMetricsRepository(c.config.Prometheus)
@@ -579,12 +950,136 @@ func (c *Container) initPrometheus() error {
 		c.metricsRepo = metricsRepo
 		if c.debugMode {
 			if c.debugMode {
-				fmt.Fprintf(os.Stderr, "Debug: PrometheusMetricsRepository created successfully\n")
+				config.Debugf("Debug: PrometheusMetricsRepository created successfully\n")
+			}
+		}
+
+		// Probe the endpoint so that a wrong URL or credentials are reported immediately
+		if promRepo, ok := metricsRepo.(*infraRepo.PrometheusMetricsRepository); ok && c.config.Prometheus.ShouldProbeOnStartup() {
+			c.probeRemoteWrite(promRepo)
+		}
+
+		// Stop pushing during sustained outages; other backends keep receiving metrics
+		if c.config.Prometheus.CircuitBreakerThreshold > 0 {
+			circuitBreaker, err = infraRepo.NewCircuitBreakerMetricsRepository(c.metricsRepo, c.config.Prometheus, c.CreateLogger("metrics"))
+			if err != nil {
+				return fmt.Errorf("failed to create circuit breaker: %w", err)
 			}
+			c.metricsRepo = circuitBreaker
+		}
+	}
+
+	// Fan out to the additional backends, e.g. to dual-write during a migration
+	if len(c.config.Prometheus.Backends) > 0 {
+		var backends []infraRepo.MetricsBackend
+		if c.config.Prometheus.RemoteWriteURL != "" {
+			backends = append(backends, infraRepo.MetricsBackend{Name: "Prometheus Remote Write", Repository: c.metricsRepo})
+		}
+		for i := range c.config.Prometheus.Backends {
+			backendConfig := &c.config.Prometheus.Backends[i]
+			repo, err := infraRepo.NewMetricsBackendRepository(backendConfig, c.config.Prometheus)
+			if err != nil {
+				return fmt.Errorf("failed to create metrics backend %s: %w", backendConfig.DisplayName(), err)
+			}
+			backends = append(backends, infraRepo.MetricsBackend{Name: backendConfig.DisplayName(), Repository: repo})
+		}
+		compositeRepo, err := infraRepo.NewCompositeMetricsRepository(backends, c.CreateLogger("metrics"))
+		if err != nil {
+			return fmt.Errorf("failed to create metrics backends: %w", err)
+		}
+		c.metricsRepo = compositeRepo
+	}
+
+	// Read previously pushed series back from the query endpoint if one is configured
+	if c.config.Prometheus.URL != "" {
+		queryRepo, err := infraRepo.NewPrometheusQueryRepository(c.config.Prometheus)
+		if err != nil {
+			return fmt.Errorf("failed to create metrics query repository: %w", err)
+		}
+		c.metricsQuery = queryRepo
+	}
+
+	// Record metrics for a scrape endpoint if a listen address is configured. Only the daemon
+	// serves it, see StartScrapeEndpoint.
+	var scrapeRepo *infraRepo.ScrapeMetricsRepository
+	if c.config.Prometheus.ScrapeListenAddress != "" {
+		var err error
+		scrapeRepo, err = infraRepo.NewScrapeMetricsRepository(c.metricsRepo, c.config.Prometheus)
+		if err != nil {
+			c.logger.Warn(context.TODO(), "Failed to create metrics scrape endpoint", domain.NewField("error", err.Error()))
+			fmt.Fprintf(os.Stderr, "Warning: Failed to create metrics scrape endpoint: %v\n", err)
+			scrapeRepo = nil
+		} else {
+			c.metricsRepo = scrapeRepo
+		}
+	}
+	c.scrapeRepo = scrapeRepo
+
+	// Scale configured metrics just before they reach any backend
+	if len(c.config.Prometheus.Transforms) > 0 {
+		transformRepo, err := infraRepo.NewTransformMetricsRepository(c.metricsRepo, c.config.Prometheus)
+		if err != nil {
+			return fmt.Errorf("failed to create metric transforms: %w", err)
+		}
+		c.metricsRepo = transformRepo
+	}
+
+	// Attach the configured static labels, and those of the cloud instance, to every series sent to any backend
+	var cloudLabels map[string]string
+	if c.config.Prometheus.CloudMetadataLabels {
+		cloudLabels = cloudMetadataLabels()
+		if len(cloudLabels) == 0 {
+			c.logger.Info(context.TODO(), "No cloud metadata service found, cloud labels are not added")
+		} else if c.debugMode {
+			config.Debugf("Debug: Cloud metadata labels: %v\n", cloudLabels)
+		}
+	}
+	if len(c.config.Prometheus.ExtraLabels) > 0 || len(cloudLabels) > 0 {
+		labelsRepo, err := infraRepo.NewExtraLabelsMetricsRepository(c.metricsRepo, c.config.Prometheus)
+		if err != nil {
+			return fmt.Errorf("failed to create extra labels: %w", err)
+		}
+		labelsRepo.AddDefaultLabels(cloudLabels)
+		c.metricsRepo = labelsRepo
+	}
+
+	// Drop filtered metrics before they reach any decorator or backend
+	if len(c.config.Prometheus.MetricAllowlist) > 0 || len(c.config.Prometheus.MetricDenylist) > 0 {
+		filterRepo, err := infraRepo.NewMetricFilterMetricsRepository(c.metricsRepo, c.config.Prometheus)
+		if err != nil {
+			return fmt.Errorf("failed to create metric filter: %w", err)
 		}
+		c.metricsRepo = filterRepo
 	}
 
 	// Initialize metrics service
+	metricsOpts := []impl.MetricsServiceOption{
+		impl.WithMetricsStateRepository(infraRepo.NewJSONMetricsStateRepository(c.config.Prometheus.StateFilePath)),
+		impl.WithSourceHostLabels(sourceHostLabels(c.config)),
+		impl.WithCursorPremiumRequestMetrics(c.config.Cursor != nil && c.config.Cursor.PremiumRequestMetrics),
+		impl.WithCursorUsageCostMetrics(c.config.Cursor != nil && c.config.Cursor.UsageCostMetrics),
+		impl.WithCursorCallCountMetrics(c.config.Cursor != nil && c.config.Cursor.CallCountMetrics),
+		impl.WithCursorBillingCycleMetric(c.config.Cursor != nil && c.config.Cursor.BillingCycleMetric),
+		impl.WithCursorUsageBasedStatusMetrics(c.config.Cursor != nil && c.config.Cursor.UsageBasedStatusMetrics),
+		impl.WithVertexAIRequestMetrics(c.config.VertexAI != nil && c.config.VertexAI.RequestMetrics, c.config.VertexAI != nil && c.config.VertexAI.RequestMetricsPerModel),
+		cursorTeamMemberOption(c.config.Cursor, c.config.HashProjectPaths),
+		impl.WithMetricsDailyWindowMode(c.config.DailyWindow()),
+		impl.WithCcAllTokensMetric(!c.config.TotalTokenComponents().IsAll()),
+		impl.WithCcSourcePathMetrics(c.config.Prometheus.SourcePathLabel, c.config.Prometheus.ShouldHashSourcePaths() || c.config.HashProjectPaths),
+		impl.WithHashedSessionIDs(c.config.HashProjectPaths),
+		impl.WithCcTokensDeltaMetric(c.config.Prometheus.CcTokensDelta),
+		impl.WithCcMaxDataStaleness(time.Duration(c.config.MaxDataStalenessSec) * time.Second),
+	}
+	if circuitBreaker != nil {
+		metricsOpts = append(metricsOpts, impl.WithCircuitStateReporter(circuitBreaker))
+	}
+	if hook := c.config.PostCollectionHook; hook != nil && hook.Command != "" {
+		hookRepo, err := infraRepo.NewCommandHookRepository(hook.Command, time.Duration(hook.TimeoutSec)*time.Second)
+		if err != nil {
+			return fmt.Errorf("failed to create post-collection hook: %w", err)
+		}
+		metricsOpts = append(metricsOpts, impl.WithPostCollectionHook(hookRepo))
+	}
 	c.metricsService = impl.NewMetricsServiceImpl(
 		c.ccService,
 		c.cursorService,
@@ -594,11 +1089,257 @@ func (c *Container) initPrometheus() error {
 		c.config.Prometheus,
 		c.CreateLogger("metrics"),
 		c.timezoneService,
+		metricsOpts...,
 	)
 
+	// Collect on demand when a scrape finds the last collection too old. Like a daemon send,
+	// the collection also pushes to Remote Write and is recorded in the status. A scrape during
+	// a running collection waits for it instead of starting another.
+	if scrapeRepo != nil && c.config.Prometheus.ScrapeRefreshAfterSec > 0 {
+		metricsService := c.metricsService
+		statusService := c.statusService
+		logger := c.CreateLogger("metrics")
+		scrapeRepo.SetOnDemandCollection(func() {
+			sent, err := metricsService.SendCurrentMetricsIfIdle()
+			if !sent {
+				return
+			}
+			if err != nil {
+				logger.Warn(context.TODO(), "On-demand collection for a scrape failed", domain.NewField("error", err.Error()))
+				_ = statusService.RecordError(err)
+				return
+			}
+			_ = statusService.UpdateLastMetricsSent(time.Now())
+			_ = statusService.ClearError()
+		}, time.Duration(c.config.Prometheus.ScrapeRefreshAfterSec)*time.Second)
+	}
+
 	return nil
 }
 
+// cloudMetadataLabels detects the cloud labels of the machine once per process, so profile
+// containers don't each wait on the metadata service
+var cloudMetadataLabels = sync.OnceValue(func() map[string]string {
+	ctx, cancel := context.WithTimeout(context.Background(), service.CloudMetadataTimeout)
+	defer cancel()
+	return service.NewCloudMetadataDetector().Detect(ctx)
+})
+
+// sourceHostLabels collects the per-source host label overrides from the provider configs
+func sourceHostLabels(cfg *config.AppConfig) map[string]string {
+	labels := make(map[string]string)
+	if cfg.Cursor != nil && cfg.Cursor.HostLabel != "" {
+		labels[usecase.MetricsSourceCursor] = cfg.Cursor.HostLabel
+	}
+	if cfg.Bedrock != nil && cfg.Bedrock.HostLabel != "" {
+		labels[usecase.MetricsSourceBedrock] = cfg.Bedrock.HostLabel
+	}
+	if cfg.VertexAI != nil && cfg.VertexAI.HostLabel != "" {
+		labels[usecase.MetricsSourceVertexAI] = cfg.VertexAI.HostLabel
+	}
+	return labels
+}
+
+// cursorTeamMemberOption configures the per-member Cursor metrics from the Cursor config.
+// hashProjectPaths, which anonymizes every identifier tosage sends, always hashes the user label.
+func cursorTeamMemberOption(cfg *config.CursorConfig, hashProjectPaths bool) impl.MetricsServiceOption {
+	if cfg == nil {
+		return impl.WithCursorTeamMemberMetrics(false, nil, 0, true)
+	}
+	return impl.WithCursorTeamMemberMetrics(cfg.TeamMemberMetrics, cfg.TeamMembers, cfg.TeamMemberLimit,
+		cfg.ShouldHashTeamMembers() || hashProjectPaths)
+}
+
+// registerProviderChecks registers a status check for each enabled provider
+func (c *Container) registerProviderChecks() {
+	if c.ccService != nil {
+		c.statusService.RegisterProviderCheck(usecase.MetricsSourceClaudeCode, func(ctx context.Context) error {
+			_, err := c.ccService.CalculateTodayTokens()
+			return err
+		})
+	}
+	if c.cursorService != nil {
+		c.statusService.RegisterProviderCheck(usecase.MetricsSourceCursor, c.cursorService.CheckConnection)
+	}
+	if c.bedrockService != nil && c.bedrockService.IsEnabled() {
+		c.statusService.RegisterProviderCheck(usecase.MetricsSourceBedrock, c.bedrockService.CheckConnection)
+	}
+	if c.vertexAIService != nil && c.vertexAIService.IsEnabled() {
+		c.statusService.RegisterProviderCheck(usecase.MetricsSourceVertexAI, c.vertexAIService.CheckConnection)
+	}
+}
+
+// remoteWriteProbeTimeout bounds the startup probe of the Remote Write endpoint
+const remoteWriteProbeTimeout = 5 * time.Second
+
+// probeRemoteWrite checks the Remote Write endpoint in the background and records the result.
+// A failed probe is reported but never aborts startup, so transient outages don't block the daemon.
+func (c *Container) probeRemoteWrite(promRepo *infraRepo.PrometheusMetricsRepository) {
+	timeout := time.Duration(c.config.Prometheus.TimeoutSec) * time.Second
+	if timeout <= 0 || timeout > remoteWriteProbeTimeout {
+		timeout = remoteWriteProbeTimeout
+	}
+
+	c.startupProbes.Add(1)
+	go func() {
+		defer c.startupProbes.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		check := StartupCheck{Name: "Prometheus Remote Write", OK: true, Detail: c.config.Prometheus.RemoteWriteURL}
+		if err := promRepo.Probe(ctx); err != nil {
+			check.OK = false
+			check.Detail = err.Error()
+			c.logger.Error(context.TODO(), "Prometheus Remote Write endpoint check failed",
+				domain.NewField("url", c.config.Prometheus.RemoteWriteURL),
+				domain.NewField("error", err.Error()))
+			fmt.Fprintf(os.Stderr, "Error: Prometheus Remote Write endpoint check failed: %v\n", err)
+		} else {
+			c.logger.Info(context.TODO(), "Prometheus Remote Write endpoint is reachable",
+				domain.NewField("url", c.config.Prometheus.RemoteWriteURL))
+		}
+		c.addStartupCheck(check)
+	}()
+}
+
+// addStartupCheck records the result of a startup check
+func (c *Container) addStartupCheck(check StartupCheck) {
+	c.startupMu.Lock()
+	defer c.startupMu.Unlock()
+	c.startupChecks = append(c.startupChecks, check)
+}
+
+// checkTimezones loads the configured timezones up front and records the result, so a
+// missing timezone database is reported at startup instead of skewing daily boundaries later
+func (c *Container) checkTimezones() {
+	check := StartupCheck{Name: "Timezone database", OK: true}
+	names, err := config.CheckTimezones(c.config)
+	if err != nil {
+		check.OK = false
+		check.Detail = err.Error()
+		c.logger.Error(context.TODO(), "Timezone check failed", domain.NewField("error", err.Error()))
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	} else {
+		check.Detail = strings.Join(names, ", ")
+	}
+	c.addStartupCheck(check)
+}
+
+// CheckConnections checks every configured provider and the metrics backend.
+// Each check shares the context deadline, so callers bound the total time with a timeout.
+func (c *Container) CheckConnections(ctx context.Context) []StartupCheck {
+	var checks []StartupCheck
+	record := func(name string, err error) {
+		check := StartupCheck{Name: name, OK: err == nil}
+		if err != nil {
+			check.Detail = err.Error()
+		}
+		checks = append(checks, check)
+	}
+
+	if c.cursorService != nil {
+		record("Cursor", c.cursorService.CheckConnection(ctx))
+	}
+	if c.bedrockService != nil {
+		record("AWS Bedrock", c.bedrockService.CheckConnection(ctx))
+	}
+	if c.vertexAIService != nil {
+		record("Google Vertex AI", c.vertexAIService.CheckConnection(ctx))
+	}
+	if checker, ok := c.metricsRepo.(repository.ConnectionChecker); ok {
+		record("Prometheus Remote Write", checker.CheckConnection(ctx))
+	}
+
+	return checks
+}
+
+// SelfTestMetricName is the metric written to each metrics backend by SelfTest
+const SelfTestMetricName = "tosage_selftest"
+
+// SelfTest writes a test metric to each configured Remote Write endpoint, including the
+// endpoints of daemon profiles and additional backends, and pushes a test log line to Loki.
+// Unlike CheckConnections it performs real writes, so it also catches permission errors.
+func (c *Container) SelfTest() []StartupCheck {
+	var checks []StartupCheck
+	record := func(name, detail string, err error) {
+		check := StartupCheck{Name: name, OK: err == nil, Detail: detail}
+		if err != nil {
+			check.Detail = err.Error()
+		}
+		checks = append(checks, check)
+	}
+
+	type backend struct {
+		name   string
+		config *config.PrometheusConfig
+	}
+	backends := []backend{{name: "Prometheus Remote Write", config: c.config.Prometheus}}
+	for _, profile := range c.config.Profiles {
+		backends = append(backends, backend{
+			name:   fmt.Sprintf("Prometheus Remote Write (%s)", profile.Name),
+			config: c.config.ForProfile(profile).Prometheus,
+		})
+	}
+	for _, backend := range backends {
+		if backend.config == nil || backend.config.RemoteWriteURL == "" {
+			continue
+		}
+		repo, err := infraRepo.NewPrometheusMetricsRepository(backend.config)
+		if err == nil {
+			err = selfTestSend(repo, backend.config)
+		}
+		record(backend.name, backend.config.RemoteWriteURL, err)
+	}
+	if c.config.Prometheus != nil {
+		for i := range c.config.Prometheus.Backends {
+			backendConfig := &c.config.Prometheus.Backends[i]
+			repo, err := infraRepo.NewMetricsBackendRepository(backendConfig, c.config.Prometheus)
+			if err == nil {
+				err = selfTestSend(repo, c.config.Prometheus)
+			}
+			record(backendConfig.DisplayName(), backendConfig.URL, err)
+		}
+	}
+
+	if c.config.Logging != nil && c.config.Logging.Promtail != nil && c.config.Logging.Promtail.URL != "" {
+		promtail := c.config.Logging.Promtail
+		err := logging.PushTestLog(promtail.URL, promtail.Username, promtail.Password, "selftest", "tosage self-test", logging.NewPromtailOptions(promtail)...)
+		record("Loki", promtail.URL, err)
+	}
+
+	return checks
+}
+
+// selfTestSend writes the self-test metric to repo with the configured extra labels
+func selfTestSend(repo repository.MetricsRepository, cfg *config.PrometheusConfig) error {
+	if len(cfg.ExtraLabels) > 0 {
+		labelsRepo, err := infraRepo.NewExtraLabelsMetricsRepository(repo, cfg)
+		if err != nil {
+			return err
+		}
+		repo = labelsRepo
+	}
+	sender, ok := repo.(repository.MetricValueSender)
+	if !ok {
+		return fmt.Errorf("metrics repository cannot send metric values")
+	}
+	hostLabel := cfg.HostLabel
+	if hostLabel == "" {
+		hostLabel, _ = os.Hostname()
+	}
+	return sender.SendMetricValue(1, hostLabel, SelfTestMetricName, nil, nil)
+}
+
+// GetStartupChecks returns the results of the checks performed during initialization,
+// waiting for the background probes to finish
+func (c *Container) GetStartupChecks() []StartupCheck {
+	c.startupProbes.Wait()
+
+	c.startupMu.Lock()
+	defer c.startupMu.Unlock()
+	return slices.Clone(c.startupChecks)
+}
+
 // GetConfig returns the application configuration
 func (c *Container) GetConfig() *config.AppConfig {
 	return c.config
@@ -634,11 +1375,21 @@ func (c *Container) GetMetricsRepository() repository.MetricsRepository {
 	return c.metricsRepo
 }
 
+// GetMetricsQueryRepository returns the metrics query repository, or nil if no query URL is configured
+func (c *Container) GetMetricsQueryRepository() repository.MetricsQueryRepository {
+	return c.metricsQuery
+}
+
 // GetMetricsService returns the metrics service
 func (c *Container) GetMetricsService() usecase.MetricsService {
 	return c.metricsService
 }
 
+// GetSummaryService returns the daily summary service, or nil if the summary is disabled
+func (c *Container) GetSummaryService() usecase.SummaryService {
+	return c.summaryService
+}
+
 // GetCursorTokenRepository returns the Cursor token repository
 func (c *Container) GetCursorTokenRepository() repository.CursorTokenRepository {
 	return c.cursorTokenRepo
@@ -697,6 +1448,15 @@ func (c *Container) CreateLogger(component string) domain.Logger {
 	return c.loggerFactory.CreateLogger(component)
 }
 
+// CloseLogFile closes the daemon log file, waiting for rotated files to be compressed.
+// Log lines written afterwards are dropped.
+func (c *Container) CloseLogFile() error {
+	if c.logFile == nil {
+		return nil
+	}
+	return c.logFile.Close()
+}
+
 // GetConfigRepository returns the config repository
 func (c *Container) GetConfigRepository() repository.ConfigRepository {
 	return c.configRepo
@@ -722,11 +1482,144 @@ func (c *Container) GetCSVExportService() usecase.CSVExportService {
 	return c.csvExportService
 }
 
+// StartScrapeEndpoint starts serving the metrics scrape endpoint, if one is configured. The
+// daemon calls it once it holds the PID file lock, so CLI runs next to a running daemon don't
+// compete for the listen address. Failing to listen is reported but not fatal.
+func (c *Container) StartScrapeEndpoint() {
+	if c.scrapeRepo == nil {
+		return
+	}
+	address := c.config.Prometheus.ScrapeListenAddress
+	if err := c.scrapeRepo.Start(address, c.logger); err != nil {
+		c.logger.Warn(context.TODO(), "Failed to start metrics scrape endpoint", domain.NewField("error", err.Error()))
+		fmt.Fprintf(os.Stderr, "Warning: Failed to start metrics scrape endpoint: %v\n", err)
+		return
+	}
+	if c.debugMode {
+		config.Debugf("Debug: Serving metrics scrape endpoint on %s/metrics\n", address)
+	}
+}
+
 // InitDaemonComponents initializes daemon components on demand
 func (c *Container) InitDaemonComponents() error {
+	c.openDaemonLogFile()
 	return c.initDaemonPlatform()
 }
 
+// InitProfiles builds a separate service graph for each configured daemon profile.
+// A profile that fails to initialize is reported and skipped so the others keep running,
+// unless FailFastOnProviderInitError is set.
+func (c *Container) InitProfiles() error {
+	c.profiles = nil
+	for _, profile := range c.config.Profiles {
+		profileContainer, err := c.newProfileContainer(profile)
+		if err != nil {
+			c.logger.Warn(context.TODO(), "Failed to initialize profile",
+				domain.NewField("profile", profile.Name),
+				domain.NewField("error", err.Error()))
+			fmt.Fprintf(os.Stderr, "Warning: Failed to initialize profile %q: %v\n", profile.Name, err)
+			if err := c.providerInitFailed(fmt.Sprintf("profile %q", profile.Name), err); err != nil {
+				return err
+			}
+			continue
+		}
+		profileContainer.StartScrapeEndpoint()
+		c.profiles = append(c.profiles, profileContainer)
+	}
+	return nil
+}
+
+// newProfileContainer creates a container sharing configuration and logging with c,
+// but with its own repositories, services and metrics backend
+func (c *Container) newProfileContainer(profile *config.ProfileConfig) (*Container, error) {
+	profileContainer := &Container{
+		config:          c.config.ForProfile(profile),
+		configRepo:      c.configRepo,
+		configService:   c.configService,
+		loggerFactory:   c.loggerFactory,
+		logger:          c.CreateLogger("profile-" + profile.Name),
+		debugMode:       c.debugMode,
+		bedrockEnabled:  c.bedrockEnabled,
+		vertexAIEnabled: c.vertexAIEnabled,
+		providers:       c.providers,
+		metricsInterval: c.metricsInterval,
+		excludeModels:   c.excludeModels,
+		entryFilter:     c.entryFilter,
+		version:         c.version,
+		profileName:     profile.Name,
+	}
+
+	// Keep each profile's last-sent state separate
+	if profileContainer.config.Prometheus != nil && profileContainer.config.Prometheus.StateFilePath == "" {
+		statePath, err := infraRepo.DefaultMetricsStatePath(profile.Name)
+		if err != nil {
+			return nil, err
+		}
+		profileContainer.config.Prometheus.StateFilePath = statePath
+	}
+
+	if err := profileContainer.initRepositories(); err != nil {
+		return nil, fmt.Errorf("failed to initialize repositories: %w", err)
+	}
+	if err := profileContainer.initDomainServices(); err != nil {
+		return nil, fmt.Errorf("failed to initialize domain services: %w", err)
+	}
+	if err := profileContainer.initUseCases(); err != nil {
+		return nil, fmt.Errorf("failed to initialize use cases: %w", err)
+	}
+
+	if profile.DisableClaudeCode {
+		profileContainer.ccService = nil
+	}
+	if profile.DisableCursor {
+		profileContainer.cursorService = nil
+	}
+
+	if err := profileContainer.initPrometheus(); err != nil {
+		return nil, fmt.Errorf("failed to initialize prometheus: %w", err)
+	}
+
+	return profileContainer, nil
+}
+
+// localProviderEnabled reports whether Claude Code or Cursor is collected. Without a provider
+// set, enabling Bedrock or Vertex AI on the command line switches both off.
+func (c *Container) localProviderEnabled(provider string) bool {
+	if c.providers != nil {
+		return c.providers[provider]
+	}
+	return !c.bedrockEnabled && !c.vertexAIEnabled
+}
+
+// GetProviders returns the providers enabled with WithProviders, or nil when the
+// configuration and the --bedrock/--vertex-ai flags decide
+func (c *Container) GetProviders() []string {
+	if c.providers == nil {
+		return nil
+	}
+	providers := make([]string, 0, len(c.providers))
+	for _, provider := range []string{usecase.MetricsSourceClaudeCode, usecase.MetricsSourceCursor, usecase.MetricsSourceBedrock, usecase.MetricsSourceVertexAI} {
+		if c.providers[provider] {
+			providers = append(providers, provider)
+		}
+	}
+	return providers
+}
+
+// GetProfileName returns the profile name, or an empty string for the top-level container
+func (c *Container) GetProfileName() string {
+	return c.profileName
+}
+
+// GetProfileMetricsServices returns the metrics service of each initialized profile keyed by name
+func (c *Container) GetProfileMetricsServices() map[string]usecase.MetricsService {
+	services := make(map[string]usecase.MetricsService, len(c.profiles))
+	for _, profile := range c.profiles {
+		services[profile.profileName] = profile.metricsService
+	}
+	return services
+}
+
 // Builder pattern for custom container configuration
 
 // ContainerBuilder builds a custom container
@@ -843,7 +1736,15 @@ func (b *ContainerBuilder) Build() (*Container, error) {
 	if b.cursorAPIRepo != nil {
 		container.cursorAPIRepo = b.cursorAPIRepo
 	} else if container.config.Cursor != nil {
-		container.cursorAPIRepo = infraRepo.NewCursorAPIRepository(time.Duration(container.config.Cursor.APITimeout) * time.Second)
+		container.cursorAPIRepo = infraRepo.NewCursorAPIRepository(
+			time.Duration(container.config.Cursor.APITimeout)*time.Second,
+			infraRepo.WithBaseURL(container.config.Cursor.BaseURL),
+			infraRepo.WithBillingDay(container.config.Cursor.BillingDay),
+			infraRepo.WithDayStartHour(container.config.Cursor.DayStartHour),
+			infraRepo.WithDailyWindowMode(container.config.DailyWindow()),
+			infraRepo.WithCursorLogger(container.CreateLogger("cursor")),
+			infraRepo.WithRateLimit(container.config.Cursor.RateLimit, container.config.Cursor.RateLimitBurst),
+		)
 	}
 
 	// Initialize remaining components
@@ -872,6 +1773,19 @@ func (b *ContainerBuilder) Build() (*Container, error) {
 		container.config.Prometheus,
 		container.CreateLogger("metrics"),
 		container.timezoneService,
+		impl.WithSourceHostLabels(sourceHostLabels(container.config)),
+		impl.WithCursorPremiumRequestMetrics(container.config.Cursor != nil && container.config.Cursor.PremiumRequestMetrics),
+		impl.WithCursorUsageCostMetrics(container.config.Cursor != nil && container.config.Cursor.UsageCostMetrics),
+		impl.WithCursorCallCountMetrics(container.config.Cursor != nil && container.config.Cursor.CallCountMetrics),
+		impl.WithCursorBillingCycleMetric(container.config.Cursor != nil && container.config.Cursor.BillingCycleMetric),
+		impl.WithCursorUsageBasedStatusMetrics(container.config.Cursor != nil && container.config.Cursor.UsageBasedStatusMetrics),
+		impl.WithVertexAIRequestMetrics(container.config.VertexAI != nil && container.config.VertexAI.RequestMetrics, container.config.VertexAI != nil && container.config.VertexAI.RequestMetricsPerModel),
+		cursorTeamMemberOption(container.config.Cursor, container.config.HashProjectPaths),
+		impl.WithMetricsDailyWindowMode(container.config.DailyWindow()),
+		impl.WithCcAllTokensMetric(!container.config.TotalTokenComponents().IsAll()),
+		impl.WithCcSourcePathMetrics(container.config.Prometheus.SourcePathLabel, container.config.Prometheus.ShouldHashSourcePaths() || container.config.HashProjectPaths),
+		impl.WithHashedSessionIDs(container.config.HashProjectPaths),
+		impl.WithCcMaxDataStaleness(time.Duration(container.config.MaxDataStalenessSec)*time.Second),
 	)
 
 	// Initialize daemon components if configured (platform-specific)
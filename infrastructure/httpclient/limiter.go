@@ -0,0 +1,113 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+var (
+	limiterMu sync.RWMutex
+	// requestSlots holds one token per outbound request in flight; nil means unlimited
+	requestSlots chan struct{}
+)
+
+// SetMaxConcurrentRequests limits the outbound HTTP and gRPC requests in flight across
+// every client created by this package. Zero or a negative value removes the limit.
+func SetMaxConcurrentRequests(n int) {
+	limiterMu.Lock()
+	defer limiterMu.Unlock()
+	if n <= 0 {
+		requestSlots = nil
+		return
+	}
+	requestSlots = make(chan struct{}, n)
+}
+
+// acquireRequestSlot waits for a free request slot and returns the function that frees it
+func acquireRequestSlot(ctx context.Context) (func(), error) {
+	limiterMu.RLock()
+	slots := requestSlots
+	limiterMu.RUnlock()
+	if slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+		var once sync.Once
+		return func() { once.Do(func() { <-slots }) }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// limitedRoundTrip sends req through base while holding a request slot, which is freed
+// once the response body has been read or closed
+func limitedRoundTrip(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	release, err := acquireRequestSlot(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		release()
+		return resp, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releasingBody frees the request slot once the body is fully read or closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+// Read implements io.Reader
+func (b *releasingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.release()
+	}
+	return n, err
+}
+
+// Close implements io.Closer
+func (b *releasingBody) Close() error {
+	b.release()
+	return b.ReadCloser.Close()
+}
+
+// GRPCDialOptions returns dial options that count gRPC calls against the request limit.
+// Streaming calls hold a slot only while the stream is being opened.
+func GRPCDialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(limitUnaryCall),
+		grpc.WithChainStreamInterceptor(limitStreamCall),
+	}
+}
+
+// limitUnaryCall holds a request slot for the duration of a unary gRPC call
+func limitUnaryCall(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	release, err := acquireRequestSlot(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// limitStreamCall holds a request slot while a gRPC stream is opened
+func limitStreamCall(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	release, err := acquireRequestSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return streamer(ctx, desc, cc, method, opts...)
+}
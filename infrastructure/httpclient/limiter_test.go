@@ -0,0 +1,82 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewClient_MaxConcurrentRequests(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	SetMaxConcurrentRequests(2)
+	t.Cleanup(func() { SetMaxConcurrentRequests(0) })
+
+	client := NewClient(5 * time.Second)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Errorf("Get() error = %v", err)
+				return
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&maxInFlight); got > 2 {
+		t.Errorf("max requests in flight = %d, want at most 2", got)
+	}
+}
+
+func TestAcquireRequestSlot_ContextCanceled(t *testing.T) {
+	SetMaxConcurrentRequests(1)
+	t.Cleanup(func() { SetMaxConcurrentRequests(0) })
+
+	release, err := acquireRequestSlot(context.Background())
+	if err != nil {
+		t.Fatalf("acquireRequestSlot() error = %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := acquireRequestSlot(ctx); err == nil {
+		t.Error("acquireRequestSlot() succeeded while the only slot was held")
+	}
+
+	// Releasing twice must not free a slot held by someone else
+	release()
+	release()
+	second, err := acquireRequestSlot(context.Background())
+	if err != nil {
+		t.Fatalf("acquireRequestSlot() after release error = %v", err)
+	}
+	defer second()
+	if _, err := acquireRequestSlot(ctx); err == nil {
+		t.Error("acquireRequestSlot() succeeded beyond the limit after a double release")
+	}
+}
@@ -0,0 +1,44 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// LoadClientCertificate loads a PEM encoded client certificate and private key pair
+func LoadClientCertificate(certPath, keyPath string) (tls.Certificate, error) {
+	if certPath == "" || keyPath == "" {
+		return tls.Certificate{}, fmt.Errorf("client certificate and key paths must both be set")
+	}
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to load client certificate %s with key %s: %w", certPath, keyPath, err)
+	}
+	return cert, nil
+}
+
+// NewTLSTransport returns a transport that presents cert during TLS handshakes
+// and sends the tosage User-Agent
+func NewTLSTransport(cert tls.Certificate) http.RoundTripper {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	return NewTransport(base)
+}
+
+// NewClientWithCertificate returns an HTTP client like NewClient that also
+// authenticates with the given client certificate (mTLS)
+func NewClientWithCertificate(timeout time.Duration, certPath, keyPath string) (*http.Client, error) {
+	cert, err := LoadClientCertificate(certPath, keyPath)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: NewTLSTransport(cert),
+	}, nil
+}
@@ -0,0 +1,109 @@
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeClientCertificate writes a self-signed client certificate and key to dir
+func writeClientCertificate(t *testing.T, dir string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "tosage-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+
+	certPath := filepath.Join(dir, "client.crt")
+	keyPath := filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return certPath, keyPath
+}
+
+func TestLoadClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeClientCertificate(t, dir)
+
+	if _, err := LoadClientCertificate(certPath, keyPath); err != nil {
+		t.Errorf("LoadClientCertificate() error = %v", err)
+	}
+	if _, err := LoadClientCertificate(certPath, ""); err == nil {
+		t.Error("LoadClientCertificate() without key should fail")
+	}
+	if _, err := LoadClientCertificate(certPath, filepath.Join(dir, "missing.key")); err == nil {
+		t.Error("LoadClientCertificate() with missing key should fail")
+	}
+	if _, err := LoadClientCertificate(keyPath, certPath); err == nil {
+		t.Error("LoadClientCertificate() with swapped files should fail")
+	}
+}
+
+func TestNewClientWithCertificate_PresentsCertificate(t *testing.T) {
+	certPath, keyPath := writeClientCertificate(t, t.TempDir())
+
+	var gotCN, gotUA string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			gotCN = r.TLS.PeerCertificates[0].Subject.CommonName
+		}
+		gotUA = r.Header.Get("User-Agent")
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	client, err := NewClientWithCertificate(5*time.Second, certPath, keyPath)
+	if err != nil {
+		t.Fatalf("NewClientWithCertificate() error = %v", err)
+	}
+
+	// Trust the test server's self-signed certificate
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	client.Transport.(*userAgentTransport).base.(*http.Transport).TLSClientConfig.RootCAs = roots
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if gotCN != "tosage-test" {
+		t.Errorf("server saw client certificate CN %q, want %q", gotCN, "tosage-test")
+	}
+	if gotUA != UserAgent() {
+		t.Errorf("User-Agent = %q, want %q", gotUA, UserAgent())
+	}
+}
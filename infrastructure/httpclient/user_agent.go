@@ -0,0 +1,74 @@
+// Package httpclient provides the HTTP client shared by tosage's outbound integrations.
+package httpclient
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultVersion is used when the binary was built without version information
+const defaultVersion = "dev"
+
+var (
+	userAgentMu sync.RWMutex
+	userAgent   = DefaultUserAgent("")
+)
+
+// DefaultUserAgent returns the User-Agent tosage identifies itself with, "tosage/<version>"
+func DefaultUserAgent(version string) string {
+	if version == "" {
+		version = defaultVersion
+	}
+	return "tosage/" + version
+}
+
+// SetUserAgent sets the User-Agent sent by every client created by this package.
+// An empty value restores the default.
+func SetUserAgent(ua string) {
+	if ua == "" {
+		ua = DefaultUserAgent("")
+	}
+	userAgentMu.Lock()
+	defer userAgentMu.Unlock()
+	userAgent = ua
+}
+
+// UserAgent returns the User-Agent currently sent
+func UserAgent() string {
+	userAgentMu.RLock()
+	defer userAgentMu.RUnlock()
+	return userAgent
+}
+
+// userAgentTransport sets the User-Agent header on requests that don't set one themselves
+type userAgentTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		// RoundTrippers must not modify the caller's request
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", UserAgent())
+	}
+	return limitedRoundTrip(t.base, req)
+}
+
+// NewTransport wraps base so that requests carry the tosage User-Agent and count
+// against the concurrent request limit. A nil base uses http.DefaultTransport.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &userAgentTransport{base: base}
+}
+
+// NewClient returns an HTTP client with the given timeout that sends the tosage User-Agent
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: NewTransport(nil),
+	}
+}
@@ -0,0 +1,54 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewClient_UserAgent(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+	}))
+	defer server.Close()
+
+	t.Cleanup(func() { SetUserAgent("") })
+
+	tests := []struct {
+		name      string
+		userAgent string
+		header    string
+		want      string
+	}{
+		{name: "default", userAgent: "", want: "tosage/dev"},
+		{name: "versioned", userAgent: DefaultUserAgent("1.2.3"), want: "tosage/1.2.3"},
+		{name: "override", userAgent: "corp-proxy-approved/1.0", want: "corp-proxy-approved/1.0"},
+		{name: "request header wins", userAgent: "tosage/1.2.3", header: "custom/2.0", want: "custom/2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetUserAgent(tt.userAgent)
+
+			req, err := http.NewRequest("GET", server.URL, nil)
+			if err != nil {
+				t.Fatalf("NewRequest() error = %v", err)
+			}
+			if tt.header != "" {
+				req.Header.Set("User-Agent", tt.header)
+			}
+
+			resp, err := NewClient(5 * time.Second).Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			_ = resp.Body.Close()
+
+			if got != tt.want {
+				t.Errorf("User-Agent = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
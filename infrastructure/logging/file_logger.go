@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ca-srg/tosage/domain"
+)
+
+// FileSink is the log file shared by the loggers of every component. It writes nothing
+// until a writer is set, so loggers can be created before the daemon decides to log to a file.
+type FileSink struct {
+	mu     sync.Mutex
+	writer io.WriteCloser
+}
+
+// NewFileSink creates a sink without a writer
+func NewFileSink() *FileSink {
+	return &FileSink{}
+}
+
+// SetWriter starts writing log lines to w, closing the previous writer if any
+func (s *FileSink) SetWriter(w io.WriteCloser) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.writer != nil {
+		_ = s.writer.Close()
+	}
+	s.writer = w
+}
+
+// IsOpen reports whether a writer is set
+func (s *FileSink) IsOpen() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writer != nil
+}
+
+// Close closes the writer; later log lines are dropped
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.writer == nil {
+		return nil
+	}
+	err := s.writer.Close()
+	s.writer = nil
+	return err
+}
+
+// writeLine writes a single log line, dropping it when no writer is set
+func (s *FileSink) writeLine(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.writer != nil {
+		_, _ = io.WriteString(s.writer, line+"\n")
+	}
+}
+
+// FileLogger passes entries on to the wrapped logger and also writes them to a FileSink,
+// in the same format as the debug output
+type FileLogger struct {
+	wrapped   domain.Logger
+	sink      *FileSink
+	component string
+	fields    []domain.Field
+}
+
+// NewFileLogger creates a logger that writes the entries of component to sink as well
+func NewFileLogger(wrapped domain.Logger, sink *FileSink, component string) *FileLogger {
+	return &FileLogger{
+		wrapped:   wrapped,
+		sink:      sink,
+		component: component,
+	}
+}
+
+func (f *FileLogger) Debug(ctx context.Context, msg string, fields ...domain.Field) {
+	f.wrapped.Debug(ctx, msg, fields...)
+	f.write(domain.LogLevelDebug, msg, fields)
+}
+
+func (f *FileLogger) Info(ctx context.Context, msg string, fields ...domain.Field) {
+	f.wrapped.Info(ctx, msg, fields...)
+	f.write(domain.LogLevelInfo, msg, fields)
+}
+
+func (f *FileLogger) Warn(ctx context.Context, msg string, fields ...domain.Field) {
+	f.wrapped.Warn(ctx, msg, fields...)
+	f.write(domain.LogLevelWarn, msg, fields)
+}
+
+func (f *FileLogger) Error(ctx context.Context, msg string, fields ...domain.Field) {
+	f.wrapped.Error(ctx, msg, fields...)
+	f.write(domain.LogLevelError, msg, fields)
+}
+
+func (f *FileLogger) WithFields(fields ...domain.Field) domain.Logger {
+	newFields := make([]domain.Field, 0, len(f.fields)+len(fields))
+	newFields = append(newFields, f.fields...)
+	newFields = append(newFields, fields...)
+
+	return &FileLogger{
+		wrapped:   f.wrapped.WithFields(fields...),
+		sink:      f.sink,
+		component: f.component,
+		fields:    newFields,
+	}
+}
+
+func (f *FileLogger) write(level domain.LogLevel, msg string, fields []domain.Field) {
+	timestamp := time.Now().Format("2006-01-02T15:04:05.000Z07:00")
+	line := fmt.Sprintf("[%s] [%s] [%s] %s", timestamp, levelToString(level), f.component, msg)
+
+	allFields := append(append([]domain.Field{}, f.fields...), fields...)
+	if len(allFields) > 0 {
+		pairs := make([]string, len(allFields))
+		for i, field := range allFields {
+			pairs[i] = fmt.Sprintf("%s=%v", field.Key, field.Value)
+		}
+		line += " {" + strings.Join(pairs, ", ") + "}"
+	}
+
+	f.sink.writeLine(line)
+}
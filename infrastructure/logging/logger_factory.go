@@ -2,6 +2,7 @@ package logging
 
 import (
 	"context"
+	"io"
 	"strings"
 
 	"github.com/ca-srg/tosage/domain"
@@ -9,29 +10,65 @@ import (
 )
 
 type LoggerFactoryImpl struct {
-	config *config.LoggingConfig
+	config   *config.LoggingConfig
+	preview  io.Writer
+	fileSink *FileSink
 }
 
-func NewLoggerFactory(config *config.LoggingConfig) domain.LoggerFactory {
-	return &LoggerFactoryImpl{
+// LoggerFactoryOption configures a LoggerFactoryImpl
+type LoggerFactoryOption func(*LoggerFactoryImpl)
+
+// WithPreviewWriter writes the Loki-bound log stream, with its labels, to w
+// instead of pushing it to Loki
+func WithPreviewWriter(w io.Writer) LoggerFactoryOption {
+	return func(f *LoggerFactoryImpl) {
+		f.preview = w
+	}
+}
+
+// WithFileSink also writes every log entry that passes the level filter to sink
+func WithFileSink(sink *FileSink) LoggerFactoryOption {
+	return func(f *LoggerFactoryImpl) {
+		f.fileSink = sink
+	}
+}
+
+func NewLoggerFactory(config *config.LoggingConfig, opts ...LoggerFactoryOption) domain.LoggerFactory {
+	f := &LoggerFactoryImpl{
 		config: config,
 	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
 }
 
 func (f *LoggerFactoryImpl) CreateLogger(component string) domain.Logger {
-	promtailLogger, err := NewPromtailLogger(f.config.Promtail.URL, f.config.Promtail.Username, f.config.Promtail.Password, component)
+	if f.preview != nil {
+		return f.wrap(NewPreviewLogger(f.preview, component), component)
+	}
+
+	promtailLogger, err := NewPromtailLogger(f.config.Promtail.URL, f.config.Promtail.Username, f.config.Promtail.Password, component, NewPromtailOptions(f.config.Promtail)...)
 	if err != nil {
-		// Fallback to a no-op logger if promtail is not available
-		return &NoOpLogger{}
+		// Fall back to a no-op Loki sink if promtail is not available; the log file still applies
+		return f.wrap(&NoOpLogger{}, component)
+	}
+
+	return f.wrap(promtailLogger, component)
+}
+
+// wrap applies the log file, log level filtering and debug output to a Loki-bound logger
+func (f *LoggerFactoryImpl) wrap(sink domain.Logger, component string) domain.Logger {
+	if f.fileSink != nil {
+		sink = NewFileLogger(sink, f.fileSink, component)
 	}
 
 	// Apply log level filtering
-	var logger domain.Logger = promtailLogger
-	logger = NewLevelFilterLogger(logger, f.parseLogLevel(f.config.Level))
+	logger := NewLevelFilterLogger(sink, f.parseLogLevel(f.config.Level))
 
 	// Wrap with debug logger if debug mode is enabled
 	if f.config.Debug {
-		logger = NewDebugLogger(logger, component)
+		return NewDebugLogger(logger, component)
 	}
 
 	return logger
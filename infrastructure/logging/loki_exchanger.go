@@ -0,0 +1,188 @@
+package logging
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ca-srg/tosage/infrastructure/config"
+	"github.com/ic2hrmk/promtail"
+)
+
+// lokiRequestTimeout matches the timeout the promtail library uses for pings
+const lokiRequestTimeout = 5 * time.Second
+
+// lokiExchanger pushes log streams to the Loki v1 JSON API with a caller
+// supplied HTTP client. The promtail library's own exchanger always uses a
+// bare http.Client, which rules out client certificates (mTLS).
+type lokiExchanger struct {
+	client   *http.Client
+	address  string
+	username string
+	password string
+	// passwordFile, when set, supplies the password instead of password
+	passwordFile *config.SecretFile
+	// tokenFile, when set, supplies a bearer token sent instead of basic authentication
+	tokenFile *config.SecretFile
+	// compress gzips each pushed batch
+	compress bool
+}
+
+// lokiPushRequest is the body of POST /loki/api/v1/push
+type lokiPushRequest struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// lokiAddress defaults the scheme to http:// like promtail.NewJSONv1Client
+func lokiAddress(address string) string {
+	if !strings.HasPrefix(address, "http://") && !strings.HasPrefix(address, "https://") {
+		return "http://" + address
+	}
+	return address
+}
+
+// newLokiExchanger creates an exchanger that pushes to address with client
+func newLokiExchanger(address string, client *http.Client) *lokiExchanger {
+	return &lokiExchanger{
+		client:  client,
+		address: lokiAddress(address),
+	}
+}
+
+// SetBasicAuth implements promtail.BasicAuthExchanger
+func (e *lokiExchanger) SetBasicAuth(username, password string) {
+	e.username = username
+	e.password = password
+}
+
+// setAuthorization authenticates req with the bearer token or the basic credentials
+func (e *lokiExchanger) setAuthorization(req *http.Request) {
+	if e.tokenFile != nil {
+		req.Header.Set("Authorization", "Bearer "+e.tokenFile.Value())
+		return
+	}
+	password := e.password
+	if e.passwordFile != nil {
+		password = e.passwordFile.Value()
+	}
+	if e.username != "" && password != "" {
+		req.SetBasicAuth(e.username, password)
+	}
+}
+
+// Push implements promtail.StreamsExchanger
+func (e *lokiExchanger) Push(streams []*promtail.LogStream) error {
+	body, err := json.Marshal(e.buildPushRequest(streams))
+	if err != nil {
+		return fmt.Errorf("failed to encode push message: %w", err)
+	}
+
+	if e.compress {
+		if body, err = gzipBody(body); err != nil {
+			return fmt.Errorf("failed to compress push message: %w", err)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.address+"/loki/api/v1/push", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	e.setAuthorization(req)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send push message: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected response code [code=%d], message: %s", resp.StatusCode, string(message))
+	}
+	return nil
+}
+
+// gzipBody compresses a push request body
+func gzipBody(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(body); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Ping implements promtail.StreamsExchanger
+func (e *lokiExchanger) Ping() (*promtail.PongResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lokiRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.address+"/ready", nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build ping request: %w", err)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("pong is not received: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	return &promtail.PongResponse{
+		IsReady: resp.StatusCode >= 200 && resp.StatusCode < 300,
+	}, nil
+}
+
+// buildPushRequest converts promtail streams to the Loki push format, formatting
+// lines the same way as the promtail library ("LEVEL: message")
+func (e *lokiExchanger) buildPushRequest(streams []*promtail.LogStream) *lokiPushRequest {
+	req := &lokiPushRequest{
+		Streams: make([]*lokiStream, 0, len(streams)),
+	}
+
+	for _, stream := range streams {
+		if stream == nil || len(stream.Entries) == 0 {
+			continue
+		}
+
+		out := &lokiStream{
+			Stream: stream.Labels,
+			Values: make([][2]string, 0, len(stream.Entries)),
+		}
+		for _, entry := range stream.Entries {
+			if entry == nil {
+				continue
+			}
+			out.Values = append(out.Values, [2]string{
+				strconv.FormatInt(entry.Timestamp.UnixNano(), 10),
+				stream.Level.String() + ": " + fmt.Sprintf(entry.Format, entry.Args...),
+			})
+		}
+		req.Streams = append(req.Streams, out)
+	}
+
+	return req
+}
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ca-srg/tosage/domain"
+)
+
+// previewLevelLabel is the label the promtail client adds with the entry's level
+const previewLevelLabel = "logLevel"
+
+// PreviewLogger writes the log entries that would be pushed to Loki to a writer instead,
+// one line per entry with the full label set of its stream, e.g.
+//
+//	2025-01-02T03:04:05Z {app="tosage", component="metrics", level="INFO", logLevel="INFO"} Sent metrics
+type PreviewLogger struct {
+	out       io.Writer
+	mu        *sync.Mutex
+	component string
+	fields    []domain.Field
+}
+
+// NewPreviewLogger creates a logger that previews the Loki streams of component on out
+func NewPreviewLogger(out io.Writer, component string) *PreviewLogger {
+	return &PreviewLogger{
+		out:       out,
+		mu:        &sync.Mutex{},
+		component: component,
+	}
+}
+
+func (p *PreviewLogger) Debug(ctx context.Context, msg string, fields ...domain.Field) {
+	p.log(domain.LogLevelDebug, msg, fields)
+}
+
+func (p *PreviewLogger) Info(ctx context.Context, msg string, fields ...domain.Field) {
+	p.log(domain.LogLevelInfo, msg, fields)
+}
+
+func (p *PreviewLogger) Warn(ctx context.Context, msg string, fields ...domain.Field) {
+	p.log(domain.LogLevelWarn, msg, fields)
+}
+
+func (p *PreviewLogger) Error(ctx context.Context, msg string, fields ...domain.Field) {
+	p.log(domain.LogLevelError, msg, fields)
+}
+
+func (p *PreviewLogger) WithFields(fields ...domain.Field) domain.Logger {
+	newFields := make([]domain.Field, 0, len(p.fields)+len(fields))
+	newFields = append(newFields, p.fields...)
+	newFields = append(newFields, fields...)
+
+	return &PreviewLogger{
+		out:       p.out,
+		mu:        p.mu,
+		component: p.component,
+		fields:    newFields,
+	}
+}
+
+func (p *PreviewLogger) log(level domain.LogLevel, msg string, fields []domain.Field) {
+	allFields := make([]domain.Field, 0, len(p.fields)+len(fields))
+	allFields = append(allFields, p.fields...)
+	allFields = append(allFields, fields...)
+
+	// Merge in the same order as the promtail client: defaults, entry labels, level
+	labels := defaultLabels(p.component)
+	for key, value := range entryLabels(level, allFields) {
+		labels[key] = value
+	}
+	labels[previewLevelLabel] = levelToString(level)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, _ = fmt.Fprintf(p.out, "%s %s %s\n", time.Now().Format(time.RFC3339), formatLabelSet(labels), msg)
+}
+
+// formatLabelSet formats labels like a LogQL stream selector, sorted by name
+func formatLabelSet(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + strconv.Quote(labels[name])
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
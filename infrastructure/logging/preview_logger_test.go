@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ca-srg/tosage/domain"
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+func TestPreviewLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewPreviewLogger(&out, "metrics").WithFields(domain.NewField("host", "laptop"))
+
+	logger.Warn(context.Background(), "Failed to send metrics", domain.NewField("error", `quote " here`))
+
+	line := strings.TrimSpace(out.String())
+	want := `{app="tosage", component="metrics", error="quote \" here", host="laptop", level="WARN", logLevel="WARN"} Failed to send metrics`
+	if !strings.HasSuffix(line, want) {
+		t.Errorf("preview line = %q, want suffix %q", line, want)
+	}
+}
+
+func TestLoggerFactory_PreviewHonorsLevel(t *testing.T) {
+	var out bytes.Buffer
+	factory := NewLoggerFactory(&config.LoggingConfig{
+		Level:    "warn",
+		Promtail: &config.PromtailConfig{URL: "http://localhost:3100"},
+	}, WithPreviewWriter(&out))
+
+	logger := factory.CreateLogger("tosage")
+	logger.Info(context.Background(), "not pushed")
+	logger.Error(context.Background(), "pushed")
+
+	if got := out.String(); strings.Contains(got, "not pushed") || !strings.Contains(got, "pushed") {
+		t.Errorf("preview output = %q, want only entries at or above the configured level", got)
+	}
+}
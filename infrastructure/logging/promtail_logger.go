@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/ca-srg/tosage/domain"
+	"github.com/ca-srg/tosage/infrastructure/config"
+	"github.com/ca-srg/tosage/infrastructure/httpclient"
 	"github.com/ic2hrmk/promtail"
 )
 
@@ -17,18 +19,103 @@ type PromtailLogger struct {
 	mu        sync.RWMutex
 }
 
-func NewPromtailLogger(url, username, password, component string) (*PromtailLogger, error) {
-	// Default labels for all logs
-	defaultLabels := map[string]string{
-		"app":       "tosage",
-		"component": component,
+// PromtailOption configures a PromtailLogger
+type PromtailOption func(*promtailOptions)
+
+type promtailOptions struct {
+	clientCertPath string
+	clientKeyPath  string
+	passwordFile   string
+	tokenFile      string
+	compress       bool
+	batchSize      int
+	batchWait      time.Duration
+}
+
+// Batching defaults of the promtail client
+const (
+	defaultPromtailBatchSize = 100
+	defaultPromtailBatchWait = 1 * time.Second
+)
+
+// WithClientCertificate makes the logger present a client certificate to Loki (mTLS)
+func WithClientCertificate(certPath, keyPath string) PromtailOption {
+	return func(o *promtailOptions) {
+		o.clientCertPath = certPath
+		o.clientKeyPath = keyPath
+	}
+}
+
+// WithCredentialFiles reads the Loki password or a bearer token from files. The token
+// is sent instead of basic authentication and the password file takes precedence over
+// the password passed to the logger.
+func WithCredentialFiles(passwordFile, tokenFile string) PromtailOption {
+	return func(o *promtailOptions) {
+		o.passwordFile = passwordFile
+		o.tokenFile = tokenFile
+	}
+}
+
+// WithCompression gzips the body of each pushed batch
+func WithCompression(enabled bool) PromtailOption {
+	return func(o *promtailOptions) {
+		o.compress = enabled
+	}
+}
+
+// WithBatching sets how many entries a batch holds at most and how long entries wait
+// for a batch to fill before it is pushed. Non-positive values keep the defaults.
+func WithBatching(size int, wait time.Duration) PromtailOption {
+	return func(o *promtailOptions) {
+		if size > 0 {
+			o.batchSize = size
+		}
+		if wait > 0 {
+			o.batchWait = wait
+		}
+	}
+}
+
+// NewPromtailOptions returns the options matching the configured Loki client settings
+func NewPromtailOptions(cfg *config.PromtailConfig) []PromtailOption {
+	opts := []PromtailOption{
+		WithCompression(cfg.ShouldCompress()),
+		WithBatching(cfg.BatchCapacity, time.Duration(cfg.BatchWaitSeconds)*time.Second),
+	}
+	if cfg.ClientCertPath != "" || cfg.ClientKeyPath != "" {
+		opts = append(opts, WithClientCertificate(cfg.ClientCertPath, cfg.ClientKeyPath))
+	}
+	if cfg.PasswordFile != "" || cfg.TokenFile != "" {
+		opts = append(opts, WithCredentialFiles(cfg.PasswordFile, cfg.TokenFile))
+	}
+	return opts
+}
+
+// newPromtailOptions applies opts to the defaults
+func newPromtailOptions(opts []PromtailOption) *promtailOptions {
+	options := &promtailOptions{
+		batchSize: defaultPromtailBatchSize,
+		batchWait: defaultPromtailBatchWait,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+func NewPromtailLogger(url, username, password, component string, opts ...PromtailOption) (*PromtailLogger, error) {
+	options := newPromtailOptions(opts)
+
+	exchanger, err := newStreamsExchanger(url, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create promtail client: %w", err)
 	}
 
-	client, err := promtail.NewJSONv1Client(
-		url,
-		defaultLabels,
-		promtail.WithSendBatchSize(100),
-		promtail.WithSendBatchTimeout(1*time.Second),
+	client, err := promtail.NewClient(
+		exchanger,
+		defaultLabels(component),
+		promtail.WithSendBatchSize(uint(options.batchSize)),
+		promtail.WithSendBatchTimeout(options.batchWait),
 		promtail.WithBasicAuth(username, password),
 	)
 	if err != nil {
@@ -42,6 +129,83 @@ func NewPromtailLogger(url, username, password, component string) (*PromtailLogg
 	}, nil
 }
 
+// defaultLabels returns the labels attached to every log stream of a component
+func defaultLabels(component string) map[string]string {
+	return map[string]string{
+		"app":       "tosage",
+		"component": component,
+	}
+}
+
+// entryLabels returns the labels a log entry adds to the default labels
+func entryLabels(level domain.LogLevel, fields []domain.Field) map[string]string {
+	labels := map[string]string{
+		"level": levelToString(level),
+	}
+	for _, field := range fields {
+		labels[field.Key] = fmt.Sprintf("%v", field.Value)
+	}
+	return labels
+}
+
+// newStreamsExchanger creates the exchanger that pushes streams to Loki. The library's own
+// exchanger always uses a bare http.Client, so every push goes through our exchanger to send
+// the tosage User-Agent and support client certificates, compression and credential files.
+func newStreamsExchanger(url string, options *promtailOptions) (promtail.StreamsExchanger, error) {
+	httpClient := httpclient.NewClient(lokiRequestTimeout)
+	if options.clientCertPath != "" || options.clientKeyPath != "" {
+		var err error
+		httpClient, err = httpclient.NewClientWithCertificate(lokiRequestTimeout, options.clientCertPath, options.clientKeyPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	exchanger := newLokiExchanger(url, httpClient)
+	exchanger.compress = options.compress
+	if options.tokenFile != "" {
+		tokenFile, err := config.LoadSecretFile(options.tokenFile)
+		if err != nil {
+			return nil, err
+		}
+		exchanger.tokenFile = tokenFile
+	} else if options.passwordFile != "" {
+		passwordFile, err := config.LoadSecretFile(options.passwordFile)
+		if err != nil {
+			return nil, err
+		}
+		exchanger.passwordFile = passwordFile
+	}
+	return exchanger, nil
+}
+
+// PushTestLog pushes a single log line to Loki synchronously, bypassing the batching
+// client, so that connection and authentication failures are returned to the caller
+func PushTestLog(url, username, password, component, message string, opts ...PromtailOption) error {
+	options := newPromtailOptions(opts)
+
+	exchanger, err := newStreamsExchanger(url, options)
+	if err != nil {
+		return err
+	}
+	if authExchanger, ok := exchanger.(promtail.BasicAuthExchanger); ok && username != "" {
+		authExchanger.SetBasicAuth(username, password)
+	}
+
+	return exchanger.Push([]*promtail.LogStream{{
+		Level: promtail.Info,
+		Labels: map[string]string{
+			"app":       "tosage",
+			"component": component,
+			"level":     "info",
+		},
+		Entries: []*promtail.LogEntry{{
+			Timestamp: time.Now(),
+			Format:    "%s",
+			Args:      []interface{}{message},
+		}},
+	}})
+}
+
 func (p *PromtailLogger) Debug(ctx context.Context, msg string, fields ...domain.Field) {
 	p.log(ctx, domain.LogLevelDebug, msg, fields...)
 }
@@ -77,14 +241,7 @@ func (p *PromtailLogger) log(ctx context.Context, level domain.LogLevel, msg str
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	labels := map[string]string{
-		"level": levelToString(level),
-	}
-
-	allFields := append(p.fields, fields...)
-	for _, field := range allFields {
-		labels[field.Key] = fmt.Sprintf("%v", field.Value)
-	}
+	labels := entryLabels(level, append(p.fields, fields...))
 
 	// Convert domain log level to promtail log level
 	var promtailLevel promtail.Level
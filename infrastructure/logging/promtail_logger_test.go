@@ -1,10 +1,21 @@
 package logging
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ca-srg/tosage/domain"
+	"github.com/ca-srg/tosage/infrastructure/httpclient"
 )
 
 func TestPromtailLogger_LogMethods(t *testing.T) {
@@ -137,3 +148,172 @@ func TestLevelToString(t *testing.T) {
 		})
 	}
 }
+
+func TestPushTestLog(t *testing.T) {
+	var gotPath, gotUser, gotPass, gotBody, gotUserAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotUserAgent = r.UserAgent()
+		gotUser, gotPass, _ = r.BasicAuth()
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	if err := PushTestLog(server.URL, "user", "secret", "selftest", "tosage self-test"); err != nil {
+		t.Fatalf("PushTestLog() error = %v", err)
+	}
+	if gotPath != "/loki/api/v1/push" {
+		t.Errorf("path = %q, want /loki/api/v1/push", gotPath)
+	}
+	if gotUser != "user" || gotPass != "secret" {
+		t.Errorf("basic auth = %q/%q, want user/secret", gotUser, gotPass)
+	}
+	if gotUserAgent != httpclient.UserAgent() {
+		t.Errorf("User-Agent = %q, want %q", gotUserAgent, httpclient.UserAgent())
+	}
+	if !strings.Contains(gotBody, "tosage self-test") || !strings.Contains(gotBody, `"component":"selftest"`) {
+		t.Errorf("body = %s, want the test message and component label", gotBody)
+	}
+}
+
+func TestPushTestLog_CredentialFiles(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(passwordFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tokenFile, []byte("bearer-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := PushTestLog(server.URL, "user", "inline", "selftest", "tosage self-test", WithCredentialFiles(passwordFile, "")); err != nil {
+		t.Fatalf("PushTestLog() error = %v", err)
+	}
+	req := &http.Request{Header: http.Header{"Authorization": {gotAuth}}}
+	if user, pass, _ := req.BasicAuth(); user != "user" || pass != "from-file" {
+		t.Errorf("basic auth = %q/%q, want user/from-file", user, pass)
+	}
+
+	if err := PushTestLog(server.URL, "user", "inline", "selftest", "tosage self-test", WithCredentialFiles(passwordFile, tokenFile)); err != nil {
+		t.Fatalf("PushTestLog() error = %v", err)
+	}
+	if gotAuth != "Bearer bearer-token" {
+		t.Errorf("Authorization = %q, want the bearer token", gotAuth)
+	}
+}
+
+func TestPushTestLog_Compressed(t *testing.T) {
+	var gotEncoding string
+	var got lokiPushRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotEncoding = r.Header.Get("Content-Encoding")
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("body is not gzip: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := json.NewDecoder(reader).Decode(&got); err != nil {
+			t.Errorf("decompressed body is not a push request: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	if err := PushTestLog(server.URL, "user", "secret", "selftest", "tosage self-test", WithCompression(true)); err != nil {
+		t.Fatalf("PushTestLog() error = %v", err)
+	}
+	if gotEncoding != "gzip" {
+		t.Errorf("Content-Encoding = %q, want gzip", gotEncoding)
+	}
+	if len(got.Streams) != 1 || got.Streams[0].Stream["component"] != "selftest" || len(got.Streams[0].Values) != 1 {
+		t.Fatalf("streams = %+v, want one selftest stream with one entry", got.Streams)
+	}
+	if line := got.Streams[0].Values[0][1]; line != "INFO: tosage self-test" {
+		t.Errorf("line = %q, want %q", line, "INFO: tosage self-test")
+	}
+}
+
+func TestPromtailLogger_CompressedBatches(t *testing.T) {
+	var mu sync.Mutex
+	var batches []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer w.WriteHeader(http.StatusNoContent)
+		if r.URL.Path != "/loki/api/v1/push" {
+			return
+		}
+		if r.Header.Get("Content-Encoding") != "gzip" {
+			t.Errorf("Content-Encoding = %q, want gzip", r.Header.Get("Content-Encoding"))
+			return
+		}
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("body is not gzip: %v", err)
+			return
+		}
+		var req lokiPushRequest
+		if err := json.NewDecoder(reader).Decode(&req); err != nil {
+			t.Errorf("decompressed body is not a push request: %v", err)
+			return
+		}
+		entries := 0
+		for _, stream := range req.Streams {
+			entries += len(stream.Values)
+		}
+		mu.Lock()
+		batches = append(batches, entries)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	logger, err := NewPromtailLogger(server.URL, "", "", "test", WithCompression(true), WithBatching(2, 50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewPromtailLogger() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		logger.Info(context.Background(), "message")
+	}
+
+	// Each batch is compressed on its own: a full batch of 2, then the remaining entry
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		total := 0
+		for _, n := range batches {
+			total += n
+		}
+		mu.Unlock()
+		if total >= 3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	_ = logger.Shutdown()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 2 || batches[0] != 2 || batches[1] != 1 {
+		t.Errorf("batches = %v, want [2 1]", batches)
+	}
+}
+
+func TestPushTestLog_Rejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	if err := PushTestLog(server.URL, "", "", "selftest", "tosage self-test"); err == nil {
+		t.Error("PushTestLog() should fail when Loki rejects the push")
+	}
+}
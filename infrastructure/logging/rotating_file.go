@@ -0,0 +1,245 @@
+package logging
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedTimeFormat is the timestamp inserted into the names of rotated log files,
+// e.g. tosage-2025-01-02T03-04-05.000.log
+const rotatedTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotationOptions controls when a RotatingFileWriter rotates and which rotated files it keeps
+type RotationOptions struct {
+	// MaxSizeMB is the size in megabytes at which the file is rotated
+	MaxSizeMB int
+	// MaxBackups is the number of rotated files kept (0 keeps all)
+	MaxBackups int
+	// MaxAgeDays removes rotated files older than this many days (0 keeps them regardless of age)
+	MaxAgeDays int
+	// Compress gzips rotated files
+	Compress bool
+}
+
+// RotatingFileWriter appends to a log file and rotates it once it would grow past the
+// configured size. The rotated file is renamed with a timestamp next to the original,
+// and gzipped and old rotated files removed by count and age in the background.
+type RotatingFileWriter struct {
+	path    string
+	options RotationOptions
+	now     func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	closed bool
+
+	// cleanupMu serializes the compression and removal of rotated files
+	cleanupMu sync.Mutex
+	cleanup   sync.WaitGroup
+}
+
+// NewRotatingFileWriter opens path for appending, creating it and its directory if needed
+func NewRotatingFileWriter(path string, options RotationOptions) (*RotatingFileWriter, error) {
+	w := &RotatingFileWriter{
+		path:    path,
+		options: options,
+		now:     time.Now,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends p to the file, rotating it first if p would take it past the size limit.
+// A single write larger than the limit is written to a fresh file as is. If the file could
+// not be reopened after a rotation, the open is retried on the next write.
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, fmt.Errorf("log file %s is closed", w.path)
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.size > 0 && w.size+int64(len(p)) > w.maxBytes() {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file and waits for rotated files to be compressed
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+
+	w.cleanup.Wait()
+	return err
+}
+
+// maxBytes returns the size limit in bytes
+func (w *RotatingFileWriter) maxBytes() int64 {
+	return int64(w.options.MaxSizeMB) * 1024 * 1024
+}
+
+// open opens the log file for appending and records its current size
+func (w *RotatingFileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// rotate renames the current file aside, opens a new one and starts cleaning up old rotated
+// files. When the rename or the open fails, the file is left closed and reopened by the next
+// write. Compression and cleanup run without the lock and their failures are not returned,
+// so logging carries on regardless.
+func (w *RotatingFileWriter) rotate() error {
+	err := w.file.Close()
+	w.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	now := w.now()
+	rotated := w.rotatedName(now)
+	if err := os.Rename(w.path, rotated); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	w.cleanup.Add(1)
+	go func() {
+		defer w.cleanup.Done()
+		w.cleanupMu.Lock()
+		defer w.cleanupMu.Unlock()
+
+		if w.options.Compress {
+			if err := compressFile(rotated); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Warning: failed to compress rotated log file %s: %v\n", rotated, err)
+			}
+		}
+		w.removeOldBackups(now)
+	}()
+
+	return w.open()
+}
+
+// rotatedName returns the name the current file is renamed to when rotated at t
+func (w *RotatingFileWriter) rotatedName(t time.Time) string {
+	ext := filepath.Ext(w.path)
+	return strings.TrimSuffix(w.path, ext) + "-" + t.Format(rotatedTimeFormat) + ext
+}
+
+// rotatedFile is a rotated log file and the time it was rotated at
+type rotatedFile struct {
+	path string
+	at   time.Time
+}
+
+// backups returns the rotated files of the log, newest first
+func (w *RotatingFileWriter) backups() []rotatedFile {
+	ext := filepath.Ext(w.path)
+	prefix := filepath.Base(strings.TrimSuffix(w.path, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(w.path))
+	if err != nil {
+		return nil
+	}
+
+	var files []rotatedFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name[len(prefix):], ".gz"), ext)
+		at, err := time.ParseInLocation(rotatedTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		files = append(files, rotatedFile{path: filepath.Join(filepath.Dir(w.path), name), at: at})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].at.After(files[j].at) })
+	return files
+}
+
+// removeOldBackups removes rotated files beyond MaxBackups and older than MaxAgeDays at now
+func (w *RotatingFileWriter) removeOldBackups(now time.Time) {
+	cutoff := time.Time{}
+	if w.options.MaxAgeDays > 0 {
+		cutoff = now.AddDate(0, 0, -w.options.MaxAgeDays)
+	}
+	for i, file := range w.backups() {
+		tooMany := w.options.MaxBackups > 0 && i >= w.options.MaxBackups
+		tooOld := !cutoff.IsZero() && file.at.Before(cutoff)
+		if tooMany || tooOld {
+			_ = os.Remove(file.path)
+		}
+	}
+}
+
+// compressFile gzips path to path.gz and removes the original
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		_ = gz.Close()
+		_ = dst.Close()
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
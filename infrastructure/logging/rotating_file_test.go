@@ -0,0 +1,153 @@
+package logging
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+func TestRotatingFileWriter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tosage.log")
+	w, err := NewRotatingFileWriter(path, RotationOptions{MaxSizeMB: 1, MaxBackups: 2, MaxAgeDays: 30, Compress: true})
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter() error = %v", err)
+	}
+	defer func() {
+		_ = w.Close()
+	}()
+
+	// An old rotated file from an earlier run is removed by age at the next rotation
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local)
+	stale := filepath.Join(dir, "tosage-"+now.AddDate(0, 0, -31).Format(rotatedTimeFormat)+".log.gz")
+	if err := os.WriteFile(stale, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each write fills most of the 1MB limit, so every following write rotates
+	chunk := bytes.Repeat([]byte("x"), 700*1024)
+	for i := 0; i < 4; i++ {
+		w.now = func() time.Time { return now.Add(time.Duration(i) * time.Minute) }
+		if _, err := w.Write(chunk); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	// Rotated files are compressed and cleaned up in the background
+	w.cleanup.Wait()
+
+	backups := w.backups()
+	if len(backups) != 2 {
+		t.Fatalf("backups = %v, want the 2 newest", backups)
+	}
+	if want := now.Add(3 * time.Minute); !backups[0].at.Equal(want) {
+		t.Errorf("newest backup rotated at %v, want %v", backups[0].at, want)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("backup older than the max age was kept")
+	}
+
+	// Rotated files are gzipped and hold a full chunk
+	if !strings.HasSuffix(backups[0].path, ".log.gz") {
+		t.Fatalf("backup %s is not compressed", backups[0].path)
+	}
+	file, err := os.Open(backups[0].path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	content, err := io.ReadAll(gz)
+	if err != nil || len(content) != len(chunk) {
+		t.Errorf("backup holds %d bytes (err %v), want %d", len(content), err, len(chunk))
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Size() != int64(len(chunk)) {
+		t.Errorf("current log file should hold only the last write")
+	}
+}
+
+func TestRotatingFileWriter_ReopensAfterFailedRotation(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	path := filepath.Join(dir, "tosage.log")
+	w, err := NewRotatingFileWriter(path, RotationOptions{MaxSizeMB: 1})
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter() error = %v", err)
+	}
+	defer func() {
+		_ = w.Close()
+	}()
+
+	chunk := bytes.Repeat([]byte("x"), 700*1024)
+	if _, err := w.Write(chunk); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	// Replace the log directory with a file so that both the rotation and the reopen fail
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(chunk); err == nil {
+		t.Fatal("Write() error = nil, want the failed rotation")
+	}
+	if _, err := w.Write(chunk); err == nil {
+		t.Fatal("Write() error = nil, want the failed reopen")
+	}
+
+	// Once the directory can be created again, writing carries on
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("recovered\n")); err != nil {
+		t.Fatalf("Write() error = %v, want the file reopened", err)
+	}
+	if content, err := os.ReadFile(path); err != nil || string(content) != "recovered\n" {
+		t.Errorf("log file = %q (err %v), want the write after recovery", content, err)
+	}
+}
+
+func TestLoggerFactory_FileSink(t *testing.T) {
+	var out bytes.Buffer
+	sink := NewFileSink()
+	factory := NewLoggerFactory(&config.LoggingConfig{
+		Level:    "info",
+		Promtail: &config.PromtailConfig{URL: "http://localhost:3100"},
+	}, WithPreviewWriter(io.Discard), WithFileSink(sink))
+	logger := factory.CreateLogger("metrics")
+
+	// Nothing is written until the sink has a writer
+	logger.Info(context.Background(), "before open")
+	sink.SetWriter(nopWriteCloser{&out})
+	logger.Debug(context.Background(), "filtered")
+	logger.WithFields().Warn(context.Background(), "Failed to send metrics")
+
+	got := out.String()
+	if strings.Contains(got, "before open") || strings.Contains(got, "filtered") {
+		t.Errorf("log file = %q, want only entries written after opening and above the level", got)
+	}
+	if !strings.Contains(got, "[WARN] [metrics] Failed to send metrics") {
+		t.Errorf("log file = %q, want the warning", got)
+	}
+}
+
+// nopWriteCloser adds a no-op Close to a writer
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -1,29 +1,59 @@
 package repository
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
 	"github.com/ca-srg/tosage/domain/entity"
 	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/infrastructure/config"
+	"github.com/ca-srg/tosage/infrastructure/httpclient"
 )
 
+// CloudWatchMetricNames are the CloudWatch names Bedrock usage is read from
+type CloudWatchMetricNames struct {
+	Namespace         string
+	InputTokenMetric  string
+	OutputTokenMetric string
+	InvocationsMetric string
+	LatencyMetric     string
+	ModelDimension    string
+}
+
+// DefaultCloudWatchMetricNames returns the names of the metrics Bedrock publishes itself
+func DefaultCloudWatchMetricNames() CloudWatchMetricNames {
+	return CloudWatchMetricNames{
+		Namespace:         config.DefaultBedrockNamespace,
+		InputTokenMetric:  config.DefaultBedrockInputTokenMetric,
+		OutputTokenMetric: config.DefaultBedrockOutputTokenMetric,
+		InvocationsMetric: config.DefaultBedrockInvocationsMetric,
+		LatencyMetric:     config.DefaultBedrockLatencyMetric,
+		ModelDimension:    config.DefaultBedrockModelDimension,
+	}
+}
+
 // BedrockCloudWatchRepository implements BedrockRepository using AWS CloudWatch
 type BedrockCloudWatchRepository struct {
 	session    *session.Session
 	cwClients  map[string]*cloudwatch.CloudWatch
 	awsProfile string
+	names      CloudWatchMetricNames
+	batchSize  int
 }
 
 // NewBedrockCloudWatchRepository creates a new Bedrock CloudWatch repository
 func NewBedrockCloudWatchRepository(awsProfile string) (*BedrockCloudWatchRepository, error) {
 	// Create AWS session
+	// CloudWatch calls go through the shared client so they count against the request limit
 	sess, err := session.NewSessionWithOptions(session.Options{
 		Profile:           awsProfile,
 		SharedConfigState: session.SharedConfigEnable,
+		Config:            aws.Config{HTTPClient: httpclient.NewClient(0)},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create AWS session: %w", err)
@@ -33,9 +63,40 @@ func NewBedrockCloudWatchRepository(awsProfile string) (*BedrockCloudWatchReposi
 		session:    sess,
 		cwClients:  make(map[string]*cloudwatch.CloudWatch),
 		awsProfile: awsProfile,
+		names:      DefaultCloudWatchMetricNames(),
+		batchSize:  config.DefaultBedrockMetricBatchSize,
 	}, nil
 }
 
+// SetMetricNames overrides the CloudWatch names to read; empty names keep their defaults
+func (r *BedrockCloudWatchRepository) SetMetricNames(names CloudWatchMetricNames) {
+	defaults := DefaultCloudWatchMetricNames()
+	pick := func(value, fallback string) string {
+		if value == "" {
+			return fallback
+		}
+		return value
+	}
+
+	r.names = CloudWatchMetricNames{
+		Namespace:         pick(names.Namespace, defaults.Namespace),
+		InputTokenMetric:  pick(names.InputTokenMetric, defaults.InputTokenMetric),
+		OutputTokenMetric: pick(names.OutputTokenMetric, defaults.OutputTokenMetric),
+		InvocationsMetric: pick(names.InvocationsMetric, defaults.InvocationsMetric),
+		LatencyMetric:     pick(names.LatencyMetric, defaults.LatencyMetric),
+		ModelDimension:    pick(names.ModelDimension, defaults.ModelDimension),
+	}
+}
+
+// SetMetricBatchSize sets the number of metric queries sent per GetMetricData request;
+// values outside 1-500 keep the default of 500
+func (r *BedrockCloudWatchRepository) SetMetricBatchSize(size int) {
+	if size <= 0 || size > config.MaxBedrockMetricBatchSize {
+		size = config.DefaultBedrockMetricBatchSize
+	}
+	r.batchSize = size
+}
+
 // getCloudWatchClient returns a CloudWatch client for the specified region
 func (r *BedrockCloudWatchRepository) getCloudWatchClient(region string) *cloudwatch.CloudWatch {
 	if client, exists := r.cwClients[region]; exists {
@@ -52,21 +113,23 @@ func (r *BedrockCloudWatchRepository) GetUsageMetrics(region string, start, end
 	cwClient := r.getCloudWatchClient(region)
 
 	// Get input tokens
-	inputTokens, err := r.getMetricValue(cwClient, "AWS/Bedrock", "InputTokenCount", start, end)
+	inputTokens, err := r.getMetricValue(cwClient, r.names.Namespace, r.names.InputTokenMetric, start, end)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get input tokens: %w", err)
 	}
 
 	// Get output tokens
-	outputTokens, err := r.getMetricValue(cwClient, "AWS/Bedrock", "OutputTokenCount", start, end)
+	outputTokens, err := r.getMetricValue(cwClient, r.names.Namespace, r.names.OutputTokenMetric, start, end)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get output tokens: %w", err)
 	}
 
-	// Get model-specific metrics
+	// Get model-specific metrics. Regions without the model dimension still report the
+	// aggregate, just without a per-model breakdown; a failed query is returned instead of
+	// dropping the per-model series.
 	modelMetrics, err := r.getModelMetrics(cwClient, start, end)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get model metrics: %w", err)
+		return nil, fmt.Errorf("failed to get per-model metrics: %w", err)
 	}
 
 	// Calculate estimated cost (simplified - actual cost depends on model pricing)
@@ -109,14 +172,14 @@ func (r *BedrockCloudWatchRepository) GetCurrentMonthUsage(region string) (*enti
 }
 
 // CheckConnection verifies AWS credentials and CloudWatch access
-func (r *BedrockCloudWatchRepository) CheckConnection() error {
+func (r *BedrockCloudWatchRepository) CheckConnection(ctx context.Context) error {
 	// Test connection by listing metrics
 	cwClient := r.getCloudWatchClient("us-east-1")
 	input := &cloudwatch.ListMetricsInput{
-		Namespace: aws.String("AWS/Bedrock"),
+		Namespace: aws.String(r.names.Namespace),
 	}
 
-	_, err := cwClient.ListMetrics(input)
+	_, err := cwClient.ListMetricsWithContext(ctx, input)
 	if err != nil {
 		return fmt.Errorf("failed to connect to CloudWatch: %w", err)
 	}
@@ -141,7 +204,7 @@ func (r *BedrockCloudWatchRepository) ListAvailableRegions() ([]string, error) {
 
 		// Check if there are any Bedrock metrics in this region
 		input := &cloudwatch.ListMetricsInput{
-			Namespace: aws.String("AWS/Bedrock"),
+			Namespace: aws.String(r.names.Namespace),
 		}
 
 		result, err := cwClient.ListMetrics(input)
@@ -187,76 +250,106 @@ func (r *BedrockCloudWatchRepository) getMetricValue(
 	return total, nil
 }
 
-// getModelMetrics retrieves model-specific metrics
+// getModelMetrics retrieves model-specific metrics. The metrics of all models are read
+// with GetMetricData, batched by the metric batch size.
 func (r *BedrockCloudWatchRepository) getModelMetrics(
-	cwClient *cloudwatch.CloudWatch,
+	cwClient cloudwatchiface.CloudWatchAPI,
 	start, end time.Time,
 ) ([]entity.BedrockModelMetric, error) {
-	// List all metrics with ModelId dimension
+	// List all metrics with the model dimension
 	listInput := &cloudwatch.ListMetricsInput{
-		Namespace: aws.String("AWS/Bedrock"),
+		Namespace: aws.String(r.names.Namespace),
 	}
 
-	result, err := cwClient.ListMetrics(listInput)
-	if err != nil {
-		return nil, err
+	type modelQuery struct {
+		modelID    string
+		metricName string
 	}
+	var queries []*cloudwatch.MetricDataQuery
+	queried := make(map[string]modelQuery)
 
-	modelMap := make(map[string]*entity.BedrockModelMetric)
-
-	// Process each metric
-	for _, metric := range result.Metrics {
-		if metric.MetricName == nil {
-			continue
-		}
+	err := cwClient.ListMetricsPages(listInput, func(page *cloudwatch.ListMetricsOutput, lastPage bool) bool {
+		for _, metric := range page.Metrics {
+			if metric.MetricName == nil {
+				continue
+			}
+			switch *metric.MetricName {
+			case r.names.InputTokenMetric, r.names.OutputTokenMetric, r.names.InvocationsMetric, r.names.LatencyMetric:
+			default:
+				continue
+			}
 
-		// Find ModelId dimension
-		var modelID string
-		for _, dimension := range metric.Dimensions {
-			if dimension.Name != nil && *dimension.Name == "ModelId" {
-				if dimension.Value != nil {
-					modelID = *dimension.Value
+			// Find the model dimension
+			var modelID string
+			for _, dimension := range metric.Dimensions {
+				if dimension.Name != nil && *dimension.Name == r.names.ModelDimension {
+					if dimension.Value != nil {
+						modelID = *dimension.Value
+					}
+					break
 				}
-				break
 			}
-		}
-
-		if modelID == "" {
-			continue
-		}
 
-		// Initialize model metric if not exists
-		if _, exists := modelMap[modelID]; !exists {
-			modelMap[modelID] = &entity.BedrockModelMetric{
-				ModelID: modelID,
+			if modelID == "" {
+				continue
 			}
+
+			// Query IDs must start with a lowercase letter
+			id := fmt.Sprintf("m%d", len(queries))
+			queried[id] = modelQuery{modelID: modelID, metricName: *metric.MetricName}
+			queries = append(queries, &cloudwatch.MetricDataQuery{
+				Id: aws.String(id),
+				MetricStat: &cloudwatch.MetricStat{
+					Metric: metric,
+					Period: aws.Int64(3600), // 1 hour periods
+					Stat:   aws.String("Sum"),
+				},
+			})
 		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sums, err := r.getMetricDataSums(cwClient, queries, start, end)
+	if err != nil {
+		return nil, err
+	}
 
-		// Get metric value based on metric name
-		dimensions := make([]*cloudwatch.Dimension, len(metric.Dimensions))
-		copy(dimensions, metric.Dimensions)
+	modelMap := make(map[string]*entity.BedrockModelMetric)
+	latencySums := make(map[string]float64)
+	for id, value := range sums {
+		q := queried[id]
 
-		value, err := r.getMetricValueWithDimensions(cwClient, *metric.MetricName, dimensions, start, end)
-		if err != nil {
-			continue // Skip failed metrics
+		// Initialize model metric if not exists
+		if _, exists := modelMap[q.modelID]; !exists {
+			modelMap[q.modelID] = &entity.BedrockModelMetric{
+				ModelID: q.modelID,
+			}
 		}
 
-		// Update the appropriate field based on metric name
-		switch *metric.MetricName {
-		case "InputTokenCount":
-			modelMap[modelID].InputTokens = int64(value)
-		case "OutputTokenCount":
-			modelMap[modelID].OutputTokens = int64(value)
-		case "Invocations":
-			modelMap[modelID].InvocationCount = int64(value)
-		case "InvocationLatency":
-			modelMap[modelID].LatencyMs = value
+		// Update the appropriate field based on metric name. A model reported under
+		// several dimension sets, e.g. per region or per operation, is summed.
+		switch q.metricName {
+		case r.names.InputTokenMetric:
+			modelMap[q.modelID].InputTokens += int64(value)
+		case r.names.OutputTokenMetric:
+			modelMap[q.modelID].OutputTokens += int64(value)
+		case r.names.InvocationsMetric:
+			modelMap[q.modelID].InvocationCount += int64(value)
+		case r.names.LatencyMetric:
+			latencySums[q.modelID] += value
 		}
 	}
 
 	// Convert map to slice
 	var metrics []entity.BedrockModelMetric
-	for _, metric := range modelMap {
+	for modelID, metric := range modelMap {
+		// The latency sum over all invocations divided by their count is the mean latency
+		if metric.InvocationCount > 0 {
+			metric.LatencyMs = latencySums[modelID] / float64(metric.InvocationCount)
+		}
 		// Calculate cost (simplified)
 		metric.Cost = r.calculateModelCost(metric.InputTokens, metric.OutputTokens, metric.ModelID)
 		metrics = append(metrics, *metric)
@@ -265,36 +358,48 @@ func (r *BedrockCloudWatchRepository) getModelMetrics(
 	return metrics, nil
 }
 
-// getMetricValueWithDimensions retrieves a metric value with specific dimensions
-func (r *BedrockCloudWatchRepository) getMetricValueWithDimensions(
-	cwClient *cloudwatch.CloudWatch,
-	metricName string,
-	dimensions []*cloudwatch.Dimension,
+// getMetricDataSums runs the queries in batches of the metric batch size, following NextToken
+// within each batch, and returns the sum of the values of each query by its ID
+func (r *BedrockCloudWatchRepository) getMetricDataSums(
+	cwClient cloudwatchiface.CloudWatchAPI,
+	queries []*cloudwatch.MetricDataQuery,
 	start, end time.Time,
-) (float64, error) {
-	input := &cloudwatch.GetMetricStatisticsInput{
-		Namespace:  aws.String("AWS/Bedrock"),
-		MetricName: aws.String(metricName),
-		StartTime:  aws.Time(start),
-		EndTime:    aws.Time(end),
-		Period:     aws.Int64(3600), // 1 hour periods
-		Statistics: []*string{aws.String("Sum")},
-		Dimensions: dimensions,
+) (map[string]float64, error) {
+	batchSize := r.batchSize
+	if batchSize <= 0 || batchSize > config.MaxBedrockMetricBatchSize {
+		batchSize = config.DefaultBedrockMetricBatchSize
 	}
 
-	result, err := cwClient.GetMetricStatistics(input)
-	if err != nil {
-		return 0, err
-	}
-
-	total := 0.0
-	for _, datapoint := range result.Datapoints {
-		if datapoint.Sum != nil {
-			total += *datapoint.Sum
+	sums := make(map[string]float64, len(queries))
+	for first := 0; first < len(queries); first += batchSize {
+		input := &cloudwatch.GetMetricDataInput{
+			MetricDataQueries: queries[first:min(first+batchSize, len(queries))],
+			StartTime:         aws.Time(start),
+			EndTime:           aws.Time(end),
+		}
+		for {
+			result, err := cwClient.GetMetricData(input)
+			if err != nil {
+				return nil, err
+			}
+			for _, data := range result.MetricDataResults {
+				if data.Id == nil {
+					continue
+				}
+				for _, value := range data.Values {
+					if value != nil {
+						sums[*data.Id] += *value
+					}
+				}
+			}
+			if result.NextToken == nil || *result.NextToken == "" {
+				break
+			}
+			input.NextToken = result.NextToken
 		}
 	}
 
-	return total, nil
+	return sums, nil
 }
 
 // calculateEstimatedCost calculates estimated cost based on token usage
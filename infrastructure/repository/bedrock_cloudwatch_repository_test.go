@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+)
+
+// fakeCloudWatch lists metrics in pages and answers every GetMetricData query with the values
+// in values, splitting each answer over two pages
+type fakeCloudWatch struct {
+	cloudwatchiface.CloudWatchAPI
+	metricPages [][]*cloudwatch.Metric
+	values      map[string][]float64
+	batchSizes  []int
+}
+
+func (f *fakeCloudWatch) ListMetricsPages(input *cloudwatch.ListMetricsInput, fn func(*cloudwatch.ListMetricsOutput, bool) bool) error {
+	for i, page := range f.metricPages {
+		if !fn(&cloudwatch.ListMetricsOutput{Metrics: page}, i == len(f.metricPages)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeCloudWatch) GetMetricData(input *cloudwatch.GetMetricDataInput) (*cloudwatch.GetMetricDataOutput, error) {
+	if len(input.MetricDataQueries) > 500 {
+		return nil, fmt.Errorf("too many queries: %d", len(input.MetricDataQueries))
+	}
+	secondPage := input.NextToken != nil
+	if !secondPage {
+		f.batchSizes = append(f.batchSizes, len(input.MetricDataQueries))
+	}
+
+	output := &cloudwatch.GetMetricDataOutput{}
+	for _, query := range input.MetricDataQueries {
+		stat := query.MetricStat
+		key := *stat.Metric.MetricName + "/" + *stat.Metric.Dimensions[0].Value
+		values := f.values[key]
+		half := len(values) / 2
+		if secondPage {
+			values = values[half:]
+		} else {
+			values = values[:half]
+		}
+		output.MetricDataResults = append(output.MetricDataResults, &cloudwatch.MetricDataResult{
+			Id:     query.Id,
+			Values: aws.Float64Slice(values),
+		})
+	}
+	if !secondPage {
+		output.NextToken = aws.String("next")
+	}
+	return output, nil
+}
+
+func bedrockMetric(name, modelID string) *cloudwatch.Metric {
+	return &cloudwatch.Metric{
+		Namespace:  aws.String("AWS/Bedrock"),
+		MetricName: aws.String(name),
+		Dimensions: []*cloudwatch.Dimension{{Name: aws.String("ModelId"), Value: aws.String(modelID)}},
+	}
+}
+
+func TestBedrockCloudWatchRepository_GetModelMetricsBatches(t *testing.T) {
+	fake := &fakeCloudWatch{values: map[string][]float64{}}
+	var page []*cloudwatch.Metric
+	for i := 0; i < 30; i++ {
+		modelID := fmt.Sprintf("anthropic.claude-%d", i)
+		page = append(page,
+			bedrockMetric("InputTokenCount", modelID),
+			bedrockMetric("OutputTokenCount", modelID),
+			bedrockMetric("ModelInvocationThrottles", modelID))
+		fake.values["InputTokenCount/"+modelID] = []float64{100, 200, 300, 400}
+		fake.values["OutputTokenCount/"+modelID] = []float64{10, 20}
+		if len(page) == 45 {
+			fake.metricPages = append(fake.metricPages, page)
+			page = nil
+		}
+	}
+	fake.metricPages = append(fake.metricPages, page)
+
+	repo := &BedrockCloudWatchRepository{names: DefaultCloudWatchMetricNames()}
+	repo.SetMetricBatchSize(25)
+	start := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+	metrics, err := repo.getModelMetrics(fake, start, start.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("getModelMetrics() error = %v", err)
+	}
+
+	// 60 token queries from both pages of ListMetrics, the throttle metric is not queried
+	if got := fmt.Sprint(fake.batchSizes); got != "[25 25 10]" {
+		t.Errorf("batch sizes = %s, want [25 25 10]", got)
+	}
+	if len(metrics) != 30 {
+		t.Fatalf("got %d models, want 30", len(metrics))
+	}
+	for _, metric := range metrics {
+		if metric.InputTokens != 1000 || metric.OutputTokens != 30 {
+			t.Errorf("%s: tokens = %d in, %d out, want 1000 in, 30 out",
+				metric.ModelID, metric.InputTokens, metric.OutputTokens)
+		}
+	}
+}
+
+func TestBedrockCloudWatchRepository_GetModelMetricsLatency(t *testing.T) {
+	const modelID = "anthropic.claude-3"
+	// The same model reported under a second dimension set
+	perRegion := func(name string) *cloudwatch.Metric {
+		metric := bedrockMetric(name, modelID)
+		metric.Dimensions = append(metric.Dimensions, &cloudwatch.Dimension{Name: aws.String("Region"), Value: aws.String("us-east-1")})
+		return metric
+	}
+	fake := &fakeCloudWatch{
+		metricPages: [][]*cloudwatch.Metric{{
+			bedrockMetric("Invocations", modelID),
+			bedrockMetric("InvocationLatency", modelID),
+			perRegion("Invocations"),
+			perRegion("InvocationLatency"),
+		}},
+		values: map[string][]float64{
+			"Invocations/" + modelID:       {4, 6},
+			"InvocationLatency/" + modelID: {500, 1500},
+		},
+	}
+
+	repo := &BedrockCloudWatchRepository{names: DefaultCloudWatchMetricNames()}
+	start := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+	metrics, err := repo.getModelMetrics(fake, start, start.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("getModelMetrics() error = %v", err)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("got %d models, want 1", len(metrics))
+	}
+
+	// 4000ms of latency over 20 invocations, not the sum of the latency sums
+	if metrics[0].InvocationCount != 20 || metrics[0].LatencyMs != 200 {
+		t.Errorf("invocations = %d, latency = %vms, want 20 invocations at 200ms",
+			metrics[0].InvocationCount, metrics[0].LatencyMs)
+	}
+}
+
+func TestBedrockCloudWatchRepository_SetMetricBatchSize(t *testing.T) {
+	repo := &BedrockCloudWatchRepository{}
+	for size, want := range map[int]int{100: 100, 500: 500, 0: 500, 501: 500, -1: 500} {
+		repo.SetMetricBatchSize(size)
+		if repo.batchSize != want {
+			t.Errorf("SetMetricBatchSize(%d) batch size = %d, want %d", size, repo.batchSize, want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ca-srg/tosage/domain"
+	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+// CircuitBreakerMetricsRepository wraps another MetricsRepository and stops pushing to it
+// after consecutive failed collection cycles. A cycle ends when lastCollectionMetric is sent,
+// and fails when it had failed pushes and no successful one. While the circuit is open pushes
+// fail fast with repository.ErrCircuitOpen; once the backoff has passed a single push tests
+// recovery (half-open), closing the circuit on success and reopening it on failure.
+type CircuitBreakerMetricsRepository struct {
+	delegate  repository.MetricsRepository
+	threshold int
+	backoff   time.Duration
+	logger    domain.Logger
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	open      bool
+	openUntil time.Time
+
+	// cycleErr is the last push error of the current cycle, cycleOK whether a push succeeded
+	cycleErr error
+	cycleOK  bool
+}
+
+// NewCircuitBreakerMetricsRepository creates a circuit breaker in front of delegate
+func NewCircuitBreakerMetricsRepository(delegate repository.MetricsRepository, cfg *config.PrometheusConfig, logger domain.Logger) (*CircuitBreakerMetricsRepository, error) {
+	if delegate == nil {
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("delegate metrics repository is nil"))
+	}
+	if cfg == nil {
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("prometheus config is nil"))
+	}
+	if cfg.CircuitBreakerThreshold < 1 {
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("circuit breaker threshold must be at least 1"))
+	}
+
+	return &CircuitBreakerMetricsRepository{
+		delegate:  delegate,
+		threshold: cfg.CircuitBreakerThreshold,
+		backoff:   time.Duration(cfg.CircuitBreakerBackoffSec) * time.Second,
+		logger:    logger,
+		now:       time.Now,
+	}, nil
+}
+
+// SendTokenMetric forwards the metric to the delegate unless the circuit is open
+func (r *CircuitBreakerMetricsRepository) SendTokenMetric(totalTokens int64, hostLabel string, metricName string) error {
+	return r.call(metricName, func() error {
+		return r.delegate.SendTokenMetric(totalTokens, hostLabel, metricName)
+	})
+}
+
+// SendTokenMetricWithTimezone forwards the metric to the delegate unless the circuit is open
+func (r *CircuitBreakerMetricsRepository) SendTokenMetricWithTimezone(totalTokens int64, hostLabel string, metricName string, timezoneInfo repository.TimezoneInfo) error {
+	return r.call(metricName, func() error {
+		return r.delegate.SendTokenMetricWithTimezone(totalTokens, hostLabel, metricName, timezoneInfo)
+	})
+}
+
+// SendTokenMetricWithLabels forwards the metric to the delegate unless the circuit is open
+func (r *CircuitBreakerMetricsRepository) SendTokenMetricWithLabels(totalTokens int64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	return r.call(metricName, func() error {
+		return r.delegate.SendTokenMetricWithLabels(totalTokens, hostLabel, metricName, labels, timezoneInfo)
+	})
+}
+
+// SendMetricValue forwards the value to the delegate unless the circuit is open
+func (r *CircuitBreakerMetricsRepository) SendMetricValue(value float64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	return r.call(metricName, func() error {
+		return sendMetricValue(r.delegate, value, hostLabel, metricName, labels, timezoneInfo)
+	})
+}
+
+// CheckConnection checks the delegate's backend regardless of the circuit state
+func (r *CircuitBreakerMetricsRepository) CheckConnection(ctx context.Context) error {
+	return checkMetricsConnection(ctx, r.delegate)
+}
+
+// CircuitOpen reports whether pushes are currently being skipped
+func (r *CircuitBreakerMetricsRepository) CircuitOpen() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.open
+}
+
+// Close closes the delegate
+func (r *CircuitBreakerMetricsRepository) Close() error {
+	return r.delegate.Close()
+}
+
+// call runs push if the circuit allows it and records the outcome. Sending
+// lastCollectionMetric ends the cycle, whether or not it was pushed.
+func (r *CircuitBreakerMetricsRepository) call(metricName string, push func() error) error {
+	if metricName == lastCollectionMetric {
+		defer r.endCycle()
+	}
+	if !r.allow() {
+		return repository.NewMetricsRepositoryError("send", repository.ErrCircuitOpen)
+	}
+	err := push()
+	r.record(err)
+	return err
+}
+
+// allow reports whether a push may be attempted. Once the backoff has passed, one push
+// is let through and the backoff restarts, so concurrent pushes don't all test recovery.
+func (r *CircuitBreakerMetricsRepository) allow() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.open {
+		return true
+	}
+	now := r.now()
+	if now.Before(r.openUntil) {
+		return false
+	}
+	r.openUntil = now.Add(r.backoff)
+	return true
+}
+
+// record updates the circuit with the outcome of a push. A success closes the circuit right
+// away; while it is open a failed test push restarts the backoff. Other failures only count
+// once the cycle ends.
+func (r *CircuitBreakerMetricsRepository) record(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		if r.open && r.logger != nil {
+			r.logger.Info(context.Background(), "Remote Write circuit closed, pushes resumed")
+		}
+		r.failures = 0
+		r.open = false
+		r.cycleOK = true
+		return
+	}
+
+	if r.open {
+		r.openUntil = r.now().Add(r.backoff)
+		return
+	}
+	r.cycleErr = err
+}
+
+// endCycle counts the cycle as failed if none of its pushes succeeded, opening the circuit
+// after threshold failed cycles in a row. Only state changes are logged.
+func (r *CircuitBreakerMetricsRepository) endCycle() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.cycleErr
+	failed := err != nil && !r.cycleOK
+	r.cycleErr = nil
+	r.cycleOK = false
+	if !failed || r.open {
+		return
+	}
+
+	r.failures++
+	if r.failures >= r.threshold {
+		r.open = true
+		r.openUntil = r.now().Add(r.backoff)
+		if r.logger != nil {
+			r.logger.Warn(context.Background(), "Remote Write circuit open, skipping pushes",
+				domain.NewField("consecutive_failed_cycles", r.failures),
+				domain.NewField("backoff", r.backoff.String()),
+				domain.NewField("error", err.Error()))
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/infrastructure/config"
+	"github.com/ca-srg/tosage/infrastructure/logging"
+)
+
+// flakyMetricsRepository fails every push while err is set and counts the pushes it receives
+type flakyMetricsRepository struct {
+	NoOpMetricsRepository
+	err    error
+	pushes int
+}
+
+func (r *flakyMetricsRepository) SendTokenMetric(totalTokens int64, hostLabel string, metricName string) error {
+	r.pushes++
+	return r.err
+}
+
+func TestCircuitBreakerMetricsRepository(t *testing.T) {
+	delegate := &flakyMetricsRepository{err: errors.New("503 service unavailable")}
+	repo, err := NewCircuitBreakerMetricsRepository(delegate, &config.PrometheusConfig{
+		CircuitBreakerThreshold:  3,
+		CircuitBreakerBackoffSec: 60,
+	}, &logging.NoOpLogger{})
+	if err != nil {
+		t.Fatalf("NewCircuitBreakerMetricsRepository() error = %v", err)
+	}
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+
+	// cycle pushes a few series and ends the collection like the metrics service does
+	cycle := func() error {
+		var firstErr error
+		for i := 0; i < 4; i++ {
+			if err := repo.SendTokenMetric(1, "", "tosage_cc_token"); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		_ = repo.SendTokenMetric(1, "", lastCollectionMetric)
+		return firstErr
+	}
+
+	// Failed pushes within one cycle count once, so the circuit opens after three failed cycles
+	for i := 0; i < 2; i++ {
+		if err := cycle(); err == nil {
+			t.Fatal("cycle should fail while the backend fails")
+		}
+		if repo.CircuitOpen() {
+			t.Fatalf("circuit opened after %d failed cycles, want 3", i+1)
+		}
+	}
+	if err := cycle(); err == nil {
+		t.Fatal("cycle should fail while the backend fails")
+	}
+	if !repo.CircuitOpen() {
+		t.Fatal("circuit should be open after 3 failed cycles")
+	}
+	if delegate.pushes != 15 {
+		t.Errorf("backend received %d pushes, want 15", delegate.pushes)
+	}
+
+	// While open, pushes are skipped without reaching the backend
+	err = repo.SendTokenMetric(1, "", "tosage_cc_token")
+	if !errors.Is(err, repository.ErrCircuitOpen) {
+		t.Errorf("SendTokenMetric() error = %v, want ErrCircuitOpen", err)
+	}
+	if delegate.pushes != 15 {
+		t.Errorf("backend received %d pushes, want 15", delegate.pushes)
+	}
+
+	// After the backoff a failing test push reopens the circuit
+	now = now.Add(61 * time.Second)
+	if err := repo.SendTokenMetric(1, "", "tosage_cc_token"); errors.Is(err, repository.ErrCircuitOpen) {
+		t.Error("test push after the backoff should reach the backend")
+	}
+	if err := repo.SendTokenMetric(1, "", "tosage_cc_token"); !errors.Is(err, repository.ErrCircuitOpen) {
+		t.Errorf("push after a failed test push error = %v, want ErrCircuitOpen", err)
+	}
+
+	// A successful test push closes the circuit
+	delegate.err = nil
+	now = now.Add(61 * time.Second)
+	if err := repo.SendTokenMetric(1, "", "tosage_cc_token"); err != nil {
+		t.Errorf("SendTokenMetric() error = %v after recovery", err)
+	}
+	if repo.CircuitOpen() {
+		t.Error("circuit should be closed after a successful push")
+	}
+	if delegate.pushes != 17 {
+		t.Errorf("backend received %d pushes, want 17", delegate.pushes)
+	}
+
+	// A cycle with a successful push is not a failed cycle
+	_ = cycle()
+	_ = cycle()
+	delegate.err = errors.New("503 service unavailable")
+	_ = cycle()
+	_ = cycle()
+	if repo.CircuitOpen() {
+		t.Error("circuit should stay closed after 2 failed cycles")
+	}
+}
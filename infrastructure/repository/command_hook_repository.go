@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hookEnvVars maps the headline metrics to the environment variables the hook receives them in
+var hookEnvVars = map[string]string{
+	"tosage_cc_token":              "TOSAGE_CC_TOKENS",
+	"tosage_cursor_token":          "TOSAGE_CURSOR_TOKENS",
+	"tosage_bedrock_total_token":   "TOSAGE_BEDROCK_TOKENS",
+	"tosage_vertex_ai_total_token": "TOSAGE_VERTEX_AI_TOKENS",
+}
+
+// hookWaitDelay is how long the hook's output is still read after it was killed, so a
+// background process holding the output open doesn't block the collection
+const hookWaitDelay = 2 * time.Second
+
+// hookPayload is the JSON written to the hook's stdin
+type hookPayload struct {
+	CollectedAt time.Time          `json:"collected_at"`
+	Metrics     map[string]float64 `json:"metrics"`
+}
+
+// CommandHookRepository runs a shell command after each collection
+type CommandHookRepository struct {
+	command string
+	timeout time.Duration
+}
+
+// NewCommandHookRepository creates a hook that runs command with the shell, killing it after timeout
+func NewCommandHookRepository(command string, timeout time.Duration) (*CommandHookRepository, error) {
+	if strings.TrimSpace(command) == "" {
+		return nil, fmt.Errorf("post-collection hook command is required")
+	}
+	return &CommandHookRepository{
+		command: command,
+		timeout: timeout,
+	}, nil
+}
+
+// Run runs the command with the totals in TOSAGE_* environment variables and the full set of
+// sent metrics as JSON on stdin. It returns the combined stdout and stderr of the command.
+func (r *CommandHookRepository) Run(ctx context.Context, collectedAt time.Time, metrics map[string]float64) (string, error) {
+	payload, err := json.Marshal(hookPayload{CollectedAt: collectedAt, Metrics: metrics})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal hook payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cmd := shellCommand(ctx, r.command)
+	cmd.Env = append(os.Environ(), hookEnv(collectedAt, metrics)...)
+	cmd.Stdin = bytes.NewReader(payload)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.WaitDelay = hookWaitDelay
+
+	err = cmd.Run()
+	out := strings.TrimSpace(output.String())
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return out, fmt.Errorf("post-collection hook timed out after %s", r.timeout)
+	}
+	if err != nil {
+		return out, fmt.Errorf("post-collection hook failed: %w", err)
+	}
+	return out, nil
+}
+
+// shellCommand runs command with the platform's shell
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "/bin/sh", "-c", command)
+}
+
+// hookEnv returns the environment variables carrying the collection's totals
+func hookEnv(collectedAt time.Time, metrics map[string]float64) []string {
+	env := []string{"TOSAGE_COLLECTED_AT=" + collectedAt.Format(time.RFC3339)}
+	for metricName, name := range hookEnvVars {
+		if value, ok := metrics[metricName]; ok {
+			env = append(env, name+"="+strconv.FormatFloat(value, 'f', -1, 64))
+		}
+	}
+	return env
+}
@@ -0,0 +1,65 @@
+//go:build unix
+
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandHookRepository_Run(t *testing.T) {
+	hook, err := NewCommandHookRepository(`echo "cc=$TOSAGE_CC_TOKENS cursor=${TOSAGE_CURSOR_TOKENS:-unset}"; cat`, 5*time.Second)
+	if err != nil {
+		t.Fatalf("NewCommandHookRepository() error = %v", err)
+	}
+
+	collectedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	output, err := hook.Run(context.Background(), collectedAt, map[string]float64{
+		"tosage_cc_token":                      12345,
+		`tosage_cc_session_token{session="a"}`: 100,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v (output %q)", err, output)
+	}
+
+	lines := strings.SplitN(output, "\n", 2)
+	if lines[0] != "cc=12345 cursor=unset" {
+		t.Errorf("environment line = %q", lines[0])
+	}
+	if len(lines) != 2 || !strings.Contains(lines[1], `"collected_at":"2025-01-02T03:04:05Z"`) ||
+		!strings.Contains(lines[1], `"tosage_cc_token":12345`) {
+		t.Errorf("stdin payload = %q", output)
+	}
+}
+
+func TestCommandHookRepository_RunFailure(t *testing.T) {
+	hook, _ := NewCommandHookRepository("echo broken >&2; exit 3", 5*time.Second)
+	output, err := hook.Run(context.Background(), time.Now(), nil)
+	if err == nil {
+		t.Fatal("Run() error = nil, want the exit status")
+	}
+	if output != "broken" {
+		t.Errorf("output = %q, want stderr", output)
+	}
+}
+
+func TestCommandHookRepository_RunTimeout(t *testing.T) {
+	hook, _ := NewCommandHookRepository("sleep 10", 100*time.Millisecond)
+
+	start := time.Now()
+	_, err := hook.Run(context.Background(), time.Now(), nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("Run() error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Run() took %s after the timeout", elapsed)
+	}
+}
+
+func TestNewCommandHookRepository_EmptyCommand(t *testing.T) {
+	if _, err := NewCommandHookRepository("  ", time.Second); err == nil {
+		t.Error("NewCommandHookRepository() with an empty command returned no error")
+	}
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ca-srg/tosage/domain"
+	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+// MetricsBackend is a named metrics repository that a CompositeMetricsRepository sends to
+type MetricsBackend struct {
+	Name       string
+	Repository repository.MetricsRepository
+}
+
+// CompositeMetricsRepository sends every metric to several backends, e.g. to dual-write
+// while migrating. A send fails only when every backend fails; failures of individual
+// backends are logged.
+type CompositeMetricsRepository struct {
+	backends []MetricsBackend
+	logger   domain.Logger
+}
+
+// NewCompositeMetricsRepository creates a repository that fans out to backends
+func NewCompositeMetricsRepository(backends []MetricsBackend, logger domain.Logger) (*CompositeMetricsRepository, error) {
+	if len(backends) == 0 {
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("no metrics backends configured"))
+	}
+	if logger == nil {
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("logger is nil"))
+	}
+	for _, backend := range backends {
+		if backend.Repository == nil {
+			return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("metrics backend %s is nil", backend.Name))
+		}
+	}
+
+	return &CompositeMetricsRepository{
+		backends: backends,
+		logger:   logger,
+	}, nil
+}
+
+// NewMetricsBackendRepository creates the repository for an additional backend. Settings
+// the backend doesn't set itself, such as the host label and timeout, come from primary.
+func NewMetricsBackendRepository(backend *config.MetricsBackendConfig, primary *config.PrometheusConfig) (repository.MetricsRepository, error) {
+	if backend == nil || primary == nil {
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("metrics backend config is nil"))
+	}
+
+	switch backend.BackendType() {
+	case config.BackendOTLP:
+		return NewOTLPMetricsRepository(backend, primary.HostLabel, time.Duration(primary.TimeoutSec)*time.Second)
+	case config.BackendRemoteWrite:
+		cfg := &config.PrometheusConfig{
+			RemoteWriteURL:      backend.URL,
+			RemoteWriteUsername: backend.Username,
+			RemoteWritePassword: backend.Password,
+			HostLabel:           primary.HostLabel,
+			TimeoutSec:          primary.TimeoutSec,
+			Compression:         primary.Compression,
+		}
+		if backend.TimeoutSec > 0 {
+			cfg.TimeoutSec = backend.TimeoutSec
+		}
+		return NewPrometheusMetricsRepository(cfg)
+	default:
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("unknown metrics backend type %q", backend.Type))
+	}
+}
+
+// SendTokenMetric sends the metric to every backend
+func (r *CompositeMetricsRepository) SendTokenMetric(totalTokens int64, hostLabel string, metricName string) error {
+	return r.fanOut(metricName, func(repo repository.MetricsRepository) error {
+		return repo.SendTokenMetric(totalTokens, hostLabel, metricName)
+	})
+}
+
+// SendTokenMetricWithTimezone sends the metric to every backend
+func (r *CompositeMetricsRepository) SendTokenMetricWithTimezone(totalTokens int64, hostLabel string, metricName string, timezoneInfo repository.TimezoneInfo) error {
+	return r.fanOut(metricName, func(repo repository.MetricsRepository) error {
+		return repo.SendTokenMetricWithTimezone(totalTokens, hostLabel, metricName, timezoneInfo)
+	})
+}
+
+// SendTokenMetricWithLabels sends the metric to every backend
+func (r *CompositeMetricsRepository) SendTokenMetricWithLabels(totalTokens int64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	return r.fanOut(metricName, func(repo repository.MetricsRepository) error {
+		return repo.SendTokenMetricWithLabels(totalTokens, hostLabel, metricName, labels, timezoneInfo)
+	})
+}
+
+// SendMetricValue sends the value to every backend
+func (r *CompositeMetricsRepository) SendMetricValue(value float64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	return r.fanOut(metricName, func(repo repository.MetricsRepository) error {
+		return sendMetricValue(repo, value, hostLabel, metricName, labels, timezoneInfo)
+	})
+}
+
+// CheckConnection checks every backend and reports the ones that are unreachable
+func (r *CompositeMetricsRepository) CheckConnection(ctx context.Context) error {
+	var errs []error
+	for _, backend := range r.backends {
+		if err := checkMetricsConnection(ctx, backend.Repository); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", backend.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every backend
+func (r *CompositeMetricsRepository) Close() error {
+	var errs []error
+	for _, backend := range r.backends {
+		if err := backend.Repository.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", backend.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fanOut calls send for each backend. Failures are logged when another backend
+// succeeded and returned when all of them failed.
+func (r *CompositeMetricsRepository) fanOut(metricName string, send func(repository.MetricsRepository) error) error {
+	var errs []error
+	for _, backend := range r.backends {
+		if err := send(backend.Repository); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", backend.Name, err))
+		}
+	}
+
+	if len(errs) == len(r.backends) {
+		return errors.Join(errs...)
+	}
+	for _, err := range errs {
+		fields := []domain.Field{
+			domain.NewField("metric", metricName),
+			domain.NewField("error", err.Error()),
+		}
+		// An open circuit is expected during an outage and is reported by its own gauge
+		if errors.Is(err, repository.ErrCircuitOpen) {
+			r.logger.Debug(context.Background(), "Skipped sending metric to a backend", fields...)
+		} else {
+			r.logger.Warn(context.Background(), "Failed to send metric to a backend", fields...)
+		}
+	}
+	return nil
+}
+
+var _ repository.MetricsRepository = (*CompositeMetricsRepository)(nil)
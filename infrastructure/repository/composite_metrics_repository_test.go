@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ca-srg/tosage/infrastructure/config"
+	"github.com/ca-srg/tosage/infrastructure/logging"
+)
+
+func TestCompositeMetricsRepository(t *testing.T) {
+	primary := &flakyMetricsRepository{}
+	secondary := &flakyMetricsRepository{}
+	repo, err := NewCompositeMetricsRepository([]MetricsBackend{
+		{Name: "primary", Repository: primary},
+		{Name: "secondary", Repository: secondary},
+	}, &logging.NoOpLogger{})
+	if err != nil {
+		t.Fatalf("NewCompositeMetricsRepository() error = %v", err)
+	}
+
+	// Every backend receives the metric
+	if err := repo.SendTokenMetric(1, "", "tosage_cc_token"); err != nil {
+		t.Fatalf("SendTokenMetric() error = %v", err)
+	}
+	if primary.pushes != 1 || secondary.pushes != 1 {
+		t.Errorf("pushes = %d/%d, want 1/1", primary.pushes, secondary.pushes)
+	}
+
+	// A partial failure is logged, not returned
+	primary.err = errors.New("503 service unavailable")
+	if err := repo.SendTokenMetric(1, "", "tosage_cc_token"); err != nil {
+		t.Errorf("SendTokenMetric() error = %v, want nil when one backend succeeds", err)
+	}
+
+	// The send fails when every backend fails, naming each one
+	secondary.err = errors.New("connection refused")
+	err = repo.SendTokenMetric(1, "", "tosage_cc_token")
+	if err == nil {
+		t.Fatal("SendTokenMetric() should fail when every backend fails")
+	}
+	if !strings.Contains(err.Error(), "primary: 503") || !strings.Contains(err.Error(), "secondary: connection refused") {
+		t.Errorf("SendTokenMetric() error = %v, want both backend errors", err)
+	}
+}
+
+func TestOTLPMetricsRepository_SendMetricValue(t *testing.T) {
+	var body otlpMetricsRequest
+	var gotUser, gotContentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, _, _ = r.BasicAuth()
+		gotContentType = r.Header.Get("Content-Type")
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("request is not valid JSON: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	primary := &config.PrometheusConfig{HostLabel: "laptop", TimeoutSec: 5}
+	repo, err := NewMetricsBackendRepository(&config.MetricsBackendConfig{
+		Type:     config.BackendOTLP,
+		URL:      server.URL + "/v1/metrics",
+		Username: "user",
+		Password: "pass",
+	}, primary)
+	if err != nil {
+		t.Fatalf("NewMetricsBackendRepository() error = %v", err)
+	}
+
+	if err := repo.SendTokenMetricWithLabels(1234, "", "tosage_cc_token", map[string]string{"model": "sonnet"}, nil); err != nil {
+		t.Fatalf("SendTokenMetricWithLabels() error = %v", err)
+	}
+
+	if gotUser != "user" || gotContentType != "application/json" {
+		t.Errorf("user = %q, content type = %q", gotUser, gotContentType)
+	}
+	if len(body.ResourceMetrics) != 1 || len(body.ResourceMetrics[0].ScopeMetrics) != 1 {
+		t.Fatalf("unexpected payload %+v", body)
+	}
+	metrics := body.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(metrics) != 1 || metrics[0].Name != "tosage_cc_token" {
+		t.Fatalf("metrics = %+v, want tosage_cc_token", metrics)
+	}
+	point := metrics[0].Gauge.DataPoints[0]
+	if point.AsDouble != 1234 || point.TimeUnixNano == "" {
+		t.Errorf("data point = %+v", point)
+	}
+	want := []otlpAttribute{
+		{Key: "host", Value: otlpAnyValue{StringValue: "laptop"}},
+		{Key: "model", Value: otlpAnyValue{StringValue: "sonnet"}},
+	}
+	if len(point.Attributes) != len(want) || point.Attributes[0] != want[0] || point.Attributes[1] != want[1] {
+		t.Errorf("attributes = %+v, want %+v", point.Attributes, want)
+	}
+}
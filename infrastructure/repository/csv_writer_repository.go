@@ -1,9 +1,11 @@
 package repository
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -26,7 +28,7 @@ func NewCSVWriterRepository(logger domain.Logger) repository.CSVWriterRepository
 	}
 }
 
-// Write writes metric records to a CSV file
+// Write writes metric records to a CSV file, gzip compressed when outputPath ends in .csv.gz
 func (r *CSVWriterRepositoryImpl) Write(records []*entity.MetricRecord, outputPath string) error {
 	// Validate output path
 	if err := r.validateOutputPath(outputPath); err != nil {
@@ -53,12 +55,23 @@ func (r *CSVWriterRepositoryImpl) Write(records []*entity.MetricRecord, outputPa
 		}
 	}()
 
+	// Compressed exports contain the same CSV, BOM included
+	var out io.Writer = file
+	var gz *gzip.Writer
+	if isGzipPath(outputPath) {
+		gz = gzip.NewWriter(file)
+		defer func() {
+			_ = gz.Close()
+		}()
+		out = gz
+	}
+
 	// Create CSV writer with UTF-8 BOM
-	if _, err := file.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+	if _, err := out.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
 		return domain.ErrCSVExportWithCause("write BOM", "failed to write UTF-8 BOM", err)
 	}
 
-	writer := csv.NewWriter(file)
+	writer := csv.NewWriter(out)
 	defer writer.Flush()
 
 	// Write header - source and project are excluded
@@ -95,10 +108,18 @@ func (r *CSVWriterRepositoryImpl) Write(records []*entity.MetricRecord, outputPa
 	}
 
 	// Check for write errors
+	writer.Flush()
 	if err := writer.Error(); err != nil {
 		return domain.ErrCSVExportWithCause("flush", "failed to flush CSV writer", err)
 	}
 
+	// Closing the gzip writer writes the trailer; a failure leaves a truncated archive
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return domain.ErrCSVExportWithCause("compress", "failed to finish gzip stream", err)
+		}
+	}
+
 	r.logger.Info(context.TODO(), "CSV export completed",
 		domain.NewField("outputPath", outputPath),
 		domain.NewField("records", len(records)))
@@ -137,14 +158,19 @@ func (r *CSVWriterRepositoryImpl) validateOutputPath(path string) error {
 		return domain.ErrFileOperation("validatePath", path, "cannot write to hidden files")
 	}
 
-	// Ensure the file has .csv extension
-	if filepath.Ext(cleanPath) != ".csv" {
-		return domain.ErrInvalidInput("outputPath", "file must have .csv extension")
+	// Ensure the file has .csv extension, optionally followed by .gz
+	if filepath.Ext(strings.TrimSuffix(cleanPath, ".gz")) != ".csv" {
+		return domain.ErrInvalidInput("outputPath", "file must have .csv extension (or .csv.gz)")
 	}
 
 	return nil
 }
 
+// isGzipPath reports whether the export should be gzip compressed
+func isGzipPath(path string) bool {
+	return strings.HasSuffix(path, ".csv.gz")
+}
+
 // sanitizeCSVField sanitizes a field to prevent CSV injection
 func (r *CSVWriterRepositoryImpl) sanitizeCSVField(field string) string {
 	// Remove any leading characters that could cause formula injection
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ca-srg/tosage/domain/entity"
+	"github.com/ca-srg/tosage/infrastructure/logging"
+)
+
+func TestCSVWriterRepository_WriteGzip(t *testing.T) {
+	records := []*entity.MetricRecord{
+		entity.NewMetricRecord(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), "claude_code", "", 1234, "tokens"),
+	}
+	writer := NewCSVWriterRepository(&logging.NoOpLogger{})
+
+	dir := t.TempDir()
+	plainPath := filepath.Join(dir, "metrics.csv")
+	gzipPath := filepath.Join(dir, "metrics.csv.gz")
+	if err := writer.Write(records, plainPath); err != nil {
+		t.Fatalf("Write(%s) error = %v", plainPath, err)
+	}
+	if err := writer.Write(records, gzipPath); err != nil {
+		t.Fatalf("Write(%s) error = %v", gzipPath, err)
+	}
+
+	plain, err := os.ReadFile(plainPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	file, err := os.Open(gzipPath)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("reading gzip stream error = %v", err)
+	}
+
+	// The compressed export holds exactly the plain CSV, BOM included
+	if !bytes.Equal(decompressed, plain) {
+		t.Errorf("decompressed content = %q, want %q", decompressed, plain)
+	}
+	if !strings.Contains(string(plain), "2024-01-15T10:00:00Z,1234.00,tokens") {
+		t.Errorf("plain CSV missing record: %q", plain)
+	}
+}
+
+func TestCSVWriterRepository_RejectsOtherExtensions(t *testing.T) {
+	writer := NewCSVWriterRepository(&logging.NoOpLogger{})
+	for _, name := range []string{"metrics.gz", "metrics.txt.gz", "metrics.txt"} {
+		if err := writer.Write(nil, filepath.Join(t.TempDir(), name)); err == nil {
+			t.Errorf("Write(%s) error = nil, want extension error", name)
+		}
+	}
+}
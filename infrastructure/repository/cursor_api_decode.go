@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ca-srg/tosage/domain"
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+// Cursor changes the shape of response fields from time to time, e.g. maxRequestUsage from a
+// number to an object. Such volatile fields are decoded as json.RawMessage and parsed with
+// fallbacks, so one changed field neither fails the whole response nor silently zeroes a metric.
+
+// cursorNumberKeys are the keys an object-shaped numeric field is looked up under
+var cursorNumberKeys = []string{"value", "count", "total", "limit", "max", "amount"}
+
+// maxLoggedResponseBytes caps how much of a response body is written to the debug log
+const maxLoggedResponseBytes = 4096
+
+// parseCursorNumber parses a volatile numeric field: a number, a numeric string, or an object
+// holding the number under one of cursorNumberKeys. present is false for a missing or null field;
+// ok is false for a shape no fallback understands.
+func parseCursorNumber(raw json.RawMessage) (value float64, present, ok bool) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return 0, false, true
+	}
+
+	var number float64
+	if err := json.Unmarshal(trimmed, &number); err == nil {
+		return number, true, true
+	}
+
+	var text string
+	if err := json.Unmarshal(trimmed, &text); err == nil {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return 0, false, true
+		}
+		if number, err := strconv.ParseFloat(text, 64); err == nil {
+			return number, true, true
+		}
+		return 0, true, false
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &object); err == nil {
+		for _, key := range cursorNumberKeys {
+			if field, found := object[key]; found {
+				return parseCursorNumber(field)
+			}
+		}
+	}
+	return 0, true, false
+}
+
+// cursorFieldParser parses the volatile fields of one response and collects the names of
+// the fields whose shape no fallback understood
+type cursorFieldParser struct {
+	invalid []string
+}
+
+// number parses a volatile numeric field, recording it as invalid if its shape is not understood.
+// A missing, null or invalid field is 0 and present is false.
+func (p *cursorFieldParser) number(name string, raw json.RawMessage) (value float64, present bool) {
+	value, present, ok := parseCursorNumber(raw)
+	if !ok {
+		p.invalid = append(p.invalid, name)
+		return 0, false
+	}
+	return value, present
+}
+
+// int parses a volatile integer field, rounding a fractional value
+func (p *cursorFieldParser) int(name string, raw json.RawMessage) int {
+	value, _ := p.number(name, raw)
+	return int(math.Round(value))
+}
+
+// optionalInt parses a volatile integer field that may be absent, returning nil when it is
+func (p *cursorFieldParser) optionalInt(name string, raw json.RawMessage) *int {
+	value, present := p.number(name, raw)
+	if !present {
+		return nil
+	}
+	rounded := int(math.Round(value))
+	return &rounded
+}
+
+// decodeResponse reads a Cursor API response and decodes it into v. A response that does not
+// decode marks the response format as broken. The body is returned for checkFields.
+func (r *CursorAPIRepository) decodeResponse(body io.Reader, v interface{}, what string) ([]byte, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, domain.ErrCursorAPIWithCause("read "+what, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		r.recordFormatBreak(what, data, domain.NewField("error", err.Error()))
+		return nil, domain.ErrCursorAPIWithCause("decode "+what, err)
+	}
+	return data, nil
+}
+
+// checkFields marks the response format as broken if parser found fields it did not understand
+func (r *CursorAPIRepository) checkFields(what string, body []byte, parser *cursorFieldParser) {
+	if len(parser.invalid) == 0 {
+		return
+	}
+	r.recordFormatBreak(what, body, domain.NewField("fields", strings.Join(parser.invalid, ",")))
+}
+
+// recordFormatBreak marks the response format as broken until the next ResponseFormatOK and
+// logs the response at debug level, with secrets masked, to help diagnose the change
+func (r *CursorAPIRepository) recordFormatBreak(what string, body []byte, cause domain.Field) {
+	r.formatBroken.Store(true)
+	if r.logger == nil {
+		return
+	}
+	if len(body) > maxLoggedResponseBytes {
+		body = body[:maxLoggedResponseBytes]
+	}
+	r.logger.Debug(context.Background(), "Cursor API response format not understood",
+		domain.NewField("response", what),
+		cause,
+		domain.NewField("body", config.MaskDebugText(string(body))))
+}
+
+// ResponseFormatOK reports whether every Cursor API response since the previous call decoded
+// with all of its fields understood, and starts the next check
+func (r *CursorAPIRepository) ResponseFormatOK() bool {
+	return !r.formatBroken.Swap(false)
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ca-srg/tosage/domain/valueobject"
+)
+
+func TestParseCursorNumber(t *testing.T) {
+	tests := []struct {
+		raw         string
+		want        float64
+		wantPresent bool
+		wantOK      bool
+	}{
+		{raw: `500`, want: 500, wantPresent: true, wantOK: true},
+		{raw: `12.5`, want: 12.5, wantPresent: true, wantOK: true},
+		{raw: `"42"`, want: 42, wantPresent: true, wantOK: true},
+		{raw: `{"value": 500, "unit": "requests"}`, want: 500, wantPresent: true, wantOK: true},
+		{raw: `{"limit": "300"}`, want: 300, wantPresent: true, wantOK: true},
+		{raw: `null`, wantOK: true},
+		{raw: ``, wantOK: true},
+		{raw: `""`, wantOK: true},
+		{raw: `"unlimited"`, wantPresent: true},
+		{raw: `[500]`, wantPresent: true},
+		{raw: `{"unit": "requests"}`, wantPresent: true},
+		{raw: `true`, wantPresent: true},
+	}
+
+	for _, tt := range tests {
+		got, present, ok := parseCursorNumber(json.RawMessage(tt.raw))
+		if got != tt.want || present != tt.wantPresent || ok != tt.wantOK {
+			t.Errorf("parseCursorNumber(%s) = %v, %v, %v, want %v, %v, %v",
+				tt.raw, got, present, ok, tt.want, tt.wantPresent, tt.wantOK)
+		}
+	}
+}
+
+func TestGetIndividualUsage_ToleratesFormatChanges(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"auth0|user","exp":9999999999}`))
+	token, err := valueobject.NewCursorToken("header." + payload + ".signature")
+	if err != nil {
+		t.Fatalf("NewCursorToken() error = %v", err)
+	}
+
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+	repo := NewCursorAPIRepository(5*time.Second, WithBaseURL(server.URL)).(*CursorAPIRepository)
+
+	// Numbers moved into strings and objects are still read
+	body = `{"gpt-4":{"numRequests":"42","maxRequestUsage":{"value":500},"numTokens":null},"startOfMonth":"2025-03-01"}`
+	usage, err := repo.getIndividualUsage(token, token.UserID())
+	if err != nil {
+		t.Fatalf("getIndividualUsage() error = %v", err)
+	}
+	if usage.GPT4.NumRequests != 42 || usage.GPT4.MaxRequestUsage != 500 {
+		t.Errorf("usage = %d of %d requests, want 42 of 500", usage.GPT4.NumRequests, usage.GPT4.MaxRequestUsage)
+	}
+	if !repo.ResponseFormatOK() {
+		t.Error("ResponseFormatOK() = false after a response it understood")
+	}
+
+	// An unknown shape keeps the other fields and is reported once
+	body = `{"gpt-4":{"numRequests":7,"maxRequestUsage":[500]}}`
+	usage, err = repo.getIndividualUsage(token, token.UserID())
+	if err != nil {
+		t.Fatalf("getIndividualUsage() error = %v", err)
+	}
+	if usage.GPT4.NumRequests != 7 {
+		t.Errorf("numRequests = %d, want 7", usage.GPT4.NumRequests)
+	}
+	if repo.ResponseFormatOK() {
+		t.Error("ResponseFormatOK() = true after an unknown maxRequestUsage shape")
+	}
+	if !repo.ResponseFormatOK() {
+		t.Error("ResponseFormatOK() = false after the check was reset")
+	}
+
+	// A response that does not decode at all is reported as well
+	body = `{"gpt-4": "disabled"}`
+	if _, err := repo.getIndividualUsage(token, token.UserID()); err == nil {
+		t.Error("getIndividualUsage() error = nil for an undecodable response")
+	}
+	if repo.ResponseFormatOK() {
+		t.Error("ResponseFormatOK() = true after an undecodable response")
+	}
+}
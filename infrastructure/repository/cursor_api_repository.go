@@ -2,50 +2,196 @@ package repository
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ca-srg/tosage/domain"
 	"github.com/ca-srg/tosage/domain/entity"
 	"github.com/ca-srg/tosage/domain/repository"
 	"github.com/ca-srg/tosage/domain/valueobject"
+	"github.com/ca-srg/tosage/infrastructure/config"
+	"github.com/ca-srg/tosage/infrastructure/httpclient"
+	"golang.org/x/time/rate"
 )
 
 // CursorAPIRepository implements the repository.CursorAPIRepository interface
 type CursorAPIRepository struct {
-	httpClient *http.Client
-	baseURL    string
+	httpClient   *http.Client
+	baseURL      string
+	billingDay   int
+	dayStartHour int
+	dailyWindow  valueobject.DailyWindowMode
+	logger       domain.Logger
+	limiter      *rate.Limiter
+
+	csrfMu sync.Mutex
+	csrf   cursorCSRFState
+
+	// formatBroken is set when a response was not fully understood, see ResponseFormatOK
+	formatBroken atomic.Bool
+
+	// billing caches the usage events the billing period and billing cycle totals are summed from
+	billingMu sync.Mutex
+	billing   billingUsage
+}
+
+// billingUsageMaxAge is how long fetched billing usage events are reused. It covers the
+// billing period and billing cycle totals of one collection, so the month's events are
+// paged through once per collection rather than once per total.
+const billingUsageMaxAge = time.Minute
+
+// billingUsage holds the user's usage events since from, fetched at fetched
+type billingUsage struct {
+	from    time.Time
+	fetched time.Time
+	events  []usageEventTokens
+	// cycleStart is the billing cycle start Cursor last reported, so the next fetch covers it too
+	cycleStart time.Time
+}
+
+// usageEventTokens is the time and token count of a usage event
+type usageEventTokens struct {
+	at     time.Time
+	tokens int64
+}
+
+// CursorAPIOption configures a CursorAPIRepository
+type CursorAPIOption func(*CursorAPIRepository)
+
+// WithBillingDay sets the day of the month (1-28) the billing period starts.
+// Out of range values keep the default.
+func WithBillingDay(day int) CursorAPIOption {
+	return func(r *CursorAPIRepository) {
+		if day >= 1 && day <= 28 {
+			r.billingDay = day
+		}
+	}
+}
+
+// WithDayStartHour sets the local hour (0-23) the daily token window starts.
+// Out of range values keep midnight.
+func WithDayStartHour(hour int) CursorAPIOption {
+	return func(r *CursorAPIRepository) {
+		if hour >= 0 && hour <= 23 {
+			r.dayStartHour = hour
+		}
+	}
+}
+
+// WithDailyWindowMode sets whether the daily token window is the calendar day, starting
+// at the day start hour, or the trailing 24 hours
+func WithDailyWindowMode(mode valueobject.DailyWindowMode) CursorAPIOption {
+	return func(r *CursorAPIRepository) {
+		r.dailyWindow = mode
+	}
+}
+
+// WithBaseURL sets the Cursor API endpoint, e.g. an httptest.Server or an enterprise
+// endpoint. Empty keeps the default.
+func WithBaseURL(baseURL string) CursorAPIOption {
+	return func(r *CursorAPIRepository) {
+		if baseURL != "" {
+			r.baseURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// WithCursorLogger sets the logger that reports CSRF handling
+func WithCursorLogger(logger domain.Logger) CursorAPIOption {
+	return func(r *CursorAPIRepository) {
+		r.logger = logger
+	}
+}
+
+// WithRateLimit limits requests to the Cursor API to requestsPerSec, allowing burst
+// requests at once (at least 1). A rate of 0 or less leaves requests unlimited.
+func WithRateLimit(requestsPerSec float64, burst int) CursorAPIOption {
+	return func(r *CursorAPIRepository) {
+		if requestsPerSec <= 0 {
+			r.limiter = nil
+			return
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		r.limiter = rate.NewLimiter(rate.Limit(requestsPerSec), burst)
+	}
 }
 
 // NewCursorAPIRepository creates a new CursorAPIRepository instance
-func NewCursorAPIRepository(timeout time.Duration) repository.CursorAPIRepository {
-	return &CursorAPIRepository{
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
-		baseURL: "https://cursor.com",
+func NewCursorAPIRepository(timeout time.Duration, opts ...CursorAPIOption) repository.CursorAPIRepository {
+	r := &CursorAPIRepository{
+		httpClient: httpclient.NewClient(timeout),
+		baseURL:    config.DefaultCursorBaseURL,
+		billingDay: config.DefaultCursorBillingDay,
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	return r
+}
+
+// billingPeriodStart returns local midnight of the billing day that started the current billing period
+func billingPeriodStart(now time.Time, billingDay int) time.Time {
+	start := time.Date(now.Year(), now.Month(), billingDay, 0, 0, 0, 0, now.Location())
+	if now.Before(start) {
+		start = start.AddDate(0, -1, 0)
+	}
+	return start
+}
+
+// dayWindowStart returns the start of the current daily window, which begins at startHour local time
+func dayWindowStart(now time.Time, startHour int) time.Time {
+	start := time.Date(now.Year(), now.Month(), now.Day(), startHour, 0, 0, 0, now.Location())
+	if now.Before(start) {
+		start = start.AddDate(0, 0, -1)
+	}
+	return start
+}
+
+// windowStart returns the start of the daily token window that ends at now
+func (r *CursorAPIRepository) windowStart(now time.Time) time.Time {
+	if r.dailyWindow.IsRolling() {
+		return valueobject.RollingWindowStart(now)
+	}
+	return dayWindowStart(now, r.dayStartHour)
 }
 
 // API response structures
 
 type usageResponse struct {
-	GPT4 struct {
-		NumRequests     int `json:"numRequests"`
-		MaxRequestUsage int `json:"maxRequestUsage"`
-		NumTokens       int `json:"numTokens"`
-	} `json:"gpt-4"`
-	GPT432K struct {
-		NumRequests     int  `json:"numRequests"`
-		MaxRequestUsage *int `json:"maxRequestUsage"`
-		NumTokens       int  `json:"numTokens"`
-	} `json:"gpt-4-32k"`
-	StartOfMonth string `json:"startOfMonth"`
+	GPT4         usageModelResponse `json:"gpt-4"`
+	GPT432K      usageModelResponse `json:"gpt-4-32k"`
+	StartOfMonth string             `json:"startOfMonth"`
+}
+
+// usageModelResponse holds the request usage of one model. The counts are volatile and
+// parsed from the raw fields by parseFields.
+type usageModelResponse struct {
+	RawNumRequests     json.RawMessage `json:"numRequests"`
+	RawMaxRequestUsage json.RawMessage `json:"maxRequestUsage"`
+	RawNumTokens       json.RawMessage `json:"numTokens"`
+
+	NumRequests     int `json:"-"`
+	MaxRequestUsage int `json:"-"`
+	NumTokens       int `json:"-"`
+}
+
+// parseFields parses the volatile counts, naming fields after model
+func (u *usageModelResponse) parseFields(parser *cursorFieldParser, model string) {
+	u.NumRequests = parser.int(model+".numRequests", u.RawNumRequests)
+	u.MaxRequestUsage = parser.int(model+".maxRequestUsage", u.RawMaxRequestUsage)
+	u.NumTokens = parser.int(model+".numTokens", u.RawNumTokens)
 }
 
 type teamResponse struct {
@@ -57,13 +203,15 @@ type teamResponse struct {
 }
 
 type teamMemberResponse struct {
-	UserID      int `json:"userId"`
-	TeamMembers []struct {
-		ID    int    `json:"id"`
-		Name  string `json:"name"`
-		Email string `json:"email"`
-		Role  string `json:"role"`
-	} `json:"teamMembers"`
+	UserID      int          `json:"userId"`
+	TeamMembers []teamMember `json:"teamMembers"`
+}
+
+type teamMember struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Role  string `json:"role"`
 }
 
 type teamSpendResponse struct {
@@ -79,32 +227,71 @@ type teamSpendResponse struct {
 
 type monthlyInvoiceResponse struct {
 	Items []struct {
-		Description string `json:"description"`
-		Cents       *int   `json:"cents"`
+		Description string          `json:"description"`
+		Cents       json.RawMessage `json:"cents"`
 	} `json:"items"`
 	HasUnpaidMidMonthInvoice bool `json:"hasUnpaidMidMonthInvoice"`
 }
 
 type filteredUsageEventsResponse struct {
-	TotalUsageEventsCount int `json:"totalUsageEventsCount"`
-	UsageEventsDisplay    []struct {
-		Timestamp        string  `json:"timestamp"`
-		Model            string  `json:"model"`
-		Kind             string  `json:"kind"`
-		MaxMode          bool    `json:"maxMode"`
-		RequestsCosts    float64 `json:"requestsCosts"`
-		UsageBasedCosts  string  `json:"usageBasedCosts"`
-		IsTokenBasedCall bool    `json:"isTokenBasedCall"`
-		TokenUsage       struct {
-			InputTokens      int     `json:"inputTokens"`
-			OutputTokens     int     `json:"outputTokens"`
-			CacheWriteTokens int     `json:"cacheWriteTokens"`
-			CacheReadTokens  int     `json:"cacheReadTokens"`
-			TotalCents       float64 `json:"totalCents"`
-		} `json:"tokenUsage"`
-		OwningUser string `json:"owningUser"`
-		OwningTeam string `json:"owningTeam"`
-	} `json:"usageEventsDisplay"`
+	TotalUsageEventsCount json.RawMessage `json:"totalUsageEventsCount"`
+	UsageEventsDisplay    []usageEvent    `json:"usageEventsDisplay"`
+}
+
+// parseFields parses the volatile fields of every event
+func (u *filteredUsageEventsResponse) parseFields(parser *cursorFieldParser) {
+	for i := range u.UsageEventsDisplay {
+		u.UsageEventsDisplay[i].parseFields(parser)
+	}
+}
+
+type usageEvent struct {
+	Timestamp        string          `json:"timestamp"`
+	Model            string          `json:"model"`
+	Kind             string          `json:"kind"`
+	MaxMode          bool            `json:"maxMode"`
+	RequestsCosts    json.RawMessage `json:"requestsCosts"`
+	UsageBasedCosts  json.RawMessage `json:"usageBasedCosts"`
+	IsTokenBasedCall bool            `json:"isTokenBasedCall"`
+	TokenUsage       struct {
+		RawInputTokens      json.RawMessage `json:"inputTokens"`
+		RawOutputTokens     json.RawMessage `json:"outputTokens"`
+		RawCacheWriteTokens json.RawMessage `json:"cacheWriteTokens"`
+		RawCacheReadTokens  json.RawMessage `json:"cacheReadTokens"`
+		TotalCents          json.RawMessage `json:"totalCents"`
+
+		InputTokens      int `json:"-"`
+		OutputTokens     int `json:"-"`
+		CacheWriteTokens int `json:"-"`
+		CacheReadTokens  int `json:"-"`
+	} `json:"tokenUsage"`
+	OwningUser string `json:"owningUser"`
+	OwningTeam string `json:"owningTeam"`
+}
+
+// parseFields parses the volatile token counts of the event
+func (e *usageEvent) parseFields(parser *cursorFieldParser) {
+	e.TokenUsage.InputTokens = parser.int("tokenUsage.inputTokens", e.TokenUsage.RawInputTokens)
+	e.TokenUsage.OutputTokens = parser.int("tokenUsage.outputTokens", e.TokenUsage.RawOutputTokens)
+	e.TokenUsage.CacheWriteTokens = parser.int("tokenUsage.cacheWriteTokens", e.TokenUsage.RawCacheWriteTokens)
+	e.TokenUsage.CacheReadTokens = parser.int("tokenUsage.cacheReadTokens", e.TokenUsage.RawCacheReadTokens)
+}
+
+// tokens returns the event's total tokens; events that are not token based count as zero
+func (e *usageEvent) tokens() int64 {
+	if !e.IsTokenBasedCall {
+		return 0
+	}
+	return int64(e.TokenUsage.InputTokens) +
+		int64(e.TokenUsage.OutputTokens) +
+		int64(e.TokenUsage.CacheWriteTokens) +
+		int64(e.TokenUsage.CacheReadTokens)
+}
+
+// key identifies an event for deduplication when overlapping time ranges are fetched again
+func (e *usageEvent) key() string {
+	return fmt.Sprintf("%s|%s|%s|%d|%d|%d|%d", e.Timestamp, e.Model, e.Kind,
+		e.TokenUsage.InputTokens, e.TokenUsage.OutputTokens, e.TokenUsage.CacheWriteTokens, e.TokenUsage.CacheReadTokens)
 }
 
 type hardLimitResponse struct {
@@ -159,8 +346,8 @@ func (r *CursorAPIRepository) GetUsageLimit(token *valueobject.CursorToken, team
 	}()
 
 	var result hardLimitResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, domain.ErrCursorAPIWithCause("decode usage limit response", err)
+	if _, err := r.decodeResponse(resp.Body, &result, "usage limit response"); err != nil {
+		return nil, err
 	}
 
 	return &repository.UsageLimitInfo{
@@ -186,8 +373,8 @@ func (r *CursorAPIRepository) CheckUsageBasedStatus(token *valueobject.CursorTok
 	}()
 
 	var statusResp usageBasedStatusResponse
-	if err := json.NewDecoder(resp.Body).Decode(&statusResp); err != nil {
-		return nil, domain.ErrCursorAPIWithCause("decode usage-based status", err)
+	if _, err := r.decodeResponse(resp.Body, &statusResp, "usage-based status"); err != nil {
+		return nil, err
 	}
 
 	// Get hard limit to determine spending limit
@@ -204,7 +391,21 @@ func (r *CursorAPIRepository) CheckUsageBasedStatus(token *valueobject.CursorTok
 
 // checkTeamMembership checks if the user is a team member
 func (r *CursorAPIRepository) checkTeamMembership(token *valueobject.CursorToken) (*entity.TeamInfo, error) {
-	
+	team, err := r.fetchTeam(token)
+	if err != nil || team == nil {
+		return nil, err
+	}
+	return team.info, nil
+}
+
+// cursorTeam is the user's first team together with its member list
+type cursorTeam struct {
+	info    *entity.TeamInfo
+	members []teamMember
+}
+
+// fetchTeam reads the user's first team and its members, or nil if the user is not a team member
+func (r *CursorAPIRepository) fetchTeam(token *valueobject.CursorToken) (*cursorTeam, error) {
 	// Get team list - send empty JSON object
 	resp, err := r.makeAPIRequest(token, "POST", "/api/dashboard/teams", map[string]interface{}{})
 	if err != nil {
@@ -215,10 +416,9 @@ func (r *CursorAPIRepository) checkTeamMembership(token *valueobject.CursorToken
 	}()
 
 	var teams teamResponse
-	if err := json.NewDecoder(resp.Body).Decode(&teams); err != nil {
-		return nil, domain.ErrCursorAPIWithCause("decode teams response", err)
+	if _, err := r.decodeResponse(resp.Body, &teams, "teams response"); err != nil {
+		return nil, err
 	}
-	
 
 	if len(teams.Teams) == 0 {
 		return nil, nil // Not a team member
@@ -237,16 +437,18 @@ func (r *CursorAPIRepository) checkTeamMembership(token *valueobject.CursorToken
 	}()
 
 	var teamDetails teamMemberResponse
-	if err := json.NewDecoder(resp.Body).Decode(&teamDetails); err != nil {
-		return nil, domain.ErrCursorAPIWithCause("decode team details", err)
+	if _, err := r.decodeResponse(resp.Body, &teamDetails, "team details"); err != nil {
+		return nil, err
 	}
-	
 
-	return &entity.TeamInfo{
-		TeamID:   teamID,
-		UserID:   teamDetails.UserID,
-		TeamName: teams.Teams[0].Name,
-		Role:     teams.Teams[0].Role,
+	return &cursorTeam{
+		info: &entity.TeamInfo{
+			TeamID:   teamID,
+			UserID:   teamDetails.UserID,
+			TeamName: teams.Teams[0].Name,
+			Role:     teams.Teams[0].Role,
+		},
+		members: teamDetails.TeamMembers,
 	}, nil
 }
 
@@ -300,49 +502,33 @@ func (r *CursorAPIRepository) getPremiumRequests(token *valueobject.CursorToken,
 
 // getIndividualUsage gets individual usage data
 func (r *CursorAPIRepository) getIndividualUsage(token *valueobject.CursorToken, userID string) (*usageResponse, error) {
-	req, err := http.NewRequest("GET", r.baseURL+"/api/usage?user="+userID, nil)
+	resp, err := r.makeAPIRequest(token, "GET", "/api/usage?user="+userID, nil)
 	if err != nil {
-		return nil, domain.ErrCursorAPIWithCause("create usage request", err)
-	}
-
-	req.Header.Set("Cookie", fmt.Sprintf("WorkosCursorSessionToken=%s", token.SessionToken()))
-
-	resp, err := r.httpClient.Do(req)
-	if err != nil {
-		return nil, domain.ErrCursorAPIWithCause("execute usage request", err)
+		return nil, err
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, domain.ErrCursorAPI("get individual usage", resp.StatusCode, string(body))
-	}
-
 	var usage usageResponse
-	if err := json.NewDecoder(resp.Body).Decode(&usage); err != nil {
-		return nil, domain.ErrCursorAPIWithCause("decode usage response", err)
+	body, err := r.decodeResponse(resp.Body, &usage, "usage response")
+	if err != nil {
+		return nil, err
 	}
+	var parser cursorFieldParser
+	usage.GPT4.parseFields(&parser, "gpt-4")
+	usage.GPT432K.parseFields(&parser, "gpt-4-32k")
+	r.checkFields("usage response", body, &parser)
 
 	return &usage, nil
 }
 
 // getUsageBasedPricing gets usage-based pricing data for current and last month
 func (r *CursorAPIRepository) getUsageBasedPricing(token *valueobject.CursorToken) (entity.UsageBasedPricingInfo, error) {
-	now := time.Now()
-	billingDay := 3
-
 	// Calculate current billing month
-	currentMonth := int(now.Month())
-	currentYear := now.Year()
-	if now.Day() < billingDay {
-		currentMonth--
-		if currentMonth == 0 {
-			currentMonth = 12
-			currentYear--
-		}
-	}
+	periodStart := billingPeriodStart(time.Now(), r.billingDay)
+	currentMonth := int(periodStart.Month())
+	currentYear := periodStart.Year()
 
 	// Calculate last billing month
 	lastMonth := currentMonth - 1
@@ -385,15 +571,22 @@ func (r *CursorAPIRepository) fetchMonthlyInvoice(token *valueobject.CursorToken
 	}()
 
 	var invoice monthlyInvoiceResponse
-	if err := json.NewDecoder(resp.Body).Decode(&invoice); err != nil {
-		return entity.MonthlyUsage{}, domain.ErrCursorAPIWithCause("decode monthly invoice", err)
+	body, err := r.decodeResponse(resp.Body, &invoice, "monthly invoice")
+	if err != nil {
+		return entity.MonthlyUsage{}, err
 	}
 
 	// Parse invoice items
 	var usageItems []entity.UsageItem
 	var midMonthPayment float64
 
-	for _, item := range invoice.Items {
+	var parser cursorFieldParser
+	defer r.checkFields("monthly invoice", body, &parser)
+	for _, rawItem := range invoice.Items {
+		item := struct {
+			Description string `json:"description"`
+			Cents       *int   `json:"cents"`
+		}{Description: rawItem.Description, Cents: parser.optionalInt("items.cents", rawItem.Cents)}
 		if item.Cents == nil {
 			continue
 		}
@@ -433,6 +626,7 @@ func (r *CursorAPIRepository) parseInvoiceItem(item struct {
 	var requestCount int
 	var model string
 	var isToolCall bool
+	var isTokenBased bool
 	var isDiscounted bool
 
 	// Check for different description patterns
@@ -440,6 +634,7 @@ func (r *CursorAPIRepository) parseInvoiceItem(item struct {
 		// Pattern: "123 token-based usage calls to claude-3-opus, totalling: $12.34"
 		_, _ = fmt.Sscanf(item.Description, "%d token-based usage calls to %s", &requestCount, &model)
 		model = strings.TrimSuffix(model, ",")
+		isTokenBased = true
 	} else if strings.Contains(item.Description, "tool calls") {
 		// Pattern: "123 tool calls"
 		_, _ = fmt.Sscanf(item.Description, "%d tool calls", &requestCount)
@@ -505,46 +700,108 @@ func (r *CursorAPIRepository) parseInvoiceItem(item struct {
 		Description:    item.Description,
 		IsDiscounted:   isDiscounted,
 		IsToolCall:     isToolCall,
+		IsTokenBased:   isTokenBased,
 	}
 }
 
+// CheckConnection verifies the token is accepted by the Cursor API by listing the user's teams
+func (r *CursorAPIRepository) CheckConnection(ctx context.Context, token *valueobject.CursorToken) error {
+	resp, err := r.makeAPIRequestWithContext(ctx, token, "POST", "/api/dashboard/teams", map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
 // makeAPIRequest makes a request to the Cursor API
 func (r *CursorAPIRepository) makeAPIRequest(token *valueobject.CursorToken, method, path string, payload interface{}) (*http.Response, error) {
-	var body io.Reader
+	return r.makeAPIRequestWithContext(context.Background(), token, method, path, payload)
+}
+
+// makeAPIRequestWithContext makes a request to the Cursor API that is canceled with ctx.
+// When Cursor rejects the request's CSRF check, a CSRF token is fetched and the request
+// is retried once with it.
+func (r *CursorAPIRepository) makeAPIRequestWithContext(ctx context.Context, token *valueobject.CursorToken, method, path string, payload interface{}) (*http.Response, error) {
+	var jsonData []byte
 	if payload != nil {
-		jsonData, err := json.Marshal(payload)
+		var err error
+		jsonData, err = json.Marshal(payload)
 		if err != nil {
 			return nil, domain.ErrCursorAPIWithCause("marshal request payload", err)
 		}
+	}
+
+	resp, body, err := r.doAPIRequest(ctx, token, method, path, jsonData)
+	if err != nil || resp.StatusCode == http.StatusOK {
+		return resp, err
+	}
+
+	if isCSRFRejection(resp, body) {
+		if r.logger != nil {
+			r.logger.Warn(ctx, "Cursor API rejected the request's CSRF check, fetching a CSRF token",
+				domain.NewField("path", path),
+				domain.NewField("status", resp.StatusCode))
+		}
+		if err := r.refreshCSRFToken(ctx, token); err != nil {
+			return nil, domain.ErrCursorAPIWithCause("refresh CSRF token after "+path+" was rejected", err)
+		}
+		resp, body, err = r.doAPIRequest(ctx, token, method, path, jsonData)
+		if err != nil || resp.StatusCode == http.StatusOK {
+			if err == nil && r.logger != nil {
+				r.logger.Info(ctx, "Cursor API accepted the request with a CSRF token", domain.NewField("path", path))
+			}
+			return resp, err
+		}
+	}
+
+	return nil, domain.ErrCursorAPI(path, resp.StatusCode, string(body))
+}
+
+// doAPIRequest sends one request to the Cursor API. A successful response is returned
+// open; otherwise its body is read, closed and returned alongside it.
+func (r *CursorAPIRepository) doAPIRequest(ctx context.Context, token *valueobject.CursorToken, method, path string, jsonData []byte) (*http.Response, []byte, error) {
+	var body io.Reader
+	if jsonData != nil {
 		body = bytes.NewReader(jsonData)
 	}
 
-	req, err := http.NewRequest(method, r.baseURL+path, body)
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, body)
 	if err != nil {
-		return nil, domain.ErrCursorAPIWithCause("create request", err)
+		return nil, nil, domain.ErrCursorAPIWithCause("create request", err)
 	}
 
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
-	req.Header.Set("Cookie", fmt.Sprintf("WorkosCursorSessionToken=%s", token.SessionToken()))
-	
-	// Add Origin and Referer headers to pass CSRF check
-	req.Header.Set("Origin", "https://cursor.com")
-	req.Header.Set("Referer", "https://cursor.com/")
+	r.setCursorHeaders(req, token)
 
+	if err := r.waitForRateLimit(ctx); err != nil {
+		return nil, nil, err
+	}
 	resp, err := r.httpClient.Do(req)
 	if err != nil {
-		return nil, domain.ErrCursorAPIWithCause("execute request", err)
+		return nil, nil, domain.ErrCursorAPIWithCause("execute request", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
+		respBody, _ := io.ReadAll(resp.Body)
 		_ = resp.Body.Close()
-		return nil, domain.ErrCursorAPI(path, resp.StatusCode, string(body))
+		return resp, respBody, nil
 	}
 
-	return resp, nil
+	return resp, nil, nil
+}
+
+// waitForRateLimit blocks until the rate limiter allows another request or ctx is done
+func (r *CursorAPIRepository) waitForRateLimit(ctx context.Context) error {
+	if r.limiter == nil {
+		return nil
+	}
+	if err := r.limiter.Wait(ctx); err != nil {
+		return domain.ErrCursorAPIWithCause("wait for rate limit", err)
+	}
+	return nil
 }
 
 // isAlphaNumeric checks if a byte is alphanumeric
@@ -552,47 +809,273 @@ func isAlphaNumeric(b byte) bool {
 	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
 }
 
-// GetAggregatedTokenUsage retrieves aggregated token usage from 00:00 to current time in the machine's timezone
+// GetAggregatedTokenUsage retrieves aggregated token usage from the start of the daily window
+// (00:00 unless configured otherwise, or 24 hours ago in rolling mode) to current time in the
+// machine's timezone
 func (r *CursorAPIRepository) GetAggregatedTokenUsage(token *valueobject.CursorToken) (int64, error) {
-	// Get current time in the machine's local timezone
 	now := time.Now()
+	return r.sumTokenUsage(token, r.windowStart(now), now)
+}
 
-	// Calculate 00:00 today in the local timezone
-	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+// GetBillingPeriodTokenUsage retrieves aggregated token usage from the start of the current billing period to current time
+func (r *CursorAPIRepository) GetBillingPeriodTokenUsage(token *valueobject.CursorToken) (int64, error) {
+	now := time.Now()
+	return r.sumBillingUsage(token, billingPeriodStart(now, r.billingDay), now)
+}
 
-	// Convert to milliseconds for API
-	startDate := startOfDay.UnixMilli()
-	endDate := now.UnixMilli()
-	
+// GetBillingCycleTokenUsage retrieves aggregated token usage from the start of the billing cycle
+// reported by Cursor (startOfMonth) to current time. The configured billing day is used when
+// Cursor does not report a usable cycle start.
+func (r *CursorAPIRepository) GetBillingCycleTokenUsage(token *valueobject.CursorToken) (int64, error) {
+	usage, err := r.getIndividualUsage(token, token.UserID())
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	start, ok := parseCycleStart(usage.StartOfMonth, now.Location())
+	if !ok || start.After(now) {
+		start = billingPeriodStart(now, r.billingDay)
+	}
+	return r.sumBillingUsage(token, start, now)
+}
+
+// sumBillingUsage sums the tokens of the user's usage events from start to now. The events are
+// fetched from the earlier of start, the billing period start and the last reported billing cycle
+// start, and reused for billingUsageMaxAge, so the billing period and billing cycle totals share
+// one pass over the month's events.
+func (r *CursorAPIRepository) sumBillingUsage(token *valueobject.CursorToken, start, now time.Time) (int64, error) {
+	r.billingMu.Lock()
+	defer r.billingMu.Unlock()
+
+	if start.Before(r.billingPeriodOrCycleStart(now)) {
+		r.billing.cycleStart = start
+	}
+	if r.billing.fetched.IsZero() || r.billing.from.After(start) || now.Sub(r.billing.fetched) >= billingUsageMaxAge {
+		from := r.billingPeriodOrCycleStart(now)
+		events, err := r.userUsageEvents(token, from, now)
+		if err != nil {
+			return 0, err
+		}
+		r.billing.from = from
+		r.billing.fetched = now
+		r.billing.events = events
+	}
+
+	total := int64(0)
+	for _, event := range r.billing.events {
+		if !event.at.Before(start) {
+			total += event.tokens
+		}
+	}
+	return total, nil
+}
+
+// billingPeriodOrCycleStart returns the earlier of the billing period start and the billing cycle
+// start Cursor last reported, ignoring a cycle start from before the previous billing period.
+// The caller must hold r.billingMu.
+func (r *CursorAPIRepository) billingPeriodOrCycleStart(now time.Time) time.Time {
+	start := billingPeriodStart(now, r.billingDay)
+	if cycleStart := r.billing.cycleStart; cycleStart.Before(start) && cycleStart.After(start.AddDate(0, -1, 0)) {
+		start = cycleStart
+	}
+	return start
+}
+
+// parseCycleStart parses the startOfMonth of a usage response, which is an RFC 3339 timestamp
+// or a plain date. A plain date is taken as local midnight.
+func parseCycleStart(value string, loc *time.Location) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// cursorLateEventWindow is how far before the newest event read an incremental fetch starts,
+// so that events which arrive late or out of order are still counted
+const cursorLateEventWindow = 15 * time.Minute
+
+// GetIncrementalTokenUsage retrieves today's token usage, requesting only events newer than position.
+// The last cursorLateEventWindow before the position is fetched again and deduplicated.
+func (r *CursorAPIRepository) GetIncrementalTokenUsage(token *valueobject.CursorToken, position *entity.CursorUsagePosition) (*entity.CursorUsagePosition, error) {
+	now := time.Now()
+	windowStart := r.windowStart(now)
+
+	// A position from an earlier window is discarded, which resets the total at the day rollover.
+	// A rolling window starts anew on every call, so it is always fetched in full.
+	next := &entity.CursorUsagePosition{WindowStart: windowStart}
+	fetchFrom := windowStart
+	seen := make(map[string]bool)
+	if position != nil && position.WindowStart.Equal(windowStart) {
+		next.LastEventAt = position.LastEventAt
+		next.Tokens = position.Tokens
+		if overlap := position.LastEventAt.Add(-cursorLateEventWindow); overlap.After(windowStart) {
+			fetchFrom = overlap
+		}
+		for _, key := range position.RecentEvents {
+			seen[key] = true
+		}
+	}
 
-	// Check if user is a team member
 	teamInfo, err := r.checkTeamMembership(token)
 	if err != nil {
-		// If team check fails, return 0 (not an error)
-		return 0, nil
+		return nil, err
 	}
-
-	// If not a team member, return 0
+	// Usage events are only available to team members
 	if teamInfo == nil || teamInfo.TeamID == 0 {
-		return 0, nil
+		return next, nil
 	}
-	
 
-	// Create request payload
 	payload := map[string]interface{}{
 		"teamId":    teamInfo.TeamID,
-		"startDate": strconv.FormatInt(startDate, 10),
-		"endDate":   strconv.FormatInt(endDate, 10),
+		"startDate": strconv.FormatInt(fetchFrom.UnixMilli(), 10),
+		"endDate":   strconv.FormatInt(now.UnixMilli(), 10),
 		"userId":    teamInfo.UserID,
-		"page":      1,
 		"pageSize":  100,
 	}
-	
 
+	recent := make(map[string]time.Time)
+	for page := 1; ; page++ {
+		payload["page"] = page
+
+		resp, err := r.makeAPIRequest(token, "POST", "/api/dashboard/get-filtered-usage-events", payload)
+		if err != nil {
+			return nil, err
+		}
+		var usageResp filteredUsageEventsResponse
+		body, err := r.decodeResponse(resp.Body, &usageResp, "filtered usage events")
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		var parser cursorFieldParser
+		usageResp.parseFields(&parser)
+		r.checkFields("filtered usage events", body, &parser)
+
+		for i := range usageResp.UsageEventsDisplay {
+			event := &usageResp.UsageEventsDisplay[i]
+			timestamp, err := strconv.ParseInt(event.Timestamp, 10, 64)
+			if err != nil {
+				continue
+			}
+			eventTime := time.UnixMilli(timestamp)
+			if eventTime.Before(fetchFrom) || eventTime.After(now) {
+				continue
+			}
+			if eventTime.After(next.LastEventAt) {
+				next.LastEventAt = eventTime
+			}
+
+			tokens := event.tokens()
+			if tokens <= 0 {
+				continue
+			}
+			key := event.key()
+			recent[key] = eventTime
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			next.Tokens += tokens
+		}
+
+		if len(usageResp.UsageEventsDisplay) < 100 {
+			break
+		}
+	}
+
+	// Remember the counted events the next fetch will see again
+	cutoff := next.LastEventAt.Add(-cursorLateEventWindow)
+	for key, eventTime := range recent {
+		if !eventTime.Before(cutoff) {
+			next.RecentEvents = append(next.RecentEvents, key)
+		}
+	}
+	sort.Strings(next.RecentEvents)
+
+	return next, nil
+}
+
+// sumTokenUsage sums the tokens of the user's usage events between start and end
+func (r *CursorAPIRepository) sumTokenUsage(token *valueobject.CursorToken, start, end time.Time) (int64, error) {
+	events, err := r.userUsageEvents(token, start, end)
+	if err != nil {
+		return 0, err
+	}
+	totalTokens := int64(0)
+	for _, event := range events {
+		totalTokens += event.tokens
+	}
+	return totalTokens, nil
+}
+
+// userUsageEvents returns the user's usage events between start and end. Without a team, or when
+// the team or events can't be requested, there are no events rather than an error.
+func (r *CursorAPIRepository) userUsageEvents(token *valueobject.CursorToken, start, end time.Time) ([]usageEventTokens, error) {
+	// Check if user is a team member
+	teamInfo, err := r.checkTeamMembership(token)
+	if err != nil {
+		// If team check fails, return no events (not an error)
+		return nil, nil
+	}
+
+	// If not a team member, return no events
+	if teamInfo == nil || teamInfo.TeamID == 0 {
+		return nil, nil
+	}
+
+	events, err := r.memberUsageEvents(token, teamInfo.TeamID, teamInfo.UserID, start, end)
+	var requestErr *usageEventsRequestError
+	if errors.As(err, &requestErr) {
+		// If API fails, return no events (not an error)
+		return nil, nil
+	}
+	return events, err
+}
+
+// usageEventsRequestError is a failed request for usage events, as opposed to an undecodable response
+type usageEventsRequestError struct {
+	err error
+}
+
+func (e *usageEventsRequestError) Error() string { return e.err.Error() }
+
+func (e *usageEventsRequestError) Unwrap() error { return e.err }
+
+// sumMemberTokenUsage sums the tokens of userID's usage events in teamID between start and end.
+// Usage of members other than the token's user is only readable with a team admin token.
+func (r *CursorAPIRepository) sumMemberTokenUsage(token *valueobject.CursorToken, teamID, userID int, start, end time.Time) (int64, error) {
+	events, err := r.memberUsageEvents(token, teamID, userID, start, end)
+	if err != nil {
+		return 0, err
+	}
 	totalTokens := int64(0)
+	for _, event := range events {
+		totalTokens += event.tokens
+	}
+	return totalTokens, nil
+}
+
+// memberUsageEvents returns userID's usage events in teamID between start and end
+func (r *CursorAPIRepository) memberUsageEvents(token *valueobject.CursorToken, teamID, userID int, start, end time.Time) ([]usageEventTokens, error) {
+	// Create request payload, with dates in milliseconds
+	payload := map[string]interface{}{
+		"teamId":    teamID,
+		"startDate": strconv.FormatInt(start.UnixMilli(), 10),
+		"endDate":   strconv.FormatInt(end.UnixMilli(), 10),
+		"userId":    userID,
+		"page":      1,
+		"pageSize":  100,
+	}
+
+	var events []usageEventTokens
 	page := 1
-	totalEvents := 0
-	eventsWithTokens := 0
 
 	// Paginate through all results
 	for {
@@ -601,18 +1084,19 @@ func (r *CursorAPIRepository) GetAggregatedTokenUsage(token *valueobject.CursorT
 		// Make API request
 		resp, err := r.makeAPIRequest(token, "POST", "/api/dashboard/get-filtered-usage-events", payload)
 		if err != nil {
-			// If API fails, return 0 (not an error)
-			return 0, nil
+			return nil, &usageEventsRequestError{err: err}
 		}
 
 		// Decode response
 		var usageResp filteredUsageEventsResponse
-		if err := json.NewDecoder(resp.Body).Decode(&usageResp); err != nil {
-			_ = resp.Body.Close()
-			return 0, domain.ErrCursorAPIWithCause("decode filtered usage events", err)
-		}
+		body, err := r.decodeResponse(resp.Body, &usageResp, "filtered usage events")
 		_ = resp.Body.Close()
-		
+		if err != nil {
+			return nil, err
+		}
+		var parser cursorFieldParser
+		usageResp.parseFields(&parser)
+		r.checkFields("filtered usage events", body, &parser)
 
 		// Process each usage event
 		for _, event := range usageResp.UsageEventsDisplay {
@@ -622,28 +1106,14 @@ func (r *CursorAPIRepository) GetAggregatedTokenUsage(token *valueobject.CursorT
 				continue
 			}
 
-			// Convert to time
+			// Check if event is within the requested range
 			eventTime := time.UnixMilli(timestamp)
-
-			// Check if event is within today's range
-			if eventTime.Before(startOfDay) || eventTime.After(now) {
+			if eventTime.Before(start) || eventTime.After(end) {
 				continue
 			}
-			
-			totalEvents++
-
-			// Sum all token types from tokenUsage
-			if event.IsTokenBasedCall {
-				eventTokens := int64(event.TokenUsage.InputTokens) + 
-					int64(event.TokenUsage.OutputTokens) + 
-					int64(event.TokenUsage.CacheWriteTokens) + 
-					int64(event.TokenUsage.CacheReadTokens)
-				
-				if eventTokens > 0 {
-					eventsWithTokens++
-					totalTokens += eventTokens
-				}
-			}
+
+			// Count all token types from tokenUsage
+			events = append(events, usageEventTokens{at: eventTime, tokens: event.tokens()})
 		}
 
 		// Check if we need to fetch more pages
@@ -653,7 +1123,42 @@ func (r *CursorAPIRepository) GetAggregatedTokenUsage(token *valueobject.CursorT
 
 		page++
 	}
-	
 
-	return totalTokens, nil
+	return events, nil
+}
+
+// GetTeamMemberTokenUsage retrieves today's token usage of the selected members of the user's team.
+// A member whose usage can't be read is returned with Err set rather than failing the others.
+func (r *CursorAPIRepository) GetTeamMemberTokenUsage(token *valueobject.CursorToken, selectMembers repository.TeamMemberSelector) ([]repository.TeamMemberTokenUsage, error) {
+	team, err := r.fetchTeam(token)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil || team.info.TeamID == 0 {
+		return nil, domain.ErrCursorAPIWithCause("read team members", fmt.Errorf("the account is not a member of a team"))
+	}
+
+	members := make([]repository.TeamMemberTokenUsage, 0, len(team.members))
+	for _, member := range team.members {
+		members = append(members, repository.TeamMemberTokenUsage{
+			UserID: member.ID,
+			Name:   member.Name,
+			Email:  member.Email,
+		})
+	}
+	if selectMembers != nil {
+		members = selectMembers(members)
+	}
+
+	now := time.Now()
+	start := r.windowStart(now)
+	for i := range members {
+		tokens, err := r.sumMemberTokenUsage(token, team.info.TeamID, members[i].UserID, start, now)
+		if err != nil {
+			members[i].Err = fmt.Errorf("failed to read token usage of team member %d: %w", members[i].UserID, err)
+			continue
+		}
+		members[i].Tokens = tokens
+	}
+	return members, nil
 }
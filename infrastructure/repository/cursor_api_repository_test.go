@@ -0,0 +1,467 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ca-srg/tosage/domain/entity"
+	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/domain/valueobject"
+)
+
+func TestBillingPeriodStart(t *testing.T) {
+	loc := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		name       string
+		now        time.Time
+		billingDay int
+		want       time.Time
+	}{
+		{
+			name:       "after billing day",
+			now:        time.Date(2025, 3, 15, 10, 0, 0, 0, loc),
+			billingDay: 3,
+			want:       time.Date(2025, 3, 3, 0, 0, 0, 0, loc),
+		},
+		{
+			name:       "on billing day",
+			now:        time.Date(2025, 3, 3, 0, 0, 0, 0, loc),
+			billingDay: 3,
+			want:       time.Date(2025, 3, 3, 0, 0, 0, 0, loc),
+		},
+		{
+			name:       "before billing day",
+			now:        time.Date(2025, 3, 2, 23, 59, 0, 0, loc),
+			billingDay: 3,
+			want:       time.Date(2025, 2, 3, 0, 0, 0, 0, loc),
+		},
+		{
+			name:       "before billing day in January",
+			now:        time.Date(2025, 1, 10, 12, 0, 0, 0, loc),
+			billingDay: 28,
+			want:       time.Date(2024, 12, 28, 0, 0, 0, 0, loc),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := billingPeriodStart(tt.now, tt.billingDay); !got.Equal(tt.want) {
+				t.Errorf("billingPeriodStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCycleStart(t *testing.T) {
+	loc := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		value  string
+		want   time.Time
+		wantOK bool
+	}{
+		{value: "2025-03-05T04:12:33.000Z", want: time.Date(2025, 3, 5, 4, 12, 33, 0, time.UTC), wantOK: true},
+		{value: "2025-03-05", want: time.Date(2025, 3, 5, 0, 0, 0, 0, loc), wantOK: true},
+		{value: ""},
+		{value: "March 5"},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseCycleStart(tt.value, loc)
+		if ok != tt.wantOK || !got.Equal(tt.want) {
+			t.Errorf("parseCycleStart(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestDayWindowStart(t *testing.T) {
+	loc := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		name      string
+		now       time.Time
+		startHour int
+		want      time.Time
+	}{
+		{
+			name:      "midnight",
+			now:       time.Date(2025, 3, 15, 10, 0, 0, 0, loc),
+			startHour: 0,
+			want:      time.Date(2025, 3, 15, 0, 0, 0, 0, loc),
+		},
+		{
+			name:      "after start hour",
+			now:       time.Date(2025, 3, 15, 10, 0, 0, 0, loc),
+			startHour: 5,
+			want:      time.Date(2025, 3, 15, 5, 0, 0, 0, loc),
+		},
+		{
+			name:      "before start hour",
+			now:       time.Date(2025, 3, 1, 4, 59, 0, 0, loc),
+			startHour: 5,
+			want:      time.Date(2025, 2, 28, 5, 0, 0, 0, loc),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dayWindowStart(tt.now, tt.startHour); !got.Equal(tt.want) {
+				t.Errorf("dayWindowStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeUsageEvent is a token based usage event served by newFakeCursorServer
+type fakeUsageEvent struct {
+	at     time.Time
+	tokens int
+}
+
+// newFakeCursorServer serves a team membership and the given usage events, recording the requested start dates
+func newFakeCursorServer(t *testing.T, events *[]fakeUsageEvent, startDates *[]int64) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/api/dashboard/teams":
+			_, _ = fmt.Fprint(w, `{"teams":[{"id":1,"name":"team","role":"member"}]}`)
+		case "/api/dashboard/team":
+			_, _ = fmt.Fprint(w, `{"userId":7}`)
+		case "/api/dashboard/get-filtered-usage-events":
+			var payload struct {
+				StartDate string `json:"startDate"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			startDate, _ := strconv.ParseInt(payload.StartDate, 10, 64)
+			*startDates = append(*startDates, startDate)
+
+			var display []map[string]interface{}
+			for _, event := range *events {
+				if event.at.UnixMilli() < startDate {
+					continue
+				}
+				display = append(display, map[string]interface{}{
+					"timestamp":        strconv.FormatInt(event.at.UnixMilli(), 10),
+					"model":            "claude",
+					"isTokenBasedCall": true,
+					"tokenUsage":       map[string]int{"inputTokens": event.tokens},
+				})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"usageEventsDisplay": display})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetIncrementalTokenUsage(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"auth0|user","exp":9999999999}`))
+	token, err := valueobject.NewCursorToken("header." + payload + ".signature")
+	if err != nil {
+		t.Fatalf("NewCursorToken() error = %v", err)
+	}
+
+	// Start the daily window one to two hours ago so every event falls inside it
+	now := time.Now()
+	startHour := (now.Hour() + 23) % 24
+	windowStart := dayWindowStart(now, startHour)
+
+	events := []fakeUsageEvent{
+		{at: now.Add(-40 * time.Minute), tokens: 100},
+		{at: now.Add(-5 * time.Minute), tokens: 50},
+	}
+	var startDates []int64
+	server := newFakeCursorServer(t, &events, &startDates)
+	repo := NewCursorAPIRepository(5*time.Second, WithDayStartHour(startHour), WithBaseURL(server.URL)).(*CursorAPIRepository)
+
+	position, err := repo.GetIncrementalTokenUsage(token, nil)
+	if err != nil {
+		t.Fatalf("GetIncrementalTokenUsage() error = %v", err)
+	}
+	if position.Tokens != 150 || !position.WindowStart.Equal(windowStart) {
+		t.Fatalf("first fetch = %d tokens from %v, want 150 from %v", position.Tokens, position.WindowStart, windowStart)
+	}
+	if startDates[0] != windowStart.UnixMilli() {
+		t.Errorf("first fetch started at %d, want window start %d", startDates[0], windowStart.UnixMilli())
+	}
+
+	// A late event before the newest one and a new event are both counted exactly once
+	events = append(events,
+		fakeUsageEvent{at: now.Add(-10 * time.Minute), tokens: 25},
+		fakeUsageEvent{at: now.Add(-1 * time.Minute), tokens: 10},
+	)
+	position, err = repo.GetIncrementalTokenUsage(token, position)
+	if err != nil {
+		t.Fatalf("GetIncrementalTokenUsage() error = %v", err)
+	}
+	if position.Tokens != 185 {
+		t.Errorf("incremental fetch = %d tokens, want 185", position.Tokens)
+	}
+	if want := now.Add(-5*time.Minute - cursorLateEventWindow).UnixMilli(); startDates[1] != want {
+		t.Errorf("incremental fetch started at %d, want %d", startDates[1], want)
+	}
+
+	// A position from the previous window is discarded
+	stale := &entity.CursorUsagePosition{WindowStart: windowStart.AddDate(0, 0, -1), LastEventAt: now, Tokens: 1000}
+	position, err = repo.GetIncrementalTokenUsage(token, stale)
+	if err != nil {
+		t.Fatalf("GetIncrementalTokenUsage() error = %v", err)
+	}
+	if position.Tokens != 185 || startDates[2] != windowStart.UnixMilli() {
+		t.Errorf("after rollover = %d tokens from %d, want 185 from the window start", position.Tokens, startDates[2])
+	}
+}
+
+func TestBillingUsageIsShared(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"auth0|user","exp":9999999999}`))
+	token, err := valueobject.NewCursorToken("header." + payload + ".signature")
+	if err != nil {
+		t.Fatalf("NewCursorToken() error = %v", err)
+	}
+
+	now := time.Now()
+	periodStart := billingPeriodStart(now, 1)
+	cycleStart := periodStart.Add(-24 * time.Hour)
+	events := []fakeUsageEvent{
+		{at: now.Add(-time.Second), tokens: 100},
+		{at: periodStart.Add(-12 * time.Hour), tokens: 40},
+	}
+	var startDates []int64
+	server := newFakeCursorServer(t, &events, &startDates)
+	repo := NewCursorAPIRepository(5*time.Second, WithBillingDay(1), WithBaseURL(server.URL)).(*CursorAPIRepository)
+
+	if tokens, err := repo.GetBillingPeriodTokenUsage(token); err != nil || tokens != 100 {
+		t.Fatalf("GetBillingPeriodTokenUsage() = %d, %v, want 100", tokens, err)
+	}
+	// A cycle start before the billing period fetches the events once more, from the earlier start
+	if tokens, err := repo.sumBillingUsage(token, cycleStart, time.Now()); err != nil || tokens != 140 {
+		t.Fatalf("sumBillingUsage() = %d, %v, want 140", tokens, err)
+	}
+	// Both totals are then summed from the events already fetched
+	if tokens, err := repo.GetBillingPeriodTokenUsage(token); err != nil || tokens != 100 {
+		t.Fatalf("GetBillingPeriodTokenUsage() = %d, %v, want 100", tokens, err)
+	}
+	if tokens, err := repo.sumBillingUsage(token, cycleStart, time.Now()); err != nil || tokens != 140 {
+		t.Fatalf("sumBillingUsage() = %d, %v, want 140", tokens, err)
+	}
+	want := []int64{periodStart.UnixMilli(), cycleStart.UnixMilli()}
+	if len(startDates) != len(want) || startDates[0] != want[0] || startDates[1] != want[1] {
+		t.Errorf("usage event fetches started at %v, want %v", startDates, want)
+	}
+}
+
+func TestMakeAPIRequest_RefreshesCSRFToken(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"auth0|user","exp":9999999999}`))
+	token, err := valueobject.NewCursorToken("header." + payload + ".signature")
+	if err != nil {
+		t.Fatalf("NewCursorToken() error = %v", err)
+	}
+
+	var csrfFetches, rejected int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case cursorCSRFPath:
+			csrfFetches++
+			http.SetCookie(w, &http.Cookie{Name: "csrf_secret", Value: "cookie-value"})
+			_, _ = fmt.Fprint(w, `{"csrfToken":"abc123"}`)
+		case "/api/dashboard/teams":
+			if r.Header.Get(cursorCSRFHeader) != "abc123" || !strings.Contains(r.Header.Get("Cookie"), "csrf_secret=cookie-value") {
+				rejected++
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = fmt.Fprint(w, `{"error":"Invalid CSRF token"}`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"teams":[]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	repo := NewCursorAPIRepository(5*time.Second, WithBaseURL(server.URL)).(*CursorAPIRepository)
+
+	for i := 0; i < 2; i++ {
+		resp, err := repo.makeAPIRequest(token, "POST", "/api/dashboard/teams", map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("makeAPIRequest() #%d error = %v", i+1, err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	// The token is fetched once and reused by the second request
+	if csrfFetches != 1 || rejected != 1 {
+		t.Errorf("csrf fetches = %d, rejections = %d, want 1 and 1", csrfFetches, rejected)
+	}
+}
+
+func TestMakeAPIRequest_OtherForbiddenIsNotRetried(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"auth0|user","exp":9999999999}`))
+	token, err := valueobject.NewCursorToken("header." + payload + ".signature")
+	if err != nil {
+		t.Fatalf("NewCursorToken() error = %v", err)
+	}
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = fmt.Fprint(w, `{"error":"Not authorized"}`)
+	}))
+	defer server.Close()
+
+	repo := NewCursorAPIRepository(5*time.Second, WithBaseURL(server.URL)).(*CursorAPIRepository)
+
+	if _, err := repo.makeAPIRequest(token, "POST", "/api/dashboard/teams", nil); err == nil {
+		t.Fatal("makeAPIRequest() error = nil, want the 403")
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1", requests)
+	}
+}
+
+func TestMakeAPIRequest_RateLimit(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"auth0|user","exp":9999999999}`))
+	token, err := valueobject.NewCursorToken("header." + payload + ".signature")
+	if err != nil {
+		t.Fatalf("NewCursorToken() error = %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	// A burst of 2 goes out at once; the third request waits for the 20/s rate
+	repo := NewCursorAPIRepository(5*time.Second, WithBaseURL(server.URL), WithRateLimit(20, 2)).(*CursorAPIRepository)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := repo.makeAPIRequest(token, "GET", "/api/usage", nil)
+		if err != nil {
+			t.Fatalf("makeAPIRequest() error = %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("3 requests took %v, want the third to wait about 50ms", elapsed)
+	}
+
+	if repo := NewCursorAPIRepository(time.Second, WithRateLimit(0, 5)).(*CursorAPIRepository); repo.limiter != nil {
+		t.Error("a rate of 0 should leave requests unlimited")
+	}
+}
+
+func TestGetTeamMemberTokenUsage(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"auth0|user","exp":9999999999}`))
+	token, err := valueobject.NewCursorToken("header." + payload + ".signature")
+	if err != nil {
+		t.Fatalf("NewCursorToken() error = %v", err)
+	}
+
+	now := time.Now()
+	startHour := (now.Hour() + 23) % 24
+	tokensByUser := map[int]int{7: 100, 8: 250}
+	var usageRequests []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/dashboard/teams":
+			_, _ = fmt.Fprint(w, `{"teams":[{"id":1,"name":"team","role":"owner"}]}`)
+		case "/api/dashboard/team":
+			_, _ = fmt.Fprint(w, `{"userId":7,"teamMembers":[`+
+				`{"id":7,"name":"Alice","email":"alice@example.com","role":"owner"},`+
+				`{"id":8,"name":"Bob","email":"bob@example.com","role":"member"},`+
+				`{"id":9,"name":"Carol","email":"carol@example.com","role":"member"}]}`)
+		case "/api/dashboard/get-filtered-usage-events":
+			var request struct {
+				UserID int `json:"userId"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			usageRequests = append(usageRequests, request.UserID)
+			if _, ok := tokensByUser[request.UserID]; !ok {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"usageEventsDisplay": []map[string]interface{}{{
+				"timestamp":        strconv.FormatInt(now.Add(-10*time.Minute).UnixMilli(), 10),
+				"isTokenBasedCall": true,
+				"tokenUsage":       map[string]int{"inputTokens": tokensByUser[request.UserID]},
+			}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	repo := NewCursorAPIRepository(5*time.Second, WithDayStartHour(startHour), WithBaseURL(server.URL)).(*CursorAPIRepository)
+
+	usage, err := repo.GetTeamMemberTokenUsage(token, nil)
+	if err != nil {
+		t.Fatalf("GetTeamMemberTokenUsage() error = %v", err)
+	}
+	if len(usage) != 3 {
+		t.Fatalf("GetTeamMemberTokenUsage() returned %d members, want 3", len(usage))
+	}
+	if usage[0].Email != "alice@example.com" || usage[0].Tokens != 100 || usage[0].Err != nil {
+		t.Errorf("first member = %+v, want alice@example.com with 100 tokens", usage[0])
+	}
+	if usage[1].Email != "bob@example.com" || usage[1].Tokens != 250 || usage[1].Err != nil {
+		t.Errorf("second member = %+v, want bob@example.com with 250 tokens", usage[1])
+	}
+	// A member whose usage can't be read doesn't fail the others
+	if usage[2].Email != "carol@example.com" || usage[2].Err == nil {
+		t.Errorf("third member = %+v, want carol@example.com with an error", usage[2])
+	}
+
+	// Only the selected members' usage is read
+	usageRequests = nil
+	usage, err = repo.GetTeamMemberTokenUsage(token, func(roster []repository.TeamMemberTokenUsage) []repository.TeamMemberTokenUsage {
+		return roster[1:2]
+	})
+	if err != nil {
+		t.Fatalf("GetTeamMemberTokenUsage() with a selector error = %v", err)
+	}
+	if len(usage) != 1 || usage[0].Email != "bob@example.com" || usage[0].Tokens != 250 {
+		t.Errorf("selected members = %+v, want only bob@example.com with 250 tokens", usage)
+	}
+	if len(usageRequests) != 1 || usageRequests[0] != 8 {
+		t.Errorf("usage requested for members %v, want only 8", usageRequests)
+	}
+}
+
+func TestParseInvoiceItem_CallKinds(t *testing.T) {
+	repo := NewCursorAPIRepository(5 * time.Second).(*CursorAPIRepository)
+	cents := 1234
+
+	tests := []struct {
+		description    string
+		wantCount      int
+		wantToolCall   bool
+		wantTokenBased bool
+	}{
+		{"123 token-based usage calls to claude-3-opus, totalling: $12.34", 123, false, true},
+		{"45 tool calls", 45, true, false},
+		{"10 extra fast premium requests (Haiku)", 10, false, false},
+	}
+	for _, tt := range tests {
+		item := repo.parseInvoiceItem(struct {
+			Description string `json:"description"`
+			Cents       *int   `json:"cents"`
+		}{Description: tt.description, Cents: &cents})
+		if item == nil {
+			t.Fatalf("parseInvoiceItem(%q) = nil", tt.description)
+		}
+		if item.RequestCount != tt.wantCount || item.IsToolCall != tt.wantToolCall || item.IsTokenBased != tt.wantTokenBased {
+			t.Errorf("parseInvoiceItem(%q) = %d calls, tool call %v, token-based %v; want %d, %v, %v",
+				tt.description, item.RequestCount, item.IsToolCall, item.IsTokenBased, tt.wantCount, tt.wantToolCall, tt.wantTokenBased)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ca-srg/tosage/domain"
+	"github.com/ca-srg/tosage/domain/valueobject"
+)
+
+const (
+	// cursorCSRFHeader carries the CSRF token on requests once Cursor asks for one
+	cursorCSRFHeader = "X-CSRF-Token"
+	// cursorCSRFPath returns a CSRF token for the session
+	cursorCSRFPath = "/api/auth/csrf"
+)
+
+// cursorCSRFState is the CSRF token fetched after Cursor rejected a request, attached to
+// every later request. The zero value attaches nothing.
+type cursorCSRFState struct {
+	token  string
+	cookie *http.Cookie
+}
+
+// setCursorHeaders sets the session cookie and the headers Cursor's CSRF check expects.
+// All requests to the Cursor API go through here, so the header logic lives in one place.
+func (r *CursorAPIRepository) setCursorHeaders(req *http.Request, token *valueobject.CursorToken) {
+	cookie := fmt.Sprintf("WorkosCursorSessionToken=%s", token.SessionToken())
+
+	r.csrfMu.Lock()
+	csrf := r.csrf
+	r.csrfMu.Unlock()
+	if csrf.cookie != nil {
+		cookie += "; " + csrf.cookie.Name + "=" + csrf.cookie.Value
+	}
+	req.Header.Set("Cookie", cookie)
+
+	// Origin and Referer pass the same-origin CSRF check
+	req.Header.Set("Origin", r.baseURL)
+	req.Header.Set("Referer", r.baseURL+"/")
+	if csrf.token != "" {
+		req.Header.Set(cursorCSRFHeader, csrf.token)
+	}
+}
+
+// isCSRFRejection reports whether a response is Cursor rejecting the request's CSRF check
+// rather than its credentials or payload
+func isCSRFRejection(resp *http.Response, body []byte) bool {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != 419 {
+		return false
+	}
+	if strings.EqualFold(resp.Header.Get(cursorCSRFHeader), "required") {
+		return true
+	}
+	return strings.Contains(strings.ToLower(string(body)), "csrf")
+}
+
+// refreshCSRFToken fetches a CSRF token for the session and attaches it to later requests.
+// The token is read from the X-CSRF-Token header, a csrfToken/csrf_token/token JSON field,
+// or a cookie whose name contains "csrf".
+func (r *CursorAPIRepository) refreshCSRFToken(ctx context.Context, token *valueobject.CursorToken) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", r.baseURL+cursorCSRFPath, nil)
+	if err != nil {
+		return domain.ErrCursorAPIWithCause("create CSRF token request", err)
+	}
+	r.setCursorHeaders(req, token)
+
+	if err := r.waitForRateLimit(ctx); err != nil {
+		return err
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return domain.ErrCursorAPIWithCause("execute CSRF token request", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return domain.ErrCursorAPI(cursorCSRFPath, resp.StatusCode, string(body))
+	}
+
+	var state cursorCSRFState
+	for _, cookie := range resp.Cookies() {
+		if strings.Contains(strings.ToLower(cookie.Name), "csrf") {
+			state.cookie = cookie
+			state.token = cookie.Value
+		}
+	}
+	if header := resp.Header.Get(cursorCSRFHeader); header != "" {
+		state.token = header
+	} else {
+		var fields struct {
+			CSRFToken      string `json:"csrfToken"`
+			CSRFTokenSnake string `json:"csrf_token"`
+			Token          string `json:"token"`
+		}
+		if json.Unmarshal(body, &fields) == nil {
+			for _, value := range []string{fields.CSRFToken, fields.CSRFTokenSnake, fields.Token} {
+				if value != "" {
+					state.token = value
+					break
+				}
+			}
+		}
+	}
+	if state.token == "" {
+		return domain.ErrCursorAPIWithCause("read CSRF token", fmt.Errorf("no token in the response of %s", cursorCSRFPath))
+	}
+
+	r.csrfMu.Lock()
+	r.csrf = state
+	r.csrfMu.Unlock()
+	return nil
+}
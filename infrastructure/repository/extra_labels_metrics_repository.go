@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+// ExtraLabelsMetricsRepository wraps another MetricsRepository and adds the configured
+// static labels to every series. Labels passed by the caller take precedence.
+type ExtraLabelsMetricsRepository struct {
+	delegate repository.MetricsRepository
+	labels   map[string]string
+}
+
+// NewExtraLabelsMetricsRepository creates a labeling repository in front of delegate
+func NewExtraLabelsMetricsRepository(delegate repository.MetricsRepository, cfg *config.PrometheusConfig) (*ExtraLabelsMetricsRepository, error) {
+	if delegate == nil {
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("delegate metrics repository is nil"))
+	}
+	if cfg == nil {
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("prometheus config is nil"))
+	}
+
+	labels := make(map[string]string, len(cfg.ExtraLabels))
+	for name, value := range cfg.ExtraLabels {
+		labels[name] = value
+	}
+	return &ExtraLabelsMetricsRepository{
+		delegate: delegate,
+		labels:   labels,
+	}, nil
+}
+
+// AddDefaultLabels adds labels that are not configured already, e.g. those detected
+// from the cloud metadata service. Configured extra labels take precedence.
+func (r *ExtraLabelsMetricsRepository) AddDefaultLabels(labels map[string]string) {
+	for name, value := range labels {
+		if _, exists := r.labels[name]; !exists {
+			r.labels[name] = value
+		}
+	}
+}
+
+// SendTokenMetric forwards the metric to the delegate with the extra labels
+func (r *ExtraLabelsMetricsRepository) SendTokenMetric(totalTokens int64, hostLabel string, metricName string) error {
+	return r.delegate.SendTokenMetricWithLabels(totalTokens, hostLabel, metricName, r.merge(nil), nil)
+}
+
+// SendTokenMetricWithTimezone forwards the metric to the delegate with the extra labels
+func (r *ExtraLabelsMetricsRepository) SendTokenMetricWithTimezone(totalTokens int64, hostLabel string, metricName string, timezoneInfo repository.TimezoneInfo) error {
+	return r.delegate.SendTokenMetricWithLabels(totalTokens, hostLabel, metricName, r.merge(nil), &timezoneInfo)
+}
+
+// SendTokenMetricWithLabels forwards the metric to the delegate with the extra labels
+func (r *ExtraLabelsMetricsRepository) SendTokenMetricWithLabels(totalTokens int64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	return r.delegate.SendTokenMetricWithLabels(totalTokens, hostLabel, metricName, r.merge(labels), timezoneInfo)
+}
+
+// SendMetricValue forwards the value to the delegate with the extra labels
+func (r *ExtraLabelsMetricsRepository) SendMetricValue(value float64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	return sendMetricValue(r.delegate, value, hostLabel, metricName, r.merge(labels), timezoneInfo)
+}
+
+// CheckConnection checks the delegate's backend, if it supports checks
+func (r *ExtraLabelsMetricsRepository) CheckConnection(ctx context.Context) error {
+	return checkMetricsConnection(ctx, r.delegate)
+}
+
+// ExpireMetrics expires the metrics in the delegate
+func (r *ExtraLabelsMetricsRepository) ExpireMetrics(metricNames ...string) {
+	expireMetrics(r.delegate, metricNames...)
+}
+
+// Close closes the delegate
+func (r *ExtraLabelsMetricsRepository) Close() error {
+	return r.delegate.Close()
+}
+
+// merge returns the extra labels overlaid with the caller's labels
+func (r *ExtraLabelsMetricsRepository) merge(labels map[string]string) map[string]string {
+	merged := make(map[string]string, len(r.labels)+len(labels))
+	for name, value := range r.labels {
+		merged[name] = value
+	}
+	for name, value := range labels {
+		merged[name] = value
+	}
+	return merged
+}
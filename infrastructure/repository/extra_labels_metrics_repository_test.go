@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+func TestExtraLabelsMetricsRepository(t *testing.T) {
+	scrapeRepo := newTestScrapeRepository(t)
+	repo, err := NewExtraLabelsMetricsRepository(scrapeRepo, &config.PrometheusConfig{
+		ExtraLabels: map[string]string{"team": "platform", "model": "default"},
+	})
+	if err != nil {
+		t.Fatalf("NewExtraLabelsMetricsRepository() error = %v", err)
+	}
+
+	if err := repo.SendTokenMetric(100, "", "tosage_cc_token"); err != nil {
+		t.Fatalf("SendTokenMetric() error = %v", err)
+	}
+	timezoneInfo := repository.TimezoneInfo{Name: "UTC", Offset: "+00:00", DetectionMethod: "config"}
+	if err := repo.SendTokenMetricWithTimezone(200, "", "tosage_cursor_token", timezoneInfo); err != nil {
+		t.Fatalf("SendTokenMetricWithTimezone() error = %v", err)
+	}
+	if err := repo.SendTokenMetricWithLabels(300, "", "tosage_cc_model_token", map[string]string{"model": "opus"}, nil); err != nil {
+		t.Fatalf("SendTokenMetricWithLabels() error = %v", err)
+	}
+
+	_, body := scrape(t, scrapeRepo, "")
+
+	for _, want := range []string{
+		`tosage_cc_token{host="test-host",model="default",team="platform"} 100`,
+		`tosage_cursor_token{detection_method="config",host="test-host",model="default",team="platform",timezone="UTC",timezone_offset="+00:00"} 200`,
+		`tosage_cc_model_token{model="opus",team="platform"} 300`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("series %s not found in:\n%s", want, body)
+		}
+	}
+}
+
+func TestExtraLabelsMetricsRepository_AddDefaultLabels(t *testing.T) {
+	scrapeRepo := newTestScrapeRepository(t)
+	cfg := &config.PrometheusConfig{ExtraLabels: map[string]string{"zone": "office"}}
+	repo, err := NewExtraLabelsMetricsRepository(scrapeRepo, cfg)
+	if err != nil {
+		t.Fatalf("NewExtraLabelsMetricsRepository() error = %v", err)
+	}
+	repo.AddDefaultLabels(map[string]string{"instance_id": "i-0abc", "zone": "us-east-1a"})
+
+	if err := repo.SendTokenMetric(100, "", "tosage_cc_token"); err != nil {
+		t.Fatalf("SendTokenMetric() error = %v", err)
+	}
+
+	_, body := scrape(t, scrapeRepo, "")
+	want := `tosage_cc_token{host="test-host",instance_id="i-0abc",zone="office"} 100`
+	if !strings.Contains(body, want) {
+		t.Errorf("series %s not found in:\n%s", want, body)
+	}
+	if len(cfg.ExtraLabels) != 1 {
+		t.Errorf("configured extra labels changed to %v", cfg.ExtraLabels)
+	}
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+// dashboardMetric is a metric tosage emits, with how the generated dashboard charts it
+type dashboardMetric struct {
+	name string
+	// group is the dashboard row the panel goes in
+	group string
+	// by lists the labels the panel keeps when aggregating
+	by []string
+	// unit is the Grafana unit of the panel
+	unit string
+	// age charts the seconds since the Unix timestamp the metric holds instead of the timestamp
+	age bool
+	// enabled reports whether the configuration makes tosage send the metric
+	enabled func(cfg *config.AppConfig, sources DashboardSources) bool
+}
+
+// DashboardSources are the providers the dashboard includes besides Claude Code and Cursor
+type DashboardSources struct {
+	Bedrock  bool
+	VertexAI bool
+}
+
+const (
+	dashboardGroupClaudeCode = "Claude Code"
+	dashboardGroupCursor     = "Cursor"
+	dashboardGroupBedrock    = "AWS Bedrock"
+	dashboardGroupVertexAI   = "Google Vertex AI"
+	dashboardGroupTosage     = "tosage"
+
+	dashboardUnitTokens  = "locale"
+	dashboardUnitSeconds = "s"
+	dashboardUnitMillis  = "ms"
+	dashboardUnitNone    = "none"
+	dashboardUnitDollars = "currencyUSD"
+)
+
+func always(*config.AppConfig, DashboardSources) bool { return true }
+
+func cursorOption(option func(*config.CursorConfig) bool) func(*config.AppConfig, DashboardSources) bool {
+	return func(cfg *config.AppConfig, _ DashboardSources) bool {
+		return cfg.Cursor != nil && option(cfg.Cursor)
+	}
+}
+
+func prometheusOption(option func(*config.PrometheusConfig) bool) func(*config.AppConfig, DashboardSources) bool {
+	return func(cfg *config.AppConfig, _ DashboardSources) bool {
+		return cfg.Prometheus != nil && option(cfg.Prometheus)
+	}
+}
+
+func bedrockSource(_ *config.AppConfig, sources DashboardSources) bool  { return sources.Bedrock }
+func vertexAISource(_ *config.AppConfig, sources DashboardSources) bool { return sources.VertexAI }
+
+func vertexAIRequestMetrics(cfg *config.AppConfig, sources DashboardSources) bool {
+	return sources.VertexAI && cfg.VertexAI != nil && cfg.VertexAI.RequestMetrics
+}
+
+func vertexAIRequestMetricsPerModel(cfg *config.AppConfig, sources DashboardSources) bool {
+	return vertexAIRequestMetrics(cfg, sources) && cfg.VertexAI.RequestMetricsPerModel
+}
+
+// dashboardMetrics lists every metric in scrapeMetricHelp in dashboard order
+var dashboardMetrics = []dashboardMetric{
+	{name: "tosage_cc_token", group: dashboardGroupClaudeCode, by: []string{"host"}, unit: dashboardUnitTokens, enabled: always},
+	{name: "tosage_cc_info", group: dashboardGroupClaudeCode, by: []string{"host"}, unit: dashboardUnitNone, enabled: prometheusOption(func(p *config.PrometheusConfig) bool { return len(p.DerivedLabels) > 0 })},
+	{name: "tosage_cc_token_all", group: dashboardGroupClaudeCode, by: []string{"host"}, unit: dashboardUnitTokens, enabled: func(cfg *config.AppConfig, _ DashboardSources) bool {
+		return !cfg.TotalTokenComponents().IsAll()
+	}},
+	{name: "tosage_cc_tokens_delta", group: dashboardGroupClaudeCode, by: []string{"host"}, unit: dashboardUnitTokens, enabled: prometheusOption(func(p *config.PrometheusConfig) bool { return p.CcTokensDelta })},
+	{name: "tosage_cc_session_token", group: dashboardGroupClaudeCode, by: []string{"host", "session"}, unit: dashboardUnitTokens, enabled: prometheusOption(func(p *config.PrometheusConfig) bool { return p.SessionMetricsTopN > 0 })},
+	{name: "tosage_cc_session_tokens_p50", group: dashboardGroupClaudeCode, by: []string{"host"}, unit: dashboardUnitTokens, enabled: prometheusOption(func(p *config.PrometheusConfig) bool { return p.SessionPercentiles })},
+	{name: "tosage_cc_session_tokens_p90", group: dashboardGroupClaudeCode, by: []string{"host"}, unit: dashboardUnitTokens, enabled: prometheusOption(func(p *config.PrometheusConfig) bool { return p.SessionPercentiles })},
+	{name: "tosage_cc_session_tokens_p99", group: dashboardGroupClaudeCode, by: []string{"host"}, unit: dashboardUnitTokens, enabled: prometheusOption(func(p *config.PrometheusConfig) bool { return p.SessionPercentiles })},
+	{name: "tosage_cc_session_tokens_max", group: dashboardGroupClaudeCode, by: []string{"host"}, unit: dashboardUnitTokens, enabled: prometheusOption(func(p *config.PrometheusConfig) bool { return p.SessionPercentiles })},
+	{name: "tosage_cc_source_path_token", group: dashboardGroupClaudeCode, by: []string{"host", "source_path"}, unit: dashboardUnitTokens, enabled: prometheusOption(func(p *config.PrometheusConfig) bool { return p.SourcePathLabel })},
+	{name: "tosage_cc_unique_projects", group: dashboardGroupClaudeCode, by: []string{"host"}, unit: dashboardUnitNone, enabled: prometheusOption(func(p *config.PrometheusConfig) bool { return p.UniqueCountMetrics })},
+	{name: "tosage_cc_unique_models", group: dashboardGroupClaudeCode, by: []string{"host"}, unit: dashboardUnitNone, enabled: prometheusOption(func(p *config.PrometheusConfig) bool { return p.UniqueCountMetrics })},
+	{name: "tosage_cc_unique_sessions", group: dashboardGroupClaudeCode, by: []string{"host"}, unit: dashboardUnitNone, enabled: prometheusOption(func(p *config.PrometheusConfig) bool { return p.UniqueCountMetrics })},
+	{name: "tosage_cc_last_entry_age_seconds", group: dashboardGroupClaudeCode, by: []string{"host"}, unit: dashboardUnitSeconds, enabled: always},
+	{name: "tosage_cc_stale", group: dashboardGroupClaudeCode, by: []string{"host"}, unit: dashboardUnitNone, enabled: func(cfg *config.AppConfig, _ DashboardSources) bool {
+		return cfg.MaxDataStalenessSec > 0
+	}},
+
+	{name: "tosage_cursor_token", group: dashboardGroupCursor, by: []string{"host"}, unit: dashboardUnitTokens, enabled: always},
+	{name: "tosage_cursor_billing_period_token", group: dashboardGroupCursor, by: []string{"host"}, unit: dashboardUnitTokens, enabled: always},
+	{name: "tosage_cursor_parse_ok", group: dashboardGroupCursor, by: []string{"host"}, unit: dashboardUnitNone, enabled: always},
+	{name: "tosage_cursor_billing_cycle_token", group: dashboardGroupCursor, by: []string{"host"}, unit: dashboardUnitTokens, enabled: cursorOption(func(c *config.CursorConfig) bool { return c.BillingCycleMetric })},
+	{name: "tosage_cursor_premium_requests", group: dashboardGroupCursor, by: []string{"host"}, unit: dashboardUnitNone, enabled: cursorOption(func(c *config.CursorConfig) bool { return c.PremiumRequestMetrics })},
+	{name: "tosage_cursor_premium_requests_limit", group: dashboardGroupCursor, by: []string{"host"}, unit: dashboardUnitNone, enabled: cursorOption(func(c *config.CursorConfig) bool { return c.PremiumRequestMetrics })},
+	{name: "tosage_cursor_usage_cost_cents", group: dashboardGroupCursor, by: []string{"host", "month"}, unit: dashboardUnitNone, enabled: cursorOption(func(c *config.CursorConfig) bool { return c.UsageCostMetrics })},
+	{name: "tosage_cursor_mid_month_payment_cents", group: dashboardGroupCursor, by: []string{"host", "month"}, unit: dashboardUnitNone, enabled: cursorOption(func(c *config.CursorConfig) bool { return c.UsageCostMetrics })},
+	{name: "tosage_cursor_unpaid_invoice", group: dashboardGroupCursor, by: []string{"host", "month"}, unit: dashboardUnitNone, enabled: cursorOption(func(c *config.CursorConfig) bool { return c.UsageCostMetrics })},
+	{name: "tosage_cursor_tool_calls", group: dashboardGroupCursor, by: []string{"host"}, unit: dashboardUnitNone, enabled: cursorOption(func(c *config.CursorConfig) bool { return c.CallCountMetrics })},
+	{name: "tosage_cursor_token_based_calls", group: dashboardGroupCursor, by: []string{"host"}, unit: dashboardUnitNone, enabled: cursorOption(func(c *config.CursorConfig) bool { return c.CallCountMetrics })},
+	{name: "tosage_cursor_usage_based_enabled", group: dashboardGroupCursor, by: []string{"host"}, unit: dashboardUnitNone, enabled: cursorOption(func(c *config.CursorConfig) bool { return c.UsageBasedStatusMetrics })},
+	{name: "tosage_cursor_member_token", group: dashboardGroupCursor, by: []string{"user"}, unit: dashboardUnitTokens, enabled: cursorOption(func(c *config.CursorConfig) bool { return c.TeamMemberMetrics })},
+	{name: "tosage_cursor_spend_limit_dollars", group: dashboardGroupCursor, by: []string{"host"}, unit: dashboardUnitDollars, enabled: cursorOption(func(c *config.CursorConfig) bool { return c.UsageBasedStatusMetrics })},
+
+	{name: "tosage_bedrock_input_token", group: dashboardGroupBedrock, by: []string{"host"}, unit: dashboardUnitTokens, enabled: bedrockSource},
+	{name: "tosage_bedrock_output_token", group: dashboardGroupBedrock, by: []string{"host"}, unit: dashboardUnitTokens, enabled: bedrockSource},
+	{name: "tosage_bedrock_total_token", group: dashboardGroupBedrock, by: []string{"host"}, unit: dashboardUnitTokens, enabled: bedrockSource},
+	{name: "tosage_bedrock_model_input_token", group: dashboardGroupBedrock, by: []string{"model"}, unit: dashboardUnitTokens, enabled: bedrockSource},
+	{name: "tosage_bedrock_model_output_token", group: dashboardGroupBedrock, by: []string{"model"}, unit: dashboardUnitTokens, enabled: bedrockSource},
+	{name: "tosage_bedrock_model_total_token", group: dashboardGroupBedrock, by: []string{"model"}, unit: dashboardUnitTokens, enabled: bedrockSource},
+
+	{name: "tosage_vertex_ai_input_token", group: dashboardGroupVertexAI, by: []string{"host"}, unit: dashboardUnitTokens, enabled: vertexAISource},
+	{name: "tosage_vertex_ai_output_token", group: dashboardGroupVertexAI, by: []string{"host"}, unit: dashboardUnitTokens, enabled: vertexAISource},
+	{name: "tosage_vertex_ai_total_token", group: dashboardGroupVertexAI, by: []string{"host"}, unit: dashboardUnitTokens, enabled: vertexAISource},
+	{name: "tosage_vertex_ai_model_input_token", group: dashboardGroupVertexAI, by: []string{"model"}, unit: dashboardUnitTokens, enabled: vertexAISource},
+	{name: "tosage_vertex_ai_model_output_token", group: dashboardGroupVertexAI, by: []string{"model"}, unit: dashboardUnitTokens, enabled: vertexAISource},
+	{name: "tosage_vertex_ai_model_total_token", group: dashboardGroupVertexAI, by: []string{"model"}, unit: dashboardUnitTokens, enabled: vertexAISource},
+	{name: "tosage_vertex_ai_request_count", group: dashboardGroupVertexAI, by: []string{"host"}, unit: dashboardUnitNone, enabled: vertexAIRequestMetrics},
+	{name: "tosage_vertex_ai_latency_ms", group: dashboardGroupVertexAI, by: []string{"host"}, unit: dashboardUnitMillis, enabled: vertexAIRequestMetrics},
+	{name: "tosage_vertex_ai_model_request_count", group: dashboardGroupVertexAI, by: []string{"model"}, unit: dashboardUnitNone, enabled: vertexAIRequestMetricsPerModel},
+	{name: "tosage_vertex_ai_model_latency_ms", group: dashboardGroupVertexAI, by: []string{"model"}, unit: dashboardUnitMillis, enabled: vertexAIRequestMetricsPerModel},
+
+	{name: "tosage_up", group: dashboardGroupTosage, by: []string{"host"}, unit: dashboardUnitNone, enabled: always},
+	{name: "tosage_collection_duration_seconds", group: dashboardGroupTosage, by: []string{"host", "source"}, unit: dashboardUnitSeconds, enabled: always},
+	{name: "tosage_last_collection_timestamp_seconds", group: dashboardGroupTosage, by: []string{"host"}, unit: dashboardUnitSeconds, age: true, enabled: always},
+	{name: "tosage_remote_write_circuit_open", group: dashboardGroupTosage, by: []string{"host"}, unit: dashboardUnitNone, enabled: prometheusOption(func(p *config.PrometheusConfig) bool { return p.CircuitBreakerThreshold > 0 })},
+}
+
+const (
+	dashboardPanelWidth  = 12
+	dashboardPanelHeight = 8
+	dashboardGridWidth   = 24
+)
+
+// BuildGrafanaDashboard returns a Grafana dashboard JSON with a panel for each metric the
+// configuration makes tosage send. Panels query the name the metric is sent as after
+// any rename_to, and metrics dropped by the allowlist or denylist are left out.
+func BuildGrafanaDashboard(cfg *config.AppConfig, sources DashboardSources) ([]byte, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config is nil")
+	}
+
+	var allowlist, denylist []string
+	if cfg.Prometheus != nil {
+		allowlist, denylist = cfg.Prometheus.MetricAllowlist, cfg.Prometheus.MetricDenylist
+	}
+	filter := &MetricFilterMetricsRepository{allowlist: allowlist, denylist: denylist}
+
+	var panels []map[string]interface{}
+	id, y := 1, 0
+	group, column := "", 0
+	for _, metric := range dashboardMetrics {
+		if !metric.enabled(cfg, sources) || !filter.allows(metric.name) {
+			continue
+		}
+		if metric.group != group {
+			if column > 0 {
+				y += dashboardPanelHeight
+			}
+			group, column = metric.group, 0
+			panels = append(panels, dashboardRow(id, group, y))
+			id++
+			y++
+		}
+		panels = append(panels, dashboardPanel(id, metric, sentMetricName(cfg, metric.name), column*dashboardPanelWidth, y))
+		id++
+		column++
+		if column*dashboardPanelWidth >= dashboardGridWidth {
+			y += dashboardPanelHeight
+			column = 0
+		}
+	}
+
+	dashboard := map[string]interface{}{
+		"title":         "tosage",
+		"uid":           "tosage",
+		"tags":          []string{"tosage"},
+		"editable":      true,
+		"schemaVersion": 39,
+		"time":          map[string]string{"from": "now-7d", "to": "now"},
+		"timezone":      "browser",
+		"templating": map[string]interface{}{
+			"list": []map[string]interface{}{{
+				"name":  "datasource",
+				"label": "Data source",
+				"type":  "datasource",
+				"query": "prometheus",
+			}},
+		},
+		"panels": panels,
+	}
+	return json.MarshalIndent(dashboard, "", "  ")
+}
+
+// sentMetricName returns the name a metric is sent as after its transform's rename_to
+func sentMetricName(cfg *config.AppConfig, name string) string {
+	if cfg.Prometheus != nil {
+		if transform := cfg.Prometheus.Transforms[name]; transform != nil && transform.RenameTo != "" {
+			return transform.RenameTo
+		}
+	}
+	return name
+}
+
+// dashboardRow returns a row header panel
+func dashboardRow(id int, title string, y int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":        id,
+		"type":      "row",
+		"title":     title,
+		"collapsed": false,
+		"gridPos":   map[string]int{"h": 1, "w": dashboardGridWidth, "x": 0, "y": y},
+		"panels":    []interface{}{},
+	}
+}
+
+// dashboardPanel returns a time series panel charting one metric
+func dashboardPanel(id int, metric dashboardMetric, sentName string, x, y int) map[string]interface{} {
+	datasource := map[string]string{"type": "prometheus", "uid": "${datasource}"}
+	legend := make([]string, len(metric.by))
+	for i, label := range metric.by {
+		legend[i] = "{{" + label + "}}"
+	}
+	expr := fmt.Sprintf("max by(%s) (%s)", strings.Join(metric.by, ", "), sentName)
+	if metric.age {
+		expr = "time() - " + expr
+	}
+
+	return map[string]interface{}{
+		"id":          id,
+		"type":        "timeseries",
+		"title":       sentName,
+		"description": scrapeMetricHelp[metric.name],
+		"datasource":  datasource,
+		"gridPos":     map[string]int{"h": dashboardPanelHeight, "w": dashboardPanelWidth, "x": x, "y": y},
+		"fieldConfig": map[string]interface{}{
+			"defaults": map[string]interface{}{
+				"unit": metric.unit,
+				"min":  0,
+			},
+			"overrides": []interface{}{},
+		},
+		"options": map[string]interface{}{
+			"legend":  map[string]interface{}{"displayMode": "table", "placement": "right", "showLegend": true, "calcs": []string{"lastNotNull"}},
+			"tooltip": map[string]string{"mode": "multi", "sort": "desc"},
+		},
+		"targets": []map[string]interface{}{{
+			"datasource":   datasource,
+			"expr":         expr,
+			"legendFormat": strings.Join(legend, " "),
+			"range":        true,
+			"refId":        "A",
+		}},
+	}
+}
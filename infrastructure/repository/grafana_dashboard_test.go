@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+func TestDashboardMetrics_MatchRegistry(t *testing.T) {
+	listed := make(map[string]bool)
+	for _, metric := range dashboardMetrics {
+		if _, ok := scrapeMetricHelp[metric.name]; !ok {
+			t.Errorf("dashboard metric %s has no HELP text", metric.name)
+		}
+		listed[metric.name] = true
+	}
+	for name := range scrapeMetricHelp {
+		if !listed[name] {
+			t.Errorf("metric %s is missing from the dashboard", name)
+		}
+	}
+}
+
+func TestBuildGrafanaDashboard(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Prometheus.MetricDenylist = []string{"tosage_cursor_billing_period_token"}
+	cfg.Prometheus.Transforms = map[string]*config.MetricTransformConfig{
+		"tosage_cc_token": {Multiplier: 0.001, RenameTo: "tosage_cc_ktoken"},
+	}
+	cfg.Prometheus.SessionMetricsTopN = 5
+
+	out, err := BuildGrafanaDashboard(cfg, DashboardSources{Bedrock: true})
+	if err != nil {
+		t.Fatalf("BuildGrafanaDashboard() error = %v", err)
+	}
+	var dashboard struct {
+		Panels []struct {
+			Type    string `json:"type"`
+			Title   string `json:"title"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	if err := json.Unmarshal(out, &dashboard); err != nil {
+		t.Fatalf("dashboard is not valid JSON: %v", err)
+	}
+
+	var rows, exprs []string
+	for _, panel := range dashboard.Panels {
+		if panel.Type == "row" {
+			rows = append(rows, panel.Title)
+			continue
+		}
+		exprs = append(exprs, panel.Targets[0].Expr)
+	}
+	all := strings.Join(exprs, "\n")
+
+	for _, want := range []string{"max by(host) (tosage_cc_ktoken)", "max by(host, session) (tosage_cc_session_token)", "max by(host) (tosage_bedrock_total_token)"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("queries do not contain %q:\n%s", want, all)
+		}
+	}
+	for _, unwanted := range []string{"(tosage_cc_token)", "tosage_cursor_billing_period_token", "tosage_vertex_ai", "tosage_cc_session_tokens_p50", "tosage_cursor_premium_requests"} {
+		if strings.Contains(all, unwanted) {
+			t.Errorf("queries contain %q:\n%s", unwanted, all)
+		}
+	}
+	if got := strings.Join(rows, ","); got != "Claude Code,Cursor,AWS Bedrock,tosage" {
+		t.Errorf("rows = %s", got)
+	}
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/ca-srg/tosage/domain/entity"
+	"github.com/ca-srg/tosage/domain/repository"
+)
+
+// JSONMetricsStateRepository persists metrics state in a JSON file
+type JSONMetricsStateRepository struct {
+	statePath string
+}
+
+// NewJSONMetricsStateRepository creates a new JSONMetricsStateRepository.
+// If statePath is empty, ~/.config/tosage/metrics_state.json is used.
+func NewJSONMetricsStateRepository(statePath string) repository.MetricsStateRepository {
+	if statePath == "" {
+		statePath, _ = DefaultMetricsStatePath("")
+	}
+	return &JSONMetricsStateRepository{
+		statePath: statePath,
+	}
+}
+
+// profileNamePattern matches the profile names allowed in a state file name
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// DefaultMetricsStatePath returns the default state file path for a daemon profile.
+// An empty profile name returns the path used by the top-level configuration. A name
+// that could leave the state directory, such as one containing "/" or "..", is an error.
+func DefaultMetricsStatePath(profile string) (string, error) {
+	homeDir, _ := os.UserHomeDir()
+	fileName := "metrics_state.json"
+	if profile != "" {
+		if !profileNamePattern.MatchString(profile) {
+			return "", fmt.Errorf("invalid profile name %q: only letters, digits, '_' and '-' are allowed", profile)
+		}
+		fileName = fmt.Sprintf("metrics_state.%s.json", profile)
+	}
+	return filepath.Join(homeDir, ".config", "tosage", fileName), nil
+}
+
+// Load reads the state file
+func (r *JSONMetricsStateRepository) Load() (*entity.MetricsState, error) {
+	data, err := os.ReadFile(r.statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entity.NewMetricsState(), nil
+		}
+		return entity.NewMetricsState(), fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	state := entity.NewMetricsState()
+	if err := json.Unmarshal(data, state); err != nil {
+		return entity.NewMetricsState(), fmt.Errorf("failed to parse state file %s: %w", r.statePath, err)
+	}
+
+	return state, nil
+}
+
+// Save writes the state file atomically
+func (r *JSONMetricsStateRepository) Save(state *entity.MetricsState) error {
+	if err := os.MkdirAll(filepath.Dir(r.statePath), 0700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	tmpFile := r.statePath + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write temp state file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile, r.statePath); err != nil {
+		_ = os.Remove(tmpFile)
+		return fmt.Errorf("failed to save state file: %w", err)
+	}
+
+	return nil
+}
+
+// GetStatePath returns the path of the state file
+func (r *JSONMetricsStateRepository) GetStatePath() string {
+	return r.statePath
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ca-srg/tosage/domain/entity"
+)
+
+func TestJSONMetricsStateRepository_MissingFile(t *testing.T) {
+	repo := NewJSONMetricsStateRepository(filepath.Join(t.TempDir(), "metrics_state.json"))
+
+	state, err := repo.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(state.Metrics) != 0 || len(state.Counters) != 0 {
+		t.Errorf("Load() = %+v, want empty state", state)
+	}
+}
+
+func TestJSONMetricsStateRepository_CorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics_state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatalf("failed to write state file: %v", err)
+	}
+	repo := NewJSONMetricsStateRepository(path)
+
+	state, err := repo.Load()
+	if err == nil {
+		t.Error("Load() error = nil, want parse error")
+	}
+	if state == nil || len(state.Metrics) != 0 {
+		t.Errorf("Load() = %+v, want fresh state", state)
+	}
+}
+
+func TestJSONMetricsStateRepository_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "metrics_state.json")
+	repo := NewJSONMetricsStateRepository(path)
+
+	state, _ := repo.Load()
+	sentAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	state.RecordSent("tosage_cc_token", 1234, sentAt)
+	state.AddToCounter("sends_total", 2)
+	state.SetDeltaBaseline("tosage_cc_tokens_delta", 1000, sentAt)
+	state.CursorPosition = &entity.CursorUsagePosition{
+		WindowStart:  sentAt.Truncate(24 * time.Hour),
+		LastEventAt:  sentAt,
+		Tokens:       42,
+		RecentEvents: []string{"event-1"},
+	}
+
+	if err := repo.Save(state); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("state file not written: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("state file permissions = %o, want 600", perm)
+	}
+
+	loaded, err := NewJSONMetricsStateRepository(path).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	last, ok := loaded.Last("tosage_cc_token")
+	if !ok {
+		t.Fatal("Last() did not find tosage_cc_token")
+	}
+	if last.LastValue != 1234 || !last.LastSentAt.Equal(sentAt) {
+		t.Errorf("Last() = %+v, want value 1234 at %v", last, sentAt)
+	}
+	if got := loaded.Counter("sends_total"); got != 2 {
+		t.Errorf("Counter(sends_total) = %v, want 2", got)
+	}
+	if delta, ok := loaded.Delta("tosage_cc_tokens_delta", 1500); !ok || delta != 500 {
+		t.Errorf("Delta(tosage_cc_tokens_delta, 1500) = %v, %v, want 500 from the saved baseline", delta, ok)
+	}
+	if !reflect.DeepEqual(loaded.CursorPosition, state.CursorPosition) {
+		t.Errorf("CursorPosition = %+v, want %+v", loaded.CursorPosition, state.CursorPosition)
+	}
+	if !loaded.UpdatedAt.Equal(state.UpdatedAt) {
+		t.Errorf("UpdatedAt = %v, want %v", loaded.UpdatedAt, state.UpdatedAt)
+	}
+}
+
+func TestDefaultMetricsStatePath(t *testing.T) {
+	path, err := DefaultMetricsStatePath("team-a_1")
+	if err != nil {
+		t.Fatalf("DefaultMetricsStatePath() error = %v", err)
+	}
+	if filepath.Base(path) != "metrics_state.team-a_1.json" {
+		t.Errorf("DefaultMetricsStatePath() = %s, want metrics_state.team-a_1.json", path)
+	}
+
+	for _, name := range []string{"../x", "a/b", "a.b", "team a"} {
+		if _, err := DefaultMetricsStatePath(name); err == nil {
+			t.Errorf("DefaultMetricsStatePath(%q) error = nil, want invalid name", name)
+		}
+	}
+}
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
 	"time"
@@ -13,30 +16,200 @@ import (
 	"github.com/ca-srg/tosage/domain/entity"
 	"github.com/ca-srg/tosage/domain/repository"
 	"github.com/ca-srg/tosage/domain/valueobject"
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+const (
+	// parseErrorWarnRatio is the share of unparseable lines above which a file is reported
+	parseErrorWarnRatio = 0.1
+	// maxParseErrorSamples is the number of parse errors kept per file for diagnostics
+	maxParseErrorSamples = 3
+	// defaultMaxLineBytes is the longest JSONL line read when no limit is set
+	defaultMaxLineBytes = 10 * 1024 * 1024
+	// lineReadBufferSize is the initial read buffer of a file; only longer lines grow a line buffer
+	lineReadBufferSize = 64 * 1024
 )
 
 // JSONLCcRepository implements CcRepository using JSONL files
 type JSONLCcRepository struct {
-	claudePaths []string
-	cache       *ccCache
+	claudePaths    []string
+	mirrors        []CcMirror
+	cache          *ccCache
+	heuristicDedup bool
+	ignoreBefore   time.Time
+	parseWorkers   int
+	tokenCap       int
+	tokenCapAction string
+	maxLineBytes   int
+
+	// oversizedWarning reports skipped oversized lines once per process
+	oversizedWarning sync.Once
+
+	// findFiles walks a projects path; it is a field so tests can simulate a slow mount
+	findFiles   func(basePath string) ([]jsonlFile, error)
+	walkTimeout time.Duration
+	listingTTL  time.Duration
+	listingMu   sync.Mutex
+	listings    map[string]*fileListing
+}
+
+// fileListing is the last good file listing of a projects path and its in-flight walk
+type fileListing struct {
+	files    []jsonlFile
+	listedAt time.Time
+	walk     *listingWalk
+}
+
+// listingWalk is a walk of a projects path; done is closed when it finishes
+type listingWalk struct {
+	done chan struct{}
+	err  error
 }
 
 // ccCache holds cached cc entries
 type ccCache struct {
 	entries      []*entity.CcEntry
+	stats        JSONLLoadStats
 	lastModified time.Time
 	mu           sync.RWMutex
 }
 
+// JSONLLoadStats summarizes the last load of the JSONL files
+type JSONLLoadStats struct {
+	// Files is the number of JSONL files read
+	Files int
+	// Lines is the number of non-empty lines read
+	Lines int
+	// ParseErrors is the number of lines that were not valid JSON
+	ParseErrors int
+	// ConversionErrors is the number of parsed lines that could not be converted to an entry,
+	// such as summary lines without a timestamp
+	ConversionErrors int
+	// CappedEntries is the number of entries above the token cap that were skipped or clamped
+	CappedEntries int
+	// OversizedLines is the number of lines skipped for being longer than the line size limit
+	OversizedLines int
+	// FilesWithErrors holds the per-file counts of files that had parse errors
+	FilesWithErrors []JSONLFileStats
+}
+
+// JSONLFileStats holds the parse results of a single JSONL file
+type JSONLFileStats struct {
+	Path             string
+	Lines            int
+	ParseErrors      int
+	ConversionErrors int
+	CappedEntries    int
+	OversizedLines   int
+	// SampleErrors holds the first few parse errors with their line numbers
+	SampleErrors []string
+}
+
+// ParseErrorRatio returns the share of lines in the file that were not valid JSON
+func (s JSONLFileStats) ParseErrorRatio() float64 {
+	if s.Lines == 0 {
+		return 0
+	}
+	return float64(s.ParseErrors) / float64(s.Lines)
+}
+
 // NewJSONLCcRepository creates a new JSONL-based cc repository
 func NewJSONLCcRepository(customPath string) *JSONLCcRepository {
 	repo := &JSONLCcRepository{
-		cache: &ccCache{},
+		cache:    &ccCache{},
+		listings: make(map[string]*fileListing),
 	}
+	repo.findFiles = repo.findJSONLFiles
 	repo.claudePaths = repo.getClaudePaths(customPath)
 	return repo
 }
 
+// AddMirror also loads the copy a mirror keeps of another machine's data. The mirror is
+// synced before each load; if a sync fails, the last copy is used.
+func (r *JSONLCcRepository) AddMirror(mirror CcMirror) {
+	r.mirrors = append(r.mirrors, mirror)
+}
+
+// SetHeuristicDedup enables treating entries with matching timestamp, session and
+// token count as duplicates, in addition to matching message or request IDs
+func (r *JSONLCcRepository) SetHeuristicDedup(enabled bool) {
+	r.heuristicDedup = enabled
+
+	// Entries cached with the previous setting are no longer valid
+	r.cache.mu.Lock()
+	r.cache.entries = nil
+	r.cache.mu.Unlock()
+}
+
+// SetIgnoreBefore drops entries timestamped before t when loading. Files last modified
+// before t are not read at all. A zero time loads every entry.
+func (r *JSONLCcRepository) SetIgnoreBefore(t time.Time) {
+	r.ignoreBefore = t
+
+	// Entries and listings cached with the previous cutoff are no longer valid
+	r.cache.mu.Lock()
+	r.cache.entries = nil
+	r.cache.mu.Unlock()
+	r.listingMu.Lock()
+	r.listings = make(map[string]*fileListing)
+	r.listingMu.Unlock()
+}
+
+// SetParseWorkers sets how many JSONL files are parsed concurrently.
+// Zero or a negative value uses one worker per CPU.
+func (r *JSONLCcRepository) SetParseWorkers(workers int) {
+	r.parseWorkers = workers
+}
+
+// SetWalkTimeout bounds how long loading waits for a directory walk. When a walk
+// takes longer, the last good listing is used and the walk finishes in the background.
+// Zero waits for every walk to finish.
+func (r *JSONLCcRepository) SetWalkTimeout(timeout time.Duration) {
+	r.walkTimeout = timeout
+}
+
+// SetListingCacheTTL sets how long a file listing is reused before the directories are
+// walked again. Files in new sessions are only picked up once the listing expires.
+// Zero walks the directories on every load.
+func (r *JSONLCcRepository) SetListingCacheTTL(ttl time.Duration) {
+	r.listingTTL = ttl
+}
+
+// SetTokenCap guards totals against corrupt entries: an entry reporting more than
+// maxTokens tokens is skipped (config.TokenCapSkip) or scaled down to maxTokens (config.TokenCapClamp).
+// Zero disables the cap.
+func (r *JSONLCcRepository) SetTokenCap(maxTokens int, action string) {
+	r.tokenCap = maxTokens
+	r.tokenCapAction = action
+
+	// Entries cached with the previous cap are no longer valid
+	r.cache.mu.Lock()
+	r.cache.entries = nil
+	r.cache.mu.Unlock()
+}
+
+// SetMaxLineBytes sets the longest JSONL line read. Longer lines are skipped and reported.
+// Zero or a negative value uses the default of 10 MiB.
+func (r *JSONLCcRepository) SetMaxLineBytes(maxBytes int) {
+	r.maxLineBytes = maxBytes
+
+	// Entries cached with the previous limit are no longer valid
+	r.cache.mu.Lock()
+	r.cache.entries = nil
+	r.cache.mu.Unlock()
+}
+
+// lineLimit returns the longest line read in bytes
+func (r *JSONLCcRepository) lineLimit() int {
+	if r.maxLineBytes <= 0 {
+		return defaultMaxLineBytes
+	}
+	return r.maxLineBytes
+}
+
+// claudeConfigDirEnv is the environment variable Claude Code reads its data directory from
+const claudeConfigDirEnv = "CLAUDE_CONFIG_DIR"
+
 // getClaudePaths returns the paths to search for Claude data
 func (r *JSONLCcRepository) getClaudePaths(customPath string) []string {
 	var paths []string
@@ -45,6 +218,11 @@ func (r *JSONLCcRepository) getClaudePaths(customPath string) []string {
 		// Use custom path if provided
 		paths = append(paths, customPath)
 	} else {
+		// Claude Code relocates its data to CLAUDE_CONFIG_DIR when it is set
+		if configDir := os.Getenv(claudeConfigDirEnv); configDir != "" {
+			paths = append(paths, filepath.Join(configDir, "projects"))
+		}
+
 		// Default paths based on operating system
 		home, err := os.UserHomeDir()
 		if err != nil {
@@ -74,20 +252,39 @@ func (r *JSONLCcRepository) loadAllEntries() ([]*entity.CcEntry, error) {
 
 	// Load fresh data
 	validPaths := r.getValidClaudePaths()
+	sourcePaths := make(map[string]string, len(r.mirrors))
+	for _, mirror := range r.mirrors {
+		if err := mirror.Sync(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to sync remote source %s: %v\n", mirror.Name(), err)
+		}
+		if info, err := os.Stat(mirror.LocalPath()); err == nil && info.IsDir() {
+			validPaths = append(validPaths, mirror.LocalPath())
+			sourcePaths[mirror.LocalPath()] = mirror.Name()
+		}
+	}
 	// fmt.Fprintf(os.Stderr, "[DEBUG] Found %d valid Claude paths: %v\n", len(validPaths), validPaths)
 	if len(validPaths) == 0 {
-		return nil, fmt.Errorf("no valid Claude data directories found")
+		// Claude Code is not installed or has never run: no usage, not an error
+		return nil, nil
 	}
 
 	var allEntries []*entity.CcEntry
 	processedIDs := make(map[string]bool) // For deduplication
+	stats := JSONLLoadStats{}
+	var loadErr error
 
 	for _, basePath := range validPaths {
 		// fmt.Fprintf(os.Stderr, "[DEBUG] Loading from base path: %s\n", basePath)
-		entries, err := r.loadFromPath(basePath, processedIDs)
+		// Entries from a mirror are attributed to the remote source rather than the cache directory
+		sourcePath := basePath
+		if name, ok := sourcePaths[basePath]; ok {
+			sourcePath = name
+		}
+		entries, err := r.loadFromPath(basePath, sourcePath, processedIDs, &stats)
 		if err != nil {
 			// Log error but continue with other paths
 			fmt.Fprintf(os.Stderr, "Warning: Failed to load from %s: %v\n", basePath, err)
+			loadErr = err
 			continue
 		}
 		// fmt.Fprintf(os.Stderr, "[DEBUG] Loaded %d entries from %s\n", len(entries), basePath)
@@ -118,8 +315,32 @@ func (r *JSONLCcRepository) loadAllEntries() ([]*entity.CcEntry, error) {
 	// 	fmt.Fprintf(os.Stderr, "[DEBUG] Date range of entries: %v to %v\n", minDate, maxDate)
 	// }
 
+	if stats.CappedEntries > 0 {
+		action := "skipped"
+		if r.tokenCapAction == config.TokenCapClamp {
+			action = "clamped"
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %d Claude Code entries reported more than %d tokens and were %s\n",
+			stats.CappedEntries, r.tokenCap, action)
+	}
+	if stats.OversizedLines > 0 {
+		r.oversizedWarning.Do(func() {
+			fmt.Fprintf(os.Stderr, "Warning: %d Claude Code lines were longer than %d bytes and were skipped; raise max_line_bytes to read them\n",
+				stats.OversizedLines, r.lineLimit())
+		})
+	}
+
+	// Keep the stats even when nothing was loaded, as they explain why
+	r.cache.mu.Lock()
+	r.cache.stats = stats
+	r.cache.mu.Unlock()
+
 	if len(allEntries) == 0 {
-		return nil, fmt.Errorf("no cc data found in any Claude directory")
+		// Only an IO failure is an error; directories without sessions simply have no usage
+		if loadErr != nil {
+			return nil, fmt.Errorf("failed to load cc data: %w", loadErr)
+		}
+		return nil, nil
 	}
 
 	// Update cache
@@ -142,9 +363,61 @@ func (r *JSONLCcRepository) getValidClaudePaths() []string {
 	return validPaths
 }
 
-// loadFromPath loads cc data from a specific Claude projects path
-func (r *JSONLCcRepository) loadFromPath(basePath string, processedIDs map[string]bool) ([]*entity.CcEntry, error) {
+// jsonlFile is a JSONL session file found under a Claude projects path
+type jsonlFile struct {
+	path        string
+	projectPath string
+	sessionID   string
+}
+
+// parsedFile holds the lines of a JSONL file parsed independently of other files,
+// so that files can be parsed concurrently and deduplicated afterwards in a fixed order
+type parsedFile struct {
+	lines []parsedLine
+	stats JSONLFileStats
+	err   error
+}
+
+// parsedLine is a valid JSON line with its dedup keys and converted entry
+type parsedLine struct {
+	lookupKeys []string
+	dedupKeys  []string
+	entry      *entity.CcEntry // nil if the line could not be converted or was skipped by the token cap
+	capped     bool            // the entry exceeded the token cap
+}
+
+// loadFromPath loads cc data from a specific Claude projects path, attributing the entries to sourcePath.
+// Files are parsed concurrently, then merged in walk order so that deduplication
+// keeps the same entries, in the same order, as a sequential load.
+func (r *JSONLCcRepository) loadFromPath(basePath, sourcePath string, processedIDs map[string]bool, stats *JSONLLoadStats) ([]*entity.CcEntry, error) {
+	files, err := r.listJSONLFiles(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := r.parseFiles(files)
+
 	var entries []*entity.CcEntry
+	for i, file := range files {
+		result := &parsed[i]
+		fileEntries := r.mergeParsedFile(result, processedIDs)
+		stats.add(result.stats)
+		if result.err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to load %s: %v\n", file.path, result.err)
+			continue // Continue with other files
+		}
+		for _, entry := range fileEntries {
+			entry.SetSourcePath(sourcePath)
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	return entries, nil
+}
+
+// findJSONLFiles returns the session files under basePath in walk order
+func (r *JSONLCcRepository) findJSONLFiles(basePath string) ([]jsonlFile, error) {
+	var files []jsonlFile
 
 	// Walk through all JSONL files in the projects directory
 	err := filepath.Walk(basePath, func(path string, info os.FileInfo, err error) error {
@@ -157,100 +430,352 @@ func (r *JSONLCcRepository) loadFromPath(basePath string, processedIDs map[strin
 			return nil
 		}
 
+		// A file not written since the cutoff only holds entries before it
+		if !r.ignoreBefore.IsZero() && info.ModTime().Before(r.ignoreBefore) {
+			return nil
+		}
+
 		// Extract session and project info from path
 		relPath, _ := filepath.Rel(basePath, path)
 		parts := strings.Split(relPath, string(filepath.Separator))
 
 		if len(parts) >= 2 {
-			projectPath := parts[0]
-			sessionID := parts[1]
-
-			// Load entries from this file
-			fileEntries, err := r.loadJSONLFile(path, projectPath, sessionID, processedIDs)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to load %s: %v\n", path, err)
-				return nil // Continue with other files
-			}
-
-			entries = append(entries, fileEntries...)
+			files = append(files, jsonlFile{
+				path:        path,
+				projectPath: parts[0],
+				sessionID:   parts[1],
+			})
 		}
 
 		return nil
 	})
 
-	if err != nil {
-		return nil, err
+	return files, err
+}
+
+// listJSONLFiles returns the session files under basePath, reusing a cached listing
+// while it is fresh. If a walk fails or outlasts the walk timeout, the last good
+// listing is returned instead.
+func (r *JSONLCcRepository) listJSONLFiles(basePath string) ([]jsonlFile, error) {
+	r.listingMu.Lock()
+	listing := r.listings[basePath]
+	if listing == nil {
+		listing = &fileListing{}
+		r.listings[basePath] = listing
+	}
+	if !listing.listedAt.IsZero() && r.listingTTL > 0 && time.Since(listing.listedAt) < r.listingTTL {
+		files := listing.files
+		r.listingMu.Unlock()
+		return files, nil
+	}
+	// Join a walk that is still running rather than starting another on a slow mount
+	walk := listing.walk
+	if walk == nil {
+		walk = &listingWalk{done: make(chan struct{})}
+		listing.walk = walk
+		go r.runWalk(basePath, listing, walk)
+	}
+	r.listingMu.Unlock()
+
+	timedOut := false
+	if r.walkTimeout > 0 {
+		timer := time.NewTimer(r.walkTimeout)
+		select {
+		case <-walk.done:
+		case <-timer.C:
+			timedOut = true
+		}
+		timer.Stop()
+	} else {
+		<-walk.done
 	}
 
-	return entries, nil
+	r.listingMu.Lock()
+	defer r.listingMu.Unlock()
+	if !timedOut && walk.err == nil {
+		return listing.files, nil
+	}
+
+	reason := fmt.Sprintf("did not finish within %s", r.walkTimeout)
+	if !timedOut {
+		reason = fmt.Sprintf("failed: %v", walk.err)
+	}
+	if listing.listedAt.IsZero() {
+		return nil, fmt.Errorf("listing %s %s", basePath, reason)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: Listing %s %s, using the listing from %s\n",
+		basePath, reason, listing.listedAt.Format(time.RFC3339))
+	return listing.files, nil
+}
+
+// runWalk walks basePath and stores the listing if the walk succeeds
+func (r *JSONLCcRepository) runWalk(basePath string, listing *fileListing, walk *listingWalk) {
+	files, err := r.findFiles(basePath)
+
+	r.listingMu.Lock()
+	if err == nil {
+		listing.files = files
+		listing.listedAt = time.Now()
+	}
+	walk.err = err
+	listing.walk = nil
+	r.listingMu.Unlock()
+	close(walk.done)
+}
+
+// parseFiles parses files with a bounded worker pool; results are indexed like files
+func (r *JSONLCcRepository) parseFiles(files []jsonlFile) []parsedFile {
+	results := make([]parsedFile, len(files))
+
+	workers := r.parseWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				file := files[i]
+				results[i] = r.parseJSONLFile(file.path, file.projectPath, file.sessionID)
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// mergeParsedFile deduplicates the lines of a parsed file against processedIDs and
+// returns the kept entries. Conversion errors are only counted for lines that are not
+// duplicates, as they would be when loading sequentially.
+func (r *JSONLCcRepository) mergeParsedFile(file *parsedFile, processedIDs map[string]bool) []*entity.CcEntry {
+	var entries []*entity.CcEntry
+	for _, line := range file.lines {
+		duplicate := false
+		for _, key := range line.lookupKeys {
+			if processedIDs[key] {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue // Skip duplicate
+		}
+		for _, key := range line.dedupKeys {
+			processedIDs[key] = true
+		}
+
+		if line.capped {
+			file.stats.CappedEntries++
+			if line.entry == nil {
+				continue // Skipped by the token cap
+			}
+		}
+		if line.entry == nil {
+			file.stats.ConversionErrors++
+			continue // Skip invalid entries
+		}
+		entries = append(entries, line.entry)
+	}
+	return entries
 }
 
-// loadJSONLFile loads and parses a single JSONL file
-func (r *JSONLCcRepository) loadJSONLFile(filePath, projectPath, sessionID string, processedIDs map[string]bool) ([]*entity.CcEntry, error) {
+// parseJSONLFile reads and parses a single JSONL file without deduplicating it
+func (r *JSONLCcRepository) parseJSONLFile(filePath, projectPath, sessionID string) parsedFile {
+	result := parsedFile{stats: JSONLFileStats{Path: filePath}}
+	fileStats := &result.stats
+
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, err
+		result.err = err
+		return result
 	}
 	defer func() {
 		_ = file.Close()
 	}()
 
-	// fmt.Fprintf(os.Stderr, "[DEBUG] Loading JSONL file: %s\n", filePath)
-
-	var entries []*entity.CcEntry
-	scanner := bufio.NewScanner(file)
-	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024) // Handle large lines up to 10MB
+	lines := newLineReader(file, r.lineLimit())
 
 	lineNum := 0
-	for scanner.Scan() {
+	for {
+		raw, tooLong, err := lines.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			result.err = fmt.Errorf("error reading file: %w", err)
+			break
+		}
 		lineNum++
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
+		if tooLong {
+			fileStats.Lines++
+			fileStats.OversizedLines++
+			continue
+		}
+		line := bytes.TrimSpace(raw)
+		if len(line) == 0 {
 			continue
 		}
+		fileStats.Lines++
 
 		var data ccData
-		if err := json.Unmarshal([]byte(line), &data); err != nil {
-			// Skip malformed lines
-			// fmt.Fprintf(os.Stderr, "[DEBUG] Failed to parse JSON at line %d: %v\n", lineNum, err)
+		if err := json.Unmarshal(line, &data); err != nil {
+			// Skip malformed lines, but keep track of them
+			fileStats.ParseErrors++
+			if len(fileStats.SampleErrors) < maxParseErrorSamples {
+				fileStats.SampleErrors = append(fileStats.SampleErrors, fmt.Sprintf("line %d: %v", lineNum, err))
+			}
 			continue
 		}
 
-		// Debug log token values
-		// fmt.Fprintf(os.Stderr, "[DEBUG] Line %d - Input tokens: %d, Output tokens: %d, Cache creation: %d, Cache read: %d\n",
-		// 	lineNum,
-		// 	data.Message.Usage.InputTokens,
-		// 	data.Message.Usage.OutputTokens,
-		// 	data.Message.Usage.CacheCreationInputTokens,
-		// 	data.Message.Usage.CacheReadInputTokens)
-
-		// Create deduplication key
-		dedupKey := r.createDedupKey(&data)
-		if dedupKey != "" && processedIDs[dedupKey] {
-			// fmt.Fprintf(os.Stderr, "[DEBUG] Skipping duplicate entry with key: %s\n", dedupKey)
-			continue // Skip duplicate
+		// Skip entries before the cutoff without registering them for deduplication
+		if r.isBeforeCutoff(data.Timestamp) {
+			continue
 		}
-		if dedupKey != "" {
-			processedIDs[dedupKey] = true
+
+		// Create deduplication keys
+		lookupKeys, dedupKeys := r.createDedupKeys(&data, sessionID)
+
+		// Apply the token cap; skipped entries still register their keys so copies are skipped too
+		capped := r.applyTokenCap(&data)
+		if capped && r.tokenCapAction != config.TokenCapClamp {
+			result.lines = append(result.lines, parsedLine{lookupKeys: lookupKeys, dedupKeys: dedupKeys, capped: true})
+			continue
 		}
 
-		// Convert to domain entity
+		// Convert to domain entity; invalid entries are counted once deduplicated
 		entry, err := r.convertToCcEntry(&data, projectPath, sessionID)
 		if err != nil {
-			// fmt.Fprintf(os.Stderr, "[DEBUG] Failed to convert to entry at line %d: %v\n", lineNum, err)
-			continue // Skip invalid entries
+			entry = nil
 		}
 
-		// fmt.Fprintf(os.Stderr, "[DEBUG] Created entry with total tokens: %d, timestamp: %v\n", entry.TotalTokens(), entry.Timestamp())
-		entries = append(entries, entry)
+		result.lines = append(result.lines, parsedLine{
+			lookupKeys: lookupKeys,
+			dedupKeys:  dedupKeys,
+			entry:      entry,
+			capped:     capped,
+		})
 	}
 
-	if err := scanner.Err(); err != nil {
-		return entries, fmt.Errorf("error reading file: %w", err)
+	if fileStats.ParseErrors > 0 && fileStats.ParseErrorRatio() > parseErrorWarnRatio {
+		fmt.Fprintf(os.Stderr, "Warning: %d of %d lines in %s could not be parsed (first errors: %s)\n",
+			fileStats.ParseErrors, fileStats.Lines, filePath, strings.Join(fileStats.SampleErrors, "; "))
 	}
 
-	// fmt.Fprintf(os.Stderr, "[DEBUG] Loaded %d entries from file: %s\n", len(entries), filePath)
-	return entries, nil
+	return result
+}
+
+// lineReader reads the lines of a file with a small read buffer, copying each line into a
+// buffer that grows only as far as the longest line needs. Lines above max bytes are skipped.
+type lineReader struct {
+	reader *bufio.Reader
+	max    int
+	line   []byte
+}
+
+// newLineReader creates a lineReader for lines of at most max bytes
+func newLineReader(r io.Reader, max int) *lineReader {
+	return &lineReader{
+		reader: bufio.NewReaderSize(r, lineReadBufferSize),
+		max:    max,
+	}
+}
+
+// next returns the next line without its newline. tooLong reports a line above the limit,
+// whose content is discarded. The line is only valid until the next call; err is io.EOF
+// after the last line.
+func (l *lineReader) next() (line []byte, tooLong bool, err error) {
+	l.line = l.line[:0]
+	for {
+		chunk, err := l.reader.ReadSlice('\n')
+		if !tooLong {
+			if len(l.line)+len(bytes.TrimSuffix(chunk, []byte("\n"))) > l.max {
+				tooLong = true
+				l.line = l.line[:0]
+			} else {
+				l.line = append(l.line, chunk...)
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF {
+			if len(l.line) == 0 && !tooLong {
+				return nil, false, io.EOF
+			}
+			return bytes.TrimSuffix(l.line, []byte("\n")), tooLong, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return bytes.TrimSuffix(l.line, []byte("\n")), tooLong, nil
+	}
+}
+
+// applyTokenCap reports whether an entry exceeds the token cap. In clamp mode its token
+// counts are scaled down proportionally so that they add up to at most the cap.
+func (r *JSONLCcRepository) applyTokenCap(data *ccData) bool {
+	if r.tokenCap <= 0 {
+		return false
+	}
+	usage := &data.Message.Usage
+	total := usage.InputTokens + usage.OutputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+	if total <= r.tokenCap {
+		return false
+	}
+
+	if r.tokenCapAction == config.TokenCapClamp {
+		scale := float64(r.tokenCap) / float64(total)
+		usage.InputTokens = int(float64(usage.InputTokens) * scale)
+		usage.OutputTokens = int(float64(usage.OutputTokens) * scale)
+		usage.CacheCreationInputTokens = int(float64(usage.CacheCreationInputTokens) * scale)
+		usage.CacheReadInputTokens = int(float64(usage.CacheReadInputTokens) * scale)
+	}
+	return true
+}
+
+// isBeforeCutoff reports whether a raw entry timestamp is before the configured cutoff.
+// Unparseable timestamps are left to convertToCcEntry to reject.
+func (r *JSONLCcRepository) isBeforeCutoff(timestamp string) bool {
+	if r.ignoreBefore.IsZero() {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return false
+	}
+	return t.Before(r.ignoreBefore)
+}
+
+// add accumulates the results of a single file
+func (s *JSONLLoadStats) add(fileStats JSONLFileStats) {
+	s.Files++
+	s.Lines += fileStats.Lines
+	s.ParseErrors += fileStats.ParseErrors
+	s.ConversionErrors += fileStats.ConversionErrors
+	s.CappedEntries += fileStats.CappedEntries
+	s.OversizedLines += fileStats.OversizedLines
+	if fileStats.ParseErrors > 0 {
+		s.FilesWithErrors = append(s.FilesWithErrors, fileStats)
+	}
+}
+
+// LoadStats returns the statistics of the most recent load of the JSONL files
+func (r *JSONLCcRepository) LoadStats() JSONLLoadStats {
+	r.cache.mu.RLock()
+	defer r.cache.mu.RUnlock()
+	return r.cache.stats
 }
 
 // convertToCcEntry converts raw cc data to domain entity
@@ -310,6 +835,38 @@ func (r *JSONLCcRepository) createDedupKey(data *ccData) string {
 	return ""
 }
 
+// createDedupKeys returns the keys an entry is looked up by to detect a duplicate,
+// and the keys it is registered under once kept.
+// Without heuristic dedup both are the single ID-based key. With it, entries are the same
+// only when both their message and request IDs match, so entries sharing just one of them
+// are kept. A copy that lacks the message ID falls back to matching timestamp, session and
+// total tokens.
+func (r *JSONLCcRepository) createDedupKeys(data *ccData, sessionID string) (lookup []string, register []string) {
+	if !r.heuristicDedup {
+		if key := r.createDedupKey(data); key != "" {
+			return []string{key}, []string{key}
+		}
+		return nil, nil
+	}
+
+	if data.Message.ID != "" || data.RequestID != "" {
+		register = append(register, "ids:"+data.Message.ID+"|"+data.RequestID)
+	}
+	lookup = append(lookup, register...)
+
+	if data.Timestamp != "" {
+		usage := data.Message.Usage
+		totalTokens := usage.InputTokens + usage.OutputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+		heuristicKey := fmt.Sprintf("heuristic:%s|%s|%d", data.Timestamp, sessionID, totalTokens)
+		register = append(register, heuristicKey)
+		// Entries with a message ID are told apart by it, so the heuristic only applies without one
+		if data.Message.ID == "" {
+			lookup = append(lookup, heuristicKey)
+		}
+	}
+	return lookup, register
+}
+
 // Repository interface implementations
 
 // FindAll returns all cc entries
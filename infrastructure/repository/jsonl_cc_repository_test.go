@@ -0,0 +1,500 @@
+package repository
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeJSONLFile(t *testing.T, path string, lines []string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write JSONL file: %v", err)
+	}
+}
+
+func TestJSONLCcRepository_LoadStats(t *testing.T) {
+	basePath := t.TempDir()
+	valid := `{"timestamp":"2025-01-02T03:04:05Z","requestId":"req-%d","message":{"id":"msg-%d","model":"claude-sonnet","usage":{"input_tokens":10,"output_tokens":5}}}`
+
+	writeJSONLFile(t, filepath.Join(basePath, "project-a", "good.jsonl"), []string{
+		strings.ReplaceAll(valid, "%d", "1"),
+		strings.ReplaceAll(valid, "%d", "2"),
+		`{"type":"summary","summary":"no timestamp"}`,
+	})
+	writeJSONLFile(t, filepath.Join(basePath, "project-b", "bad.jsonl"), []string{
+		strings.ReplaceAll(valid, "%d", "3"),
+		"\xff\xfe garbage",
+		"{not json",
+	})
+
+	repo := NewJSONLCcRepository(basePath)
+	entries, err := repo.FindAll()
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("FindAll() returned %d entries, want 3", len(entries))
+	}
+
+	stats := repo.LoadStats()
+	if stats.Files != 2 || stats.Lines != 6 {
+		t.Errorf("LoadStats() files = %d, lines = %d, want 2 and 6", stats.Files, stats.Lines)
+	}
+	if stats.ParseErrors != 2 {
+		t.Errorf("LoadStats().ParseErrors = %d, want 2", stats.ParseErrors)
+	}
+	if stats.ConversionErrors != 1 {
+		t.Errorf("LoadStats().ConversionErrors = %d, want 1", stats.ConversionErrors)
+	}
+	if len(stats.FilesWithErrors) != 1 {
+		t.Fatalf("LoadStats().FilesWithErrors has %d files, want 1", len(stats.FilesWithErrors))
+	}
+
+	fileStats := stats.FilesWithErrors[0]
+	if !strings.HasSuffix(fileStats.Path, "bad.jsonl") {
+		t.Errorf("FilesWithErrors[0].Path = %s, want bad.jsonl", fileStats.Path)
+	}
+	if len(fileStats.SampleErrors) != 2 || !strings.HasPrefix(fileStats.SampleErrors[0], "line 2:") {
+		t.Errorf("FilesWithErrors[0].SampleErrors = %v, want errors for lines 2 and 3", fileStats.SampleErrors)
+	}
+}
+
+func TestJSONLCcRepository_NoClaudeData(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "missing directory", path: filepath.Join(t.TempDir(), "missing")},
+		{name: "empty directory", path: t.TempDir()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewJSONLCcRepository(tt.path)
+
+			entries, err := repo.FindAll()
+			if err != nil {
+				t.Fatalf("FindAll() error = %v", err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("FindAll() returned %d entries, want 0", len(entries))
+			}
+
+			entries, err = repo.FindByDate(time.Now())
+			if err != nil {
+				t.Fatalf("FindByDate() error = %v", err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("FindByDate() returned %d entries, want 0", len(entries))
+			}
+		})
+	}
+}
+
+func TestJSONLCcRepository_ClaudeConfigDir(t *testing.T) {
+	// Keep the real default locations out of the search
+	t.Setenv("HOME", t.TempDir())
+	configDir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", configDir)
+
+	writeJSONLFile(t, filepath.Join(configDir, "projects", "project-a", "session.jsonl"), []string{
+		`{"timestamp":"2025-01-02T03:04:05Z","requestId":"req-1","message":{"id":"msg-1","model":"claude-sonnet","usage":{"input_tokens":10,"output_tokens":5}}}`,
+	})
+
+	repo := NewJSONLCcRepository("")
+	if paths := repo.getClaudePaths(""); len(paths) == 0 || paths[0] != filepath.Join(configDir, "projects") {
+		t.Errorf("getClaudePaths() = %v, want CLAUDE_CONFIG_DIR/projects first", paths)
+	}
+
+	entries, err := repo.FindAll()
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("FindAll() returned %d entries, want 1 from CLAUDE_CONFIG_DIR", len(entries))
+	}
+}
+
+func TestJSONLCcRepository_SourcePath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	entry := `{"timestamp":"2025-01-02T03:04:05Z","requestId":"req-%d","message":{"id":"msg-%d","model":"claude-sonnet","usage":{"input_tokens":10,"output_tokens":5}}}`
+
+	newPath := filepath.Join(home, ".config", "claude", "projects")
+	oldPath := filepath.Join(home, ".claude", "projects")
+	writeJSONLFile(t, filepath.Join(newPath, "project-a", "session.jsonl"), []string{strings.ReplaceAll(entry, "%d", "1")})
+	writeJSONLFile(t, filepath.Join(oldPath, "project-b", "session.jsonl"), []string{strings.ReplaceAll(entry, "%d", "2")})
+
+	entries, err := NewJSONLCcRepository("").FindAll()
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	got := make(map[string]string)
+	for _, e := range entries {
+		got[e.RequestID()] = e.SourcePath()
+	}
+	if got["req-1"] != newPath || got["req-2"] != oldPath {
+		t.Errorf("source paths = %v, want req-1 from %s and req-2 from %s", got, newPath, oldPath)
+	}
+}
+
+func TestJSONLCcRepository_HeuristicDedup(t *testing.T) {
+	withIDs := `{"timestamp":"2025-01-02T03:04:05Z","version":"1.0.0","requestId":"req-1","message":{"id":"msg-1","model":"claude-sonnet","usage":{"input_tokens":10,"output_tokens":5}}}`
+	withRequestID := `{"timestamp":"2025-01-02T03:04:05Z","version":"1.0.1","requestId":"req-1","message":{"model":"claude-sonnet","usage":{"input_tokens":10,"output_tokens":5}}}`
+	withoutIDs := `{"timestamp":"2025-01-02T03:04:05Z","version":"1.0.2","message":{"model":"claude-sonnet","usage":{"input_tokens":10,"output_tokens":5}}}`
+	otherMessage := `{"timestamp":"2025-01-02T03:04:05Z","requestId":"req-2","message":{"id":"msg-2","model":"claude-sonnet","usage":{"input_tokens":10,"output_tokens":5}}}`
+	// Shares only the request ID with withIDs, so it is a distinct entry
+	otherMessageSameRequest := `{"timestamp":"2025-01-02T03:04:06Z","requestId":"req-1","message":{"id":"msg-3","model":"claude-sonnet","usage":{"input_tokens":10,"output_tokens":5}}}`
+
+	tests := []struct {
+		name      string
+		heuristic bool
+		want      int
+	}{
+		{name: "ID dedup only", heuristic: false, want: 5},
+		{name: "heuristic dedup", heuristic: true, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			basePath := t.TempDir()
+			writeJSONLFile(t, filepath.Join(basePath, "project", "session.jsonl"), []string{withIDs, otherMessage, otherMessageSameRequest})
+
+			otherPath := t.TempDir()
+			writeJSONLFile(t, filepath.Join(otherPath, "project", "session.jsonl"), []string{withRequestID, withoutIDs})
+
+			repo := NewJSONLCcRepository(basePath)
+			repo.claudePaths = []string{basePath, otherPath}
+			repo.SetHeuristicDedup(tt.heuristic)
+
+			entries, err := repo.FindAll()
+			if err != nil {
+				t.Fatalf("FindAll() error = %v", err)
+			}
+			if len(entries) != tt.want {
+				t.Errorf("FindAll() returned %d entries, want %d", len(entries), tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONLCcRepository_IgnoreBefore(t *testing.T) {
+	line := func(timestamp, id string) string {
+		return fmt.Sprintf(`{"timestamp":%q,"requestId":"req-%s","message":{"id":"msg-%s","model":"claude-sonnet","usage":{"input_tokens":10,"output_tokens":5}}}`, timestamp, id, id)
+	}
+
+	basePath := t.TempDir()
+	recent := filepath.Join(basePath, "project", "recent.jsonl")
+	writeJSONLFile(t, recent, []string{
+		line("2024-12-31T23:59:59Z", "1"),
+		line("2025-01-01T00:00:00Z", "2"),
+		line("2025-02-01T12:00:00Z", "3"),
+	})
+	old := filepath.Join(basePath, "project", "old.jsonl")
+	writeJSONLFile(t, old, []string{line("2025-03-01T00:00:00Z", "4")})
+
+	cutoff := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	// A file last written before the cutoff is skipped without being read
+	if err := os.Chtimes(old, cutoff.Add(-time.Hour), cutoff.Add(-time.Hour)); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	repo := NewJSONLCcRepository(basePath)
+	all, err := repo.FindAll()
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("FindAll() without cutoff returned %d entries, want 4", len(all))
+	}
+
+	repo.SetIgnoreBefore(cutoff)
+	entries, err := repo.FindAll()
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("FindAll() returned %d entries, want 2", len(entries))
+	}
+	for _, e := range entries {
+		if e.Timestamp().Before(cutoff) {
+			t.Errorf("entry %s at %v is before the cutoff", e.ID(), e.Timestamp())
+		}
+	}
+	if stats := repo.LoadStats(); stats.Files != 1 {
+		t.Errorf("LoadStats().Files = %d, want 1", stats.Files)
+	}
+}
+
+// writeSyntheticSessions writes files session files to basePath. Every other file repeats
+// the previous file's first message, so results depend on deduplication order.
+func writeSyntheticSessions(tb testing.TB, basePath string, files, linesPerFile int) {
+	tb.Helper()
+	for f := 0; f < files; f++ {
+		lines := make([]string, 0, linesPerFile)
+		for l := 0; l < linesPerFile; l++ {
+			id := fmt.Sprintf("%d-%d", f, l)
+			if f%2 == 1 && l == 0 {
+				id = fmt.Sprintf("%d-0", f-1)
+			}
+			lines = append(lines, fmt.Sprintf(`{"timestamp":"2025-01-02T03:%02d:%02dZ","requestId":"req-%s","message":{"id":"msg-%s","model":"claude-sonnet","usage":{"input_tokens":%d,"output_tokens":5}}}`,
+				l%60, f%60, id, id, 10+f))
+		}
+		path := filepath.Join(basePath, fmt.Sprintf("project-%d", f%10), fmt.Sprintf("session-%03d.jsonl", f))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+			tb.Fatalf("failed to write JSONL file: %v", err)
+		}
+	}
+}
+
+func TestJSONLCcRepository_ConcurrentParsingMatchesSequential(t *testing.T) {
+	basePath := t.TempDir()
+	writeSyntheticSessions(t, basePath, 40, 20)
+
+	load := func(workers int) []string {
+		repo := NewJSONLCcRepository(basePath)
+		repo.SetParseWorkers(workers)
+		entries, err := repo.FindAll()
+		if err != nil {
+			t.Fatalf("FindAll() error = %v", err)
+		}
+		ids := make([]string, len(entries))
+		for i, e := range entries {
+			ids[i] = e.SessionID() + "/" + e.MessageID()
+		}
+		return ids
+	}
+
+	sequential := load(1)
+	if len(sequential) != 40*20-20 {
+		t.Fatalf("sequential load returned %d entries, want %d", len(sequential), 40*20-20)
+	}
+	concurrent := load(8)
+	if strings.Join(concurrent, ",") != strings.Join(sequential, ",") {
+		t.Error("concurrent load returned different entries or order than sequential load")
+	}
+}
+
+func BenchmarkJSONLCcRepository_Load(b *testing.B) {
+	basePath := b.TempDir()
+	writeSyntheticSessions(b, basePath, 300, 200)
+
+	for _, workers := range []int{1, 0} {
+		name := fmt.Sprintf("workers=%d", workers)
+		if workers == 0 {
+			name = "workers=NumCPU"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				repo := NewJSONLCcRepository(basePath)
+				repo.SetParseWorkers(workers)
+				if _, err := repo.FindAll(); err != nil {
+					b.Fatalf("FindAll() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONLCcRepository_ListingCache(t *testing.T) {
+	basePath := t.TempDir()
+	writeJSONLFile(t, filepath.Join(basePath, "project-a", "session-1.jsonl"), []string{"{}"})
+
+	repo := NewJSONLCcRepository(basePath)
+	walks := 0
+	repo.findFiles = func(path string) ([]jsonlFile, error) {
+		walks++
+		return repo.findJSONLFiles(path)
+	}
+
+	repo.SetListingCacheTTL(time.Hour)
+	for i := 0; i < 2; i++ {
+		files, err := repo.listJSONLFiles(basePath)
+		if err != nil || len(files) != 1 {
+			t.Fatalf("listJSONLFiles() = %d files, %v; want 1 file", len(files), err)
+		}
+	}
+	if walks != 1 {
+		t.Errorf("walks = %d, want 1 while the listing is cached", walks)
+	}
+
+	repo.SetListingCacheTTL(0)
+	if _, err := repo.listJSONLFiles(basePath); err != nil {
+		t.Fatalf("listJSONLFiles() error = %v", err)
+	}
+	if walks != 2 {
+		t.Errorf("walks = %d, want 2 without a listing cache", walks)
+	}
+}
+
+// waitForWalk waits until no walk of basePath is in flight
+func waitForWalk(t *testing.T, repo *JSONLCcRepository, basePath string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		repo.listingMu.Lock()
+		idle := repo.listings[basePath].walk == nil
+		repo.listingMu.Unlock()
+		if idle {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("walk did not finish")
+}
+
+func TestJSONLCcRepository_WalkTimeout(t *testing.T) {
+	basePath := t.TempDir()
+	writeJSONLFile(t, filepath.Join(basePath, "project-a", "session-1.jsonl"), []string{"{}"})
+
+	// Each walk blocks until the test hands it a result
+	type walkResult struct {
+		files []jsonlFile
+		err   error
+	}
+	results := make(chan walkResult)
+	repo := NewJSONLCcRepository(basePath)
+	repo.SetWalkTimeout(50 * time.Millisecond)
+	repo.findFiles = func(string) ([]jsonlFile, error) {
+		result := <-results
+		return result.files, result.err
+	}
+
+	// A first walk that times out has no listing to fall back on
+	if _, err := repo.listJSONLFiles(basePath); err == nil {
+		t.Fatal("listJSONLFiles() should fail when the first walk times out")
+	}
+
+	// The abandoned walk still completes in the background and its listing is kept
+	good, err := repo.findJSONLFiles(basePath)
+	if err != nil {
+		t.Fatalf("findJSONLFiles() error = %v", err)
+	}
+	results <- walkResult{files: good}
+	waitForWalk(t, repo, basePath)
+
+	// A slow walk falls back to the last good listing
+	if files, err := repo.listJSONLFiles(basePath); err != nil || len(files) != 1 {
+		t.Errorf("slow walk: listJSONLFiles() = %d files, %v; want the last good listing", len(files), err)
+	}
+	results <- walkResult{err: fmt.Errorf("transport endpoint is not connected")}
+	waitForWalk(t, repo, basePath)
+
+	// So does a failed walk
+	go func() {
+		results <- walkResult{err: fmt.Errorf("transport endpoint is not connected")}
+	}()
+	if files, err := repo.listJSONLFiles(basePath); err != nil || len(files) != 1 {
+		t.Errorf("failed walk: listJSONLFiles() = %d files, %v; want the last good listing", len(files), err)
+	}
+}
+
+func TestJSONLCcRepository_MaxLineBytes(t *testing.T) {
+	basePath := t.TempDir()
+	entry := `{"timestamp":"2025-01-02T03:04:05Z","message":{"id":"msg-%s","usage":{"input_tokens":10,"output_tokens":5}},"toolOutput":"%s"}`
+	writeJSONLFile(t, filepath.Join(basePath, "project-a", "session-1.jsonl"), []string{
+		fmt.Sprintf(entry, "1", ""),
+		// Longer than the initial read buffer but within the limit
+		fmt.Sprintf(entry, "2", strings.Repeat("x", 100*1024)),
+		// Above the limit: skipped, and the lines after it are still read
+		fmt.Sprintf(entry, "3", strings.Repeat("x", 300*1024)),
+		fmt.Sprintf(entry, "4", ""),
+	})
+
+	repo := NewJSONLCcRepository(basePath)
+	repo.SetMaxLineBytes(200 * 1024)
+	entries, err := repo.FindAll()
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("FindAll() returned %d entries, want 3", len(entries))
+	}
+	stats := repo.LoadStats()
+	if stats.Lines != 4 || stats.OversizedLines != 1 || stats.ParseErrors != 0 {
+		t.Errorf("LoadStats() lines = %d, oversized = %d, parse errors = %d; want 4, 1, 0",
+			stats.Lines, stats.OversizedLines, stats.ParseErrors)
+	}
+}
+
+func TestLineReader(t *testing.T) {
+	// The last line has no newline, and an oversized line may end the file
+	for _, tt := range []struct {
+		input string
+		want  []string
+	}{
+		{input: "a\n" + strings.Repeat("x", 20) + "\nbb", want: []string{"a", "<too long>", "bb"}},
+		{input: "a\n\n" + strings.Repeat("x", 20), want: []string{"a", "", "<too long>"}},
+		{input: "", want: nil},
+	} {
+		lines := newLineReader(strings.NewReader(tt.input), 10)
+		var got []string
+		for {
+			line, tooLong, err := lines.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("next() error = %v", err)
+			}
+			if tooLong {
+				got = append(got, "<too long>")
+			} else {
+				got = append(got, string(line))
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("lines of %q = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestJSONLCcRepository_TokenCap(t *testing.T) {
+	basePath := t.TempDir()
+	writeJSONLFile(t, filepath.Join(basePath, "project-a", "session-1.jsonl"), []string{
+		`{"timestamp":"2025-01-02T03:04:05Z","message":{"id":"msg-1","usage":{"input_tokens":100,"output_tokens":50}}}`,
+		`{"timestamp":"2025-01-02T03:05:05Z","message":{"id":"msg-2","usage":{"input_tokens":3000000000,"output_tokens":1000000000}}}`,
+	})
+
+	tests := []struct {
+		action      string
+		wantEntries int
+		wantTokens  int
+	}{
+		{action: "skip", wantEntries: 1, wantTokens: 150},
+		{action: "clamp", wantEntries: 2, wantTokens: 150 + 1000000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			repo := NewJSONLCcRepository(basePath)
+			repo.SetTokenCap(1000000, tt.action)
+
+			entries, err := repo.FindAll()
+			if err != nil {
+				t.Fatalf("FindAll() error = %v", err)
+			}
+			total := 0
+			for _, entry := range entries {
+				total += entry.TotalTokens()
+			}
+			if len(entries) != tt.wantEntries || total != tt.wantTokens {
+				t.Errorf("FindAll() = %d entries, %d tokens; want %d entries, %d tokens", len(entries), total, tt.wantEntries, tt.wantTokens)
+			}
+			if capped := repo.LoadStats().CappedEntries; capped != 1 {
+				t.Errorf("LoadStats().CappedEntries = %d, want 1", capped)
+			}
+		})
+	}
+}
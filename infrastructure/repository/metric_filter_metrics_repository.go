@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+// MetricFilterMetricsRepository wraps another MetricsRepository and drops metrics by name.
+// With an allowlist only matching metrics are forwarded; with a denylist matching metrics
+// are dropped. Patterns are globs as understood by path.Match, e.g. "tosage_cursor_*".
+type MetricFilterMetricsRepository struct {
+	delegate  repository.MetricsRepository
+	allowlist []string
+	denylist  []string
+}
+
+// NewMetricFilterMetricsRepository creates a filtering repository in front of delegate
+func NewMetricFilterMetricsRepository(delegate repository.MetricsRepository, cfg *config.PrometheusConfig) (*MetricFilterMetricsRepository, error) {
+	if delegate == nil {
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("delegate metrics repository is nil"))
+	}
+	if cfg == nil {
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("prometheus config is nil"))
+	}
+
+	return &MetricFilterMetricsRepository{
+		delegate:  delegate,
+		allowlist: cfg.MetricAllowlist,
+		denylist:  cfg.MetricDenylist,
+	}, nil
+}
+
+// SendTokenMetric forwards the metric to the delegate unless it is filtered out
+func (r *MetricFilterMetricsRepository) SendTokenMetric(totalTokens int64, hostLabel string, metricName string) error {
+	if !r.allows(metricName) {
+		return nil
+	}
+	return r.delegate.SendTokenMetric(totalTokens, hostLabel, metricName)
+}
+
+// SendTokenMetricWithTimezone forwards the metric to the delegate unless it is filtered out
+func (r *MetricFilterMetricsRepository) SendTokenMetricWithTimezone(totalTokens int64, hostLabel string, metricName string, timezoneInfo repository.TimezoneInfo) error {
+	if !r.allows(metricName) {
+		return nil
+	}
+	return r.delegate.SendTokenMetricWithTimezone(totalTokens, hostLabel, metricName, timezoneInfo)
+}
+
+// SendTokenMetricWithLabels forwards the metric to the delegate unless it is filtered out
+func (r *MetricFilterMetricsRepository) SendTokenMetricWithLabels(totalTokens int64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	if !r.allows(metricName) {
+		return nil
+	}
+	return r.delegate.SendTokenMetricWithLabels(totalTokens, hostLabel, metricName, labels, timezoneInfo)
+}
+
+// SendMetricValue forwards the value to the delegate unless it is filtered out
+func (r *MetricFilterMetricsRepository) SendMetricValue(value float64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	if !r.allows(metricName) {
+		return nil
+	}
+	return sendMetricValue(r.delegate, value, hostLabel, metricName, labels, timezoneInfo)
+}
+
+// CheckConnection checks the delegate's backend, if it supports checks
+func (r *MetricFilterMetricsRepository) CheckConnection(ctx context.Context) error {
+	return checkMetricsConnection(ctx, r.delegate)
+}
+
+// ExpireMetrics expires the metrics in the delegate
+func (r *MetricFilterMetricsRepository) ExpireMetrics(metricNames ...string) {
+	expireMetrics(r.delegate, metricNames...)
+}
+
+// Close closes the delegate
+func (r *MetricFilterMetricsRepository) Close() error {
+	return r.delegate.Close()
+}
+
+// allows reports whether a metric passes the allowlist or denylist. The last collection
+// timestamp always passes, as the scrape endpoint's on-demand refresh depends on it.
+func (r *MetricFilterMetricsRepository) allows(metricName string) bool {
+	if metricName == lastCollectionMetric {
+		return true
+	}
+	if len(r.allowlist) > 0 {
+		return matchesAnyMetricPattern(r.allowlist, metricName)
+	}
+	return !matchesAnyMetricPattern(r.denylist, metricName)
+}
+
+// matchesAnyMetricPattern reports whether name matches one of the glob patterns.
+// Invalid patterns are rejected when the configuration is validated, so they never match here.
+func matchesAnyMetricPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
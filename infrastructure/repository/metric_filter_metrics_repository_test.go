@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+func TestMetricFilterMetricsRepository(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *config.PrometheusConfig
+		want   []string
+		absent []string
+	}{
+		{
+			name:   "allowlist",
+			config: &config.PrometheusConfig{MetricAllowlist: []string{"tosage_cc_token"}},
+			want:   []string{"tosage_cc_token{", "tosage_last_collection_timestamp_seconds{"},
+			absent: []string{"tosage_cc_token_all", "tosage_cursor_token", "tosage_bedrock_token"},
+		},
+		{
+			name:   "denylist",
+			config: &config.PrometheusConfig{MetricDenylist: []string{"tosage_cursor_*", "tosage_*_all", "tosage_last_*"}},
+			want:   []string{"tosage_cc_token{", "tosage_bedrock_token{", "tosage_last_collection_timestamp_seconds{"},
+			absent: []string{"tosage_cc_token_all", "tosage_cursor_token"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scrapeRepo := newTestScrapeRepository(t)
+			repo, err := NewMetricFilterMetricsRepository(scrapeRepo, tt.config)
+			if err != nil {
+				t.Fatalf("NewMetricFilterMetricsRepository() error = %v", err)
+			}
+
+			if err := repo.SendTokenMetric(100, "", "tosage_cc_token"); err != nil {
+				t.Fatalf("SendTokenMetric() error = %v", err)
+			}
+			if err := repo.SendTokenMetric(150, "", "tosage_cc_token_all"); err != nil {
+				t.Fatalf("SendTokenMetric() error = %v", err)
+			}
+			if err := repo.SendTokenMetricWithLabels(200, "", "tosage_cursor_token", nil, nil); err != nil {
+				t.Fatalf("SendTokenMetricWithLabels() error = %v", err)
+			}
+			if err := repo.SendMetricValue(300, "", "tosage_bedrock_token", map[string]string{"region": "us-east-1"}, nil); err != nil {
+				t.Fatalf("SendMetricValue() error = %v", err)
+			}
+			// Never filtered, as the scrape endpoint's refresh depends on it
+			if err := repo.SendTokenMetric(1700000000, "", "tosage_last_collection_timestamp_seconds"); err != nil {
+				t.Fatalf("SendTokenMetric() error = %v", err)
+			}
+
+			_, body := scrape(t, scrapeRepo, "")
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("series %s not found in:\n%s", want, body)
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(body, absent+"{") {
+					t.Errorf("filtered series %s was sent:\n%s", absent, body)
+				}
+			}
+		})
+	}
+}
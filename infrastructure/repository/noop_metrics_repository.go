@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/ca-srg/tosage/domain/repository"
 )
 
@@ -14,17 +16,34 @@ func NewNoOpMetricsRepository() repository.MetricsRepository {
 }
 
 // SendTokenMetric does nothing
-func (r *NoOpMetricsRepository) SendTokenMetric(totalTokens int, hostLabel string, metricName string) error {
+func (r *NoOpMetricsRepository) SendTokenMetric(totalTokens int64, hostLabel string, metricName string) error {
 	// No-op: do nothing
 	return nil
 }
 
 // SendTokenMetricWithTimezone does nothing
-func (r *NoOpMetricsRepository) SendTokenMetricWithTimezone(totalTokens int, hostLabel string, metricName string, timezoneInfo repository.TimezoneInfo) error {
+func (r *NoOpMetricsRepository) SendTokenMetricWithTimezone(totalTokens int64, hostLabel string, metricName string, timezoneInfo repository.TimezoneInfo) error {
+	// No-op: do nothing
+	return nil
+}
+
+// SendTokenMetricWithLabels does nothing
+func (r *NoOpMetricsRepository) SendTokenMetricWithLabels(totalTokens int64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	// No-op: do nothing
+	return nil
+}
+
+// SendMetricValue does nothing
+func (r *NoOpMetricsRepository) SendMetricValue(value float64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
 	// No-op: do nothing
 	return nil
 }
 
+// CheckConnection always succeeds as there is no backend
+func (r *NoOpMetricsRepository) CheckConnection(ctx context.Context) error {
+	return nil
+}
+
 // Close does nothing
 func (r *NoOpMetricsRepository) Close() error {
 	// No-op: do nothing
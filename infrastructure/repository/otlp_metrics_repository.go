@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/infrastructure/config"
+	"github.com/ca-srg/tosage/infrastructure/httpclient"
+)
+
+// otlpServiceName is the service.name resource attribute of pushed metrics
+const otlpServiceName = "tosage"
+
+// OTLPMetricsRepository implements MetricsRepository by pushing each metric as a gauge
+// to an OpenTelemetry collector over OTLP/HTTP, using the JSON encoding
+type OTLPMetricsRepository struct {
+	url       string
+	username  string
+	password  string
+	timeout   time.Duration
+	client    *http.Client
+	hostLabel string
+}
+
+// NewOTLPMetricsRepository creates an OTLP repository for backend. Claude Code and Cursor
+// metrics get hostLabel (or the hostname if it is empty) when no host is given.
+func NewOTLPMetricsRepository(backend *config.MetricsBackendConfig, hostLabel string, timeout time.Duration) (*OTLPMetricsRepository, error) {
+	if backend == nil || backend.URL == "" {
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("otlp url is empty"))
+	}
+	if backend.TimeoutSec > 0 {
+		timeout = time.Duration(backend.TimeoutSec) * time.Second
+	}
+
+	return &OTLPMetricsRepository{
+		url:       backend.URL,
+		username:  backend.Username,
+		password:  backend.Password,
+		timeout:   timeout,
+		client:    httpclient.NewClient(timeout),
+		hostLabel: resolveHostLabel(hostLabel),
+	}, nil
+}
+
+// SendTokenMetric sends the total token count metric
+func (r *OTLPMetricsRepository) SendTokenMetric(totalTokens int64, hostLabel string, metricName string) error {
+	return r.SendTokenMetricWithLabels(totalTokens, hostLabel, metricName, nil, nil)
+}
+
+// SendTokenMetricWithTimezone sends the total token count metric with timezone information
+func (r *OTLPMetricsRepository) SendTokenMetricWithTimezone(totalTokens int64, hostLabel string, metricName string, timezoneInfo repository.TimezoneInfo) error {
+	return r.SendTokenMetricWithLabels(totalTokens, hostLabel, metricName, nil, &timezoneInfo)
+}
+
+// SendTokenMetricWithLabels sends the total token count metric with additional series labels
+func (r *OTLPMetricsRepository) SendTokenMetricWithLabels(totalTokens int64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	if hostLabel == "" && usesDefaultHostLabel(metricName) {
+		hostLabel = r.hostLabel
+	}
+	return r.SendMetricValue(float64(totalTokens), hostLabel, metricName, labels, timezoneInfo)
+}
+
+// SendMetricValue sends a metric value without rounding it to a token count
+func (r *OTLPMetricsRepository) SendMetricValue(value float64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	payload := otlpGaugePayload(metricName, value, seriesLabels(hostLabel, labels, timezoneInfo), time.Now())
+	if err := r.post(ctx, payload); err != nil {
+		if ctx.Err() != nil {
+			return repository.NewMetricsRepositoryError("send", fmt.Errorf("timeout: %w", err))
+		}
+		return repository.NewMetricsRepositoryError("send", err)
+	}
+	return nil
+}
+
+// CheckConnection sends an export request without metrics to check that the collector
+// is reachable and accepts the credentials
+func (r *OTLPMetricsRepository) CheckConnection(ctx context.Context) error {
+	if err := r.post(ctx, otlpMetricsRequest{ResourceMetrics: []otlpResourceMetrics{}}); err != nil {
+		return repository.NewMetricsRepositoryError("probe", err)
+	}
+	return nil
+}
+
+// Close cleans up resources
+func (r *OTLPMetricsRepository) Close() error {
+	return nil
+}
+
+// post sends an export request to the collector
+func (r *OTLPMetricsRepository) post(ctx context.Context, payload otlpMetricsRequest) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if r.username != "" && r.password != "" {
+		httpReq.SetBasicAuth(r.username, r.password)
+	}
+
+	resp, err := r.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("otlp endpoint returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// OTLP/HTTP JSON request, limited to what a single gauge data point needs
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name  string    `json:"name"`
+	Gauge otlpGauge `json:"gauge"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpDataPoint struct {
+	Attributes []otlpAttribute `json:"attributes,omitempty"`
+	// TimeUnixNano is a 64-bit integer, which the OTLP JSON encoding writes as a string
+	TimeUnixNano string  `json:"timeUnixNano"`
+	AsDouble     float64 `json:"asDouble"`
+}
+
+type otlpAttribute struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// otlpGaugePayload builds an export request holding a single gauge data point
+func otlpGaugePayload(metricName string, value float64, labels map[string]string, timestamp time.Time) otlpMetricsRequest {
+	return otlpMetricsRequest{
+		ResourceMetrics: []otlpResourceMetrics{{
+			Resource: otlpResource{
+				Attributes: []otlpAttribute{{Key: "service.name", Value: otlpAnyValue{StringValue: otlpServiceName}}},
+			},
+			ScopeMetrics: []otlpScopeMetrics{{
+				Scope: otlpScope{Name: otlpServiceName},
+				Metrics: []otlpMetric{{
+					Name: metricName,
+					Gauge: otlpGauge{DataPoints: []otlpDataPoint{{
+						Attributes:   otlpAttributes(labels),
+						TimeUnixNano: strconv.FormatInt(timestamp.UnixNano(), 10),
+						AsDouble:     value,
+					}}},
+				}},
+			}},
+		}},
+	}
+}
+
+// otlpAttributes converts labels to OTLP attributes, sorted by name
+func otlpAttributes(labels map[string]string) []otlpAttribute {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	attributes := make([]otlpAttribute, len(names))
+	for i, name := range names {
+		attributes[i] = otlpAttribute{Key: name, Value: otlpAnyValue{StringValue: labels[name]}}
+	}
+	return attributes
+}
+
+var _ repository.MetricsRepository = (*OTLPMetricsRepository)(nil)
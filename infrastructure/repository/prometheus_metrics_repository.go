@@ -24,24 +24,12 @@ func NewPrometheusMetricsRepository(cfg *config.PrometheusConfig) (repository.Me
 	}
 
 	// Use hostname if HostLabel is not specified
-	hostLabel := cfg.HostLabel
-	if hostLabel == "" {
-		hostname, err := os.Hostname()
-		if err != nil {
-			// Fall back to "unknown" if hostname cannot be determined
-			hostLabel = "unknown"
-		} else {
-			hostLabel = hostname
-		}
-	}
+	hostLabel := resolveHostLabel(cfg.HostLabel)
 
 	// Create authentication config (always use basic auth if credentials are provided)
-	var authConfig *AuthConfig
-	if cfg.RemoteWriteUsername != "" && cfg.RemoteWritePassword != "" {
-		authConfig = &AuthConfig{
-			Username: cfg.RemoteWriteUsername,
-			Password: cfg.RemoteWritePassword,
-		}
+	authConfig, err := newRemoteWriteAuthConfig(cfg)
+	if err != nil {
+		return nil, repository.NewMetricsRepositoryError("initialize", err)
 	}
 
 	// Determine URL to use
@@ -59,6 +47,14 @@ func NewPrometheusMetricsRepository(cfg *config.PrometheusConfig) (repository.Me
 	if err != nil {
 		return nil, repository.NewMetricsRepositoryError("initialize", err)
 	}
+	if err := rwClient.SetCompression(cfg.Compression); err != nil {
+		return nil, repository.NewMetricsRepositoryError("initialize", err)
+	}
+	if cfg.ClientCertPath != "" || cfg.ClientKeyPath != "" {
+		if err := rwClient.SetClientCertificate(cfg.ClientCertPath, cfg.ClientKeyPath); err != nil {
+			return nil, repository.NewMetricsRepositoryError("initialize", err)
+		}
+	}
 
 	return &PrometheusMetricsRepository{
 		config:    cfg,
@@ -67,8 +63,37 @@ func NewPrometheusMetricsRepository(cfg *config.PrometheusConfig) (repository.Me
 	}, nil
 }
 
+// newRemoteWriteAuthConfig returns the Remote Write credentials, loading the configured
+// credential files. A password file takes precedence over the inline password.
+func newRemoteWriteAuthConfig(cfg *config.PrometheusConfig) (*AuthConfig, error) {
+	authConfig := &AuthConfig{
+		Username: cfg.RemoteWriteUsername,
+		Password: cfg.RemoteWritePassword,
+	}
+	if cfg.RemoteWriteTokenFile != "" {
+		tokenFile, err := config.LoadSecretFile(cfg.RemoteWriteTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		authConfig.TokenFile = tokenFile
+		return authConfig, nil
+	}
+	if cfg.RemoteWritePasswordFile != "" {
+		passwordFile, err := config.LoadSecretFile(cfg.RemoteWritePasswordFile)
+		if err != nil {
+			return nil, err
+		}
+		authConfig.PasswordFile = passwordFile
+		authConfig.Password = ""
+	}
+	if authConfig.Username == "" || (authConfig.Password == "" && authConfig.PasswordFile == nil) {
+		return nil, nil
+	}
+	return authConfig, nil
+}
+
 // SendTokenMetric sends the total token count metric to Prometheus
-func (r *PrometheusMetricsRepository) SendTokenMetric(totalTokens int, hostLabel string, metricName string) error {
+func (r *PrometheusMetricsRepository) SendTokenMetric(totalTokens int64, hostLabel string, metricName string) error {
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(r.config.TimeoutSec)*time.Second)
 	defer cancel()
@@ -79,7 +104,7 @@ func (r *PrometheusMetricsRepository) SendTokenMetric(totalTokens int, hostLabel
 	// Only add host label if it's not empty (don't use default if explicitly passed as empty)
 	if hostLabel != "" {
 		labels["host"] = hostLabel
-	} else if metricName == "tosage_cc_token" || metricName == "tosage_cursor_token" {
+	} else if usesDefaultHostLabel(metricName) {
 		// For CC and Cursor metrics, use default host label if not provided
 		labels["host"] = r.hostLabel
 	}
@@ -97,7 +122,7 @@ func (r *PrometheusMetricsRepository) SendTokenMetric(totalTokens int, hostLabel
 }
 
 // SendTokenMetricWithTimezone sends the total token count metric with timezone information
-func (r *PrometheusMetricsRepository) SendTokenMetricWithTimezone(totalTokens int, hostLabel string, metricName string, timezoneInfo repository.TimezoneInfo) error {
+func (r *PrometheusMetricsRepository) SendTokenMetricWithTimezone(totalTokens int64, hostLabel string, metricName string, timezoneInfo repository.TimezoneInfo) error {
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(r.config.TimeoutSec)*time.Second)
 	defer cancel()
@@ -112,7 +137,7 @@ func (r *PrometheusMetricsRepository) SendTokenMetricWithTimezone(totalTokens in
 	// Only add host label if it's not empty (don't use default if explicitly passed as empty)
 	if hostLabel != "" {
 		labels["host"] = hostLabel
-	} else if metricName == "tosage_cc_token" || metricName == "tosage_cursor_token" {
+	} else if usesDefaultHostLabel(metricName) {
 		// For CC and Cursor metrics, use default host label if not provided
 		labels["host"] = r.hostLabel
 	}
@@ -129,6 +154,89 @@ func (r *PrometheusMetricsRepository) SendTokenMetricWithTimezone(totalTokens in
 	return nil
 }
 
+// SendTokenMetricWithLabels sends the total token count metric with additional series labels
+func (r *PrometheusMetricsRepository) SendTokenMetricWithLabels(totalTokens int64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	if hostLabel == "" && usesDefaultHostLabel(metricName) {
+		hostLabel = r.hostLabel
+	}
+	return r.SendMetricValue(float64(totalTokens), hostLabel, metricName, labels, timezoneInfo)
+}
+
+// SendMetricValue sends a metric value without rounding it to a token count
+func (r *PrometheusMetricsRepository) SendMetricValue(value float64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(r.config.TimeoutSec)*time.Second)
+	defer cancel()
+
+	err := r.rwClient.SendGaugeMetric(ctx, metricName, value, seriesLabels(hostLabel, labels, timezoneInfo))
+	if err != nil {
+		if ctx.Err() != nil {
+			return repository.NewMetricsRepositoryError("send", fmt.Errorf("timeout: %w", err))
+		}
+		return repository.NewMetricsRepositoryError("send", err)
+	}
+
+	return nil
+}
+
+// Probe checks that the Remote Write endpoint is reachable and accepts the credentials
+func (r *PrometheusMetricsRepository) Probe(ctx context.Context) error {
+	if err := r.rwClient.Probe(ctx); err != nil {
+		return repository.NewMetricsRepositoryError("probe", err)
+	}
+	return nil
+}
+
+// seriesLabels combines extra labels with the timezone and host labels, which take precedence
+func seriesLabels(hostLabel string, extra map[string]string, timezoneInfo *repository.TimezoneInfo) map[string]string {
+	labels := make(map[string]string, len(extra)+4)
+	for name, value := range extra {
+		labels[name] = value
+	}
+	if timezoneInfo != nil {
+		labels["timezone"] = timezoneInfo.Name
+		labels["timezone_offset"] = timezoneInfo.Offset
+		labels["detection_method"] = timezoneInfo.DetectionMethod
+	}
+	if hostLabel != "" {
+		labels["host"] = hostLabel
+	}
+	return labels
+}
+
+// CheckConnection verifies the Remote Write endpoint is reachable within the context deadline
+func (r *PrometheusMetricsRepository) CheckConnection(ctx context.Context) error {
+	return r.Probe(ctx)
+}
+
+// resolveHostLabel returns the configured host label or the hostname if it is empty
+func resolveHostLabel(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		// Fall back to "unknown" if hostname cannot be determined
+		return "unknown"
+	}
+	return hostname
+}
+
+// usesDefaultHostLabel reports whether a metric gets the default host label when none is given.
+// Claude Code and Cursor usage is local to the machine; cloud provider usage is not.
+func usesDefaultHostLabel(metricName string) bool {
+	switch metricName {
+	case "tosage_cc_token", "tosage_cc_info", "tosage_cc_token_all", "tosage_cc_tokens_delta", "tosage_cc_last_entry_age_seconds", "tosage_cc_stale", "tosage_up", "tosage_last_collection_timestamp_seconds", "tosage_cc_session_token", "tosage_cc_source_path_token", "tosage_cursor_token", "tosage_cursor_billing_period_token", "tosage_cursor_billing_cycle_token", "tosage_cursor_parse_ok",
+		"tosage_cc_session_tokens_p50", "tosage_cc_session_tokens_p90", "tosage_cc_session_tokens_p99", "tosage_cc_session_tokens_max",
+		"tosage_cc_unique_projects", "tosage_cc_unique_models", "tosage_cc_unique_sessions",
+		"tosage_cursor_premium_requests", "tosage_cursor_premium_requests_limit",
+		"tosage_cursor_usage_cost_cents", "tosage_cursor_mid_month_payment_cents", "tosage_cursor_unpaid_invoice",
+		"tosage_cursor_tool_calls", "tosage_cursor_token_based_calls",
+		"tosage_cursor_usage_based_enabled", "tosage_cursor_spend_limit_dollars":
+		return true
+	}
+	return false
+}
+
 // Close cleans up resources
 func (r *PrometheusMetricsRepository) Close() error {
 	// Remote Write client doesn't require explicit cleanup
@@ -3,6 +3,8 @@ package repository
 import (
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/ca-srg/tosage/infrastructure/config"
@@ -103,6 +105,16 @@ func TestPrometheusMetricsRepository_SendTokenMetric(t *testing.T) {
 }
 
 func TestPrometheusMetricsRepository_WithAuth(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(passwordFile, []byte("filepass\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tokenFile, []byte("bearer-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
 	tests := []struct {
 		name           string
 		config         *config.PrometheusConfig
@@ -120,6 +132,28 @@ func TestPrometheusMetricsRepository_WithAuth(t *testing.T) {
 			expectedHeader: "Authorization",
 			expectedValue:  "Basic dGVzdHVzZXI6dGVzdHBhc3M=", // base64("testuser:testpass")
 		},
+		{
+			name: "password file takes precedence",
+			config: &config.PrometheusConfig{
+				RemoteWriteURL:          "placeholder",
+				RemoteWriteUsername:     "testuser",
+				RemoteWritePassword:     "testpass",
+				RemoteWritePasswordFile: passwordFile,
+				TimeoutSec:              30,
+			},
+			expectedHeader: "Authorization",
+			expectedValue:  "Basic dGVzdHVzZXI6ZmlsZXBhc3M=", // base64("testuser:filepass")
+		},
+		{
+			name: "bearer token file",
+			config: &config.PrometheusConfig{
+				RemoteWriteURL:       "placeholder",
+				RemoteWriteTokenFile: tokenFile,
+				TimeoutSec:           30,
+			},
+			expectedHeader: "Authorization",
+			expectedValue:  "Bearer bearer-token",
+		},
 	}
 
 	for _, tt := range tests {
@@ -223,7 +257,7 @@ func TestPrometheusMetricsRepository_SendTokenMetric_CursorMetrics(t *testing.T)
 
 	tests := []struct {
 		name       string
-		tokens     int
+		tokens     int64
 		hostLabel  string
 		metricName string
 		wantErr    bool
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ca-srg/tosage/domain/entity"
+	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/infrastructure/config"
+	"github.com/ca-srg/tosage/infrastructure/httpclient"
+)
+
+// prometheusQueryPath is the instant query endpoint of the Prometheus HTTP API
+const prometheusQueryPath = "/api/v1/query"
+
+// QueryLookback is how far back QueryLatest looks for a sample
+const QueryLookback = 7 * 24 * time.Hour
+
+// PrometheusQueryRepository implements MetricsQueryRepository using the Prometheus HTTP API
+type PrometheusQueryRepository struct {
+	queryURL string
+	username string
+	password string
+	timeout  time.Duration
+	client   *http.Client
+}
+
+// NewPrometheusQueryRepository creates a query repository for the configured query URL.
+// The URL may be the server's base URL or its full /api/v1/query endpoint.
+func NewPrometheusQueryRepository(cfg *config.PrometheusConfig) (*PrometheusQueryRepository, error) {
+	if cfg == nil || cfg.URL == "" {
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("prometheus query url is empty"))
+	}
+
+	queryURL := strings.TrimSuffix(cfg.URL, "/")
+	if !strings.HasSuffix(queryURL, prometheusQueryPath) {
+		queryURL += prometheusQueryPath
+	}
+
+	timeout := time.Duration(cfg.TimeoutSec) * time.Second
+	return &PrometheusQueryRepository{
+		queryURL: queryURL,
+		username: cfg.Username,
+		password: cfg.Password,
+		timeout:  timeout,
+		client:   httpclient.NewClient(timeout),
+	}, nil
+}
+
+// queryResponse is the subset of the Prometheus query API response used here
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Value  [2]interface{}    `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// timestampResolution is the subquery step used to find when the latest sample was pushed.
+// It is finer than any push interval, so every push is seen.
+const timestampResolution = time.Minute
+
+// QueryLatest returns the most recent sample of metric within QueryLookback. Only the last value
+// of each series is read (last_over_time), and a second query finds when it was pushed, so the
+// returned timestamp is the push time rather than the query time.
+func (r *PrometheusQueryRepository) QueryLatest(metric string, labels map[string]string) (*entity.MetricSample, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	selector := metric + labelSelector(labels)
+	values, err := r.query(ctx, fmt.Sprintf("last_over_time(%s[%s])", selector, formatLookback(QueryLookback)))
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	pushTimes, err := r.query(ctx, fmt.Sprintf("last_over_time(timestamp(%s)[%s:%s])",
+		selector, formatLookback(QueryLookback), formatLookback(timestampResolution)))
+	if err != nil {
+		return nil, err
+	}
+	pushed := make(map[string]float64, len(pushTimes))
+	for _, series := range pushTimes {
+		pushed[labelSelector(series.Labels)] = series.Value
+	}
+
+	var latest *entity.MetricSample
+	for _, series := range values {
+		seconds, ok := pushed[labelSelector(series.Labels)]
+		if !ok {
+			continue
+		}
+		series.Timestamp = time.UnixMilli(int64(seconds * 1000))
+		if latest == nil || series.Timestamp.After(latest.Timestamp) {
+			latest = series
+		}
+	}
+	return latest, nil
+}
+
+// query runs an instant query and returns one sample per series of the resulting vector
+func (r *PrometheusQueryRepository) query(ctx context.Context, query string) ([]*entity.MetricSample, error) {
+	params := url.Values{}
+	params.Set("query", query)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, r.queryURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, repository.NewMetricsRepositoryError("query", fmt.Errorf("failed to create request: %w", err))
+	}
+	if r.username != "" || r.password != "" {
+		httpReq.SetBasicAuth(r.username, r.password)
+	}
+
+	resp, err := r.client.Do(httpReq)
+	if err != nil {
+		return nil, repository.NewMetricsRepositoryError("query", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, repository.NewMetricsRepositoryError("query", fmt.Errorf("failed to read response: %w", err))
+	}
+
+	var parsed queryResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, repository.NewMetricsRepositoryError("query", fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body))))
+		}
+		return nil, repository.NewMetricsRepositoryError("query", fmt.Errorf("failed to decode response: %w", err))
+	}
+	if parsed.Status != "success" {
+		return nil, repository.NewMetricsRepositoryError("query", fmt.Errorf("status %d: %s", resp.StatusCode, parsed.Error))
+	}
+	if parsed.Data.ResultType != "vector" {
+		return nil, repository.NewMetricsRepositoryError("query", fmt.Errorf("unexpected result type %q", parsed.Data.ResultType))
+	}
+
+	samples := make([]*entity.MetricSample, 0, len(parsed.Data.Result))
+	for _, series := range parsed.Data.Result {
+		sample, err := parseSample(series.Value)
+		if err != nil {
+			return nil, repository.NewMetricsRepositoryError("query", err)
+		}
+		sample.Labels = series.Metric
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
+
+// parseSample converts a [timestamp, "value"] pair from the query API
+func parseSample(pair [2]interface{}) (*entity.MetricSample, error) {
+	seconds, ok := pair[0].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid sample timestamp %v", pair[0])
+	}
+	text, ok := pair[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid sample value %v", pair[1])
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sample value %q: %w", text, err)
+	}
+	return &entity.MetricSample{
+		Value:     value,
+		Timestamp: time.UnixMilli(int64(seconds * 1000)),
+	}, nil
+}
+
+// labelSelector formats labels as a PromQL selector, e.g. {host="a"}
+func labelSelector(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	matchers := make([]string, len(names))
+	for i, name := range names {
+		matchers[i] = name + "=" + strconv.Quote(labels[name])
+	}
+	return "{" + strings.Join(matchers, ",") + "}"
+}
+
+// formatLookback formats a duration as a PromQL range in whole seconds
+func formatLookback(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+}
+
+var _ repository.MetricsQueryRepository = (*PrometheusQueryRepository)(nil)
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+func TestPrometheusQueryRepository_QueryLatest(t *testing.T) {
+	var gotPath, gotUser, gotPass string
+	var gotQueries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		query := r.URL.Query().Get("query")
+		gotQueries = append(gotQueries, query)
+		gotUser, gotPass, _ = r.BasicAuth()
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(query, "timestamp(") {
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"host":"a","timezone":"UTC"},"value":[1700090000,"1700000600"]},
+				{"metric":{"host":"a","timezone":"Asia/Tokyo"},"value":[1700090000,"1700001200"]}
+			]}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"host":"a","timezone":"UTC"},"value":[1700090000,"150"]},
+			{"metric":{"host":"a","timezone":"Asia/Tokyo"},"value":[1700090000,"175"]}
+		]}}`))
+	}))
+	defer server.Close()
+
+	repo, err := NewPrometheusQueryRepository(&config.PrometheusConfig{
+		URL:        server.URL + "/",
+		Username:   "user",
+		Password:   "pass",
+		TimeoutSec: 5,
+	})
+	if err != nil {
+		t.Fatalf("NewPrometheusQueryRepository() error = %v", err)
+	}
+
+	sample, err := repo.QueryLatest("tosage_cc_token", map[string]string{"host": "a", "model": ""})
+	if err != nil {
+		t.Fatalf("QueryLatest() error = %v", err)
+	}
+
+	if gotPath != "/api/v1/query" {
+		t.Errorf("path = %q, want /api/v1/query", gotPath)
+	}
+	wantQueries := []string{
+		`last_over_time(tosage_cc_token{host="a",model=""}[604800s])`,
+		`last_over_time(timestamp(tosage_cc_token{host="a",model=""})[604800s:60s])`,
+	}
+	if strings.Join(gotQueries, "\n") != strings.Join(wantQueries, "\n") {
+		t.Errorf("queries = %q, want %q", gotQueries, wantQueries)
+	}
+	if gotUser != "user" || gotPass != "pass" {
+		t.Errorf("basic auth = %q/%q, want user/pass", gotUser, gotPass)
+	}
+	if sample == nil {
+		t.Fatal("QueryLatest() returned no sample")
+	}
+	if sample.Value != 175 {
+		t.Errorf("Value = %v, want 175", sample.Value)
+	}
+	if !sample.Timestamp.Equal(time.Unix(1700001200, 0)) {
+		t.Errorf("Timestamp = %v, want %v", sample.Timestamp, time.Unix(1700001200, 0))
+	}
+	if sample.Labels["timezone"] != "Asia/Tokyo" {
+		t.Errorf("Labels = %v, want the latest series' labels", sample.Labels)
+	}
+}
+
+func TestPrometheusQueryRepository_QueryLatestNoData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	defer server.Close()
+
+	repo, err := NewPrometheusQueryRepository(&config.PrometheusConfig{URL: server.URL + "/api/v1/query", TimeoutSec: 5})
+	if err != nil {
+		t.Fatalf("NewPrometheusQueryRepository() error = %v", err)
+	}
+
+	sample, err := repo.QueryLatest("tosage_cc_token", nil)
+	if err != nil {
+		t.Fatalf("QueryLatest() error = %v", err)
+	}
+	if sample != nil {
+		t.Errorf("QueryLatest() = %+v, want nil", sample)
+	}
+}
+
+func TestPrometheusQueryRepository_QueryLatestError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+	}))
+	defer server.Close()
+
+	repo, err := NewPrometheusQueryRepository(&config.PrometheusConfig{URL: server.URL, TimeoutSec: 5})
+	if err != nil {
+		t.Fatalf("NewPrometheusQueryRepository() error = %v", err)
+	}
+
+	_, err = repo.QueryLatest("tosage_cc_token", nil)
+	if err == nil || !strings.Contains(err.Error(), "parse error") {
+		t.Errorf("QueryLatest() error = %v, want the API error", err)
+	}
+}
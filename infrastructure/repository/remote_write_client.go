@@ -2,6 +2,7 @@ package repository
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/base64"
 	"fmt"
@@ -11,20 +12,28 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ca-srg/tosage/infrastructure/config"
+	"github.com/ca-srg/tosage/infrastructure/httpclient"
 	"github.com/golang/snappy"
 )
 
 // RemoteWriteClient handles sending metrics to Prometheus Remote Write endpoint
 type RemoteWriteClient struct {
-	url        string
-	client     *http.Client
-	authConfig *AuthConfig
+	url         string
+	client      *http.Client
+	authConfig  *AuthConfig
+	compression string
 }
 
-// AuthConfig holds authentication configuration (basic auth only)
+// AuthConfig holds basic authentication configuration. Client certificates
+// are configured separately with SetClientCertificate.
 type AuthConfig struct {
 	Username string
 	Password string
+	// PasswordFile, when set, supplies the password instead of Password
+	PasswordFile *config.SecretFile
+	// TokenFile, when set, supplies a bearer token sent instead of basic authentication
+	TokenFile *config.SecretFile
 }
 
 // NewRemoteWriteClient creates a new Remote Write client
@@ -33,17 +42,62 @@ func NewRemoteWriteClient(url string, timeout time.Duration, authConfig *AuthCon
 		return nil, fmt.Errorf("remote write URL is required")
 	}
 
-	client := &http.Client{
-		Timeout: timeout,
-	}
+	client := httpclient.NewClient(timeout)
 
 	return &RemoteWriteClient{
-		url:        url,
-		client:     client,
-		authConfig: authConfig,
+		url:         url,
+		client:      client,
+		authConfig:  authConfig,
+		compression: config.CompressionSnappy,
 	}, nil
 }
 
+// SetCompression sets the payload compression method (snappy, gzip or none).
+// An empty value selects snappy, the Remote Write default.
+func (c *RemoteWriteClient) SetCompression(compression string) error {
+	switch compression {
+	case "":
+		c.compression = config.CompressionSnappy
+	case config.CompressionSnappy, config.CompressionGzip, config.CompressionNone:
+		c.compression = compression
+	default:
+		return fmt.Errorf("unsupported compression %q", compression)
+	}
+	return nil
+}
+
+// SetClientCertificate makes the client present the given certificate to the
+// endpoint (mTLS). It can be combined with basic authentication.
+func (c *RemoteWriteClient) SetClientCertificate(certPath, keyPath string) error {
+	client, err := httpclient.NewClientWithCertificate(c.client.Timeout, certPath, keyPath)
+	if err != nil {
+		return err
+	}
+	c.client = client
+	return nil
+}
+
+// compress compresses the payload with the configured method and returns
+// the value for the Content-Encoding header (empty for no compression)
+func (c *RemoteWriteClient) compress(data []byte) ([]byte, string, error) {
+	switch c.compression {
+	case config.CompressionGzip:
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(data); err != nil {
+			return nil, "", err
+		}
+		if err := gz.Close(); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "gzip", nil
+	case config.CompressionNone:
+		return data, "", nil
+	default:
+		return snappy.Encode(nil, data), "snappy", nil
+	}
+}
+
 // RetryConfig holds retry configuration
 type RetryConfig struct {
 	MaxRetries int
@@ -108,8 +162,31 @@ func (c *RemoteWriteClient) sendGaugeMetricOnce(ctx context.Context, metricName
 		return fmt.Errorf("failed to encode write request: %w", err)
 	}
 
-	// Compress with Snappy
-	compressed := snappy.Encode(nil, data)
+	return c.post(ctx, data)
+}
+
+// Probe sends an empty write request to check that the endpoint is reachable
+// and accepts the configured credentials. No samples are written.
+func (c *RemoteWriteClient) Probe(ctx context.Context) error {
+	err := c.post(ctx, nil)
+	if err == nil {
+		return nil
+	}
+
+	errMsg := err.Error()
+	if strings.Contains(errMsg, "status 401") || strings.Contains(errMsg, "status 403") {
+		return fmt.Errorf("authentication rejected by %s: %w", c.url, err)
+	}
+	return fmt.Errorf("remote write endpoint %s is unreachable: %w", c.url, err)
+}
+
+// post sends an encoded write request to the Remote Write endpoint
+func (c *RemoteWriteClient) post(ctx context.Context, data []byte) error {
+	// Compress with the configured method
+	compressed, contentEncoding, err := c.compress(data)
+	if err != nil {
+		return fmt.Errorf("failed to compress request: %w", err)
+	}
 
 	// Create HTTP request
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(compressed))
@@ -119,7 +196,9 @@ func (c *RemoteWriteClient) sendGaugeMetricOnce(ctx context.Context, metricName
 
 	// Set headers for protobuf format
 	httpReq.Header.Set("Content-Type", "application/x-protobuf")
-	httpReq.Header.Set("Content-Encoding", "snappy")
+	if contentEncoding != "" {
+		httpReq.Header.Set("Content-Encoding", contentEncoding)
+	}
 	httpReq.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
 
 	// Add authentication
@@ -144,7 +223,7 @@ func (c *RemoteWriteClient) sendGaugeMetricOnce(ctx context.Context, metricName
 		// Log password on 401 error for debugging
 		if resp.StatusCode == http.StatusUnauthorized {
 			password := os.Getenv("TOSAGE_PROMETHEUS_REMOTE_WRITE_PASSWORD")
-			fmt.Fprintf(os.Stderr, "[AUTH DEBUG] 401 error occurred. TOSAGE_PROMETHEUS_REMOTE_WRITE_PASSWORD=%q\n", password)
+			config.Debugf("[AUTH DEBUG] 401 error occurred. TOSAGE_PROMETHEUS_REMOTE_WRITE_PASSWORD=%q\n", password)
 		}
 		return fmt.Errorf("remote write failed with status %d: %s", resp.StatusCode, string(body))
 	}
@@ -158,11 +237,19 @@ func (c *RemoteWriteClient) addAuthentication(req *http.Request) error {
 		return nil
 	}
 
-	// Always use basic authentication
-	if c.authConfig.Username == "" || c.authConfig.Password == "" {
+	if c.authConfig.TokenFile != nil {
+		req.Header.Set("Authorization", "Bearer "+c.authConfig.TokenFile.Value())
+		return nil
+	}
+
+	password := c.authConfig.Password
+	if c.authConfig.PasswordFile != nil {
+		password = c.authConfig.PasswordFile.Value()
+	}
+	if c.authConfig.Username == "" || password == "" {
 		return fmt.Errorf("basic auth requires username and password")
 	}
-	auth := base64.StdEncoding.EncodeToString([]byte(c.authConfig.Username + ":" + c.authConfig.Password))
+	auth := base64.StdEncoding.EncodeToString([]byte(c.authConfig.Username + ":" + password))
 	req.Header.Set("Authorization", "Basic "+auth)
 
 	return nil
@@ -2,14 +2,18 @@ package repository
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/golang/snappy"
 )
 
 func TestNewRemoteWriteClient(t *testing.T) {
@@ -369,14 +373,141 @@ func TestSendGaugeMetric_401Error(t *testing.T) {
 	}
 	output := buf.String()
 
-	// Check that password was logged
+	// Check that the debug line was logged with the password masked
 	if !strings.Contains(output, "[AUTH DEBUG]") {
 		t.Error("expected [AUTH DEBUG] in stderr output")
 	}
 	if !strings.Contains(output, "401 error occurred") {
 		t.Error("expected '401 error occurred' in stderr output")
 	}
-	if !strings.Contains(output, testPassword) {
-		t.Errorf("expected password %q in stderr output, got: %s", testPassword, output)
+	if strings.Contains(output, testPassword) || !strings.Contains(output, "PASSWORD=****") {
+		t.Errorf("expected password %q to be masked in stderr output, got: %s", testPassword, output)
+	}
+}
+
+func TestProbe(t *testing.T) {
+	tests := []struct {
+		name           string
+		serverResponse int
+		wantErr        bool
+		errContains    string
+	}{
+		{
+			name:           "reachable",
+			serverResponse: http.StatusNoContent,
+			wantErr:        false,
+		},
+		{
+			name:           "auth rejected",
+			serverResponse: http.StatusForbidden,
+			wantErr:        true,
+			errContains:    "authentication rejected",
+		},
+		{
+			name:           "server error",
+			serverResponse: http.StatusBadGateway,
+			wantErr:        true,
+			errContains:    "unreachable",
+		},
 	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestCount := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestCount++
+				w.WriteHeader(tt.serverResponse)
+			}))
+			defer server.Close()
+
+			client, err := NewRemoteWriteClient(server.URL, 5*time.Second, nil)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			err = client.Probe(context.Background())
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				} else if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("error = %v, want error containing %v", err, tt.errContains)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			// The probe never retries
+			if requestCount != 1 {
+				t.Errorf("expected 1 request, got %d", requestCount)
+			}
+		})
+	}
+}
+
+func TestSendGaugeMetric_Compression(t *testing.T) {
+	tests := []struct {
+		name         string
+		compression  string
+		wantEncoding string
+		decode       func([]byte) ([]byte, error)
+	}{
+		{
+			name:         "default snappy",
+			compression:  "",
+			wantEncoding: "snappy",
+			decode:       func(b []byte) ([]byte, error) { return snappy.Decode(nil, b) },
+		},
+		{
+			name:         "gzip",
+			compression:  "gzip",
+			wantEncoding: "gzip",
+			decode: func(b []byte) ([]byte, error) {
+				gz, err := gzip.NewReader(bytes.NewReader(b))
+				if err != nil {
+					return nil, err
+				}
+				return io.ReadAll(gz)
+			},
+		},
+		{
+			name:         "none",
+			compression:  "none",
+			wantEncoding: "",
+			decode:       func(b []byte) ([]byte, error) { return b, nil },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Content-Encoding"); got != tt.wantEncoding {
+					t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+				}
+				body, _ := io.ReadAll(r.Body)
+				decoded, err := tt.decode(body)
+				if err != nil {
+					t.Errorf("failed to decode body: %v", err)
+				} else if !bytes.Contains(decoded, []byte("test_metric")) {
+					t.Errorf("decoded payload does not contain the metric name")
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			client, _ := NewRemoteWriteClient(server.URL, 5*time.Second, nil)
+			if err := client.SetCompression(tt.compression); err != nil {
+				t.Fatalf("SetCompression() error = %v", err)
+			}
+			if err := client.SendGaugeMetric(context.Background(), "test_metric", 1, map[string]string{"host": "h"}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		client, _ := NewRemoteWriteClient("http://localhost", 5*time.Second, nil)
+		if err := client.SetCompression("zstd"); err == nil {
+			t.Error("expected error for unsupported compression")
+		}
+	})
 }
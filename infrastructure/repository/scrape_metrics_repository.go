@@ -0,0 +1,475 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ca-srg/tosage/domain"
+	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+const (
+	// textContentType is the content type of the Prometheus text exposition format 0.0.4
+	textContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+	// openMetricsContentType is the content type of the OpenMetrics text format 1.0.0
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+	metricTypeGauge = "gauge"
+
+	// lastCollectionMetric is the metric the metrics service sends at the end of each collection
+	lastCollectionMetric = "tosage_last_collection_timestamp_seconds"
+
+	// maxRefreshWait bounds how long a scrape waits for an on-demand collection, matching
+	// Prometheus' default scrape timeout; a slower collection is served on the next scrape
+	maxRefreshWait = 10 * time.Second
+)
+
+// scrapeMetricHelp holds HELP text for the metrics tosage emits
+var scrapeMetricHelp = map[string]string{
+	"tosage_cc_token":                       "Claude Code tokens used today",
+	"tosage_cc_info":                        "Always 1, labeled with values derived from today's Claude Code usage",
+	"tosage_cc_token_all":                   "Claude Code tokens used today over every token component",
+	"tosage_cc_tokens_delta":                "Claude Code tokens used since the previous push",
+	"tosage_cc_session_token":               "Claude Code tokens used today by one of the largest sessions",
+	"tosage_cc_source_path_token":           "Claude Code tokens used today, read from one data directory",
+	"tosage_cc_session_tokens_p50":          "Median of today's Claude Code tokens per session",
+	"tosage_cc_session_tokens_p90":          "90th percentile of today's Claude Code tokens per session",
+	"tosage_cc_session_tokens_p99":          "99th percentile of today's Claude Code tokens per session",
+	"tosage_cc_session_tokens_max":          "Tokens used today by the largest Claude Code session",
+	"tosage_cc_unique_projects":             "Number of projects Claude Code was used in today",
+	"tosage_cc_unique_models":               "Number of models Claude Code used today",
+	"tosage_cc_unique_sessions":             "Number of Claude Code sessions today",
+	"tosage_cursor_token":                   "Cursor tokens used today",
+	"tosage_cursor_billing_period_token":    "Cursor tokens used in the current billing period",
+	"tosage_cursor_member_token":            "Cursor tokens used today by a team member",
+	"tosage_cursor_billing_cycle_token":     "Cursor tokens used since the billing cycle start reported by Cursor",
+	"tosage_cursor_parse_ok":                "1 if every Cursor API response of the cycle was understood, 0 if its format changed",
+	"tosage_cursor_premium_requests":        "Cursor premium requests used this month",
+	"tosage_cursor_premium_requests_limit":  "Cursor monthly premium request limit",
+	"tosage_cursor_usage_cost_cents":        "Cursor usage-based cost of a billing month in cents",
+	"tosage_cursor_mid_month_payment_cents": "Cursor usage paid mid-month in a billing month in cents",
+	"tosage_cursor_unpaid_invoice":          "1 while a billing month has an unpaid mid-month invoice",
+	"tosage_cursor_tool_calls":              "Cursor tool calls billed in the current billing month",
+	"tosage_cursor_token_based_calls":       "Cursor token-based calls billed in the current billing month",
+	"tosage_cursor_usage_based_enabled":     "1 while Cursor usage-based pricing is enabled",
+	"tosage_cursor_spend_limit_dollars":     "Cursor usage-based pricing hard limit in dollars",
+	"tosage_bedrock_input_token":            "AWS Bedrock input tokens used today",
+	"tosage_bedrock_output_token":           "AWS Bedrock output tokens used today",
+	"tosage_bedrock_total_token":            "AWS Bedrock total tokens used today",
+	"tosage_bedrock_model_input_token":      "AWS Bedrock input tokens used today by a model",
+	"tosage_bedrock_model_output_token":     "AWS Bedrock output tokens used today by a model",
+	"tosage_bedrock_model_total_token":      "AWS Bedrock total tokens used today by a model",
+	"tosage_vertex_ai_input_token":          "Google Vertex AI input tokens used today",
+	"tosage_vertex_ai_output_token":         "Google Vertex AI output tokens used today",
+	"tosage_vertex_ai_total_token":          "Google Vertex AI total tokens used today",
+	"tosage_vertex_ai_model_input_token":    "Google Vertex AI input tokens used today by a model",
+	"tosage_vertex_ai_model_output_token":   "Google Vertex AI output tokens used today by a model",
+	"tosage_vertex_ai_model_total_token":    "Google Vertex AI total tokens used today by a model",
+	"tosage_vertex_ai_request_count":        "Google Vertex AI requests made today",
+	"tosage_vertex_ai_latency_ms":           "Google Vertex AI average response latency today in milliseconds",
+	"tosage_vertex_ai_model_request_count":  "Google Vertex AI requests made today to a model",
+	"tosage_vertex_ai_model_latency_ms":     "Google Vertex AI average response latency of a model today in milliseconds",
+
+	"tosage_cc_last_entry_age_seconds":         "Seconds since the newest Claude Code entry was written",
+	"tosage_cc_stale":                          "1 while the Claude Code push is skipped because its newest entry is too old",
+	"tosage_collection_duration_seconds":       "Seconds each source took to collect usage in the last cycle",
+	"tosage_last_collection_timestamp_seconds": "Unix time the last collection finished, to detect stale scraped values",
+	"tosage_up":                        "1 every cycle tosage runs, regardless of usage",
+	"tosage_remote_write_circuit_open": "1 while the Remote Write circuit breaker is skipping pushes",
+}
+
+// ScrapeMetricsRepository wraps another MetricsRepository and additionally
+// exposes the most recently sent values on a Prometheus scrape endpoint.
+// The endpoint serves OpenMetrics when the scraper asks for it via the
+// Accept header and the legacy text format otherwise.
+type ScrapeMetricsRepository struct {
+	delegate  repository.MetricsRepository
+	hostLabel string
+
+	mu       sync.RWMutex
+	families map[string]*scrapeFamily
+
+	// lastCollection is when the last collection finished, from tosage_last_collection_timestamp_seconds
+	lastCollection time.Time
+
+	// cycle counts finished collections. Samples remember the cycle they were last recorded in,
+	// so series dropped from a family (e.g. by a top-N cap) can be pruned when the cycle ends.
+	cycle uint64
+
+	// collect runs an on-demand collection when a scrape finds lastCollection older than
+	// refreshAfter, if set. refreshing is closed when the running collection finishes.
+	collect      func()
+	refreshAfter time.Duration
+	refreshMu    sync.Mutex
+	refreshing   chan struct{}
+	lastRefresh  time.Time
+
+	server *http.Server
+}
+
+// scrapeFamily is a metric family with its samples keyed by label signature
+type scrapeFamily struct {
+	name    string
+	help    string
+	typ     string
+	samples map[string]*scrapeSample
+}
+
+// scrapeSample is a single series of a metric family
+type scrapeSample struct {
+	labels map[string]string
+	value  float64
+	cycle  uint64
+}
+
+// NewScrapeMetricsRepository creates a scrape endpoint repository in front of delegate
+func NewScrapeMetricsRepository(delegate repository.MetricsRepository, cfg *config.PrometheusConfig) (*ScrapeMetricsRepository, error) {
+	if delegate == nil {
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("delegate metrics repository is nil"))
+	}
+	if cfg == nil {
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("prometheus config is nil"))
+	}
+
+	return &ScrapeMetricsRepository{
+		delegate:  delegate,
+		hostLabel: resolveHostLabel(cfg.HostLabel),
+		families:  make(map[string]*scrapeFamily),
+	}, nil
+}
+
+// SetOnDemandCollection makes a scrape run collect first when the last collection finished
+// more than refreshAfter ago. A zero refreshAfter serves the last-collected values as they are.
+func (r *ScrapeMetricsRepository) SetOnDemandCollection(collect func(), refreshAfter time.Duration) {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+	r.collect = collect
+	r.refreshAfter = refreshAfter
+}
+
+// Start starts serving /metrics on the given address. An error that stops the server
+// later on is reported to logger.
+func (r *ScrapeMetricsRepository) Start(addr string, logger domain.Logger) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return repository.NewMetricsRepositoryError("listen", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", r)
+
+	r.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := r.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error(context.Background(), "Metrics scrape endpoint stopped",
+				domain.NewField("address", addr),
+				domain.NewField("error", err.Error()))
+		}
+	}()
+
+	return nil
+}
+
+// SendTokenMetric records the value for the scrape endpoint and forwards it to the delegate
+func (r *ScrapeMetricsRepository) SendTokenMetric(totalTokens int64, hostLabel string, metricName string) error {
+	r.record(metricName, float64(totalTokens), r.buildLabels(hostLabel, metricName, nil))
+	return r.delegate.SendTokenMetric(totalTokens, hostLabel, metricName)
+}
+
+// SendTokenMetricWithTimezone records the value for the scrape endpoint and forwards it to the delegate
+func (r *ScrapeMetricsRepository) SendTokenMetricWithTimezone(totalTokens int64, hostLabel string, metricName string, timezoneInfo repository.TimezoneInfo) error {
+	r.record(metricName, float64(totalTokens), r.buildLabels(hostLabel, metricName, &timezoneInfo))
+	return r.delegate.SendTokenMetricWithTimezone(totalTokens, hostLabel, metricName, timezoneInfo)
+}
+
+// SendTokenMetricWithLabels records the value for the scrape endpoint and forwards it to the delegate
+func (r *ScrapeMetricsRepository) SendTokenMetricWithLabels(totalTokens int64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	r.record(metricName, float64(totalTokens), r.buildSeriesLabels(hostLabel, metricName, labels, timezoneInfo))
+	return r.delegate.SendTokenMetricWithLabels(totalTokens, hostLabel, metricName, labels, timezoneInfo)
+}
+
+// SendMetricValue records the value for the scrape endpoint and forwards it to the delegate.
+// The value is rounded if the delegate only accepts token counts.
+func (r *ScrapeMetricsRepository) SendMetricValue(value float64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	r.record(metricName, value, r.buildSeriesLabels(hostLabel, metricName, labels, timezoneInfo))
+	return sendMetricValue(r.delegate, value, hostLabel, metricName, labels, timezoneInfo)
+}
+
+// CheckConnection checks the delegate's backend, if it supports checks
+func (r *ScrapeMetricsRepository) CheckConnection(ctx context.Context) error {
+	return checkMetricsConnection(ctx, r.delegate)
+}
+
+// ExpireMetrics removes every series of the given metrics from the scrape endpoint, so values
+// that are no longer current aren't served as if they were
+func (r *ScrapeMetricsRepository) ExpireMetrics(metricNames ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range metricNames {
+		delete(r.families, name)
+	}
+}
+
+// Close stops the scrape endpoint and closes the delegate
+func (r *ScrapeMetricsRepository) Close() error {
+	if r.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = r.server.Shutdown(ctx)
+	}
+	return r.delegate.Close()
+}
+
+// ServeHTTP renders the recorded metrics, negotiating the exposition format
+func (r *ScrapeMetricsRepository) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	openMetrics := acceptsOpenMetrics(req.Header.Get("Accept"))
+
+	if done := r.startRefresh(time.Now()); done != nil {
+		timer := time.NewTimer(maxRefreshWait)
+		select {
+		case <-done:
+		case <-timer.C:
+		case <-req.Context().Done():
+		}
+		timer.Stop()
+	}
+
+	var buf bytes.Buffer
+	r.writeMetrics(&buf, openMetrics)
+
+	if openMetrics {
+		w.Header().Set("Content-Type", openMetricsContentType)
+	} else {
+		w.Header().Set("Content-Type", textContentType)
+	}
+	_, _ = w.Write(buf.Bytes())
+}
+
+// startRefresh starts an on-demand collection if the last collection is older than refreshAfter
+// and returns a channel closed once it finishes, or nil if the values are fresh enough. Scrapes
+// during a collection wait for the same one, and a collection that fails to record a new
+// timestamp is not retried within refreshAfter.
+func (r *ScrapeMetricsRepository) startRefresh(now time.Time) <-chan struct{} {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+
+	if r.collect == nil || r.refreshAfter <= 0 {
+		return nil
+	}
+	if r.refreshing != nil {
+		return r.refreshing
+	}
+
+	r.mu.RLock()
+	lastCollection := r.lastCollection
+	r.mu.RUnlock()
+	if now.Sub(lastCollection) < r.refreshAfter || now.Sub(r.lastRefresh) < r.refreshAfter {
+		return nil
+	}
+
+	done := make(chan struct{})
+	r.refreshing = done
+	r.lastRefresh = now
+	go func(collect func()) {
+		collect()
+		r.refreshMu.Lock()
+		r.refreshing = nil
+		r.refreshMu.Unlock()
+		close(done)
+	}(r.collect)
+	return done
+}
+
+// buildLabels builds series labels the same way the Remote Write repository does
+func (r *ScrapeMetricsRepository) buildLabels(hostLabel, metricName string, timezoneInfo *repository.TimezoneInfo) map[string]string {
+	labels := map[string]string{}
+	if timezoneInfo != nil {
+		labels["timezone"] = timezoneInfo.Name
+		labels["timezone_offset"] = timezoneInfo.Offset
+		labels["detection_method"] = timezoneInfo.DetectionMethod
+	}
+
+	if hostLabel != "" {
+		labels["host"] = hostLabel
+	} else if usesDefaultHostLabel(metricName) {
+		labels["host"] = r.hostLabel
+	}
+
+	return labels
+}
+
+// buildSeriesLabels adds extra labels to the labels built by buildLabels without overriding them
+func (r *ScrapeMetricsRepository) buildSeriesLabels(hostLabel, metricName string, extra map[string]string, timezoneInfo *repository.TimezoneInfo) map[string]string {
+	labels := r.buildLabels(hostLabel, metricName, timezoneInfo)
+	for name, value := range extra {
+		if _, exists := labels[name]; !exists {
+			labels[name] = value
+		}
+	}
+	return labels
+}
+
+// record stores the latest value of a series. Recording the last collection timestamp ends
+// the cycle and prunes the series of families sent in it that were not sent again.
+func (r *ScrapeMetricsRepository) record(metricName string, value float64, labels map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if metricName == lastCollectionMetric {
+		r.lastCollection = time.Unix(int64(value), 0)
+		defer r.endCycle()
+	}
+
+	family, exists := r.families[metricName]
+	if !exists {
+		help := scrapeMetricHelp[metricName]
+		if help == "" {
+			help = "Metric reported by tosage"
+		}
+		family = &scrapeFamily{
+			name:    metricName,
+			help:    help,
+			typ:     metricTypeGauge,
+			samples: make(map[string]*scrapeSample),
+		}
+		r.families[metricName] = family
+	}
+
+	key := formatLabels(labels)
+	if sample, exists := family.samples[key]; exists {
+		sample.value = value
+		sample.cycle = r.cycle
+		return
+	}
+	family.samples[key] = &scrapeSample{
+		labels: labels,
+		value:  value,
+		cycle:  r.cycle,
+	}
+}
+
+// endCycle removes the series that were not re-sent in the current cycle from the families
+// that were, and starts the next cycle. Families, or series with a source label, not sent at
+// all keep their values, as their source may be collected on a longer interval.
+// The caller must hold r.mu.
+func (r *ScrapeMetricsRepository) endCycle() {
+	for _, family := range r.families {
+		sent := make(map[string]bool)
+		for _, sample := range family.samples {
+			if sample.cycle == r.cycle {
+				sent[sample.labels["source"]] = true
+			}
+		}
+		for key, sample := range family.samples {
+			if sample.cycle != r.cycle && sent[sample.labels["source"]] {
+				delete(family.samples, key)
+			}
+		}
+	}
+	r.cycle++
+}
+
+// writeMetrics writes all families in the requested exposition format
+func (r *ScrapeMetricsRepository) writeMetrics(buf *bytes.Buffer, openMetrics bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		family := r.families[name]
+		fmt.Fprintf(buf, "# HELP %s %s\n", family.name, escapeHelp(family.help))
+		fmt.Fprintf(buf, "# TYPE %s %s\n", family.name, family.typ)
+
+		keys := make([]string, 0, len(family.samples))
+		for key := range family.samples {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			sample := family.samples[key]
+			buf.WriteString(family.name)
+			buf.WriteString(formatLabels(sample.labels))
+			buf.WriteByte(' ')
+			buf.WriteString(formatFloat(sample.value))
+			buf.WriteByte('\n')
+		}
+	}
+
+	if openMetrics {
+		buf.WriteString("# EOF\n")
+	}
+}
+
+// acceptsOpenMetrics reports whether the Accept header asks for OpenMetrics
+func acceptsOpenMetrics(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if mediaType == "application/openmetrics-text" {
+			return true
+		}
+	}
+	return false
+}
+
+// formatLabels renders a label set as {k="v",...} sorted by name
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(name)
+		sb.WriteString(`="`)
+		sb.WriteString(escapeLabelValue(labels[name]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// escapeLabelValue escapes backslash, double quote and newline
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// escapeHelp escapes backslash and newline in HELP text
+func escapeHelp(v string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(v)
+}
+
+// formatFloat formats a sample value
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+func newTestScrapeRepository(t *testing.T) *ScrapeMetricsRepository {
+	t.Helper()
+	repo, err := NewScrapeMetricsRepository(NewNoOpMetricsRepository(), &config.PrometheusConfig{HostLabel: "test-host"})
+	if err != nil {
+		t.Fatalf("NewScrapeMetricsRepository() error = %v", err)
+	}
+	return repo
+}
+
+func scrape(t *testing.T, repo *ScrapeMetricsRepository, accept string) (string, string) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	repo.ServeHTTP(rec, req)
+
+	body, err := io.ReadAll(rec.Result().Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	return rec.Result().Header.Get("Content-Type"), string(body)
+}
+
+func TestScrapeMetricsRepository_ContentNegotiation(t *testing.T) {
+	repo := newTestScrapeRepository(t)
+	if err := repo.SendTokenMetric(1234, "", "tosage_cc_token"); err != nil {
+		t.Fatalf("SendTokenMetric() error = %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		accept          string
+		wantContentType string
+		wantEOF         bool
+	}{
+		{
+			name:            "no accept header",
+			accept:          "",
+			wantContentType: textContentType,
+			wantEOF:         false,
+		},
+		{
+			name:            "text format",
+			accept:          "text/plain;version=0.0.4;q=0.5,*/*;q=0.1",
+			wantContentType: textContentType,
+			wantEOF:         false,
+		},
+		{
+			name:            "openmetrics",
+			accept:          "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5",
+			wantContentType: openMetricsContentType,
+			wantEOF:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, body := scrape(t, repo, tt.accept)
+			if contentType != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", contentType, tt.wantContentType)
+			}
+			if !strings.Contains(body, "# HELP tosage_cc_token ") {
+				t.Errorf("body missing HELP line:\n%s", body)
+			}
+			if !strings.Contains(body, "# TYPE tosage_cc_token gauge\n") {
+				t.Errorf("body missing TYPE line:\n%s", body)
+			}
+			if !strings.Contains(body, `tosage_cc_token{host="test-host"} 1234`+"\n") {
+				t.Errorf("body missing sample:\n%s", body)
+			}
+			if got := strings.HasSuffix(body, "# EOF\n"); got != tt.wantEOF {
+				t.Errorf("EOF marker present = %v, want %v:\n%s", got, tt.wantEOF, body)
+			}
+		})
+	}
+}
+
+func TestScrapeMetricsRepository_KeepsLatestValuePerSeries(t *testing.T) {
+	repo := newTestScrapeRepository(t)
+	tz := repository.TimezoneInfo{Name: "Asia/Tokyo", Offset: "+09:00", DetectionMethod: "config"}
+
+	_ = repo.SendTokenMetricWithTimezone(10, "", "tosage_bedrock_total_token", tz)
+	_ = repo.SendTokenMetricWithTimezone(20, "", "tosage_bedrock_total_token", tz)
+
+	_, body := scrape(t, repo, "")
+	want := `tosage_bedrock_total_token{detection_method="config",timezone="Asia/Tokyo",timezone_offset="+09:00"} 20` + "\n"
+	if !strings.Contains(body, want) {
+		t.Errorf("body = %q, want it to contain %q", body, want)
+	}
+	if strings.Count(body, "tosage_bedrock_total_token{") != 1 {
+		t.Errorf("expected a single series, got:\n%s", body)
+	}
+}
+
+func TestScrapeMetricsRepository_OnDemandCollection(t *testing.T) {
+	repo := newTestScrapeRepository(t)
+	collections := 0
+	repo.SetOnDemandCollection(func() {
+		collections++
+		_ = repo.SendTokenMetric(int64(collections), "", "tosage_cc_token")
+		_ = repo.SendTokenMetric(time.Now().Unix(), "", "tosage_last_collection_timestamp_seconds")
+	}, time.Minute)
+
+	// Nothing collected yet: the scrape waits for a collection and serves its values
+	_, body := scrape(t, repo, "")
+	if collections != 1 || !strings.Contains(body, `tosage_cc_token{host="test-host"} 1`+"\n") {
+		t.Fatalf("collections = %d, body:\n%s", collections, body)
+	}
+	if !strings.Contains(body, "# TYPE tosage_last_collection_timestamp_seconds gauge") {
+		t.Errorf("last collection gauge missing:\n%s", body)
+	}
+
+	// A fresh collection is served as is
+	scrape(t, repo, "")
+	if collections != 1 {
+		t.Errorf("collections = %d after a fresh scrape, want 1", collections)
+	}
+
+	// A stale collection is refreshed, but a failed refresh is not retried within the threshold
+	stale := time.Now().Add(-2 * time.Minute)
+	_ = repo.SendTokenMetric(stale.Unix(), "", "tosage_last_collection_timestamp_seconds")
+	repo.lastRefresh = stale
+	if done := repo.startRefresh(time.Now()); done == nil {
+		t.Fatal("startRefresh() = nil for a stale collection")
+	} else {
+		<-done
+	}
+	_ = repo.SendTokenMetric(stale.Unix(), "", "tosage_last_collection_timestamp_seconds")
+	if done := repo.startRefresh(time.Now()); done != nil {
+		t.Error("startRefresh() retried within the threshold")
+	}
+	if collections != 2 {
+		t.Errorf("collections = %d, want 2", collections)
+	}
+}
+
+func TestScrapeMetricsRepository_PrunesSeriesNotResent(t *testing.T) {
+	repo := newTestScrapeRepository(t)
+	sendSessions := func(sessions ...string) {
+		for _, session := range sessions {
+			_ = repo.SendTokenMetricWithLabels(100, "", "tosage_cc_session_token", map[string]string{"session": session}, nil)
+		}
+		_ = repo.SendTokenMetric(time.Now().Unix(), "", lastCollectionMetric)
+	}
+
+	sendSessions("a", "b")
+	_ = repo.SendTokenMetric(7, "", "tosage_cursor_token")
+	_ = repo.SendTokenMetricWithLabels(1, "", "tosage_collection_duration_seconds", map[string]string{"source": "cursor"}, nil)
+	_ = repo.SendTokenMetric(time.Now().Unix(), "", lastCollectionMetric)
+	_ = repo.SendTokenMetricWithLabels(2, "", "tosage_collection_duration_seconds", map[string]string{"source": "claude_code"}, nil)
+
+	// "a" falls out of the top N; Cursor is not collected in this cycle
+	sendSessions("b", "c")
+
+	_, body := scrape(t, repo, "")
+	if strings.Contains(body, `session="a"`) {
+		t.Errorf("series not re-sent was kept:\n%s", body)
+	}
+	for _, want := range []string{`session="b"`, `session="c"`, `tosage_cursor_token{host="test-host"} 7`, `source="cursor"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %s:\n%s", want, body)
+		}
+	}
+}
+
+func TestScrapeMetricsRepository_ExpireMetrics(t *testing.T) {
+	repo := newTestScrapeRepository(t)
+	_ = repo.SendTokenMetric(150, "", "tosage_cc_token")
+	_ = repo.SendTokenMetric(1, "", "tosage_cc_stale")
+
+	repo.ExpireMetrics("tosage_cc_token")
+
+	_, body := scrape(t, repo, "")
+	if strings.Contains(body, "tosage_cc_token") {
+		t.Errorf("expired metric still served:\n%s", body)
+	}
+	if !strings.Contains(body, `tosage_cc_stale{host="test-host"} 1`) {
+		t.Errorf("body missing tosage_cc_stale:\n%s", body)
+	}
+}
@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ca-srg/tosage/infrastructure/config"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// CcMirror keeps a local copy of the Claude Code data of another machine
+type CcMirror interface {
+	// Name identifies the source in warnings and the source_path label
+	Name() string
+	// LocalPath is the local projects directory holding the copy
+	LocalPath() string
+	// Sync brings the copy up to date; it may skip the check if the copy is recent
+	Sync() error
+}
+
+// SFTPCcMirror mirrors the Claude Code JSONL files of a remote machine over SFTP.
+// Session files only grow, so a file that got longer is extended with the new bytes
+// rather than downloaded again, and the remote files are checked at most once per sync interval.
+type SFTPCcMirror struct {
+	source   config.RemoteSourceConfig
+	localDir string
+
+	// connect opens an SFTP session; it is a field so tests can use an in-memory file system
+	connect func() (remoteFileSystem, error)
+
+	mu       sync.Mutex
+	lastSync time.Time
+}
+
+// NewSFTPCcMirror creates a mirror of a remote source in the user cache directory
+func NewSFTPCcMirror(source config.RemoteSourceConfig) (*SFTPCcMirror, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the cache directory: %w", err)
+	}
+	return newSFTPCcMirror(source, filepath.Join(cacheDir, "tosage", "remote", mirrorDirName(source.DisplayName()))), nil
+}
+
+// newSFTPCcMirror creates a mirror of a remote source in localDir
+func newSFTPCcMirror(source config.RemoteSourceConfig, localDir string) *SFTPCcMirror {
+	m := &SFTPCcMirror{source: source, localDir: localDir}
+	m.connect = m.dial
+	return m
+}
+
+// mirrorDirName turns a source name into a directory name
+func mirrorDirName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// Name returns the name of the remote source
+func (m *SFTPCcMirror) Name() string {
+	return m.source.DisplayName()
+}
+
+// LocalPath returns the directory holding the copy
+func (m *SFTPCcMirror) LocalPath() string {
+	return m.localDir
+}
+
+// Sync downloads new and grown session files and removes local copies of files deleted
+// on the remote machine. It does nothing until the sync interval has passed since the last sync.
+func (m *SFTPCcMirror) Sync() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.lastSync.IsZero() && time.Since(m.lastSync) < m.source.SyncInterval() {
+		return nil
+	}
+
+	remote, err := m.connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", m.source.Address(), err)
+	}
+	defer func() {
+		_ = remote.Close()
+	}()
+
+	files := make(map[string]remoteFileInfo)
+	if err := listRemoteJSONLFiles(remote, m.source.ClaudePath(), "", files); err != nil {
+		return err
+	}
+
+	var syncErr error
+	for rel, info := range files {
+		if err := m.syncFile(remote, rel, info); err != nil && syncErr == nil {
+			syncErr = err // Keep going; the failed file is retried at the next sync
+		}
+	}
+	m.removeDeleted(files)
+
+	m.lastSync = time.Now()
+	return syncErr
+}
+
+// listRemoteJSONLFiles collects the JSONL files under dir, keyed by their slash-separated path relative to the root
+func listRemoteJSONLFiles(remote remoteFileSystem, dir, rel string, files map[string]remoteFileInfo) error {
+	entries, err := remote.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		entryRel := path.Join(rel, entry.name)
+		if entry.isDir {
+			if err := listRemoteJSONLFiles(remote, path.Join(dir, entry.name), entryRel, files); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasSuffix(entry.name, ".jsonl") {
+			files[entryRel] = entry
+		}
+	}
+	return nil
+}
+
+// syncFile brings the local copy of a remote file up to date
+func (m *SFTPCcMirror) syncFile(remote remoteFileSystem, rel string, info remoteFileInfo) error {
+	local := filepath.Join(m.localDir, filepath.FromSlash(rel))
+	stat, err := os.Stat(local)
+	if err == nil && stat.Size() == info.size && stat.ModTime().Equal(info.modTime) {
+		return nil
+	}
+
+	// A grown file gets only its new bytes; a shrunk one was rewritten and is downloaded again
+	offset := int64(0)
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if err == nil && stat.Size() <= info.size {
+		offset = stat.Size()
+		flags = os.O_WRONLY | os.O_APPEND
+	}
+
+	if err := os.MkdirAll(filepath.Dir(local), 0700); err != nil {
+		return fmt.Errorf("failed to create mirror directory: %w", err)
+	}
+	file, err := os.OpenFile(local, flags, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open mirror file: %w", err)
+	}
+	_, copyErr := remote.CopyFrom(path.Join(m.source.ClaudePath(), rel), offset, file)
+	if err := file.Close(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	if copyErr != nil {
+		return fmt.Errorf("failed to download %s: %w", rel, copyErr)
+	}
+
+	// The remote modification time marks the copy as current and applies the ignore-before cutoff
+	if err := os.Chtimes(local, info.modTime, info.modTime); err != nil {
+		return fmt.Errorf("failed to update mirror file time: %w", err)
+	}
+	return nil
+}
+
+// removeDeleted removes local copies of files that no longer exist on the remote machine
+func (m *SFTPCcMirror) removeDeleted(files map[string]remoteFileInfo) {
+	_ = filepath.WalkDir(m.localDir, func(local string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(local, ".jsonl") {
+			return nil
+		}
+		rel, err := filepath.Rel(m.localDir, local)
+		if err != nil {
+			return nil
+		}
+		if _, ok := files[filepath.ToSlash(rel)]; !ok {
+			_ = os.Remove(local)
+		}
+		return nil
+	})
+}
+
+// dial opens an SFTP session on the remote machine, checking its host key against known_hosts
+func (m *SFTPCcMirror) dial() (remoteFileSystem, error) {
+	knownHostsPath := m.source.KnownHostsPath
+	if knownHostsPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the home directory: %w", err)
+		}
+		knownHostsPath = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeys, err := knownhosts.New(knownHostsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known hosts: %w", err)
+	}
+
+	auth, closeAgent, err := sshAuthMethods(m.source.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAgent()
+
+	// The deadline applies to the handshake and to every SFTP response after it
+	conn, err := net.DialTimeout("tcp", m.source.Address(), m.source.Timeout())
+	if err != nil {
+		return nil, err
+	}
+	conn = &deadlineConn{Conn: conn, timeout: m.source.Timeout()}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, m.source.Address(), &ssh.ClientConfig{
+		User:            m.source.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeys,
+		Timeout:         m.source.Timeout(),
+	})
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	client := ssh.NewClient(sshConn, chans, reqs)
+
+	session, err := sftp.NewClient(client)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to start the sftp subsystem: %w", err)
+	}
+	return &sftpFileSystem{client: session, closer: client}, nil
+}
+
+// sshAuthMethods returns the private key and SSH agent authentication methods.
+// The returned function closes the agent connection once authentication is done.
+func sshAuthMethods(keyPath string) ([]ssh.AuthMethod, func(), error) {
+	var methods []ssh.AuthMethod
+	closeAgent := func() {}
+
+	if keyPath != "" {
+		key, err := os.ReadFile(keyPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read SSH key: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse SSH key %s (keys with a passphrase must be loaded into the SSH agent): %w", keyPath, err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+			closeAgent = func() { _ = conn.Close() }
+		}
+	}
+
+	if len(methods) == 0 {
+		return nil, nil, fmt.Errorf("no SSH key configured and no SSH agent available")
+	}
+	return methods, closeAgent, nil
+}
@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ca-srg/tosage/infrastructure/config"
+	"github.com/pkg/sftp"
+)
+
+// memoryRemote is an in-memory remote file system recording the reads made
+type memoryRemote struct {
+	files map[string][]byte // slash-separated path → content
+	mtime map[string]time.Time
+	reads []string // "path@offset"
+}
+
+func newMemoryRemote() *memoryRemote {
+	return &memoryRemote{files: make(map[string][]byte), mtime: make(map[string]time.Time)}
+}
+
+func (m *memoryRemote) write(name, content string, at time.Time) {
+	m.files[name] = []byte(content)
+	m.mtime[name] = at
+}
+
+func (m *memoryRemote) ReadDir(dir string) ([]remoteFileInfo, error) {
+	seen := make(map[string]bool)
+	var entries []remoteFileInfo
+	for name, content := range m.files {
+		if !strings.HasPrefix(name, dir+"/") {
+			continue
+		}
+		first, _, nested := strings.Cut(strings.TrimPrefix(name, dir+"/"), "/")
+		if seen[first] {
+			continue
+		}
+		seen[first] = true
+		if nested {
+			entries = append(entries, remoteFileInfo{name: first, isDir: true})
+		} else {
+			entries = append(entries, remoteFileInfo{name: first, size: int64(len(content)), modTime: m.mtime[name]})
+		}
+	}
+	if len(entries) == 0 {
+		return nil, os.ErrNotExist
+	}
+	return entries, nil
+}
+
+func (m *memoryRemote) CopyFrom(file string, offset int64, w io.Writer) (int64, error) {
+	m.reads = append(m.reads, file+"@"+strconv.FormatInt(offset, 10))
+	content, ok := m.files[file]
+	if !ok {
+		return 0, os.ErrNotExist
+	}
+	n, err := w.Write(content[offset:])
+	return int64(n), err
+}
+
+func (m *memoryRemote) Close() error { return nil }
+
+func TestSFTPCcMirror_Sync(t *testing.T) {
+	remote := newMemoryRemote()
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	remote.write(".claude/projects/app/s1.jsonl", "line1\n", start)
+	remote.write(".claude/projects/api/s2.jsonl", "line1\nline2\n", start)
+	remote.write(".claude/projects/api/notes.txt", "ignored", start)
+
+	localDir := t.TempDir()
+	mirror := newSFTPCcMirror(config.RemoteSourceConfig{Host: "devbox", User: "me", SyncIntervalSec: 1}, localDir)
+	mirror.connect = func() (remoteFileSystem, error) { return remote, nil }
+	sync := func() {
+		t.Helper()
+		mirror.lastSync = time.Time{}
+		if err := mirror.Sync(); err != nil {
+			t.Fatalf("Sync() error = %v", err)
+		}
+	}
+	readLocal := func(rel string) string {
+		t.Helper()
+		content, err := os.ReadFile(filepath.Join(localDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return "<missing>"
+		}
+		return string(content)
+	}
+
+	sync()
+	if got := readLocal("app/s1.jsonl"); got != "line1\n" {
+		t.Errorf("app/s1.jsonl = %q", got)
+	}
+	if got := readLocal("api/notes.txt"); got != "<missing>" {
+		t.Errorf("non-JSONL file was mirrored: %q", got)
+	}
+	if info, err := os.Stat(filepath.Join(localDir, "api", "s2.jsonl")); err != nil || !info.ModTime().Equal(start) {
+		t.Errorf("mirror file time = %v (err %v), want the remote time %v", info.ModTime(), err, start)
+	}
+
+	// Unchanged files are not read again, a grown file only from its old end, and a
+	// deleted file is removed
+	remote.reads = nil
+	remote.write(".claude/projects/app/s1.jsonl", "line1\nline2\n", start.Add(time.Minute))
+	delete(remote.files, ".claude/projects/api/s2.jsonl")
+	remote.write(".claude/projects/api/s3.jsonl", "new\n", start.Add(time.Minute))
+	sync()
+
+	sort.Strings(remote.reads)
+	if want := []string{".claude/projects/api/s3.jsonl@0", ".claude/projects/app/s1.jsonl@6"}; strings.Join(remote.reads, ",") != strings.Join(want, ",") {
+		t.Errorf("reads = %v, want %v", remote.reads, want)
+	}
+	if got := readLocal("app/s1.jsonl"); got != "line1\nline2\n" {
+		t.Errorf("grown file = %q", got)
+	}
+	if got := readLocal("api/s2.jsonl"); got != "<missing>" {
+		t.Errorf("deleted file was kept: %q", got)
+	}
+
+	// Within the sync interval the remote machine is not contacted
+	mirror.connect = func() (remoteFileSystem, error) { return nil, errors.New("unexpected connection") }
+	if err := mirror.Sync(); err != nil {
+		t.Errorf("Sync() within the interval error = %v", err)
+	}
+}
+
+func TestJSONLCcRepository_Mirror(t *testing.T) {
+	remote := newMemoryRemote()
+	line := `{"timestamp":"2025-03-01T10:00:00Z","sessionId":"s1","version":"1.0.0","requestId":"req-remote","message":{"id":"msg-remote","model":"claude-sonnet-4-20250514","usage":{"input_tokens":10,"output_tokens":5}}}` + "\n"
+	remote.write("projects/-work-app/s1.jsonl", line, time.Now())
+
+	mirror := newSFTPCcMirror(config.RemoteSourceConfig{Name: "devbox", Host: "devbox", User: "me", RemotePath: "projects"}, t.TempDir())
+	mirror.connect = func() (remoteFileSystem, error) { return remote, nil }
+
+	repo := NewJSONLCcRepository(t.TempDir())
+	repo.AddMirror(mirror)
+	entries, err := repo.FindAll()
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].TotalTokens() != 15 {
+		t.Fatalf("entries = %v, want the remote entry", entries)
+	}
+	if got := entries[0].SourcePath(); got != "devbox" {
+		t.Errorf("SourcePath() = %q, want the remote source name", got)
+	}
+}
+
+func TestSFTPFileSystem(t *testing.T) {
+	root := t.TempDir()
+	content := bytes.Repeat([]byte("0123456789"), 10000) // several read requests
+	if err := os.MkdirAll(filepath.Join(root, "projects", "app"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "projects", "app", "s1.jsonl"), content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	remote := startSFTPServer(t, root)
+
+	entries, err := remote.ReadDir("projects/app")
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].name != "s1.jsonl" || entries[0].size != int64(len(content)) || entries[0].isDir {
+		t.Errorf("ReadDir() = %+v", entries)
+	}
+	if entries, err := remote.ReadDir("projects"); err != nil || len(entries) != 1 || !entries[0].isDir {
+		t.Errorf("ReadDir(projects) = %+v, %v, want the app directory", entries, err)
+	}
+
+	var out bytes.Buffer
+	n, err := remote.CopyFrom("projects/app/s1.jsonl", 5, &out)
+	if err != nil {
+		t.Fatalf("CopyFrom() error = %v", err)
+	}
+	if n != int64(len(content)-5) || !bytes.Equal(out.Bytes(), content[5:]) {
+		t.Errorf("CopyFrom() copied %d bytes, want the %d after the offset", n, len(content)-5)
+	}
+
+	if _, err := remote.CopyFrom("projects/missing.jsonl", 0, io.Discard); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("CopyFrom(missing) error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestDeadlineConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer func() {
+		_ = server.Close()
+	}()
+	conn := &deadlineConn{Conn: client, timeout: 50 * time.Millisecond}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	// A server that never answers fails the read instead of blocking it
+	done := make(chan error, 1)
+	go func() {
+		_, err := conn.Read(make([]byte, 1))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Errorf("Read() error = %v, want a timeout", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Read() blocked past the deadline")
+	}
+
+	// The deadline is renewed for every call
+	go func() {
+		_, _ = server.Write([]byte("x"))
+	}()
+	if _, err := conn.Read(make([]byte, 1)); err != nil {
+		t.Errorf("Read() after a timeout error = %v", err)
+	}
+}
+
+// startSFTPServer serves root over SFTP through in-memory pipes
+func startSFTPServer(t *testing.T, root string) remoteFileSystem {
+	t.Helper()
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	server, err := sftp.NewServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{serverIn, serverOut}, sftp.WithServerWorkingDirectory(root), sftp.ReadOnly())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	go func() {
+		_ = server.Serve()
+	}()
+
+	client, err := sftp.NewClientPipe(clientIn, clientOut)
+	if err != nil {
+		t.Fatalf("NewClientPipe() error = %v", err)
+	}
+	remote := &sftpFileSystem{client: client}
+	t.Cleanup(func() {
+		_ = server.Close() // ends the client's reads
+		_ = remote.Close()
+	})
+	return remote
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// remoteFileInfo describes a file or directory on the remote machine
+type remoteFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+// remoteFileSystem reads directories and files on the remote machine
+type remoteFileSystem interface {
+	// ReadDir lists the entries of a directory
+	ReadDir(dir string) ([]remoteFileInfo, error)
+	// CopyFrom copies a file from offset to w and returns the bytes copied
+	CopyFrom(file string, offset int64, w io.Writer) (int64, error)
+	// Close ends the session
+	Close() error
+}
+
+// sftpFileSystem reads the remote files through an SFTP session
+type sftpFileSystem struct {
+	client *sftp.Client
+	// closer ends the connection the session runs on
+	closer io.Closer
+}
+
+// ReadDir lists the entries of a directory
+func (s *sftpFileSystem) ReadDir(dir string) ([]remoteFileInfo, error) {
+	infos, err := s.client.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading directory %s: %w", dir, err)
+	}
+	entries := make([]remoteFileInfo, 0, len(infos))
+	for _, info := range infos {
+		entries = append(entries, remoteFileInfo{
+			name:    info.Name(),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+			isDir:   info.IsDir(),
+		})
+	}
+	return entries, nil
+}
+
+// CopyFrom copies a file from offset to w
+func (s *sftpFileSystem) CopyFrom(file string, offset int64, w io.Writer) (int64, error) {
+	f, err := s.client.Open(file)
+	if err != nil {
+		return 0, fmt.Errorf("opening %s: %w", file, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("seeking %s: %w", file, err)
+	}
+	copied, err := io.Copy(w, f)
+	if err != nil {
+		return copied, fmt.Errorf("reading %s: %w", file, err)
+	}
+	return copied, nil
+}
+
+// Close ends the session and the connection
+func (s *sftpFileSystem) Close() error {
+	err := s.client.Close()
+	if s.closer != nil {
+		if closeErr := s.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// deadlineConn fails a read or write that makes no progress within the timeout,
+// so a server that stops answering ends the connection instead of blocking the sync.
+type deadlineConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *deadlineConn) Read(b []byte) (int, error) {
+	if err := c.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *deadlineConn) Write(b []byte) (int, error) {
+	if err := c.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(b)
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+// TransformMetricsRepository wraps another MetricsRepository and scales configured
+// metrics (value*multiplier + offset) just before they are sent, optionally under a new name.
+// Metrics without a transform are forwarded unchanged.
+type TransformMetricsRepository struct {
+	delegate   repository.MetricsRepository
+	transforms map[string]*config.MetricTransformConfig
+	hostLabel  string
+}
+
+// NewTransformMetricsRepository creates a transforming repository in front of delegate
+func NewTransformMetricsRepository(delegate repository.MetricsRepository, cfg *config.PrometheusConfig) (*TransformMetricsRepository, error) {
+	if delegate == nil {
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("delegate metrics repository is nil"))
+	}
+	if cfg == nil {
+		return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("prometheus config is nil"))
+	}
+	// A renamed series merging into one tosage already sends would mix two meanings
+	for name, transform := range cfg.Transforms {
+		if transform == nil || transform.RenameTo == "" || transform.RenameTo == name {
+			continue
+		}
+		if _, ok := scrapeMetricHelp[transform.RenameTo]; ok {
+			return nil, repository.NewMetricsRepositoryError("initialize", fmt.Errorf("metric transform for %s renames it to %s, which tosage already sends", name, transform.RenameTo))
+		}
+	}
+
+	return &TransformMetricsRepository{
+		delegate:   delegate,
+		transforms: cfg.Transforms,
+		hostLabel:  resolveHostLabel(cfg.HostLabel),
+	}, nil
+}
+
+// SendTokenMetric transforms the value if configured and forwards it to the delegate
+func (r *TransformMetricsRepository) SendTokenMetric(totalTokens int64, hostLabel string, metricName string) error {
+	transform, ok := r.transforms[metricName]
+	if !ok || transform == nil {
+		return r.delegate.SendTokenMetric(totalTokens, hostLabel, metricName)
+	}
+	value, name, hostLabel := r.apply(transform, float64(totalTokens), hostLabel, metricName)
+	return sendMetricValue(r.delegate, value, hostLabel, name, nil, nil)
+}
+
+// SendTokenMetricWithTimezone transforms the value if configured and forwards it to the delegate
+func (r *TransformMetricsRepository) SendTokenMetricWithTimezone(totalTokens int64, hostLabel string, metricName string, timezoneInfo repository.TimezoneInfo) error {
+	transform, ok := r.transforms[metricName]
+	if !ok || transform == nil {
+		return r.delegate.SendTokenMetricWithTimezone(totalTokens, hostLabel, metricName, timezoneInfo)
+	}
+	value, name, hostLabel := r.apply(transform, float64(totalTokens), hostLabel, metricName)
+	return sendMetricValue(r.delegate, value, hostLabel, name, nil, &timezoneInfo)
+}
+
+// SendTokenMetricWithLabels transforms the value if configured and forwards it to the delegate
+func (r *TransformMetricsRepository) SendTokenMetricWithLabels(totalTokens int64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	transform, ok := r.transforms[metricName]
+	if !ok || transform == nil {
+		return r.delegate.SendTokenMetricWithLabels(totalTokens, hostLabel, metricName, labels, timezoneInfo)
+	}
+	value, name, hostLabel := r.apply(transform, float64(totalTokens), hostLabel, metricName)
+	return sendMetricValue(r.delegate, value, hostLabel, name, labels, timezoneInfo)
+}
+
+// SendMetricValue transforms the value if configured and forwards it to the delegate unrounded
+func (r *TransformMetricsRepository) SendMetricValue(value float64, hostLabel string, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	transform, ok := r.transforms[metricName]
+	if !ok || transform == nil {
+		return sendMetricValue(r.delegate, value, hostLabel, metricName, labels, timezoneInfo)
+	}
+	value, name, hostLabel := r.apply(transform, value, hostLabel, metricName)
+	return sendMetricValue(r.delegate, value, hostLabel, name, labels, timezoneInfo)
+}
+
+// CheckConnection checks the delegate's backend, if it supports checks
+func (r *TransformMetricsRepository) CheckConnection(ctx context.Context) error {
+	return checkMetricsConnection(ctx, r.delegate)
+}
+
+// ExpireMetrics expires the metrics in the delegate under the names they are sent as
+func (r *TransformMetricsRepository) ExpireMetrics(metricNames ...string) {
+	names := make([]string, 0, len(metricNames))
+	for _, name := range metricNames {
+		if transform, ok := r.transforms[name]; ok && transform != nil && transform.RenameTo != "" {
+			name = transform.RenameTo
+		}
+		names = append(names, name)
+	}
+	expireMetrics(r.delegate, names...)
+}
+
+// Close closes the delegate
+func (r *TransformMetricsRepository) Close() error {
+	return r.delegate.Close()
+}
+
+// apply computes the transformed value and the name to send it as.
+// The default host label of Claude Code and Cursor metrics is resolved here,
+// because the delegate no longer recognizes a renamed metric.
+func (r *TransformMetricsRepository) apply(transform *config.MetricTransformConfig, value float64, hostLabel, metricName string) (float64, string, string) {
+	value = value*transform.Multiplier + transform.Offset
+
+	if hostLabel == "" && usesDefaultHostLabel(metricName) {
+		hostLabel = r.hostLabel
+	}
+
+	name := metricName
+	if transform.RenameTo != "" {
+		name = transform.RenameTo
+	}
+	return value, name, hostLabel
+}
+
+// checkMetricsConnection checks repo's backend; repositories without a check are assumed reachable
+func checkMetricsConnection(ctx context.Context, repo repository.MetricsRepository) error {
+	if checker, ok := repo.(repository.ConnectionChecker); ok {
+		return checker.CheckConnection(ctx)
+	}
+	return nil
+}
+
+// sendMetricValue sends a fractional value, rounding it when the repository only accepts token counts
+func sendMetricValue(repo repository.MetricsRepository, value float64, hostLabel, metricName string, labels map[string]string, timezoneInfo *repository.TimezoneInfo) error {
+	if sender, ok := repo.(repository.MetricValueSender); ok {
+		return sender.SendMetricValue(value, hostLabel, metricName, labels, timezoneInfo)
+	}
+	return repo.SendTokenMetricWithLabels(int64(math.Round(value)), hostLabel, metricName, labels, timezoneInfo)
+}
+
+// expireMetrics expires the last sent values of the metrics, if repo keeps serving them
+func expireMetrics(repo repository.MetricsRepository, metricNames ...string) {
+	if expirer, ok := repo.(repository.MetricsExpirer); ok {
+		expirer.ExpireMetrics(metricNames...)
+	}
+}
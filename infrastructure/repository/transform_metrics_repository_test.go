@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+func TestTransformMetricsRepository(t *testing.T) {
+	scrapeRepo := newTestScrapeRepository(t)
+	repo, err := NewTransformMetricsRepository(scrapeRepo, &config.PrometheusConfig{
+		HostLabel: "test-host",
+		Transforms: map[string]*config.MetricTransformConfig{
+			"tosage_cc_token": {Multiplier: 0.001, RenameTo: "tosage_cc_ktoken"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewTransformMetricsRepository() error = %v", err)
+	}
+
+	if err := repo.SendTokenMetric(12345, "", "tosage_cc_token"); err != nil {
+		t.Fatalf("SendTokenMetric() error = %v", err)
+	}
+	if err := repo.SendTokenMetric(500, "", "tosage_cursor_token"); err != nil {
+		t.Fatalf("SendTokenMetric() error = %v", err)
+	}
+
+	_, body := scrape(t, scrapeRepo, "")
+
+	if !strings.Contains(body, `tosage_cc_ktoken{host="test-host"} 12.345`) {
+		t.Errorf("transformed metric not found in:\n%s", body)
+	}
+	if strings.Contains(body, "tosage_cc_token{") {
+		t.Errorf("original metric should not be sent when renamed:\n%s", body)
+	}
+	if !strings.Contains(body, `tosage_cursor_token{host="test-host"} 500`) {
+		t.Errorf("untransformed metric not found in:\n%s", body)
+	}
+
+	// Fractional values are forwarded unrounded, transformed or not
+	if err := repo.SendMetricValue(0.25, "", "tosage_cc_token", nil, nil); err != nil {
+		t.Fatalf("SendMetricValue() error = %v", err)
+	}
+	if err := repo.SendMetricValue(0.004, "", "tosage_collection_duration_seconds", map[string]string{"source": "cursor"}, nil); err != nil {
+		t.Fatalf("SendMetricValue() error = %v", err)
+	}
+	_, body = scrape(t, scrapeRepo, "")
+	if !strings.Contains(body, `tosage_cc_ktoken{host="test-host"} 0.00025`) {
+		t.Errorf("transformed fractional value not found in:\n%s", body)
+	}
+	if !strings.Contains(body, `tosage_collection_duration_seconds{source="cursor"} 0.004`) {
+		t.Errorf("untransformed fractional value not found in:\n%s", body)
+	}
+
+	// Expiring a renamed metric expires it under its new name
+	repo.ExpireMetrics("tosage_cc_token")
+	_, body = scrape(t, scrapeRepo, "")
+	if strings.Contains(body, "tosage_cc_ktoken") {
+		t.Errorf("renamed metric not expired:\n%s", body)
+	}
+}
+
+func TestNewTransformMetricsRepository_RenameToTosageMetric(t *testing.T) {
+	_, err := NewTransformMetricsRepository(newTestScrapeRepository(t), &config.PrometheusConfig{
+		Transforms: map[string]*config.MetricTransformConfig{
+			"tosage_cc_token": {Multiplier: 1, RenameTo: "tosage_cursor_token"},
+		},
+	})
+	if err == nil {
+		t.Error("NewTransformMetricsRepository() error = nil, want an error for a rename to tosage_cursor_token")
+	}
+}
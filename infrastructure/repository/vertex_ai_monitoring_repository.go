@@ -10,12 +10,15 @@ import (
 	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/ca-srg/tosage/domain/entity"
 	"github.com/ca-srg/tosage/domain/repository"
 	"github.com/ca-srg/tosage/infrastructure/auth"
+	"github.com/ca-srg/tosage/infrastructure/httpclient"
 )
 
 // VertexAIMonitoringRepository implements VertexAIRepository using Google Cloud Monitoring
@@ -34,6 +37,9 @@ func NewVertexAIMonitoringRepository(projectID string, authenticator auth.Vertex
 		// Use the token source from the authenticator
 		opts = append(opts, option.WithTokenSource(authenticator.GetTokenSource()))
 	}
+	for _, dialOpt := range httpclient.GRPCDialOptions() {
+		opts = append(opts, option.WithGRPCDialOption(dialOpt))
+	}
 
 	client, err := monitoring.NewMetricClient(ctx, opts...)
 	if err != nil {
@@ -61,12 +67,13 @@ func (r *VertexAIMonitoringRepository) GetUsageMetrics(projectID string, start,
 	// Get input and output tokens separately
 	inputTokens, outputTokens, err := r.getTokenCountByType(ctx, projectID, metricType, start, end)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve token count metric: %w", err)
+		return nil, classifyMonitoringError(projectID, metricType, err)
 	}
 	
+	// The API answered but recorded no tokens: an idle period, not a failure
 	totalTokens := inputTokens + outputTokens
 	if totalTokens == 0 {
-		return nil, fmt.Errorf("no token usage data found for metric %s", metricType)
+		return entity.NewVertexAIUsage(0, 0, 0, []entity.VertexAIModelMetric{}, projectID, "")
 	}
 	
 
@@ -121,9 +128,7 @@ func (r *VertexAIMonitoringRepository) GetCurrentMonthUsage(projectID string) (*
 }
 
 // CheckConnection verifies Google Cloud credentials and Cloud Monitoring access
-func (r *VertexAIMonitoringRepository) CheckConnection() error {
-	ctx := context.Background()
-
+func (r *VertexAIMonitoringRepository) CheckConnection(ctx context.Context) error {
 	// Test connection by listing metric descriptors
 	projectName := fmt.Sprintf("projects/%s", r.projectID)
 	req := &monitoringpb.ListMetricDescriptorsRequest{
@@ -212,6 +217,19 @@ func (r *VertexAIMonitoringRepository) debugListMetrics(ctx context.Context, pro
 	}
 }
 
+// classifyMonitoringError describes why a Cloud Monitoring query failed, separating
+// missing permissions and unavailable metrics from other errors
+func classifyMonitoringError(projectID, metricType string, err error) error {
+	switch status.Code(err) {
+	case codes.PermissionDenied, codes.Unauthenticated:
+		return fmt.Errorf("access denied to Cloud Monitoring metric %s in project %s (the monitoring.timeSeries.list permission is required): %w", metricType, projectID, err)
+	case codes.NotFound:
+		return fmt.Errorf("metric %s is unavailable in Cloud Monitoring for project %s: %w", metricType, projectID, err)
+	default:
+		return fmt.Errorf("failed to retrieve token count metric: %w", err)
+	}
+}
+
 // getTokenCountByType retrieves input and output token counts separately
 func (r *VertexAIMonitoringRepository) getTokenCountByType(
 	ctx context.Context,
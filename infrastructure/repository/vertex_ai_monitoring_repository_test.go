@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassifyMonitoringError(t *testing.T) {
+	const metricType = "aiplatform.googleapis.com/publisher/online_serving/token_count"
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"permission denied", status.Error(codes.PermissionDenied, "denied"), "access denied"},
+		{"unauthenticated", status.Error(codes.Unauthenticated, "no credentials"), "access denied"},
+		{"metric not found", status.Error(codes.NotFound, "not found"), "is unavailable"},
+		{"other error", errors.New("connection reset"), "failed to retrieve token count metric"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyMonitoringError("my-project", metricType, tt.err)
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("classifyMonitoringError() = %v, want it to contain %q", err, tt.want)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("classifyMonitoringError() does not wrap %v", tt.err)
+			}
+		})
+	}
+}
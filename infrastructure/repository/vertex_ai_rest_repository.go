@@ -15,6 +15,7 @@ import (
 	"github.com/ca-srg/tosage/domain/entity"
 	"github.com/ca-srg/tosage/domain/repository"
 	"github.com/ca-srg/tosage/infrastructure/auth"
+	"github.com/ca-srg/tosage/infrastructure/httpclient"
 )
 
 // VertexAIRESTRepository implements VertexAIRepository using REST API with retry logic
@@ -52,7 +53,7 @@ func NewVertexAIRESTRepository(projectID string, authenticator auth.VertexAIAuth
 	return &VertexAIRESTRepository{
 		projectID:      projectID,
 		authenticator:  authenticator,
-		client:         &http.Client{Timeout: 30 * time.Second},
+		client:         httpclient.NewClient(30 * time.Second),
 		maxRetries:     10,
 		retryDelay:     2 * time.Second,
 		serviceAccount: serviceAccount,
@@ -347,9 +348,7 @@ func (r *VertexAIRESTRepository) GetCurrentMonthUsage(projectID string) (*entity
 }
 
 // CheckConnection verifies Vertex AI API connectivity
-func (r *VertexAIRESTRepository) CheckConnection() error {
-	ctx := context.Background()
-
+func (r *VertexAIRESTRepository) CheckConnection(ctx context.Context) error {
 	// Try to connect to at least one model
 	locations := []string{"us-central1", "asia-northeast1"}
 
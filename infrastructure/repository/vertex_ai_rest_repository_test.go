@@ -143,7 +143,7 @@ func TestVertexAIRESTRepository_Integration(t *testing.T) {
 	repo.SetRetryDelay(1 * time.Second)
 
 	t.Run("CheckConnection", func(t *testing.T) {
-		err := repo.CheckConnection()
+		err := repo.CheckConnection(context.Background())
 		// We expect this to fail if models are not available
 		// but the function should handle it gracefully
 		if err != nil {
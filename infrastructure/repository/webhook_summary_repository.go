@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ca-srg/tosage/domain/entity"
+	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/infrastructure/httpclient"
+)
+
+// WebhookSummaryRepository posts daily summaries as JSON to a webhook URL
+type WebhookSummaryRepository struct {
+	url         string
+	client      *http.Client
+	retryConfig *RetryConfig
+}
+
+// NewWebhookSummaryRepository creates a webhook summary repository
+func NewWebhookSummaryRepository(url string, timeout time.Duration, maxRetries int) (*WebhookSummaryRepository, error) {
+	if url == "" {
+		return nil, fmt.Errorf("summary webhook URL is required")
+	}
+
+	retryConfig := DefaultRetryConfig()
+	retryConfig.MaxRetries = maxRetries
+
+	return &WebhookSummaryRepository{
+		url:         url,
+		client:      httpclient.NewClient(timeout),
+		retryConfig: retryConfig,
+	}, nil
+}
+
+// webhookStatusError is returned when the webhook responds with a non-2xx status
+type webhookStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *webhookStatusError) Error() string {
+	return fmt.Sprintf("webhook returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// Send posts the summary, retrying network errors, 429 and 5xx responses with exponential backoff
+func (r *WebhookSummaryRepository) Send(ctx context.Context, summary *entity.DailySummary) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to marshal summary: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= r.retryConfig.MaxRetries; attempt++ {
+		if attempt > 0 {
+			delay := r.retryConfig.BaseDelay * time.Duration(1<<uint(attempt-1))
+			if delay > r.retryConfig.MaxDelay {
+				delay = r.retryConfig.MaxDelay
+			}
+
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return fmt.Errorf("context cancelled during retry: %w", ctx.Err())
+			}
+		}
+
+		lastErr = r.sendOnce(ctx, body)
+		if lastErr == nil {
+			return nil
+		}
+		if statusErr, ok := lastErr.(*webhookStatusError); ok &&
+			statusErr.StatusCode != http.StatusTooManyRequests && statusErr.StatusCode < 500 {
+			return lastErr
+		}
+	}
+
+	return fmt.Errorf("failed after %d retries: %w", r.retryConfig.MaxRetries, lastErr)
+}
+
+// sendOnce posts the encoded summary once
+func (r *WebhookSummaryRepository) sendOnce(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post summary: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &webhookStatusError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	return nil
+}
+
+// Ensure WebhookSummaryRepository implements SummaryRepository
+var _ repository.SummaryRepository = (*WebhookSummaryRepository)(nil)
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ca-srg/tosage/domain/entity"
+)
+
+func TestWebhookSummaryRepository_Send(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantErr   bool
+		wantCalls int32
+	}{
+		{name: "success", statuses: []int{http.StatusOK}, wantCalls: 1},
+		{name: "retries server errors", statuses: []int{http.StatusBadGateway, http.StatusNoContent}, wantCalls: 2},
+		{name: "does not retry client errors", statuses: []int{http.StatusBadRequest}, wantErr: true, wantCalls: 1},
+		{name: "gives up after max retries", statuses: []int{500, 500, 500}, wantErr: true, wantCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			var received entity.DailySummary
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				if r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("Content-Type = %q, want application/json", r.Header.Get("Content-Type"))
+				}
+				_ = json.NewDecoder(r.Body).Decode(&received)
+				w.WriteHeader(tt.statuses[int(n)-1])
+			}))
+			defer server.Close()
+
+			repo, err := NewWebhookSummaryRepository(server.URL, 5*time.Second, 2)
+			if err != nil {
+				t.Fatalf("NewWebhookSummaryRepository() error = %v", err)
+			}
+			repo.retryConfig.BaseDelay = time.Millisecond
+
+			summary := entity.NewDailySummary(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), "host", time.Now())
+			summary.AddSource("claude_code", 1234)
+
+			err = repo.Send(context.Background(), summary)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&calls); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+			if received.Date != "2025-01-15" || received.TotalTokens != 1234 {
+				t.Errorf("received = %+v, want date 2025-01-15 and 1234 tokens", received)
+			}
+		})
+	}
+}
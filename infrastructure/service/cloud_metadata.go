@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ca-srg/tosage/infrastructure/httpclient"
+)
+
+// Labels added from the cloud metadata service
+const (
+	CloudLabelInstanceID   = "instance_id"
+	CloudLabelZone         = "zone"
+	CloudLabelInstanceType = "instance_type"
+)
+
+// CloudMetadataTimeout bounds the whole metadata lookup, so machines outside a cloud,
+// where the metadata address never answers, start without a noticeable delay
+const CloudMetadataTimeout = time.Second
+
+// defaultMetadataURL is the link-local address both EC2 and GCE serve their metadata on
+const defaultMetadataURL = "http://169.254.169.254"
+
+// maxMetadataBytes caps a metadata value read from the service
+const maxMetadataBytes = 1024
+
+// CloudMetadataDetector reads the instance ID, zone and instance type of the machine
+// from the EC2 (IMDSv2) or GCE metadata service
+type CloudMetadataDetector struct {
+	client *http.Client
+	awsURL string
+	gcpURL string
+}
+
+// NewCloudMetadataDetector creates a detector for the standard metadata address
+func NewCloudMetadataDetector() *CloudMetadataDetector {
+	// The metadata service is link-local; a proxy must never see these requests
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	return &CloudMetadataDetector{
+		client: &http.Client{Transport: httpclient.NewTransport(transport)},
+		awsURL: defaultMetadataURL,
+		gcpURL: defaultMetadataURL,
+	}
+}
+
+// Detect returns the cloud labels of the machine, or nil when it is not on EC2 or GCE
+// or the metadata service does not answer before ctx is done. EC2 and GCE are queried
+// concurrently and the first complete answer wins.
+func (d *CloudMetadataDetector) Detect(ctx context.Context) map[string]string {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan map[string]string, 2)
+	for _, detect := range []func(context.Context) (map[string]string, error){d.detectAWS, d.detectGCP} {
+		go func(detect func(context.Context) (map[string]string, error)) {
+			labels, err := detect(ctx)
+			if err != nil {
+				labels = nil
+			}
+			results <- labels
+		}(detect)
+	}
+
+	for i := 0; i < 2; i++ {
+		if labels := <-results; labels != nil {
+			return labels
+		}
+	}
+	return nil
+}
+
+// detectAWS reads the labels from the EC2 instance metadata service using an IMDSv2 session token
+func (d *CloudMetadataDetector) detectAWS(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, d.awsURL+"/latest/api/token", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	token, err := d.read(req)
+	if err != nil {
+		return nil, fmt.Errorf("get IMDSv2 token: %w", err)
+	}
+
+	labels := make(map[string]string, 3)
+	for label, path := range map[string]string{
+		CloudLabelInstanceID:   "instance-id",
+		CloudLabelZone:         "placement/availability-zone",
+		CloudLabelInstanceType: "instance-type",
+	} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.awsURL+"/latest/meta-data/"+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", token)
+		value, err := d.read(req)
+		if err != nil {
+			return nil, fmt.Errorf("get EC2 %s: %w", path, err)
+		}
+		labels[label] = value
+	}
+	return labels, nil
+}
+
+// detectGCP reads the labels from the GCE metadata server. Zone and machine type are
+// reported as resource paths, e.g. projects/123/zones/us-central1-a, of which the last
+// segment is kept.
+func (d *CloudMetadataDetector) detectGCP(ctx context.Context) (map[string]string, error) {
+	labels := make(map[string]string, 3)
+	for label, path := range map[string]string{
+		CloudLabelInstanceID:   "id",
+		CloudLabelZone:         "zone",
+		CloudLabelInstanceType: "machine-type",
+	} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.gcpURL+"/computeMetadata/v1/instance/"+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+		value, err := d.read(req)
+		if err != nil {
+			return nil, fmt.Errorf("get GCE %s: %w", path, err)
+		}
+		labels[label] = value[strings.LastIndex(value, "/")+1:]
+	}
+	return labels, nil
+}
+
+// read performs a metadata request and returns its non-empty, trimmed body. GCE answers
+// carry a Metadata-Flavor header, which tells them apart from other servers on the address.
+func (d *CloudMetadataDetector) read(req *http.Request) (string, error) {
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if req.Header.Get("Metadata-Flavor") != "" && resp.Header.Get("Metadata-Flavor") != "Google" {
+		return "", fmt.Errorf("response is not from the GCE metadata server")
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMetadataBytes))
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSpace(string(body))
+	if value == "" {
+		return "", fmt.Errorf("empty response")
+	}
+	return value, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestMetadataDetector(awsURL, gcpURL string) *CloudMetadataDetector {
+	return &CloudMetadataDetector{client: http.DefaultClient, awsURL: awsURL, gcpURL: gcpURL}
+}
+
+func TestCloudMetadataDetector_AWS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest/api/token" {
+			if r.Method != http.MethodPut || r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = fmt.Fprint(w, "session-token")
+			return
+		}
+		if r.Header.Get("X-aws-ec2-metadata-token") != "session-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		values := map[string]string{
+			"/latest/meta-data/instance-id":                 "i-0123456789abcdef0",
+			"/latest/meta-data/placement/availability-zone": "ap-northeast-1a",
+			"/latest/meta-data/instance-type":               "t3.medium",
+		}
+		value, ok := values[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprint(w, value)
+	}))
+	defer server.Close()
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	got := newTestMetadataDetector(server.URL, notFound.URL).Detect(context.Background())
+	want := map[string]string{
+		CloudLabelInstanceID:   "i-0123456789abcdef0",
+		CloudLabelZone:         "ap-northeast-1a",
+		CloudLabelInstanceType: "t3.medium",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Detect() = %v, want %v", got, want)
+	}
+}
+
+func TestCloudMetadataDetector_GCP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		values := map[string]string{
+			"/computeMetadata/v1/instance/id":           "4520031799277581759",
+			"/computeMetadata/v1/instance/zone":         "projects/123456789/zones/asia-northeast1-b",
+			"/computeMetadata/v1/instance/machine-type": "projects/123456789/machineTypes/e2-medium",
+		}
+		value, ok := values[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Metadata-Flavor", "Google")
+		_, _ = fmt.Fprint(w, value)
+	}))
+	defer server.Close()
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	got := newTestMetadataDetector(notFound.URL, server.URL).Detect(context.Background())
+	want := map[string]string{
+		CloudLabelInstanceID:   "4520031799277581759",
+		CloudLabelZone:         "asia-northeast1-b",
+		CloudLabelInstanceType: "e2-medium",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Detect() = %v, want %v", got, want)
+	}
+}
+
+func TestCloudMetadataDetector_NotOnCloud(t *testing.T) {
+	// Another server on the address that answers without the GCE flavor header is ignored
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "hello")
+	}))
+	defer plain.Close()
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	if got := newTestMetadataDetector(notFound.URL, plain.URL).Detect(context.Background()); got != nil {
+		t.Errorf("Detect() = %v, want nil", got)
+	}
+}
+
+func TestCloudMetadataDetector_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer hanging.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if got := newTestMetadataDetector(hanging.URL, hanging.URL).Detect(ctx); got != nil {
+		t.Errorf("Detect() = %v, want nil", got)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Detect() took %v, want it bounded by the context", elapsed)
+	}
+}
@@ -0,0 +1,111 @@
+//go:build unix
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ErrAlreadyRunning is returned when another process holds the PID file lock
+var ErrAlreadyRunning = errors.New("another tosage instance is already running")
+
+// PIDLock is an exclusive lock on the daemon PID file. The lock is held by the open file,
+// so the kernel releases it when the process exits, even after a crash.
+type PIDLock struct {
+	file *os.File
+	path string
+}
+
+// errPIDFileReplaced is returned by lockPIDFile when the locked file is no longer at the path
+var errPIDFileReplaced = errors.New("PID file was replaced while locking it")
+
+// maxPIDLockAttempts bounds how often AcquirePIDLock reopens a PID file replaced while locking it
+const maxPIDLockAttempts = 10
+
+// AcquirePIDLock locks path and writes the current PID to it. If another process holds
+// the lock, it returns an error wrapping ErrAlreadyRunning that names that process.
+func AcquirePIDLock(path string) (*PIDLock, error) {
+	for attempt := 1; ; attempt++ {
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open PID file: %w", err)
+		}
+
+		lock, err := lockPIDFile(file, path)
+		if errors.Is(err, errPIDFileReplaced) && attempt < maxPIDLockAttempts {
+			continue
+		}
+		return lock, err
+	}
+}
+
+// lockPIDFile locks the opened PID file and writes the current PID to it. Release removes the
+// file before unlocking it, so the file may have been removed, and another instance may have
+// created and locked a new one, since it was opened. Only the lock on the file at path counts,
+// so errPIDFileReplaced is returned otherwise. The file is closed unless it is locked.
+func lockPIDFile(file *os.File, path string) (*PIDLock, error) {
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		content := make([]byte, 32)
+		n, _ := file.Read(content)
+		_ = file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			pid := strings.TrimSpace(string(content[:n]))
+			return nil, fmt.Errorf("%w (PID %s holds %s)", ErrAlreadyRunning, pid, path)
+		}
+		return nil, fmt.Errorf("failed to lock PID file: %w", err)
+	}
+
+	locked, err := file.Stat()
+	if err == nil {
+		var current os.FileInfo
+		current, err = os.Stat(path)
+		if err == nil && !os.SameFile(locked, current) {
+			err = errPIDFileReplaced
+		}
+	}
+	if err != nil {
+		_ = file.Close()
+		if os.IsNotExist(err) {
+			err = errPIDFileReplaced
+		}
+		if errors.Is(err, errPIDFileReplaced) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to check PID file: %w", err)
+	}
+
+	if err := file.Truncate(0); err == nil {
+		_, err = file.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to write PID file: %w", err)
+	}
+
+	return &PIDLock{file: file, path: path}, nil
+}
+
+// Release removes the PID file and releases the lock
+func (l *PIDLock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+
+	// Remove while still holding the lock, so a new instance never locks a file that is
+	// about to be deleted
+	err := os.Remove(l.path)
+	if err != nil && os.IsNotExist(err) {
+		err = nil
+	}
+	_ = syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file = nil
+	return err
+}
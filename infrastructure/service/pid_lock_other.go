@@ -0,0 +1,40 @@
+//go:build !unix
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// ErrAlreadyRunning is returned when another process holds the PID file lock
+var ErrAlreadyRunning = errors.New("another tosage instance is already running")
+
+// PIDLock is the daemon PID file. File locks are only supported on Unix, so on other
+// platforms it records the PID without preventing a second instance.
+type PIDLock struct {
+	path string
+}
+
+// AcquirePIDLock writes the current PID to path
+func AcquirePIDLock(path string) (*PIDLock, error) {
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write PID file: %w", err)
+	}
+	return &PIDLock{path: path}, nil
+}
+
+// Release removes the PID file
+func (l *PIDLock) Release() error {
+	if l == nil || l.path == "" {
+		return nil
+	}
+	err := os.Remove(l.path)
+	l.path = ""
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+//go:build unix
+
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestAcquirePIDLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tosage.pid")
+
+	lock, err := AcquirePIDLock(path)
+	if err != nil {
+		t.Fatalf("AcquirePIDLock() error = %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || string(content) != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("PID file = %q, %v, want %d", content, err, os.Getpid())
+	}
+
+	// flock locks belong to the open file, so a second open conflicts even in this process
+	if _, err := AcquirePIDLock(path); !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("second AcquirePIDLock() error = %v, want ErrAlreadyRunning", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("PID file still exists after Release(): %v", err)
+	}
+
+	lock, err = AcquirePIDLock(path)
+	if err != nil {
+		t.Fatalf("AcquirePIDLock() after Release() error = %v", err)
+	}
+	_ = lock.Release()
+}
+
+func TestAcquirePIDLock_StalePIDFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tosage.pid")
+	// A PID file left behind by a crashed instance is not locked
+	if err := os.WriteFile(path, []byte("99999999"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := AcquirePIDLock(path)
+	if err != nil {
+		t.Fatalf("AcquirePIDLock() error = %v", err)
+	}
+	defer func() { _ = lock.Release() }()
+
+	content, _ := os.ReadFile(path)
+	if string(content) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("PID file = %q, want %d", content, os.Getpid())
+	}
+}
+
+func TestLockPIDFile_Replaced(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tosage.pid")
+
+	// An instance opened the PID file just before a releasing instance removed it
+	stale, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lockPIDFile(stale, path); !errors.Is(err, errPIDFileReplaced) {
+		t.Fatalf("lockPIDFile() of a removed file error = %v, want errPIDFileReplaced", err)
+	}
+
+	// Meanwhile a new instance created and locked a new file
+	lock, err := AcquirePIDLock(path)
+	if err != nil {
+		t.Fatalf("AcquirePIDLock() error = %v", err)
+	}
+	defer func() { _ = lock.Release() }()
+
+	stale, err = os.OpenFile(filepath.Join(filepath.Dir(path), "old.pid"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lockPIDFile(stale, path); !errors.Is(err, errPIDFileReplaced) {
+		t.Errorf("lockPIDFile() of a replaced file error = %v, want errPIDFileReplaced", err)
+	}
+}
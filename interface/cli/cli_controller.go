@@ -5,6 +5,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/ca-srg/tosage/domain/repository"
 	"github.com/ca-srg/tosage/interface/presenter"
 	usecase "github.com/ca-srg/tosage/usecase/interface"
 )
@@ -18,6 +19,8 @@ type CLIController struct {
 	skipCCMetrics    bool
 	bedrockService   usecase.BedrockService
 	vertexAIService  usecase.VertexAIService
+	timezoneService  repository.TimezoneService
+	trendDays        int
 }
 
 // NewCLIController creates a new CLI controller
@@ -50,6 +53,16 @@ func (c *CLIController) SetVertexAIService(service usecase.VertexAIService) {
 	c.vertexAIService = service
 }
 
+// SetTimezoneService sets the timezone service used to compute day boundaries for the trend
+func (c *CLIController) SetTimezoneService(service repository.TimezoneService) {
+	c.timezoneService = service
+}
+
+// SetTrendDays sets the number of days of daily Claude Code totals to print (0 disables the trend)
+func (c *CLIController) SetTrendDays(days int) {
+	c.trendDays = days
+}
+
 // Run executes the CLI controller - always shows today's tokens in JST
 func (c *CLIController) Run() error {
 	// If skip CC metrics is enabled, try to show Bedrock/Vertex AI metrics instead
@@ -127,6 +140,52 @@ func (c *CLIController) Run() error {
 	// Output in the requested format
 	fmt.Printf("cursor total token: %d\n", cursorTotalTokens)
 	fmt.Printf("claude code total token: %d\n", claudeCodeTotalTokens)
-	
+
+	if c.trendDays > 0 {
+		if err := c.printTrend(jst); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to get Claude Code trend: %v\n", err)
+		}
+	}
+
 	return nil
 }
+
+// printTrend prints the daily Claude Code totals of the last trendDays days, including today
+func (c *CLIController) printTrend(defaultLocation *time.Location) error {
+	loc := defaultLocation
+	if c.timezoneService != nil {
+		if userLoc, err := c.timezoneService.GetConfiguredTimezone(); err == nil && userLoc != nil {
+			loc = userLoc
+		}
+	}
+
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	start := today.AddDate(0, 0, -(c.trendDays - 1))
+
+	breakdown, err := c.ccService.CalculateDateBreakdown(usecase.DateBreakdownFilter{
+		StartDate: &start,
+		EndDate:   &now,
+	})
+	if err != nil {
+		return err
+	}
+
+	byDate := make(map[string]usecase.DateBreakdownItem, len(breakdown.Dates))
+	for _, item := range breakdown.Dates {
+		byDate[item.Date] = item
+	}
+
+	// Fill in days without usage so the trend always covers the full range
+	items := make([]usecase.DateBreakdownItem, 0, c.trendDays)
+	for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		item, exists := byDate[date]
+		if !exists {
+			item = usecase.DateBreakdownItem{Date: date}
+		}
+		items = append(items, item)
+	}
+
+	return c.consolePresenter.PrintTokenTrend(items)
+}
@@ -0,0 +1,335 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+// RemoteWriteProbe checks that a Remote Write endpoint is reachable with the given settings
+type RemoteWriteProbe func(cfg *config.PrometheusConfig) error
+
+// SetupWizard asks for the essential settings and writes them to the config file.
+// Values already in the config file are kept without asking. When stdin is not a
+// terminal the wizard does not prompt and takes the values from the environment instead.
+type SetupWizard struct {
+	configRepo  repository.ConfigRepository
+	probe       RemoteWriteProbe
+	in          *bufio.Reader
+	out         io.Writer
+	interactive bool
+	getenv      func(string) string
+}
+
+// NewSetupWizard creates a wizard reading answers from in and writing prompts to out
+func NewSetupWizard(configRepo repository.ConfigRepository, probe RemoteWriteProbe, in io.Reader, out io.Writer, interactive bool) *SetupWizard {
+	return &SetupWizard{
+		configRepo:  configRepo,
+		probe:       probe,
+		in:          bufio.NewReader(in),
+		out:         out,
+		interactive: interactive,
+		getenv:      os.Getenv,
+	}
+}
+
+// IsTerminal reports whether f is an interactive terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Run asks for the settings, validates them and saves the config file
+func (w *SetupWizard) Run() error {
+	cfg, err := w.configRepo.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config file: %w", err)
+	}
+	if cfg == nil {
+		cfg = config.MinimalDefaultConfig()
+	}
+	template := config.MinimalDefaultConfig()
+
+	if w.interactive {
+		_, _ = fmt.Fprintf(w.out, "tosage setup writes %s. Press Enter to accept the value in brackets.\n\n", w.configRepo.GetConfigPath())
+	} else {
+		_, _ = fmt.Fprintf(w.out, "stdin is not a terminal; taking setup values from the environment\n")
+	}
+
+	if err := w.setupPrometheus(cfg); err != nil {
+		return err
+	}
+	if err := w.setupTimezone(cfg, template.CSVExport.TimeZone); err != nil {
+		return err
+	}
+	if err := w.setupProviders(cfg); err != nil {
+		return err
+	}
+
+	// Validate the file as it will be loaded, on top of the defaults
+	merged := config.DefaultConfig()
+	merged.MergeJSONConfig(cfg)
+	if err := merged.Validate(); err != nil {
+		return fmt.Errorf("the resulting configuration is invalid: %w", err)
+	}
+	if err := w.configRepo.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config file: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(w.out, "\nSaved %s\n", w.configRepo.GetConfigPath())
+	return nil
+}
+
+// setupPrometheus asks for the Remote Write endpoint and credentials and tests them
+func (w *SetupWizard) setupPrometheus(cfg *config.AppConfig) error {
+	if cfg.Prometheus == nil {
+		cfg.Prometheus = config.MinimalDefaultConfig().Prometheus
+	}
+	prom := cfg.Prometheus
+
+	if prom.RemoteWriteURL != "" {
+		w.skip("Prometheus Remote Write URL", prom.RemoteWriteURL)
+		return nil
+	}
+
+	remoteWriteURL, err := w.ask("Prometheus Remote Write URL", "TOSAGE_PROMETHEUS_REMOTE_WRITE_URL", "", validateRemoteWriteURL)
+	if err != nil {
+		return err
+	}
+	prom.RemoteWriteURL = remoteWriteURL
+
+	// Basic authentication is optional only when a client certificate authenticates
+	if prom.RemoteWriteUsername == "" {
+		label, validate := "Remote Write username", requireValue
+		if prom.ClientCertPath != "" || prom.ClientKeyPath != "" {
+			label, validate = "Remote Write username (empty for none)", nil
+		}
+		if prom.RemoteWriteUsername, err = w.ask(label, "TOSAGE_PROMETHEUS_REMOTE_WRITE_USERNAME", "", validate); err != nil {
+			return err
+		}
+	}
+	if prom.RemoteWriteUsername != "" && prom.RemoteWritePassword == "" {
+		if prom.RemoteWritePassword, err = w.ask("Remote Write password (shown as typed)", "TOSAGE_PROMETHEUS_REMOTE_WRITE_PASSWORD", "", requireValue); err != nil {
+			return err
+		}
+	}
+
+	if w.probe == nil {
+		return nil
+	}
+	_, _ = fmt.Fprintf(w.out, "Testing the Remote Write connection... ")
+	if err := w.probe(prom); err != nil {
+		_, _ = fmt.Fprintf(w.out, "failed: %v\n", err)
+		keep, askErr := w.confirm("Save the settings anyway?", false)
+		if askErr != nil {
+			return askErr
+		}
+		if !keep {
+			return fmt.Errorf("setup cancelled: the Remote Write connection test failed")
+		}
+		return nil
+	}
+	_, _ = fmt.Fprintf(w.out, "OK\n")
+	return nil
+}
+
+// setupTimezone asks for the timezone CSV exports use
+func (w *SetupWizard) setupTimezone(cfg *config.AppConfig, templateTimezone string) error {
+	if cfg.CSVExport == nil {
+		cfg.CSVExport = config.MinimalDefaultConfig().CSVExport
+	}
+	if tz := cfg.CSVExport.TimeZone; tz != "" && tz != templateTimezone {
+		w.skip("Timezone", tz)
+		return nil
+	}
+
+	tz, err := w.ask("Timezone for CSV exports (IANA name)", "TOSAGE_CSV_EXPORT_TIMEZONE", templateTimezone, func(value string) error {
+		_, err := config.LoadTimezone(value)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	cfg.CSVExport.TimeZone = tz
+	return nil
+}
+
+// setupProviders asks which cloud providers to collect. Claude Code and Cursor usage is
+// read locally and always collected.
+func (w *SetupWizard) setupProviders(cfg *config.AppConfig) error {
+	if cfg.Bedrock != nil {
+		w.skip("AWS Bedrock", enabledText(cfg.Bedrock.Enabled))
+	} else {
+		enabled, err := w.confirmEnv("Collect AWS Bedrock usage?", "TOSAGE_BEDROCK_ENABLED")
+		if err != nil {
+			return err
+		}
+		if enabled {
+			regions, err := w.ask("Bedrock regions (comma-separated)", "TOSAGE_BEDROCK_REGIONS", "us-east-1", requireValue)
+			if err != nil {
+				return err
+			}
+			cfg.Bedrock = config.DefaultConfig().Bedrock
+			cfg.Bedrock.Enabled = true
+			cfg.Bedrock.Regions = splitList(regions)
+		}
+	}
+
+	if cfg.VertexAI != nil {
+		w.skip("Google Vertex AI", enabledText(cfg.VertexAI.Enabled))
+	} else {
+		enabled, err := w.confirmEnv("Collect Google Vertex AI usage?", "TOSAGE_VERTEX_AI_ENABLED")
+		if err != nil {
+			return err
+		}
+		if enabled {
+			projectID, err := w.ask("Google Cloud project ID", "TOSAGE_VERTEX_AI_PROJECT_ID", "", requireValue)
+			if err != nil {
+				return err
+			}
+			cfg.VertexAI = config.DefaultConfig().VertexAI
+			cfg.VertexAI.Enabled = true
+			cfg.VertexAI.ProjectID = projectID
+		}
+	}
+	return nil
+}
+
+// ask prompts for a value until it passes validate. The environment variable, if set,
+// becomes the default; without a terminal it is the answer.
+func (w *SetupWizard) ask(label, envVar, fallback string, validate func(string) error) (string, error) {
+	def := fallback
+	if value := w.getenv(envVar); value != "" {
+		def = value
+	}
+
+	if !w.interactive {
+		if validate != nil {
+			if err := validate(def); err != nil {
+				return "", fmt.Errorf("%s: %w (set %s or run --setup in a terminal)", label, err, envVar)
+			}
+		}
+		return def, nil
+	}
+
+	for {
+		answer, err := w.prompt(label, def)
+		if err != nil {
+			return "", err
+		}
+		if validate == nil {
+			return answer, nil
+		}
+		if err := validate(answer); err != nil {
+			_, _ = fmt.Fprintf(w.out, "  %v\n", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// confirm asks a yes/no question; without a terminal the default is the answer
+func (w *SetupWizard) confirm(label string, def bool) (bool, error) {
+	if !w.interactive {
+		return def, nil
+	}
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		answer, err := w.prompt(label+" ["+hint+"]", "")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		_, _ = fmt.Fprintf(w.out, "  please answer y or n\n")
+	}
+}
+
+// confirmEnv asks a yes/no question defaulting to the boolean environment variable
+func (w *SetupWizard) confirmEnv(label, envVar string) (bool, error) {
+	def := false
+	switch strings.ToLower(w.getenv(envVar)) {
+	case "1", "true", "yes":
+		def = true
+	}
+	return w.confirm(label, def)
+}
+
+// prompt prints the label with the default and reads one line
+func (w *SetupWizard) prompt(label, def string) (string, error) {
+	if def != "" {
+		_, _ = fmt.Fprintf(w.out, "%s [%s]: ", label, def)
+	} else {
+		_, _ = fmt.Fprintf(w.out, "%s: ", label)
+	}
+	line, err := w.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		if err == io.EOF {
+			return "", fmt.Errorf("setup cancelled: input closed")
+		}
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// skip reports a setting that is already configured
+func (w *SetupWizard) skip(label, value string) {
+	_, _ = fmt.Fprintf(w.out, "%s: %s (already configured)\n", label, value)
+}
+
+// validateRemoteWriteURL requires an absolute HTTP(S) URL
+func validateRemoteWriteURL(value string) error {
+	if value == "" {
+		return fmt.Errorf("a URL is required")
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http:// or https:// URL", value)
+	}
+	return nil
+}
+
+// requireValue rejects an empty answer
+func requireValue(value string) error {
+	if value == "" {
+		return fmt.Errorf("a value is required")
+	}
+	return nil
+}
+
+// splitList splits a comma-separated answer, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// enabledText describes whether a provider is enabled
+func enabledText(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
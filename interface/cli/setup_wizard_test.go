@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+// memoryConfigRepository keeps the config in memory
+type memoryConfigRepository struct {
+	saved *config.AppConfig
+}
+
+func (r *memoryConfigRepository) Exists() (bool, error)                { return r.saved != nil, nil }
+func (r *memoryConfigRepository) Load() (*config.AppConfig, error)     { return r.saved, nil }
+func (r *memoryConfigRepository) GetConfigPath() string                { return "/tmp/tosage/config.json" }
+func (r *memoryConfigRepository) EnsureConfigDir() error               { return nil }
+func (r *memoryConfigRepository) Validate(cfg *config.AppConfig) error { return cfg.Validate() }
+func (r *memoryConfigRepository) Save(cfg *config.AppConfig) error     { r.saved = cfg; return nil }
+
+func TestSetupWizard_Interactive(t *testing.T) {
+	repo := &memoryConfigRepository{}
+	var probed string
+	probe := func(cfg *config.PrometheusConfig) error {
+		probed = cfg.RemoteWriteURL
+		return nil
+	}
+	answers := strings.Join([]string{
+		"not a url",                           // rejected, asked again
+		"https://prometheus.example.com/push", // Remote Write URL
+		"alice",                               // username
+		"secret-password",                     // password
+		"Nowhere/Atlantis",                    // rejected, asked again
+		"",                                    // keep the default timezone
+		"y",                                   // Bedrock
+		"us-west-2, eu-west-1",                // regions
+		"n",                                   // Vertex AI
+	}, "\n") + "\n"
+	var out bytes.Buffer
+
+	wizard := NewSetupWizard(repo, probe, strings.NewReader(answers), &out, true)
+	wizard.getenv = func(string) string { return "" }
+	if err := wizard.Run(); err != nil {
+		t.Fatalf("Run() error = %v\n%s", err, out.String())
+	}
+
+	cfg := repo.saved
+	if cfg == nil {
+		t.Fatal("config was not saved")
+	}
+	if cfg.Prometheus.RemoteWriteURL != "https://prometheus.example.com/push" || probed != cfg.Prometheus.RemoteWriteURL {
+		t.Errorf("remote write URL = %q (probed %q)", cfg.Prometheus.RemoteWriteURL, probed)
+	}
+	if cfg.Prometheus.RemoteWriteUsername != "alice" || cfg.Prometheus.RemoteWritePassword != "secret-password" {
+		t.Errorf("credentials = %q/%q", cfg.Prometheus.RemoteWriteUsername, cfg.Prometheus.RemoteWritePassword)
+	}
+	if cfg.CSVExport.TimeZone != "Asia/Tokyo" {
+		t.Errorf("timezone = %q, want the default", cfg.CSVExport.TimeZone)
+	}
+	if cfg.Bedrock == nil || !cfg.Bedrock.Enabled || strings.Join(cfg.Bedrock.Regions, ",") != "us-west-2,eu-west-1" {
+		t.Errorf("bedrock = %+v, want enabled in us-west-2 and eu-west-1", cfg.Bedrock)
+	}
+	if cfg.VertexAI != nil {
+		t.Errorf("vertex AI = %+v, want it left unset", cfg.VertexAI)
+	}
+	if got := strings.Count(out.String(), "Prometheus Remote Write URL:"); got != 2 {
+		t.Errorf("URL was asked %d times, want 2 after the invalid answer", got)
+	}
+}
+
+func TestSetupWizard_SkipsConfiguredValues(t *testing.T) {
+	existing := config.MinimalDefaultConfig()
+	existing.Prometheus.RemoteWriteURL = "https://prometheus.example.com/push"
+	existing.Prometheus.RemoteWriteUsername = "alice"
+	existing.Prometheus.RemoteWritePassword = "secret-password"
+	existing.CSVExport.TimeZone = "Europe/Berlin"
+	existing.VertexAI = config.DefaultConfig().VertexAI
+	repo := &memoryConfigRepository{saved: existing}
+	probe := func(cfg *config.PrometheusConfig) error {
+		t.Error("a configured endpoint should not be probed again")
+		return nil
+	}
+	var out bytes.Buffer
+
+	// Only the Bedrock question is left
+	wizard := NewSetupWizard(repo, probe, strings.NewReader("\n"), &out, true)
+	wizard.getenv = func(string) string { return "" }
+	if err := wizard.Run(); err != nil {
+		t.Fatalf("Run() error = %v\n%s", err, out.String())
+	}
+	for _, want := range []string{"Prometheus Remote Write URL: https://prometheus.example.com/push (already configured)", "Timezone: Europe/Berlin (already configured)", "Google Vertex AI: disabled (already configured)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestSetupWizard_NonInteractive(t *testing.T) {
+	env := map[string]string{
+		"TOSAGE_PROMETHEUS_REMOTE_WRITE_URL":      "http://localhost:9090/api/v1/write",
+		"TOSAGE_PROMETHEUS_REMOTE_WRITE_USERNAME": "alice",
+		"TOSAGE_PROMETHEUS_REMOTE_WRITE_PASSWORD": "secret-password",
+		"TOSAGE_VERTEX_AI_ENABLED":                "true",
+	}
+	probe := func(cfg *config.PrometheusConfig) error {
+		return fmt.Errorf("connection refused")
+	}
+
+	// A failed connection test cancels setup without a terminal to confirm
+	wizard := NewSetupWizard(&memoryConfigRepository{}, probe, strings.NewReader(""), &bytes.Buffer{}, false)
+	wizard.getenv = func(name string) string { return env[name] }
+	if err := wizard.Run(); err == nil || !strings.Contains(err.Error(), "connection test failed") {
+		t.Errorf("Run() error = %v, want the failed connection test", err)
+	}
+
+	// A missing required value names the environment variable to set
+	repo := &memoryConfigRepository{}
+	wizard = NewSetupWizard(repo, nil, strings.NewReader(""), &bytes.Buffer{}, false)
+	wizard.getenv = func(name string) string { return env[name] }
+	if err := wizard.Run(); err == nil || !strings.Contains(err.Error(), "TOSAGE_VERTEX_AI_PROJECT_ID") {
+		t.Errorf("Run() error = %v, want one naming TOSAGE_VERTEX_AI_PROJECT_ID", err)
+	}
+
+	env["TOSAGE_VERTEX_AI_PROJECT_ID"] = "my-project"
+	if err := wizard.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if repo.saved.Prometheus.RemoteWriteURL != env["TOSAGE_PROMETHEUS_REMOTE_WRITE_URL"] || repo.saved.VertexAI == nil || repo.saved.VertexAI.ProjectID != "my-project" {
+		t.Errorf("saved config = %+v, want the values from the environment", repo.saved)
+	}
+}
@@ -10,7 +10,6 @@ import (
 	"os/exec"
 	"os/signal"
 	"runtime"
-	"strconv"
 	"sync"
 	"syscall"
 	"time"
@@ -30,14 +29,14 @@ type DaemonController struct {
 	metricsService usecase.MetricsService
 	systrayCtrl    *SystrayController
 
-	ctx             context.Context
-	cancel          context.CancelFunc
-	wg              sync.WaitGroup
-	logger          domain.Logger
-	pidFile         string
-	metricsTicker   *time.Ticker
-	metricsTickerMu sync.Mutex
-	isPaused        bool
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	logger      domain.Logger
+	pauseMu     sync.Mutex
+	isPaused    bool
+	triggerChan chan os.Signal
+	wakeChan    chan struct{}
 }
 
 // NewDaemonController creates a new daemon controller
@@ -73,16 +72,18 @@ func (d *DaemonController) startInternal() error {
 	// Create context for cancellation
 	d.ctx, d.cancel = context.WithCancel(context.Background())
 
-	// Write PID file
-	if err := d.writePIDFile(); err != nil {
-		return fmt.Errorf("failed to write PID file: %w", err)
-	}
-
 	// Update status service
 	if err := d.statusService.SetDaemonStarted(time.Now()); err != nil {
 		return fmt.Errorf("failed to update daemon status: %w", err)
 	}
 
+	// SIGUSR1 triggers an immediate metrics send from the run loop
+	d.triggerChan = make(chan os.Signal, 1)
+	signal.Notify(d.triggerChan, syscall.SIGUSR1)
+
+	// OnSystemWake asks the run loop for a catch-up send
+	d.wakeChan = make(chan struct{}, 1)
+
 	// Start the daemon run loop in a goroutine
 	d.wg.Add(1)
 	go d.run()
@@ -116,11 +117,6 @@ func (d *DaemonController) Stop() error {
 		d.logger.Error(d.ctx, "Failed to update daemon status", domain.NewField("error", err.Error()))
 	}
 
-	// Remove PID file
-	if err := d.removePIDFile(); err != nil {
-		d.logger.Error(d.ctx, "Failed to remove PID file", domain.NewField("error", err.Error()))
-	}
-
 	// Unregister system event handler
 	UnregisterSystemEventHandler(d)
 
@@ -151,7 +147,6 @@ func (d *DaemonController) Run() {
 		// Perform cleanup after systray exits
 		d.wg.Wait()
 		_ = d.statusService.SetDaemonStopped()
-		_ = d.removePIDFile()
 		UnregisterSystemEventHandler(d)
 		d.logger.Info(d.ctx, "Daemon stopped successfully")
 	})
@@ -160,19 +155,28 @@ func (d *DaemonController) Run() {
 // run is the main daemon loop
 func (d *DaemonController) run() {
 	defer d.wg.Done()
+	defer signal.Stop(d.triggerChan)
 
-	// Start periodic metrics if configured
+	// Start periodic metrics if configured. The metrics service decides when the next send is
+	// due, following the cron schedule or the shortest per-source interval.
+	var metricsTick <-chan time.Time
+	var metricsTimer *time.Timer
+	var graceDone <-chan struct{}
 	if d.config.Prometheus != nil && d.metricsService != nil {
-		interval := time.Duration(d.config.Prometheus.IntervalSec) * time.Second
-		d.metricsTickerMu.Lock()
-		d.metricsTicker = time.NewTicker(interval)
-		d.metricsTickerMu.Unlock()
-		defer d.metricsTicker.Stop()
-
-		// Send initial metrics
-		d.sendMetrics()
-		d.updateNextSendTime(interval)
+		// Wait out the startup grace period without blocking the menu bar
+		done := make(chan struct{})
+		go func() {
+			if d.metricsService.WaitBeforeFirstPush(d.ctx) {
+				close(done)
+			}
+		}()
+		graceDone = done
 	}
+	defer func() {
+		if metricsTimer != nil {
+			metricsTimer.Stop()
+		}
+	}()
 
 	// Main loop
 	for {
@@ -180,17 +184,40 @@ func (d *DaemonController) run() {
 		case <-d.ctx.Done():
 			return
 
-		case <-d.metricsTicker.C:
-			if !d.isPaused {
-				d.sendMetrics()
-				d.updateNextSendTime(time.Duration(d.config.Prometheus.IntervalSec) * time.Second)
+		case <-graceDone:
+			graceDone = nil
+			// Send initial metrics
+			d.sendMetrics()
+			metricsTimer = time.NewTimer(d.scheduleNextSend())
+			metricsTick = metricsTimer.C
+
+		case <-metricsTick:
+			d.pauseMu.Lock()
+			paused := d.isPaused
+			d.pauseMu.Unlock()
+			if !paused {
+				d.sendDueMetrics()
 			}
+			metricsTimer.Reset(d.scheduleNextSend())
 
 		case <-d.systrayCtrl.GetSendNowChannel():
 			d.logger.Info(d.ctx, "Manual metrics send requested")
 			d.sendMetrics()
 			d.systrayCtrl.ShowNotification("Metrics Sent", "Token cc metrics sent successfully")
 
+		case <-d.wakeChan:
+			// Ticks missed during sleep are not replayed; catch up once and restart the schedule
+			d.logger.Info(d.ctx, "Sending catch-up metrics after wake")
+			d.sendMetrics()
+			if metricsTimer != nil {
+				metricsTimer.Reset(d.scheduleNextSend())
+			}
+
+		case sig := <-d.triggerChan:
+			// Sent from this loop, so it never overlaps a scheduled send and leaves the ticker as is
+			d.logger.Info(d.ctx, "Metrics collection manually triggered", domain.NewField("signal", sig.String()))
+			d.sendMetrics()
+
 		case <-d.systrayCtrl.GetSettingsChannel():
 			d.openSettings()
 
@@ -208,8 +235,18 @@ func (d *DaemonController) run() {
 	}
 }
 
-// sendMetrics sends current metrics
+// sendMetrics sends the metrics of every source, at startup or on request
 func (d *DaemonController) sendMetrics() {
+	d.sendWith(d.metricsService.SendCurrentMetrics)
+}
+
+// sendDueMetrics sends the metrics of the sources whose interval has elapsed, on a scheduled tick
+func (d *DaemonController) sendDueMetrics() {
+	d.sendWith(d.metricsService.SendDueMetrics)
+}
+
+// sendWith updates today's token count, sends metrics with send and records the outcome
+func (d *DaemonController) sendWith(send func() error) {
 	d.logger.Debug(d.ctx, "Sending metrics...")
 
 	// Get current cc
@@ -221,12 +258,12 @@ func (d *DaemonController) sendMetrics() {
 	}
 
 	// Update token count in status
-	if err := d.statusService.UpdateTodayTokenCount(int64(tokens)); err != nil {
+	if err := d.statusService.UpdateTodayTokenCount(tokens); err != nil {
 		d.logger.Error(d.ctx, "Failed to update token count", domain.NewField("error", err.Error()))
 	}
 
 	// Send metrics
-	if err := d.metricsService.SendCurrentMetrics(); err != nil {
+	if err := send(); err != nil {
 		d.logger.Error(d.ctx, "Failed to send metrics", domain.NewField("error", err.Error()))
 		_ = d.statusService.RecordError(err)
 		return
@@ -243,12 +280,14 @@ func (d *DaemonController) sendMetrics() {
 	d.systrayCtrl.UpdateStatus(status)
 }
 
-// updateNextSendTime updates the next metrics send time
-func (d *DaemonController) updateNextSendTime(interval time.Duration) {
-	nextTime := time.Now().Add(interval)
+// scheduleNextSend records the next scheduled metrics send and returns how long until it
+func (d *DaemonController) scheduleNextSend() time.Duration {
+	now := time.Now()
+	nextTime := d.metricsService.NextSendTime(now)
 	if err := d.statusService.UpdateNextMetricsSend(nextTime); err != nil {
 		d.logger.Error(d.ctx, "Failed to update next send time", domain.NewField("error", err.Error()))
 	}
+	return nextTime.Sub(now)
 }
 
 // setupSignalHandlers sets up signal handlers for graceful shutdown
@@ -264,50 +303,19 @@ func (d *DaemonController) setupSignalHandlers() {
 		d.wg.Wait()
 		// Clean up
 		_ = d.statusService.SetDaemonStopped()
-		_ = d.removePIDFile()
 		UnregisterSystemEventHandler(d)
 		// Quit system tray to unblock the main thread
 		systray.Quit()
 	}()
 }
 
-// writePIDFile writes the process ID to a file
-func (d *DaemonController) writePIDFile() error {
-	if d.config.Daemon == nil || d.config.Daemon.PidFile == "" {
-		return nil
-	}
-
-	pid := os.Getpid()
-	pidStr := strconv.Itoa(pid)
-
-	if err := os.WriteFile(d.config.Daemon.PidFile, []byte(pidStr), 0644); err != nil {
-		return fmt.Errorf("failed to write PID file: %w", err)
-	}
-
-	d.pidFile = d.config.Daemon.PidFile
-	return nil
-}
-
-// removePIDFile removes the PID file
-func (d *DaemonController) removePIDFile() error {
-	if d.pidFile == "" {
-		return nil
-	}
-
-	if err := os.Remove(d.pidFile); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to remove PID file: %w", err)
-	}
-
-	return nil
-}
-
 // OnSystemSleep handles system sleep events
 func (d *DaemonController) OnSystemSleep() {
 	d.logger.Info(d.ctx, "System going to sleep, pausing metrics collection")
 
-	d.metricsTickerMu.Lock()
+	d.pauseMu.Lock()
 	d.isPaused = true
-	d.metricsTickerMu.Unlock()
+	d.pauseMu.Unlock()
 
 	// Update status to indicate sleep
 	_ = d.statusService.RecordError(fmt.Errorf("system sleeping"))
@@ -317,19 +325,25 @@ func (d *DaemonController) OnSystemSleep() {
 func (d *DaemonController) OnSystemWake() {
 	d.logger.Info(d.ctx, "System waking up, resuming metrics collection")
 
-	d.metricsTickerMu.Lock()
+	d.pauseMu.Lock()
 	d.isPaused = false
-	d.metricsTickerMu.Unlock()
+	d.pauseMu.Unlock()
 
 	// Clear sleep error
 	_ = d.statusService.ClearError()
 
-	// Send catch-up metrics after a brief delay to allow network to stabilize
+	// Send catch-up metrics from the main loop after a brief delay to allow network to stabilize
 	go func() {
-		time.Sleep(5 * time.Second)
-		d.logger.Info(d.ctx, "Sending catch-up metrics after wake")
-		d.sendMetrics()
-		d.updateNextSendTime(time.Duration(d.config.Prometheus.IntervalSec) * time.Second)
+		select {
+		case <-time.After(5 * time.Second):
+		case <-d.ctx.Done():
+			return
+		}
+		select {
+		case d.wakeChan <- struct{}{}:
+		default:
+			// A catch-up send is already pending
+		}
 	}()
 }
 
@@ -5,7 +5,9 @@ package controller
 
 import (
 	"context"
+	"os"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -148,7 +150,7 @@ func TestDaemonController_MetricsSending(t *testing.T) {
 	// Create mock services
 	ccService := &MockCcService{tokenCount: 12345}
 	statusService := impl.NewStatusService()
-	metricsService := &MockMetricsService{}
+	metricsService := &MockMetricsService{nextSendIn: time.Second}
 	configService := &MockConfigService{}
 	systrayCtrl := NewSystrayController(ccService, statusService, metricsService, configService, nil, nil)
 
@@ -167,9 +169,12 @@ func TestDaemonController_MetricsSending(t *testing.T) {
 	// Wait for automatic metrics send
 	time.Sleep(1500 * time.Millisecond)
 
-	// Check that metrics were sent
-	if metricsService.GetSendCount() < 1 {
-		t.Error("Expected metrics to be sent at least once")
+	// Check that metrics were sent, on the scheduled tick only for the sources due
+	if metricsService.GetSendCount() < 2 {
+		t.Error("Expected the initial and a scheduled metrics send")
+	}
+	if metricsService.GetDueCount() < 1 {
+		t.Error("Expected the scheduled tick to send only the due metrics")
 	}
 
 	// Check status was updated
@@ -235,6 +240,89 @@ func TestDaemonController_ManualMetricsSend(t *testing.T) {
 	}
 }
 
+func TestDaemonController_SignalTriggeredSend(t *testing.T) {
+	cfg := &config.AppConfig{
+		Daemon: &config.DaemonConfig{
+			Enabled: true,
+		},
+		Prometheus: &config.PrometheusConfig{
+			IntervalSec: 600, // Long interval so automatic send doesn't interfere
+			TimeoutSec:  30,
+		},
+	}
+
+	ccService := &MockCcService{tokenCount: 100}
+	statusService := impl.NewStatusService()
+	metricsService := &MockMetricsService{}
+	configService := &MockConfigService{}
+	systrayCtrl := NewSystrayController(ccService, statusService, metricsService, configService, nil, nil)
+	daemon := NewDaemonController(cfg, configService, ccService, statusService, metricsService, systrayCtrl, &mockLogger{})
+
+	if err := daemon.Start(); err != nil {
+		t.Fatalf("Failed to start daemon: %v", err)
+	}
+	defer func() {
+		_ = daemon.Stop()
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("Failed to send SIGUSR1: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if sendCount := metricsService.GetSendCount(); sendCount != 2 { // 1 initial + 1 triggered
+		t.Errorf("Expected 2 metrics sends, got %d", sendCount)
+	}
+}
+
+func TestDaemonController_GracePeriod(t *testing.T) {
+	cfg := &config.AppConfig{
+		Daemon: &config.DaemonConfig{
+			Enabled: true,
+		},
+		Prometheus: &config.PrometheusConfig{
+			IntervalSec:     600,
+			TimeoutSec:      30,
+			InitialDelaySec: 60,
+		},
+	}
+
+	ccService := &MockCcService{tokenCount: 100}
+	statusService := impl.NewStatusService()
+	metricsService := &MockMetricsService{grace: make(chan struct{})}
+	configService := &MockConfigService{}
+	systrayCtrl := NewSystrayController(ccService, statusService, metricsService, configService, nil, nil)
+	daemon := NewDaemonController(cfg, configService, ccService, statusService, metricsService, systrayCtrl, &mockLogger{})
+
+	if err := daemon.Start(); err != nil {
+		t.Fatalf("Failed to start daemon: %v", err)
+	}
+	defer func() {
+		_ = daemon.Stop()
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	if sendCount := metricsService.GetSendCount(); sendCount != 0 {
+		t.Fatalf("Expected no metrics sends during the grace period, got %d", sendCount)
+	}
+
+	// The menu bar keeps working while the first push waits
+	systrayCtrl.sendNowChan <- struct{}{}
+	time.Sleep(100 * time.Millisecond)
+	if sendCount := metricsService.GetSendCount(); sendCount != 1 {
+		t.Fatalf("Expected the manual send during the grace period, got %d sends", sendCount)
+	}
+
+	close(metricsService.grace)
+	time.Sleep(100 * time.Millisecond)
+	if sendCount := metricsService.GetSendCount(); sendCount != 2 { // 1 manual + 1 initial
+		t.Errorf("Expected 2 metrics sends, got %d", sendCount)
+	}
+}
+
 func TestDaemonController_SystemEvents(t *testing.T) {
 	// Skip if not on Darwin
 	if !isDarwin() {
@@ -286,6 +374,7 @@ func TestDaemonController_SystemEvents(t *testing.T) {
 	}
 
 	// Simulate system wake
+	wokeAt := time.Now()
 	daemon.OnSystemWake()
 
 	// Check that daemon is resumed
@@ -300,20 +389,30 @@ func TestDaemonController_SystemEvents(t *testing.T) {
 	if metricsService.GetSendCount() < 2 {
 		t.Error("Expected catch-up metrics to be sent after wake")
 	}
+
+	// The schedule restarts from the catch-up send
+	status, _ = statusService.GetStatus()
+	if status.NextMetricsSendAt == nil || !status.NextMetricsSendAt.After(wokeAt.Add(time.Hour)) {
+		t.Errorf("Expected the next send to be rescheduled after wake, got %v", status.NextMetricsSendAt)
+	}
 }
 
 // Mock implementations for testing
 
 type MockCcService struct {
-	tokenCount int
+	tokenCount int64
 	err        error
 }
 
-func (m *MockCcService) CalculateDailyTokens(date time.Time) (int, error) {
+func (m *MockCcService) CalculateDailyTokens(date time.Time) (int64, error) {
 	return m.tokenCount, m.err
 }
 
-func (m *MockCcService) CalculateTodayTokens() (int, error) {
+func (m *MockCcService) CalculateTodayTokens() (int64, error) {
+	return m.tokenCount, m.err
+}
+
+func (m *MockCcService) CalculateTodayAllTokens() (int64, error) {
 	return m.tokenCount, m.err
 }
 
@@ -357,11 +456,11 @@ func (m *MockCcService) GetDateRange() (start, end time.Time, err error) {
 	return time.Now(), time.Now(), nil
 }
 
-func (m *MockCcService) CalculateDailyTokensInUserTimezone(date time.Time) (int, error) {
+func (m *MockCcService) CalculateDailyTokensInUserTimezone(date time.Time) (int64, error) {
 	return m.tokenCount, m.err
 }
 
-func (m *MockCcService) CalculateTodayTokensInUserTimezone() (int, error) {
+func (m *MockCcService) CalculateTodayTokensInUserTimezone() (int64, error) {
 	return m.tokenCount, m.err
 }
 
@@ -370,9 +469,12 @@ func (m *MockCcService) GetDateRangeInUserTimezone() (start, end time.Time, err
 }
 
 type MockMetricsService struct {
-	mu        sync.Mutex
-	sendCount int
-	err       error
+	mu         sync.Mutex
+	sendCount  int
+	dueCount   int
+	err        error
+	nextSendIn time.Duration // defaults to an hour
+	grace      chan struct{} // holds back the first push until closed, if set
 }
 
 func (m *MockMetricsService) StartPeriodicMetrics() error {
@@ -390,6 +492,47 @@ func (m *MockMetricsService) SendCurrentMetrics() error {
 	return m.err
 }
 
+func (m *MockMetricsService) SendCurrentMetricsWithReport() (*usecase.MetricsSendReport, error) {
+	err := m.SendCurrentMetrics()
+	return usecase.NewMetricsSendReport(time.Now()), err
+}
+
+func (m *MockMetricsService) SendCurrentMetricsIfIdle() (bool, error) {
+	return true, m.SendCurrentMetrics()
+}
+
+func (m *MockMetricsService) SendDueMetrics() error {
+	m.mu.Lock()
+	m.dueCount++
+	m.mu.Unlock()
+	return m.SendCurrentMetrics()
+}
+
+func (m *MockMetricsService) NextSendTime(after time.Time) time.Time {
+	if m.nextSendIn == 0 {
+		return after.Add(time.Hour)
+	}
+	return after.Add(m.nextSendIn)
+}
+
+func (m *MockMetricsService) WaitBeforeFirstPush(ctx context.Context) bool {
+	if m.grace == nil {
+		return true
+	}
+	select {
+	case <-m.grace:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (m *MockMetricsService) GetDueCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dueCount
+}
+
 func (m *MockMetricsService) GetSendCount() int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
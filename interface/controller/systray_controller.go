@@ -4,6 +4,7 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -12,6 +13,9 @@ import (
 	"github.com/getlantern/systray"
 )
 
+// connectionCheckTimeout bounds the credential check performed before enabling a provider
+const connectionCheckTimeout = 30 * time.Second
+
 // SystrayController manages the system tray menu and interactions
 type SystrayController struct {
 	ccService       usecase.CcService
@@ -271,7 +275,10 @@ func (s *SystrayController) handleBedrockToggle() {
 	// This is a simplified implementation - in practice, you'd update the config file
 	if newState {
 		// Check AWS credentials before enabling
-		if err := s.bedrockService.CheckConnection(); err != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), connectionCheckTimeout)
+		err := s.bedrockService.CheckConnection(ctx)
+		cancel()
+		if err != nil {
 			s.ShowNotification("Error", "AWS credentials not configured or CloudWatch access denied")
 			// Revert checkbox state
 			if currentState {
@@ -307,7 +314,10 @@ func (s *SystrayController) handleVertexAIToggle() {
 	// This is a simplified implementation - in practice, you'd update the config file
 	if newState {
 		// Check GCP credentials before enabling
-		if err := s.vertexAIService.CheckConnection(); err != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), connectionCheckTimeout)
+		err := s.vertexAIService.CheckConnection(ctx)
+		cancel()
+		if err != nil {
 			s.ShowNotification("Error", "GCP credentials not configured or Cloud Monitoring access denied")
 			// Revert checkbox state
 			if currentState {
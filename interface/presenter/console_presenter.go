@@ -8,19 +8,106 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"github.com/ca-srg/tosage/domain/valueobject"
 	usecase "github.com/ca-srg/tosage/usecase/interface"
 )
 
+// DefaultThousandsSeparator is the digit group separator used unless configured otherwise
+const DefaultThousandsSeparator = ","
+
+// DefaultCostPrecision is the number of decimal places costs are printed with unless configured otherwise
+const DefaultCostPrecision = 2
+
 // ConsolePresenterImpl implements ConsolePresenter for terminal output
 type ConsolePresenterImpl struct {
-	writer io.Writer
+	writer             io.Writer
+	errWriter          io.Writer
+	rawNumbers         bool
+	thousandsSeparator string
+	costPrecision      int
+	hashProjectPaths   bool
 }
 
-// NewConsolePresenter creates a new console presenter
+// NewConsolePresenter creates a new console presenter writing to stdout and errors to stderr
 func NewConsolePresenter() *ConsolePresenterImpl {
+	return NewConsolePresenterWithWriter(os.Stdout, os.Stderr)
+}
+
+// NewConsolePresenterWithWriter creates a console presenter writing output to out and
+// errors to errOut, e.g. to capture the output in tests or when embedding tosage
+func NewConsolePresenterWithWriter(out, errOut io.Writer) *ConsolePresenterImpl {
 	return &ConsolePresenterImpl{
-		writer: os.Stdout,
+		writer:             out,
+		errWriter:          errOut,
+		thousandsSeparator: DefaultThousandsSeparator,
+		costPrecision:      DefaultCostPrecision,
+	}
+}
+
+// SetRawNumbers prints numbers as plain integers without digit grouping
+func (p *ConsolePresenterImpl) SetRawNumbers(raw bool) {
+	p.rawNumbers = raw
+}
+
+// SetThousandsSeparator sets the digit group separator; empty restores the default
+func (p *ConsolePresenterImpl) SetThousandsSeparator(separator string) {
+	if separator == "" {
+		separator = DefaultThousandsSeparator
+	}
+	p.thousandsSeparator = separator
+}
+
+// SetHashProjectPaths prints project paths as their stable hash
+func (p *ConsolePresenterImpl) SetHashProjectPaths(hash bool) {
+	p.hashProjectPaths = hash
+}
+
+// SetCostPrecision sets the decimal places costs are printed with; a negative value restores the default
+func (p *ConsolePresenterImpl) SetCostPrecision(decimals int) {
+	if decimals < 0 {
+		decimals = DefaultCostPrecision
+	}
+	p.costPrecision = decimals
+}
+
+// LocaleThousandsSeparator returns the digit group separator of the locale in
+// LC_ALL, LC_NUMERIC or LANG, falling back to the default for unknown locales
+func LocaleThousandsSeparator() string {
+	locale := ""
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if locale = os.Getenv(name); locale != "" {
+			break
+		}
+	}
+	// e.g. "de_DE.UTF-8" -> "de_DE"
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+
+	switch {
+	case locale == "de_CH" || locale == "it_CH":
+		return "'"
+	case hasLanguage(locale, "de", "es", "it", "nl", "pt", "id", "tr", "da", "el"):
+		return "."
+	case hasLanguage(locale, "fr", "ru", "pl", "cs", "sv", "fi", "nb", "uk", "sk", "hu"):
+		return "\u00a0"
+	default:
+		return DefaultThousandsSeparator
+	}
+}
+
+// hasLanguage checks if the locale's language code is one of languages
+func hasLanguage(locale string, languages ...string) bool {
+	language := locale
+	if i := strings.Index(locale, "_"); i >= 0 {
+		language = locale[:i]
+	}
+	for _, l := range languages {
+		if language == l {
+			return true
+		}
 	}
+	return false
 }
 
 // PrintVersion prints version information
@@ -30,7 +117,7 @@ func (p *ConsolePresenterImpl) PrintVersion() {
 
 // PrintError prints an error message
 func (p *ConsolePresenterImpl) PrintError(err error) {
-	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	_, _ = fmt.Fprintf(p.errWriter, "Error: %v\n", err)
 }
 
 // PrintStringList prints a list of strings with a title
@@ -43,13 +130,13 @@ func (p *ConsolePresenterImpl) PrintStringList(title string, items []string) err
 }
 
 // PrintDailyTokens prints daily token count (simple format)
-func (p *ConsolePresenterImpl) PrintDailyTokens(date time.Time, tokens int) error {
+func (p *ConsolePresenterImpl) PrintDailyTokens(date time.Time, tokens int64) error {
 	_, _ = fmt.Fprintln(p.writer, tokens)
 	return nil
 }
 
 // PrintDailyTokensVerbose prints daily token count with date
-func (p *ConsolePresenterImpl) PrintDailyTokensVerbose(date time.Time, tokens int) error {
+func (p *ConsolePresenterImpl) PrintDailyTokensVerbose(date time.Time, tokens int64) error {
 	_, _ = fmt.Fprintf(p.writer, "Date: %s\n", date.Format("2006-01-02"))
 	_, _ = fmt.Fprintf(p.writer, "Total Tokens: %s\n", p.formatNumber(tokens))
 	return nil
@@ -67,7 +154,7 @@ func (p *ConsolePresenterImpl) PrintTokenStats(stats *usecase.TokenStatsResult)
 			stats.DateRange.Days)
 	}
 
-	_, _ = fmt.Fprintf(p.writer, "Entries: %s\n", p.formatNumber(stats.EntryCount))
+	_, _ = fmt.Fprintf(p.writer, "Entries: %s\n", p.formatNumber(int64(stats.EntryCount)))
 	_, _ = fmt.Fprintln(p.writer)
 
 	// Token breakdown
@@ -80,7 +167,7 @@ func (p *ConsolePresenterImpl) PrintTokenStats(stats *usecase.TokenStatsResult)
 	_, _ = fmt.Fprintln(p.writer)
 
 	// Cost
-	_, _ = fmt.Fprintf(p.writer, "Total Cost: %s %.2f\n", p.getCurrencySymbol(stats.Currency), stats.Cost)
+	_, _ = fmt.Fprintf(p.writer, "Total Cost: %s\n", p.formatCost(stats.Currency, stats.Cost))
 
 	return nil
 }
@@ -104,11 +191,10 @@ func (p *ConsolePresenterImpl) PrintCostBreakdown(result *usecase.CostBreakdownR
 
 	// Data rows
 	for _, item := range result.Breakdowns {
-		_, _ = fmt.Fprintf(w, "%s\t%s\t%s %.2f\t%.1f%%\t%d\n",
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%.1f%%\t%d\n",
 			p.truncateString(item.Key, 20),
 			p.formatNumber(item.TotalTokens),
-			p.getCurrencySymbol(item.Currency),
-			item.Cost,
+			p.formatCost(item.Currency, item.Cost),
 			item.Percentage,
 			item.EntryCount)
 	}
@@ -120,10 +206,9 @@ func (p *ConsolePresenterImpl) PrintCostBreakdown(result *usecase.CostBreakdownR
 		strings.Repeat("-", 12),
 		strings.Repeat("-", 10),
 		strings.Repeat("-", 7))
-	_, _ = fmt.Fprintf(w, "Total\t%s\t%s %.2f\t100.0%%\t%d\n",
+	_, _ = fmt.Fprintf(w, "Total\t%s\t%s\t100.0%%\t%d\n",
 		p.formatNumber(result.Total.TotalTokens),
-		p.getCurrencySymbol(result.Total.Currency),
-		result.Total.Cost,
+		p.formatCost(result.Total.Currency, result.Total.Cost),
 		result.Total.EntryCount)
 
 	_ = w.Flush()
@@ -153,14 +238,13 @@ func (p *ConsolePresenterImpl) PrintModelBreakdown(result *usecase.ModelBreakdow
 	// Data rows
 	for _, model := range result.Models {
 		cacheTokens := model.CacheCreationTokens + model.CacheReadTokens
-		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s %.2f\t%.1f%%\t%.1f%%\n",
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%.1f%%\t%.1f%%\n",
 			p.truncateString(model.ModelName, 25),
 			p.formatNumber(model.InputTokens),
 			p.formatNumber(model.OutputTokens),
 			p.formatNumber(cacheTokens),
 			p.formatNumber(model.TotalTokens),
-			p.getCurrencySymbol(model.Currency),
-			model.Cost,
+			p.formatCost(model.Currency, model.Cost),
 			model.TokenPercentage,
 			model.CostPercentage)
 	}
@@ -177,13 +261,12 @@ func (p *ConsolePresenterImpl) PrintModelBreakdown(result *usecase.ModelBreakdow
 		strings.Repeat("-", 8))
 
 	totalCache := result.Total.CacheCreationTokens + result.Total.CacheReadTokens
-	_, _ = fmt.Fprintf(w, "Total\t%s\t%s\t%s\t%s\t%s %.2f\t100.0%%\t100.0%%\n",
+	_, _ = fmt.Fprintf(w, "Total\t%s\t%s\t%s\t%s\t%s\t100.0%%\t100.0%%\n",
 		p.formatNumber(result.Total.InputTokens),
 		p.formatNumber(result.Total.OutputTokens),
 		p.formatNumber(totalCache),
 		p.formatNumber(result.Total.TotalTokens),
-		p.getCurrencySymbol(result.Total.Currency),
-		result.Total.Cost)
+		p.formatCost(result.Total.Currency, result.Total.Cost))
 
 	_ = w.Flush()
 	return nil
@@ -211,14 +294,13 @@ func (p *ConsolePresenterImpl) PrintDateBreakdown(result *usecase.DateBreakdownR
 	// Data rows
 	for _, date := range result.Dates {
 		cacheTokens := date.CacheCreationTokens + date.CacheReadTokens
-		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s %.2f\t%d\n",
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\n",
 			date.Date,
 			p.formatNumber(date.InputTokens),
 			p.formatNumber(date.OutputTokens),
 			p.formatNumber(cacheTokens),
 			p.formatNumber(date.TotalTokens),
-			p.getCurrencySymbol(date.Currency),
-			date.Cost,
+			p.formatCost(date.Currency, date.Cost),
 			date.EntryCount)
 	}
 
@@ -233,19 +315,53 @@ func (p *ConsolePresenterImpl) PrintDateBreakdown(result *usecase.DateBreakdownR
 		strings.Repeat("-", 7))
 
 	totalCache := result.Total.CacheCreationTokens + result.Total.CacheReadTokens
-	_, _ = fmt.Fprintf(w, "Total\t%s\t%s\t%s\t%s\t%s %.2f\t%d\n",
+	_, _ = fmt.Fprintf(w, "Total\t%s\t%s\t%s\t%s\t%s\t%d\n",
 		p.formatNumber(result.Total.InputTokens),
 		p.formatNumber(result.Total.OutputTokens),
 		p.formatNumber(totalCache),
 		p.formatNumber(result.Total.TotalTokens),
-		p.getCurrencySymbol(result.Total.Currency),
-		result.Total.Cost,
+		p.formatCost(result.Total.Currency, result.Total.Cost),
 		result.Total.EntryCount)
 
 	_ = w.Flush()
 	return nil
 }
 
+// sparkBlocks are the characters used to draw the trend sparkline, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// PrintTokenTrend prints daily token totals, oldest first, with a sparkline
+func (p *ConsolePresenterImpl) PrintTokenTrend(items []usecase.DateBreakdownItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	var maxTokens int64
+	for _, item := range items {
+		if item.TotalTokens > maxTokens {
+			maxTokens = item.TotalTokens
+		}
+	}
+
+	spark := make([]rune, len(items))
+	for i, item := range items {
+		level := 0
+		if maxTokens > 0 {
+			level = int(item.TotalTokens * int64(len(sparkBlocks)-1) / maxTokens)
+		}
+		spark[i] = sparkBlocks[level]
+	}
+
+	_, _ = fmt.Fprintf(p.writer, "claude code trend (last %d days): %s\n", len(items), string(spark))
+
+	w := tabwriter.NewWriter(p.writer, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for _, item := range items {
+		_, _ = fmt.Fprintf(w, "  %s\t%s\t\n", item.Date, p.formatNumber(item.TotalTokens))
+	}
+	_ = w.Flush()
+	return nil
+}
+
 // PrintCcSummary prints usage summary
 func (p *ConsolePresenterImpl) PrintCcSummary(summary *usecase.CcSummaryResult) error {
 	_, _ = fmt.Fprintln(p.writer, "Cc Summary")
@@ -261,14 +377,14 @@ func (p *ConsolePresenterImpl) PrintCcSummary(summary *usecase.CcSummaryResult)
 	// Overview
 	_, _ = fmt.Fprintln(p.writer, "Overview:")
 	_, _ = fmt.Fprintf(p.writer, "  Total Tokens:       %s\n", p.formatNumber(summary.TotalTokens))
-	_, _ = fmt.Fprintf(p.writer, "  Total Cost:         %s %.2f\n", p.getCurrencySymbol(summary.Currency), summary.TotalCost)
-	_, _ = fmt.Fprintf(p.writer, "  Total Entries:      %s\n", p.formatNumber(summary.EntryCount))
+	_, _ = fmt.Fprintf(p.writer, "  Total Cost:         %s\n", p.formatCost(summary.Currency, summary.TotalCost))
+	_, _ = fmt.Fprintf(p.writer, "  Total Entries:      %s\n", p.formatNumber(int64(summary.EntryCount)))
 	_, _ = fmt.Fprintln(p.writer)
 
 	// Daily averages
 	_, _ = fmt.Fprintln(p.writer, "Daily Averages:")
 	_, _ = fmt.Fprintf(p.writer, "  Tokens per Day:     %s\n", p.formatNumber(summary.AverageDailyTokens))
-	_, _ = fmt.Fprintf(p.writer, "  Cost per Day:       %s %.2f\n", p.getCurrencySymbol(summary.Currency), summary.AverageDailyCost)
+	_, _ = fmt.Fprintf(p.writer, "  Cost per Day:       %s\n", p.formatCost(summary.Currency, summary.AverageDailyCost))
 	_, _ = fmt.Fprintln(p.writer)
 
 	// Cc patterns
@@ -280,7 +396,7 @@ func (p *ConsolePresenterImpl) PrintCcSummary(summary *usecase.CcSummaryResult)
 		_, _ = fmt.Fprintf(p.writer, "  Most Used Model:    %s\n", summary.MostUsedModel)
 	}
 	if summary.MostActiveProject != "" {
-		_, _ = fmt.Fprintf(p.writer, "  Most Active Project: %s\n", summary.MostActiveProject)
+		_, _ = fmt.Fprintf(p.writer, "  Most Active Project: %s\n", valueobject.DisplayProjectPath(summary.MostActiveProject, p.hashProjectPaths))
 	}
 	_, _ = fmt.Fprintln(p.writer)
 
@@ -299,15 +415,13 @@ func (p *ConsolePresenterImpl) PrintCostEstimate(estimate *usecase.CostEstimateR
 	_, _ = fmt.Fprintln(p.writer, "Monthly Cost Estimate")
 	_, _ = fmt.Fprintln(p.writer, strings.Repeat("=", 40))
 
-	_, _ = fmt.Fprintf(p.writer, "Estimated Monthly Cost: %s %.2f\n",
-		p.getCurrencySymbol(estimate.Currency),
-		estimate.EstimatedMonthlyCost)
+	_, _ = fmt.Fprintf(p.writer, "Estimated Monthly Cost: %s\n",
+		p.formatCost(estimate.Currency, estimate.EstimatedMonthlyCost))
 	_, _ = fmt.Fprintln(p.writer)
 
 	_, _ = fmt.Fprintf(p.writer, "Based on:         %d days of data\n", estimate.BasedOnDays)
-	_, _ = fmt.Fprintf(p.writer, "Average Daily:    %s %.2f\n",
-		p.getCurrencySymbol(estimate.Currency),
-		estimate.AverageDailyCost)
+	_, _ = fmt.Fprintf(p.writer, "Average Daily:    %s\n",
+		p.formatCost(estimate.Currency, estimate.AverageDailyCost))
 	_, _ = fmt.Fprintf(p.writer, "Confidence:       %.0f%%\n", estimate.Confidence*100)
 
 	if estimate.Confidence < 0.5 {
@@ -345,13 +459,12 @@ func (p *ConsolePresenterImpl) PrintCcData(data *usecase.CcDataResult) error {
 
 	// Data rows
 	for _, entry := range data.Entries {
-		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s %.2f\n",
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
 			entry.Timestamp.Format("2006-01-02 15:04:05"),
-			p.truncateString(entry.ProjectPath, 20),
+			p.truncateString(valueobject.DisplayProjectPath(entry.ProjectPath, p.hashProjectPaths), 20),
 			p.truncateString(entry.Model, 20),
-			p.formatNumber(entry.TotalTokens),
-			p.getCurrencySymbol(entry.Currency),
-			entry.Cost)
+			p.formatNumber(int64(entry.TotalTokens)),
+			p.formatCost(entry.Currency, entry.Cost))
 	}
 
 	_ = w.Flush()
@@ -365,23 +478,32 @@ func (p *ConsolePresenterImpl) PrintCcData(data *usecase.CcDataResult) error {
 
 // Helper methods
 
-func (p *ConsolePresenterImpl) formatNumber(n int) string {
-	if n < 1000 {
+func (p *ConsolePresenterImpl) formatNumber(n int64) string {
+	if p.rawNumbers || n < 1000 {
 		return fmt.Sprintf("%d", n)
 	}
 
-	// Format with commas
+	// Format with the thousands separator
+	separator := p.thousandsSeparator
+	if separator == "" {
+		separator = DefaultThousandsSeparator
+	}
 	str := fmt.Sprintf("%d", n)
 	result := ""
 	for i, digit := range str {
 		if i > 0 && (len(str)-i)%3 == 0 {
-			result += ","
+			result += separator
 		}
 		result += string(digit)
 	}
 	return result
 }
 
+// formatCost prints an amount with its currency symbol at the configured precision
+func (p *ConsolePresenterImpl) formatCost(currency string, amount float64) string {
+	return fmt.Sprintf("%s %.*f", p.getCurrencySymbol(currency), p.costPrecision, amount)
+}
+
 func (p *ConsolePresenterImpl) getCurrencySymbol(currency string) string {
 	switch currency {
 	case "USD":
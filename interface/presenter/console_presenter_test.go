@@ -0,0 +1,136 @@
+package presenter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ca-srg/tosage/domain/valueobject"
+	usecase "github.com/ca-srg/tosage/usecase/interface"
+)
+
+func TestConsolePresenterImpl_FormatNumber(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       bool
+		separator string
+		n         int64
+		want      string
+	}{
+		{name: "default", n: 1234567, want: "1,234,567"},
+		{name: "small", n: 999, want: "999"},
+		{name: "beyond int32", n: 5000000000, want: "5,000,000,000"},
+		{name: "raw", raw: true, n: 1234567, want: "1234567"},
+		{name: "custom separator", separator: ".", n: 1234567, want: "1.234.567"},
+		{name: "empty separator uses default", separator: "", n: 1000, want: "1,000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewConsolePresenter()
+			p.SetRawNumbers(tt.raw)
+			p.SetThousandsSeparator(tt.separator)
+			if got := p.formatNumber(tt.n); got != tt.want {
+				t.Errorf("formatNumber(%d) = %q, want %q", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewConsolePresenterWithWriter(t *testing.T) {
+	var out, errOut bytes.Buffer
+	p := NewConsolePresenterWithWriter(&out, &errOut)
+
+	if err := p.PrintDailyTokensVerbose(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), 1234); err != nil {
+		t.Fatalf("PrintDailyTokensVerbose() error = %v", err)
+	}
+	p.PrintError(errors.New("boom"))
+
+	if got, want := out.String(), "Date: 2025-03-01\nTotal Tokens: 1,234\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if got, want := errOut.String(), "Error: boom\n"; got != want {
+		t.Errorf("error output = %q, want %q", got, want)
+	}
+}
+
+func TestConsolePresenterImpl_FormatCost(t *testing.T) {
+	tests := []struct {
+		name      string
+		precision int
+		currency  string
+		amount    float64
+		want      string
+	}{
+		{name: "default", precision: -1, currency: "USD", amount: 12.345, want: "$ 12.35"},
+		{name: "sub-cent rounds away by default", precision: -1, currency: "USD", amount: 0.0034, want: "$ 0.00"},
+		{name: "four decimals", precision: 4, currency: "USD", amount: 0.0034, want: "$ 0.0034"},
+		{name: "other currency", precision: 3, currency: "EUR", amount: 1.5, want: "€ 1.500"},
+		{name: "no decimals", precision: 0, currency: "JPY", amount: 1234.4, want: "¥ 1234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewConsolePresenter()
+			p.SetCostPrecision(tt.precision)
+			if got := p.formatCost(tt.currency, tt.amount); got != tt.want {
+				t.Errorf("formatCost(%q, %v) = %q, want %q", tt.currency, tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocaleThousandsSeparator(t *testing.T) {
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{lang: "en_US.UTF-8", want: ","},
+		{lang: "ja_JP.UTF-8", want: ","},
+		{lang: "de_DE.UTF-8", want: "."},
+		{lang: "de_CH.UTF-8", want: "'"},
+		{lang: "fr_FR.UTF-8", want: "\u00a0"},
+		{lang: "", want: ","},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			t.Setenv("LC_ALL", "")
+			t.Setenv("LC_NUMERIC", "")
+			t.Setenv("LANG", tt.lang)
+			if got := LocaleThousandsSeparator(); got != tt.want {
+				t.Errorf("LocaleThousandsSeparator() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsolePresenterImpl_HashProjectPaths(t *testing.T) {
+	summary := &usecase.CcSummaryResult{MostActiveProject: "/work/secret-client"}
+	data := &usecase.CcDataResult{TotalCount: 1, Entries: []usecase.CcDataEntry{
+		{Timestamp: time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC), ProjectPath: "/work/secret-client", Model: "claude-sonnet-4"},
+	}}
+	hashed := valueobject.HashProjectPath("/work/secret-client")
+
+	for _, hash := range []bool{false, true} {
+		var out bytes.Buffer
+		p := NewConsolePresenterWithWriter(&out, io.Discard)
+		p.SetHashProjectPaths(hash)
+		if err := p.PrintCcSummary(summary); err != nil {
+			t.Fatalf("PrintCcSummary() error = %v", err)
+		}
+		if err := p.PrintCcData(data); err != nil {
+			t.Fatalf("PrintCcData() error = %v", err)
+		}
+
+		if got := strings.Contains(out.String(), "secret-client"); got == hash {
+			t.Errorf("hash=%v: output contains the raw path = %v:\n%s", hash, got, out.String())
+		}
+		if got := strings.Count(out.String(), hashed); hash && got != 2 {
+			t.Errorf("hash=%v: output contains the hashed path %d times, want 2:\n%s", hash, got, out.String())
+		}
+	}
+}
@@ -6,13 +6,15 @@ import (
 	"os"
 	"time"
 
+	"github.com/ca-srg/tosage/domain/valueobject"
 	usecase "github.com/ca-srg/tosage/usecase/interface"
 )
 
 // JSONPresenterImpl implements JSONPresenter for JSON output
 type JSONPresenterImpl struct {
-	writer  io.Writer
-	encoder *json.Encoder
+	writer           io.Writer
+	encoder          *json.Encoder
+	hashProjectPaths bool
 }
 
 // NewJSONPresenter creates a new JSON presenter
@@ -26,8 +28,13 @@ func NewJSONPresenter() *JSONPresenterImpl {
 	}
 }
 
+// SetHashProjectPaths prints project paths as their stable hash
+func (p *JSONPresenterImpl) SetHashProjectPaths(hash bool) {
+	p.hashProjectPaths = hash
+}
+
 // PrintDailyTokens prints daily token count as JSON
-func (p *JSONPresenterImpl) PrintDailyTokens(date time.Time, tokens int) error {
+func (p *JSONPresenterImpl) PrintDailyTokens(date time.Time, tokens int64) error {
 	data := map[string]interface{}{
 		"date":   date.Format("2006-01-02"),
 		"tokens": tokens,
@@ -38,7 +45,7 @@ func (p *JSONPresenterImpl) PrintDailyTokens(date time.Time, tokens int) error {
 // PrintTokenStats prints token statistics as JSON
 func (p *JSONPresenterImpl) PrintTokenStats(stats *usecase.TokenStatsResult) error {
 	data := map[string]interface{}{
-		"tokens": map[string]int{
+		"tokens": map[string]int64{
 			"input":         stats.InputTokens,
 			"output":        stats.OutputTokens,
 			"cacheCreation": stats.CacheCreationTokens,
@@ -70,7 +77,7 @@ func (p *JSONPresenterImpl) PrintCostBreakdown(result *usecase.CostBreakdownResu
 	for i, item := range result.Breakdowns {
 		breakdowns[i] = map[string]interface{}{
 			"key": item.Key,
-			"tokens": map[string]int{
+			"tokens": map[string]int64{
 				"input":         item.InputTokens,
 				"output":        item.OutputTokens,
 				"cacheCreation": item.CacheCreationTokens,
@@ -89,7 +96,7 @@ func (p *JSONPresenterImpl) PrintCostBreakdown(result *usecase.CostBreakdownResu
 	data := map[string]interface{}{
 		"breakdowns": breakdowns,
 		"total": map[string]interface{}{
-			"tokens": map[string]int{
+			"tokens": map[string]int64{
 				"input":         result.Total.InputTokens,
 				"output":        result.Total.OutputTokens,
 				"cacheCreation": result.Total.CacheCreationTokens,
@@ -114,7 +121,7 @@ func (p *JSONPresenterImpl) PrintModelBreakdown(result *usecase.ModelBreakdownRe
 	for i, model := range result.Models {
 		models[i] = map[string]interface{}{
 			"modelName": model.ModelName,
-			"tokens": map[string]int{
+			"tokens": map[string]int64{
 				"input":         model.InputTokens,
 				"output":        model.OutputTokens,
 				"cacheCreation": model.CacheCreationTokens,
@@ -134,7 +141,7 @@ func (p *JSONPresenterImpl) PrintModelBreakdown(result *usecase.ModelBreakdownRe
 	data := map[string]interface{}{
 		"models": models,
 		"total": map[string]interface{}{
-			"tokens": map[string]int{
+			"tokens": map[string]int64{
 				"input":         result.Total.InputTokens,
 				"output":        result.Total.OutputTokens,
 				"cacheCreation": result.Total.CacheCreationTokens,
@@ -159,7 +166,7 @@ func (p *JSONPresenterImpl) PrintDateBreakdown(result *usecase.DateBreakdownResu
 	for i, date := range result.Dates {
 		dates[i] = map[string]interface{}{
 			"date": date.Date,
-			"tokens": map[string]int{
+			"tokens": map[string]int64{
 				"input":         date.InputTokens,
 				"output":        date.OutputTokens,
 				"cacheCreation": date.CacheCreationTokens,
@@ -177,7 +184,7 @@ func (p *JSONPresenterImpl) PrintDateBreakdown(result *usecase.DateBreakdownResu
 	data := map[string]interface{}{
 		"dates": dates,
 		"total": map[string]interface{}{
-			"tokens": map[string]int{
+			"tokens": map[string]int64{
 				"input":         result.Total.InputTokens,
 				"output":        result.Total.OutputTokens,
 				"cacheCreation": result.Total.CacheCreationTokens,
@@ -228,7 +235,7 @@ func (p *JSONPresenterImpl) PrintCcSummary(summary *usecase.CcSummaryResult) err
 		data["mostUsedModel"] = summary.MostUsedModel
 	}
 	if summary.MostActiveProject != "" {
-		data["mostActiveProject"] = summary.MostActiveProject
+		data["mostActiveProject"] = valueobject.DisplayProjectPath(summary.MostActiveProject, p.hashProjectPaths)
 	}
 
 	return p.encoder.Encode(data)
@@ -259,7 +266,7 @@ func (p *JSONPresenterImpl) PrintCcData(data *usecase.CcDataResult) error {
 			"timestamp":   entry.Timestamp.Format(time.RFC3339),
 			"date":        entry.Date,
 			"sessionId":   entry.SessionID,
-			"projectPath": entry.ProjectPath,
+			"projectPath": valueobject.DisplayProjectPath(entry.ProjectPath, p.hashProjectPaths),
 			"model":       entry.Model,
 			"tokens": map[string]int{
 				"input":         entry.InputTokens,
@@ -14,14 +14,15 @@ type ConsolePresenter interface {
 	PrintStringList(title string, items []string) error
 
 	// Token-related output
-	PrintDailyTokens(date time.Time, tokens int) error
-	PrintDailyTokensVerbose(date time.Time, tokens int) error
+	PrintDailyTokens(date time.Time, tokens int64) error
+	PrintDailyTokensVerbose(date time.Time, tokens int64) error
 	PrintTokenStats(stats *usecase.TokenStatsResult) error
 
 	// Breakdown output
 	PrintCostBreakdown(result *usecase.CostBreakdownResult, groupBy string) error
 	PrintModelBreakdown(result *usecase.ModelBreakdownResult) error
 	PrintDateBreakdown(result *usecase.DateBreakdownResult) error
+	PrintTokenTrend(items []usecase.DateBreakdownItem) error
 
 	// Summary and estimates
 	PrintCcSummary(summary *usecase.CcSummaryResult) error
@@ -34,7 +35,7 @@ type ConsolePresenter interface {
 // JSONPresenter handles JSON output formatting
 type JSONPresenter interface {
 	// Token-related output
-	PrintDailyTokens(date time.Time, tokens int) error
+	PrintDailyTokens(date time.Time, tokens int64) error
 	PrintTokenStats(stats *usecase.TokenStatsResult) error
 
 	// Breakdown output
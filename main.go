@@ -2,28 +2,64 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"slices"
+	"sort"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/ca-srg/tosage/domain"
+	"github.com/ca-srg/tosage/domain/entity"
+	"github.com/ca-srg/tosage/infrastructure/auth"
 	infraConfig "github.com/ca-srg/tosage/infrastructure/config"
 	"github.com/ca-srg/tosage/infrastructure/di"
+	infraRepo "github.com/ca-srg/tosage/infrastructure/repository"
+	"github.com/ca-srg/tosage/infrastructure/service"
 	"github.com/ca-srg/tosage/interface/cli"
+	"github.com/ca-srg/tosage/interface/presenter"
 	"github.com/ca-srg/tosage/usecase/impl"
+	usecase "github.com/ca-srg/tosage/usecase/interface"
 )
 
+// maxTrendDays is the largest value accepted by --trend
+const maxTrendDays = 90
+
+// ccTokenMetric is the series --delta reads back from Prometheus
+const ccTokenMetric = "tosage_cc_token"
+
+// Version is set at build time via -ldflags "-X main.Version=..."
+var Version = "dev"
+
 func main() {
 	// Parse command line flags
 	var (
 		cliMode         = flag.Bool("cli", false, "Run in CLI mode (default is daemon mode on macOS)")
 		daemonMode      = flag.Bool("daemon", false, "Run in daemon mode (macOS only)")
 		debugMode       = flag.Bool("debug", false, "Enable debug logging to stdout")
+		debugUnsafe     = flag.Bool("debug-unsafe", false, "Like --debug, but print passwords, tokens and keys in debug output unmasked")
 		includeBedrock  = flag.Bool("bedrock", false, "Include AWS Bedrock usage metrics (requires AWS credentials)")
 		includeVertexAI = flag.Bool("vertex-ai", false, "Include Google Vertex AI usage metrics (requires Google Cloud credentials)")
+		providerList    = flag.String("providers", "", "Comma-separated providers to enable, overriding the configuration: claude,cursor,bedrock,vertex_ai (cannot be combined with --bedrock/--vertex-ai)")
+		interval        = flag.Duration("interval", 0, "Override the metrics push interval for this run (e.g. 60s, 5m; minimum 60s)")
+		trend           = flag.Int("trend", 0, "Also print daily Claude Code token totals for the last N days (CLI mode)")
+		rawNumbers      = flag.Bool("raw-numbers", false, "Print numbers in console output as plain integers without separators")
+		numberSeparator = flag.String("number-separator", "", "Digit group separator for console output (default \",\"; \"locale\" uses LC_NUMERIC/LANG)")
+		setup           = flag.Bool("setup", false, "Prompt for the essential settings, write them to the config file and exit (reads the environment when stdin is not a terminal)")
+		validateGCPKey  = flag.String("validate-gcp-key", "", "Validate a Google Cloud service account key (file path or inline JSON) and exit")
+		selfTest        = flag.Bool("selftest", false, "Write a test metric and log line to each configured backend and exit")
+		logPreview      = flag.Bool("log-preview", false, "Print the log lines and labels that would be pushed to Loki to stdout instead of pushing them")
+		delta           = flag.Bool("delta", false, "Show the change in today's Claude Code tokens since the value last pushed to Prometheus and exit")
+		status          = flag.Bool("status", false, "Check that each enabled provider can be reached, print the results and exit")
+		jsonOutput      = flag.Bool("json", false, "Print --status results as JSON")
+		printDashboard  = flag.Bool("print-dashboard", false, "Print a Grafana dashboard JSON for the metrics the current configuration sends and exit")
+		entryFilter     = flag.String("filter", "", "Only count Claude Code entries matching an expression, e.g. 'model ~ \"claude-3\" AND project = \"/work/app\"'")
+		failOnPushError = flag.Bool("fail-on-push-error", false, "Exit non-zero when the one-shot metrics push of a CLI-mode run fails")
 
 		// CSV export flags
 		exportCSV   = flag.Bool("export-csv", false, "Export metrics to CSV file")
@@ -31,20 +67,96 @@ func main() {
 		startTime   = flag.String("start-time", "", "Start time in ISO 8601 format (default: 30 days ago)")
 		endTime     = flag.String("end-time", "", "End time in ISO 8601 format (default: now)")
 		metricTypes = flag.String("metrics-types", "", "Comma-separated list of metric types to export (claude_code,cursor,bedrock,vertex_ai,all)")
+		compress    = flag.Bool("compress", false, "Gzip the CSV export (implied by an --output ending in .csv.gz)")
+		exportRange = flag.String("range", "", "Named export range: last-7-days, last-month, this-month or ytd (cannot be combined with --start-time/--end-time)")
+		granularity = flag.String("granularity", "", "CSV export rows per entry, hourly or daily (default: daily; entry and hourly require --metrics-types claude_code)")
+		exportDry   = flag.Bool("export-dry-run", false, "Report the rows per source and time range a CSV export would write, without writing it")
+		minTokens   = flag.Int("min-tokens", 0, "Leave out CSV export rows with fewer tokens than this, checked after hourly or daily aggregation")
 	)
+	var excludeModels stringListFlag
+	flag.Var(&excludeModels, "exclude-model", "Exclude Claude Code models matching this glob or prefix from totals and metrics (repeatable)")
 	flag.Parse()
 
+	// Key validation does not need configuration or providers
+	if *validateGCPKey != "" {
+		os.Exit(runValidateGCPKey(*validateGCPKey))
+	}
+
+	// Setup writes the config file the container would otherwise load
+	if *setup {
+		os.Exit(runSetup())
+	}
+
+	// Debug output masks secrets unless they are explicitly asked for
+	if *debugUnsafe {
+		*debugMode = true
+		infraConfig.SetUnsafeDebug(true)
+	}
+
 	// Create DI container with options
 	opts := []di.ContainerOption{}
 	if *debugMode {
 		opts = append(opts, di.WithDebugMode(true))
 	}
+	if *logPreview {
+		opts = append(opts, di.WithLogPreview(true))
+	}
 	if *includeBedrock {
 		opts = append(opts, di.WithBedrockEnabled(true))
 	}
 	if *includeVertexAI {
 		opts = append(opts, di.WithVertexAIEnabled(true))
 	}
+	// Whether a cloud provider was enabled on the command line, which daemon mode doesn't support
+	cloudProviders := *includeBedrock || *includeVertexAI
+	if *providerList != "" {
+		if cloudProviders {
+			fmt.Fprintf(os.Stderr, "--providers cannot be combined with --bedrock or --vertex-ai\n")
+			os.Exit(1)
+		}
+		providers, err := di.ParseProviders(*providerList)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --providers: %v\n", err)
+			os.Exit(1)
+		}
+		for _, provider := range providers {
+			cloudProviders = cloudProviders || provider == usecase.MetricsSourceBedrock || provider == usecase.MetricsSourceVertexAI
+		}
+		opts = append(opts, di.WithProviders(providers))
+	}
+	if *interval != 0 {
+		if *interval < infraConfig.MinPrometheusIntervalSec*time.Second {
+			fmt.Fprintf(os.Stderr, "Invalid --interval %s: must be at least %ds\n", *interval, infraConfig.MinPrometheusIntervalSec)
+			os.Exit(1)
+		}
+		opts = append(opts, di.WithMetricsInterval(*interval))
+	}
+	if len(excludeModels) > 0 {
+		opts = append(opts, di.WithExcludedModels(excludeModels))
+	}
+	var ccFilter *entity.CcEntryFilter
+	if *entryFilter != "" {
+		var err error
+		ccFilter, err = entity.ParseCcEntryFilter(*entryFilter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --filter: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, di.WithEntryFilter(ccFilter))
+	}
+	if *trend < 0 || *trend > maxTrendDays {
+		fmt.Fprintf(os.Stderr, "Invalid --trend %d: must be between 1 and %d\n", *trend, maxTrendDays)
+		os.Exit(1)
+	}
+
+	if *rawNumbers || *numberSeparator != "" {
+		separator := *numberSeparator
+		if separator == "locale" {
+			separator = presenter.LocaleThousandsSeparator()
+		}
+		opts = append(opts, di.WithNumberFormat(*rawNumbers, separator))
+	}
+	opts = append(opts, di.WithVersion(Version))
 
 	container, err := di.NewContainer(opts...)
 	if err != nil {
@@ -52,12 +164,30 @@ func main() {
 		os.Exit(1)
 	}
 
+	printStartupSummary(container)
+
+	if *selfTest {
+		os.Exit(runSelfTest(container))
+	}
+
+	if *delta {
+		os.Exit(runDelta(container))
+	}
+
+	if *status {
+		os.Exit(runStatus(container, *jsonOutput))
+	}
+
+	if *printDashboard {
+		os.Exit(runPrintDashboard(container, *includeBedrock, *includeVertexAI))
+	}
+
 	// Get configuration
 	config := container.GetConfig()
 
 	// Check if CSV export mode is requested
-	if *exportCSV {
-		runCSVExportMode(container, *output, *startTime, *endTime, *metricTypes)
+	if *exportCSV || *exportDry {
+		runCSVExportMode(container, *output, *startTime, *endTime, *exportRange, *granularity, *metricTypes, *minTokens, ccFilter, *compress, *exportDry)
 		return
 	}
 
@@ -85,9 +215,9 @@ func main() {
 		runDaemon = true
 	}
 
-	// Daemon mode is not supported when Bedrock or Vertex AI flags are set
-	if runDaemon && (*includeBedrock || *includeVertexAI) {
-		fmt.Fprintf(os.Stderr, "Daemon mode is not supported when --bedrock or --vertex-ai flags are set\n")
+	// Daemon mode is not supported when Bedrock or Vertex AI is enabled on the command line
+	if runDaemon && cloudProviders {
+		fmt.Fprintf(os.Stderr, "Daemon mode is not supported when --bedrock, --vertex-ai or a cloud provider in --providers is set\n")
 		os.Exit(1)
 	}
 
@@ -95,32 +225,250 @@ func main() {
 	if runDaemon {
 		runDaemonMode(container)
 	} else {
-		runCLIMode(container)
+		runCLIMode(container, *trend, *failOnPushError)
 	}
 }
 
-// handleShutdown handles graceful shutdown with signal handling
-func handleShutdown(metricsService interface{ StopPeriodicMetrics() error }, logger domain.Logger) {
-	// Create channel to listen for interrupt signals
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+// stringListFlag is a repeatable string flag that also accepts comma-separated values
+type stringListFlag []string
 
-	// Wait for signal
-	<-sigChan
+func (f *stringListFlag) String() string {
+	return strings.Join(*f, ",")
+}
 
-	// Stop metrics service gracefully
-	ctx := context.Background()
-	logger.Info(ctx, "Shutting down metrics service...")
-	if err := metricsService.StopPeriodicMetrics(); err != nil {
-		logger.Error(ctx, "Error stopping metrics service", domain.NewField("error", err.Error()))
+func (f *stringListFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*f = append(*f, v)
+		}
+	}
+	return nil
+}
+
+// runValidateGCPKey checks a service account key and prints what was detected.
+// It returns the process exit code.
+func runValidateGCPKey(keyOrPath string) int {
+	info, err := auth.InspectServiceAccountKey(context.Background(), keyOrPath)
+	if info != nil {
+		fmt.Printf("Source:         %s\n", info.Source)
+		fmt.Printf("Type:           %s\n", info.Type)
+		fmt.Printf("Project ID:     %s\n", info.ProjectID)
+		fmt.Printf("Client email:   %s\n", info.ClientEmail)
+		fmt.Printf("Private key ID: %s\n", info.PrivateKeyID)
+		fmt.Printf("Private key:    ****\n")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Service account key is invalid: %v\n", err)
+		return 1
+	}
+
+	fmt.Println("Service account key is valid")
+	return 0
+}
+
+// runSetup runs the setup wizard and returns the process exit code
+func runSetup() int {
+	wizard := cli.NewSetupWizard(infraRepo.NewJSONConfigRepository(), probeRemoteWrite, os.Stdin, os.Stdout, cli.IsTerminal(os.Stdin))
+	if err := wizard.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Setup failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// probeRemoteWrite checks the Remote Write endpoint with the settings entered during setup
+func probeRemoteWrite(cfg *infraConfig.PrometheusConfig) error {
+	probeConfig := *cfg
+	if probeConfig.TimeoutSec <= 0 {
+		probeConfig.TimeoutSec = infraConfig.DefaultConfig().Prometheus.TimeoutSec
+	}
+	repo, err := infraRepo.NewPrometheusMetricsRepository(&probeConfig)
+	if err != nil {
+		return err
+	}
+	promRepo, ok := repo.(*infraRepo.PrometheusMetricsRepository)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(probeConfig.TimeoutSec)*time.Second)
+	defer cancel()
+	return promRepo.Probe(ctx)
+}
+
+// runSelfTest writes to each configured backend and returns the process exit code
+func runSelfTest(container *di.Container) int {
+	checks := container.SelfTest()
+	if len(checks) == 0 {
+		fmt.Fprintf(os.Stderr, "Self-test: no metrics or logging backend is configured\n")
+		return 1
+	}
+
+	exitCode := 0
+	fmt.Printf("Self-test:\n")
+	for _, check := range checks {
+		status := "OK"
+		if !check.OK {
+			status = "FAILED"
+			exitCode = 1
+		}
+		fmt.Printf("  %-25s %-6s %s\n", check.Name, status, check.Detail)
+	}
+	return exitCode
+}
+
+// runPrintDashboard writes a Grafana dashboard for the configured metrics to stdout
+func runPrintDashboard(container *di.Container, includeBedrock, includeVertexAI bool) int {
+	cfg := container.GetConfig()
+	dashboard, err := infraRepo.BuildGrafanaDashboard(cfg, infraRepo.DashboardSources{
+		Bedrock:  includeBedrock || (cfg.Bedrock != nil && cfg.Bedrock.Enabled),
+		VertexAI: includeVertexAI || (cfg.VertexAI != nil && cfg.VertexAI.Enabled),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to build dashboard: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(dashboard))
+	return 0
+}
+
+// statusCheckTimeout bounds the provider checks of --status
+const statusCheckTimeout = 30 * time.Second
+
+// runStatus checks each enabled provider and prints the report as a table or as JSON.
+// It returns the process exit code, non-zero if any check failed.
+func runStatus(container *di.Container, asJSON bool) int {
+	ctx, cancel := context.WithTimeout(context.Background(), statusCheckTimeout)
+	defer cancel()
+	report := container.GetStatusService().CheckAll(ctx)
+
+	exitCode := 0
+	if !report.OK {
+		exitCode = 1
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode status: %v\n", err)
+			return 1
+		}
+		return exitCode
+	}
+
+	if len(report.Providers) == 0 {
+		fmt.Printf("Status: no provider is enabled\n")
+		return exitCode
+	}
+
+	providers := make([]string, 0, len(report.Providers))
+	for provider := range report.Providers {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	fmt.Printf("Status:\n")
+	for _, provider := range providers {
+		result := report.Providers[provider]
+		state := "OK"
+		if result.Status != usecase.ProviderStatusOK {
+			state = "FAILED"
+		}
+		fmt.Printf("  %-25s %-6s %6dms %s\n", provider, state, result.LatencyMs, result.Error)
+	}
+	return exitCode
+}
+
+// runDelta compares today's Claude Code tokens with the last value pushed to Prometheus,
+// read back from the query endpoint. It returns the process exit code.
+func runDelta(container *di.Container) int {
+	queryRepo := container.GetMetricsQueryRepository()
+	if queryRepo == nil {
+		fmt.Fprintf(os.Stderr, "--delta requires the Prometheus query URL (prometheus.url or TOSAGE_PROMETHEUS_URL)\n")
+		return 1
+	}
+
+	current, err := container.GetCcService().CalculateTodayTokens()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to calculate today's tokens: %v\n", err)
+		return 1
 	}
 
-	// Exit gracefully
-	os.Exit(0)
+	hostLabel := container.GetConfig().Prometheus.HostLabel
+	if hostLabel == "" {
+		hostLabel, _ = os.Hostname()
+	}
+	last, err := queryRepo.QueryLatest(ccTokenMetric, map[string]string{"host": hostLabel})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to query Prometheus: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Claude Code tokens today: %d\n", current)
+	if last == nil {
+		fmt.Printf("No %s samples for host %q in the last %d days\n", ccTokenMetric, hostLabel, int(infraRepo.QueryLookback.Hours()/24))
+		return 0
+	}
+	fmt.Printf("Last pushed:              %.0f at %s\n", last.Value, last.Timestamp.Local().Format(time.RFC3339))
+	fmt.Printf("Change:                   %+d\n", current-int64(last.Value))
+	return 0
+}
+
+// printStartupSummary prints the results of the startup checks to stderr
+func printStartupSummary(container *di.Container) {
+	checks := container.GetStartupChecks()
+	if len(checks) == 0 {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Startup summary:\n")
+	for _, check := range checks {
+		status := "OK"
+		if !check.OK {
+			status = "FAILED"
+		}
+		fmt.Fprintf(os.Stderr, "  %-25s %-6s %s\n", check.Name, status, check.Detail)
+	}
 }
 
-// runCLIMode runs the application in CLI mode
-func runCLIMode(container *di.Container) {
+// printSendReport prints the metrics that were pushed and the ones that failed to w
+func printSendReport(w io.Writer, report *usecase.MetricsSendReport) {
+	if report == nil {
+		return
+	}
+
+	for _, result := range report.Results {
+		name := result.MetricName
+		if name == "" {
+			name = result.Source
+		}
+		if result.Sent {
+			fmt.Fprintf(w, "Pushed %s = %.0f\n", name, result.Value)
+		} else {
+			fmt.Fprintf(w, "Failed to push %s: %v\n", name, result.Err)
+		}
+	}
+}
+
+// reloadSecretFilesOnSIGHUP re-reads the Loki and Remote Write credential files
+// whenever the process receives SIGHUP, so rotated secrets apply without a restart
+func reloadSecretFilesOnSIGHUP(logger domain.Logger) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+
+	ctx := context.Background()
+	for range sigChan {
+		if err := infraConfig.ReloadSecretFiles(); err != nil {
+			logger.Warn(ctx, "Failed to reload credential files", domain.NewField("error", err.Error()))
+			continue
+		}
+		logger.Info(ctx, "Reloaded credential files")
+	}
+}
+
+// runCLIMode runs the application in CLI mode. With failOnPushError, a failed one-shot
+// metrics push makes the process exit non-zero after the token count is displayed.
+func runCLIMode(container *di.Container, trendDays int, failOnPushError bool) {
 	// Get services
 	cliControllerIface := container.GetCLIController()
 	cliController, ok := cliControllerIface.(*cli.CLIController)
@@ -129,13 +477,17 @@ func runCLIMode(container *di.Container) {
 		os.Exit(1)
 	}
 
+	cliController.SetTimezoneService(container.GetTimezoneService())
+	cliController.SetTrendDays(trendDays)
+
 	// Skip Claude Code and Cursor metrics if Bedrock or Vertex AI is enabled
 	config := container.GetConfig()
 	bedrockEnabled := config.Bedrock != nil && config.Bedrock.Enabled
 	vertexAIEnabled := config.VertexAI != nil && config.VertexAI.Enabled
 
 	if bedrockEnabled || vertexAIEnabled {
-		cliController.SetSkipCCMetrics(true)
+		// An explicit provider set keeps showing Claude Code when it is included
+		cliController.SetSkipCCMetrics(!slices.Contains(container.GetProviders(), usecase.MetricsSourceClaudeCode))
 
 		// Check if services were properly initialized and set them to CLI controller
 		bedrockService := container.GetBedrockService()
@@ -160,39 +512,40 @@ func runCLIMode(container *di.Container) {
 	logger := container.CreateLogger("main")
 	ctx := context.Background()
 
-	// Check if vertex-ai flag is set
+	// Send metrics once, right away: a one-shot run exits before a startup grace period
+	// or the periodic loop would push. Vertex AI runs also print the outcome of each metric.
+	var reportOut io.Writer
 	if vertexAIEnabled {
-		// Send metrics once to Prometheus
-		if err := metricsService.SendCurrentMetrics(); err != nil {
-			logger.Error(ctx, "Failed to send metrics to Prometheus", domain.NewField("error", err.Error()))
-			fmt.Fprintf(os.Stderr, "Failed to send metrics to Prometheus: %v\n", err)
-			// Continue to display token count even if sending fails
-		} else {
-			logger.Info(ctx, "Successfully sent Vertex AI metrics to Prometheus")
-		}
-		
-		// Display token count and exit
-		if err := cliController.Run(); err != nil {
-			fmt.Fprintf(os.Stderr, "%v\n", err)
-			os.Exit(1)
-		}
-		return
-	}
-
-	// Start metrics service if Prometheus is enabled
-	if err := metricsService.StartPeriodicMetrics(); err != nil {
-		// Log error but don't fail application startup
-		logger.Warn(ctx, "Failed to start metrics service", domain.NewField("error", err.Error()))
+		reportOut = os.Stderr
 	}
+	pushFailed := pushMetricsOnce(ctx, metricsService, logger, reportOut)
 
-	// Setup graceful shutdown
-	go handleShutdown(metricsService, logger)
+	go reloadSecretFilesOnSIGHUP(logger)
 
-	// Run without arguments - always shows today's tokens in JST
+	// Display the token count even if sending failed
 	if err := cliController.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
+	if failOnPushError && pushFailed {
+		os.Exit(1)
+	}
+}
+
+// pushMetricsOnce sends the current metrics once for a one-shot run and reports whether any
+// of them failed to push. When reportOut is set, the outcome of each metric is printed to it.
+func pushMetricsOnce(ctx context.Context, metricsService usecase.MetricsService, logger domain.Logger, reportOut io.Writer) bool {
+	report, err := metricsService.SendCurrentMetricsWithReport()
+	if err != nil {
+		logger.Warn(ctx, "Failed to send metrics", domain.NewField("error", err.Error()))
+	}
+	if reportOut != nil {
+		if err != nil {
+			fmt.Fprintf(reportOut, "Failed to send metrics to Prometheus: %v\n", err)
+		}
+		printSendReport(reportOut, report)
+	}
+	return err != nil || (report != nil && report.HasFailures())
 }
 
 // runDaemonMode runs the application in daemon mode
@@ -208,11 +561,77 @@ func runDaemonMode(container *di.Container) {
 		os.Exit(1)
 	}
 
+	// Lock the PID file so a second instance exits instead of double-pushing metrics
+	var pidLock *service.PIDLock
+	if config := container.GetConfig(); config.Daemon != nil && config.Daemon.PidFile != "" {
+		lock, err := service.AcquirePIDLock(config.Daemon.PidFile)
+		if err != nil {
+			logger.Error(ctx, "Failed to acquire PID file lock",
+				domain.NewField("pid_file", config.Daemon.PidFile),
+				domain.NewField("error", err.Error()))
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		pidLock = lock
+	}
+
+	// Only the instance holding the lock listens for scrapes
+	container.StartScrapeEndpoint()
+
+	go reloadSecretFilesOnSIGHUP(logger)
+
+	// Start additional profiles, each on its own ticker
+	// InitProfiles only fails when fail_fast_on_provider_init_error is set
+	if err := container.InitProfiles(); err != nil {
+		logger.Error(ctx, "Failed to initialize profiles", domain.NewField("error", err.Error()))
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		_ = pidLock.Release()
+		os.Exit(1)
+	}
+	profileServices := container.GetProfileMetricsServices()
+	for name, metricsService := range profileServices {
+		if err := metricsService.StartPeriodicMetrics(); err != nil {
+			logger.Warn(ctx, "Failed to start profile metrics service",
+				domain.NewField("profile", name),
+				domain.NewField("error", err.Error()))
+		}
+	}
+
+	// Post the daily summary if enabled
+	summaryService := container.GetSummaryService()
+	if summaryService != nil {
+		if err := summaryService.Start(); err != nil {
+			logger.Warn(ctx, "Failed to start daily summary", domain.NewField("error", err.Error()))
+		}
+	}
+
 	// Run the daemon controller on the main thread
 	// This is required for macOS GUI components
 
 	// Call Run() using a helper function to avoid platform-specific type issues
 	runDaemonController(daemonController, logger, ctx)
+
+	if summaryService != nil {
+		if err := summaryService.Stop(); err != nil {
+			logger.Error(ctx, "Error stopping daily summary", domain.NewField("error", err.Error()))
+		}
+	}
+
+	for name, metricsService := range profileServices {
+		if err := metricsService.StopPeriodicMetrics(); err != nil {
+			logger.Error(ctx, "Error stopping profile metrics service",
+				domain.NewField("profile", name),
+				domain.NewField("error", err.Error()))
+		}
+	}
+
+	if err := pidLock.Release(); err != nil {
+		logger.Warn(ctx, "Failed to remove PID file", domain.NewField("error", err.Error()))
+	}
+
+	if err := container.CloseLogFile(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to close daemon log file: %v\n", err)
+	}
 }
 
 // runDaemonController is a helper function to run the daemon controller
@@ -229,7 +648,7 @@ func runDaemonController(daemonController interface{}, logger domain.Logger, ctx
 }
 
 // runCSVExportMode runs the application in CSV export mode
-func runCSVExportMode(container *di.Container, outputPath, startTimeStr, endTimeStr, metricTypesStr string) {
+func runCSVExportMode(container *di.Container, outputPath, startTimeStr, endTimeStr, rangePreset, granularity, metricTypesStr string, minTokens int, filter *entity.CcEntryFilter, compress, dryRun bool) {
 	// Get logger
 	logger := container.CreateLogger("main")
 	ctx := context.Background()
@@ -252,12 +671,36 @@ func runCSVExportMode(container *di.Container, outputPath, startTimeStr, endTime
 		}
 	}
 
+	// Presets are resolved in the configured timezone
+	var rangeConfig impl.ExportRangeConfig
+	if csvConfig := container.GetConfig().CSVExport; csvConfig != nil {
+		rangeConfig.MaxExportDays = csvConfig.MaxExportDays
+	}
+	if loc, err := container.GetTimezoneService().GetConfiguredTimezone(); err == nil {
+		rangeConfig.Location = loc
+	}
+
 	// Generate export options
-	options, err := impl.GenerateExportOptions(outputPath, startTimeStr, endTimeStr, metricTypes)
+	options, err := impl.GenerateExportOptions(outputPath, startTimeStr, endTimeStr, rangePreset, metricTypes, compress, rangeConfig)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Invalid export options: %v\n", err)
 		os.Exit(1)
 	}
+	options.Granularity = granularity
+	if err := impl.ValidateExportGranularity(options.Granularity, options.MetricTypes); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid export options: %v\n", err)
+		os.Exit(1)
+	}
+	if minTokens < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid --min-tokens %d: must be 0 or more\n", minTokens)
+		os.Exit(1)
+	}
+	options.MinTokens = minTokens
+	options.Filter = filter
+	// Resolve the default file name here so the message below names the file written
+	if options.OutputPath == "" {
+		options.OutputPath = impl.DefaultCSVExportPath(time.Now(), options.Compress)
+	}
 
 	// Get CSV export service
 	csvExportService := container.GetCSVExportService()
@@ -266,9 +709,19 @@ func runCSVExportMode(container *di.Container, outputPath, startTimeStr, endTime
 		os.Exit(1)
 	}
 
+	if dryRun {
+		summary, err := csvExportService.DryRun(*options)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Export dry run failed: %v\n", err)
+			os.Exit(1)
+		}
+		printExportSummary(summary)
+		return
+	}
+
 	// Perform export
 	logger.Info(ctx, "Starting CSV export",
-		domain.NewField("output", outputPath),
+		domain.NewField("output", options.OutputPath),
 		domain.NewField("startTime", startTimeStr),
 		domain.NewField("endTime", endTimeStr),
 		domain.NewField("metricTypes", metricTypes))
@@ -279,10 +732,23 @@ func runCSVExportMode(container *di.Container, outputPath, startTimeStr, endTime
 	}
 
 	// Display the output path that was actually used
-	actualOutputPath := outputPath
-	if actualOutputPath == "" {
-		actualOutputPath = options.OutputPath
-	}
+	fmt.Printf("Successfully exported metrics to: %s\n", options.OutputPath)
+}
 
-	fmt.Printf("Successfully exported metrics to: %s\n", actualOutputPath)
+// printExportSummary prints what a CSV export would write
+func printExportSummary(summary *usecase.CSVExportSummary) {
+	sources := make([]string, 0, len(summary.RowsBySource))
+	for source := range summary.RowsBySource {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	fmt.Printf("Export dry run (no file written)\n")
+	fmt.Printf("Time range: %s to %s\n", summary.StartTime.Format(time.RFC3339), summary.EndTime.Format(time.RFC3339))
+	fmt.Printf("Output:     %s\n", summary.OutputPath)
+	fmt.Printf("Rows by source:\n")
+	for _, source := range sources {
+		fmt.Printf("  %-12s %d\n", source, summary.RowsBySource[source])
+	}
+	fmt.Printf("Total rows: %d\n", summary.TotalRows)
 }
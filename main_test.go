@@ -2,10 +2,16 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"os"
 	"os/exec"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/ca-srg/tosage/infrastructure/logging"
+	usecase "github.com/ca-srg/tosage/usecase/interface"
 )
 
 // TestBackwardCompatibility_CLIMode tests that the CLI mode continues to work
@@ -264,3 +270,52 @@ func TestBackwardCompatibility_CursorIntegration(t *testing.T) {
 		}
 	}
 }
+
+// oneShotMetricsService is a MetricsService whose one-shot send returns a fixed report and error
+type oneShotMetricsService struct {
+	usecase.MetricsService
+	report *usecase.MetricsSendReport
+	err    error
+}
+
+func (s *oneShotMetricsService) SendCurrentMetricsWithReport() (*usecase.MetricsSendReport, error) {
+	return s.report, s.err
+}
+
+func TestPushMetricsOnce(t *testing.T) {
+	sent := usecase.NewMetricsSendReport(time.Now())
+	sent.AddSent(usecase.MetricsSourceBedrock, "tosage_bedrock_token", 42)
+	partial := usecase.NewMetricsSendReport(time.Now())
+	partial.AddSent(usecase.MetricsSourceClaudeCode, "tosage_cc_token", 100)
+	partial.AddFailure(usecase.MetricsSourceBedrock, "tosage_bedrock_token", errors.New("connection refused"))
+
+	tests := []struct {
+		name       string
+		report     *usecase.MetricsSendReport
+		err        error
+		wantFailed bool
+		wantOutput string
+	}{
+		{name: "all sent", report: sent, wantOutput: "Pushed tosage_bedrock_token = 42"},
+		{name: "a metric failed", report: partial, wantFailed: true, wantOutput: "Failed to push tosage_bedrock_token: connection refused"},
+		{name: "collection failed", err: errors.New("failed to calculate today's tokens"), wantFailed: true, wantOutput: "Failed to send metrics to Prometheus"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &oneShotMetricsService{report: tt.report, err: tt.err}
+
+			var out bytes.Buffer
+			if failed := pushMetricsOnce(context.Background(), service, &logging.NoOpLogger{}, &out); failed != tt.wantFailed {
+				t.Errorf("pushMetricsOnce() = %v, want %v", failed, tt.wantFailed)
+			}
+			if !strings.Contains(out.String(), tt.wantOutput) {
+				t.Errorf("output = %q, want it to contain %q", out.String(), tt.wantOutput)
+			}
+
+			// Runs that do not print the report still see the failure
+			if failed := pushMetricsOnce(context.Background(), service, &logging.NoOpLogger{}, nil); failed != tt.wantFailed {
+				t.Errorf("pushMetricsOnce() without a report = %v, want %v", failed, tt.wantFailed)
+			}
+		})
+	}
+}
@@ -17,6 +17,8 @@ import (
 	"time"
 
 	"github.com/golang/snappy"
+
+	"github.com/ca-srg/tosage/infrastructure/httpclient"
 )
 
 const (
@@ -27,6 +29,9 @@ const (
 	defaultPrometheusPass  = "password"
 )
 
+// remoteWriteClient はすべての送信で共有するHTTPクライアント（tosageのUser-Agentを付与）
+var remoteWriteClient = httpclient.NewClient(30 * time.Second)
+
 var (
 	// エンジニアの名前（ホスト名生成用）
 	firstNames = []string{
@@ -223,8 +228,7 @@ func sendMetric(ctx context.Context, cfg *Config, hostname, metricName string, v
 	req.Header.Set("Authorization", "Basic "+auth)
 
 	// リクエストを送信
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := remoteWriteClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
@@ -39,7 +39,7 @@ func TestCSVExportIntegration(t *testing.T) {
 	t.Run("ExportWithDefaultOptions", func(t *testing.T) {
 		outputPath := filepath.Join(tempDir, "test_default.csv")
 
-		options, err := impl.GenerateExportOptions(outputPath, "", "", nil)
+		options, err := impl.GenerateExportOptions(outputPath, "", "", "", nil, false, impl.ExportRangeConfig{})
 		require.NoError(t, err)
 
 		err = csvExportService.Export(*options)
@@ -78,7 +78,10 @@ func TestCSVExportIntegration(t *testing.T) {
 			outputPath,
 			startTime.Format(time.RFC3339),
 			endTime.Format(time.RFC3339),
+			"",
 			[]string{"claude_code"},
+			false,
+			impl.ExportRangeConfig{},
 		)
 		require.NoError(t, err)
 
@@ -97,7 +100,10 @@ func TestCSVExportIntegration(t *testing.T) {
 			outputPath,
 			"",
 			"",
+			"",
 			[]string{"claude_code", "cursor"},
+			false,
+			impl.ExportRangeConfig{},
 		)
 		require.NoError(t, err)
 
@@ -160,7 +166,7 @@ func TestCSVExportIntegration(t *testing.T) {
 
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
-				options, err := impl.GenerateExportOptions(tc.outputPath, "", "", nil)
+				options, err := impl.GenerateExportOptions(tc.outputPath, "", "", "", nil, false, impl.ExportRangeConfig{})
 
 				// Some validations happen during option generation
 				if err != nil {
@@ -183,7 +189,10 @@ func TestCSVExportIntegration(t *testing.T) {
 			outputPath,
 			"invalid-time-format",
 			"",
+			"",
 			nil,
+			false,
+			impl.ExportRangeConfig{},
 		)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "invalid start time")
@@ -196,7 +205,10 @@ func TestCSVExportIntegration(t *testing.T) {
 			outputPath,
 			"",
 			"",
+			"",
 			[]string{"invalid_metric"},
+			false,
+			impl.ExportRangeConfig{},
 		)
 		require.NoError(t, err)
 
@@ -47,7 +47,7 @@ func TestVertexAIAuthentication_ServiceAccountKey(t *testing.T) {
 		assert.NotNil(t, repo)
 
 		// Test connection
-		err = repo.CheckConnection()
+		err = repo.CheckConnection(context.Background())
 		assert.NoError(t, err)
 	})
 }
@@ -82,7 +82,7 @@ func TestVertexAIAuthentication_ServiceAccountKeyFile(t *testing.T) {
 		assert.NotNil(t, repo)
 
 		// Test connection
-		err = repo.CheckConnection()
+		err = repo.CheckConnection(context.Background())
 		assert.NoError(t, err)
 	})
 }
@@ -120,7 +120,7 @@ func TestVertexAIAuthentication_DefaultCredentials(t *testing.T) {
 		assert.NotNil(t, repo)
 
 		// Test connection
-		err = repo.CheckConnection()
+		err = repo.CheckConnection(context.Background())
 		assert.NoError(t, err)
 	})
 }
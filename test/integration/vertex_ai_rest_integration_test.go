@@ -119,7 +119,7 @@ func TestVertexAIRESTRepository_Integration_CountTokens(t *testing.T) {
 	})
 
 	t.Run("Check connection", func(t *testing.T) {
-		err := repo.CheckConnection()
+		err := repo.CheckConnection(ctx)
 		if err != nil {
 			t.Logf("CheckConnection failed (expected if permissions are limited): %v", err)
 			// This is not a failure - just informational
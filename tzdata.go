@@ -0,0 +1,7 @@
+//go:build !notzdata
+
+package main
+
+// Embed the timezone database so timezones still load on images without system tzdata,
+// such as distroless. Build with -tags notzdata to rely on the system database only.
+import _ "time/tzdata"
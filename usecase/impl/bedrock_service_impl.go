@@ -93,7 +93,7 @@ func (s *BedrockServiceImpl) GetCurrentUsage() (*entity.BedrockUsage, error) {
 		totalInputTokens,
 		totalOutputTokens,
 		totalCost,
-		allModelMetrics,
+		entity.FilterBedrockModelMetrics(allModelMetrics, s.config.IncludeModels, s.config.ExcludeModels),
 		primaryRegion,
 		accountID,
 	)
@@ -113,13 +113,12 @@ func (s *BedrockServiceImpl) GetUsageForRegion(region string) (*entity.BedrockUs
 	}
 	s.cacheMutex.RUnlock()
 
-	// Get current date in JST
+	// Get the current JST day, up to the ingestion delay before now
 	jst, _ := time.LoadLocation("Asia/Tokyo")
-	now := time.Now().In(jst)
-	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, jst)
+	startOfDay, end := currentIngestionDay(time.Now().In(jst), s.config.IngestionDelay)
 
 	// Fetch usage from repository
-	usage, err := s.bedrockRepo.GetUsageMetrics(region, startOfDay, now)
+	usage, err := s.usageInWindow(region, startOfDay, end)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Bedrock usage for region %s: %w", region, err)
 	}
@@ -141,6 +140,43 @@ func (s *BedrockServiceImpl) GetDailyUsage(date time.Time) (*entity.BedrockUsage
 		return nil, domain.ErrBusinessRule("bedrock disabled", "Bedrock tracking is disabled in configuration")
 	}
 
+	// Collect daily usage from all configured regions. With an ingestion delay the day ends
+	// that long before now, so samples CloudWatch publishes late are counted once they arrive.
+	fetch := func(region string) (*entity.BedrockUsage, error) {
+		return s.bedrockRepo.GetDailyUsage(region, date)
+	}
+	if s.config.IngestionDelay > 0 {
+		start, end := ingestionDay(date, time.Now(), s.config.IngestionDelay)
+		fetch = func(region string) (*entity.BedrockUsage, error) {
+			return s.usageInWindow(region, start, end)
+		}
+	}
+	return s.aggregateRegions(fetch, "Failed to get Bedrock daily usage", domain.NewField("date", date.Format("2006-01-02")))
+}
+
+// usageInWindow fetches the usage of an ingestion window. A window that is still empty,
+// within the ingestion delay after midnight, has no usage and is not queried.
+func (s *BedrockServiceImpl) usageInWindow(region string, start, end time.Time) (*entity.BedrockUsage, error) {
+	if !end.After(start) {
+		return entity.NewBedrockUsage(0, 0, 0, []entity.BedrockModelMetric{}, region, "")
+	}
+	return s.bedrockRepo.GetUsageMetrics(region, start, end)
+}
+
+// GetUsageInRange retrieves aggregated usage between start and end across all configured regions
+func (s *BedrockServiceImpl) GetUsageInRange(start, end time.Time) (*entity.BedrockUsage, error) {
+	if !s.IsEnabled() {
+		return nil, domain.ErrBusinessRule("bedrock disabled", "Bedrock tracking is disabled in configuration")
+	}
+
+	return s.aggregateRegions(func(region string) (*entity.BedrockUsage, error) {
+		return s.bedrockRepo.GetUsageMetrics(region, start, end)
+	}, "Failed to get Bedrock usage", domain.NewField("start", start.Format(time.RFC3339)))
+}
+
+// aggregateRegions sums the usage fetched for each configured region. Regions that fail
+// are logged with failureMsg and skipped.
+func (s *BedrockServiceImpl) aggregateRegions(fetch func(region string) (*entity.BedrockUsage, error), failureMsg string, fields ...domain.Field) (*entity.BedrockUsage, error) {
 	var totalInputTokens int64
 	var totalOutputTokens int64
 	var totalCost float64
@@ -148,15 +184,13 @@ func (s *BedrockServiceImpl) GetDailyUsage(date time.Time) (*entity.BedrockUsage
 	var primaryRegion string
 	var accountID string
 
-	// Collect daily usage from all configured regions
 	for _, region := range s.config.Regions {
-		usage, err := s.bedrockRepo.GetDailyUsage(region, date)
+		usage, err := fetch(region)
 		if err != nil {
 			// Log error but continue with other regions
-			s.logger.Error(context.TODO(), "Failed to get Bedrock daily usage",
-				domain.NewField("region", region),
-				domain.NewField("date", date.Format("2006-01-02")),
-				domain.NewField("error", err.Error()))
+			logFields := append([]domain.Field{domain.NewField("region", region)}, fields...)
+			logFields = append(logFields, domain.NewField("error", err.Error()))
+			s.logger.Error(context.TODO(), failureMsg, logFields...)
 			continue
 		}
 
@@ -179,12 +213,12 @@ func (s *BedrockServiceImpl) GetDailyUsage(date time.Time) (*entity.BedrockUsage
 		accountID = "current-account"
 	}
 
-	// Create consolidated daily usage
+	// Create consolidated usage
 	return entity.NewBedrockUsage(
 		totalInputTokens,
 		totalOutputTokens,
 		totalCost,
-		allModelMetrics,
+		entity.FilterBedrockModelMetrics(allModelMetrics, s.config.IncludeModels, s.config.ExcludeModels),
 		primaryRegion,
 		accountID,
 	)
@@ -238,19 +272,19 @@ func (s *BedrockServiceImpl) GetCurrentMonthUsage() (*entity.BedrockUsage, error
 		totalInputTokens,
 		totalOutputTokens,
 		totalCost,
-		allModelMetrics,
+		entity.FilterBedrockModelMetrics(allModelMetrics, s.config.IncludeModels, s.config.ExcludeModels),
 		primaryRegion,
 		accountID,
 	)
 }
 
 // CheckConnection verifies AWS credentials and CloudWatch access
-func (s *BedrockServiceImpl) CheckConnection() error {
+func (s *BedrockServiceImpl) CheckConnection(ctx context.Context) error {
 	if !s.IsEnabled() {
 		return domain.ErrBusinessRule("bedrock disabled", "Bedrock tracking is disabled in configuration")
 	}
 
-	return s.bedrockRepo.CheckConnection()
+	return s.bedrockRepo.CheckConnection(ctx)
 }
 
 // GetConfiguredRegions returns the list of configured regions
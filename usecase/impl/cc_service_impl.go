@@ -6,6 +6,7 @@ import (
 
 	"github.com/ca-srg/tosage/domain/entity"
 	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/domain/valueobject"
 	usecase "github.com/ca-srg/tosage/usecase/interface"
 )
 
@@ -14,22 +15,71 @@ type CcServiceImpl struct {
 	ccRepo          repository.CcRepository
 	loadCcData      *LoadCcDataUseCase
 	timezoneService repository.TimezoneService
+	excludeModels   []string
+	entryFilter     *entity.CcEntryFilter
+	dailyWindow     valueobject.DailyWindowMode
+	totalComponents valueobject.TokenComponents
+}
+
+// CcServiceOption configures optional behavior of CcServiceImpl
+type CcServiceOption func(*CcServiceImpl)
+
+// WithExcludedModels drops entries whose model matches any of the patterns from all
+// totals and breakdowns. Patterns are globs (e.g. "claude-*-embed") or model name prefixes.
+func WithExcludedModels(patterns []string) CcServiceOption {
+	return func(s *CcServiceImpl) {
+		s.excludeModels = patterns
+		s.loadCcData.excludeModels = patterns
+	}
+}
+
+// WithEntryFilter keeps only the entries matching filter in all totals and breakdowns.
+// A nil filter keeps all entries.
+func WithEntryFilter(filter *entity.CcEntryFilter) CcServiceOption {
+	return func(s *CcServiceImpl) {
+		s.entryFilter = filter
+		s.loadCcData.entryFilter = filter
+	}
+}
+
+// WithCcDailyWindowMode sets the period CalculateTodayTokens covers: the calendar day
+// (the default) or the trailing 24 hours
+func WithCcDailyWindowMode(mode valueobject.DailyWindowMode) CcServiceOption {
+	return func(s *CcServiceImpl) {
+		s.dailyWindow = mode
+	}
+}
+
+// WithTotalTokenComponents sets the token components counted in the daily totals and in
+// the TotalTokens of loaded entries. Breakdowns keep reporting each component separately.
+func WithTotalTokenComponents(components valueobject.TokenComponents) CcServiceOption {
+	return func(s *CcServiceImpl) {
+		s.totalComponents = components
+		s.loadCcData.totalComponents = components
+	}
 }
 
 // NewCcServiceImpl creates a new instance of CcServiceImpl
 func NewCcServiceImpl(
 	ccRepo repository.CcRepository,
 	timezoneService repository.TimezoneService,
+	opts ...CcServiceOption,
 ) *CcServiceImpl {
-	return &CcServiceImpl{
+	s := &CcServiceImpl{
 		ccRepo:          ccRepo,
 		loadCcData:      NewLoadCcDataUseCase(ccRepo),
 		timezoneService: timezoneService,
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 // CalculateDailyTokens calculates total token count for a specific date
-func (s *CcServiceImpl) CalculateDailyTokens(date time.Time) (int, error) {
+func (s *CcServiceImpl) CalculateDailyTokens(date time.Time) (int64, error) {
 	// If timezone service is available, use timezone-aware method
 	if s.timezoneService != nil {
 		return s.CalculateDailyTokensInUserTimezone(date)
@@ -43,20 +93,43 @@ func (s *CcServiceImpl) CalculateDailyTokens(date time.Time) (int, error) {
 	if err != nil {
 		return 0, fmt.Errorf("failed to get entries for date: %w", err)
 	}
+	entries = entity.NewCcEntryCollection(entries).ExcludeModels(s.excludeModels).Filter(s.entryFilter).Entries()
 
-	// Calculate total tokens
-	totalTokens := 0
-	for _, entry := range entries {
-		tokens := entry.TotalTokens()
-		totalTokens += tokens
+	return s.sumTotalTokens(entries), nil
+}
+
+// CalculateTodayTokens calculates total token count for today, which is the trailing
+// 24 hours in rolling daily window mode
+func (s *CcServiceImpl) CalculateTodayTokens() (int64, error) {
+	if !s.dailyWindow.IsRolling() {
+		return s.CalculateDailyTokens(time.Now())
 	}
 
-	return totalTokens, nil
+	now := time.Now()
+	entries, err := s.ccRepo.FindByDateRange(valueobject.RollingWindowStart(now), now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get entries for the last 24 hours: %w", err)
+	}
+	entries = entity.NewCcEntryCollection(entries).ExcludeModels(s.excludeModels).Filter(s.entryFilter).Entries()
+
+	return s.sumTotalTokens(entries), nil
 }
 
-// CalculateTodayTokens calculates total token count for today
-func (s *CcServiceImpl) CalculateTodayTokens() (int, error) {
-	return s.CalculateDailyTokens(time.Now())
+// CalculateTodayAllTokens calculates today's token count over every token component,
+// regardless of the components selected with WithTotalTokenComponents
+func (s *CcServiceImpl) CalculateTodayAllTokens() (int64, error) {
+	all := *s
+	all.totalComponents = valueobject.TokenComponents{}
+	return all.CalculateTodayTokens()
+}
+
+// sumTotalTokens sums the selected token components of entries
+func (s *CcServiceImpl) sumTotalTokens(entries []*entity.CcEntry) int64 {
+	var totalTokens int64
+	for _, entry := range entries {
+		totalTokens += int64(s.totalComponents.Total(entry.TokenStats()))
+	}
+	return totalTokens
 }
 
 // CalculateTokenStats calculates aggregated token statistics
@@ -74,19 +147,19 @@ func (s *CcServiceImpl) CalculateTokenStats(filter usecase.TokenStatsFilter) (*u
 	}
 
 	// Calculate stats without cost
-	inputTokens := 0
-	outputTokens := 0
-	cacheCreationTokens := 0
-	cacheReadTokens := 0
-	totalTokens := 0
+	var inputTokens int64
+	var outputTokens int64
+	var cacheCreationTokens int64
+	var cacheReadTokens int64
+	var totalTokens int64
 
 	for _, entry := range entries {
 		stats := entry.TokenStats()
-		inputTokens += stats.InputTokens()
-		outputTokens += stats.OutputTokens()
-		cacheCreationTokens += stats.CacheCreationTokens()
-		cacheReadTokens += stats.CacheReadTokens()
-		totalTokens += stats.TotalTokens()
+		inputTokens += int64(stats.InputTokens())
+		outputTokens += int64(stats.OutputTokens())
+		cacheCreationTokens += int64(stats.CacheCreationTokens())
+		cacheReadTokens += int64(stats.CacheReadTokens())
+		totalTokens += int64(stats.TotalTokens())
 	}
 
 	// Get date range
@@ -148,11 +221,11 @@ func (s *CcServiceImpl) CalculateModelBreakdown(filter usecase.ModelBreakdownFil
 
 	// Group by model
 	modelStats := make(map[string]*struct {
-		inputTokens         int
-		outputTokens        int
-		cacheCreationTokens int
-		cacheReadTokens     int
-		totalTokens         int
+		inputTokens         int64
+		outputTokens        int64
+		cacheCreationTokens int64
+		cacheReadTokens     int64
+		totalTokens         int64
 		entryCount          int
 	})
 
@@ -160,30 +233,30 @@ func (s *CcServiceImpl) CalculateModelBreakdown(filter usecase.ModelBreakdownFil
 		model := entry.Model()
 		if _, exists := modelStats[model]; !exists {
 			modelStats[model] = &struct {
-				inputTokens         int
-				outputTokens        int
-				cacheCreationTokens int
-				cacheReadTokens     int
-				totalTokens         int
+				inputTokens         int64
+				outputTokens        int64
+				cacheCreationTokens int64
+				cacheReadTokens     int64
+				totalTokens         int64
 				entryCount          int
 			}{}
 		}
 
 		stats := entry.TokenStats()
-		modelStats[model].inputTokens += stats.InputTokens()
-		modelStats[model].outputTokens += stats.OutputTokens()
-		modelStats[model].cacheCreationTokens += stats.CacheCreationTokens()
-		modelStats[model].cacheReadTokens += stats.CacheReadTokens()
-		modelStats[model].totalTokens += stats.TotalTokens()
+		modelStats[model].inputTokens += int64(stats.InputTokens())
+		modelStats[model].outputTokens += int64(stats.OutputTokens())
+		modelStats[model].cacheCreationTokens += int64(stats.CacheCreationTokens())
+		modelStats[model].cacheReadTokens += int64(stats.CacheReadTokens())
+		modelStats[model].totalTokens += int64(stats.TotalTokens())
 		modelStats[model].entryCount++
 	}
 
 	// Calculate totals
-	totalInputTokens := 0
-	totalOutputTokens := 0
-	totalCacheCreationTokens := 0
-	totalCacheReadTokens := 0
-	totalTokens := 0
+	var totalInputTokens int64
+	var totalOutputTokens int64
+	var totalCacheCreationTokens int64
+	var totalCacheReadTokens int64
+	var totalTokens int64
 
 	for _, stats := range modelStats {
 		totalInputTokens += stats.inputTokens
@@ -239,45 +312,53 @@ func (s *CcServiceImpl) CalculateDateBreakdown(filter usecase.DateBreakdownFilte
 	if err != nil {
 		return nil, fmt.Errorf("failed to get filtered entries: %w", err)
 	}
+	entries = entity.NewCcEntryCollection(entries).Filter(filter.EntryFilter).Entries()
 
-	// Group by date
+	// Group by date, or by hour, in the configured timezone
+	loc := s.configuredLocation()
 	dateStats := make(map[string]*struct {
-		inputTokens         int
-		outputTokens        int
-		cacheCreationTokens int
-		cacheReadTokens     int
-		totalTokens         int
+		start               time.Time
+		inputTokens         int64
+		outputTokens        int64
+		cacheCreationTokens int64
+		cacheReadTokens     int64
+		totalTokens         int64
 		entryCount          int
 	})
 
 	for _, entry := range entries {
-		date := entry.Timestamp().Format("2006-01-02")
+		timestamp := entry.Timestamp()
+		if loc != nil {
+			timestamp = timestamp.In(loc)
+		}
+		start, date := breakdownBucket(timestamp, filter.Hourly)
 		if _, exists := dateStats[date]; !exists {
 			dateStats[date] = &struct {
-				inputTokens         int
-				outputTokens        int
-				cacheCreationTokens int
-				cacheReadTokens     int
-				totalTokens         int
+				start               time.Time
+				inputTokens         int64
+				outputTokens        int64
+				cacheCreationTokens int64
+				cacheReadTokens     int64
+				totalTokens         int64
 				entryCount          int
-			}{}
+			}{start: start}
 		}
 
 		stats := entry.TokenStats()
-		dateStats[date].inputTokens += stats.InputTokens()
-		dateStats[date].outputTokens += stats.OutputTokens()
-		dateStats[date].cacheCreationTokens += stats.CacheCreationTokens()
-		dateStats[date].cacheReadTokens += stats.CacheReadTokens()
-		dateStats[date].totalTokens += stats.TotalTokens()
+		dateStats[date].inputTokens += int64(stats.InputTokens())
+		dateStats[date].outputTokens += int64(stats.OutputTokens())
+		dateStats[date].cacheCreationTokens += int64(stats.CacheCreationTokens())
+		dateStats[date].cacheReadTokens += int64(stats.CacheReadTokens())
+		dateStats[date].totalTokens += int64(stats.TotalTokens())
 		dateStats[date].entryCount++
 	}
 
 	// Calculate totals
-	totalInputTokens := 0
-	totalOutputTokens := 0
-	totalCacheCreationTokens := 0
-	totalCacheReadTokens := 0
-	totalTokens := 0
+	var totalInputTokens int64
+	var totalOutputTokens int64
+	var totalCacheCreationTokens int64
+	var totalCacheReadTokens int64
+	var totalTokens int64
 
 	for _, stats := range dateStats {
 		totalInputTokens += stats.inputTokens
@@ -305,6 +386,7 @@ func (s *CcServiceImpl) CalculateDateBreakdown(filter usecase.DateBreakdownFilte
 	for date, stats := range dateStats {
 		result.Dates = append(result.Dates, usecase.DateBreakdownItem{
 			Date:                date,
+			Start:               stats.start,
 			InputTokens:         stats.inputTokens,
 			OutputTokens:        stats.outputTokens,
 			CacheCreationTokens: stats.cacheCreationTokens,
@@ -319,6 +401,18 @@ func (s *CcServiceImpl) CalculateDateBreakdown(filter usecase.DateBreakdownFilte
 	return result, nil
 }
 
+// breakdownBucket returns the start and key of the date, or hour, that t falls in within
+// its location. The hour is taken from the wall clock so that zones with half-hour offsets
+// are bucketed on their own hours.
+func breakdownBucket(t time.Time, hourly bool) (time.Time, string) {
+	if hourly {
+		start := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+		return start, start.Format("2006-01-02T15:00")
+	}
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return start, start.Format("2006-01-02")
+}
+
 // LoadCcData loads usage data with optional filters
 func (s *CcServiceImpl) LoadCcData(filter usecase.CcDataFilter) (*usecase.CcDataResult, error) {
 	return s.loadCcData.Execute(filter)
@@ -339,19 +433,19 @@ func (s *CcServiceImpl) GetCcSummary(filter usecase.CcSummaryFilter) (*usecase.C
 	}
 
 	// Calculate total stats without cost
-	inputTokens := 0
-	outputTokens := 0
-	cacheCreationTokens := 0
-	cacheReadTokens := 0
-	totalTokens := 0
+	var inputTokens int64
+	var outputTokens int64
+	var cacheCreationTokens int64
+	var cacheReadTokens int64
+	var totalTokens int64
 
 	for _, entry := range entries {
 		stats := entry.TokenStats()
-		inputTokens += stats.InputTokens()
-		outputTokens += stats.OutputTokens()
-		cacheCreationTokens += stats.CacheCreationTokens()
-		cacheReadTokens += stats.CacheReadTokens()
-		totalTokens += stats.TotalTokens()
+		inputTokens += int64(stats.InputTokens())
+		outputTokens += int64(stats.OutputTokens())
+		cacheCreationTokens += int64(stats.CacheCreationTokens())
+		cacheReadTokens += int64(stats.CacheReadTokens())
+		totalTokens += int64(stats.TotalTokens())
 	}
 
 	// Get unique counts
@@ -407,10 +501,10 @@ func (s *CcServiceImpl) GetCcSummary(filter usecase.CcSummaryFilter) (*usecase.C
 	}
 
 	// Calculate averages
-	avgDailyTokens := 0
+	var avgDailyTokens int64
 	avgDailyCost := 0.0
 	if dateRange.Days > 0 {
-		avgDailyTokens = totalTokens / dateRange.Days
+		avgDailyTokens = totalTokens / int64(dateRange.Days)
 		avgDailyCost = 0
 	}
 
@@ -493,7 +587,7 @@ func (s *CcServiceImpl) getFilteredEntries(
 	}
 
 	// Apply additional filters
-	collection := entity.NewCcEntryCollection(entries)
+	collection := entity.NewCcEntryCollection(entries).ExcludeModels(s.excludeModels).Filter(s.entryFilter)
 
 	if model != "" {
 		collection = collection.FilterByModel(model)
@@ -508,8 +602,21 @@ func (s *CcServiceImpl) getFilteredEntries(
 
 // Timezone-aware methods
 
+// configuredLocation returns the user's configured timezone, or nil when there is no
+// timezone service, in which case entries keep the location of their timestamps
+func (s *CcServiceImpl) configuredLocation() *time.Location {
+	if s.timezoneService == nil {
+		return nil
+	}
+	loc, err := s.timezoneService.GetConfiguredTimezone()
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
 // CalculateDailyTokensInUserTimezone calculates total token count for a specific date in user's timezone
-func (s *CcServiceImpl) CalculateDailyTokensInUserTimezone(date time.Time) (int, error) {
+func (s *CcServiceImpl) CalculateDailyTokensInUserTimezone(date time.Time) (int64, error) {
 	if s.timezoneService == nil {
 		// Fall back to existing method if timezone service not available
 		return s.CalculateDailyTokens(date)
@@ -531,19 +638,14 @@ func (s *CcServiceImpl) CalculateDailyTokensInUserTimezone(date time.Time) (int,
 	}
 
 	// Create collection with timezone context
+	entries = entity.NewCcEntryCollection(entries).ExcludeModels(s.excludeModels).Filter(s.entryFilter).Entries()
 	collection := entity.NewCcEntryCollectionWithTimezone(entries, userTimezone)
 
-	// Calculate total tokens
-	totalTokens := 0
-	for _, entry := range collection.Entries() {
-		totalTokens += entry.TotalTokens()
-	}
-
-	return totalTokens, nil
+	return s.sumTotalTokens(collection.Entries()), nil
 }
 
 // CalculateTodayTokensInUserTimezone calculates total token count for today in user's timezone
-func (s *CcServiceImpl) CalculateTodayTokensInUserTimezone() (int, error) {
+func (s *CcServiceImpl) CalculateTodayTokensInUserTimezone() (int64, error) {
 	if s.timezoneService == nil {
 		// Fall back to existing method if timezone service not available
 		return s.CalculateTodayTokens()
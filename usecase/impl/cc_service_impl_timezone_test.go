@@ -7,6 +7,7 @@ import (
 	"github.com/ca-srg/tosage/domain/entity"
 	"github.com/ca-srg/tosage/domain/repository"
 	"github.com/ca-srg/tosage/domain/valueobject"
+	usecase "github.com/ca-srg/tosage/usecase/interface"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -239,10 +240,46 @@ func TestCcServiceImpl_CalculateDailyTokensInUserTimezone(t *testing.T) {
 
 	// Assert
 	require.NoError(t, err)
-	assert.Equal(t, 750, totalTokens) // 375 + 375
+	assert.Equal(t, int64(750), totalTokens) // 375 + 375
 	mockRepo.AssertExpectations(t)
 }
 
+func TestCcServiceImpl_TotalTokenComponents(t *testing.T) {
+	mockRepo := new(MockCcRepository)
+	mockTimezoneService := &MockTimezoneService{Location: time.UTC}
+	components, err := valueobject.ParseTokenComponents([]string{"input", "output", "cache_creation"})
+	require.NoError(t, err)
+
+	service := NewCcServiceImpl(mockRepo, mockTimezoneService, WithTotalTokenComponents(components))
+
+	entry, _ := entity.NewCcEntry(
+		"id1",
+		time.Now(),
+		"session1",
+		"/project1",
+		"claude-sonnet-4",
+		valueobject.NewTokenStats(100, 200, 50, 1000),
+		"1.0",
+		"msg1",
+		"req1",
+	)
+	mockRepo.On("FindByDateRange", mock.Anything, mock.Anything).Return([]*entity.CcEntry{entry}, nil)
+
+	// Cache reads are left out of the total
+	totalTokens, err := service.CalculateTodayTokens()
+	require.NoError(t, err)
+	assert.Equal(t, int64(350), totalTokens)
+
+	allTokens, err := service.CalculateTodayAllTokens()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1350), allTokens)
+
+	// The selection doesn't leak into later calls
+	totalTokens, err = service.CalculateTodayTokens()
+	require.NoError(t, err)
+	assert.Equal(t, int64(350), totalTokens)
+}
+
 func TestCcServiceImpl_CalculateTodayTokensInUserTimezone(t *testing.T) {
 	// Setup
 	mockRepo := new(MockCcRepository)
@@ -287,7 +324,7 @@ func TestCcServiceImpl_CalculateTodayTokensInUserTimezone(t *testing.T) {
 
 	// Assert
 	require.NoError(t, err)
-	assert.Equal(t, 375, totalTokens)
+	assert.Equal(t, int64(375), totalTokens)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -366,7 +403,7 @@ func TestCcServiceImpl_TimezoneServiceFallback(t *testing.T) {
 	t.Run("CalculateDailyTokensInUserTimezone falls back", func(t *testing.T) {
 		totalTokens, err := service.CalculateDailyTokensInUserTimezone(date)
 		require.NoError(t, err)
-		assert.Equal(t, 375, totalTokens)
+		assert.Equal(t, int64(375), totalTokens)
 	})
 
 	t.Run("GetDateRangeInUserTimezone returns as-is", func(t *testing.T) {
@@ -382,3 +419,113 @@ func TestCcServiceImpl_TimezoneServiceFallback(t *testing.T) {
 
 	mockRepo.AssertExpectations(t)
 }
+
+func TestCcServiceImpl_CalculateDateBreakdown(t *testing.T) {
+	jst := time.FixedZone("JST", 9*3600)
+	pst := time.FixedZone("PST", -8*3600)
+	// 2024-01-15 20:00 UTC is already 2024-01-16 in JST
+	late, _ := entity.NewCcEntry("id1", time.Date(2024, 1, 15, 20, 0, 0, 0, time.UTC), "session1", "/project1",
+		"claude-sonnet-4", valueobject.NewTokenStats(100, 200, 0, 0), "1.0", "msg1", "req1")
+	early, _ := entity.NewCcEntry("id2", time.Date(2024, 1, 15, 2, 0, 0, 0, time.UTC), "session1", "/project1",
+		"claude-sonnet-4", valueobject.NewTokenStats(10, 20, 0, 0), "1.0", "msg2", "req2")
+
+	tests := []struct {
+		name            string
+		timezoneService repository.TimezoneService
+		want            map[string]int64
+	}{
+		{
+			name:            "configured timezone",
+			timezoneService: &MockTimezoneService{Location: jst},
+			want:            map[string]int64{"2024-01-15": 30, "2024-01-16": 300},
+		},
+		{
+			// The range is given in PST, but the dates still follow the configured timezone
+			name:            "range in another timezone",
+			timezoneService: &MockTimezoneService{Location: time.UTC},
+			want:            map[string]int64{"2024-01-15": 330},
+		},
+		{
+			name: "no timezone service keeps the timestamps' location",
+			want: map[string]int64{"2024-01-15": 330},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockCcRepository)
+			mockRepo.On("FindByDateRange", mock.Anything, mock.Anything).Return([]*entity.CcEntry{late, early}, nil)
+			service := NewCcServiceImpl(mockRepo, tt.timezoneService)
+
+			start := time.Date(2024, 1, 14, 0, 0, 0, 0, pst)
+			end := time.Date(2024, 1, 17, 0, 0, 0, 0, pst)
+			result, err := service.CalculateDateBreakdown(usecase.DateBreakdownFilter{StartDate: &start, EndDate: &end})
+			require.NoError(t, err)
+
+			got := make(map[string]int64, len(result.Dates))
+			for _, item := range result.Dates {
+				got[item.Date] = item.TotalTokens
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, int64(330), result.Total.TotalTokens)
+		})
+	}
+}
+
+func TestCcServiceImpl_CalculateDateBreakdownHourly(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	// 03:20 and 03:40 UTC are 08:50 and 09:10 in IST, on both sides of an IST hour
+	first, _ := entity.NewCcEntry("id1", time.Date(2024, 1, 15, 3, 20, 0, 0, time.UTC), "session1", "/project1",
+		"claude-sonnet-4", valueobject.NewTokenStats(100, 200, 0, 0), "1.0", "msg1", "req1")
+	second, _ := entity.NewCcEntry("id2", time.Date(2024, 1, 15, 3, 40, 0, 0, time.UTC), "session1", "/project1",
+		"claude-sonnet-4", valueobject.NewTokenStats(10, 20, 0, 0), "1.0", "msg2", "req2")
+
+	mockRepo := new(MockCcRepository)
+	mockRepo.On("FindByDateRange", mock.Anything, mock.Anything).Return([]*entity.CcEntry{first, second}, nil)
+	service := NewCcServiceImpl(mockRepo, &MockTimezoneService{Location: ist})
+
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)
+	result, err := service.CalculateDateBreakdown(usecase.DateBreakdownFilter{StartDate: &start, EndDate: &end, Hourly: true})
+	require.NoError(t, err)
+
+	got := make(map[string]int64, len(result.Dates))
+	for _, item := range result.Dates {
+		got[item.Date] = item.TotalTokens
+		assert.Equal(t, ist, item.Start.Location())
+		assert.Equal(t, item.Date, item.Start.Format("2006-01-02T15:00"))
+	}
+	assert.Equal(t, map[string]int64{"2024-01-15T08:00": 300, "2024-01-15T09:00": 30}, got)
+}
+
+func TestCcServiceImpl_CalculateTodayTokensRollingWindow(t *testing.T) {
+	mockRepo := new(MockCcRepository)
+	service := NewCcServiceImpl(mockRepo, &MockTimezoneService{Location: time.UTC},
+		WithCcDailyWindowMode(valueobject.DailyWindowRolling24h))
+
+	entry, _ := entity.NewCcEntry(
+		"id1",
+		time.Now().Add(-20*time.Hour),
+		"session1",
+		"/project1",
+		"claude-sonnet-4",
+		valueobject.NewTokenStats(100, 200, 50, 25),
+		"1.0",
+		"msg1",
+		"req1",
+	)
+
+	// The window is the trailing 24 hours, not the calendar day
+	mockRepo.On("FindByDateRange",
+		mock.MatchedBy(func(start time.Time) bool {
+			return time.Since(start) > 23*time.Hour && time.Since(start) < 25*time.Hour
+		}),
+		mock.MatchedBy(func(end time.Time) bool { return time.Since(end) < time.Minute }),
+	).Return([]*entity.CcEntry{entry}, nil)
+
+	totalTokens, err := service.CalculateTodayTokens()
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(375), totalTokens)
+	mockRepo.AssertExpectations(t)
+}
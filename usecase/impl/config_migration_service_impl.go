@@ -112,8 +112,11 @@ func (s *ConfigMigrationServiceImpl) validateMigratedConfig(cfg *config.AppConfi
 
 	// Prometheusの設定が正しく移行されているか確認
 	if cfg.Prometheus != nil && cfg.Prometheus.RemoteWriteURL != "" {
-		// RemoteWriteURLが設定されている場合、認証情報も必要
-		if cfg.Prometheus.RemoteWriteUsername == "" || cfg.Prometheus.RemoteWritePassword == "" {
+		// RemoteWriteURLが設定されている場合、認証情報も必要（クライアント証明書またはトークンがある場合は不要）
+		hasClientCert := cfg.Prometheus.ClientCertPath != "" && cfg.Prometheus.ClientKeyPath != ""
+		hasToken := cfg.Prometheus.RemoteWriteTokenFile != ""
+		hasPassword := cfg.Prometheus.RemoteWritePassword != "" || cfg.Prometheus.RemoteWritePasswordFile != ""
+		if !hasClientCert && !hasToken && (cfg.Prometheus.RemoteWriteUsername == "" || !hasPassword) {
 			return fmt.Errorf("remote write authentication is required when remote write URL is set")
 		}
 	}
@@ -125,9 +128,29 @@ func (s *ConfigMigrationServiceImpl) validateMigratedConfig(cfg *config.AppConfi
 func (s *ConfigMigrationServiceImpl) copyConfig(src *config.AppConfig) *config.AppConfig {
 	// 新しいAppConfigインスタンスを作成
 	dst := &config.AppConfig{
-		Version:       src.Version,
-		ClaudePath:    src.ClaudePath,
-		ConfigSources: make(config.ConfigSourceMap),
+		Version:               src.Version,
+		ClaudePath:            src.ClaudePath,
+		RemoteSources:         src.RemoteSources,
+		HashProjectPaths:      src.HashProjectPaths,
+		ExcludeModels:         append([]string{}, src.ExcludeModels...),
+		TokenComponents:       append([]string{}, src.TokenComponents...),
+		Profiles:              append([]*config.ProfileConfig{}, src.Profiles...),
+		HeuristicDedup:        src.HeuristicDedup,
+		UserAgent:             src.UserAgent,
+		IgnoreBeforeDate:      src.IgnoreBeforeDate,
+		ParseWorkers:          src.ParseWorkers,
+		WalkTimeoutSec:        src.WalkTimeoutSec,
+		ListingCacheSec:       src.ListingCacheSec,
+		MaxDataStalenessSec:   src.MaxDataStalenessSec,
+		DailyWindowMode:       src.DailyWindowMode,
+		MaxEntryTokens:        src.MaxEntryTokens,
+		MaxEntryTokensAction:  src.MaxEntryTokensAction,
+		MaxConcurrentRequests: src.MaxConcurrentRequests,
+		MaxLineBytes:          src.MaxLineBytes,
+		CostPrecision:         src.CostPrecision,
+
+		FailFastOnProviderInitError: src.FailFastOnProviderInitError,
+		ConfigSources:               make(config.ConfigSourceMap),
 	}
 
 	// ConfigSourcesをコピー
@@ -138,24 +161,67 @@ func (s *ConfigMigrationServiceImpl) copyConfig(src *config.AppConfig) *config.A
 	// Prometheus設定をコピー
 	if src.Prometheus != nil {
 		dst.Prometheus = &config.PrometheusConfig{
-			RemoteWriteURL:      src.Prometheus.RemoteWriteURL,
-			RemoteWriteUsername: src.Prometheus.RemoteWriteUsername,
-			RemoteWritePassword: src.Prometheus.RemoteWritePassword,
-			URL:                 src.Prometheus.URL,
-			Username:            src.Prometheus.Username,
-			Password:            src.Prometheus.Password,
-			HostLabel:           src.Prometheus.HostLabel,
-			IntervalSec:         src.Prometheus.IntervalSec,
-			TimeoutSec:          src.Prometheus.TimeoutSec,
+			RemoteWriteURL:           src.Prometheus.RemoteWriteURL,
+			RemoteWriteUsername:      src.Prometheus.RemoteWriteUsername,
+			RemoteWritePassword:      src.Prometheus.RemoteWritePassword,
+			URL:                      src.Prometheus.URL,
+			Username:                 src.Prometheus.Username,
+			Password:                 src.Prometheus.Password,
+			HostLabel:                src.Prometheus.HostLabel,
+			IntervalSec:              src.Prometheus.IntervalSec,
+			TimeoutSec:               src.Prometheus.TimeoutSec,
+			ScrapeListenAddress:      src.Prometheus.ScrapeListenAddress,
+			StateFilePath:            src.Prometheus.StateFilePath,
+			ProbeOnStartup:           src.Prometheus.ProbeOnStartup,
+			Compression:              src.Prometheus.Compression,
+			Transforms:               src.Prometheus.Transforms,
+			Backends:                 src.Prometheus.Backends,
+			ClientCertPath:           src.Prometheus.ClientCertPath,
+			ClientKeyPath:            src.Prometheus.ClientKeyPath,
+			AlignToInterval:          src.Prometheus.AlignToInterval,
+			CircuitBreakerThreshold:  src.Prometheus.CircuitBreakerThreshold,
+			CircuitBreakerBackoffSec: src.Prometheus.CircuitBreakerBackoffSec,
+			SessionMetricsTopN:       src.Prometheus.SessionMetricsTopN,
+			HashSessionIDs:           src.Prometheus.HashSessionIDs,
+			InitialDelaySec:          src.Prometheus.InitialDelaySec,
+			NetworkWaitSec:           src.Prometheus.NetworkWaitSec,
+			SourceIntervalSec:        src.Prometheus.SourceIntervalSec,
+			CcTokensDelta:            src.Prometheus.CcTokensDelta,
+			MetricAllowlist:          append([]string{}, src.Prometheus.MetricAllowlist...),
+			MetricDenylist:           append([]string{}, src.Prometheus.MetricDenylist...),
+			SourcePathLabel:          src.Prometheus.SourcePathLabel,
+			HashSourcePaths:          src.Prometheus.HashSourcePaths,
+			SessionPercentiles:       src.Prometheus.SessionPercentiles,
+			RemoteWritePasswordFile:  src.Prometheus.RemoteWritePasswordFile,
+			RemoteWriteTokenFile:     src.Prometheus.RemoteWriteTokenFile,
+			Schedule:                 src.Prometheus.Schedule,
+			UniqueCountMetrics:       src.Prometheus.UniqueCountMetrics,
+			CloudMetadataLabels:      src.Prometheus.CloudMetadataLabels,
+			ScrapeRefreshAfterSec:    src.Prometheus.ScrapeRefreshAfterSec,
 		}
 	}
 
 	// Cursor設定をコピー
 	if src.Cursor != nil {
 		dst.Cursor = &config.CursorConfig{
-			DatabasePath: src.Cursor.DatabasePath,
-			APITimeout:   src.Cursor.APITimeout,
-			CacheTimeout: src.Cursor.CacheTimeout,
+			DatabasePath:            src.Cursor.DatabasePath,
+			APITimeout:              src.Cursor.APITimeout,
+			CacheTimeout:            src.Cursor.CacheTimeout,
+			BillingDay:              src.Cursor.BillingDay,
+			DayStartHour:            src.Cursor.DayStartHour,
+			HostLabel:               src.Cursor.HostLabel,
+			PremiumRequestMetrics:   src.Cursor.PremiumRequestMetrics,
+			BaseURL:                 src.Cursor.BaseURL,
+			TeamMemberMetrics:       src.Cursor.TeamMemberMetrics,
+			TeamMembers:             append([]string{}, src.Cursor.TeamMembers...),
+			TeamMemberLimit:         src.Cursor.TeamMemberLimit,
+			UsageCostMetrics:        src.Cursor.UsageCostMetrics,
+			CallCountMetrics:        src.Cursor.CallCountMetrics,
+			RateLimit:               src.Cursor.RateLimit,
+			RateLimitBurst:          src.Cursor.RateLimitBurst,
+			UsageBasedStatusMetrics: src.Cursor.UsageBasedStatusMetrics,
+			BillingCycleMetric:      src.Cursor.BillingCycleMetric,
+			HashTeamMembers:         src.Cursor.HashTeamMembers,
 		}
 	}
 
@@ -167,27 +233,48 @@ func (s *ConfigMigrationServiceImpl) copyConfig(src *config.AppConfig) *config.A
 			AWSProfile:            src.Bedrock.AWSProfile,
 			AssumeRoleARN:         src.Bedrock.AssumeRoleARN,
 			CollectionIntervalSec: src.Bedrock.CollectionIntervalSec,
+			Namespace:             src.Bedrock.Namespace,
+			InputTokenMetric:      src.Bedrock.InputTokenMetric,
+			OutputTokenMetric:     src.Bedrock.OutputTokenMetric,
+			InvocationsMetric:     src.Bedrock.InvocationsMetric,
+			LatencyMetric:         src.Bedrock.LatencyMetric,
+			ModelDimension:        src.Bedrock.ModelDimension,
+			IncludeModels:         append([]string{}, src.Bedrock.IncludeModels...),
+			ExcludeModels:         append([]string{}, src.Bedrock.ExcludeModels...),
+			HostLabel:             src.Bedrock.HostLabel,
+			AllowUnknownRegions:   src.Bedrock.AllowUnknownRegions,
+			MetricBatchSize:       src.Bedrock.MetricBatchSize,
+			IngestionDelaySec:     src.Bedrock.IngestionDelaySec,
 		}
 	}
 
 	// VertexAI設定をコピー
 	if src.VertexAI != nil {
 		dst.VertexAI = &config.VertexAIConfig{
-			Enabled:               src.VertexAI.Enabled,
-			ProjectID:             src.VertexAI.ProjectID,
-			ServiceAccountKeyPath: src.VertexAI.ServiceAccountKeyPath,
-			CollectionIntervalSec: src.VertexAI.CollectionIntervalSec,
+			Enabled:                src.VertexAI.Enabled,
+			ProjectID:              src.VertexAI.ProjectID,
+			ServiceAccountKeyPath:  src.VertexAI.ServiceAccountKeyPath,
+			CollectionIntervalSec:  src.VertexAI.CollectionIntervalSec,
+			HostLabel:              src.VertexAI.HostLabel,
+			ServiceAccountKeys:     append([]string{}, src.VertexAI.ServiceAccountKeys...),
+			RequestMetrics:         src.VertexAI.RequestMetrics,
+			RequestMetricsPerModel: src.VertexAI.RequestMetricsPerModel,
+			IngestionDelaySec:      src.VertexAI.IngestionDelaySec,
 		}
 	}
 
 	// Daemon設定をコピー
 	if src.Daemon != nil {
 		dst.Daemon = &config.DaemonConfig{
-			Enabled:      src.Daemon.Enabled,
-			StartAtLogin: src.Daemon.StartAtLogin,
-			HideFromDock: src.Daemon.HideFromDock,
-			LogPath:      src.Daemon.LogPath,
-			PidFile:      src.Daemon.PidFile,
+			Enabled:       src.Daemon.Enabled,
+			StartAtLogin:  src.Daemon.StartAtLogin,
+			HideFromDock:  src.Daemon.HideFromDock,
+			LogPath:       src.Daemon.LogPath,
+			PidFile:       src.Daemon.PidFile,
+			LogMaxSizeMB:  src.Daemon.LogMaxSizeMB,
+			LogMaxBackups: src.Daemon.LogMaxBackups,
+			LogMaxAgeDays: src.Daemon.LogMaxAgeDays,
+			LogCompress:   src.Daemon.LogCompress,
 		}
 	}
 
@@ -205,6 +292,11 @@ func (s *ConfigMigrationServiceImpl) copyConfig(src *config.AppConfig) *config.A
 				BatchWaitSeconds: src.Logging.Promtail.BatchWaitSeconds,
 				BatchCapacity:    src.Logging.Promtail.BatchCapacity,
 				TimeoutSeconds:   src.Logging.Promtail.TimeoutSeconds,
+				ClientCertPath:   src.Logging.Promtail.ClientCertPath,
+				ClientKeyPath:    src.Logging.Promtail.ClientKeyPath,
+				Compress:         src.Logging.Promtail.Compress,
+				PasswordFile:     src.Logging.Promtail.PasswordFile,
+				TokenFile:        src.Logging.Promtail.TokenFile,
 			}
 		}
 	}
@@ -217,6 +309,26 @@ func (s *ConfigMigrationServiceImpl) copyConfig(src *config.AppConfig) *config.A
 			DefaultMetricTypes: src.CSVExport.DefaultMetricTypes,
 			MaxExportDays:      src.CSVExport.MaxExportDays,
 			TimeZone:           src.CSVExport.TimeZone,
+			HashProjectPaths:   src.CSVExport.HashProjectPaths,
+		}
+	}
+
+	// Summary設定をコピー
+	if src.Summary != nil {
+		dst.Summary = &config.SummaryConfig{
+			Enabled:    src.Summary.Enabled,
+			WebhookURL: src.Summary.WebhookURL,
+			SendTime:   src.Summary.SendTime,
+			TopModels:  src.Summary.TopModels,
+			MaxRetries: src.Summary.MaxRetries,
+		}
+	}
+
+	// PostCollectionHook設定をコピー
+	if src.PostCollectionHook != nil {
+		dst.PostCollectionHook = &config.PostCollectionHookConfig{
+			Command:    src.PostCollectionHook.Command,
+			TimeoutSec: src.PostCollectionHook.TimeoutSec,
 		}
 	}
 
@@ -109,6 +109,18 @@ func loadConfigWithFallback(configRepo repository.ConfigRepository, logger domai
 			domain.NewField("config_path", configRepo.GetConfigPath()))
 	}
 
+	// Populate the environment from .env; real environment variables take precedence
+	envFile := config.EnvFilePath()
+	if loaded, err := config.LoadDotEnv(envFile); err != nil {
+		logger.Warn(ctx, "Failed to load env file",
+			domain.NewField("path", envFile),
+			domain.NewField("error", err.Error()))
+	} else if len(loaded) > 0 {
+		logger.Info(ctx, "Loaded variables from env file",
+			domain.NewField("path", envFile),
+			domain.NewField("keys", loaded))
+	}
+
 	// Load environment variables (they override JSON values)
 	if err := cfg.LoadFromEnv(); err != nil {
 		// 環境変数のエラーは無視してデフォルト値で継続
@@ -236,7 +248,7 @@ func (s *ConfigServiceImpl) CreateDefaultConfig() error {
 	return nil
 }
 
-// ExportConfig は現在の設定をエクスポート用に整形する（パスワードなどをマスク）
+// ExportConfig は現在の設定をエクスポート用に整形する（secret:"true" タグのフィールドはマスク）
 func (s *ConfigServiceImpl) ExportConfig() map[string]interface{} {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -246,6 +258,41 @@ func (s *ConfigServiceImpl) ExportConfig() map[string]interface{} {
 
 	// 基本設定
 	exportMap["claude_path"] = s.config.ClaudePath
+	remoteSources := make([]map[string]interface{}, 0, len(s.config.RemoteSources))
+	for i := range s.config.RemoteSources {
+		source := &s.config.RemoteSources[i]
+		remoteSources = append(remoteSources, map[string]interface{}{
+			"name":                  source.DisplayName(),
+			"host":                  source.Host,
+			"user":                  source.User,
+			"key_path":              source.KeyPath,
+			"known_hosts_path":      source.KnownHostsPath,
+			"remote_path":           source.ClaudePath(),
+			"sync_interval_seconds": int(source.SyncInterval().Seconds()),
+			"timeout_seconds":       int(source.Timeout().Seconds()),
+		})
+	}
+	exportMap["remote_sources"] = remoteSources
+	exportMap["hash_project_paths"] = s.config.HashProjectPaths
+	exportMap["exclude_models"] = s.config.ExcludeModels
+	exportMap["token_components"] = s.config.TokenComponents
+	exportMap["heuristic_dedup"] = s.config.HeuristicDedup
+	exportMap["user_agent"] = s.config.UserAgent
+	exportMap["ignore_before_date"] = s.config.IgnoreBeforeDate
+	exportMap["parse_workers"] = s.config.ParseWorkers
+	exportMap["walk_timeout_seconds"] = s.config.WalkTimeoutSec
+	exportMap["listing_cache_seconds"] = s.config.ListingCacheSec
+	exportMap["max_data_staleness_seconds"] = s.config.MaxDataStalenessSec
+	exportMap["daily_window_mode"] = s.config.DailyWindowMode
+	config.MaskSecrets(s.config, exportMap)
+	exportMap["max_entry_tokens"] = s.config.MaxEntryTokens
+	exportMap["max_entry_tokens_action"] = s.config.MaxEntryTokensAction
+	exportMap["max_concurrent_requests"] = s.config.MaxConcurrentRequests
+	exportMap["max_line_bytes"] = s.config.MaxLineBytes
+	if s.config.CostPrecision != nil {
+		exportMap["cost_precision"] = *s.config.CostPrecision
+	}
+	exportMap["fail_fast_on_provider_init_error"] = s.config.FailFastOnProviderInitError
 
 	// Prometheus設定
 	if s.config.Prometheus != nil {
@@ -253,20 +300,57 @@ func (s *ConfigServiceImpl) ExportConfig() map[string]interface{} {
 		prometheusMap["remote_write_url"] = s.config.Prometheus.RemoteWriteURL
 		prometheusMap["host_label"] = s.config.Prometheus.HostLabel
 		prometheusMap["interval_seconds"] = s.config.Prometheus.IntervalSec
+		prometheusMap["align_to_interval"] = s.config.Prometheus.AlignToInterval
+		if s.config.Prometheus.Schedule != "" {
+			prometheusMap["schedule"] = s.config.Prometheus.Schedule
+		}
 		prometheusMap["timeout_seconds"] = s.config.Prometheus.TimeoutSec
+		prometheusMap["compression"] = s.config.Prometheus.Compression
+		prometheusMap["client_cert_path"] = s.config.Prometheus.ClientCertPath
+		prometheusMap["client_key_path"] = s.config.Prometheus.ClientKeyPath
+		if s.config.Prometheus.RemoteWritePasswordFile != "" {
+			prometheusMap["remote_write_password_file"] = s.config.Prometheus.RemoteWritePasswordFile
+		}
+		if s.config.Prometheus.RemoteWriteTokenFile != "" {
+			prometheusMap["remote_write_token_file"] = s.config.Prometheus.RemoteWriteTokenFile
+		}
+		prometheusMap["probe_on_startup"] = s.config.Prometheus.ShouldProbeOnStartup()
+		prometheusMap["circuit_breaker_threshold"] = s.config.Prometheus.CircuitBreakerThreshold
+		prometheusMap["circuit_breaker_backoff_seconds"] = s.config.Prometheus.CircuitBreakerBackoffSec
+		prometheusMap["scrape_listen_address"] = s.config.Prometheus.ScrapeListenAddress
+		prometheusMap["scrape_refresh_after_seconds"] = s.config.Prometheus.ScrapeRefreshAfterSec
+		prometheusMap["state_file_path"] = s.config.Prometheus.StateFilePath
+		prometheusMap["transforms"] = s.config.Prometheus.Transforms
+		prometheusMap["extra_labels"] = s.config.Prometheus.ExtraLabels
+		prometheusMap["cloud_metadata_labels"] = s.config.Prometheus.CloudMetadataLabels
+		prometheusMap["derived_labels"] = s.config.Prometheus.DerivedLabels
+		prometheusMap["metric_allowlist"] = s.config.Prometheus.MetricAllowlist
+		prometheusMap["metric_denylist"] = s.config.Prometheus.MetricDenylist
+		prometheusMap["source_path_label"] = s.config.Prometheus.SourcePathLabel
+		prometheusMap["session_percentiles"] = s.config.Prometheus.SessionPercentiles
+		prometheusMap["unique_count_metrics"] = s.config.Prometheus.UniqueCountMetrics
+		prometheusMap["hash_source_paths"] = s.config.Prometheus.ShouldHashSourcePaths()
+		backends := make([]map[string]interface{}, 0, len(s.config.Prometheus.Backends))
+		for i := range s.config.Prometheus.Backends {
+			backend := &s.config.Prometheus.Backends[i]
+			backendMap := map[string]interface{}{
+				"name":            backend.Name,
+				"type":            backend.BackendType(),
+				"url":             backend.URL,
+				"username":        backend.Username,
+				"timeout_seconds": backend.TimeoutSec,
+			}
+			config.MaskSecrets(backend, backendMap)
+			backends = append(backends, backendMap)
+		}
+		prometheusMap["backends"] = backends
 		// Remote Write認証情報
 		prometheusMap["remote_write_username"] = s.config.Prometheus.RemoteWriteUsername
-		// パスワードはマスク
-		if s.config.Prometheus.RemoteWritePassword != "" {
-			prometheusMap["remote_write_password"] = "****"
-		}
 		// Query認証情報
 		prometheusMap["url"] = s.config.Prometheus.URL
 		prometheusMap["username"] = s.config.Prometheus.Username
-		// パスワードはマスク
-		if s.config.Prometheus.Password != "" {
-			prometheusMap["password"] = "****"
-		}
+		// パスワードなど secret:"true" のフィールドはマスク
+		config.MaskSecrets(s.config.Prometheus, prometheusMap)
 		exportMap["prometheus"] = prometheusMap
 	}
 
@@ -276,6 +360,21 @@ func (s *ConfigServiceImpl) ExportConfig() map[string]interface{} {
 		cursorMap["database_path"] = s.config.Cursor.DatabasePath
 		cursorMap["api_timeout"] = s.config.Cursor.APITimeout
 		cursorMap["cache_timeout"] = s.config.Cursor.CacheTimeout
+		cursorMap["billing_day"] = s.config.Cursor.BillingDay
+		cursorMap["day_start_hour"] = s.config.Cursor.DayStartHour
+		cursorMap["host_label"] = s.config.Cursor.HostLabel
+		cursorMap["premium_request_metrics"] = s.config.Cursor.PremiumRequestMetrics
+		cursorMap["usage_cost_metrics"] = s.config.Cursor.UsageCostMetrics
+		cursorMap["call_count_metrics"] = s.config.Cursor.CallCountMetrics
+		cursorMap["billing_cycle_metric"] = s.config.Cursor.BillingCycleMetric
+		cursorMap["team_member_metrics"] = s.config.Cursor.TeamMemberMetrics
+		cursorMap["team_members"] = s.config.Cursor.TeamMembers
+		cursorMap["team_member_limit"] = s.config.Cursor.TeamMemberLimit
+		cursorMap["hash_team_members"] = s.config.Cursor.ShouldHashTeamMembers()
+		cursorMap["usage_based_status_metrics"] = s.config.Cursor.UsageBasedStatusMetrics
+		cursorMap["rate_limit"] = s.config.Cursor.RateLimit
+		cursorMap["rate_limit_burst"] = s.config.Cursor.RateLimitBurst
+		config.MaskSecrets(s.config.Cursor, cursorMap)
 		exportMap["cursor"] = cursorMap
 	}
 
@@ -285,7 +384,12 @@ func (s *ConfigServiceImpl) ExportConfig() map[string]interface{} {
 		daemonMap["enabled"] = s.config.Daemon.Enabled
 		daemonMap["start_at_login"] = s.config.Daemon.StartAtLogin
 		daemonMap["log_path"] = s.config.Daemon.LogPath
+		daemonMap["log_max_size_mb"] = s.config.Daemon.LogMaxSizeMB
+		daemonMap["log_max_backups"] = s.config.Daemon.MaxLogBackups()
+		daemonMap["log_max_age_days"] = s.config.Daemon.MaxLogAgeDays()
+		daemonMap["log_compress"] = s.config.Daemon.ShouldCompressLogs()
 		daemonMap["pid_file"] = s.config.Daemon.PidFile
+		config.MaskSecrets(s.config.Daemon, daemonMap)
 		exportMap["daemon"] = daemonMap
 	}
 
@@ -300,18 +404,54 @@ func (s *ConfigServiceImpl) ExportConfig() map[string]interface{} {
 			promtailMap := make(map[string]interface{})
 			promtailMap["url"] = s.config.Logging.Promtail.URL
 			promtailMap["username"] = s.config.Logging.Promtail.Username
-			// パスワードはマスク
-			if s.config.Logging.Promtail.Password != "" {
-				promtailMap["password"] = "****"
-			}
 			promtailMap["batch_wait_seconds"] = s.config.Logging.Promtail.BatchWaitSeconds
 			promtailMap["batch_capacity"] = s.config.Logging.Promtail.BatchCapacity
 			promtailMap["timeout_seconds"] = s.config.Logging.Promtail.TimeoutSeconds
+			promtailMap["client_cert_path"] = s.config.Logging.Promtail.ClientCertPath
+			promtailMap["client_key_path"] = s.config.Logging.Promtail.ClientKeyPath
+			if s.config.Logging.Promtail.PasswordFile != "" {
+				promtailMap["password_file"] = s.config.Logging.Promtail.PasswordFile
+			}
+			if s.config.Logging.Promtail.TokenFile != "" {
+				promtailMap["token_file"] = s.config.Logging.Promtail.TokenFile
+			}
+			config.MaskSecrets(s.config.Logging.Promtail, promtailMap)
 			loggingMap["promtail"] = promtailMap
 		}
+		config.MaskSecrets(s.config.Logging, loggingMap)
 		exportMap["logging"] = loggingMap
 	}
 
+	// Summary設定（Webhook URLはトークンを含むことがあるためマスク）
+	if s.config.Summary != nil {
+		summaryMap := make(map[string]interface{})
+		summaryMap["enabled"] = s.config.Summary.Enabled
+		summaryMap["send_time"] = s.config.Summary.SendTime
+		summaryMap["top_models"] = s.config.Summary.TopModels
+		summaryMap["max_retries"] = s.config.Summary.MaxRetries
+		config.MaskSecrets(s.config.Summary, summaryMap)
+		exportMap["summary"] = summaryMap
+	}
+
+	// PostCollectionHook設定
+	if s.config.PostCollectionHook != nil {
+		exportMap["post_collection_hook"] = map[string]interface{}{
+			"command":         s.config.PostCollectionHook.Command,
+			"timeout_seconds": s.config.PostCollectionHook.TimeoutSec,
+		}
+	}
+
+	// プロファイル設定（認証情報を含むため名前のみ）
+	if len(s.config.Profiles) > 0 {
+		profileNames := make([]string, 0, len(s.config.Profiles))
+		for _, profile := range s.config.Profiles {
+			if profile != nil {
+				profileNames = append(profileNames, profile.Name)
+			}
+		}
+		exportMap["profiles"] = profileNames
+	}
+
 	// ソース情報を追加
 	sourcesMap := make(map[string]string)
 	for key, source := range s.config.ConfigSources {
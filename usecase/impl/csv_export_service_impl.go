@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/ca-srg/tosage/domain"
@@ -45,22 +46,11 @@ func (s *CSVExportServiceImpl) Export(options usecase.CSVExportOptions) error {
 		return err
 	}
 
-	// Set default values
-	now := time.Now()
-	startTime := s.getStartTime(options.StartTime, now)
-	endTime := s.getEndTime(options.EndTime, now)
-	outputPath := s.getOutputPath(options.OutputPath, now)
-
-	// Validate time range
-	if endTime.Before(startTime) {
-		return domain.ErrInvalidInput("time range", "end time must be after start time")
-	}
-
-	// Collect metrics data
-	records, err := s.metricsCollector.Collect(startTime, endTime, options.MetricTypes)
+	startTime, endTime, records, err := s.collect(options)
 	if err != nil {
-		return domain.ErrCSVExportWithCause("collect metrics", "failed to collect metrics data", err)
+		return err
 	}
+	outputPath := s.getOutputPath(options.OutputPath, options.Compress, time.Now())
 
 	if len(records) == 0 {
 		s.logger.Warn(context.TODO(), "No metrics data found for the specified criteria",
@@ -87,6 +77,82 @@ func (s *CSVExportServiceImpl) Export(options usecase.CSVExportOptions) error {
 	return nil
 }
 
+// DryRun collects the data an export would write and counts its rows per source.
+// The writer is not invoked, so no file is created.
+func (s *CSVExportServiceImpl) DryRun(options usecase.CSVExportOptions) (*usecase.CSVExportSummary, error) {
+	startTime, endTime, records, err := s.collect(options)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &usecase.CSVExportSummary{
+		OutputPath:   s.getOutputPath(options.OutputPath, options.Compress, time.Now()),
+		StartTime:    startTime,
+		EndTime:      endTime,
+		RowsBySource: make(map[string]int),
+		TotalRows:    len(records),
+	}
+	for _, metricType := range options.MetricTypes {
+		if metricType != "all" {
+			summary.RowsBySource[metricType] = 0
+		}
+	}
+	for _, record := range records {
+		summary.RowsBySource[record.Source]++
+	}
+	return summary, nil
+}
+
+// collect resolves the time range of options and collects the records to export
+func (s *CSVExportServiceImpl) collect(options usecase.CSVExportOptions) (time.Time, time.Time, []*entity.MetricRecord, error) {
+	// Set default values
+	now := time.Now()
+	startTime := s.getStartTime(options.StartTime, now)
+	endTime := s.getEndTime(options.EndTime, now)
+
+	// Validate time range
+	if endTime.Before(startTime) {
+		return startTime, endTime, nil, domain.ErrInvalidInput("time range", "end time must be after start time")
+	}
+
+	if err := ValidateExportGranularity(options.Granularity, options.MetricTypes); err != nil {
+		return startTime, endTime, nil, err
+	}
+	if options.MinTokens < 0 {
+		return startTime, endTime, nil, domain.ErrInvalidInput("min tokens", "must not be negative")
+	}
+
+	// Collect metrics data
+	var records []*entity.MetricRecord
+	var err error
+	if (options.Granularity == "" || options.Granularity == usecase.ExportGranularityDaily) && options.Filter == nil {
+		records, err = s.metricsCollector.Collect(startTime, endTime, options.MetricTypes)
+	} else {
+		records, err = s.metricsCollector.CollectWithGranularity(startTime, endTime, options.MetricTypes, options.Granularity, options.Filter)
+	}
+	if err != nil {
+		return startTime, endTime, nil, domain.ErrCSVExportWithCause("collect metrics", "failed to collect metrics data", err)
+	}
+	return startTime, endTime, dropBelowMinTokens(records, options.MinTokens), nil
+}
+
+// dropBelowMinTokens removes token rows with fewer than minTokens tokens. Rows are checked
+// as collected, i.e. after hourly or daily aggregation; rows counted in other units, such
+// as Cursor requests, are kept.
+func dropBelowMinTokens(records []*entity.MetricRecord, minTokens int) []*entity.MetricRecord {
+	if minTokens <= 0 {
+		return records
+	}
+	kept := records[:0]
+	for _, record := range records {
+		if record.Unit == "tokens" && record.Value < float64(minTokens) {
+			continue
+		}
+		kept = append(kept, record)
+	}
+	return kept
+}
+
 // validateOptions validates export options
 func (s *CSVExportServiceImpl) validateOptions(options usecase.CSVExportOptions) error {
 	// Output path validation is done in csvWriter
@@ -114,12 +180,21 @@ func (s *CSVExportServiceImpl) getEndTime(optionTime *time.Time, now time.Time)
 }
 
 // getOutputPath returns output path with defaults
-func (s *CSVExportServiceImpl) getOutputPath(optionPath string, now time.Time) string {
+func (s *CSVExportServiceImpl) getOutputPath(optionPath string, compress bool, now time.Time) string {
 	if optionPath != "" {
 		return optionPath
 	}
-	// Default: metrics_YYYYMMDD_HHMMSS.csv in current directory
-	return fmt.Sprintf("metrics_%s.csv", now.Format("20060102_150405"))
+	return DefaultCSVExportPath(now, compress)
+}
+
+// DefaultCSVExportPath returns metrics_YYYYMMDD_HHMMSS.csv in the current directory,
+// with a .gz suffix when the export is compressed
+func DefaultCSVExportPath(now time.Time, compress bool) string {
+	path := fmt.Sprintf("metrics_%s.csv", now.Format("20060102_150405"))
+	if compress {
+		path += gzipExtension
+	}
+	return path
 }
 
 // sortRecordsByTimestamp sorts records by timestamp
@@ -136,11 +211,32 @@ func (s *CSVExportServiceImpl) sortRecordsByTimestamp(records []*entity.MetricRe
 	}
 }
 
-// GenerateExportOptions creates export options with validation
-func GenerateExportOptions(outputPath string, startTimeStr, endTimeStr string, metricTypes []string) (*usecase.CSVExportOptions, error) {
+// gzipExtension is appended to the .csv extension of compressed exports
+const gzipExtension = ".gz"
+
+// GenerateExportOptions creates export options with validation.
+// An output path ending in .csv.gz enables compression; with compress set, a .csv
+// path gets the .gz suffix so the file name matches its content.
+// A range preset (e.g. "last-month") is expanded in rangeConfig.Location and cannot be
+// combined with an explicit start or end time. The range is checked against
+// rangeConfig.MaxExportDays once both of its ends are known.
+func GenerateExportOptions(outputPath string, startTimeStr, endTimeStr, rangePreset string, metricTypes []string, compress bool, rangeConfig ExportRangeConfig) (*usecase.CSVExportOptions, error) {
 	options := &usecase.CSVExportOptions{
 		OutputPath:  outputPath,
 		MetricTypes: metricTypes,
+		Compress:    compress,
+	}
+
+	if rangePreset != "" {
+		if startTimeStr != "" || endTimeStr != "" {
+			return nil, domain.ErrInvalidInput("range", "a range preset cannot be combined with a start or end time")
+		}
+		startTime, endTime, err := resolveRangePreset(rangePreset, time.Now(), rangeConfig.Location)
+		if err != nil {
+			return nil, err
+		}
+		options.StartTime = &startTime
+		options.EndTime = &endTime
 	}
 
 	// Parse start time if provided
@@ -161,9 +257,24 @@ func GenerateExportOptions(outputPath string, startTimeStr, endTimeStr string, m
 		options.EndTime = &endTime
 	}
 
+	if options.StartTime != nil && options.EndTime != nil {
+		if err := validateExportDays(*options.StartTime, *options.EndTime, rangeConfig.MaxExportDays); err != nil {
+			return nil, err
+		}
+	}
+
 	// Validate output path extension
-	if outputPath != "" && filepath.Ext(outputPath) != ".csv" {
-		return nil, domain.ErrInvalidInput("output path", "file must have .csv extension")
+	if outputPath != "" {
+		csvPath := outputPath
+		if strings.HasSuffix(outputPath, ".csv"+gzipExtension) {
+			csvPath = strings.TrimSuffix(outputPath, gzipExtension)
+			options.Compress = true
+		} else if compress {
+			options.OutputPath = outputPath + gzipExtension
+		}
+		if filepath.Ext(csvPath) != ".csv" {
+			return nil, domain.ErrInvalidInput("output path", "file must have .csv extension (or .csv.gz)")
+		}
 	}
 
 	return options, nil
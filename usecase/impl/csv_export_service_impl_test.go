@@ -8,6 +8,7 @@ import (
 
 	"github.com/ca-srg/tosage/domain"
 	"github.com/ca-srg/tosage/domain/entity"
+	"github.com/ca-srg/tosage/infrastructure/config"
 	usecase "github.com/ca-srg/tosage/usecase/interface"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -37,6 +38,14 @@ func (m *MockMetricsDataCollector) Collect(startTime, endTime time.Time, metricT
 	return nil, args.Error(1)
 }
 
+func (m *MockMetricsDataCollector) CollectWithGranularity(startTime, endTime time.Time, metricTypes []string, granularity string, filter *entity.CcEntryFilter) ([]*entity.MetricRecord, error) {
+	args := m.Called(startTime, endTime, metricTypes, granularity, filter)
+	if result := args.Get(0); result != nil {
+		return result.([]*entity.MetricRecord), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 type MockCSVWriter struct {
 	mock.Mock
 }
@@ -247,6 +256,75 @@ func TestCSVExportService_Export_NoData(t *testing.T) {
 	mockWriter.AssertExpectations(t)
 }
 
+func TestCSVExportService_DryRun(t *testing.T) {
+	mockCollector := new(MockMetricsDataCollector)
+	mockWriter := new(MockCSVWriter)
+	logger := &MockCSVExportLogger{}
+
+	service := NewCSVExportService(mockCollector, mockWriter, logger)
+
+	startTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endTime := time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC)
+	records := []*entity.MetricRecord{
+		{Timestamp: startTime, Source: "claude_code", Value: 1000},
+		{Timestamp: startTime, Source: "claude_code", Value: 2000},
+		{Timestamp: startTime, Source: "cursor", Value: 500},
+	}
+	mockCollector.On("Collect", startTime, endTime, []string{"claude_code", "cursor", "bedrock"}).
+		Return(records, nil)
+
+	summary, err := service.DryRun(usecase.CSVExportOptions{
+		OutputPath:  "/tmp/test.csv",
+		StartTime:   &startTime,
+		EndTime:     &endTime,
+		MetricTypes: []string{"claude_code", "cursor", "bedrock"},
+	})
+
+	// The writer is never called
+	require.NoError(t, err)
+	mockCollector.AssertExpectations(t)
+	mockWriter.AssertNotCalled(t, "Write", mock.Anything, mock.Anything)
+
+	assert.Equal(t, startTime, summary.StartTime)
+	assert.Equal(t, endTime, summary.EndTime)
+	assert.Equal(t, "/tmp/test.csv", summary.OutputPath)
+	assert.Equal(t, map[string]int{"claude_code": 2, "cursor": 1, "bedrock": 0}, summary.RowsBySource)
+	assert.Equal(t, 3, summary.TotalRows)
+}
+
+func TestCSVExportService_DryRun_MinTokens(t *testing.T) {
+	mockCollector := new(MockMetricsDataCollector)
+	service := NewCSVExportService(mockCollector, new(MockCSVWriter), &MockCSVExportLogger{})
+
+	startTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endTime := time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC)
+	records := []*entity.MetricRecord{
+		{Timestamp: startTime, Source: "claude_code", Value: 5, Unit: "tokens"},
+		{Timestamp: startTime, Source: "claude_code", Value: 100, Unit: "tokens"},
+		{Timestamp: startTime, Source: "bedrock", Value: 99, Unit: "tokens"},
+		{Timestamp: startTime, Source: "cursor", Value: 3, Unit: "requests"},
+	}
+	mockCollector.On("Collect", startTime, endTime, []string{"claude_code", "cursor", "bedrock"}).
+		Return(records, nil)
+
+	options := usecase.CSVExportOptions{
+		StartTime:   &startTime,
+		EndTime:     &endTime,
+		MetricTypes: []string{"claude_code", "cursor", "bedrock"},
+		MinTokens:   100,
+	}
+	summary, err := service.DryRun(options)
+
+	// Request rows are not token counts and are kept
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"claude_code": 1, "cursor": 1, "bedrock": 0}, summary.RowsBySource)
+	assert.Equal(t, 2, summary.TotalRows)
+
+	options.MinTokens = -1
+	_, err = service.DryRun(options)
+	assert.Error(t, err)
+}
+
 func TestCSVExportService_Export_SortRecords(t *testing.T) {
 	mockCollector := new(MockMetricsDataCollector)
 	mockWriter := new(MockCSVWriter)
@@ -364,7 +442,7 @@ func TestGenerateExportOptions_Success(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			options, err := GenerateExportOptions(tt.outputPath, tt.startTimeStr, tt.endTimeStr, tt.metricTypes)
+			options, err := GenerateExportOptions(tt.outputPath, tt.startTimeStr, tt.endTimeStr, "", tt.metricTypes, false, ExportRangeConfig{})
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -415,3 +493,180 @@ func TestParseTimeString_Formats(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateExportOptions_Compression(t *testing.T) {
+	tests := []struct {
+		name         string
+		outputPath   string
+		compress     bool
+		wantPath     string
+		wantCompress bool
+		expectError  bool
+	}{
+		{name: "plain csv", outputPath: "/tmp/metrics.csv", wantPath: "/tmp/metrics.csv"},
+		{name: "gz extension enables compression", outputPath: "/tmp/metrics.csv.gz", wantPath: "/tmp/metrics.csv.gz", wantCompress: true},
+		{name: "flag appends gz extension", outputPath: "/tmp/metrics.csv", compress: true, wantPath: "/tmp/metrics.csv.gz", wantCompress: true},
+		{name: "flag with gz extension", outputPath: "/tmp/metrics.csv.gz", compress: true, wantPath: "/tmp/metrics.csv.gz", wantCompress: true},
+		{name: "flag without path", compress: true, wantPath: "", wantCompress: true},
+		{name: "gz without csv", outputPath: "/tmp/metrics.gz", expectError: true},
+		{name: "flag with other extension", outputPath: "/tmp/metrics.txt", compress: true, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := GenerateExportOptions(tt.outputPath, "", "", "", nil, tt.compress, ExportRangeConfig{})
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPath, options.OutputPath)
+			assert.Equal(t, tt.wantCompress, options.Compress)
+		})
+	}
+}
+
+func TestDefaultCSVExportPath(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+	assert.Equal(t, "metrics_20240115_103045.csv", DefaultCSVExportPath(now, false))
+	assert.Equal(t, "metrics_20240115_103045.csv.gz", DefaultCSVExportPath(now, true))
+}
+
+func TestResolveRangePreset(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	// 2024-03-15 01:00 in Tokyo is still 2024-03-14 in UTC
+	now := time.Date(2024, 3, 14, 16, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		preset string
+		start  time.Time
+		end    time.Time
+	}{
+		{RangeLast7Days, time.Date(2024, 3, 9, 0, 0, 0, 0, tokyo), time.Date(2024, 3, 16, 0, 0, 0, 0, tokyo).Add(-time.Nanosecond)},
+		{RangeLastMonth, time.Date(2024, 2, 1, 0, 0, 0, 0, tokyo), time.Date(2024, 3, 1, 0, 0, 0, 0, tokyo).Add(-time.Nanosecond)},
+		{RangeThisMonth, time.Date(2024, 3, 1, 0, 0, 0, 0, tokyo), time.Date(2024, 3, 16, 0, 0, 0, 0, tokyo).Add(-time.Nanosecond)},
+		{RangeYTD, time.Date(2024, 1, 1, 0, 0, 0, 0, tokyo), time.Date(2024, 3, 16, 0, 0, 0, 0, tokyo).Add(-time.Nanosecond)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			start, end, err := resolveRangePreset(tt.preset, now, tokyo)
+			require.NoError(t, err)
+			assert.True(t, tt.start.Equal(start), "start = %v", start)
+			assert.True(t, tt.end.Equal(end), "end = %v", end)
+		})
+	}
+
+	_, _, err := resolveRangePreset("last-decade", now, tokyo)
+	assert.Error(t, err)
+}
+
+func TestValidateExportDays_LeapYearYTD(t *testing.T) {
+	// On Dec 31 of a leap year ytd covers 366 days, which the default maximum allows
+	now := time.Date(2024, 12, 31, 12, 0, 0, 0, time.UTC)
+	start, end, err := resolveRangePreset(RangeYTD, now, time.UTC)
+	require.NoError(t, err)
+	assert.NoError(t, validateExportDays(start, end, config.DefaultConfig().CSVExport.MaxExportDays))
+	assert.Error(t, validateExportDays(start, end, 365))
+}
+
+func TestGenerateExportOptions_RangePreset(t *testing.T) {
+	options, err := GenerateExportOptions("", "", "", "this-month", nil, false, ExportRangeConfig{MaxExportDays: 365})
+	require.NoError(t, err)
+	require.NotNil(t, options.StartTime)
+	require.NotNil(t, options.EndTime)
+	assert.Equal(t, 1, options.StartTime.Day())
+
+	// A preset together with an explicit bound is rejected
+	_, err = GenerateExportOptions("", "2024-01-01", "", "ytd", nil, false, ExportRangeConfig{})
+	assert.Error(t, err)
+
+	// The range may not exceed the configured maximum
+	_, err = GenerateExportOptions("", "", "", "last-7-days", nil, false, ExportRangeConfig{MaxExportDays: 6})
+	assert.Error(t, err)
+	_, err = GenerateExportOptions("", "2024-01-01", "2024-12-31", "", nil, false, ExportRangeConfig{MaxExportDays: 30})
+	assert.Error(t, err)
+}
+
+func TestCSVExportService_Export_Granularity(t *testing.T) {
+	mockCollector := new(MockMetricsDataCollector)
+	mockWriter := new(MockCSVWriter)
+	service := NewCSVExportService(mockCollector, mockWriter, &MockCSVExportLogger{})
+
+	startTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endTime := time.Date(2024, 1, 1, 23, 59, 59, 0, time.UTC)
+	records := []*entity.MetricRecord{{Timestamp: startTime, Source: "claude_code", Value: 10}}
+	mockCollector.On("CollectWithGranularity", startTime, endTime, []string{"claude_code"}, usecase.ExportGranularityHourly, (*entity.CcEntryFilter)(nil)).
+		Return(records, nil)
+	mockWriter.On("Write", records, "/tmp/test.csv").Return(nil)
+
+	err := service.Export(usecase.CSVExportOptions{
+		OutputPath:  "/tmp/test.csv",
+		StartTime:   &startTime,
+		EndTime:     &endTime,
+		MetricTypes: []string{"claude_code"},
+		Granularity: usecase.ExportGranularityHourly,
+	})
+	require.NoError(t, err)
+	mockCollector.AssertExpectations(t)
+	mockWriter.AssertExpectations(t)
+
+	// Hourly data does not exist for the other sources
+	err = service.Export(usecase.CSVExportOptions{
+		OutputPath:  "/tmp/test.csv",
+		MetricTypes: []string{"claude_code", "bedrock"},
+		Granularity: usecase.ExportGranularityHourly,
+	})
+	assert.Error(t, err)
+}
+
+func TestCSVExportService_Export_Filter(t *testing.T) {
+	mockCollector := new(MockMetricsDataCollector)
+	mockWriter := new(MockCSVWriter)
+	service := NewCSVExportService(mockCollector, mockWriter, &MockCSVExportLogger{})
+
+	filter, err := entity.ParseCcEntryFilter(`model ~ "claude-3"`)
+	require.NoError(t, err)
+	startTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endTime := time.Date(2024, 1, 1, 23, 59, 59, 0, time.UTC)
+	records := []*entity.MetricRecord{{Timestamp: startTime, Source: "claude_code", Value: 10}}
+	// Daily exports with a filter go through the filtered collection as well
+	mockCollector.On("CollectWithGranularity", startTime, endTime, []string{"claude_code"}, "", filter).
+		Return(records, nil)
+	mockWriter.On("Write", records, "/tmp/test.csv").Return(nil)
+
+	err = service.Export(usecase.CSVExportOptions{
+		OutputPath:  "/tmp/test.csv",
+		StartTime:   &startTime,
+		EndTime:     &endTime,
+		MetricTypes: []string{"claude_code"},
+		Filter:      filter,
+	})
+	require.NoError(t, err)
+	mockCollector.AssertExpectations(t)
+	mockWriter.AssertExpectations(t)
+}
+
+func TestValidateExportGranularity(t *testing.T) {
+	tests := []struct {
+		granularity string
+		metricTypes []string
+		wantErr     bool
+	}{
+		{"", []string{"all"}, false},
+		{"daily", []string{"claude_code", "cursor"}, false},
+		{"entry", []string{"claude_code"}, false},
+		{"hourly", []string{"claude_code"}, false},
+		{"hourly", []string{"all"}, true},
+		{"entry", []string{"cursor"}, true},
+		{"entry", nil, true},
+		{"weekly", []string{"claude_code"}, true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateExportGranularity(tt.granularity, tt.metricTypes)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateExportGranularity(%q, %v) error = %v, wantErr %v", tt.granularity, tt.metricTypes, err, tt.wantErr)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package impl
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"github.com/ca-srg/tosage/domain"
 	"github.com/ca-srg/tosage/domain/entity"
 	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/domain/valueobject"
 	"github.com/ca-srg/tosage/infrastructure/config"
 	usecase "github.com/ca-srg/tosage/usecase/interface"
 )
@@ -211,23 +213,114 @@ func (s *CursorServiceImpl) ClearCache() {
 
 // GetAggregatedTokenUsage retrieves aggregated token usage from JST 00:00 to current time
 func (s *CursorServiceImpl) GetAggregatedTokenUsage() (int64, error) {
+	token, err := s.getValidToken()
+	if err != nil {
+		return 0, err
+	}
+
+	// Get aggregated token usage from API
+	totalTokens, err := s.apiRepo.GetAggregatedTokenUsage(token)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get aggregated token usage: %w", err)
+	}
+
+	return totalTokens, nil
+}
+
+// GetIncrementalTokenUsage retrieves today's token usage, reading only events newer than position
+func (s *CursorServiceImpl) GetIncrementalTokenUsage(position *entity.CursorUsagePosition) (*entity.CursorUsagePosition, error) {
+	token, err := s.getValidToken()
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := s.apiRepo.GetIncrementalTokenUsage(token, position)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incremental token usage: %w", err)
+	}
+
+	return next, nil
+}
+
+// GetBillingPeriodTokenUsage retrieves aggregated token usage for the current billing period
+func (s *CursorServiceImpl) GetBillingPeriodTokenUsage() (int64, error) {
+	token, err := s.getValidToken()
+	if err != nil {
+		return 0, err
+	}
+
+	totalTokens, err := s.apiRepo.GetBillingPeriodTokenUsage(token)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get billing period token usage: %w", err)
+	}
+
+	return totalTokens, nil
+}
+
+// GetBillingCycleTokenUsage retrieves aggregated token usage since the billing cycle start reported by Cursor
+func (s *CursorServiceImpl) GetBillingCycleTokenUsage() (int64, error) {
+	token, err := s.getValidToken()
+	if err != nil {
+		return 0, err
+	}
+
+	totalTokens, err := s.apiRepo.GetBillingCycleTokenUsage(token)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get billing cycle token usage: %w", err)
+	}
+
+	return totalTokens, nil
+}
+
+// GetTeamMemberTokenUsage retrieves today's token usage of the selected members of the user's team
+func (s *CursorServiceImpl) GetTeamMemberTokenUsage(selectMembers repository.TeamMemberSelector) ([]repository.TeamMemberTokenUsage, error) {
+	token, err := s.getValidToken()
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := s.apiRepo.GetTeamMemberTokenUsage(token, selectMembers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team member token usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// getValidToken returns the stored token if it has not expired
+func (s *CursorServiceImpl) getValidToken() (*valueobject.CursorToken, error) {
 	// Get token from repository
 	token, err := s.tokenRepo.GetToken()
 	if err != nil {
-		return 0, fmt.Errorf("failed to retrieve Cursor token: %w", err)
+		return nil, fmt.Errorf("failed to retrieve Cursor token: %w", err)
 	}
 
 	// Check if token is expired
 	if token.IsExpired() {
-		return 0, domain.ErrCursorToken("token has expired").
+		return nil, domain.ErrCursorToken("token has expired").
 			WithDetails("expiresAt", token.ExpiresAt())
 	}
 
-	// Get aggregated token usage from API
-	totalTokens, err := s.apiRepo.GetAggregatedTokenUsage(token)
+	return token, nil
+}
+
+// CheckConnection verifies the stored token is accepted by the Cursor API
+func (s *CursorServiceImpl) CheckConnection(ctx context.Context) error {
+	token, err := s.tokenRepo.GetToken()
 	if err != nil {
-		return 0, fmt.Errorf("failed to get aggregated token usage: %w", err)
+		return fmt.Errorf("failed to retrieve Cursor token: %w", err)
 	}
 
-	return totalTokens, nil
+	if token.IsExpired() {
+		return domain.ErrCursorToken("token has expired").
+			WithDetails("expiresAt", token.ExpiresAt())
+	}
+
+	return s.apiRepo.CheckConnection(ctx, token)
+}
+
+// ResponseFormatOK reports whether every Cursor API response since the previous call was
+// fully understood, and starts the next check
+func (s *CursorServiceImpl) ResponseFormatOK() bool {
+	return s.apiRepo.ResponseFormatOK()
 }
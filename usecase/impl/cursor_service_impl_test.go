@@ -1,6 +1,7 @@
 package impl
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -30,6 +31,7 @@ type mockCursorAPIRepository struct {
 	usageErr  error
 	limitErr  error
 	statusErr error
+	connErr   error
 	callCount map[string]int
 }
 
@@ -59,6 +61,35 @@ func (m *mockCursorAPIRepository) GetAggregatedTokenUsage(token *valueobject.Cur
 	return 0, nil
 }
 
+func (m *mockCursorAPIRepository) GetIncrementalTokenUsage(token *valueobject.CursorToken, position *entity.CursorUsagePosition) (*entity.CursorUsagePosition, error) {
+	m.callCount["GetIncrementalTokenUsage"]++
+	return &entity.CursorUsagePosition{}, nil
+}
+
+func (m *mockCursorAPIRepository) GetBillingPeriodTokenUsage(token *valueobject.CursorToken) (int64, error) {
+	m.callCount["GetBillingPeriodTokenUsage"]++
+	return 0, nil
+}
+
+func (m *mockCursorAPIRepository) GetBillingCycleTokenUsage(token *valueobject.CursorToken) (int64, error) {
+	m.callCount["GetBillingCycleTokenUsage"]++
+	return 0, nil
+}
+
+func (m *mockCursorAPIRepository) GetTeamMemberTokenUsage(token *valueobject.CursorToken, selectMembers repository.TeamMemberSelector) ([]repository.TeamMemberTokenUsage, error) {
+	m.callCount["GetTeamMemberTokenUsage"]++
+	return nil, nil
+}
+
+func (m *mockCursorAPIRepository) CheckConnection(ctx context.Context, token *valueobject.CursorToken) error {
+	m.callCount["CheckConnection"]++
+	return m.connErr
+}
+
+func (m *mockCursorAPIRepository) ResponseFormatOK() bool {
+	return true
+}
+
 // Test helper functions
 
 func createTestToken(expired bool) *valueobject.CursorToken {
@@ -508,3 +539,39 @@ func containsString(s, substr string) bool {
 	}
 	return false
 }
+
+func TestCursorService_CheckConnection(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     *valueobject.CursorToken
+		tokenErr  error
+		connErr   error
+		wantErr   bool
+		wantCalls int
+	}{
+		{name: "accepted token", token: createTestToken(false), wantCalls: 1},
+		{name: "rejected token", token: createTestToken(false), connErr: fmt.Errorf("401"), wantErr: true, wantCalls: 1},
+		{name: "expired token", token: createTestToken(true), wantErr: true},
+		{name: "missing token", tokenErr: fmt.Errorf("not found"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiRepo := newMockCursorAPIRepository()
+			apiRepo.connErr = tt.connErr
+			service := NewCursorService(
+				&mockCursorTokenRepository{token: tt.token, err: tt.tokenErr},
+				apiRepo,
+				&config.CursorConfig{CacheTimeout: 300},
+			)
+
+			err := service.CheckConnection(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckConnection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := apiRepo.callCount["CheckConnection"]; got != tt.wantCalls {
+				t.Errorf("API CheckConnection calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
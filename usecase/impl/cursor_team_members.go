@@ -0,0 +1,82 @@
+package impl
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/domain/valueobject"
+	"github.com/ca-srg/tosage/infrastructure/config"
+)
+
+// cursorTeamMemberFilter selects the Cursor team members sent as separate series
+type cursorTeamMemberFilter struct {
+	// allow holds the lowercased emails and names to send (all members when empty)
+	allow map[string]bool
+	// limit is the largest number of members read and sent
+	limit int
+	// hashed replaces the user label with a stable hash
+	hashed bool
+}
+
+// newCursorTeamMemberFilter creates a filter for allowlist, capped at limit members, that hashes
+// the user label if hashed is set. A limit of zero or less selects config.DefaultCursorTeamMemberLimit.
+func newCursorTeamMemberFilter(allowlist []string, limit int, hashed bool) *cursorTeamMemberFilter {
+	allow := make(map[string]bool)
+	for _, entry := range allowlist {
+		if entry = strings.ToLower(strings.TrimSpace(entry)); entry != "" {
+			allow[entry] = true
+		}
+	}
+	if limit <= 0 {
+		limit = config.DefaultCursorTeamMemberLimit
+	}
+	return &cursorTeamMemberFilter{allow: allow, limit: limit, hashed: hashed}
+}
+
+// apply returns the allowed members of the team roster in label order, cut at the limit so the
+// same members are read every cycle. dropped counts the allowed members cut by the limit.
+func (f *cursorTeamMemberFilter) apply(roster []repository.TeamMemberTokenUsage) (selected []repository.TeamMemberTokenUsage, dropped int) {
+	for _, member := range roster {
+		if f.allows(member) {
+			selected = append(selected, member)
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return cursorTeamMemberLabel(selected[i]) < cursorTeamMemberLabel(selected[j])
+	})
+	if len(selected) > f.limit {
+		dropped = len(selected) - f.limit
+		selected = selected[:f.limit]
+	}
+	return selected, dropped
+}
+
+// allows reports whether member's email or name is in the allowlist
+func (f *cursorTeamMemberFilter) allows(member repository.TeamMemberTokenUsage) bool {
+	if len(f.allow) == 0 {
+		return true
+	}
+	return f.allow[strings.ToLower(member.Email)] || f.allow[strings.ToLower(member.Name)]
+}
+
+// label returns the user label of member, hashed if the filter hashes labels
+func (f *cursorTeamMemberFilter) label(member repository.TeamMemberTokenUsage) string {
+	if f.hashed {
+		return valueobject.HashTeamMember(cursorTeamMemberLabel(member))
+	}
+	return cursorTeamMemberLabel(member)
+}
+
+// cursorTeamMemberLabel is the user label of member: the email, else the name, else the user ID
+func cursorTeamMemberLabel(member repository.TeamMemberTokenUsage) string {
+	switch {
+	case member.Email != "":
+		return member.Email
+	case member.Name != "":
+		return member.Name
+	default:
+		return strconv.Itoa(member.UserID)
+	}
+}
@@ -0,0 +1,93 @@
+package impl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ca-srg/tosage/domain"
+)
+
+// Named export ranges accepted by --range
+const (
+	RangeLast7Days = "last-7-days"
+	RangeLastMonth = "last-month"
+	RangeThisMonth = "this-month"
+	RangeYTD       = "ytd"
+)
+
+// ExportRangeConfig holds the settings an export range is resolved and validated with
+type ExportRangeConfig struct {
+	// Location is the timezone presets are resolved in (UTC when nil)
+	Location *time.Location
+	// MaxExportDays limits the length of the range (no limit when 0)
+	MaxExportDays int
+}
+
+// rangePresets maps each preset name to the start and end it covers at now.
+// Every range starts at midnight; open ranges end with the current day.
+var rangePresets = map[string]func(now time.Time) (time.Time, time.Time){
+	RangeLast7Days: func(now time.Time) (time.Time, time.Time) {
+		today := startOfDay(now)
+		return today.AddDate(0, 0, -6), endOfDay(today)
+	},
+	RangeLastMonth: func(now time.Time) (time.Time, time.Time) {
+		thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return thisMonth.AddDate(0, -1, 0), thisMonth.Add(-time.Nanosecond)
+	},
+	RangeThisMonth: func(now time.Time) (time.Time, time.Time) {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), endOfDay(startOfDay(now))
+	},
+	RangeYTD: func(now time.Time) (time.Time, time.Time) {
+		return time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location()), endOfDay(startOfDay(now))
+	},
+}
+
+// RangePresetNames returns the accepted preset names, sorted
+func RangePresetNames() []string {
+	names := make([]string, 0, len(rangePresets))
+	for name := range rangePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveRangePreset expands preset to the start and end it covers at now in loc
+func resolveRangePreset(preset string, now time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	resolve, ok := rangePresets[strings.ToLower(strings.TrimSpace(preset))]
+	if !ok {
+		return time.Time{}, time.Time{}, domain.ErrInvalidInput("range",
+			fmt.Sprintf("unknown preset %q (available: %s)", preset, strings.Join(RangePresetNames(), ", ")))
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	start, end := resolve(now.In(loc))
+	return start, end, nil
+}
+
+// validateExportDays checks that the range from start to end spans at most maxDays days
+func validateExportDays(start, end time.Time, maxDays int) error {
+	if maxDays <= 0 {
+		return nil
+	}
+	// Integer division, as float hours round a range ending just before midnight up a day
+	days := int(end.Sub(start)/(24*time.Hour)) + 1
+	if days > maxDays {
+		return domain.ErrInvalidInput("time range",
+			fmt.Sprintf("range covers %d days, more than the maximum of %d (csv_export.max_export_days)", days, maxDays))
+	}
+	return nil
+}
+
+// startOfDay returns midnight of the day t falls on, in t's location
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// endOfDay returns the last instant of the day starting at midnight
+func endOfDay(midnight time.Time) time.Time {
+	return midnight.AddDate(0, 0, 1).Add(-time.Nanosecond)
+}
@@ -0,0 +1,34 @@
+package impl
+
+import "time"
+
+// ingestionDay returns the window to query for the usage of the day of date. Like the
+// repositories' GetDailyUsage, the calendar date of date is taken as a JST day. The window
+// ends delay before now: samples newer than that are not visible in the cloud monitoring
+// services yet and are counted by a later query instead. Within the delay after midnight,
+// today's window is empty.
+func ingestionDay(date, now time.Time, delay time.Duration) (start, end time.Time) {
+	if delay < 0 {
+		delay = 0
+	}
+	jst, _ := time.LoadLocation("Asia/Tokyo")
+	start = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, jst)
+	end = start.Add(24 * time.Hour)
+	if cutoff := now.Add(-delay); cutoff.Before(end) {
+		end = cutoff
+	}
+	if end.Before(start) {
+		end = start
+	}
+	return start, end
+}
+
+// currentIngestionDay returns the window of the current day, shifted by the delay as a whole:
+// within the delay after midnight the previous day is still current, so its last calls are
+// counted once they are visible, and the day rolls over only after that
+func currentIngestionDay(now time.Time, delay time.Duration) (start, end time.Time) {
+	if delay < 0 {
+		delay = 0
+	}
+	return ingestionDay(now.Add(-delay), now, delay)
+}
@@ -0,0 +1,131 @@
+package impl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIngestionDay(t *testing.T) {
+	jst, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("Asia/Tokyo not available: %v", err)
+	}
+	tests := []struct {
+		name      string
+		date      time.Time
+		now       time.Time
+		delay     time.Duration
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			name:      "today ends the delay before now",
+			date:      time.Date(2025, 3, 14, 15, 0, 0, 0, jst),
+			now:       time.Date(2025, 3, 14, 15, 0, 0, 0, jst),
+			delay:     3 * time.Minute,
+			wantStart: time.Date(2025, 3, 14, 0, 0, 0, 0, jst),
+			wantEnd:   time.Date(2025, 3, 14, 14, 57, 0, 0, jst),
+		},
+		{
+			name:      "just after midnight today is empty",
+			date:      time.Date(2025, 3, 14, 0, 1, 0, 0, jst),
+			now:       time.Date(2025, 3, 14, 0, 1, 0, 0, jst),
+			delay:     3 * time.Minute,
+			wantStart: time.Date(2025, 3, 14, 0, 0, 0, 0, jst),
+			wantEnd:   time.Date(2025, 3, 14, 0, 0, 0, 0, jst),
+		},
+		{
+			name:      "the date of another location is a JST day",
+			date:      time.Date(2025, 3, 14, 20, 0, 0, 0, time.UTC),
+			now:       time.Date(2025, 3, 16, 12, 0, 0, 0, time.UTC),
+			delay:     3 * time.Minute,
+			wantStart: time.Date(2025, 3, 14, 0, 0, 0, 0, jst),
+			wantEnd:   time.Date(2025, 3, 15, 0, 0, 0, 0, jst),
+		},
+		{
+			name:      "a past day is whole",
+			date:      time.Date(2025, 3, 10, 12, 0, 0, 0, jst),
+			now:       time.Date(2025, 3, 14, 15, 0, 0, 0, jst),
+			delay:     3 * time.Minute,
+			wantStart: time.Date(2025, 3, 10, 0, 0, 0, 0, jst),
+			wantEnd:   time.Date(2025, 3, 11, 0, 0, 0, 0, jst),
+		},
+		{
+			name:      "yesterday within the delay is cut",
+			date:      time.Date(2025, 3, 13, 12, 0, 0, 0, jst),
+			now:       time.Date(2025, 3, 14, 0, 1, 0, 0, jst),
+			delay:     3 * time.Minute,
+			wantStart: time.Date(2025, 3, 13, 0, 0, 0, 0, jst),
+			wantEnd:   time.Date(2025, 3, 13, 23, 58, 0, 0, jst),
+		},
+		{
+			name:      "a negative delay queries up to now",
+			date:      time.Date(2025, 3, 14, 15, 0, 0, 0, jst),
+			now:       time.Date(2025, 3, 14, 15, 0, 0, 0, jst),
+			delay:     -time.Second,
+			wantStart: time.Date(2025, 3, 14, 0, 0, 0, 0, jst),
+			wantEnd:   time.Date(2025, 3, 14, 15, 0, 0, 0, jst),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := ingestionDay(tt.date, tt.now, tt.delay)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("ingestionDay() = %v - %v, want %v - %v", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestCurrentIngestionDay(t *testing.T) {
+	jst, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("Asia/Tokyo not available: %v", err)
+	}
+	tests := []struct {
+		name      string
+		now       time.Time
+		delay     time.Duration
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			name:      "during the day",
+			now:       time.Date(2025, 3, 14, 15, 0, 0, 0, jst),
+			delay:     3 * time.Minute,
+			wantStart: time.Date(2025, 3, 14, 0, 0, 0, 0, jst),
+			wantEnd:   time.Date(2025, 3, 14, 14, 57, 0, 0, jst),
+		},
+		{
+			name:      "within the delay after midnight the previous day is current",
+			now:       time.Date(2025, 3, 14, 0, 1, 0, 0, jst),
+			delay:     3 * time.Minute,
+			wantStart: time.Date(2025, 3, 13, 0, 0, 0, 0, jst),
+			wantEnd:   time.Date(2025, 3, 13, 23, 58, 0, 0, jst),
+		},
+		{
+			name:      "the day rolls over once the delay has passed",
+			now:       time.Date(2025, 3, 14, 0, 3, 0, 0, jst),
+			delay:     3 * time.Minute,
+			wantStart: time.Date(2025, 3, 14, 0, 0, 0, 0, jst),
+			wantEnd:   time.Date(2025, 3, 14, 0, 0, 0, 0, jst),
+		},
+		{
+			name:      "a negative delay queries up to now",
+			now:       time.Date(2025, 3, 14, 0, 1, 0, 0, jst),
+			delay:     -time.Second,
+			wantStart: time.Date(2025, 3, 14, 0, 0, 0, 0, jst),
+			wantEnd:   time.Date(2025, 3, 14, 0, 1, 0, 0, jst),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := currentIngestionDay(tt.now, tt.delay)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("currentIngestionDay() = %v - %v, want %v - %v", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
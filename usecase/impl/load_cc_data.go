@@ -5,12 +5,16 @@ import (
 
 	"github.com/ca-srg/tosage/domain/entity"
 	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/domain/valueobject"
 	usecase "github.com/ca-srg/tosage/usecase/interface"
 )
 
 // LoadCcDataUseCase implements the use case for loading cc data
 type LoadCcDataUseCase struct {
-	ccRepo repository.CcRepository
+	ccRepo          repository.CcRepository
+	excludeModels   []string
+	entryFilter     *entity.CcEntryFilter
+	totalComponents valueobject.TokenComponents
 }
 
 // NewLoadCcDataUseCase creates a new instance of the use case
@@ -72,6 +76,7 @@ func (uc *LoadCcDataUseCase) ExecuteAll() (*usecase.CcDataResult, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to find all entries: %w", err)
 	}
+	entries = entity.NewCcEntryCollection(entries).ExcludeModels(uc.excludeModels).Filter(uc.entryFilter).Entries()
 
 	result := &usecase.CcDataResult{
 		Entries:    make([]usecase.CcDataEntry, len(entries)),
@@ -103,7 +108,7 @@ func (uc *LoadCcDataUseCase) getFilteredEntries(filter usecase.CcDataFilter) ([]
 	}
 
 	// Apply additional filters using collection
-	collection := entity.NewCcEntryCollection(entries)
+	collection := entity.NewCcEntryCollection(entries).ExcludeModels(uc.excludeModels).Filter(uc.entryFilter).Filter(filter.EntryFilter)
 
 	if filter.ProjectPath != "" {
 		collection = collection.FilterByProject(filter.ProjectPath)
@@ -135,12 +140,13 @@ func (uc *LoadCcDataUseCase) convertToCcDataEntry(entry *entity.CcEntry) usecase
 		OutputTokens:        stats.OutputTokens(),
 		CacheCreationTokens: stats.CacheCreationTokens(),
 		CacheReadTokens:     stats.CacheReadTokens(),
-		TotalTokens:         stats.TotalTokens(),
+		TotalTokens:         uc.totalComponents.Total(stats),
 		Cost:                0,
 		Currency:            "USD",
 		Version:             entry.Version(),
 		MessageID:           entry.MessageID(),
 		RequestID:           entry.RequestID(),
+		SourcePath:          entry.SourcePath(),
 	}
 }
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/ca-srg/tosage/domain"
 	"github.com/ca-srg/tosage/domain/entity"
+	"github.com/ca-srg/tosage/domain/valueobject"
 	usecase "github.com/ca-srg/tosage/usecase/interface"
 )
 
@@ -18,6 +19,17 @@ type MetricsDataCollectorImpl struct {
 	bedrockService  usecase.BedrockService
 	vertexAIService usecase.VertexAIService
 	logger          domain.Logger
+	hashProjects    bool
+}
+
+// MetricsDataCollectorOption configures optional behavior of MetricsDataCollectorImpl
+type MetricsDataCollectorOption func(*MetricsDataCollectorImpl)
+
+// WithExportedProjectPathsHashed writes the project path of each record as its stable hash
+func WithExportedProjectPathsHashed(enabled bool) MetricsDataCollectorOption {
+	return func(c *MetricsDataCollectorImpl) {
+		c.hashProjects = enabled
+	}
 }
 
 // NewMetricsDataCollector creates a new MetricsDataCollector
@@ -27,22 +39,40 @@ func NewMetricsDataCollector(
 	bedrockService usecase.BedrockService,
 	vertexAIService usecase.VertexAIService,
 	logger domain.Logger,
+	opts ...MetricsDataCollectorOption,
 ) usecase.MetricsDataCollector {
-	return &MetricsDataCollectorImpl{
+	c := &MetricsDataCollectorImpl{
 		ccService:       ccService,
 		cursorService:   cursorService,
 		bedrockService:  bedrockService,
 		vertexAIService: vertexAIService,
 		logger:          logger,
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
-// Collect collects metrics data from all sources
+// Collect collects daily metrics data from all sources
 func (c *MetricsDataCollectorImpl) Collect(startTime, endTime time.Time, metricTypes []string) ([]*entity.MetricRecord, error) {
+	return c.CollectWithGranularity(startTime, endTime, metricTypes, usecase.ExportGranularityDaily, nil)
+}
+
+// CollectWithGranularity collects metrics data from all sources with one record per entry,
+// hour or day
+func (c *MetricsDataCollectorImpl) CollectWithGranularity(startTime, endTime time.Time, metricTypes []string, granularity string, filter *entity.CcEntryFilter) ([]*entity.MetricRecord, error) {
 	c.logger.Info(context.TODO(), "Starting metrics collection",
 		domain.NewField("startTime", startTime),
 		domain.NewField("endTime", endTime),
-		domain.NewField("metricTypes", metricTypes))
+		domain.NewField("metricTypes", metricTypes),
+		domain.NewField("granularity", granularity))
+
+	if err := ValidateExportGranularity(granularity, metricTypes); err != nil {
+		return nil, err
+	}
 
 	// Validate metric types
 	validTypes := map[string]bool{
@@ -89,7 +119,7 @@ func (c *MetricsDataCollectorImpl) Collect(startTime, endTime time.Time, metricT
 		go func(mType string) {
 			defer wg.Done()
 
-			records, err := c.collectMetricType(mType, startTime, endTime)
+			records, err := c.collectMetricType(mType, startTime, endTime, granularity, filter)
 			if err != nil {
 				errors <- fmt.Errorf("%s: %w", mType, err)
 				return
@@ -149,10 +179,13 @@ func (c *MetricsDataCollectorImpl) Collect(startTime, endTime time.Time, metricT
 }
 
 // collectMetricType collects metrics for a specific type
-func (c *MetricsDataCollectorImpl) collectMetricType(metricType string, startTime, endTime time.Time) ([]*entity.MetricRecord, error) {
+func (c *MetricsDataCollectorImpl) collectMetricType(metricType string, startTime, endTime time.Time, granularity string, filter *entity.CcEntryFilter) ([]*entity.MetricRecord, error) {
 	switch metricType {
 	case "claude_code":
-		return c.collectClaudeCode(startTime, endTime)
+		if granularity == usecase.ExportGranularityEntry {
+			return c.collectClaudeCodeEntries(startTime, endTime, filter)
+		}
+		return c.collectClaudeCode(startTime, endTime, granularity == usecase.ExportGranularityHourly, filter)
 	case "cursor":
 		return c.collectCursor(startTime, endTime)
 	case "bedrock":
@@ -164,8 +197,8 @@ func (c *MetricsDataCollectorImpl) collectMetricType(metricType string, startTim
 	}
 }
 
-// collectClaudeCode collects Claude Code metrics
-func (c *MetricsDataCollectorImpl) collectClaudeCode(startTime, endTime time.Time) ([]*entity.MetricRecord, error) {
+// collectClaudeCode collects Claude Code metrics per date, or per hour, in the configured timezone
+func (c *MetricsDataCollectorImpl) collectClaudeCode(startTime, endTime time.Time, hourly bool, entryFilter *entity.CcEntryFilter) ([]*entity.MetricRecord, error) {
 	// Check if Claude Code service is available
 	if c.ccService == nil {
 		return nil, nil // No Claude Code service configured
@@ -173,8 +206,10 @@ func (c *MetricsDataCollectorImpl) collectClaudeCode(startTime, endTime time.Tim
 
 	// Get date breakdown for the time range
 	filter := usecase.DateBreakdownFilter{
-		StartDate: &startTime,
-		EndDate:   &endTime,
+		StartDate:   &startTime,
+		EndDate:     &endTime,
+		Hourly:      hourly,
+		EntryFilter: entryFilter,
 	}
 
 	breakdown, err := c.ccService.CalculateDateBreakdown(filter)
@@ -184,13 +219,17 @@ func (c *MetricsDataCollectorImpl) collectClaudeCode(startTime, endTime time.Tim
 
 	var records []*entity.MetricRecord
 	for _, item := range breakdown.Dates {
-		// Parse date string
-		date, err := time.Parse("2006-01-02", item.Date)
-		if err != nil {
-			c.logger.Warn(context.TODO(), "Failed to parse date",
-				domain.NewField("date", item.Date),
-				domain.NewField("error", err.Error()))
-			continue
+		// Hours keep their start in the configured timezone
+		date := item.Start
+		if !hourly {
+			// Parse date string
+			date, err = time.Parse("2006-01-02", item.Date)
+			if err != nil {
+				c.logger.Warn(context.TODO(), "Failed to parse date",
+					domain.NewField("date", item.Date),
+					domain.NewField("error", err.Error()))
+				continue
+			}
 		}
 
 		// Create metric record
@@ -217,6 +256,71 @@ func (c *MetricsDataCollectorImpl) collectClaudeCode(startTime, endTime time.Tim
 	return records, nil
 }
 
+// collectClaudeCodeEntries collects one Claude Code record per entry
+func (c *MetricsDataCollectorImpl) collectClaudeCodeEntries(startTime, endTime time.Time, filter *entity.CcEntryFilter) ([]*entity.MetricRecord, error) {
+	if c.ccService == nil {
+		return nil, nil // No Claude Code service configured
+	}
+
+	data, err := c.ccService.LoadCcData(usecase.CcDataFilter{StartDate: &startTime, EndDate: &endTime, EntryFilter: filter})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load entries for claude_code: %w", err)
+	}
+
+	records := make([]*entity.MetricRecord, 0, len(data.Entries))
+	for _, entry := range data.Entries {
+		record := newCcEntryRecord(entry, valueobject.DisplayProjectPath(entry.ProjectPath, c.hashProjects))
+		record.AddMetadata("model", entry.Model)
+		record.AddMetadata("session_id", entry.SessionID)
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// newCcEntryRecord creates a Claude Code record for a single entry, with the same metadata
+// as the daily records
+func newCcEntryRecord(entry usecase.CcDataEntry, project string) *entity.MetricRecord {
+	currency := entry.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+
+	record := entity.NewMetricRecord(entry.Timestamp, "claude_code", project, float64(entry.TotalTokens), "tokens")
+	record.AddMetadata("input_tokens", fmt.Sprintf("%d", entry.InputTokens))
+	record.AddMetadata("output_tokens", fmt.Sprintf("%d", entry.OutputTokens))
+	record.AddMetadata("cache_creation_tokens", fmt.Sprintf("%d", entry.CacheCreationTokens))
+	record.AddMetadata("cache_read_tokens", fmt.Sprintf("%d", entry.CacheReadTokens))
+	record.AddMetadata("cost", fmt.Sprintf("%.4f", entry.Cost))
+	record.AddMetadata("currency", currency)
+	record.AddMetadata("entry_count", "1")
+	return record
+}
+
+// ValidateExportGranularity checks that granularity is known and available for every
+// selected metric type. Entry and hourly data only exist for claude_code.
+func ValidateExportGranularity(granularity string, metricTypes []string) error {
+	switch granularity {
+	case "", usecase.ExportGranularityDaily:
+		return nil
+	case usecase.ExportGranularityEntry, usecase.ExportGranularityHourly:
+	default:
+		return domain.ErrInvalidInput("granularity", fmt.Sprintf("unknown granularity %q (available: %s, %s, %s)",
+			granularity, usecase.ExportGranularityEntry, usecase.ExportGranularityHourly, usecase.ExportGranularityDaily))
+	}
+
+	for _, metricType := range metricTypes {
+		if metricType != "claude_code" {
+			return domain.ErrInvalidInput("granularity",
+				fmt.Sprintf("%s granularity is only available for claude_code, not %s", granularity, metricType))
+		}
+	}
+	if len(metricTypes) == 0 {
+		return domain.ErrInvalidInput("granularity",
+			fmt.Sprintf("%s granularity is only available for claude_code", granularity))
+	}
+	return nil
+}
+
 // collectCursor collects Cursor metrics
 func (c *MetricsDataCollectorImpl) collectCursor(startTime, endTime time.Time) ([]*entity.MetricRecord, error) {
 	// Check if Cursor service is available
@@ -0,0 +1,93 @@
+package impl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ca-srg/tosage/domain/valueobject"
+	usecase "github.com/ca-srg/tosage/usecase/interface"
+)
+
+func TestMetricsDataCollector_ClaudeCodeGranularity(t *testing.T) {
+	base := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	ccService := &mockCcService{
+		loadCcDataFunc: func(filter usecase.CcDataFilter) (*usecase.CcDataResult, error) {
+			return &usecase.CcDataResult{Entries: []usecase.CcDataEntry{
+				{Timestamp: base.Add(5 * time.Minute), Model: "claude-sonnet-4", InputTokens: 10, OutputTokens: 5, TotalTokens: 15, Cost: 0.5},
+				{Timestamp: base.Add(50 * time.Minute), Model: "claude-opus-4", InputTokens: 20, OutputTokens: 10, TotalTokens: 30, Cost: 1.0},
+				{Timestamp: base.Add(70 * time.Minute), Model: "claude-sonnet-4", InputTokens: 1, OutputTokens: 1, TotalTokens: 2},
+			}}, nil
+		},
+	}
+	collector := NewMetricsDataCollector(ccService, nil, nil, nil, &mockLogger{})
+	start, end := base.Add(-time.Hour), base.Add(3*time.Hour)
+
+	entries, err := collector.CollectWithGranularity(start, end, []string{"claude_code"}, usecase.ExportGranularityEntry, nil)
+	if err != nil {
+		t.Fatalf("CollectWithGranularity(entry) error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("entry records = %d, want 3", len(entries))
+	}
+	if model, _ := entries[1].GetMetadata("model"); model != "claude-opus-4" || entries[1].Value != 30 {
+		t.Errorf("second entry = %v %s, want 30 claude-opus-4", entries[1].Value, model)
+	}
+
+	ist := time.FixedZone("IST", 5*3600+1800)
+	hourStart := time.Date(2024, 1, 1, 14, 0, 0, 0, ist)
+	ccService.calculateDateBreakdownFunc = func(filter usecase.DateBreakdownFilter) (*usecase.DateBreakdownResult, error) {
+		if !filter.Hourly {
+			t.Error("CalculateDateBreakdown() filter.Hourly = false, want true")
+		}
+		return &usecase.DateBreakdownResult{Dates: []usecase.DateBreakdownItem{
+			{Date: "2024-01-01T14:00", Start: hourStart, InputTokens: 30, OutputTokens: 15, TotalTokens: 45, Currency: "USD", EntryCount: 2},
+		}}, nil
+	}
+
+	hours, err := collector.CollectWithGranularity(start, end, []string{"claude_code"}, usecase.ExportGranularityHourly, nil)
+	if err != nil {
+		t.Fatalf("CollectWithGranularity(hourly) error = %v", err)
+	}
+	if len(hours) != 1 {
+		t.Fatalf("hourly records = %d, want 1", len(hours))
+	}
+	if !hours[0].Timestamp.Equal(hourStart) || hours[0].Value != 45 {
+		t.Errorf("hour = %v at %v, want 45 at %v", hours[0].Value, hours[0].Timestamp, hourStart)
+	}
+	if count, _ := hours[0].GetMetadata("entry_count"); count != "2" {
+		t.Errorf("hour entry_count = %s, want 2", count)
+	}
+}
+
+func TestMetricsDataCollector_HashProjectPaths(t *testing.T) {
+	base := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	ccService := &mockCcService{
+		loadCcDataFunc: func(filter usecase.CcDataFilter) (*usecase.CcDataResult, error) {
+			return &usecase.CcDataResult{Entries: []usecase.CcDataEntry{
+				{Timestamp: base, ProjectPath: "/work/secret-client", TotalTokens: 15},
+			}}, nil
+		},
+	}
+	start, end := base.Add(-time.Hour), base.Add(time.Hour)
+
+	tests := []struct {
+		name string
+		opts []MetricsDataCollectorOption
+		want string
+	}{
+		{name: "raw by default", want: "/work/secret-client"},
+		{name: "hashed", opts: []MetricsDataCollectorOption{WithExportedProjectPathsHashed(true)}, want: valueobject.HashProjectPath("/work/secret-client")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := NewMetricsDataCollector(ccService, nil, nil, nil, &mockLogger{}, tt.opts...)
+			records, err := collector.CollectWithGranularity(start, end, []string{"claude_code"}, usecase.ExportGranularityEntry, nil)
+			if err != nil {
+				t.Fatalf("CollectWithGranularity() error = %v", err)
+			}
+			if len(records) != 1 || records[0].Project != tt.want {
+				t.Errorf("records = %+v, want project %q", records, tt.want)
+			}
+		})
+	}
+}
@@ -2,12 +2,18 @@ package impl
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/ca-srg/tosage/domain"
+	"github.com/ca-srg/tosage/domain/entity"
 	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/domain/valueobject"
 	"github.com/ca-srg/tosage/infrastructure/config"
 	usecase "github.com/ca-srg/tosage/usecase/interface"
 )
@@ -20,13 +26,229 @@ type MetricsServiceImpl struct {
 	vertexAIService usecase.VertexAIService
 	metricsRepo     repository.MetricsRepository
 	config          *config.PrometheusConfig
-	ticker          *time.Ticker
 	stopChan        chan struct{}
 	wg              sync.WaitGroup
 	mu              sync.Mutex
 	isRunning       bool
 	logger          domain.Logger
 	timezoneService repository.TimezoneService
+
+	// sourceHostLabels overrides the host label per source (usecase.MetricsSource*)
+	sourceHostLabels map[string]string
+
+	// cursorPremiumRequests enables the Cursor premium request gauges
+	cursorPremiumRequests bool
+
+	// cursorUsageCost enables the Cursor usage-based cost gauges of the current and last billing month
+	cursorUsageCost bool
+
+	// cursorCallCounts enables the Cursor tool call and token-based call gauges
+	cursorCallCounts bool
+	// cursorBillingCycle enables the Cursor billing cycle token gauge
+	cursorBillingCycle bool
+
+	// cursorUsageBasedStatus enables the Cursor usage-based pricing status and spend limit gauges
+	cursorUsageBasedStatus bool
+
+	// vertexAIRequests enables the Vertex AI request count and latency gauges, with a series
+	// per model as well if vertexAIRequestsPerModel is set
+	vertexAIRequests         bool
+	vertexAIRequestsPerModel bool
+
+	// cursorTeamMembers enables tosage_cursor_member_token per team member, if set
+	cursorTeamMembers *cursorTeamMemberFilter
+
+	// ccAllTokens enables tosage_cc_token_all, today's Claude Code total over every token component
+	ccAllTokens bool
+
+	// ccTokensDelta enables tosage_cc_tokens_delta, the Claude Code tokens used since the previous push
+	ccTokensDelta bool
+
+	// ccMaxStaleness skips the Claude Code push when the newest entry is older, if set
+	ccMaxStaleness time.Duration
+
+	// ccSourcePaths enables tosage_cc_token per Claude data directory, hashed if hashSourcePaths is set
+	ccSourcePaths   bool
+	hashSourcePaths bool
+
+	// hashSessionIDs replaces the session label of tosage_cc_session_token with a stable hash
+	hashSessionIDs bool
+
+	// postCollectionHook runs after each successful collection, if one is configured
+	postCollectionHook repository.CollectionHookRepository
+
+	// lastCollected records when each source was last collected, to schedule sources
+	// with their own interval
+	lastCollected map[string]time.Time
+	scheduleMu    sync.Mutex
+
+	// collectMu is held for a whole collection, so cycles started by the timer, signals and
+	// on-demand scrapes don't overlap
+	collectMu sync.Mutex
+
+	// now and after are the clock of the periodic loop, replaced in tests
+	now   func() time.Time
+	after func(d time.Duration) <-chan time.Time
+
+	// circuitState reports the Remote Write circuit breaker state, if one is configured
+	circuitState repository.CircuitStateReporter
+
+	// dailyWindow defines the period the Bedrock, Vertex AI and derived label queries cover.
+	// Claude Code and Cursor services apply the same mode themselves.
+	dailyWindow valueobject.DailyWindowMode
+
+	// Persisted state
+	stateRepo  repository.MetricsStateRepository
+	state      *entity.MetricsState
+	stateDirty bool
+	// sendFailed is set when a send of the current cycle failed, which skips saving the state
+	sendFailed bool
+	stateMu    sync.Mutex
+}
+
+// MetricsServiceOption configures optional dependencies of MetricsServiceImpl
+type MetricsServiceOption func(*MetricsServiceImpl)
+
+// WithMetricsStateRepository persists last-sent metrics through the given repository
+func WithMetricsStateRepository(stateRepo repository.MetricsStateRepository) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		s.stateRepo = stateRepo
+	}
+}
+
+// WithSourceHostLabels overrides the host label of individual sources, keyed by
+// usecase.MetricsSource*. Empty values keep the source's default host label.
+func WithSourceHostLabels(labels map[string]string) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		s.sourceHostLabels = labels
+	}
+}
+
+// WithCircuitStateReporter sends tosage_remote_write_circuit_open after each collection,
+// reporting whether the given circuit breaker is skipping Remote Write pushes
+func WithCircuitStateReporter(reporter repository.CircuitStateReporter) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		s.circuitState = reporter
+	}
+}
+
+// WithMetricsDailyWindowMode sets whether "today" is the calendar day (the default)
+// or the trailing 24 hours
+func WithMetricsDailyWindowMode(mode valueobject.DailyWindowMode) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		s.dailyWindow = mode
+	}
+}
+
+// WithCursorPremiumRequestMetrics sends tosage_cursor_premium_requests and
+// tosage_cursor_premium_requests_limit along with the Cursor token metrics
+func WithCursorPremiumRequestMetrics(enabled bool) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		s.cursorPremiumRequests = enabled
+	}
+}
+
+// WithCursorUsageCostMetrics sends tosage_cursor_usage_cost_cents,
+// tosage_cursor_mid_month_payment_cents and tosage_cursor_unpaid_invoice for the current
+// and last billing month, labeled with the month
+func WithCursorUsageCostMetrics(enabled bool) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		s.cursorUsageCost = enabled
+	}
+}
+
+// WithCursorCallCountMetrics sends tosage_cursor_tool_calls and tosage_cursor_token_based_calls,
+// the calls of each kind billed in the current billing month
+func WithCursorCallCountMetrics(enabled bool) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		s.cursorCallCounts = enabled
+	}
+}
+
+// WithCursorBillingCycleMetric sends tosage_cursor_billing_cycle_token, the tokens used since
+// the billing cycle start reported by Cursor
+func WithCursorBillingCycleMetric(enabled bool) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		s.cursorBillingCycle = enabled
+	}
+}
+
+// WithCursorUsageBasedStatusMetrics sends tosage_cursor_usage_based_enabled, 1 while usage-based
+// pricing is on, and tosage_cursor_spend_limit_dollars, its hard limit when one is set
+func WithCursorUsageBasedStatusMetrics(enabled bool) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		s.cursorUsageBasedStatus = enabled
+	}
+}
+
+// WithVertexAIRequestMetrics sends tosage_vertex_ai_request_count and tosage_vertex_ai_latency_ms,
+// the average latency in milliseconds. With perModel, each model is also sent with a model label.
+func WithVertexAIRequestMetrics(enabled, perModel bool) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		s.vertexAIRequests = enabled
+		s.vertexAIRequestsPerModel = perModel
+	}
+}
+
+// WithCursorTeamMemberMetrics sends tosage_cursor_member_token for every member of the Cursor
+// team, labeled with the member's email, hashed if hashed is set. Only members whose email or
+// name is in allowlist are read (all members when empty), and at most limit of them.
+func WithCursorTeamMemberMetrics(enabled bool, allowlist []string, limit int, hashed bool) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		if !enabled {
+			s.cursorTeamMembers = nil
+			return
+		}
+		s.cursorTeamMembers = newCursorTeamMemberFilter(allowlist, limit, hashed)
+	}
+}
+
+// WithCcAllTokensMetric sends tosage_cc_token_all, today's Claude Code total over every token
+// component, next to tosage_cc_token when the latter only counts some of them
+func WithCcAllTokensMetric(enabled bool) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		s.ccAllTokens = enabled
+	}
+}
+
+// WithCcTokensDeltaMetric enables tosage_cc_tokens_delta, computed from the persisted previous value
+func WithCcTokensDeltaMetric(enabled bool) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		s.ccTokensDelta = enabled
+	}
+}
+
+// WithCcMaxDataStaleness skips the Claude Code push and sends tosage_cc_stale = 1 when the
+// newest entry is older than maxAge. Fresh data sends tosage_cc_stale = 0; zero disables the check.
+func WithCcMaxDataStaleness(maxAge time.Duration) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		s.ccMaxStaleness = maxAge
+	}
+}
+
+// WithCcSourcePathMetrics sends tosage_cc_source_path_token per Claude data directory with a source_path
+// label, replacing the path with a stable hash if hashed is set
+func WithCcSourcePathMetrics(enabled, hashed bool) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		s.ccSourcePaths = enabled
+		s.hashSourcePaths = hashed
+	}
+}
+
+// WithHashedSessionIDs replaces the session label of tosage_cc_session_token with a stable hash,
+// in addition to the Prometheus hash_session_ids setting
+func WithHashedSessionIDs(enabled bool) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		s.hashSessionIDs = enabled
+	}
+}
+
+// WithPostCollectionHook runs hook after each successful collection with the values sent, whether
+// the collection was periodic, requested by the daemon or triggered by a scrape
+func WithPostCollectionHook(hook repository.CollectionHookRepository) MetricsServiceOption {
+	return func(s *MetricsServiceImpl) {
+		s.postCollectionHook = hook
+	}
 }
 
 // NewMetricsServiceImpl creates a new metrics service implementation
@@ -39,8 +261,9 @@ func NewMetricsServiceImpl(
 	config *config.PrometheusConfig,
 	logger domain.Logger,
 	timezoneService repository.TimezoneService,
+	opts ...MetricsServiceOption,
 ) usecase.MetricsService {
-	return &MetricsServiceImpl{
+	s := &MetricsServiceImpl{
 		ccService:       ccService,
 		cursorService:   cursorService,
 		bedrockService:  bedrockService,
@@ -51,7 +274,16 @@ func NewMetricsServiceImpl(
 		isRunning:       false,
 		logger:          logger,
 		timezoneService: timezoneService,
+		now:             time.Now,
+		after:           time.After,
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+	s.loadState()
+
+	return s
 }
 
 // StartPeriodicMetrics starts the periodic metrics collection
@@ -77,20 +309,17 @@ func (s *MetricsServiceImpl) StartPeriodicMetrics() error {
 			domain.NewField("interval_sec", s.config.IntervalSec))
 	}
 
-	// Send initial metrics
-	if err := s.sendMetrics(); err != nil {
-		ctx := context.Background()
-		s.logger.Warn(ctx, "Failed to send initial metrics", domain.NewField("error", err.Error()))
-		// Don't fail startup due to metrics error
+	// Send initial metrics now, unless a grace period defers them to the periodic loop
+	graceful := s.config.InitialDelaySec > 0 || s.config.NetworkWaitSec > 0
+	if !graceful {
+		s.sendInitialMetrics()
 	}
 
-	// Start ticker for periodic collection
-	s.ticker = time.NewTicker(time.Duration(s.config.IntervalSec) * time.Second)
 	s.isRunning = true
 
 	// Start goroutine for periodic metrics
 	s.wg.Add(1)
-	go s.runPeriodicMetrics()
+	go s.runPeriodicMetrics(graceful)
 
 	return nil
 }
@@ -104,11 +333,6 @@ func (s *MetricsServiceImpl) StopPeriodicMetrics() error {
 		return nil
 	}
 
-	// Stop ticker
-	if s.ticker != nil {
-		s.ticker.Stop()
-	}
-
 	// Signal goroutine to stop
 	close(s.stopChan)
 
@@ -118,7 +342,7 @@ func (s *MetricsServiceImpl) StopPeriodicMetrics() error {
 	// Send final metrics before stopping
 	if err := s.sendMetrics(); err != nil {
 		ctx := context.Background()
-		s.logger.Warn(ctx, "Failed to send final metrics", domain.NewField("error", err.Error()))
+		s.logSendFailure(ctx, "Failed to send final metrics", err)
 		// Don't fail shutdown due to metrics error
 	}
 
@@ -133,155 +357,433 @@ func (s *MetricsServiceImpl) SendCurrentMetrics() error {
 	return s.sendMetrics()
 }
 
-// runPeriodicMetrics runs the periodic metrics collection loop
-func (s *MetricsServiceImpl) runPeriodicMetrics() {
+// SendCurrentMetricsWithReport sends the current metrics immediately and reports what was sent
+func (s *MetricsServiceImpl) SendCurrentMetricsWithReport() (*usecase.MetricsSendReport, error) {
+	return s.sendMetricsWithReport()
+}
+
+// SendCurrentMetricsIfIdle sends the current metrics unless a collection is already running.
+// Otherwise it waits for that collection to finish and returns false without starting another.
+func (s *MetricsServiceImpl) SendCurrentMetricsIfIdle() (bool, error) {
+	if !s.collectMu.TryLock() {
+		s.collectMu.Lock()
+		s.collectMu.Unlock()
+		return false, nil
+	}
+	defer s.collectMu.Unlock()
+
+	_, err := s.collectAndSendLocked(s.allSourcesDue(s.now()))
+	return true, err
+}
+
+// SendDueMetrics sends the metrics of the sources whose collection interval has elapsed
+func (s *MetricsServiceImpl) SendDueMetrics() error {
+	_, err := s.collectAndSend(s.dueSources(s.now()))
+	return err
+}
+
+// NextSendTime returns the next cron schedule match after the given time. Without a schedule
+// it returns the next tick interval boundary when AlignToInterval is set, or one tick later.
+func (s *MetricsServiceImpl) NextSendTime(after time.Time) time.Time {
+	if schedule := s.cronSchedule(); schedule != nil {
+		return schedule.Next(after)
+	}
+	if s.config.AlignToInterval {
+		return nextAlignedTick(after, s.tickInterval())
+	}
+	return after.Add(s.tickInterval())
+}
+
+// sendInitialMetrics sends the first metrics after startup
+func (s *MetricsServiceImpl) sendInitialMetrics() {
+	if err := s.sendMetrics(); err != nil {
+		ctx := context.Background()
+		s.logSendFailure(ctx, "Failed to send initial metrics", err)
+		// Don't fail startup due to metrics error
+	}
+}
+
+// runPeriodicMetrics runs the periodic metrics collection loop until the service is stopped,
+// sending at each NextSendTime. With sendInitial set it first waits out the startup grace
+// period and sends the initial metrics.
+func (s *MetricsServiceImpl) runPeriodicMetrics(sendInitial bool) {
 	defer s.wg.Done()
 
-	for {
-		select {
-		case <-s.ticker.C:
-			if err := s.sendMetrics(); err != nil {
-				ctx := context.Background()
-				s.logger.Warn(ctx, "Failed to send periodic metrics", domain.NewField("error", err.Error()))
-				// Continue running even if metrics fail
-			}
-		case <-s.stopChan:
+	if sendInitial {
+		if !s.waitBeforeFirstPush(context.Background()) {
+			return
+		}
+		s.sendInitialMetrics()
+	}
+
+	next := s.NextSendTime(s.now())
+	for !next.IsZero() {
+		if !s.sleep(context.Background(), next.Sub(s.now())) {
 			return
 		}
+		s.sendPeriodicMetrics()
+
+		// Count from the planned send so slow collections don't shift the schedule,
+		// skipping sends missed while collecting
+		now := s.now()
+		if next = s.NextSendTime(next); next.Before(now) {
+			next = s.NextSendTime(now)
+		}
+	}
+}
+
+// cronSchedule returns the configured cron schedule, or nil to send every interval.
+// An invalid schedule, which config validation rejects, falls back to the interval.
+func (s *MetricsServiceImpl) cronSchedule() *valueobject.CronSchedule {
+	if s.config.Schedule == "" {
+		return nil
+	}
+	schedule, err := valueobject.ParseCronSchedule(s.config.Schedule)
+	if err != nil {
+		s.logger.Warn(context.Background(), "Invalid metrics schedule, sending every interval instead",
+			domain.NewField("error", err.Error()))
+		return nil
+	}
+	return schedule
+}
+
+// networkPollInterval is how often the metrics backend is checked while waiting for the network
+const networkPollInterval = 2 * time.Second
+
+// WaitBeforeFirstPush waits out the startup grace period before the first push, for callers
+// that run their own schedule. Returns false if ctx is done first.
+func (s *MetricsServiceImpl) WaitBeforeFirstPush(ctx context.Context) bool {
+	return s.waitBeforeFirstPush(ctx)
+}
+
+// waitBeforeFirstPush waits InitialDelaySec, then up to NetworkWaitSec for the metrics backend
+// to become reachable. A backend that is still unreachable is logged and pushed to anyway.
+// Returns false if ctx is done or the service was stopped while waiting.
+func (s *MetricsServiceImpl) waitBeforeFirstPush(ctx context.Context) bool {
+	if s.config.InitialDelaySec > 0 {
+		s.logger.Info(ctx, "Delaying the first metrics push", domain.NewField("delay_sec", s.config.InitialDelaySec))
+		if !s.sleep(ctx, time.Duration(s.config.InitialDelaySec)*time.Second) {
+			return false
+		}
+	}
+
+	checker, ok := s.metricsRepo.(repository.ConnectionChecker)
+	if s.config.NetworkWaitSec <= 0 || !ok {
+		return true
+	}
+
+	deadline := s.now().Add(time.Duration(s.config.NetworkWaitSec) * time.Second)
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, deadline.Sub(s.now()))
+		err := checker.CheckConnection(checkCtx)
+		cancel()
+		if err == nil {
+			return true
+		}
+
+		remaining := deadline.Sub(s.now())
+		if remaining <= 0 {
+			s.logger.Warn(ctx, "Metrics backend still unreachable, sending the first metrics anyway",
+				domain.NewField("waited_sec", s.config.NetworkWaitSec),
+				domain.NewField("error", err.Error()))
+			return true
+		}
+		s.logger.Debug(ctx, "Waiting for the metrics backend to become reachable", domain.NewField("error", err.Error()))
+		if !s.sleep(ctx, min(networkPollInterval, remaining)) {
+			return false
+		}
+	}
+}
+
+// sleep waits for d and returns false if ctx is done or the service is stopped first
+func (s *MetricsServiceImpl) sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-s.after(d):
+		return true
+	case <-ctx.Done():
+		return false
+	case <-s.stopChan:
+		return false
+	}
+}
+
+// sendPeriodicMetrics sends metrics for one tick of the periodic loop
+func (s *MetricsServiceImpl) sendPeriodicMetrics() {
+	if err := s.SendDueMetrics(); err != nil {
+		ctx := context.Background()
+		s.logSendFailure(ctx, "Failed to send periodic metrics", err)
+		// Continue running even if metrics fail
+	}
+}
+
+// runPostCollectionHook passes the values sent in report to the post-collection hook and
+// logs its output. The hook's own timeout bounds how long the collection waits for it.
+func (s *MetricsServiceImpl) runPostCollectionHook(report *usecase.MetricsSendReport) {
+	if s.postCollectionHook == nil || report == nil {
+		return
+	}
+
+	metrics := make(map[string]float64)
+	for _, result := range report.Results {
+		if result.Sent {
+			metrics[result.MetricName] = result.Value
+		}
+	}
+
+	if len(metrics) == 0 {
+		// No source was due this tick
+		return
+	}
+
+	ctx := context.Background()
+	output, err := s.postCollectionHook.Run(ctx, report.SentAt, metrics)
+	if err != nil {
+		s.logger.Warn(ctx, "Post-collection hook failed",
+			domain.NewField("error", err.Error()),
+			domain.NewField("output", output))
+		return
+	}
+	if output != "" {
+		s.logger.Info(ctx, "Post-collection hook output", domain.NewField("output", output))
+	}
+}
+
+// metricsSources lists the sources that can be given their own collection interval
+var metricsSources = []string{
+	usecase.MetricsSourceClaudeCode,
+	usecase.MetricsSourceCursor,
+	usecase.MetricsSourceBedrock,
+	usecase.MetricsSourceVertexAI,
+}
+
+// sourceInterval returns how often source is collected: its SourceIntervalSec entry,
+// or the global interval
+func (s *MetricsServiceImpl) sourceInterval(source string) time.Duration {
+	if sec := s.config.SourceIntervalSec[source]; sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return time.Duration(s.config.IntervalSec) * time.Second
+}
+
+// tickInterval returns the shortest source interval, which the periodic loop ticks at.
+// Sources with a longer interval are skipped on ticks before they are due.
+func (s *MetricsServiceImpl) tickInterval() time.Duration {
+	tick := time.Duration(s.config.IntervalSec) * time.Second
+	for _, source := range metricsSources {
+		tick = min(tick, s.sourceInterval(source))
+	}
+	return tick
+}
+
+// dueSources returns which sources are due for collection at now and marks them collected.
+// Half a tick of slack keeps ticker jitter from pushing a source back by a whole tick.
+func (s *MetricsServiceImpl) dueSources(now time.Time) func(source string) bool {
+	s.scheduleMu.Lock()
+	defer s.scheduleMu.Unlock()
+
+	if s.lastCollected == nil {
+		s.lastCollected = make(map[string]time.Time)
 	}
+	slack := s.tickInterval() / 2
+	due := make(map[string]bool, len(metricsSources))
+	for _, source := range metricsSources {
+		last, ok := s.lastCollected[source]
+		if !ok || now.Sub(last) >= s.sourceInterval(source)-slack {
+			due[source] = true
+			s.lastCollected[source] = now
+		}
+	}
+	return func(source string) bool { return due[source] }
+}
+
+// allSourcesDue marks every source collected at now, for collections outside the schedule
+// such as the initial and final push
+func (s *MetricsServiceImpl) allSourcesDue(now time.Time) func(source string) bool {
+	s.scheduleMu.Lock()
+	defer s.scheduleMu.Unlock()
+
+	if s.lastCollected == nil {
+		s.lastCollected = make(map[string]time.Time)
+	}
+	for _, source := range metricsSources {
+		s.lastCollected[source] = now
+	}
+	return func(string) bool { return true }
+}
+
+// nextAlignedTick returns the first multiple of interval strictly after now.
+// Boundaries are counted from the zero time, so intervals that divide an hour
+// land on wall clock marks such as :00, :10, :20.
+func nextAlignedTick(now time.Time, interval time.Duration) time.Time {
+	if interval <= 0 {
+		return now
+	}
+	return now.Truncate(interval).Add(interval)
 }
 
 // sendMetrics calculates and sends the current metrics
 func (s *MetricsServiceImpl) sendMetrics() error {
+	_, err := s.sendMetricsWithReport()
+	return err
+}
+
+// sendMetricsWithReport calculates and sends the current metrics of every source, recording the outcome per metric
+func (s *MetricsServiceImpl) sendMetricsWithReport() (*usecase.MetricsSendReport, error) {
+	return s.collectAndSend(s.allSourcesDue(s.now()))
+}
+
+// collectAndSend calculates and sends the current metrics of the sources due reports true for,
+// waiting for a collection that is already running to finish first
+func (s *MetricsServiceImpl) collectAndSend(due func(source string) bool) (*usecase.MetricsSendReport, error) {
+	s.collectMu.Lock()
+	defer s.collectMu.Unlock()
+
+	return s.collectAndSendLocked(due)
+}
+
+// collectAndSendLocked runs a collection with collectMu held
+func (s *MetricsServiceImpl) collectAndSendLocked(due func(source string) bool) (*usecase.MetricsSendReport, error) {
 	ctx := context.Background()
+	report := usecase.NewMetricsSendReport(time.Now())
+	defer s.saveState()
+
+	// Time each source's collection and report it once the cycle is done
+	durations := make(map[string]time.Duration)
+	defer s.sendCollectionDurations(ctx, durations)
+	defer s.sendCircuitState(ctx)
 
-	// Claude Code metrics if ClaudeService is available
-	if s.ccService != nil {
+	// The heartbeat goes first so it is sent even when every source fails
+	s.sendHeartbeat(ctx)
+
+	// Claude Code metrics if ClaudeService is available and its data isn't stale
+	if s.ccService != nil && due(usecase.MetricsSourceClaudeCode) && !s.ccDataStale(ctx, report) {
 		// Calculate today's tokens
+		start := time.Now()
 		totalTokens, err := s.ccService.CalculateTodayTokens()
+		durations[usecase.MetricsSourceClaudeCode] = time.Since(start)
 		if err != nil {
-			return fmt.Errorf("failed to calculate today's tokens: %w", err)
+			err = fmt.Errorf("failed to calculate today's tokens: %w", err)
+			report.AddFailure(usecase.MetricsSourceClaudeCode, "tosage_cc_token", err)
+			return report, err
 		}
 
 		// Send metrics to Prometheus
-		if s.timezoneService != nil {
-			// Send with timezone information
-			timezoneInfo := s.timezoneService.GetTimezoneInfo()
-			if err := s.metricsRepo.SendTokenMetricWithTimezone(totalTokens, s.config.HostLabel, "tosage_cc_token", timezoneInfo); err != nil {
-				return fmt.Errorf("failed to send token metric with timezone: %w", err)
-			}
-		} else {
-			// Fall back to sending without timezone information
-			if err := s.metricsRepo.SendTokenMetric(totalTokens, s.config.HostLabel, "tosage_cc_token"); err != nil {
-				return fmt.Errorf("failed to send token metric: %w", err)
-			}
+		if err := s.sendTokenMetric(report, usecase.MetricsSourceClaudeCode, totalTokens, s.hostLabelFor(usecase.MetricsSourceClaudeCode), "tosage_cc_token"); err != nil {
+			return report, fmt.Errorf("failed to send token metric: %w", err)
 		}
 
 		s.logger.Info(ctx, "Successfully sent Claude Code metrics", domain.NewField("tokens", totalTokens))
+
+		if s.ccAllTokens {
+			s.sendCcAllTokens(ctx, report)
+		}
+		if s.ccTokensDelta {
+			s.sendCcTokensDelta(ctx, report, totalTokens)
+		}
+		s.sendCcLastEntryAge(ctx)
+
+		// Today's entries feed the derived labels, the unique counts and the session and source
+		// path metrics, so they are loaded once
+		if entries, ok := s.ccTodayEntries(ctx); ok {
+			activity := summarizeCcActivity(entries)
+			if labels := s.derivedCcLabels(activity); len(labels) > 0 {
+				s.sendCcInfo(ctx, report, labels)
+			}
+			s.sendCcSessionMetrics(ctx, entries)
+			if s.config.SessionPercentiles {
+				s.sendCcSessionPercentiles(ctx, entries)
+			}
+			if s.config.UniqueCountMetrics {
+				s.sendCcUniqueCounts(ctx, activity)
+			}
+			if s.ccSourcePaths {
+				s.sendCcSourcePathMetrics(ctx, report, entries)
+			}
+		}
 	}
 
 	// Send Cursor metrics if CursorService is available
-	if s.cursorService != nil {
+	if s.cursorService != nil && due(usecase.MetricsSourceCursor) {
 		// Get aggregated token usage from JST 00:00 to current time
-		totalTokens, err := s.cursorService.GetAggregatedTokenUsage()
+		start := time.Now()
+		totalTokens, err := s.cursorDailyTokens()
+		durations[usecase.MetricsSourceCursor] = time.Since(start)
 		if err != nil {
 			// Log error but don't fail the entire metrics operation
 			s.logger.Warn(ctx, "Failed to get Cursor token usage", domain.NewField("error", err.Error()))
+			report.AddFailure(usecase.MetricsSourceCursor, "tosage_cursor_token", err)
 		} else {
-			// Send Cursor token metric
-			if s.timezoneService != nil {
-				// Send with timezone information
-				timezoneInfo := s.timezoneService.GetTimezoneInfo()
-				if err := s.metricsRepo.SendTokenMetricWithTimezone(int(totalTokens), s.config.HostLabel, "tosage_cursor_token", timezoneInfo); err != nil {
-					// Log error but don't fail the entire metrics operation
-					s.logger.Warn(ctx, "Failed to send Cursor metrics with timezone", domain.NewField("error", err.Error()))
-				} else {
-					s.logger.Info(ctx, "Successfully sent Cursor metrics",
-						domain.NewField("total_tokens", totalTokens),
-						domain.NewField("period", "JST 00:00 to now"))
-				}
+			if err := s.sendTokenMetric(report, usecase.MetricsSourceCursor, totalTokens, s.hostLabelFor(usecase.MetricsSourceCursor), "tosage_cursor_token"); err != nil {
+				// Log error but don't fail the entire metrics operation
+				s.logSendFailure(ctx, "Failed to send Cursor metrics", err)
 			} else {
-				// Fall back to sending without timezone information
-				if err := s.metricsRepo.SendTokenMetric(int(totalTokens), s.config.HostLabel, "tosage_cursor_token"); err != nil {
-					// Log error but don't fail the entire metrics operation
-					s.logger.Warn(ctx, "Failed to send Cursor metrics", domain.NewField("error", err.Error()))
-				} else {
-					s.logger.Info(ctx, "Successfully sent Cursor metrics",
-						domain.NewField("total_tokens", totalTokens),
-						domain.NewField("period", "JST 00:00 to now"))
-				}
+				s.logger.Info(ctx, "Successfully sent Cursor metrics",
+					domain.NewField("total_tokens", totalTokens),
+					domain.NewField("period", s.dailyPeriod("JST 00:00 to now")))
 			}
+			s.sendCursorBillingPeriodMetric(ctx, report, durations)
+		}
+
+		if s.cursorBillingCycle {
+			s.sendCursorBillingCycleMetric(ctx, report, durations)
+		}
+
+		if s.cursorPremiumRequests || s.cursorUsageCost || s.cursorCallCounts {
+			s.sendCursorUsageMetrics(ctx, report, durations)
 		}
+		if s.cursorUsageBasedStatus {
+			s.sendCursorUsageBasedStatusMetrics(ctx, report, durations)
+		}
+		if s.cursorTeamMembers != nil {
+			s.sendCursorTeamMemberMetrics(ctx, report, durations)
+		}
+		s.sendCursorParseStatus(ctx, report)
 	}
 
 	// Send Bedrock metrics if BedrockService is available and enabled
-	if s.bedrockService != nil && s.bedrockService.IsEnabled() {
+	if s.bedrockService != nil && s.bedrockService.IsEnabled() && due(usecase.MetricsSourceBedrock) {
 		// Get today's Bedrock usage
-		jst, _ := time.LoadLocation("Asia/Tokyo")
-		today := time.Now().In(jst)
-		bedrockUsage, err := s.bedrockService.GetDailyUsage(today)
+		start := time.Now()
+		bedrockUsage, err := s.bedrockDailyUsage()
+		durations[usecase.MetricsSourceBedrock] = time.Since(start)
 		if err != nil {
 			// Log error but don't fail the entire metrics operation
 			s.logger.Warn(ctx, "Failed to get Bedrock usage", domain.NewField("error", err.Error()))
+			report.AddFailure(usecase.MetricsSourceBedrock, "", err)
 		} else if bedrockUsage != nil && !bedrockUsage.IsEmpty() {
 			// Send Bedrock token metrics (separate input/output metrics)
-			if s.timezoneService != nil {
-				timezoneInfo := s.timezoneService.GetTimezoneInfo()
-
-				// Send input tokens
-				if err := s.metricsRepo.SendTokenMetricWithTimezone(int(bedrockUsage.InputTokens()), "", "tosage_bedrock_input_token", timezoneInfo); err != nil {
-					s.logger.Warn(ctx, "Failed to send Bedrock input token metrics", domain.NewField("error", err.Error()))
-				}
-
-				// Send output tokens
-				if err := s.metricsRepo.SendTokenMetricWithTimezone(int(bedrockUsage.OutputTokens()), "", "tosage_bedrock_output_token", timezoneInfo); err != nil {
-					s.logger.Warn(ctx, "Failed to send Bedrock output token metrics", domain.NewField("error", err.Error()))
-				}
-
-				// Send total tokens
-				if err := s.metricsRepo.SendTokenMetricWithTimezone(int(bedrockUsage.TotalTokens()), "", "tosage_bedrock_total_token", timezoneInfo); err != nil {
-					s.logger.Warn(ctx, "Failed to send Bedrock total token metrics", domain.NewField("error", err.Error()))
-				} else {
-					s.logger.Info(ctx, "Successfully sent Bedrock metrics",
-						domain.NewField("input_tokens", bedrockUsage.InputTokens()),
-						domain.NewField("output_tokens", bedrockUsage.OutputTokens()),
-						domain.NewField("total_tokens", bedrockUsage.TotalTokens()),
-						domain.NewField("total_cost", bedrockUsage.TotalCost()),
-						domain.NewField("period", "JST today"))
-				}
+			if err := s.sendTokenMetric(report, usecase.MetricsSourceBedrock, int64(bedrockUsage.InputTokens()), s.hostLabelFor(usecase.MetricsSourceBedrock), "tosage_bedrock_input_token"); err != nil {
+				s.logSendFailure(ctx, "Failed to send Bedrock input token metrics", err)
+			}
+			if err := s.sendTokenMetric(report, usecase.MetricsSourceBedrock, int64(bedrockUsage.OutputTokens()), s.hostLabelFor(usecase.MetricsSourceBedrock), "tosage_bedrock_output_token"); err != nil {
+				s.logSendFailure(ctx, "Failed to send Bedrock output token metrics", err)
+			}
+			if err := s.sendTokenMetric(report, usecase.MetricsSourceBedrock, int64(bedrockUsage.TotalTokens()), s.hostLabelFor(usecase.MetricsSourceBedrock), "tosage_bedrock_total_token"); err != nil {
+				s.logSendFailure(ctx, "Failed to send Bedrock total token metrics", err)
 			} else {
-				// Fall back to sending without timezone information
-				if err := s.metricsRepo.SendTokenMetric(int(bedrockUsage.InputTokens()), "", "tosage_bedrock_input_token"); err != nil {
-					s.logger.Warn(ctx, "Failed to send Bedrock input token metrics", domain.NewField("error", err.Error()))
-				}
-				if err := s.metricsRepo.SendTokenMetric(int(bedrockUsage.OutputTokens()), "", "tosage_bedrock_output_token"); err != nil {
-					s.logger.Warn(ctx, "Failed to send Bedrock output token metrics", domain.NewField("error", err.Error()))
-				}
-				if err := s.metricsRepo.SendTokenMetric(int(bedrockUsage.TotalTokens()), "", "tosage_bedrock_total_token"); err != nil {
-					s.logger.Warn(ctx, "Failed to send Bedrock total token metrics", domain.NewField("error", err.Error()))
-				} else {
-					s.logger.Info(ctx, "Successfully sent Bedrock metrics",
-						domain.NewField("input_tokens", bedrockUsage.InputTokens()),
-						domain.NewField("output_tokens", bedrockUsage.OutputTokens()),
-						domain.NewField("total_tokens", bedrockUsage.TotalTokens()),
-						domain.NewField("total_cost", bedrockUsage.TotalCost()),
-						domain.NewField("period", "JST today"))
-				}
+				s.logger.Info(ctx, "Successfully sent Bedrock metrics",
+					domain.NewField("input_tokens", bedrockUsage.InputTokens()),
+					domain.NewField("output_tokens", bedrockUsage.OutputTokens()),
+					domain.NewField("total_tokens", bedrockUsage.TotalTokens()),
+					domain.NewField("total_cost", bedrockUsage.TotalCost()),
+					domain.NewField("period", s.dailyPeriod("JST today")))
 			}
+			s.sendBedrockModelMetrics(ctx, report, bedrockUsage)
 		}
 	}
 
 	// Send Vertex AI metrics if VertexAIService is available and enabled
-	if s.vertexAIService != nil && s.vertexAIService.IsEnabled() {
+	if s.vertexAIService != nil && s.vertexAIService.IsEnabled() && due(usecase.MetricsSourceVertexAI) {
 		s.logger.Info(ctx, "Checking Vertex AI metrics",
 			domain.NewField("service_enabled", s.vertexAIService.IsEnabled()))
 		// Get today's Vertex AI usage
-		jst, _ := time.LoadLocation("Asia/Tokyo")
-		today := time.Now().In(jst)
-		vertexAIUsage, err := s.vertexAIService.GetDailyUsage(today)
+		start := time.Now()
+		vertexAIUsage, err := s.vertexAIDailyUsage()
+		durations[usecase.MetricsSourceVertexAI] = time.Since(start)
 		if err != nil {
 			// Log error but don't fail the entire metrics operation
 			s.logger.Warn(ctx, "Failed to get Vertex AI usage", domain.NewField("error", err.Error()))
+			report.AddFailure(usecase.MetricsSourceVertexAI, "", err)
 		} else if vertexAIUsage != nil {
 			s.logger.Info(ctx, "Vertex AI usage retrieved",
 				domain.NewField("is_empty", vertexAIUsage.IsEmpty()),
@@ -290,52 +792,1045 @@ func (s *MetricsServiceImpl) sendMetrics() error {
 				domain.NewField("total_tokens", vertexAIUsage.TotalTokens()))
 			if !vertexAIUsage.IsEmpty() {
 				// Send Vertex AI token metrics (separate input/output metrics)
-				if s.timezoneService != nil {
-					timezoneInfo := s.timezoneService.GetTimezoneInfo()
-
-					// Send input tokens
-					if err := s.metricsRepo.SendTokenMetricWithTimezone(int(vertexAIUsage.InputTokens()), "", "tosage_vertex_ai_input_token", timezoneInfo); err != nil {
-						s.logger.Warn(ctx, "Failed to send Vertex AI input token metrics", domain.NewField("error", err.Error()))
-					}
-
-					// Send output tokens
-					if err := s.metricsRepo.SendTokenMetricWithTimezone(int(vertexAIUsage.OutputTokens()), "", "tosage_vertex_ai_output_token", timezoneInfo); err != nil {
-						s.logger.Warn(ctx, "Failed to send Vertex AI output token metrics", domain.NewField("error", err.Error()))
-					}
-
-					// Send total tokens
-					if err := s.metricsRepo.SendTokenMetricWithTimezone(int(vertexAIUsage.TotalTokens()), "", "tosage_vertex_ai_total_token", timezoneInfo); err != nil {
-						s.logger.Warn(ctx, "Failed to send Vertex AI total token metrics", domain.NewField("error", err.Error()))
-					} else {
-						s.logger.Info(ctx, "Successfully sent Vertex AI metrics",
-							domain.NewField("input_tokens", vertexAIUsage.InputTokens()),
-							domain.NewField("output_tokens", vertexAIUsage.OutputTokens()),
-							domain.NewField("total_tokens", vertexAIUsage.TotalTokens()),
-							domain.NewField("total_cost", vertexAIUsage.TotalCost()),
-							domain.NewField("period", "JST today"))
-					}
+				if err := s.sendTokenMetric(report, usecase.MetricsSourceVertexAI, int64(vertexAIUsage.InputTokens()), s.hostLabelFor(usecase.MetricsSourceVertexAI), "tosage_vertex_ai_input_token"); err != nil {
+					s.logSendFailure(ctx, "Failed to send Vertex AI input token metrics", err)
+				}
+				if err := s.sendTokenMetric(report, usecase.MetricsSourceVertexAI, int64(vertexAIUsage.OutputTokens()), s.hostLabelFor(usecase.MetricsSourceVertexAI), "tosage_vertex_ai_output_token"); err != nil {
+					s.logSendFailure(ctx, "Failed to send Vertex AI output token metrics", err)
+				}
+				if err := s.sendTokenMetric(report, usecase.MetricsSourceVertexAI, int64(vertexAIUsage.TotalTokens()), s.hostLabelFor(usecase.MetricsSourceVertexAI), "tosage_vertex_ai_total_token"); err != nil {
+					s.logSendFailure(ctx, "Failed to send Vertex AI total token metrics", err)
 				} else {
-					// Fall back to sending without timezone information
-					if err := s.metricsRepo.SendTokenMetric(int(vertexAIUsage.InputTokens()), "", "tosage_vertex_ai_input_token"); err != nil {
-						s.logger.Warn(ctx, "Failed to send Vertex AI input token metrics", domain.NewField("error", err.Error()))
-					}
-					if err := s.metricsRepo.SendTokenMetric(int(vertexAIUsage.OutputTokens()), "", "tosage_vertex_ai_output_token"); err != nil {
-						s.logger.Warn(ctx, "Failed to send Vertex AI output token metrics", domain.NewField("error", err.Error()))
-					}
-					if err := s.metricsRepo.SendTokenMetric(int(vertexAIUsage.TotalTokens()), "", "tosage_vertex_ai_total_token"); err != nil {
-						s.logger.Warn(ctx, "Failed to send Vertex AI total token metrics", domain.NewField("error", err.Error()))
-					} else {
-						s.logger.Info(ctx, "Successfully sent Vertex AI metrics",
-							domain.NewField("input_tokens", vertexAIUsage.InputTokens()),
-							domain.NewField("output_tokens", vertexAIUsage.OutputTokens()),
-							domain.NewField("total_tokens", vertexAIUsage.TotalTokens()),
-							domain.NewField("total_cost", vertexAIUsage.TotalCost()),
-							domain.NewField("period", "JST today"))
-					}
+					s.logger.Info(ctx, "Successfully sent Vertex AI metrics",
+						domain.NewField("input_tokens", vertexAIUsage.InputTokens()),
+						domain.NewField("output_tokens", vertexAIUsage.OutputTokens()),
+						domain.NewField("total_tokens", vertexAIUsage.TotalTokens()),
+						domain.NewField("total_cost", vertexAIUsage.TotalCost()),
+						domain.NewField("period", s.dailyPeriod("JST today")))
 				}
+				s.sendVertexAIModelMetrics(ctx, report, vertexAIUsage)
+			}
+			if s.vertexAIRequests {
+				s.sendVertexAIRequestMetrics(ctx, report, vertexAIUsage)
 			}
 		}
 	}
 
-	return nil
+	s.sendLastCollectionTimestamp(ctx, due)
+	s.runPostCollectionHook(report)
+
+	return report, nil
+}
+
+// bedrockDailyUsage returns today's Bedrock usage: the JST calendar day, or the trailing
+// 24 hours in rolling mode
+func (s *MetricsServiceImpl) bedrockDailyUsage() (*entity.BedrockUsage, error) {
+	now := time.Now()
+	if s.dailyWindow.IsRolling() {
+		return s.bedrockService.GetUsageInRange(valueobject.RollingWindowStart(now), now)
+	}
+	jst, _ := time.LoadLocation("Asia/Tokyo")
+	return s.bedrockService.GetDailyUsage(now.In(jst))
+}
+
+// vertexAIDailyUsage returns today's Vertex AI usage: the JST calendar day, or the trailing
+// 24 hours in rolling mode
+func (s *MetricsServiceImpl) vertexAIDailyUsage() (*entity.VertexAIUsage, error) {
+	now := time.Now()
+	if s.dailyWindow.IsRolling() {
+		return s.vertexAIService.GetUsageInRange(valueobject.RollingWindowStart(now), now)
+	}
+	jst, _ := time.LoadLocation("Asia/Tokyo")
+	return s.vertexAIService.GetDailyUsage(now.In(jst))
+}
+
+// dailyPeriod describes the daily window for logs; calendar describes the calendar day
+func (s *MetricsServiceImpl) dailyPeriod(calendar string) string {
+	if s.dailyWindow.IsRolling() {
+		return "last 24 hours"
+	}
+	return calendar
+}
+
+// ccTodayEntries loads today's Claude Code entries for the metrics computed from them.
+// Reports false if none of those metrics is enabled or the entries could not be loaded.
+func (s *MetricsServiceImpl) ccTodayEntries(ctx context.Context) ([]usecase.CcDataEntry, bool) {
+	if len(s.config.DerivedLabels) == 0 && !s.config.UniqueCountMetrics && s.config.SessionMetricsTopN <= 0 &&
+		!s.config.SessionPercentiles && !s.ccSourcePaths {
+		return nil, false
+	}
+
+	now := time.Now()
+	dayStart := s.ccDayStart(now)
+	data, err := s.ccService.LoadCcData(usecase.CcDataFilter{StartDate: &dayStart, EndDate: &now})
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to load today's Claude Code entries", domain.NewField("error", err.Error()))
+		return nil, false
+	}
+	return data.Entries, true
+}
+
+// ccActivity is how broad a set of Claude Code entries is
+type ccActivity struct {
+	mostUsedModel  string
+	uniqueProjects int
+	uniqueModels   int
+	uniqueSessions int
+}
+
+// summarizeCcActivity counts the distinct projects, models and sessions of entries and finds
+// the model with the most tokens. Empty values are not counted.
+func summarizeCcActivity(entries []usecase.CcDataEntry) ccActivity {
+	projects := make(map[string]bool)
+	sessions := make(map[string]bool)
+	modelTokens := make(map[string]int)
+	for _, entry := range entries {
+		if entry.ProjectPath != "" {
+			projects[entry.ProjectPath] = true
+		}
+		if entry.SessionID != "" {
+			sessions[entry.SessionID] = true
+		}
+		if entry.Model != "" {
+			modelTokens[entry.Model] += entry.TotalTokens
+		}
+	}
+
+	activity := ccActivity{
+		uniqueProjects: len(projects),
+		uniqueModels:   len(modelTokens),
+		uniqueSessions: len(sessions),
+	}
+	maxTokens := -1
+	for model, tokens := range modelTokens {
+		// Ties go to the first model by name so the label does not flap between cycles
+		if tokens > maxTokens || (tokens == maxTokens && model < activity.mostUsedModel) {
+			maxTokens = tokens
+			activity.mostUsedModel = model
+		}
+	}
+	return activity
+}
+
+// derivedCcLabels computes the configured derived labels from today's Claude Code activity.
+// Counts are bucketed so the labels stay low-cardinality. Returns nil if none are configured.
+func (s *MetricsServiceImpl) derivedCcLabels(activity ccActivity) map[string]string {
+	if len(s.config.DerivedLabels) == 0 {
+		return nil
+	}
+
+	labels := make(map[string]string, len(s.config.DerivedLabels))
+	for _, name := range s.config.DerivedLabels {
+		switch name {
+		case config.DerivedLabelMostUsedModel:
+			labels[name] = activity.mostUsedModel
+			if labels[name] == "" {
+				labels[name] = "none"
+			}
+		case config.DerivedLabelUniqueProjects:
+			labels[name] = countBucket(activity.uniqueProjects)
+		case config.DerivedLabelUniqueSessions:
+			labels[name] = countBucket(activity.uniqueSessions)
+		}
+	}
+	return labels
+}
+
+// sendCcInfo sends tosage_cc_info, always 1, carrying the derived labels. Keeping them off
+// tosage_cc_token leaves its series unchanged when a derived value changes; join on host to
+// use them with the token metrics.
+func (s *MetricsServiceImpl) sendCcInfo(ctx context.Context, report *usecase.MetricsSendReport, labels map[string]string) {
+	if err := s.sendLabeledTokenMetric(report, usecase.MetricsSourceClaudeCode, 1, s.hostLabelFor(usecase.MetricsSourceClaudeCode), "tosage_cc_info", labels); err != nil {
+		s.logSendFailure(ctx, "Failed to send Claude Code derived labels", err)
+	}
+}
+
+// sendCcUniqueCounts sends the number of projects, models and sessions used today, which shows
+// how broad the day's activity was alongside the token totals. The counts are sent as they are,
+// outside the token bookkeeping of the report and the persisted state.
+func (s *MetricsServiceImpl) sendCcUniqueCounts(ctx context.Context, activity ccActivity) {
+	hostLabel := s.hostLabelFor(usecase.MetricsSourceClaudeCode)
+	for _, metric := range []struct {
+		name  string
+		value int
+	}{
+		{"tosage_cc_unique_projects", activity.uniqueProjects},
+		{"tosage_cc_unique_models", activity.uniqueModels},
+		{"tosage_cc_unique_sessions", activity.uniqueSessions},
+	} {
+		if err := s.metricsRepo.SendTokenMetric(int64(metric.value), hostLabel, metric.name); err != nil {
+			s.logSendFailure(ctx, "Failed to send Claude Code unique count metric", err, domain.NewField("metric", metric.name))
+		}
+	}
+}
+
+// ccDayStart returns the start of today's Claude Code window: midnight in the user's
+// timezone, or 24 hours ago in rolling mode
+func (s *MetricsServiceImpl) ccDayStart(now time.Time) time.Time {
+	if s.dailyWindow.IsRolling() {
+		return valueobject.RollingWindowStart(now)
+	}
+	if s.timezoneService != nil {
+		dayStart, _ := s.timezoneService.GetDayBoundaries(now)
+		return dayStart
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// sendCcSessionMetrics sends tosage_cc_session_token for today's largest Claude Code
+// sessions, at most SessionMetricsTopN of them, so runaway sessions stand out
+func (s *MetricsServiceImpl) sendCcSessionMetrics(ctx context.Context, entries []usecase.CcDataEntry) {
+	if s.config.SessionMetricsTopN <= 0 {
+		return
+	}
+
+	for _, session := range topSessions(entries, s.config.SessionMetricsTopN) {
+		sessionLabel := session.id
+		if s.config.HashSessionIDs || s.hashSessionIDs {
+			sessionLabel = valueobject.HashSessionID(session.id)
+		}
+		labels := map[string]string{"session": sessionLabel}
+		if err := s.metricsRepo.SendTokenMetricWithLabels(session.tokens, s.hostLabelFor(usecase.MetricsSourceClaudeCode), "tosage_cc_session_token", labels, nil); err != nil {
+			s.logSendFailure(ctx, "Failed to send Claude Code session metric", err,
+				domain.NewField("session", sessionLabel))
+			return
+		}
+	}
+}
+
+// sessionPercentileMetrics are the percentiles of today's per-session tokens that are sent, by metric name
+var sessionPercentileMetrics = []struct {
+	name       string
+	percentile float64
+}{
+	{"tosage_cc_session_tokens_p50", 50},
+	{"tosage_cc_session_tokens_p90", 90},
+	{"tosage_cc_session_tokens_p99", 99},
+	{"tosage_cc_session_tokens_max", 100},
+}
+
+// sendCcSessionPercentiles sends the distribution of today's per-session Claude Code tokens
+// as a fixed set of gauges, showing whether a few heavy sessions dominate usage without
+// a series per session. Nothing is sent before the first session of the day.
+func (s *MetricsServiceImpl) sendCcSessionPercentiles(ctx context.Context, entries []usecase.CcDataEntry) {
+	totals := sessionTotals(entries)
+	if len(totals) == 0 {
+		return
+	}
+	tokens := make([]int64, 0, len(totals))
+	for _, total := range totals {
+		tokens = append(tokens, total)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i] < tokens[j] })
+
+	for _, metric := range sessionPercentileMetrics {
+		value := nearestRankPercentile(tokens, metric.percentile)
+		if err := s.metricsRepo.SendTokenMetric(value, s.hostLabelFor(usecase.MetricsSourceClaudeCode), metric.name); err != nil {
+			s.logSendFailure(ctx, "Failed to send Claude Code session percentile metric", err,
+				domain.NewField("metric", metric.name))
+			return
+		}
+	}
+}
+
+// nearestRankPercentile returns the p-th percentile (0 < p <= 100) of sorted values by the
+// nearest-rank method, so the result is always one of the values
+func nearestRankPercentile(sorted []int64, p float64) int64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// sendCcSourcePathMetrics sends today's Claude Code tokens per data directory as
+// tosage_cc_source_path_token{source_path="..."}, so multi-path setups show where usage was read from
+func (s *MetricsServiceImpl) sendCcSourcePathMetrics(ctx context.Context, report *usecase.MetricsSendReport, entries []usecase.CcDataEntry) {
+	totals := make(map[string]int64)
+	for _, entry := range entries {
+		if entry.SourcePath != "" {
+			totals[entry.SourcePath] += int64(entry.TotalTokens)
+		}
+	}
+	paths := make([]string, 0, len(totals))
+	for path := range totals {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		pathLabel := path
+		if s.hashSourcePaths {
+			pathLabel = valueobject.HashSourcePath(path)
+		}
+		labels := map[string]string{"source_path": pathLabel}
+		if err := s.sendLabeledTokenMetric(report, usecase.MetricsSourceClaudeCode, totals[path], s.hostLabelFor(usecase.MetricsSourceClaudeCode), "tosage_cc_source_path_token", labels); err != nil {
+			s.logSendFailure(ctx, "Failed to send Claude Code source path metric", err,
+				domain.NewField("source_path", pathLabel))
+		}
+	}
+}
+
+// sessionTokens is the token total of one Claude Code session
+type sessionTokens struct {
+	id     string
+	tokens int64
+}
+
+// topSessions sums the tokens of entries per session and returns the n largest sessions,
+// largest first. Entries without a session ID are skipped.
+func topSessions(entries []usecase.CcDataEntry, n int) []sessionTokens {
+	totals := sessionTotals(entries)
+	sessions := make([]sessionTokens, 0, len(totals))
+	for id, tokens := range totals {
+		sessions = append(sessions, sessionTokens{id: id, tokens: tokens})
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].tokens != sessions[j].tokens {
+			return sessions[i].tokens > sessions[j].tokens
+		}
+		return sessions[i].id < sessions[j].id
+	})
+	if len(sessions) > n {
+		sessions = sessions[:n]
+	}
+	return sessions
+}
+
+// sessionTotals sums the tokens of entries per session ID, skipping entries without one
+func sessionTotals(entries []usecase.CcDataEntry) map[string]int64 {
+	totals := make(map[string]int64)
+	for _, entry := range entries {
+		if entry.SessionID != "" {
+			totals[entry.SessionID] += int64(entry.TotalTokens)
+		}
+	}
+	return totals
+}
+
+// countBucket maps a count to one of a few fixed ranges
+func countBucket(n int) string {
+	switch {
+	case n <= 0:
+		return "0"
+	case n == 1:
+		return "1"
+	case n <= 5:
+		return "2-5"
+	case n <= 10:
+		return "6-10"
+	default:
+		return "11+"
+	}
+}
+
+// sendCursorBillingPeriodMetric sends the tokens used in the current Cursor billing period,
+// which starts on the configured billing day rather than at midnight
+func (s *MetricsServiceImpl) sendCursorBillingPeriodMetric(ctx context.Context, report *usecase.MetricsSendReport, durations map[string]time.Duration) {
+	start := time.Now()
+	periodTokens, err := s.cursorService.GetBillingPeriodTokenUsage()
+	durations[usecase.MetricsSourceCursor] += time.Since(start)
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to get Cursor billing period token usage", domain.NewField("error", err.Error()))
+		report.AddFailure(usecase.MetricsSourceCursor, "tosage_cursor_billing_period_token", err)
+		return
+	}
+	if err := s.sendTokenMetric(report, usecase.MetricsSourceCursor, periodTokens, s.hostLabelFor(usecase.MetricsSourceCursor), "tosage_cursor_billing_period_token"); err != nil {
+		s.logSendFailure(ctx, "Failed to send Cursor billing period metrics", err)
+	}
+}
+
+// sendCursorBillingCycleMetric sends the tokens used since the billing cycle start reported by
+// Cursor, which matches how Cursor bills even when the configured billing day is off
+func (s *MetricsServiceImpl) sendCursorBillingCycleMetric(ctx context.Context, report *usecase.MetricsSendReport, durations map[string]time.Duration) {
+	start := time.Now()
+	cycleTokens, err := s.cursorService.GetBillingCycleTokenUsage()
+	durations[usecase.MetricsSourceCursor] += time.Since(start)
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to get Cursor billing cycle token usage", domain.NewField("error", err.Error()))
+		report.AddFailure(usecase.MetricsSourceCursor, "tosage_cursor_billing_cycle_token", err)
+		return
+	}
+	if err := s.sendTokenMetric(report, usecase.MetricsSourceCursor, cycleTokens, s.hostLabelFor(usecase.MetricsSourceCursor), "tosage_cursor_billing_cycle_token"); err != nil {
+		s.logSendFailure(ctx, "Failed to send Cursor billing cycle metrics", err)
+	}
+}
+
+// sendCursorParseStatus sends tosage_cursor_parse_ok, 0 when a Cursor API response of this cycle
+// was not fully understood. A change in the response format then shows up as a failed gauge
+// rather than as usage silently dropping to zero.
+func (s *MetricsServiceImpl) sendCursorParseStatus(ctx context.Context, report *usecase.MetricsSendReport) {
+	value := 1
+	if !s.cursorService.ResponseFormatOK() {
+		value = 0
+		s.logger.Warn(ctx, "A Cursor API response was not fully understood; run with --debug to log it")
+	}
+	if err := s.sendTokenMetric(report, usecase.MetricsSourceCursor, int64(value), s.hostLabelFor(usecase.MetricsSourceCursor), "tosage_cursor_parse_ok"); err != nil {
+		s.logSendFailure(ctx, "Failed to send Cursor parse status", err)
+	}
+}
+
+// sendCursorUsageMetrics sends the enabled metrics read from the current Cursor usage: premium
+// requests, usage costs and call counts. They share one usage request per cycle.
+func (s *MetricsServiceImpl) sendCursorUsageMetrics(ctx context.Context, report *usecase.MetricsSendReport, durations map[string]time.Duration) {
+	start := time.Now()
+	usage, err := s.cursorService.GetCurrentUsage()
+	durations[usecase.MetricsSourceCursor] += time.Since(start)
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to get Cursor usage", domain.NewField("error", err.Error()))
+		if s.cursorPremiumRequests {
+			report.AddFailure(usecase.MetricsSourceCursor, "tosage_cursor_premium_requests", err)
+		}
+		if s.cursorUsageCost {
+			report.AddFailure(usecase.MetricsSourceCursor, "tosage_cursor_usage_cost_cents", err)
+		}
+		if s.cursorCallCounts {
+			report.AddFailure(usecase.MetricsSourceCursor, "tosage_cursor_tool_calls", err)
+		}
+		return
+	}
+
+	if s.cursorPremiumRequests {
+		s.sendCursorPremiumRequestMetrics(ctx, report, usage)
+	}
+	if s.cursorUsageCost {
+		s.sendCursorUsageCostMetrics(ctx, report, usage)
+	}
+	if s.cursorCallCounts {
+		s.sendCursorCallCountMetrics(ctx, report, usage)
+	}
+}
+
+// sendCursorPremiumRequestMetrics sends the premium requests used this month and the monthly limit,
+// so alerts can fire before the quota is exhausted
+func (s *MetricsServiceImpl) sendCursorPremiumRequestMetrics(ctx context.Context, report *usecase.MetricsSendReport, usage *entity.CursorUsage) {
+	premium := usage.PremiumRequests()
+	hostLabel := s.hostLabelFor(usecase.MetricsSourceCursor)
+	if err := s.sendTokenMetric(report, usecase.MetricsSourceCursor, int64(premium.Current), hostLabel, "tosage_cursor_premium_requests"); err != nil {
+		s.logSendFailure(ctx, "Failed to send Cursor premium request metrics", err)
+	}
+	if err := s.sendTokenMetric(report, usecase.MetricsSourceCursor, int64(premium.Limit), hostLabel, "tosage_cursor_premium_requests_limit"); err != nil {
+		s.logSendFailure(ctx, "Failed to send Cursor premium request limit metrics", err)
+	}
+}
+
+// sendCursorUsageCostMetrics sends the usage-based cost of the current and last billing month
+// as read from the Cursor invoices. Mid-month payments and unpaid invoices are separate gauges,
+// so the cost is the full usage of the month whether or not part of it was paid already.
+func (s *MetricsServiceImpl) sendCursorUsageCostMetrics(ctx context.Context, report *usecase.MetricsSendReport, usage *entity.CursorUsage) {
+	pricing := usage.UsageBasedPricing()
+	hostLabel := s.hostLabelFor(usecase.MetricsSourceCursor)
+	for _, month := range []entity.MonthlyUsage{pricing.CurrentMonth, pricing.LastMonth} {
+		if month.Year == 0 {
+			continue
+		}
+		var unpaid int64
+		if month.HasUnpaidInvoice {
+			unpaid = 1
+		}
+		labels := map[string]string{"month": month.Period()}
+		metrics := []struct {
+			name  string
+			value int64
+		}{
+			{"tosage_cursor_usage_cost_cents", int64(month.TotalCostCents())},
+			{"tosage_cursor_mid_month_payment_cents", int64(month.MidMonthPaymentCents())},
+			{"tosage_cursor_unpaid_invoice", unpaid},
+		}
+		for _, metric := range metrics {
+			if err := s.sendLabeledTokenMetric(report, usecase.MetricsSourceCursor, metric.value, hostLabel, metric.name, labels); err != nil {
+				s.logSendFailure(ctx, "Failed to send Cursor usage cost metrics", err)
+			}
+		}
+	}
+}
+
+// sendCursorCallCountMetrics sends the tool calls and token-based calls of the current
+// billing month, as classified by the Cursor invoice items
+func (s *MetricsServiceImpl) sendCursorCallCountMetrics(ctx context.Context, report *usecase.MetricsSendReport, usage *entity.CursorUsage) {
+	month := usage.UsageBasedPricing().CurrentMonth
+	hostLabel := s.hostLabelFor(usecase.MetricsSourceCursor)
+	if err := s.sendTokenMetric(report, usecase.MetricsSourceCursor, int64(month.ToolCallCount()), hostLabel, "tosage_cursor_tool_calls"); err != nil {
+		s.logSendFailure(ctx, "Failed to send Cursor tool call metrics", err)
+	}
+	if err := s.sendTokenMetric(report, usecase.MetricsSourceCursor, int64(month.TokenBasedCallCount()), hostLabel, "tosage_cursor_token_based_calls"); err != nil {
+		s.logSendFailure(ctx, "Failed to send Cursor token-based call metrics", err)
+	}
+}
+
+// sendCursorUsageBasedStatusMetrics sends whether usage-based pricing is enabled and the
+// spending hard limit, so an alert can fire when pricing is switched on unexpectedly.
+// The limit is sent in whole dollars and only when one is set.
+func (s *MetricsServiceImpl) sendCursorUsageBasedStatusMetrics(ctx context.Context, report *usecase.MetricsSendReport, durations map[string]time.Duration) {
+	start := time.Now()
+	enabled, err := s.cursorService.IsUsageBasedPricingEnabled()
+	durations[usecase.MetricsSourceCursor] += time.Since(start)
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to get Cursor usage-based pricing status", domain.NewField("error", err.Error()))
+		report.AddFailure(usecase.MetricsSourceCursor, "tosage_cursor_usage_based_enabled", err)
+		return
+	}
+
+	value := 0
+	if enabled {
+		value = 1
+	}
+	hostLabel := s.hostLabelFor(usecase.MetricsSourceCursor)
+	if err := s.sendTokenMetric(report, usecase.MetricsSourceCursor, int64(value), hostLabel, "tosage_cursor_usage_based_enabled"); err != nil {
+		s.logSendFailure(ctx, "Failed to send Cursor usage-based pricing status", err)
+	}
+
+	start = time.Now()
+	limit, err := s.cursorService.GetUsageLimit()
+	durations[usecase.MetricsSourceCursor] += time.Since(start)
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to get Cursor spend limit", domain.NewField("error", err.Error()))
+		report.AddFailure(usecase.MetricsSourceCursor, "tosage_cursor_spend_limit_dollars", err)
+		return
+	}
+	if limit == nil || limit.HardLimit == nil {
+		return
+	}
+	if err := s.sendTokenMetric(report, usecase.MetricsSourceCursor, int64(math.Round(*limit.HardLimit)), hostLabel, "tosage_cursor_spend_limit_dollars"); err != nil {
+		s.logSendFailure(ctx, "Failed to send Cursor spend limit", err)
+	}
+}
+
+// sendCursorTeamMemberMetrics sends today's tokens of each selected team member as
+// tosage_cursor_member_token with a user label
+func (s *MetricsServiceImpl) sendCursorTeamMemberMetrics(ctx context.Context, report *usecase.MetricsSendReport, durations map[string]time.Duration) {
+	const metricName = "tosage_cursor_member_token"
+
+	// The allowlist and the cap apply to the roster, so only the selected members' usage is read
+	var dropped int
+	start := time.Now()
+	members, err := s.cursorService.GetTeamMemberTokenUsage(func(roster []repository.TeamMemberTokenUsage) []repository.TeamMemberTokenUsage {
+		var selected []repository.TeamMemberTokenUsage
+		selected, dropped = s.cursorTeamMembers.apply(roster)
+		return selected
+	})
+	durations[usecase.MetricsSourceCursor] += time.Since(start)
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to get Cursor team member token usage", domain.NewField("error", err.Error()))
+		report.AddFailure(usecase.MetricsSourceCursor, metricName+"{user}", err)
+		return
+	}
+	if dropped > 0 {
+		s.logger.Info(ctx, "Cursor team member metrics capped",
+			domain.NewField("read", len(members)),
+			domain.NewField("dropped", dropped))
+	}
+
+	hostLabel := s.hostLabelFor(usecase.MetricsSourceCursor)
+	for _, member := range members {
+		user := s.cursorTeamMembers.label(member)
+		if member.Err != nil {
+			s.logger.Warn(ctx, "Failed to get Cursor team member token usage",
+				domain.NewField("user", user),
+				domain.NewField("error", member.Err.Error()))
+			report.AddFailure(usecase.MetricsSourceCursor, seriesKey(metricName, map[string]string{"user": user}), member.Err)
+			continue
+		}
+		labels := map[string]string{"user": user}
+		if err := s.sendLabeledTokenMetric(report, usecase.MetricsSourceCursor, member.Tokens, hostLabel, metricName, labels); err != nil {
+			s.logSendFailure(ctx, "Failed to send Cursor team member metrics", err)
+		}
+	}
+}
+
+// modelTokenUsage is the token usage of a single model as reported by a provider
+type modelTokenUsage struct {
+	model  string
+	input  int64
+	output int64
+}
+
+// sendVertexAIModelMetrics sends per-model Vertex AI token metrics labeled with the model,
+// in addition to the aggregate. Only models with usage today are sent to bound cardinality.
+func (s *MetricsServiceImpl) sendVertexAIModelMetrics(ctx context.Context, report *usecase.MetricsSendReport, usage *entity.VertexAIUsage) {
+	var models []modelTokenUsage
+	for _, metric := range usage.ModelMetrics() {
+		models = append(models, modelTokenUsage{model: metric.ModelID, input: metric.InputTokens, output: metric.OutputTokens})
+	}
+	s.sendModelTokenMetrics(ctx, report, usecase.MetricsSourceVertexAI, "tosage_vertex_ai", models)
+}
+
+// vertexAIRequestStats is the request count and request-weighted latency of one model
+type vertexAIRequestStats struct {
+	requests int64
+	// latencyRequests and latencyTotal cover only the entries that report a latency
+	latencyRequests int64
+	latencyTotal    float64
+}
+
+// add adds a model metric. Metrics without a latency count as requests only.
+func (r *vertexAIRequestStats) add(metric entity.VertexAIModelMetric) {
+	r.requests += metric.RequestCount
+	if metric.RequestCount > 0 && metric.LatencyMs > 0 {
+		r.latencyRequests += metric.RequestCount
+		r.latencyTotal += metric.LatencyMs * float64(metric.RequestCount)
+	}
+}
+
+// averageLatencyMs returns the average latency and whether any entry reported one
+func (r *vertexAIRequestStats) averageLatencyMs() (float64, bool) {
+	if r.latencyRequests == 0 {
+		return 0, false
+	}
+	return r.latencyTotal / float64(r.latencyRequests), true
+}
+
+// sendVertexAIRequestMetrics sends tosage_vertex_ai_request_count and tosage_vertex_ai_latency_ms
+// for all models and, if enabled, tosage_vertex_ai_model_request_count and tosage_vertex_ai_model_latency_ms
+// per model. The latency is not sent when no model reports one.
+func (s *MetricsServiceImpl) sendVertexAIRequestMetrics(ctx context.Context, report *usecase.MetricsSendReport, usage *entity.VertexAIUsage) {
+	total := &vertexAIRequestStats{}
+	perModel := make(map[string]*vertexAIRequestStats)
+	var models []string
+	for _, metric := range usage.ModelMetrics() {
+		total.add(metric)
+		if metric.ModelID == "" || metric.RequestCount == 0 {
+			continue
+		}
+		stats, exists := perModel[metric.ModelID]
+		if !exists {
+			stats = &vertexAIRequestStats{}
+			perModel[metric.ModelID] = stats
+			models = append(models, metric.ModelID)
+		}
+		stats.add(metric)
+	}
+
+	s.sendVertexAIRequestStats(ctx, report, total, "tosage_vertex_ai", nil)
+	if !s.vertexAIRequestsPerModel {
+		return
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		s.sendVertexAIRequestStats(ctx, report, perModel[model], "tosage_vertex_ai_model", map[string]string{"model": model})
+	}
+}
+
+// sendVertexAIRequestStats sends <prefix>_request_count and, when known, <prefix>_latency_ms of stats
+func (s *MetricsServiceImpl) sendVertexAIRequestStats(ctx context.Context, report *usecase.MetricsSendReport, stats *vertexAIRequestStats, prefix string, labels map[string]string) {
+	hostLabel := s.hostLabelFor(usecase.MetricsSourceVertexAI)
+	if err := s.sendLabeledTokenMetric(report, usecase.MetricsSourceVertexAI, stats.requests, hostLabel, prefix+"_request_count", labels); err != nil {
+		s.logSendFailure(ctx, "Failed to send Vertex AI request count", err, domain.NewField("model", labels["model"]))
+	}
+	latency, ok := stats.averageLatencyMs()
+	if !ok {
+		return
+	}
+	if err := s.sendLabeledTokenMetric(report, usecase.MetricsSourceVertexAI, int64(math.Round(latency)), hostLabel, prefix+"_latency_ms", labels); err != nil {
+		s.logSendFailure(ctx, "Failed to send Vertex AI latency", err, domain.NewField("model", labels["model"]))
+	}
+}
+
+// sendBedrockModelMetrics sends per-model Bedrock token metrics labeled with the model,
+// in addition to the aggregate. Regions without the model dimension contribute no models.
+func (s *MetricsServiceImpl) sendBedrockModelMetrics(ctx context.Context, report *usecase.MetricsSendReport, usage *entity.BedrockUsage) {
+	var models []modelTokenUsage
+	for _, metric := range usage.ModelMetrics() {
+		models = append(models, modelTokenUsage{model: metric.ModelID, input: metric.InputTokens, output: metric.OutputTokens})
+	}
+	s.sendModelTokenMetrics(ctx, report, usecase.MetricsSourceBedrock, "tosage_bedrock", models)
+}
+
+// sendModelTokenMetrics sends <prefix>_model_input_token, _model_output_token and _model_total_token per model.
+// Usage of the same model from several regions or locations is summed, and models without usage are skipped.
+func (s *MetricsServiceImpl) sendModelTokenMetrics(ctx context.Context, report *usecase.MetricsSendReport, source, prefix string, usage []modelTokenUsage) {
+	totals := make(map[string]*modelTokenUsage)
+	var models []string
+	for _, metric := range usage {
+		if metric.model == "" || metric.input+metric.output == 0 {
+			continue
+		}
+		tokens, exists := totals[metric.model]
+		if !exists {
+			tokens = &modelTokenUsage{model: metric.model}
+			totals[metric.model] = tokens
+			models = append(models, metric.model)
+		}
+		tokens.input += metric.input
+		tokens.output += metric.output
+	}
+	sort.Strings(models)
+
+	for _, model := range models {
+		tokens := totals[model]
+		labels := map[string]string{"model": model}
+		metrics := []struct {
+			name  string
+			value int64
+		}{
+			{prefix + "_model_input_token", tokens.input},
+			{prefix + "_model_output_token", tokens.output},
+			{prefix + "_model_total_token", tokens.input + tokens.output},
+		}
+		for _, metric := range metrics {
+			if err := s.sendLabeledTokenMetric(report, source, metric.value, s.hostLabelFor(source), metric.name, labels); err != nil {
+				s.logSendFailure(ctx, "Failed to send model token metrics", err,
+					domain.NewField("source", source),
+					domain.NewField("model", model),
+					domain.NewField("metric", metric.name))
+			}
+		}
+	}
+}
+
+// sendCollectionDurations sends tosage_collection_duration_seconds{source=...} with how long
+// each source took to collect its usage in this cycle, whether or not the collection succeeded
+func (s *MetricsServiceImpl) sendCollectionDurations(ctx context.Context, durations map[string]time.Duration) {
+	sources := make([]string, 0, len(durations))
+	for source := range durations {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	for _, source := range sources {
+		seconds := durations[source].Seconds()
+		labels := map[string]string{"source": source}
+
+		var err error
+		if sender, ok := s.metricsRepo.(repository.MetricValueSender); ok {
+			err = sender.SendMetricValue(seconds, s.config.HostLabel, "tosage_collection_duration_seconds", labels, nil)
+		} else {
+			err = s.metricsRepo.SendTokenMetricWithLabels(int64(math.Round(seconds)), s.config.HostLabel, "tosage_collection_duration_seconds", labels, nil)
+		}
+		if err != nil {
+			s.logSendFailure(ctx, "Failed to send collection duration metric", err,
+				domain.NewField("source", source))
+			continue
+		}
+		s.logger.Debug(ctx, "Collection duration",
+			domain.NewField("source", source),
+			domain.NewField("seconds", seconds))
+	}
+}
+
+// sendCcAllTokens sends today's Claude Code total over every token component.
+// Failures are logged without failing the collection.
+func (s *MetricsServiceImpl) sendCcAllTokens(ctx context.Context, report *usecase.MetricsSendReport) {
+	totalTokens, err := s.ccService.CalculateTodayAllTokens()
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to calculate today's tokens over all components", domain.NewField("error", err.Error()))
+		report.AddFailure(usecase.MetricsSourceClaudeCode, "tosage_cc_token_all", err)
+		return
+	}
+	if err := s.sendTokenMetric(report, usecase.MetricsSourceClaudeCode, totalTokens, s.hostLabelFor(usecase.MetricsSourceClaudeCode), "tosage_cc_token_all"); err != nil {
+		s.logSendFailure(ctx, "Failed to send Claude Code metrics over all token components", err)
+	}
+}
+
+// sendCcTokensDelta sends the Claude Code tokens used since the previous push, computed from
+// the baseline in the persisted state. The daily total resetting at midnight yields zero.
+// The first push without a baseline only records one, and the baseline moves only when the
+// delta was sent, so tokens of a failed push count toward the next one.
+func (s *MetricsServiceImpl) sendCcTokensDelta(ctx context.Context, report *usecase.MetricsSendReport, totalTokens int64) {
+	const metricName = "tosage_cc_tokens_delta"
+
+	s.stateMu.Lock()
+	if s.state == nil {
+		s.stateMu.Unlock()
+		return
+	}
+	delta, ok := s.state.Delta(metricName, float64(totalTokens))
+	if !ok {
+		s.state.SetDeltaBaseline(metricName, float64(totalTokens), time.Now())
+		s.stateDirty = true
+	}
+	s.stateMu.Unlock()
+	if !ok {
+		return
+	}
+
+	if err := s.sendTokenMetric(report, usecase.MetricsSourceClaudeCode, int64(delta), s.hostLabelFor(usecase.MetricsSourceClaudeCode), metricName); err != nil {
+		s.logSendFailure(ctx, "Failed to send Claude Code token delta", err)
+		return
+	}
+
+	s.stateMu.Lock()
+	s.state.SetDeltaBaseline(metricName, float64(totalTokens), time.Now())
+	s.stateDirty = true
+	s.stateMu.Unlock()
+}
+
+// ccDataStale reports whether the newest Claude Code entry is older than the configured
+// maximum age, sending tosage_cc_stale when the check is enabled. Stale data still gets
+// its last entry age sent so the cause is visible.
+func (s *MetricsServiceImpl) ccDataStale(ctx context.Context, report *usecase.MetricsSendReport) bool {
+	if s.ccMaxStaleness <= 0 {
+		return false
+	}
+	_, newest, err := s.ccService.GetDateRange()
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to get the newest Claude Code entry", domain.NewField("error", err.Error()))
+		return false
+	}
+
+	// Without any entries there is no old total that could be published
+	age := time.Since(newest)
+	stale := !newest.IsZero() && age > s.ccMaxStaleness
+	value := 0
+	if stale {
+		value = 1
+	}
+	if err := s.sendTokenMetric(report, usecase.MetricsSourceClaudeCode, int64(value), s.hostLabelFor(usecase.MetricsSourceClaudeCode), "tosage_cc_stale"); err != nil {
+		s.logSendFailure(ctx, "Failed to send Claude Code staleness", err)
+	}
+	if stale {
+		s.logger.Warn(ctx, "Skipping Claude Code metrics: the newest entry is older than the maximum data staleness",
+			domain.NewField("newest_entry", newest.Format(time.RFC3339)),
+			domain.NewField("age_seconds", int64(age.Seconds())),
+			domain.NewField("max_staleness_seconds", int64(s.ccMaxStaleness.Seconds())))
+		s.sendCcLastEntryAge(ctx)
+		// A scrape endpoint would keep serving the last totals, so they are expired there
+		if expirer, ok := s.metricsRepo.(repository.MetricsExpirer); ok {
+			expirer.ExpireMetrics(ccStaleMetrics...)
+		}
+	}
+	return stale
+}
+
+// ccStaleMetrics are the Claude Code metrics skipped while the data is stale.
+// tosage_cc_stale and tosage_cc_last_entry_age_seconds keep being sent.
+var ccStaleMetrics = []string{
+	"tosage_cc_token",
+	"tosage_cc_token_all",
+	"tosage_cc_tokens_delta",
+	"tosage_cc_info",
+	"tosage_cc_session_token",
+	"tosage_cc_session_tokens_p50",
+	"tosage_cc_session_tokens_p90",
+	"tosage_cc_session_tokens_p99",
+	"tosage_cc_session_tokens_max",
+	"tosage_cc_unique_projects",
+	"tosage_cc_unique_models",
+	"tosage_cc_unique_sessions",
+	"tosage_cc_source_path_token",
+}
+
+// sendCcLastEntryAge sends how many seconds ago the newest Claude Code entry was written.
+// A growing value means no new entries are being read, e.g. because the data path broke,
+// while tosage_cc_token keeps reporting the last known total.
+func (s *MetricsServiceImpl) sendCcLastEntryAge(ctx context.Context) {
+	_, newest, err := s.ccService.GetDateRange()
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to get the newest Claude Code entry", domain.NewField("error", err.Error()))
+		return
+	}
+	if newest.IsZero() {
+		// No entries at all; there is no age to report
+		return
+	}
+
+	age := time.Since(newest).Seconds()
+	if age < 0 {
+		age = 0
+	}
+	hostLabel := s.hostLabelFor(usecase.MetricsSourceClaudeCode)
+	if sender, ok := s.metricsRepo.(repository.MetricValueSender); ok {
+		err = sender.SendMetricValue(age, hostLabel, "tosage_cc_last_entry_age_seconds", nil, nil)
+	} else {
+		err = s.metricsRepo.SendTokenMetric(int64(math.Round(age)), hostLabel, "tosage_cc_last_entry_age_seconds")
+	}
+	if err != nil {
+		s.logSendFailure(ctx, "Failed to send Claude Code last entry age", err)
+	}
+}
+
+// sendHeartbeat sends tosage_up = 1 every cycle, whether or not any usage is collected,
+// so a missing series means tosage itself stopped reporting
+func (s *MetricsServiceImpl) sendHeartbeat(ctx context.Context) {
+	if err := s.metricsRepo.SendTokenMetric(1, s.config.HostLabel, "tosage_up"); err != nil {
+		s.logSendFailure(ctx, "Failed to send heartbeat", err)
+	}
+}
+
+// sendLastCollectionTimestamp sends tosage_last_collection_timestamp_seconds once a cycle that
+// collected at least one source finishes, so scrapers of the last-sent values can tell how old they are
+func (s *MetricsServiceImpl) sendLastCollectionTimestamp(ctx context.Context, due func(source string) bool) {
+	for _, source := range metricsSources {
+		if due(source) {
+			if err := s.metricsRepo.SendTokenMetric(s.now().Unix(), s.config.HostLabel, "tosage_last_collection_timestamp_seconds"); err != nil {
+				s.logSendFailure(ctx, "Failed to send last collection timestamp", err)
+			}
+			return
+		}
+	}
+}
+
+// sendCircuitState sends 1 while the Remote Write circuit is open and 0 otherwise.
+// While the circuit is open only other backends, such as the scrape endpoint, receive it.
+func (s *MetricsServiceImpl) sendCircuitState(ctx context.Context) {
+	if s.circuitState == nil {
+		return
+	}
+
+	value := 0
+	if s.circuitState.CircuitOpen() {
+		value = 1
+	}
+	if err := s.metricsRepo.SendTokenMetric(int64(value), s.config.HostLabel, "tosage_remote_write_circuit_open"); err != nil {
+		s.logSendFailure(ctx, "Failed to send circuit breaker state", err)
+	}
+}
+
+// logSendFailure logs a failed push. Pushes skipped by an open circuit breaker are only
+// logged at debug level, since the breaker already logged that the backend is failing.
+func (s *MetricsServiceImpl) logSendFailure(ctx context.Context, msg string, err error, fields ...domain.Field) {
+	fields = append(fields, domain.NewField("error", err.Error()))
+	if errors.Is(err, repository.ErrCircuitOpen) {
+		s.logger.Debug(ctx, msg, fields...)
+		return
+	}
+	s.logger.Warn(ctx, msg, fields...)
+}
+
+// hostLabelFor returns the host label for a source's metrics. An override wins; otherwise
+// Claude Code and Cursor use the global host label (empty lets the repository fall back to
+// the hostname) and Bedrock and Vertex AI, which report account-wide usage, get none.
+func (s *MetricsServiceImpl) hostLabelFor(source string) string {
+	if label := s.sourceHostLabels[source]; label != "" {
+		return label
+	}
+	switch source {
+	case usecase.MetricsSourceClaudeCode, usecase.MetricsSourceCursor:
+		return s.config.HostLabel
+	default:
+		return ""
+	}
+}
+
+// sendTokenMetric sends a single token metric, attaching timezone information
+// when available, and records the outcome in the report and the persisted state
+func (s *MetricsServiceImpl) sendTokenMetric(report *usecase.MetricsSendReport, source string, totalTokens int64, hostLabel string, metricName string) error {
+	return s.sendLabeledTokenMetric(report, source, totalTokens, hostLabel, metricName, nil)
+}
+
+// sendLabeledTokenMetric is sendTokenMetric for a series with additional labels.
+// The report and the persisted state key the series by its name and labels.
+func (s *MetricsServiceImpl) sendLabeledTokenMetric(report *usecase.MetricsSendReport, source string, totalTokens int64, hostLabel string, metricName string, labels map[string]string) error {
+	var err error
+	if len(labels) > 0 {
+		var timezoneInfo *repository.TimezoneInfo
+		if s.timezoneService != nil {
+			info := s.timezoneService.GetTimezoneInfo()
+			timezoneInfo = &info
+		}
+		err = s.metricsRepo.SendTokenMetricWithLabels(totalTokens, hostLabel, metricName, labels, timezoneInfo)
+	} else if s.timezoneService != nil {
+		err = s.metricsRepo.SendTokenMetricWithTimezone(totalTokens, hostLabel, metricName, s.timezoneService.GetTimezoneInfo())
+	} else {
+		// Fall back to sending without timezone information
+		err = s.metricsRepo.SendTokenMetric(totalTokens, hostLabel, metricName)
+	}
+
+	seriesName := seriesKey(metricName, labels)
+	if err != nil {
+		report.AddFailure(source, seriesName, err)
+	} else {
+		report.AddSent(source, seriesName, float64(totalTokens))
+	}
+
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if s.state != nil {
+		if err != nil {
+			s.state.AddToCounter("send_errors_total", 1)
+			s.sendFailed = true
+		} else {
+			s.state.RecordSent(seriesName, float64(totalTokens), time.Now())
+			s.state.AddToCounter("sends_total", 1)
+		}
+		s.stateDirty = true
+	}
+
+	return err
+}
+
+// seriesKey returns the metric name with its labels in Prometheus notation, e.g. name{model="x"}
+func seriesKey(metricName string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return metricName
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf("%s=%q", name, labels[name])
+	}
+	return metricName + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// cursorDailyTokens returns today's Cursor tokens. When state is persisted, only events newer
+// than the saved position are fetched and the new position is kept for the next collection.
+func (s *MetricsServiceImpl) cursorDailyTokens() (int64, error) {
+	s.stateMu.Lock()
+	persisted := s.state != nil
+	var position *entity.CursorUsagePosition
+	if persisted {
+		position = s.state.CursorPosition
+	}
+	s.stateMu.Unlock()
+
+	if !persisted {
+		return s.cursorService.GetAggregatedTokenUsage()
+	}
+
+	next, err := s.cursorService.GetIncrementalTokenUsage(position)
+	if err != nil {
+		return 0, err
+	}
+
+	s.stateMu.Lock()
+	s.state.CursorPosition = next
+	s.stateDirty = true
+	s.stateMu.Unlock()
+
+	return next.Tokens, nil
+}
+
+// loadState reads the persisted state, starting fresh if it is missing or corrupt
+func (s *MetricsServiceImpl) loadState() {
+	if s.stateRepo == nil {
+		return
+	}
+
+	state, err := s.stateRepo.Load()
+	if err != nil {
+		s.logger.Warn(context.Background(), "Failed to load metrics state, starting fresh",
+			domain.NewField("path", s.stateRepo.GetStatePath()),
+			domain.NewField("error", err.Error()))
+	}
+	s.state = state
+}
+
+// saveState persists the state if it changed since the last save. After a cycle with failed
+// sends the state is kept in memory only, so that a restart resumes from the baselines of the
+// last cycle that fully reached the backend.
+func (s *MetricsServiceImpl) saveState() {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	sendFailed := s.sendFailed
+	s.sendFailed = false
+	if s.stateRepo == nil || s.state == nil || !s.stateDirty || sendFailed {
+		return
+	}
+
+	if err := s.stateRepo.Save(s.state); err != nil {
+		s.logger.Warn(context.Background(), "Failed to save metrics state",
+			domain.NewField("path", s.stateRepo.GetStatePath()),
+			domain.NewField("error", err.Error()))
+		return
+	}
+	s.stateDirty = false
 }
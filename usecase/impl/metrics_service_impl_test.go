@@ -3,13 +3,18 @@ package impl
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/ca-srg/tosage/domain"
 	"github.com/ca-srg/tosage/domain/entity"
 	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/domain/valueobject"
 	"github.com/ca-srg/tosage/infrastructure/config"
 	usecase "github.com/ca-srg/tosage/usecase/interface"
 )
@@ -26,16 +31,20 @@ func (m *mockLogger) Error(ctx context.Context, msg string, fields ...domain.Fie
 func (m *mockLogger) WithFields(fields ...domain.Field) domain.Logger               { return m }
 
 type mockCcService struct {
-	calculateTodayTokensFunc func() (int, error)
-	callCount                int
-	mu                       sync.Mutex
+	calculateTodayTokensFunc    func() (int64, error)
+	calculateTodayAllTokensFunc func() (int64, error)
+	getDateRangeFunc            func() (time.Time, time.Time, error)
+	loadCcDataFunc              func(filter usecase.CcDataFilter) (*usecase.CcDataResult, error)
+	calculateDateBreakdownFunc  func(filter usecase.DateBreakdownFilter) (*usecase.DateBreakdownResult, error)
+	callCount                   int
+	mu                          sync.Mutex
 }
 
-func (m *mockCcService) CalculateDailyTokens(date time.Time) (int, error) {
+func (m *mockCcService) CalculateDailyTokens(date time.Time) (int64, error) {
 	return 0, errors.New("not implemented")
 }
 
-func (m *mockCcService) CalculateTodayTokens() (int, error) {
+func (m *mockCcService) CalculateTodayTokens() (int64, error) {
 	m.mu.Lock()
 	m.callCount++
 	m.mu.Unlock()
@@ -66,10 +75,16 @@ func (m *mockCcService) CalculateModelBreakdown(filter usecase.ModelBreakdownFil
 }
 
 func (m *mockCcService) CalculateDateBreakdown(filter usecase.DateBreakdownFilter) (*usecase.DateBreakdownResult, error) {
+	if m.calculateDateBreakdownFunc != nil {
+		return m.calculateDateBreakdownFunc(filter)
+	}
 	return nil, errors.New("not implemented")
 }
 
 func (m *mockCcService) LoadCcData(filter usecase.CcDataFilter) (*usecase.CcDataResult, error) {
+	if m.loadCcDataFunc != nil {
+		return m.loadCcDataFunc(filter)
+	}
 	return nil, errors.New("not implemented")
 }
 
@@ -90,14 +105,24 @@ func (m *mockCcService) GetAvailableModels() ([]string, error) {
 }
 
 func (m *mockCcService) GetDateRange() (start, end time.Time, err error) {
+	if m.getDateRangeFunc != nil {
+		return m.getDateRangeFunc()
+	}
 	return time.Time{}, time.Time{}, errors.New("not implemented")
 }
 
-func (m *mockCcService) CalculateDailyTokensInUserTimezone(date time.Time) (int, error) {
+func (m *mockCcService) CalculateDailyTokensInUserTimezone(date time.Time) (int64, error) {
 	return m.CalculateDailyTokens(date)
 }
 
-func (m *mockCcService) CalculateTodayTokensInUserTimezone() (int, error) {
+func (m *mockCcService) CalculateTodayAllTokens() (int64, error) {
+	if m.calculateTodayAllTokensFunc != nil {
+		return m.calculateTodayAllTokensFunc()
+	}
+	return 1000, nil
+}
+
+func (m *mockCcService) CalculateTodayTokensInUserTimezone() (int64, error) {
 	return m.CalculateTodayTokens()
 }
 
@@ -106,12 +131,26 @@ func (m *mockCcService) GetDateRangeInUserTimezone() (start, end time.Time, err
 }
 
 type mockMetricsRepository struct {
-	sendTokenMetricFunc func(totalTokens int, hostLabel string, metricName string) error
+	sendTokenMetricFunc func(totalTokens int64, hostLabel string, metricName string) error
 	sendCount           int
+	labeledSends        []labeledSend
+	valueSends          []valueSend
 	mu                  sync.Mutex
 }
 
-func (m *mockMetricsRepository) SendTokenMetric(totalTokens int, hostLabel string, metricName string) error {
+type valueSend struct {
+	metricName string
+	labels     map[string]string
+	value      float64
+}
+
+type labeledSend struct {
+	metricName string
+	labels     map[string]string
+	value      int64
+}
+
+func (m *mockMetricsRepository) SendTokenMetric(totalTokens int64, hostLabel string, metricName string) error {
 	m.mu.Lock()
 	m.sendCount++
 	m.mu.Unlock()
@@ -122,11 +161,25 @@ func (m *mockMetricsRepository) SendTokenMetric(totalTokens int, hostLabel strin
 	return nil
 }
 
-func (m *mockMetricsRepository) SendTokenMetricWithTimezone(totalTokens int, hostLabel string, metricName string, timezone repository.TimezoneInfo) error {
+func (m *mockMetricsRepository) SendTokenMetricWithTimezone(totalTokens int64, hostLabel string, metricName string, timezone repository.TimezoneInfo) error {
 	// For testing, just call the regular SendTokenMetric
 	return m.SendTokenMetric(totalTokens, hostLabel, metricName)
 }
 
+func (m *mockMetricsRepository) SendTokenMetricWithLabels(totalTokens int64, hostLabel string, metricName string, labels map[string]string, timezone *repository.TimezoneInfo) error {
+	m.mu.Lock()
+	m.labeledSends = append(m.labeledSends, labeledSend{metricName: metricName, labels: labels, value: totalTokens})
+	m.mu.Unlock()
+	return m.SendTokenMetric(totalTokens, hostLabel, metricName)
+}
+
+func (m *mockMetricsRepository) SendMetricValue(value float64, hostLabel string, metricName string, labels map[string]string, timezone *repository.TimezoneInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.valueSends = append(m.valueSends, valueSend{metricName: metricName, labels: labels, value: value})
+	return nil
+}
+
 func (m *mockMetricsRepository) Close() error {
 	return nil
 }
@@ -138,10 +191,17 @@ func (m *mockMetricsRepository) GetSendCount() int {
 }
 
 type mockCursorService struct {
-	getCurrentUsageFunc         func() (*entity.CursorUsage, error)
-	getAggregatedTokenUsageFunc func() (int64, error)
-	callCount                   int
-	mu                          sync.Mutex
+	getCurrentUsageFunc            func() (*entity.CursorUsage, error)
+	getAggregatedTokenUsageFunc    func() (int64, error)
+	getBillingPeriodTokenUsageFunc func() (int64, error)
+	getBillingCycleTokenUsageFunc  func() (int64, error)
+	getIncrementalTokenUsageFunc   func(position *entity.CursorUsagePosition) (*entity.CursorUsagePosition, error)
+	teamMemberUsage                []repository.TeamMemberTokenUsage
+	usageLimit                     *repository.UsageLimitInfo
+	usageBasedEnabled              *bool
+	formatBroken                   bool
+	callCount                      int
+	mu                             sync.Mutex
 }
 
 func (m *mockCursorService) GetCurrentUsage() (*entity.CursorUsage, error) {
@@ -166,10 +226,16 @@ func (m *mockCursorService) GetCurrentUsage() (*entity.CursorUsage, error) {
 }
 
 func (m *mockCursorService) GetUsageLimit() (*repository.UsageLimitInfo, error) {
+	if m.usageLimit != nil {
+		return m.usageLimit, nil
+	}
 	return nil, errors.New("not implemented")
 }
 
 func (m *mockCursorService) IsUsageBasedPricingEnabled() (bool, error) {
+	if m.usageBasedEnabled != nil {
+		return *m.usageBasedEnabled, nil
+	}
 	return false, errors.New("not implemented")
 }
 
@@ -184,6 +250,141 @@ func (m *mockCursorService) GetAggregatedTokenUsage() (int64, error) {
 	return 0, errors.New("not implemented")
 }
 
+func (m *mockCursorService) GetIncrementalTokenUsage(position *entity.CursorUsagePosition) (*entity.CursorUsagePosition, error) {
+	if m.getIncrementalTokenUsageFunc != nil {
+		return m.getIncrementalTokenUsageFunc(position)
+	}
+	tokens, err := m.GetAggregatedTokenUsage()
+	if err != nil {
+		return nil, err
+	}
+	return &entity.CursorUsagePosition{Tokens: tokens}, nil
+}
+
+func (m *mockCursorService) GetBillingPeriodTokenUsage() (int64, error) {
+	if m.getBillingPeriodTokenUsageFunc != nil {
+		return m.getBillingPeriodTokenUsageFunc()
+	}
+	return 0, errors.New("not implemented")
+}
+
+func (m *mockCursorService) GetBillingCycleTokenUsage() (int64, error) {
+	if m.getBillingCycleTokenUsageFunc != nil {
+		return m.getBillingCycleTokenUsageFunc()
+	}
+	return 0, errors.New("not implemented")
+}
+
+// GetTeamMemberTokenUsage passes the roster of teamMemberUsage, without tokens, to selectMembers
+// and returns the usage of the selected members
+func (m *mockCursorService) GetTeamMemberTokenUsage(selectMembers repository.TeamMemberSelector) ([]repository.TeamMemberTokenUsage, error) {
+	if m.teamMemberUsage == nil {
+		return nil, errors.New("not implemented")
+	}
+	usage := make(map[int]repository.TeamMemberTokenUsage, len(m.teamMemberUsage))
+	roster := make([]repository.TeamMemberTokenUsage, 0, len(m.teamMemberUsage))
+	for _, member := range m.teamMemberUsage {
+		usage[member.UserID] = member
+		roster = append(roster, repository.TeamMemberTokenUsage{UserID: member.UserID, Name: member.Name, Email: member.Email})
+	}
+	if selectMembers != nil {
+		roster = selectMembers(roster)
+	}
+	selected := make([]repository.TeamMemberTokenUsage, 0, len(roster))
+	for _, member := range roster {
+		selected = append(selected, usage[member.UserID])
+	}
+	return selected, nil
+}
+
+func (m *mockCursorService) CheckConnection(ctx context.Context) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockCursorService) ResponseFormatOK() bool {
+	return !m.formatBroken
+}
+
+type mockBedrockService struct {
+	usage      *entity.BedrockUsage
+	rangeStart time.Time
+}
+
+func (m *mockBedrockService) GetCurrentUsage() (*entity.BedrockUsage, error) {
+	return m.usage, nil
+}
+
+func (m *mockBedrockService) GetUsageForRegion(region string) (*entity.BedrockUsage, error) {
+	return m.usage, nil
+}
+
+func (m *mockBedrockService) GetDailyUsage(date time.Time) (*entity.BedrockUsage, error) {
+	return m.usage, nil
+}
+
+func (m *mockBedrockService) GetUsageInRange(start, end time.Time) (*entity.BedrockUsage, error) {
+	m.rangeStart = start
+	return m.usage, nil
+}
+
+func (m *mockBedrockService) GetCurrentMonthUsage() (*entity.BedrockUsage, error) {
+	return m.usage, nil
+}
+
+func (m *mockBedrockService) IsEnabled() bool {
+	return true
+}
+
+func (m *mockBedrockService) CheckConnection(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockBedrockService) GetConfiguredRegions() []string {
+	return nil
+}
+
+func (m *mockBedrockService) GetAvailableRegions() ([]string, error) {
+	return nil, nil
+}
+
+type mockVertexAIService struct {
+	usage      *entity.VertexAIUsage
+	rangeStart time.Time
+}
+
+func (m *mockVertexAIService) GetCurrentUsage() (*entity.VertexAIUsage, error) {
+	return m.usage, nil
+}
+
+func (m *mockVertexAIService) GetUsageForProject(projectID string) (*entity.VertexAIUsage, error) {
+	return m.usage, nil
+}
+
+func (m *mockVertexAIService) GetDailyUsage(date time.Time) (*entity.VertexAIUsage, error) {
+	return m.usage, nil
+}
+
+func (m *mockVertexAIService) GetUsageInRange(start, end time.Time) (*entity.VertexAIUsage, error) {
+	m.rangeStart = start
+	return m.usage, nil
+}
+
+func (m *mockVertexAIService) GetCurrentMonthUsage() (*entity.VertexAIUsage, error) {
+	return m.usage, nil
+}
+
+func (m *mockVertexAIService) IsEnabled() bool {
+	return true
+}
+
+func (m *mockVertexAIService) CheckConnection(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockVertexAIService) GetConfiguredProjects() []string {
+	return nil
+}
+
 func (m *mockCursorService) GetCallCount() int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -256,6 +457,180 @@ func TestMetricsServiceImpl_StartPeriodicMetrics(t *testing.T) {
 	}
 }
 
+// checkingMetricsRepository is a mockMetricsRepository whose backend becomes reachable
+// after a number of failed connection checks
+type checkingMetricsRepository struct {
+	*mockMetricsRepository
+	failures int
+	checks   int
+}
+
+func (m *checkingMetricsRepository) CheckConnection(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checks++
+	if m.checks <= m.failures {
+		return errors.New("network is unreachable")
+	}
+	return nil
+}
+
+// fakeClock drives the periodic loop of a MetricsServiceImpl without sleeping
+type fakeClock struct {
+	mu        sync.Mutex
+	now       time.Time
+	waits     chan time.Duration
+	pending   chan time.Time
+	pendingAt time.Time
+}
+
+// useFakeClock replaces the clock of service with a fake one starting at now
+func useFakeClock(service usecase.MetricsService, now time.Time) *fakeClock {
+	c := &fakeClock{now: now, waits: make(chan time.Duration, 1)}
+	s := service.(*MetricsServiceImpl)
+	s.now = c.Now
+	s.after = c.After
+	return c
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.mu.Lock()
+	c.pending = ch
+	c.pendingAt = c.now.Add(d)
+	c.mu.Unlock()
+	c.waits <- d
+	return ch
+}
+
+// wait waits for the loop to start waiting and returns the duration it waits for
+func (c *fakeClock) wait(t *testing.T) time.Duration {
+	t.Helper()
+	select {
+	case d := <-c.waits:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("periodic loop did not wait")
+		return 0
+	}
+}
+
+// fire ends the current wait, moving the clock to its end
+func (c *fakeClock) fire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.pendingAt
+	c.pending <- c.now
+}
+
+// advance waits for the loop to start waiting, ends the wait and returns its duration
+func (c *fakeClock) advance(t *testing.T) time.Duration {
+	t.Helper()
+	d := c.wait(t)
+	c.fire()
+	return d
+}
+
+func TestMetricsServiceImpl_InitialDelay(t *testing.T) {
+	metricsRepo := &mockMetricsRepository{}
+	config := &config.PrometheusConfig{IntervalSec: 600, InitialDelaySec: 90}
+	service := NewMetricsServiceImpl(&mockCcService{}, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil)
+	clock := useFakeClock(service, time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC))
+
+	if err := service.StartPeriodicMetrics(); err != nil {
+		t.Fatalf("StartPeriodicMetrics() error = %v", err)
+	}
+	defer func() { _ = service.StopPeriodicMetrics() }()
+
+	if got := clock.wait(t); got != 90*time.Second {
+		t.Errorf("initial delay = %s, want 90s", got)
+	}
+	if count := metricsRepo.GetSendCount(); count != 0 {
+		t.Fatalf("sends during the initial delay = %d, want 0", count)
+	}
+	clock.fire()
+
+	// The loop waits for the first tick once the initial metrics are sent
+	if got := clock.wait(t); got != 10*time.Minute {
+		t.Errorf("wait after the initial push = %s, want 10m", got)
+	}
+	if metricsRepo.GetSendCount() == 0 {
+		t.Error("no metrics were sent after the initial delay")
+	}
+}
+
+func TestMetricsServiceImpl_WaitBeforeFirstPush(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int
+		networkSec int
+		wantWaits  []time.Duration
+		wantChecks int
+	}{
+		{
+			name:       "backend reachable after two polls",
+			failures:   2,
+			networkSec: 5,
+			wantWaits:  []time.Duration{2 * time.Second, 2 * time.Second},
+			wantChecks: 3,
+		},
+		{
+			name:       "gives up at the deadline",
+			failures:   10,
+			networkSec: 3,
+			wantWaits:  []time.Duration{2 * time.Second, time.Second},
+			wantChecks: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metricsRepo := &checkingMetricsRepository{mockMetricsRepository: &mockMetricsRepository{}, failures: tt.failures}
+			config := &config.PrometheusConfig{IntervalSec: 600, NetworkWaitSec: tt.networkSec}
+			service := NewMetricsServiceImpl(&mockCcService{}, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil)
+			clock := useFakeClock(service, time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC))
+
+			result := make(chan bool, 1)
+			go func() { result <- service.WaitBeforeFirstPush(context.Background()) }()
+			for i, want := range tt.wantWaits {
+				if got := clock.advance(t); got != want {
+					t.Errorf("wait %d = %s, want %s", i, got, want)
+				}
+			}
+			if !<-result {
+				t.Error("WaitBeforeFirstPush() = false, want true")
+			}
+
+			metricsRepo.mu.Lock()
+			defer metricsRepo.mu.Unlock()
+			if metricsRepo.checks != tt.wantChecks {
+				t.Errorf("connection checks = %d, want %d", metricsRepo.checks, tt.wantChecks)
+			}
+		})
+	}
+
+	t.Run("context canceled during the initial delay", func(t *testing.T) {
+		config := &config.PrometheusConfig{IntervalSec: 600, InitialDelaySec: 60}
+		service := NewMetricsServiceImpl(&mockCcService{}, nil, nil, nil, &mockMetricsRepository{}, config, &mockLogger{}, nil)
+		clock := useFakeClock(service, time.Now())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		result := make(chan bool, 1)
+		go func() { result <- service.WaitBeforeFirstPush(ctx) }()
+		clock.wait(t)
+		cancel()
+		if <-result {
+			t.Error("WaitBeforeFirstPush() = true after cancel, want false")
+		}
+	})
+}
+
 func TestMetricsServiceImpl_StopPeriodicMetrics(t *testing.T) {
 	ccService := &mockCcService{}
 	metricsRepo := &mockMetricsRepository{}
@@ -300,36 +675,36 @@ func TestMetricsServiceImpl_StopPeriodicMetrics(t *testing.T) {
 func TestMetricsServiceImpl_SendCurrentMetrics(t *testing.T) {
 	tests := []struct {
 		name            string
-		ccServiceFunc   func() (int, error)
-		metricsRepoFunc func(int, string, string) error
+		ccServiceFunc   func() (int64, error)
+		metricsRepoFunc func(int64, string, string) error
 		wantErr         bool
 	}{
 		{
 			name: "successful send",
-			ccServiceFunc: func() (int, error) {
+			ccServiceFunc: func() (int64, error) {
 				return 12345, nil
 			},
-			metricsRepoFunc: func(tokens int, host string, metricName string) error {
+			metricsRepoFunc: func(tokens int64, host string, metricName string) error {
 				return nil
 			},
 			wantErr: false,
 		},
 		{
 			name: "cc service error",
-			ccServiceFunc: func() (int, error) {
+			ccServiceFunc: func() (int64, error) {
 				return 0, errors.New("cc error")
 			},
-			metricsRepoFunc: func(tokens int, host string, metricName string) error {
+			metricsRepoFunc: func(tokens int64, host string, metricName string) error {
 				return nil
 			},
 			wantErr: true,
 		},
 		{
 			name: "metrics repo error",
-			ccServiceFunc: func() (int, error) {
+			ccServiceFunc: func() (int64, error) {
 				return 12345, nil
 			},
-			metricsRepoFunc: func(tokens int, host string, metricName string) error {
+			metricsRepoFunc: func(tokens int64, host string, metricName string) error {
 				return errors.New("send error")
 			},
 			wantErr: true,
@@ -360,6 +735,58 @@ func TestMetricsServiceImpl_SendCurrentMetrics(t *testing.T) {
 	}
 }
 
+func TestMetricsServiceImpl_SendCurrentMetricsWithReport(t *testing.T) {
+	ccService := &mockCcService{
+		calculateTodayTokensFunc: func() (int64, error) {
+			return 12345, nil
+		},
+	}
+	config := &config.PrometheusConfig{
+		IntervalSec: 600,
+		HostLabel:   "test-host",
+	}
+
+	t.Run("reports sent values", func(t *testing.T) {
+		service := NewMetricsServiceImpl(ccService, nil, nil, nil, &mockMetricsRepository{}, config, &mockLogger{}, nil)
+
+		report, err := service.SendCurrentMetricsWithReport()
+		if err != nil {
+			t.Fatalf("SendCurrentMetricsWithReport() error = %v", err)
+		}
+		result, ok := report.Result("tosage_cc_token")
+		if !ok {
+			t.Fatal("report has no result for tosage_cc_token")
+		}
+		if !result.Sent || result.Value != 12345 || result.Source != usecase.MetricsSourceClaudeCode {
+			t.Errorf("result = %+v, want sent claude_code value 12345", result)
+		}
+		if report.HasFailures() {
+			t.Errorf("HasFailures() = true, want false")
+		}
+	})
+
+	t.Run("reports send failures", func(t *testing.T) {
+		metricsRepo := &mockMetricsRepository{
+			sendTokenMetricFunc: func(int64, string, string) error {
+				return errors.New("send error")
+			},
+		}
+		service := NewMetricsServiceImpl(ccService, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil)
+
+		report, err := service.SendCurrentMetricsWithReport()
+		if err == nil {
+			t.Fatal("SendCurrentMetricsWithReport() error = nil, want error")
+		}
+		result, ok := report.Result("tosage_cc_token")
+		if !ok || result.Sent || result.Err == nil {
+			t.Errorf("result = %+v, want failed result with error", result)
+		}
+		if !report.HasFailures() {
+			t.Errorf("HasFailures() = false, want true")
+		}
+	})
+}
+
 func TestMetricsServiceImpl_PeriodicExecution(t *testing.T) {
 	ccService := &mockCcService{}
 	metricsRepo := &mockMetricsRepository{}
@@ -394,7 +821,7 @@ func TestMetricsServiceImpl_ErrorHandling(t *testing.T) {
 	// Test that errors don't stop periodic execution
 	errorCount := 0
 	ccService := &mockCcService{
-		calculateTodayTokensFunc: func() (int, error) {
+		calculateTodayTokensFunc: func() (int64, error) {
 			errorCount++
 			if errorCount%2 == 0 {
 				return 1000, nil
@@ -405,7 +832,7 @@ func TestMetricsServiceImpl_ErrorHandling(t *testing.T) {
 
 	successCount := 0
 	metricsRepo := &mockMetricsRepository{
-		sendTokenMetricFunc: func(tokens int, host string, metricName string) error {
+		sendTokenMetricFunc: func(tokens int64, host string, metricName string) error {
 			successCount++
 			return nil
 		},
@@ -594,12 +1021,16 @@ func TestMetricsServiceImpl_CursorMetrics(t *testing.T) {
 }
 
 func TestMetricsServiceImpl_CursorMetrics_Values(t *testing.T) {
-	var capturedTokens int
+	var capturedTokens int64
 	var capturedHostLabel string
 	var capturedMetricName string
 
 	metricsRepo := &mockMetricsRepository{
-		sendTokenMetricFunc: func(totalTokens int, hostLabel string, metricName string) error {
+		sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+			// Status gauges are sent every cycle; capture only usage metrics
+			if metricName == "tosage_up" || metricName == "tosage_cursor_parse_ok" || metricName == "tosage_last_collection_timestamp_seconds" {
+				return nil
+			}
 			capturedTokens = totalTokens
 			capturedHostLabel = hostLabel
 			capturedMetricName = metricName
@@ -609,7 +1040,7 @@ func TestMetricsServiceImpl_CursorMetrics_Values(t *testing.T) {
 
 	tests := []struct {
 		name               string
-		expectedTokens     int
+		expectedTokens     int64
 		expectedMetricName string
 	}{
 		{
@@ -684,7 +1115,7 @@ func TestMetricsServiceImpl_GracefulDegradation(t *testing.T) {
 		{
 			name: "only cursorService nil",
 			ccService: &mockCcService{
-				calculateTodayTokensFunc: func() (int, error) {
+				calculateTodayTokensFunc: func() (int64, error) {
 					return 1000, nil
 				},
 			},
@@ -698,8 +1129,11 @@ func TestMetricsServiceImpl_GracefulDegradation(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			callCount := 0
 			metricsRepo := &mockMetricsRepository{
-				sendTokenMetricFunc: func(tokens int, hostLabel, metricName string) error {
-					callCount++
+				sendTokenMetricFunc: func(tokens int64, hostLabel, metricName string) error {
+					// Status gauges are sent every cycle; only count usage metrics
+					if metricName != "tosage_up" && metricName != "tosage_cursor_parse_ok" && metricName != "tosage_last_collection_timestamp_seconds" {
+						callCount++
+					}
 					return nil
 				},
 			}
@@ -732,3 +1166,1507 @@ func TestMetricsServiceImpl_GracefulDegradation(t *testing.T) {
 		})
 	}
 }
+
+func TestMetricsServiceImpl_Heartbeat(t *testing.T) {
+	var heartbeats []int64
+	metricsRepo := &mockMetricsRepository{
+		sendTokenMetricFunc: func(tokens int64, hostLabel, metricName string) error {
+			if metricName == "tosage_up" {
+				heartbeats = append(heartbeats, tokens)
+			}
+			return nil
+		},
+	}
+	// Claude Code collection fails, so no usage is sent
+	ccService := &mockCcService{
+		calculateTodayTokensFunc: func() (int64, error) { return 0, errors.New("data directory missing") },
+	}
+	config := &config.PrometheusConfig{IntervalSec: 600}
+	service := NewMetricsServiceImpl(ccService, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil)
+
+	if err := service.SendCurrentMetrics(); err == nil {
+		t.Fatal("SendCurrentMetrics() error = nil, want the collection error")
+	}
+	if len(heartbeats) != 1 || heartbeats[0] != 1 {
+		t.Errorf("heartbeats = %v, want a single tosage_up = 1", heartbeats)
+	}
+}
+
+func TestMetricsServiceImpl_LastCollectionTimestamp(t *testing.T) {
+	var timestamps []int64
+	metricsRepo := &mockMetricsRepository{
+		sendTokenMetricFunc: func(tokens int64, hostLabel, metricName string) error {
+			if metricName == "tosage_last_collection_timestamp_seconds" {
+				timestamps = append(timestamps, tokens)
+			}
+			return nil
+		},
+	}
+	ccService := &mockCcService{}
+	config := &config.PrometheusConfig{IntervalSec: 600}
+	service := NewMetricsServiceImpl(ccService, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil)
+	collectedAt := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	useFakeClock(service, collectedAt)
+
+	if err := service.SendCurrentMetrics(); err != nil {
+		t.Fatalf("SendCurrentMetrics() error = %v", err)
+	}
+	if len(timestamps) != 1 || timestamps[0] != collectedAt.Unix() {
+		t.Errorf("timestamps = %v, want a single timestamp %d of the collection", timestamps, collectedAt.Unix())
+	}
+
+	// A failed collection leaves the timestamp of the last successful one
+	ccService.calculateTodayTokensFunc = func() (int64, error) { return 0, errors.New("data directory missing") }
+	if err := service.SendCurrentMetrics(); err == nil {
+		t.Fatal("SendCurrentMetrics() error = nil, want the collection error")
+	}
+	if len(timestamps) != 1 {
+		t.Errorf("timestamps = %v after a failed collection, want no new timestamp", timestamps)
+	}
+}
+
+func TestMetricsServiceImpl_SendVertexAIModelMetrics(t *testing.T) {
+	usage, err := entity.NewVertexAIUsage(1500, 300, 0, []entity.VertexAIModelMetric{
+		{ModelID: "gemini-1.5-pro", InputTokens: 1000, OutputTokens: 200},
+		{ModelID: "gemini-1.5-flash", InputTokens: 500, OutputTokens: 100},
+		{ModelID: "gemini-1.0-pro", InputTokens: 0, OutputTokens: 0},
+	}, "test-project", "us-central1")
+	if err != nil {
+		t.Fatalf("NewVertexAIUsage() error = %v", err)
+	}
+
+	metricsRepo := &mockMetricsRepository{}
+	config := &config.PrometheusConfig{IntervalSec: 600}
+	service := NewMetricsServiceImpl(nil, nil, nil, &mockVertexAIService{usage: usage}, metricsRepo, config, &mockLogger{}, nil)
+
+	report, err := service.SendCurrentMetricsWithReport()
+	if err != nil {
+		t.Fatalf("SendCurrentMetricsWithReport() error = %v", err)
+	}
+
+	// The aggregate is still sent without a model label
+	if result, ok := report.Result("tosage_vertex_ai_total_token"); !ok || result.Value != 1800 {
+		t.Errorf("aggregate result = %+v, want 1800", result)
+	}
+
+	// Models without usage are skipped
+	if len(metricsRepo.labeledSends) != 6 {
+		t.Fatalf("labeled sends = %d, want 6 (3 metrics for 2 models)", len(metricsRepo.labeledSends))
+	}
+	for _, send := range metricsRepo.labeledSends {
+		if send.labels["model"] == "gemini-1.0-pro" {
+			t.Errorf("model without usage was sent: %+v", send)
+		}
+	}
+
+	result, ok := report.Result(`tosage_vertex_ai_model_total_token{model="gemini-1.5-pro"}`)
+	if !ok || result.Value != 1200 {
+		t.Errorf("per-model result = %+v, want 1200", result)
+	}
+}
+
+func TestMetricsServiceImpl_VertexAIRequestMetrics(t *testing.T) {
+	usage, err := entity.NewVertexAIUsage(1500, 300, 0, []entity.VertexAIModelMetric{
+		{ModelID: "gemini-1.5-pro", InputTokens: 1000, OutputTokens: 200, RequestCount: 30, LatencyMs: 400},
+		{ModelID: "gemini-1.5-flash", InputTokens: 500, OutputTokens: 100, RequestCount: 10, LatencyMs: 200},
+		// A model without a latency counts as requests only
+		{ModelID: "gemini-1.0-pro", RequestCount: 5},
+	}, "test-project", "us-central1")
+	if err != nil {
+		t.Fatalf("NewVertexAIUsage() error = %v", err)
+	}
+
+	config := &config.PrometheusConfig{IntervalSec: 600}
+	service := NewMetricsServiceImpl(nil, nil, nil, &mockVertexAIService{usage: usage}, &mockMetricsRepository{}, config, &mockLogger{}, nil,
+		WithVertexAIRequestMetrics(true, true))
+
+	report, err := service.SendCurrentMetricsWithReport()
+	if err != nil {
+		t.Fatalf("SendCurrentMetricsWithReport() error = %v", err)
+	}
+
+	want := map[string]float64{
+		"tosage_vertex_ai_request_count":                               45,
+		"tosage_vertex_ai_latency_ms":                                  350,
+		`tosage_vertex_ai_model_request_count{model="gemini-1.5-pro"}`: 30,
+		`tosage_vertex_ai_model_latency_ms{model="gemini-1.5-flash"}`:  200,
+		`tosage_vertex_ai_model_request_count{model="gemini-1.0-pro"}`: 5,
+	}
+	for name, value := range want {
+		if result, ok := report.Result(name); !ok || result.Value != value {
+			t.Errorf("%s = %+v, want %v", name, result, value)
+		}
+	}
+	if _, ok := report.Result(`tosage_vertex_ai_model_latency_ms{model="gemini-1.0-pro"}`); ok {
+		t.Error("latency sent for a model without latency data")
+	}
+}
+
+func TestMetricsServiceImpl_SendBedrockModelMetrics(t *testing.T) {
+	// The same model reported by two regions is summed
+	usage, err := entity.NewBedrockUsage(1600, 400, 0, []entity.BedrockModelMetric{
+		{ModelID: "anthropic.claude-3-haiku", InputTokens: 1000, OutputTokens: 200},
+		{ModelID: "anthropic.claude-3-haiku", InputTokens: 500, OutputTokens: 100},
+		{ModelID: "amazon.titan-embed", InputTokens: 100, OutputTokens: 100},
+	}, "us-east-1", "current-account")
+	if err != nil {
+		t.Fatalf("NewBedrockUsage() error = %v", err)
+	}
+
+	metricsRepo := &mockMetricsRepository{}
+	config := &config.PrometheusConfig{IntervalSec: 600}
+	service := NewMetricsServiceImpl(nil, nil, &mockBedrockService{usage: usage}, nil, metricsRepo, config, &mockLogger{}, nil)
+
+	report, err := service.SendCurrentMetricsWithReport()
+	if err != nil {
+		t.Fatalf("SendCurrentMetricsWithReport() error = %v", err)
+	}
+
+	// The aggregate is still sent without a model label
+	if result, ok := report.Result("tosage_bedrock_total_token"); !ok || result.Value != 2000 {
+		t.Errorf("aggregate result = %+v, want 2000", result)
+	}
+
+	if len(metricsRepo.labeledSends) != 6 {
+		t.Fatalf("labeled sends = %d, want 6 (3 metrics for 2 models)", len(metricsRepo.labeledSends))
+	}
+	if result, ok := report.Result(`tosage_bedrock_model_total_token{model="anthropic.claude-3-haiku"}`); !ok || result.Value != 1800 {
+		t.Errorf("haiku total = %+v, want 1800", result)
+	}
+}
+
+func TestMetricsServiceImpl_SendCollectionDurations(t *testing.T) {
+	ccService := &mockCcService{
+		calculateTodayTokensFunc: func() (int64, error) {
+			time.Sleep(10 * time.Millisecond)
+			return 100, nil
+		},
+	}
+	cursorService := &mockCursorService{
+		getAggregatedTokenUsageFunc: func() (int64, error) {
+			return 0, errors.New("cursor unavailable")
+		},
+	}
+	metricsRepo := &mockMetricsRepository{}
+	config := &config.PrometheusConfig{IntervalSec: 600, HostLabel: "test-host"}
+	service := NewMetricsServiceImpl(ccService, cursorService, nil, nil, metricsRepo, config, &mockLogger{}, nil)
+
+	if err := service.SendCurrentMetrics(); err != nil {
+		t.Fatalf("SendCurrentMetrics() error = %v", err)
+	}
+
+	// Failed collections are timed too; disabled sources are not reported
+	durations := make(map[string]float64)
+	for _, send := range metricsRepo.valueSends {
+		if send.metricName != "tosage_collection_duration_seconds" {
+			t.Errorf("unexpected value metric %s", send.metricName)
+			continue
+		}
+		durations[send.labels["source"]] = send.value
+	}
+	if len(durations) != 2 {
+		t.Fatalf("durations = %v, want claude_code and cursor", durations)
+	}
+	if durations[usecase.MetricsSourceClaudeCode] < 0.01 {
+		t.Errorf("claude_code duration = %v, want at least 0.01s", durations[usecase.MetricsSourceClaudeCode])
+	}
+	if _, ok := durations[usecase.MetricsSourceCursor]; !ok {
+		t.Error("cursor duration missing")
+	}
+}
+
+func TestNextAlignedTick(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 3, 27, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		interval time.Duration
+		want     time.Time
+	}{
+		{
+			name:     "ten minute boundary",
+			now:      base,
+			interval: 10 * time.Minute,
+			want:     time.Date(2024, 1, 15, 10, 10, 0, 0, time.UTC),
+		},
+		{
+			name:     "minute boundary",
+			now:      base,
+			interval: time.Minute,
+			want:     time.Date(2024, 1, 15, 10, 4, 0, 0, time.UTC),
+		},
+		{
+			name:     "exactly on boundary moves to the next one",
+			now:      time.Date(2024, 1, 15, 10, 10, 0, 0, time.UTC),
+			interval: 10 * time.Minute,
+			want:     time.Date(2024, 1, 15, 10, 20, 0, 0, time.UTC),
+		},
+		{
+			name:     "hour boundary crosses the day",
+			now:      time.Date(2024, 1, 15, 23, 59, 59, 0, time.UTC),
+			interval: time.Hour,
+			want:     time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextAlignedTick(tt.now, tt.interval); !got.Equal(tt.want) {
+				t.Errorf("nextAlignedTick() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetricsServiceImpl_AlignedTicks(t *testing.T) {
+	var mu sync.Mutex
+	var calls []time.Time
+	ccService := &mockCcService{}
+	config := &config.PrometheusConfig{IntervalSec: 600, AlignToInterval: true}
+	service := NewMetricsServiceImpl(ccService, nil, nil, nil, &mockMetricsRepository{}, config, &mockLogger{}, nil)
+	clock := useFakeClock(service, time.Date(2025, 6, 2, 9, 3, 27, 0, time.UTC))
+	ccService.calculateTodayTokensFunc = func() (int64, error) {
+		mu.Lock()
+		calls = append(calls, clock.Now())
+		mu.Unlock()
+		return 100, nil
+	}
+
+	if err := service.StartPeriodicMetrics(); err != nil {
+		t.Fatalf("StartPeriodicMetrics() error = %v", err)
+	}
+	// The first wait runs to the next 10 minute boundary, later ones a whole interval
+	if got := clock.advance(t); got != 6*time.Minute+33*time.Second {
+		t.Errorf("first wait = %s, want 6m33s", got)
+	}
+	if got := clock.advance(t); got != 10*time.Minute {
+		t.Errorf("second wait = %s, want 10m", got)
+	}
+	// The third wait starts once the second tick is sent; its tick and the final
+	// send at shutdown both fall on 9:30
+	clock.advance(t)
+	_ = service.StopPeriodicMetrics()
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []time.Time{
+		time.Date(2025, 6, 2, 9, 3, 27, 0, time.UTC), // initial send
+		time.Date(2025, 6, 2, 9, 10, 0, 0, time.UTC), // first tick
+		time.Date(2025, 6, 2, 9, 20, 0, 0, time.UTC), // second tick
+		time.Date(2025, 6, 2, 9, 30, 0, 0, time.UTC), // third tick
+	}
+	if len(calls) < len(want) {
+		t.Fatalf("got %d sends, want at least %d", len(calls), len(want))
+	}
+	for i, w := range want {
+		if !calls[i].Equal(w) {
+			t.Errorf("send %d at %s, want %s", i, calls[i].Format(time.TimeOnly), w.Format(time.TimeOnly))
+		}
+	}
+}
+
+func TestMetricsServiceImpl_ScheduleReplacesInterval(t *testing.T) {
+	config := &config.PrometheusConfig{IntervalSec: 1, Schedule: "0 9 * * 1-5"}
+	service := NewMetricsServiceImpl(&mockCcService{}, nil, nil, nil, &mockMetricsRepository{}, config, &mockLogger{}, nil)
+	// Friday 17:30, so the next match is Monday 9:00 rather than a second later
+	clock := useFakeClock(service, time.Date(2025, 6, 6, 17, 30, 0, 0, time.UTC))
+
+	if err := service.StartPeriodicMetrics(); err != nil {
+		t.Fatalf("StartPeriodicMetrics() error = %v", err)
+	}
+	defer func() { _ = service.StopPeriodicMetrics() }()
+
+	if got, want := clock.advance(t), 63*time.Hour+30*time.Minute; got != want {
+		t.Errorf("wait for the first scheduled send = %s, want %s", got, want)
+	}
+	if got, want := clock.wait(t), 24*time.Hour; got != want {
+		t.Errorf("wait for the second scheduled send = %s, want %s", got, want)
+	}
+}
+
+func TestMetricsServiceImpl_SourceHostLabels(t *testing.T) {
+	usage, err := entity.NewBedrockUsage(100, 50, 0, []entity.BedrockModelMetric{
+		{ModelID: "anthropic.claude-3-haiku", InputTokens: 100, OutputTokens: 50},
+	}, "us-east-1", "current-account")
+	if err != nil {
+		t.Fatalf("NewBedrockUsage() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		want      map[string]string
+	}{
+		{
+			name: "defaults",
+			want: map[string]string{
+				"tosage_cc_token":            "global-host",
+				"tosage_cursor_token":        "global-host",
+				"tosage_bedrock_total_token": "",
+			},
+		},
+		{
+			name: "overrides",
+			overrides: map[string]string{
+				usecase.MetricsSourceCursor:  "team-cursor",
+				usecase.MetricsSourceBedrock: "aws-account",
+			},
+			want: map[string]string{
+				"tosage_cc_token":            "global-host",
+				"tosage_cursor_token":        "team-cursor",
+				"tosage_bedrock_total_token": "aws-account",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			got := make(map[string]string)
+			metricsRepo := &mockMetricsRepository{
+				sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+					mu.Lock()
+					defer mu.Unlock()
+					got[metricName] = hostLabel
+					return nil
+				},
+			}
+			cursorService := &mockCursorService{
+				getAggregatedTokenUsageFunc: func() (int64, error) { return 10, nil },
+			}
+			config := &config.PrometheusConfig{IntervalSec: 600, HostLabel: "global-host"}
+			service := NewMetricsServiceImpl(&mockCcService{}, cursorService, &mockBedrockService{usage: usage}, nil,
+				metricsRepo, config, &mockLogger{}, nil, WithSourceHostLabels(tt.overrides))
+
+			if err := service.SendCurrentMetrics(); err != nil {
+				t.Fatalf("SendCurrentMetrics() error = %v", err)
+			}
+			for metric, want := range tt.want {
+				if host, ok := got[metric]; !ok || host != want {
+					t.Errorf("%s host label = %q (sent %v), want %q", metric, host, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMetricsServiceImpl_CursorPremiumRequestMetrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		want    map[string]int64
+	}{
+		{name: "disabled", enabled: false, want: map[string]int64{}},
+		{
+			name:    "enabled",
+			enabled: true,
+			want: map[string]int64{
+				"tosage_cursor_premium_requests":       350,
+				"tosage_cursor_premium_requests_limit": 500,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			got := make(map[string]int64)
+			metricsRepo := &mockMetricsRepository{
+				sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+					mu.Lock()
+					defer mu.Unlock()
+					if strings.HasPrefix(metricName, "tosage_cursor_premium_requests") {
+						got[metricName] = totalTokens
+					}
+					return nil
+				},
+			}
+			cursorService := &mockCursorService{
+				getAggregatedTokenUsageFunc: func() (int64, error) { return 10, nil },
+			}
+			config := &config.PrometheusConfig{IntervalSec: 600}
+			service := NewMetricsServiceImpl(nil, cursorService, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+				WithCursorPremiumRequestMetrics(tt.enabled))
+
+			if err := service.SendCurrentMetrics(); err != nil {
+				t.Fatalf("SendCurrentMetrics() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("premium request metrics = %v, want %v", got, tt.want)
+			}
+			for metric, want := range tt.want {
+				if got[metric] != want {
+					t.Errorf("%s = %d, want %d", metric, got[metric], want)
+				}
+			}
+		})
+	}
+}
+
+// memoryMetricsStateRepository keeps the metrics state in memory and counts the saves
+type memoryMetricsStateRepository struct {
+	state *entity.MetricsState
+	saves int
+}
+
+func (r *memoryMetricsStateRepository) Load() (*entity.MetricsState, error) {
+	if r.state == nil {
+		return entity.NewMetricsState(), nil
+	}
+	return r.state, nil
+}
+
+func (r *memoryMetricsStateRepository) Save(state *entity.MetricsState) error {
+	r.state = state
+	r.saves++
+	return nil
+}
+
+func (r *memoryMetricsStateRepository) GetStatePath() string {
+	return "memory"
+}
+
+func TestMetricsServiceImpl_IncrementalCursorTokens(t *testing.T) {
+	var positions []*entity.CursorUsagePosition
+	cursorService := &mockCursorService{
+		getIncrementalTokenUsageFunc: func(position *entity.CursorUsagePosition) (*entity.CursorUsagePosition, error) {
+			positions = append(positions, position)
+			next := &entity.CursorUsagePosition{Tokens: 100}
+			if position != nil {
+				next.Tokens = position.Tokens + 20
+			}
+			return next, nil
+		},
+	}
+	var sent []int64
+	metricsRepo := &mockMetricsRepository{
+		sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+			if metricName == "tosage_cursor_token" {
+				sent = append(sent, totalTokens)
+			}
+			return nil
+		},
+	}
+	stateRepo := &memoryMetricsStateRepository{}
+	config := &config.PrometheusConfig{IntervalSec: 600}
+
+	service := NewMetricsServiceImpl(nil, cursorService, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+		WithMetricsStateRepository(stateRepo))
+	if err := service.SendCurrentMetrics(); err != nil {
+		t.Fatalf("SendCurrentMetrics() error = %v", err)
+	}
+
+	// A restarted service continues from the persisted position
+	service = NewMetricsServiceImpl(nil, cursorService, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+		WithMetricsStateRepository(stateRepo))
+	if err := service.SendCurrentMetrics(); err != nil {
+		t.Fatalf("SendCurrentMetrics() error = %v", err)
+	}
+
+	if len(positions) != 2 || positions[0] != nil || positions[1] == nil || positions[1].Tokens != 100 {
+		t.Fatalf("positions passed to the Cursor service = %v, want nil then the saved position", positions)
+	}
+	if len(sent) != 2 || sent[0] != 100 || sent[1] != 120 {
+		t.Errorf("tosage_cursor_token values = %v, want [100 120]", sent)
+	}
+}
+
+func TestMetricsServiceImpl_SkipsSavingStateAfterFailedSends(t *testing.T) {
+	cursorService := &mockCursorService{
+		getIncrementalTokenUsageFunc: func(position *entity.CursorUsagePosition) (*entity.CursorUsagePosition, error) {
+			return &entity.CursorUsagePosition{Tokens: 100}, nil
+		},
+	}
+	var sendErr error
+	metricsRepo := &mockMetricsRepository{
+		sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+			if metricName == "tosage_cursor_token" {
+				return sendErr
+			}
+			return nil
+		},
+	}
+	stateRepo := &memoryMetricsStateRepository{}
+	service := NewMetricsServiceImpl(nil, cursorService, nil, nil, metricsRepo, &config.PrometheusConfig{IntervalSec: 600},
+		&mockLogger{}, nil, WithMetricsStateRepository(stateRepo))
+
+	sendErr = errors.New("remote write unavailable")
+	_ = service.SendCurrentMetrics()
+	if stateRepo.saves != 0 {
+		t.Errorf("state saved %d times after a failed send, want 0", stateRepo.saves)
+	}
+
+	sendErr = nil
+	if err := service.SendCurrentMetrics(); err != nil {
+		t.Fatalf("SendCurrentMetrics() error = %v", err)
+	}
+	if stateRepo.saves != 1 {
+		t.Errorf("state saved %d times after a successful cycle, want 1", stateRepo.saves)
+	}
+}
+
+// todaysEntries returns entries for the derived label and unique count tests: three projects,
+// two models and twelve sessions, with claude-sonnet-4 used most
+func todaysEntries() []usecase.CcDataEntry {
+	now := time.Now()
+	var entries []usecase.CcDataEntry
+	for i := 0; i < 12; i++ {
+		model := "claude-sonnet-4"
+		if i%3 == 0 {
+			model = "claude-opus-4"
+		}
+		entries = append(entries, usecase.CcDataEntry{
+			Timestamp:   now,
+			ProjectPath: fmt.Sprintf("/work/project-%d", i%3),
+			SessionID:   fmt.Sprintf("session-%d", i),
+			Model:       model,
+			TotalTokens: 10,
+		})
+	}
+	return entries
+}
+
+func TestMetricsServiceImpl_DerivedLabels(t *testing.T) {
+	ccService := &mockCcService{
+		calculateTodayTokensFunc: func() (int64, error) { return 1000, nil },
+		loadCcDataFunc: func(filter usecase.CcDataFilter) (*usecase.CcDataResult, error) {
+			if filter.StartDate == nil || filter.EndDate == nil || !filter.StartDate.Before(*filter.EndDate) {
+				t.Errorf("entries filter = %+v, want today's range", filter)
+			}
+			return &usecase.CcDataResult{Entries: todaysEntries()}, nil
+		},
+	}
+	var ccTokens int64
+	metricsRepo := &mockMetricsRepository{
+		sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+			if metricName == "tosage_cc_token" {
+				ccTokens = totalTokens
+			}
+			return nil
+		},
+	}
+	config := &config.PrometheusConfig{
+		IntervalSec:   600,
+		DerivedLabels: []string{"most_used_model", "unique_projects", "unique_sessions"},
+	}
+	service := NewMetricsServiceImpl(ccService, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil)
+
+	if err := service.SendCurrentMetrics(); err != nil {
+		t.Fatalf("SendCurrentMetrics() error = %v", err)
+	}
+	if len(metricsRepo.labeledSends) != 1 {
+		t.Fatalf("labeled sends = %d, want 1", len(metricsRepo.labeledSends))
+	}
+	send := metricsRepo.labeledSends[0]
+	want := map[string]string{"most_used_model": "claude-sonnet-4", "unique_projects": "2-5", "unique_sessions": "11+"}
+	// The labels go on the info gauge so tosage_cc_token keeps a single series
+	if send.metricName != "tosage_cc_info" || send.value != 1 {
+		t.Errorf("send = %+v, want tosage_cc_info 1", send)
+	}
+	for name, value := range want {
+		if send.labels[name] != value {
+			t.Errorf("label %s = %q, want %q", name, send.labels[name], value)
+		}
+	}
+	if ccTokens != 1000 {
+		t.Errorf("unlabeled tosage_cc_token = %d, want 1000", ccTokens)
+	}
+}
+
+func TestMetricsServiceImpl_UniqueCountMetrics(t *testing.T) {
+	loads := 0
+	ccService := &mockCcService{
+		calculateTodayTokensFunc: func() (int64, error) { return 1000, nil },
+		loadCcDataFunc: func(filter usecase.CcDataFilter) (*usecase.CcDataResult, error) {
+			loads++
+			return &usecase.CcDataResult{Entries: todaysEntries()}, nil
+		},
+	}
+	sent := make(map[string]int64)
+	metricsRepo := &mockMetricsRepository{
+		sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+			sent[metricName] = totalTokens
+			return nil
+		},
+	}
+	stateRepo := &memoryMetricsStateRepository{}
+	config := &config.PrometheusConfig{
+		IntervalSec:        600,
+		DerivedLabels:      []string{"unique_projects"},
+		UniqueCountMetrics: true,
+		SessionMetricsTopN: 3,
+		SessionPercentiles: true,
+	}
+	service := NewMetricsServiceImpl(ccService, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+		WithMetricsStateRepository(stateRepo))
+
+	report, err := service.SendCurrentMetricsWithReport()
+	if err != nil {
+		t.Fatalf("SendCurrentMetricsWithReport() error = %v", err)
+	}
+	want := map[string]int64{"tosage_cc_unique_projects": 3, "tosage_cc_unique_models": 2, "tosage_cc_unique_sessions": 12}
+	for name, value := range want {
+		if got, ok := sent[name]; !ok || got != value {
+			t.Errorf("%s = %d (sent %v), want %d", name, got, ok, value)
+		}
+		// The counts are not token totals, so they stay out of the send bookkeeping
+		if _, ok := stateRepo.state.Last(name); ok {
+			t.Errorf("%s recorded in the metrics state", name)
+		}
+		for _, result := range report.Results {
+			if result.MetricName == name {
+				t.Errorf("%s recorded in the send report", name)
+			}
+		}
+	}
+	// The derived labels, unique counts and session metrics share one load of today's entries
+	if loads != 1 {
+		t.Errorf("LoadCcData() called %d times, want 1", loads)
+	}
+}
+
+func TestMetricsServiceImpl_CcLastEntryAge(t *testing.T) {
+	newest := time.Now().Add(-90 * time.Minute)
+	ccService := &mockCcService{
+		getDateRangeFunc: func() (time.Time, time.Time, error) {
+			return newest.Add(-48 * time.Hour), newest, nil
+		},
+	}
+	metricsRepo := &mockMetricsRepository{}
+	config := &config.PrometheusConfig{IntervalSec: 600}
+	service := NewMetricsServiceImpl(ccService, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil)
+
+	if err := service.SendCurrentMetrics(); err != nil {
+		t.Fatalf("SendCurrentMetrics() error = %v", err)
+	}
+
+	var ages []float64
+	for _, send := range metricsRepo.valueSends {
+		if send.metricName == "tosage_cc_last_entry_age_seconds" {
+			ages = append(ages, send.value)
+		}
+	}
+	if len(ages) != 1 {
+		t.Fatalf("age sends = %v, want 1", ages)
+	}
+	if ages[0] < 5400 || ages[0] > 5460 {
+		t.Errorf("age = %v, want about 5400 seconds", ages[0])
+	}
+}
+
+func TestMetricsServiceImpl_CcMaxDataStaleness(t *testing.T) {
+	newest := time.Now().Add(-3 * time.Hour)
+	ccService := &mockCcService{
+		calculateTodayTokensFunc: func() (int64, error) { return 150, nil },
+		getDateRangeFunc: func() (time.Time, time.Time, error) {
+			return newest.Add(-48 * time.Hour), newest, nil
+		},
+	}
+	config := &config.PrometheusConfig{IntervalSec: 600}
+	metricsRepo := &expiringMetricsRepository{}
+	service := NewMetricsServiceImpl(ccService, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+		WithCcMaxDataStaleness(2*time.Hour))
+
+	report, err := service.SendCurrentMetricsWithReport()
+	if err != nil {
+		t.Fatalf("SendCurrentMetricsWithReport() error = %v", err)
+	}
+	if result, ok := report.Result("tosage_cc_stale"); !ok || result.Value != 1 {
+		t.Errorf("tosage_cc_stale = %+v, want 1", result)
+	}
+	if _, ok := report.Result("tosage_cc_token"); ok {
+		t.Error("tosage_cc_token was pushed for stale data")
+	}
+	// The last totals are no longer served by a scrape endpoint
+	if !slices.Contains(metricsRepo.expired, "tosage_cc_token") || slices.Contains(metricsRepo.expired, "tosage_cc_stale") {
+		t.Errorf("expired = %v, want tosage_cc_token and not tosage_cc_stale", metricsRepo.expired)
+	}
+
+	// Fresh data is pushed and clears the flag
+	newest = time.Now().Add(-time.Hour)
+	report, err = service.SendCurrentMetricsWithReport()
+	if err != nil {
+		t.Fatalf("SendCurrentMetricsWithReport() error = %v", err)
+	}
+	if result, ok := report.Result("tosage_cc_stale"); !ok || result.Value != 0 {
+		t.Errorf("tosage_cc_stale = %+v, want 0", result)
+	}
+	if result, ok := report.Result("tosage_cc_token"); !ok || result.Value != 150 {
+		t.Errorf("tosage_cc_token = %+v, want 150", result)
+	}
+}
+
+// expiringMetricsRepository records the metrics it is asked to expire
+type expiringMetricsRepository struct {
+	mockMetricsRepository
+	expired []string
+}
+
+func (m *expiringMetricsRepository) ExpireMetrics(metricNames ...string) {
+	m.expired = append(m.expired, metricNames...)
+}
+
+func TestMetricsServiceImpl_CcAllTokensMetric(t *testing.T) {
+	ccService := &mockCcService{
+		calculateTodayTokensFunc:    func() (int64, error) { return 150, nil },
+		calculateTodayAllTokensFunc: func() (int64, error) { return 1150, nil },
+	}
+
+	for _, enabled := range []bool{false, true} {
+		var mu sync.Mutex
+		sent := make(map[string]int64)
+		metricsRepo := &mockMetricsRepository{
+			sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+				mu.Lock()
+				defer mu.Unlock()
+				sent[metricName] = totalTokens
+				return nil
+			},
+		}
+		config := &config.PrometheusConfig{IntervalSec: 600}
+		service := NewMetricsServiceImpl(ccService, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+			WithCcAllTokensMetric(enabled))
+
+		if err := service.SendCurrentMetrics(); err != nil {
+			t.Fatalf("SendCurrentMetrics() error = %v", err)
+		}
+		if sent["tosage_cc_token"] != 150 {
+			t.Errorf("enabled=%v: tosage_cc_token = %d, want 150", enabled, sent["tosage_cc_token"])
+		}
+		all, ok := sent["tosage_cc_token_all"]
+		if ok != enabled {
+			t.Fatalf("enabled=%v: tosage_cc_token_all sent = %v", enabled, ok)
+		}
+		if enabled && all != 1150 {
+			t.Errorf("tosage_cc_token_all = %d, want 1150", all)
+		}
+	}
+}
+
+func TestMetricsServiceImpl_CcTokensDelta(t *testing.T) {
+	totals := []int64{100, 250, 40}
+	var calls int
+	ccService := &mockCcService{
+		calculateTodayTokensFunc: func() (int64, error) {
+			total := totals[calls]
+			calls++
+			return total, nil
+		},
+	}
+	var deltas []int64
+	metricsRepo := &mockMetricsRepository{
+		sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+			if metricName == "tosage_cc_tokens_delta" {
+				deltas = append(deltas, totalTokens)
+			}
+			return nil
+		},
+	}
+	stateRepo := &memoryMetricsStateRepository{}
+	config := &config.PrometheusConfig{IntervalSec: 600}
+
+	// Each push runs in a fresh service, so the previous value comes from the persisted state
+	for range totals {
+		service := NewMetricsServiceImpl(ccService, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+			WithMetricsStateRepository(stateRepo), WithCcTokensDeltaMetric(true))
+		if err := service.SendCurrentMetrics(); err != nil {
+			t.Fatalf("SendCurrentMetrics() error = %v", err)
+		}
+	}
+
+	// The first push only records the baseline; the reset to 40 is clamped at zero
+	if len(deltas) != 2 || deltas[0] != 150 || deltas[1] != 0 {
+		t.Errorf("tosage_cc_tokens_delta values = %v, want [150 0]", deltas)
+	}
+}
+
+func TestMetricsServiceImpl_ConcurrentSendsDeltaOnce(t *testing.T) {
+	var totalCalls atomic.Int32
+	ccService := &mockCcService{
+		calculateTodayTokensFunc: func() (int64, error) {
+			if totalCalls.Add(1) == 1 {
+				return 100, nil
+			}
+			return 250, nil
+		},
+	}
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var deltas []int64
+	metricsRepo := &mockMetricsRepository{
+		sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+			if metricName != "tosage_cc_tokens_delta" {
+				return nil
+			}
+			mu.Lock()
+			deltas = append(deltas, totalTokens)
+			first := len(deltas) == 1
+			mu.Unlock()
+			// Hold the first cycle before it moves the baseline until the second one has been started
+			if first {
+				close(entered)
+				<-release
+			}
+			return nil
+		},
+	}
+	config := &config.PrometheusConfig{IntervalSec: 600}
+	service := NewMetricsServiceImpl(ccService, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+		WithMetricsStateRepository(&memoryMetricsStateRepository{}), WithCcTokensDeltaMetric(true))
+
+	// The first push records the baseline
+	if err := service.SendCurrentMetrics(); err != nil {
+		t.Fatalf("SendCurrentMetrics() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_ = service.SendCurrentMetrics()
+	}()
+	<-entered
+	go func() {
+		defer wg.Done()
+		_ = service.SendCurrentMetrics()
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// The second cycle runs after the first moved the baseline, so the 150 tokens are sent once
+	if len(deltas) != 2 || deltas[0] != 150 || deltas[1] != 0 {
+		t.Errorf("tosage_cc_tokens_delta values = %v, want [150 0]", deltas)
+	}
+}
+
+func TestMetricsServiceImpl_SendCurrentMetricsIfIdle(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var calls atomic.Int32
+	ccService := &mockCcService{
+		calculateTodayTokensFunc: func() (int64, error) {
+			if calls.Add(1) == 1 {
+				close(entered)
+				<-release
+			}
+			return 100, nil
+		},
+	}
+	config := &config.PrometheusConfig{IntervalSec: 600}
+	service := NewMetricsServiceImpl(ccService, nil, nil, nil, &mockMetricsRepository{}, config, &mockLogger{}, nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = service.SendCurrentMetrics()
+	}()
+	<-entered
+
+	result := make(chan bool)
+	go func() {
+		sent, _ := service.SendCurrentMetricsIfIdle()
+		result <- sent
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	// The on-demand send waits for the running cycle instead of collecting again
+	if sent := <-result; sent {
+		t.Error("SendCurrentMetricsIfIdle() = true during a running collection, want false")
+	}
+	<-done
+	if got := calls.Load(); got != 1 {
+		t.Errorf("CalculateTodayTokens called %d times, want 1", got)
+	}
+
+	if sent, err := service.SendCurrentMetricsIfIdle(); !sent || err != nil {
+		t.Errorf("SendCurrentMetricsIfIdle() = %v, %v when idle, want true, nil", sent, err)
+	}
+}
+
+func TestMetricsServiceImpl_CursorTeamMemberMetrics(t *testing.T) {
+	cursorService := &mockCursorService{
+		getAggregatedTokenUsageFunc: func() (int64, error) { return 100, nil },
+		teamMemberUsage: []repository.TeamMemberTokenUsage{
+			{UserID: 1, Name: "Alice", Email: "alice@example.com", Err: errors.New("rate limited")},
+			{UserID: 2, Name: "Bob", Email: "bob@example.com", Tokens: 300},
+			{UserID: 3, Name: "Carol", Email: "carol@example.com", Tokens: 200},
+			{UserID: 4, Name: "Dave", Tokens: 900},
+		},
+	}
+	config := &config.PrometheusConfig{IntervalSec: 600}
+
+	tests := []struct {
+		name   string
+		hashed bool
+		users  []string
+	}{
+		{name: "raw", users: []string{"bob@example.com"}},
+		{name: "hashed", hashed: true, users: []string{valueobject.HashTeamMember("bob@example.com")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metricsRepo := &mockMetricsRepository{}
+
+			// Dave is not allowed and Carol is cut by the cap before her usage is read.
+			// Alice's usage fails to read, which skips her without failing Bob.
+			service := NewMetricsServiceImpl(nil, cursorService, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+				WithCursorTeamMemberMetrics(true, []string{"ALICE@example.com", "Bob", "carol@example.com"}, 2, tt.hashed))
+			report, err := service.SendCurrentMetricsWithReport()
+			if err != nil {
+				t.Fatalf("SendCurrentMetricsWithReport() error = %v", err)
+			}
+
+			if len(metricsRepo.labeledSends) != len(tt.users) {
+				t.Fatalf("labeled sends = %+v, want %d", metricsRepo.labeledSends, len(tt.users))
+			}
+			for i, send := range metricsRepo.labeledSends {
+				if send.metricName != "tosage_cursor_member_token" || send.labels["user"] != tt.users[i] || send.value != 300 {
+					t.Errorf("send %d = %s{user=%q} %d, want tosage_cursor_member_token{user=%q} 300",
+						i, send.metricName, send.labels["user"], send.value, tt.users[i])
+				}
+			}
+			if !report.HasFailures() {
+				t.Error("report has no failure for the member whose usage could not be read")
+			}
+		})
+	}
+}
+
+func TestMetricsServiceImpl_CursorUsageCostMetrics(t *testing.T) {
+	cursorService := &mockCursorService{
+		getAggregatedTokenUsageFunc: func() (int64, error) { return 100, nil },
+		getCurrentUsageFunc: func() (*entity.CursorUsage, error) {
+			return entity.NewCursorUsage(entity.PremiumRequestsInfo{}, entity.UsageBasedPricingInfo{
+				CurrentMonth: entity.MonthlyUsage{
+					Month:            2,
+					Year:             2025,
+					Items:            []entity.UsageItem{{TotalCost: 12.5}, {TotalCost: 3}},
+					MidMonthPayment:  10,
+					HasUnpaidInvoice: true,
+				},
+				LastMonth: entity.MonthlyUsage{Month: 1, Year: 2025, Items: []entity.UsageItem{{TotalCost: 40.25}}},
+			}, nil), nil
+		},
+	}
+	metricsRepo := &mockMetricsRepository{}
+	config := &config.PrometheusConfig{IntervalSec: 600}
+
+	service := NewMetricsServiceImpl(nil, cursorService, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+		WithCursorUsageCostMetrics(true))
+	if err := service.SendCurrentMetrics(); err != nil {
+		t.Fatalf("SendCurrentMetrics() error = %v", err)
+	}
+
+	got := make(map[string]int64)
+	for _, send := range metricsRepo.labeledSends {
+		got[send.metricName+"/"+send.labels["month"]] = send.value
+	}
+	want := map[string]int64{
+		"tosage_cursor_usage_cost_cents/2025-02":        1550,
+		"tosage_cursor_mid_month_payment_cents/2025-02": 1000,
+		"tosage_cursor_unpaid_invoice/2025-02":          1,
+		"tosage_cursor_usage_cost_cents/2025-01":        4025,
+		"tosage_cursor_mid_month_payment_cents/2025-01": 0,
+		"tosage_cursor_unpaid_invoice/2025-01":          0,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("labeled sends = %v, want %v", got, want)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %d, want %d", key, got[key], value)
+		}
+	}
+}
+
+func TestMetricsServiceImpl_CursorCallCountMetrics(t *testing.T) {
+	usageCalls := 0
+	cursorService := &mockCursorService{
+		getAggregatedTokenUsageFunc: func() (int64, error) { return 100, nil },
+		getCurrentUsageFunc: func() (*entity.CursorUsage, error) {
+			usageCalls++
+			return entity.NewCursorUsage(entity.PremiumRequestsInfo{}, entity.UsageBasedPricingInfo{
+				CurrentMonth: entity.MonthlyUsage{Items: []entity.UsageItem{
+					{RequestCount: 30, IsToolCall: true},
+					{RequestCount: 120, IsTokenBased: true},
+					{RequestCount: 80, IsTokenBased: true},
+					{RequestCount: 5},
+				}},
+			}, nil), nil
+		},
+	}
+	sent := make(map[string]int64)
+	metricsRepo := &mockMetricsRepository{
+		sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+			sent[metricName] = totalTokens
+			return nil
+		},
+	}
+	config := &config.PrometheusConfig{IntervalSec: 600}
+
+	service := NewMetricsServiceImpl(nil, cursorService, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+		WithCursorUsageCostMetrics(true), WithCursorCallCountMetrics(true))
+	if err := service.SendCurrentMetrics(); err != nil {
+		t.Fatalf("SendCurrentMetrics() error = %v", err)
+	}
+
+	if sent["tosage_cursor_tool_calls"] != 30 || sent["tosage_cursor_token_based_calls"] != 200 {
+		t.Errorf("call counts = %d tool calls, %d token-based calls, want 30 and 200",
+			sent["tosage_cursor_tool_calls"], sent["tosage_cursor_token_based_calls"])
+	}
+	// Usage costs and call counts share the cycle's usage response
+	if usageCalls != 1 {
+		t.Errorf("GetCurrentUsage called %d times, want 1", usageCalls)
+	}
+}
+
+func TestMetricsServiceImpl_CursorParseStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		formatBroken bool
+		want         int64
+	}{
+		{name: "responses understood", want: 1},
+		{name: "unknown field shape", formatBroken: true, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursorService := &mockCursorService{
+				getAggregatedTokenUsageFunc: func() (int64, error) { return 100, nil },
+				formatBroken:                tt.formatBroken,
+			}
+			sent := make(map[string]int64)
+			metricsRepo := &mockMetricsRepository{
+				sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+					sent[metricName] = totalTokens
+					return nil
+				},
+			}
+			config := &config.PrometheusConfig{IntervalSec: 600}
+
+			service := NewMetricsServiceImpl(nil, cursorService, nil, nil, metricsRepo, config, &mockLogger{}, nil)
+			if err := service.SendCurrentMetrics(); err != nil {
+				t.Fatalf("SendCurrentMetrics() error = %v", err)
+			}
+
+			// The token metric is still sent alongside the parse status
+			if got, ok := sent["tosage_cursor_parse_ok"]; !ok || got != tt.want {
+				t.Errorf("tosage_cursor_parse_ok = %d (sent %v), want %d", got, ok, tt.want)
+			}
+			if sent["tosage_cursor_token"] != 100 {
+				t.Errorf("tosage_cursor_token = %d, want 100", sent["tosage_cursor_token"])
+			}
+		})
+	}
+}
+
+func TestMetricsServiceImpl_CursorBillingCycleMetric(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		cursorService := &mockCursorService{
+			getAggregatedTokenUsageFunc:   func() (int64, error) { return 100, nil },
+			getBillingCycleTokenUsageFunc: func() (int64, error) { return 4200, nil },
+		}
+		sent := make(map[string]int64)
+		metricsRepo := &mockMetricsRepository{
+			sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+				sent[metricName] = totalTokens
+				return nil
+			},
+		}
+		config := &config.PrometheusConfig{IntervalSec: 600}
+
+		service := NewMetricsServiceImpl(nil, cursorService, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+			WithCursorBillingCycleMetric(enabled))
+		if err := service.SendCurrentMetrics(); err != nil {
+			t.Fatalf("SendCurrentMetrics() error = %v", err)
+		}
+
+		got, ok := sent["tosage_cursor_billing_cycle_token"]
+		if ok != enabled || (enabled && got != 4200) {
+			t.Errorf("enabled=%v: tosage_cursor_billing_cycle_token = %d (sent %v)", enabled, got, ok)
+		}
+	}
+}
+
+func TestMetricsServiceImpl_CursorUsageBasedStatusMetrics(t *testing.T) {
+	enabled := true
+	hardLimit := 150.0
+	cursorService := &mockCursorService{
+		getAggregatedTokenUsageFunc: func() (int64, error) { return 100, nil },
+		usageLimit:                  &repository.UsageLimitInfo{HardLimit: &hardLimit},
+		usageBasedEnabled:           &enabled,
+	}
+	sent := make(map[string]int64)
+	metricsRepo := &mockMetricsRepository{
+		sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+			sent[metricName] = totalTokens
+			return nil
+		},
+	}
+	config := &config.PrometheusConfig{IntervalSec: 600}
+
+	service := NewMetricsServiceImpl(nil, cursorService, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+		WithCursorUsageBasedStatusMetrics(true))
+	if err := service.SendCurrentMetrics(); err != nil {
+		t.Fatalf("SendCurrentMetrics() error = %v", err)
+	}
+	if sent["tosage_cursor_usage_based_enabled"] != 1 || sent["tosage_cursor_spend_limit_dollars"] != 150 {
+		t.Errorf("sent = %v, want usage-based pricing enabled with a 150 dollar limit", sent)
+	}
+
+	// Without a hard limit only the status is sent
+	cursorService.usageLimit = &repository.UsageLimitInfo{}
+	sent = make(map[string]int64)
+	if err := service.SendCurrentMetrics(); err != nil {
+		t.Fatalf("SendCurrentMetrics() error = %v", err)
+	}
+	if _, ok := sent["tosage_cursor_spend_limit_dollars"]; ok || sent["tosage_cursor_usage_based_enabled"] != 1 {
+		t.Errorf("sent = %v, want only the status without a limit", sent)
+	}
+}
+
+func TestMetricsServiceImpl_SourceIntervals(t *testing.T) {
+	config := &config.PrometheusConfig{
+		IntervalSec:       300,
+		SourceIntervalSec: map[string]int{usecase.MetricsSourceBedrock: 1800, usecase.MetricsSourceVertexAI: 1800},
+	}
+	service := NewMetricsServiceImpl(nil, nil, nil, nil, &mockMetricsRepository{}, config, &mockLogger{}, nil).(*MetricsServiceImpl)
+
+	if got := service.tickInterval(); got != 5*time.Minute {
+		t.Errorf("tickInterval() = %s, want 5m", got)
+	}
+
+	start := time.Now()
+	bedrockCollections := 0
+	for tick := 0; tick <= 12; tick++ {
+		// Ticks arrive a little late, as real tickers do
+		due := service.dueSources(start.Add(time.Duration(tick)*5*time.Minute + time.Duration(tick)*time.Second))
+		if !due(usecase.MetricsSourceClaudeCode) || !due(usecase.MetricsSourceCursor) {
+			t.Errorf("tick %d: Claude Code and Cursor should be collected every tick", tick)
+		}
+		if due(usecase.MetricsSourceBedrock) {
+			bedrockCollections++
+			if tick%6 != 0 {
+				t.Errorf("tick %d: Bedrock collected between its 30 minute intervals", tick)
+			}
+		}
+	}
+	if bedrockCollections != 3 {
+		t.Errorf("Bedrock collections = %d, want 3 (at 0, 30 and 60 minutes)", bedrockCollections)
+	}
+
+	// A shorter source interval speeds up the tick
+	config.SourceIntervalSec = map[string]int{usecase.MetricsSourceClaudeCode: 120}
+	if got := service.tickInterval(); got != 2*time.Minute {
+		t.Errorf("tickInterval() = %s, want 2m", got)
+	}
+}
+
+func TestMetricsServiceImpl_SendDueMetrics(t *testing.T) {
+	ccService := &mockCcService{}
+	config := &config.PrometheusConfig{
+		IntervalSec:       600,
+		SourceIntervalSec: map[string]int{usecase.MetricsSourceCursor: 120},
+	}
+	service := NewMetricsServiceImpl(ccService, nil, nil, nil, &mockMetricsRepository{}, config, &mockLogger{}, nil)
+
+	if err := service.SendCurrentMetrics(); err != nil {
+		t.Fatalf("SendCurrentMetrics() error = %v", err)
+	}
+	if err := service.SendDueMetrics(); err != nil {
+		t.Fatalf("SendDueMetrics() error = %v", err)
+	}
+	if ccService.callCount != 1 {
+		t.Errorf("Claude Code collected %d times, want 1: it is not due again for 10 minutes", ccService.callCount)
+	}
+
+	// Without a schedule the next send is one tick, the shortest source interval, away
+	now := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	if got, want := service.NextSendTime(now), now.Add(2*time.Minute); !got.Equal(want) {
+		t.Errorf("NextSendTime() = %s, want %s", got, want)
+	}
+
+	config.Schedule = "30 * * * *"
+	if got, want := service.NextSendTime(now), now.Add(30*time.Minute); !got.Equal(want) {
+		t.Errorf("NextSendTime() with schedule = %s, want %s", got, want)
+	}
+}
+
+type recordingCollectionHook struct {
+	calls   int
+	metrics map[string]float64
+}
+
+func (h *recordingCollectionHook) Run(ctx context.Context, collectedAt time.Time, metrics map[string]float64) (string, error) {
+	h.calls++
+	h.metrics = metrics
+	return "ok", nil
+}
+
+func TestMetricsServiceImpl_PostCollectionHook(t *testing.T) {
+	hook := &recordingCollectionHook{}
+	ccService := &mockCcService{}
+	metricsRepo := &mockMetricsRepository{}
+	config := &config.PrometheusConfig{IntervalSec: 600}
+	service := NewMetricsServiceImpl(ccService, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+		WithPostCollectionHook(hook)).(*MetricsServiceImpl)
+
+	service.sendPeriodicMetrics()
+	if hook.calls != 1 {
+		t.Fatalf("hook calls = %d, want 1", hook.calls)
+	}
+
+	// Collections outside the periodic loop, such as the daemon's, run the hook as well
+	if err := service.SendCurrentMetrics(); err != nil {
+		t.Fatalf("SendCurrentMetrics() error = %v", err)
+	}
+	if hook.calls != 2 {
+		t.Fatalf("hook calls after SendCurrentMetrics = %d, want 2", hook.calls)
+	}
+	if hook.metrics["tosage_cc_token"] != 1000 {
+		t.Errorf("hook metrics = %v, want tosage_cc_token 1000", hook.metrics)
+	}
+
+	// A failed collection doesn't run the hook
+	failing := &mockCcService{calculateTodayTokensFunc: func() (int64, error) { return 0, errors.New("read failed") }}
+	service = NewMetricsServiceImpl(failing, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+		WithPostCollectionHook(hook)).(*MetricsServiceImpl)
+	service.sendPeriodicMetrics()
+	if hook.calls != 2 {
+		t.Errorf("hook calls after a failed collection = %d, want 2", hook.calls)
+	}
+}
+
+func TestMetricsServiceImpl_SessionMetrics(t *testing.T) {
+	ccService := &mockCcService{
+		loadCcDataFunc: func(filter usecase.CcDataFilter) (*usecase.CcDataResult, error) {
+			if filter.StartDate == nil || filter.EndDate == nil {
+				t.Errorf("filter = %+v, want today's range", filter)
+			}
+			return &usecase.CcDataResult{Entries: []usecase.CcDataEntry{
+				{SessionID: "a", TotalTokens: 100},
+				{SessionID: "b", TotalTokens: 500},
+				{SessionID: "a", TotalTokens: 300},
+				{SessionID: "c", TotalTokens: 50},
+				{SessionID: "", TotalTokens: 9999},
+			}}, nil
+		},
+	}
+
+	tests := []struct {
+		name       string
+		configHash bool
+		optionHash bool
+		hash       bool
+	}{
+		{"raw", false, false, false},
+		{"hash_session_ids", true, false, true},
+		{"hash_project_paths", false, true, true},
+	}
+	for _, tt := range tests {
+		hash := tt.hash
+		metricsRepo := &mockMetricsRepository{}
+		config := &config.PrometheusConfig{IntervalSec: 600, SessionMetricsTopN: 2, HashSessionIDs: tt.configHash}
+		service := NewMetricsServiceImpl(ccService, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+			WithHashedSessionIDs(tt.optionHash))
+
+		if err := service.SendCurrentMetrics(); err != nil {
+			t.Fatalf("SendCurrentMetrics() error = %v", err)
+		}
+
+		got := make(map[string]int64)
+		for _, send := range metricsRepo.labeledSends {
+			if send.metricName == "tosage_cc_session_token" {
+				got[send.labels["session"]] = send.value
+			}
+		}
+		want := map[string]int64{"b": 500, "a": 400}
+		if hash {
+			want = map[string]int64{valueobject.HashSessionID("b"): 500, valueobject.HashSessionID("a"): 400}
+		}
+		if len(got) != len(want) {
+			t.Fatalf("hash=%v: session sends = %v, want %v", hash, got, want)
+		}
+		for session, tokens := range want {
+			if got[session] != tokens {
+				t.Errorf("hash=%v: session %s = %d, want %d", hash, session, got[session], tokens)
+			}
+		}
+	}
+}
+
+func TestMetricsServiceImpl_SessionPercentiles(t *testing.T) {
+	// Ten sessions of 100..1000 tokens, the first split over two entries
+	entries := []usecase.CcDataEntry{{SessionID: "s1", TotalTokens: 40}, {SessionID: "s1", TotalTokens: 60}, {SessionID: "", TotalTokens: 99999}}
+	for i := 2; i <= 10; i++ {
+		entries = append(entries, usecase.CcDataEntry{SessionID: fmt.Sprintf("s%d", i), TotalTokens: i * 100})
+	}
+	ccService := &mockCcService{
+		loadCcDataFunc: func(filter usecase.CcDataFilter) (*usecase.CcDataResult, error) {
+			return &usecase.CcDataResult{Entries: entries}, nil
+		},
+	}
+
+	for _, enabled := range []bool{false, true} {
+		var mu sync.Mutex
+		got := make(map[string]int64)
+		metricsRepo := &mockMetricsRepository{
+			sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+				mu.Lock()
+				defer mu.Unlock()
+				if strings.HasPrefix(metricName, "tosage_cc_session_tokens_") {
+					got[metricName] = totalTokens
+				}
+				return nil
+			},
+		}
+		config := &config.PrometheusConfig{IntervalSec: 600, SessionPercentiles: enabled}
+		service := NewMetricsServiceImpl(ccService, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil)
+		if err := service.SendCurrentMetrics(); err != nil {
+			t.Fatalf("SendCurrentMetrics() error = %v", err)
+		}
+
+		want := map[string]int{}
+		if enabled {
+			want = map[string]int{
+				"tosage_cc_session_tokens_p50": 500,
+				"tosage_cc_session_tokens_p90": 900,
+				"tosage_cc_session_tokens_p99": 1000,
+				"tosage_cc_session_tokens_max": 1000,
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("enabled=%v: percentile sends = %v, want %v", enabled, got, want)
+		}
+	}
+}
+
+func TestMetricsServiceImpl_SourcePathMetrics(t *testing.T) {
+	ccService := &mockCcService{
+		loadCcDataFunc: func(filter usecase.CcDataFilter) (*usecase.CcDataResult, error) {
+			return &usecase.CcDataResult{Entries: []usecase.CcDataEntry{
+				{SourcePath: "/home/me/.claude/projects", TotalTokens: 100},
+				{SourcePath: "/home/me/.config/claude/projects", TotalTokens: 200},
+				{SourcePath: "/home/me/.claude/projects", TotalTokens: 300},
+				{SourcePath: "", TotalTokens: 9999},
+			}}, nil
+		},
+	}
+
+	for _, hash := range []bool{false, true} {
+		metricsRepo := &mockMetricsRepository{}
+		config := &config.PrometheusConfig{IntervalSec: 600}
+		service := NewMetricsServiceImpl(ccService, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+			WithCcSourcePathMetrics(true, hash))
+
+		if err := service.SendCurrentMetrics(); err != nil {
+			t.Fatalf("SendCurrentMetrics() error = %v", err)
+		}
+
+		got := make(map[string]int64)
+		for _, send := range metricsRepo.labeledSends {
+			if send.metricName == "tosage_cc_source_path_token" {
+				got[send.labels["source_path"]] = send.value
+			}
+		}
+		want := map[string]int64{"/home/me/.claude/projects": 400, "/home/me/.config/claude/projects": 200}
+		if hash {
+			want = map[string]int64{
+				valueobject.HashSourcePath("/home/me/.claude/projects"):        400,
+				valueobject.HashSourcePath("/home/me/.config/claude/projects"): 200,
+			}
+		}
+		if len(got) != len(want) {
+			t.Fatalf("hash=%v: source path sends = %v, want %v", hash, got, want)
+		}
+		for path, tokens := range want {
+			if got[path] != tokens {
+				t.Errorf("hash=%v: source path %s = %d, want %d", hash, path, got[path], tokens)
+			}
+		}
+	}
+
+	// A failed send reaches the report instead of only the log
+	metricsRepo := &mockMetricsRepository{
+		sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+			if metricName == "tosage_cc_source_path_token" {
+				return errors.New("push failed")
+			}
+			return nil
+		},
+	}
+	service := NewMetricsServiceImpl(ccService, nil, nil, nil, metricsRepo, &config.PrometheusConfig{IntervalSec: 600}, &mockLogger{}, nil,
+		WithCcSourcePathMetrics(true, false))
+	report, err := service.SendCurrentMetricsWithReport()
+	if err != nil {
+		t.Fatalf("SendCurrentMetricsWithReport() error = %v", err)
+	}
+	if result, ok := report.Result(`tosage_cc_source_path_token{source_path="/home/me/.claude/projects"}`); !ok || result.Err == nil {
+		t.Errorf("source path result = %+v, want a failure", result)
+	}
+}
+
+func TestCountBucket(t *testing.T) {
+	tests := map[int]string{0: "0", 1: "1", 2: "2-5", 5: "2-5", 6: "6-10", 10: "6-10", 11: "11+", 500: "11+"}
+	for n, want := range tests {
+		if got := countBucket(n); got != want {
+			t.Errorf("countBucket(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+// fixedCircuitState reports a fixed circuit breaker state
+type fixedCircuitState bool
+
+func (f fixedCircuitState) CircuitOpen() bool { return bool(f) }
+
+func TestMetricsServiceImpl_CircuitStateGauge(t *testing.T) {
+	for _, open := range []bool{false, true} {
+		var got []int64
+		metricsRepo := &mockMetricsRepository{
+			sendTokenMetricFunc: func(totalTokens int64, hostLabel string, metricName string) error {
+				if metricName == "tosage_remote_write_circuit_open" {
+					got = append(got, totalTokens)
+				}
+				return nil
+			},
+		}
+		config := &config.PrometheusConfig{IntervalSec: 600}
+		service := NewMetricsServiceImpl(nil, nil, nil, nil, metricsRepo, config, &mockLogger{}, nil,
+			WithCircuitStateReporter(fixedCircuitState(open)))
+
+		if err := service.SendCurrentMetrics(); err != nil {
+			t.Fatalf("SendCurrentMetrics() error = %v", err)
+		}
+		want := int64(0)
+		if open {
+			want = 1
+		}
+		if len(got) != 1 || got[0] != want {
+			t.Errorf("open=%v: circuit gauge sends = %v, want [%d]", open, got, want)
+		}
+	}
+}
+
+func TestMetricsServiceImpl_RollingDailyWindow(t *testing.T) {
+	bedrockUsage, err := entity.NewBedrockUsage(100, 50, 0.1, nil, "us-east-1", "123456789012")
+	if err != nil {
+		t.Fatalf("NewBedrockUsage() error = %v", err)
+	}
+	bedrockService := &mockBedrockService{usage: bedrockUsage}
+	metricsRepo := &mockMetricsRepository{}
+	config := &config.PrometheusConfig{IntervalSec: 600}
+	service := NewMetricsServiceImpl(nil, nil, bedrockService, nil, metricsRepo, config, &mockLogger{}, nil,
+		WithMetricsDailyWindowMode(valueobject.DailyWindowRolling24h))
+
+	if err := service.SendCurrentMetrics(); err != nil {
+		t.Fatalf("SendCurrentMetrics() error = %v", err)
+	}
+	if age := time.Since(bedrockService.rangeStart); age < 23*time.Hour || age > 25*time.Hour {
+		t.Errorf("Bedrock range start is %s ago, want 24h", age)
+	}
+}
@@ -1,6 +1,7 @@
 package impl
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 type StatusServiceImpl struct {
 	mu     sync.RWMutex
 	status *usecase.StatusInfo
+	checks map[string]usecase.ProviderCheck
 }
 
 // NewStatusService creates a new instance of StatusService
@@ -19,6 +21,7 @@ func NewStatusService() usecase.StatusService {
 		status: &usecase.StatusInfo{
 			IsRunning: false,
 		},
+		checks: make(map[string]usecase.ProviderCheck),
 	}
 }
 
@@ -109,3 +112,58 @@ func (s *StatusServiceImpl) SetDaemonStopped() error {
 	s.status.NextMetricsSendAt = nil
 	return nil
 }
+
+// RegisterProviderCheck sets the check CheckAll runs for provider
+func (s *StatusServiceImpl) RegisterProviderCheck(provider string, check usecase.ProviderCheck) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checks[provider] = check
+}
+
+// CheckAll runs every registered provider check concurrently and reports the results.
+// The report is OK only if every check succeeded.
+func (s *StatusServiceImpl) CheckAll(ctx context.Context) *usecase.StatusReport {
+	s.mu.RLock()
+	checks := make(map[string]usecase.ProviderCheck, len(s.checks))
+	for provider, check := range s.checks {
+		checks[provider] = check
+	}
+	s.mu.RUnlock()
+
+	report := &usecase.StatusReport{
+		OK:        true,
+		CheckedAt: time.Now(),
+		Providers: make(map[string]*usecase.ProviderCheckResult, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for provider, check := range checks {
+		wg.Add(1)
+		go func(provider string, check usecase.ProviderCheck) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := check(ctx)
+			result := &usecase.ProviderCheckResult{
+				Status:    usecase.ProviderStatusOK,
+				LatencyMs: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				result.Status = usecase.ProviderStatusError
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Providers[provider] = result
+			if err != nil {
+				report.OK = false
+			}
+		}(provider, check)
+	}
+	wg.Wait()
+
+	return report
+}
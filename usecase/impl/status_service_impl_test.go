@@ -1,9 +1,13 @@
 package impl
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"testing"
 	"time"
+
+	usecase "github.com/ca-srg/tosage/usecase/interface"
 )
 
 func TestStatusServiceImpl_BasicOperations(t *testing.T) {
@@ -160,3 +164,50 @@ func TestStatusServiceImpl_ConcurrentAccess(t *testing.T) {
 		t.Error("Expected non-nil status after concurrent access")
 	}
 }
+
+func TestStatusServiceImpl_CheckAll(t *testing.T) {
+	service := NewStatusService()
+	service.RegisterProviderCheck(usecase.MetricsSourceClaudeCode, func(ctx context.Context) error { return nil })
+	service.RegisterProviderCheck(usecase.MetricsSourceCursor, func(ctx context.Context) error {
+		return errors.New("session token expired")
+	})
+
+	report := service.CheckAll(context.Background())
+	if report.OK {
+		t.Error("Expected the report not to be OK with a failing provider")
+	}
+	if result := report.Providers[usecase.MetricsSourceClaudeCode]; result == nil || result.Status != usecase.ProviderStatusOK || result.Error != "" {
+		t.Errorf("claude_code result = %+v, want ok", result)
+	}
+	if result := report.Providers[usecase.MetricsSourceCursor]; result == nil || result.Status != usecase.ProviderStatusError || result.Error != "session token expired" {
+		t.Errorf("cursor result = %+v, want the error", result)
+	}
+
+	// The report serializes with per-provider status, latency_ms and error fields
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded struct {
+		OK        bool `json:"ok"`
+		Providers map[string]struct {
+			Status    string `json:"status"`
+			LatencyMs *int64 `json:"latency_ms"`
+			Error     string `json:"error"`
+		} `json:"providers"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	cursor := decoded.Providers[usecase.MetricsSourceCursor]
+	if decoded.OK || cursor.Status != "error" || cursor.LatencyMs == nil || cursor.Error != "session token expired" {
+		t.Errorf("JSON report = %s", data)
+	}
+
+	// Without failing checks the report is OK
+	service = NewStatusService()
+	service.RegisterProviderCheck(usecase.MetricsSourceClaudeCode, func(ctx context.Context) error { return nil })
+	if report := service.CheckAll(context.Background()); !report.OK || len(report.Providers) != 1 {
+		t.Errorf("report = %+v, want OK with one provider", report)
+	}
+}
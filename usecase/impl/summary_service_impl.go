@@ -0,0 +1,236 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ca-srg/tosage/domain"
+	"github.com/ca-srg/tosage/domain/entity"
+	"github.com/ca-srg/tosage/domain/repository"
+	"github.com/ca-srg/tosage/infrastructure/config"
+	usecase "github.com/ca-srg/tosage/usecase/interface"
+)
+
+// summarySendTimeout bounds a single summary delivery including retries
+const summarySendTimeout = 5 * time.Minute
+
+// SummaryServiceImpl implements the SummaryService interface
+type SummaryServiceImpl struct {
+	ccService       usecase.CcService
+	cursorService   usecase.CursorService
+	bedrockService  usecase.BedrockService
+	vertexAIService usecase.VertexAIService
+	summaryRepo     repository.SummaryRepository
+	config          *config.SummaryConfig
+	hostLabel       string
+	logger          domain.Logger
+	timezoneService repository.TimezoneService
+
+	stopChan  chan struct{}
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	isRunning bool
+}
+
+// NewSummaryServiceImpl creates a new summary service implementation
+func NewSummaryServiceImpl(
+	ccService usecase.CcService,
+	cursorService usecase.CursorService,
+	bedrockService usecase.BedrockService,
+	vertexAIService usecase.VertexAIService,
+	summaryRepo repository.SummaryRepository,
+	config *config.SummaryConfig,
+	hostLabel string,
+	logger domain.Logger,
+	timezoneService repository.TimezoneService,
+) usecase.SummaryService {
+	return &SummaryServiceImpl{
+		ccService:       ccService,
+		cursorService:   cursorService,
+		bedrockService:  bedrockService,
+		vertexAIService: vertexAIService,
+		summaryRepo:     summaryRepo,
+		config:          config,
+		hostLabel:       hostLabel,
+		logger:          logger,
+		timezoneService: timezoneService,
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// Start schedules the summary to be sent daily at the configured time
+func (s *SummaryServiceImpl) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isRunning {
+		return fmt.Errorf("summary service is already running")
+	}
+	if s.config == nil {
+		return fmt.Errorf("summary config is nil")
+	}
+	sendAt, err := config.ParseSummarySendTime(s.config.SendTime)
+	if err != nil {
+		return err
+	}
+
+	s.isRunning = true
+	s.wg.Add(1)
+	go s.run(sendAt)
+
+	return nil
+}
+
+// Stop cancels the schedule
+func (s *SummaryServiceImpl) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isRunning {
+		return nil
+	}
+
+	close(s.stopChan)
+	s.wg.Wait()
+
+	s.isRunning = false
+	s.stopChan = make(chan struct{}) // Reset for potential restart
+
+	return nil
+}
+
+// run sends the summary every day at sendAt past local midnight until stopped
+func (s *SummaryServiceImpl) run(sendAt time.Duration) {
+	defer s.wg.Done()
+
+	for {
+		next := nextSummaryTime(time.Now().In(s.location()), sendAt)
+		s.logger.Info(context.Background(), "Next daily summary scheduled", domain.NewField("at", next.Format(time.RFC3339)))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			ctx, cancel := context.WithTimeout(context.Background(), summarySendTimeout)
+			if err := s.SendSummary(ctx); err != nil {
+				s.logger.Error(ctx, "Failed to send daily summary", domain.NewField("error", err.Error()))
+			}
+			cancel()
+		case <-s.stopChan:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// nextSummaryTime returns the first time after now that is sendAt past a local midnight
+func nextSummaryTime(now time.Time, sendAt time.Duration) time.Time {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	next := midnight.Add(sendAt)
+	if !next.After(now) {
+		midnight = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+		next = midnight.Add(sendAt)
+	}
+	return next
+}
+
+// location returns the user's timezone, falling back to the system one
+func (s *SummaryServiceImpl) location() *time.Location {
+	if s.timezoneService != nil {
+		if loc, err := s.timezoneService.GetConfiguredTimezone(); err == nil && loc != nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// SendSummary builds today's summary and delivers it
+func (s *SummaryServiceImpl) SendSummary(ctx context.Context) error {
+	summary, err := s.BuildSummary(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to build summary: %w", err)
+	}
+
+	if err := s.summaryRepo.Send(ctx, summary); err != nil {
+		return fmt.Errorf("failed to send summary: %w", err)
+	}
+
+	s.logger.Info(ctx, "Sent daily summary",
+		domain.NewField("date", summary.Date),
+		domain.NewField("total_tokens", summary.TotalTokens))
+	return nil
+}
+
+// BuildSummary computes the summary of usage so far on the day of now.
+// A source that fails is reported in the summary rather than failing it.
+func (s *SummaryServiceImpl) BuildSummary(now time.Time) (*entity.DailySummary, error) {
+	ctx := context.Background()
+	now = now.In(s.location())
+	summary := entity.NewDailySummary(now, s.hostLabel, now)
+
+	if s.ccService != nil {
+		totalTokens, err := s.ccService.CalculateDailyTokens(now)
+		if err != nil {
+			s.logger.Warn(ctx, "Failed to calculate Claude Code tokens for summary", domain.NewField("error", err.Error()))
+			summary.AddSourceError(usecase.MetricsSourceClaudeCode, err)
+		} else {
+			summary.AddSource(usecase.MetricsSourceClaudeCode, int64(totalTokens))
+
+			start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+			end := start.AddDate(0, 0, 1)
+			breakdown, err := s.ccService.CalculateModelBreakdown(usecase.ModelBreakdownFilter{StartDate: &start, EndDate: &end})
+			if err != nil {
+				s.logger.Warn(ctx, "Failed to calculate Claude Code model breakdown for summary", domain.NewField("error", err.Error()))
+			} else {
+				for _, model := range breakdown.Models {
+					summary.AddModel(usecase.MetricsSourceClaudeCode, model.ModelName, int64(model.TotalTokens))
+				}
+			}
+		}
+	}
+
+	if s.cursorService != nil {
+		totalTokens, err := s.cursorService.GetAggregatedTokenUsage()
+		if err != nil {
+			s.logger.Warn(ctx, "Failed to get Cursor tokens for summary", domain.NewField("error", err.Error()))
+			summary.AddSourceError(usecase.MetricsSourceCursor, err)
+		} else {
+			summary.AddSource(usecase.MetricsSourceCursor, totalTokens)
+		}
+	}
+
+	if s.bedrockService != nil && s.bedrockService.IsEnabled() {
+		usage, err := s.bedrockService.GetDailyUsage(now)
+		if err != nil {
+			s.logger.Warn(ctx, "Failed to get Bedrock usage for summary", domain.NewField("error", err.Error()))
+			summary.AddSourceError(usecase.MetricsSourceBedrock, err)
+		} else if usage != nil {
+			summary.AddSource(usecase.MetricsSourceBedrock, usage.TotalTokens())
+			for _, metric := range usage.ModelMetrics() {
+				summary.AddModel(usecase.MetricsSourceBedrock, metric.ModelID, metric.InputTokens+metric.OutputTokens)
+			}
+		}
+	}
+
+	if s.vertexAIService != nil && s.vertexAIService.IsEnabled() {
+		usage, err := s.vertexAIService.GetDailyUsage(now)
+		if err != nil {
+			s.logger.Warn(ctx, "Failed to get Vertex AI usage for summary", domain.NewField("error", err.Error()))
+			summary.AddSourceError(usecase.MetricsSourceVertexAI, err)
+		} else if usage != nil {
+			summary.AddSource(usecase.MetricsSourceVertexAI, usage.TotalTokens())
+			for _, metric := range usage.ModelMetrics() {
+				summary.AddModel(usecase.MetricsSourceVertexAI, metric.ModelID, metric.InputTokens+metric.OutputTokens)
+			}
+		}
+	}
+
+	topModels := 5
+	if s.config != nil {
+		topModels = s.config.TopModels
+	}
+	summary.LimitTopModels(topModels)
+
+	return summary, nil
+}
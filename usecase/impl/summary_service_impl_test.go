@@ -0,0 +1,100 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ca-srg/tosage/domain/entity"
+	"github.com/ca-srg/tosage/infrastructure/config"
+	usecase "github.com/ca-srg/tosage/usecase/interface"
+)
+
+type mockSummaryRepository struct {
+	sent []*entity.DailySummary
+	err  error
+}
+
+func (m *mockSummaryRepository) Send(ctx context.Context, summary *entity.DailySummary) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, summary)
+	return nil
+}
+
+func TestNextSummaryTime(t *testing.T) {
+	loc := time.FixedZone("JST", 9*60*60)
+	sendAt := 18 * time.Hour
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{name: "before send time", now: time.Date(2025, 1, 15, 9, 0, 0, 0, loc), want: time.Date(2025, 1, 15, 18, 0, 0, 0, loc)},
+		{name: "at send time", now: time.Date(2025, 1, 15, 18, 0, 0, 0, loc), want: time.Date(2025, 1, 16, 18, 0, 0, 0, loc)},
+		{name: "after send time", now: time.Date(2025, 1, 31, 20, 0, 0, 0, loc), want: time.Date(2025, 2, 1, 18, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextSummaryTime(tt.now, sendAt); !got.Equal(tt.want) {
+				t.Errorf("nextSummaryTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummaryServiceImpl_SendSummary(t *testing.T) {
+	usage, err := entity.NewVertexAIUsage(1500, 300, 0, []entity.VertexAIModelMetric{
+		{ModelID: "gemini-1.5-pro", InputTokens: 1000, OutputTokens: 200},
+		{ModelID: "gemini-1.5-flash", InputTokens: 500, OutputTokens: 100},
+		{ModelID: "gemini-1.0-pro", InputTokens: 0, OutputTokens: 0},
+	}, "test-project", "us-central1")
+	if err != nil {
+		t.Fatalf("NewVertexAIUsage() error = %v", err)
+	}
+
+	cursorService := &mockCursorService{
+		getAggregatedTokenUsageFunc: func() (int64, error) { return 0, errors.New("token expired") },
+	}
+	summaryRepo := &mockSummaryRepository{}
+	cfg := &config.SummaryConfig{Enabled: true, SendTime: "18:00", TopModels: 1}
+	service := NewSummaryServiceImpl(nil, cursorService, nil, &mockVertexAIService{usage: usage}, summaryRepo, cfg, "test-host", &mockLogger{}, nil)
+
+	if err := service.SendSummary(context.Background()); err != nil {
+		t.Fatalf("SendSummary() error = %v", err)
+	}
+	if len(summaryRepo.sent) != 1 {
+		t.Fatalf("sent summaries = %d, want 1", len(summaryRepo.sent))
+	}
+
+	summary := summaryRepo.sent[0]
+	if summary.Host != "test-host" {
+		t.Errorf("Host = %q, want test-host", summary.Host)
+	}
+	if summary.TotalTokens != 1800 {
+		t.Errorf("TotalTokens = %d, want 1800", summary.TotalTokens)
+	}
+
+	// A failing source is reported instead of failing the summary
+	if len(summary.Sources) != 2 || summary.Sources[0].Source != usecase.MetricsSourceCursor || summary.Sources[0].Error == "" {
+		t.Errorf("Sources = %+v, want failed cursor and vertex_ai", summary.Sources)
+	}
+
+	if len(summary.TopModels) != 1 || summary.TopModels[0].Model != "gemini-1.5-pro" || summary.TopModels[0].TotalTokens != 1200 {
+		t.Errorf("TopModels = %+v, want only gemini-1.5-pro with 1200 tokens", summary.TopModels)
+	}
+}
+
+func TestSummaryServiceImpl_SendSummaryError(t *testing.T) {
+	summaryRepo := &mockSummaryRepository{err: errors.New("webhook unavailable")}
+	cfg := &config.SummaryConfig{Enabled: true, SendTime: "18:00", TopModels: 5}
+	service := NewSummaryServiceImpl(nil, nil, nil, nil, summaryRepo, cfg, "", &mockLogger{}, nil)
+
+	if err := service.SendSummary(context.Background()); err == nil {
+		t.Error("SendSummary() error = nil, want delivery error")
+	}
+}
@@ -1,6 +1,7 @@
 package impl
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -80,13 +81,15 @@ func (s *VertexAIServiceImpl) GetUsageForProject(projectID string) (*entity.Vert
 	}
 	s.cacheMutex.RUnlock()
 
-	// Get current date in JST
+	// Get the current JST day, up to the ingestion delay before now
 	jst, _ := time.LoadLocation("Asia/Tokyo")
-	now := time.Now().In(jst)
-	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, jst)
+	startOfDay, end := currentIngestionDay(time.Now().In(jst), s.config.IngestionDelay)
 
-	// Fetch usage from repository
-	usage, err := s.vertexAIMonitoringRepo.GetUsageMetrics(projectID, startOfDay, now)
+	// Fetch usage from repository; the window is empty right after the day rolls over
+	if !end.After(startOfDay) {
+		return entity.NewVertexAIUsage(0, 0, 0, []entity.VertexAIModelMetric{}, projectID, "")
+	}
+	usage, err := s.vertexAIMonitoringRepo.GetUsageMetrics(projectID, startOfDay, end)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Vertex AI usage for project %s: %w", projectID, err)
 	}
@@ -113,6 +116,16 @@ func (s *VertexAIServiceImpl) GetDailyUsage(date time.Time) (*entity.VertexAIUsa
 		return nil, domain.ErrBusinessRule("project id required", "Vertex AI project ID is required but not configured")
 	}
 
+	// With an ingestion delay the day ends that long before now, so samples Cloud Monitoring
+	// makes visible late are counted once they arrive
+	if s.config.IngestionDelay > 0 {
+		start, end := ingestionDay(date, time.Now(), s.config.IngestionDelay)
+		if !end.After(start) {
+			return entity.NewVertexAIUsage(0, 0, 0, []entity.VertexAIModelMetric{}, s.config.ProjectID, "")
+		}
+		return s.vertexAIRepo.GetUsageMetrics(s.config.ProjectID, start, end)
+	}
+
 	// Get daily usage without location filter
 	usage, err := s.vertexAIRepo.GetDailyUsage(s.config.ProjectID, date)
 	if err != nil {
@@ -122,6 +135,20 @@ func (s *VertexAIServiceImpl) GetDailyUsage(date time.Time) (*entity.VertexAIUsa
 	return usage, nil
 }
 
+// GetUsageInRange retrieves aggregated usage between start and end for the configured project
+func (s *VertexAIServiceImpl) GetUsageInRange(start, end time.Time) (*entity.VertexAIUsage, error) {
+	if !s.IsEnabled() {
+		return nil, domain.ErrBusinessRule("vertex ai disabled", "Vertex AI tracking is disabled in configuration")
+	}
+
+	// If no project ID is configured, return error
+	if s.config.ProjectID == "" {
+		return nil, domain.ErrBusinessRule("project id required", "Vertex AI project ID is required but not configured")
+	}
+
+	return s.vertexAIRepo.GetUsageMetrics(s.config.ProjectID, start, end)
+}
+
 // GetCurrentMonthUsage retrieves usage for the current month
 func (s *VertexAIServiceImpl) GetCurrentMonthUsage() (*entity.VertexAIUsage, error) {
 	if !s.IsEnabled() {
@@ -143,12 +170,12 @@ func (s *VertexAIServiceImpl) GetCurrentMonthUsage() (*entity.VertexAIUsage, err
 }
 
 // CheckConnection verifies Google Cloud credentials and Cloud Monitoring access
-func (s *VertexAIServiceImpl) CheckConnection() error {
+func (s *VertexAIServiceImpl) CheckConnection(ctx context.Context) error {
 	if !s.IsEnabled() {
 		return domain.ErrBusinessRule("vertex ai disabled", "Vertex AI tracking is disabled in configuration")
 	}
 
-	return s.vertexAIRepo.CheckConnection()
+	return s.vertexAIRepo.CheckConnection(ctx)
 }
 
 // GetConfiguredProjects returns the list of configured project IDs
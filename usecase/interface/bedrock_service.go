@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"time"
 
 	"github.com/ca-srg/tosage/domain/entity"
@@ -19,14 +20,18 @@ type BedrockService interface {
 	// Uses JST timezone for date boundaries
 	GetDailyUsage(date time.Time) (*entity.BedrockUsage, error)
 
+	// GetUsageInRange retrieves aggregated usage between start and end,
+	// e.g. a trailing 24-hour window
+	GetUsageInRange(start, end time.Time) (*entity.BedrockUsage, error)
+
 	// GetCurrentMonthUsage retrieves usage for the current month
 	GetCurrentMonthUsage() (*entity.BedrockUsage, error)
 
 	// IsEnabled checks if Bedrock tracking is enabled in configuration
 	IsEnabled() bool
 
-	// CheckConnection verifies AWS credentials and CloudWatch access
-	CheckConnection() error
+	// CheckConnection verifies AWS credentials and CloudWatch access within the context deadline
+	CheckConnection(ctx context.Context) error
 
 	// GetConfiguredRegions returns the list of configured regions
 	GetConfiguredRegions() []string
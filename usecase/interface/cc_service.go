@@ -2,15 +2,21 @@ package usecase
 
 import (
 	"time"
+
+	"github.com/ca-srg/tosage/domain/entity"
 )
 
 // CcService defines the interface for cc-related use cases
 type CcService interface {
 	// CalculateDailyTokens calculates total token count for a specific date
-	CalculateDailyTokens(date time.Time) (int, error)
+	CalculateDailyTokens(date time.Time) (int64, error)
 
 	// CalculateTodayTokens calculates total token count for today
-	CalculateTodayTokens() (int, error)
+	CalculateTodayTokens() (int64, error)
+
+	// CalculateTodayAllTokens calculates today's token count over every token component,
+	// regardless of the configured token components
+	CalculateTodayAllTokens() (int64, error)
 
 	// CalculateTokenStats calculates aggregated token statistics
 	CalculateTokenStats(filter TokenStatsFilter) (*TokenStatsResult, error)
@@ -45,10 +51,10 @@ type CcService interface {
 	// Timezone-aware methods
 
 	// CalculateDailyTokensInUserTimezone calculates total token count for a specific date in user's timezone
-	CalculateDailyTokensInUserTimezone(date time.Time) (int, error)
+	CalculateDailyTokensInUserTimezone(date time.Time) (int64, error)
 
 	// CalculateTodayTokensInUserTimezone calculates total token count for today in user's timezone
-	CalculateTodayTokensInUserTimezone() (int, error)
+	CalculateTodayTokensInUserTimezone() (int64, error)
 
 	// GetDateRangeInUserTimezone returns the date range of available data in user's timezone
 	GetDateRangeInUserTimezone() (start, end time.Time, err error)
@@ -65,11 +71,11 @@ type TokenStatsFilter struct {
 
 // TokenStatsResult contains the result of token statistics calculation
 type TokenStatsResult struct {
-	InputTokens         int
-	OutputTokens        int
-	CacheCreationTokens int
-	CacheReadTokens     int
-	TotalTokens         int
+	InputTokens         int64
+	OutputTokens        int64
+	CacheCreationTokens int64
+	CacheReadTokens     int64
+	TotalTokens         int64
 	Cost                float64
 	Currency            string
 	EntryCount          int
@@ -103,11 +109,11 @@ type CostBreakdownResult struct {
 // CostBreakdownItem represents a single item in cost breakdown
 type CostBreakdownItem struct {
 	Key                 string // Model name, date, project path, or session ID
-	InputTokens         int
-	OutputTokens        int
-	CacheCreationTokens int
-	CacheReadTokens     int
-	TotalTokens         int
+	InputTokens         int64
+	OutputTokens        int64
+	CacheCreationTokens int64
+	CacheReadTokens     int64
+	TotalTokens         int64
 	Cost                float64
 	Currency            string
 	EntryCount          int
@@ -130,11 +136,11 @@ type ModelBreakdownResult struct {
 // ModelBreakdownItem represents cc for a single model
 type ModelBreakdownItem struct {
 	ModelName           string
-	InputTokens         int
-	OutputTokens        int
-	CacheCreationTokens int
-	CacheReadTokens     int
-	TotalTokens         int
+	InputTokens         int64
+	OutputTokens        int64
+	CacheCreationTokens int64
+	CacheReadTokens     int64
+	TotalTokens         int64
 	Cost                float64
 	Currency            string
 	EntryCount          int
@@ -148,6 +154,10 @@ type DateBreakdownFilter struct {
 	EndDate     *time.Time
 	ProjectPath string
 	Model       string
+	// Hourly groups the entries by hour instead of by date
+	Hourly bool
+	// EntryFilter keeps only the entries matching it, on top of the configured filter (nil keeps all)
+	EntryFilter *entity.CcEntryFilter
 }
 
 // DateBreakdownResult contains the result of date breakdown
@@ -158,12 +168,13 @@ type DateBreakdownResult struct {
 
 // DateBreakdownItem represents cc for a single date
 type DateBreakdownItem struct {
-	Date                string // YYYY-MM-DD format
-	InputTokens         int
-	OutputTokens        int
-	CacheCreationTokens int
-	CacheReadTokens     int
-	TotalTokens         int
+	Date                string    // YYYY-MM-DD format, or YYYY-MM-DDTHH:00 when grouped by hour
+	Start               time.Time // Start of the date or hour in the configured timezone
+	InputTokens         int64
+	OutputTokens        int64
+	CacheCreationTokens int64
+	CacheReadTokens     int64
+	TotalTokens         int64
 	Cost                float64
 	Currency            string
 	EntryCount          int
@@ -178,6 +189,8 @@ type CcDataFilter struct {
 	SessionID   string
 	Limit       int
 	Offset      int
+	// EntryFilter keeps only the entries matching it, on top of the configured filter (nil keeps all)
+	EntryFilter *entity.CcEntryFilter
 }
 
 // CcDataResult contains loaded cc data
@@ -205,6 +218,7 @@ type CcDataEntry struct {
 	Version             string
 	MessageID           string
 	RequestID           string
+	SourcePath          string // Claude data directory the entry was read from
 }
 
 // CcSummaryFilter defines filters for cc summary
@@ -217,7 +231,7 @@ type CcSummaryFilter struct {
 
 // CcSummaryResult contains cc summary information
 type CcSummaryResult struct {
-	TotalTokens        int
+	TotalTokens        int64
 	TotalCost          float64
 	Currency           string
 	EntryCount         int
@@ -225,7 +239,7 @@ type CcSummaryResult struct {
 	UniqueModels       int
 	UniqueSessions     int
 	DateRange          DateRange
-	AverageDailyTokens int
+	AverageDailyTokens int64
 	AverageDailyCost   float64
 	MostUsedModel      string
 	MostActiveProject  string
@@ -10,6 +10,20 @@ import (
 type CSVExportService interface {
 	// Export exports metrics data to CSV file
 	Export(options CSVExportOptions) error
+
+	// DryRun collects the data an export would write and summarizes it without writing a file
+	DryRun(options CSVExportOptions) (*CSVExportSummary, error)
+}
+
+// CSVExportSummary describes the rows an export would write
+type CSVExportSummary struct {
+	OutputPath string
+	StartTime  time.Time
+	EndTime    time.Time
+	// RowsBySource counts rows per source (claude_code, cursor, bedrock, vertex_ai).
+	// Explicitly requested sources without rows are included with a count of zero.
+	RowsBySource map[string]int
+	TotalRows    int
 }
 
 // CSVExportOptions represents options for CSV export
@@ -18,10 +32,27 @@ type CSVExportOptions struct {
 	StartTime   *time.Time
 	EndTime     *time.Time
 	MetricTypes []string // claude_code, cursor, bedrock, vertex_ai
+	Compress    bool     // gzip the output; OutputPath then ends in .csv.gz
+	Granularity string   // entry, hourly or daily (default: daily)
+	MinTokens   int      // drop token rows below this many tokens after aggregation (0 keeps all)
+	// Filter keeps only the Claude Code entries matching it (nil keeps all); other sources are not filtered
+	Filter *entity.CcEntryFilter
 }
 
+// Export granularities: one row per entry, per hour or per day and source
+const (
+	ExportGranularityEntry  = "entry"
+	ExportGranularityHourly = "hourly"
+	ExportGranularityDaily  = "daily"
+)
+
 // MetricsDataCollector defines the interface for collecting metrics data
 type MetricsDataCollector interface {
-	// Collect collects metrics data from all sources
+	// Collect collects daily metrics data from all sources
 	Collect(startTime, endTime time.Time, metricTypes []string) ([]*entity.MetricRecord, error)
+
+	// CollectWithGranularity collects metrics data with one record per entry, hour or day,
+	// from the Claude Code entries matching filter (nil keeps all).
+	// Entry and hourly records are only available for claude_code.
+	CollectWithGranularity(startTime, endTime time.Time, metricTypes []string, granularity string, filter *entity.CcEntryFilter) ([]*entity.MetricRecord, error)
 }
@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"context"
+
 	"github.com/ca-srg/tosage/domain/entity"
 	"github.com/ca-srg/tosage/domain/repository"
 )
@@ -18,4 +20,26 @@ type CursorService interface {
 
 	// GetAggregatedTokenUsage retrieves aggregated token usage from JST 00:00 to current time
 	GetAggregatedTokenUsage() (int64, error)
+
+	// GetIncrementalTokenUsage retrieves today's token usage, reading only events newer than position.
+	// It returns the position to continue from next time.
+	GetIncrementalTokenUsage(position *entity.CursorUsagePosition) (*entity.CursorUsagePosition, error)
+
+	// GetBillingPeriodTokenUsage retrieves aggregated token usage for the current billing period
+	GetBillingPeriodTokenUsage() (int64, error)
+
+	// GetBillingCycleTokenUsage retrieves aggregated token usage since the billing cycle start reported by Cursor
+	GetBillingCycleTokenUsage() (int64, error)
+
+	// GetTeamMemberTokenUsage retrieves today's token usage of the members of the user's team
+	// chosen by selectMembers, or of every member if it is nil. A member whose usage can't be
+	// read is returned with Err set. The stored token must belong to a team admin.
+	GetTeamMemberTokenUsage(selectMembers repository.TeamMemberSelector) ([]repository.TeamMemberTokenUsage, error)
+
+	// CheckConnection verifies the stored token is accepted by the Cursor API within the context deadline
+	CheckConnection(ctx context.Context) error
+
+	// ResponseFormatOK reports whether every Cursor API response since the previous call was
+	// fully understood, and starts the next check
+	ResponseFormatOK() bool
 }
@@ -1,5 +1,10 @@
 package usecase
 
+import (
+	"context"
+	"time"
+)
+
 // MetricsService defines the interface for metrics collection and reporting
 type MetricsService interface {
 	// StartPeriodicMetrics starts the periodic metrics collection
@@ -10,6 +15,91 @@ type MetricsService interface {
 
 	// SendCurrentMetrics sends the current metrics immediately
 	SendCurrentMetrics() error
+
+	// SendCurrentMetricsWithReport sends the current metrics immediately and reports what was sent
+	SendCurrentMetricsWithReport() (*MetricsSendReport, error)
+
+	// SendCurrentMetricsIfIdle sends the current metrics unless a collection is already running,
+	// in which case it waits for that collection and returns false without starting another
+	SendCurrentMetricsIfIdle() (bool, error)
+
+	// SendDueMetrics sends the metrics of the sources whose collection interval has elapsed,
+	// for callers that run their own schedule instead of StartPeriodicMetrics
+	SendDueMetrics() error
+
+	// NextSendTime returns when the periodic schedule sends next after the given time
+	NextSendTime(after time.Time) time.Time
+
+	// WaitBeforeFirstPush waits out the configured startup grace period before the first push,
+	// for callers that run their own schedule. Returns false if ctx is done first.
+	WaitBeforeFirstPush(ctx context.Context) bool
+}
+
+// Metric sources reported in MetricsSendReport
+const (
+	MetricsSourceClaudeCode = "claude_code"
+	MetricsSourceCursor     = "cursor"
+	MetricsSourceBedrock    = "bedrock"
+	MetricsSourceVertexAI   = "vertex_ai"
+)
+
+// MetricsSendReport describes the outcome of a single metrics send
+type MetricsSendReport struct {
+	SentAt  time.Time
+	Results []MetricSendResult
+}
+
+// MetricSendResult is the outcome for a single metric
+type MetricSendResult struct {
+	Source     string
+	MetricName string // empty if the source failed before a metric could be built
+	Value      float64
+	Sent       bool
+	Err        error
+}
+
+// NewMetricsSendReport creates an empty report
+func NewMetricsSendReport(sentAt time.Time) *MetricsSendReport {
+	return &MetricsSendReport{SentAt: sentAt}
+}
+
+// AddSent records a metric that was sent successfully
+func (r *MetricsSendReport) AddSent(source, metricName string, value float64) {
+	r.Results = append(r.Results, MetricSendResult{
+		Source:     source,
+		MetricName: metricName,
+		Value:      value,
+		Sent:       true,
+	})
+}
+
+// AddFailure records a metric that could not be collected or sent
+func (r *MetricsSendReport) AddFailure(source, metricName string, err error) {
+	r.Results = append(r.Results, MetricSendResult{
+		Source:     source,
+		MetricName: metricName,
+		Err:        err,
+	})
+}
+
+// Result returns the result for a metric name
+func (r *MetricsSendReport) Result(metricName string) (MetricSendResult, bool) {
+	for _, result := range r.Results {
+		if result.MetricName == metricName {
+			return result, true
+		}
+	}
+	return MetricSendResult{}, false
+}
+
+// HasFailures reports whether any metric could not be collected or sent
+func (r *MetricsSendReport) HasFailures() bool {
+	for _, result := range r.Results {
+		if !result.Sent {
+			return true
+		}
+	}
+	return false
 }
 
 // MetricsServiceError represents an error from metrics service operations
@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"time"
 )
 
@@ -28,6 +29,39 @@ type StatusInfo struct {
 	DaemonStartedAt *time.Time
 }
 
+// Provider check statuses reported by CheckAll
+const (
+	ProviderStatusOK    = "ok"
+	ProviderStatusError = "error"
+)
+
+// ProviderCheck checks that a provider can be reached and its usage read
+type ProviderCheck func(ctx context.Context) error
+
+// ProviderCheckResult is the outcome of checking a single provider
+type ProviderCheckResult struct {
+	// Status is ProviderStatusOK or ProviderStatusError
+	Status string `json:"status"`
+
+	// LatencyMs is how long the check took in milliseconds
+	LatencyMs int64 `json:"latency_ms"`
+
+	// Error describes why the check failed (empty when it succeeded)
+	Error string `json:"error,omitempty"`
+}
+
+// StatusReport is the result of checking every registered provider
+type StatusReport struct {
+	// OK is true when every provider check succeeded
+	OK bool `json:"ok"`
+
+	// CheckedAt is when the checks started
+	CheckedAt time.Time `json:"checked_at"`
+
+	// Providers holds the result of each provider check, keyed by source name (e.g. claude_code)
+	Providers map[string]*ProviderCheckResult `json:"providers"`
+}
+
 // StatusService provides status information about the application
 type StatusService interface {
 	// GetStatus returns the current status information
@@ -53,4 +87,10 @@ type StatusService interface {
 
 	// SetDaemonStopped clears the daemon runtime information
 	SetDaemonStopped() error
+
+	// RegisterProviderCheck sets the check CheckAll runs for provider
+	RegisterProviderCheck(provider string, check ProviderCheck)
+
+	// CheckAll runs every registered provider check concurrently and reports the results
+	CheckAll(ctx context.Context) *StatusReport
 }
@@ -0,0 +1,23 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/ca-srg/tosage/domain/entity"
+)
+
+// SummaryService builds and delivers the daily usage summary
+type SummaryService interface {
+	// Start schedules the summary to be sent daily at the configured time
+	Start() error
+
+	// Stop cancels the schedule
+	Stop() error
+
+	// BuildSummary computes the summary of usage so far on the day of now
+	BuildSummary(now time.Time) (*entity.DailySummary, error)
+
+	// SendSummary builds today's summary and delivers it
+	SendSummary(ctx context.Context) error
+}
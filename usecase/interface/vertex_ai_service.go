@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"time"
 
 	"github.com/ca-srg/tosage/domain/entity"
@@ -19,14 +20,18 @@ type VertexAIService interface {
 	// Uses JST timezone for date boundaries
 	GetDailyUsage(date time.Time) (*entity.VertexAIUsage, error)
 
+	// GetUsageInRange retrieves aggregated usage between start and end,
+	// e.g. a trailing 24-hour window
+	GetUsageInRange(start, end time.Time) (*entity.VertexAIUsage, error)
+
 	// GetCurrentMonthUsage retrieves usage for the current month
 	GetCurrentMonthUsage() (*entity.VertexAIUsage, error)
 
 	// IsEnabled checks if Vertex AI tracking is enabled in configuration
 	IsEnabled() bool
 
-	// CheckConnection verifies Google Cloud credentials and Cloud Monitoring access
-	CheckConnection() error
+	// CheckConnection verifies Google Cloud credentials and Cloud Monitoring access within the context deadline
+	CheckConnection(ctx context.Context) error
 
 	// GetConfiguredProjects returns the list of configured project IDs
 	GetConfiguredProjects() []string